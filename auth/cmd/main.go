@@ -2,17 +2,27 @@ package main
 
 import (
 	authpb "auth/auth/v1"
+	"auth/internal/events"
 	authgrpc "auth/internal/grpc"
+	"auth/internal/mail"
+	"auth/internal/oidc"
+	"auth/internal/passhash"
 	"auth/internal/repository/pg"
 	"auth/internal/service"
+	"auth/internal/throttle"
+	"auth/internal/validation"
 
+	"context"
 	"database/sql"
 	"log"
 	"net"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 )
 
@@ -43,9 +53,74 @@ func main() {
 	}
 
 	repo := pg.New(db)
-	svc := service.New(repo)
+	tokens := pg.NewRefreshTokenRepo(db)
+	signingKeys := pg.NewSigningKeyRepo(db)
+	deletions := pg.NewDeletionRepo(db)
+	emailTokens := pg.NewEmailTokenRepo(db)
+	twoFactor := pg.NewTwoFactorRepo(db)
+	auditLog := pg.NewAuditRepo(db)
+	personalTokens := pg.NewPersonalTokenRepo(db)
+	sessions := pg.NewSessionRepo(db)
+	oidcLogins := pg.NewOIDCRepo(db)
 
-	grpcServer := grpc.NewServer()
+	ctx := context.Background()
+
+	// account deletions are handed to the ledger over Redis; without it
+	// they are recorded and stay pending. Failed sign-ins are counted in
+	// Redis too, so every instance sees them, or else per instance.
+	var bus *events.Bus
+	var publisher service.DeletionPublisher
+	var attempts throttle.Store = throttle.NewMemoryStore()
+	if rdb := connectRedis(ctx); rdb != nil {
+		bus = events.New(rdb)
+		publisher = bus
+		attempts = throttle.NewRedisStore(rdb)
+	}
+
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:8080"
+	}
+
+	hasher := passhash.Default()
+	go serveMetrics(repo, hasher)
+
+	svc := service.New(service.Deps{
+		Users:          repo,
+		Tokens:         tokens,
+		SigningKeys:    signingKeys,
+		Deletions:      deletions,
+		EmailTokens:    emailTokens,
+		TwoFactor:      twoFactor,
+		AuditLog:       auditLog,
+		PersonalTokens: personalTokens,
+		Sessions:       sessions,
+		OIDCLogins:     oidcLogins,
+		Providers:      newOIDCProviders(),
+		Guard:          throttle.NewGuard(attempts),
+		Passwords:      newPasswordPolicy(),
+		Hasher:         hasher,
+		Publisher:      publisher,
+		Mailer:         newMailer(),
+		AppURL:         appURL,
+	})
+
+	if err := svc.RotateKeys(ctx); err != nil {
+		log.Fatalf("load signing keys: %v", err)
+	}
+	go svc.RunKeyRotation(ctx)
+
+	if bus != nil {
+		go svc.RunDeletions(ctx)
+		go func() {
+			host, _ := os.Hostname()
+			if err := bus.ConsumeErasures(ctx, "auth-"+host, svc.CompleteDeletion); err != nil {
+				log.Printf("account erasures: %v", err)
+			}
+		}()
+	}
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(authgrpc.ClientIP, authgrpc.UserAgent))
 
 	authHandler := authgrpc.New(svc)
 	authpb.RegisterAuthServiceServer(grpcServer, authHandler)
@@ -58,3 +133,87 @@ func main() {
 	log.Println("Auth gRPC listening on :50052")
 	log.Fatal(grpcServer.Serve(lis))
 }
+
+// newMailer sends through SMTP_ADDR when it is set. Otherwise emails are
+// written to MAIL_FILE, or to stderr, for local development.
+func newMailer() mail.Mailer {
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		from := os.Getenv("MAIL_FROM")
+		if from == "" {
+			from = "noreply@gofinance.local"
+		}
+		return mail.NewSMTPMailer(addr, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+	}
+
+	if path := os.Getenv("MAIL_FILE"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatalf("open mail file: %v", err)
+		}
+		return mail.NewFileMailer(f)
+	}
+
+	log.Println("SMTP_ADDR not set, emails are written to stderr")
+	return mail.NewFileMailer(os.Stderr)
+}
+
+// newPasswordPolicy takes the minimum length from PASSWORD_MIN_LENGTH and
+// refuses the leaked passwords listed in PASSWORD_BREACHED_FILE.
+func newPasswordPolicy() validation.PasswordPolicy {
+	policy := validation.DefaultPasswordPolicy()
+
+	if v := os.Getenv("PASSWORD_MIN_LENGTH"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Fatalf("invalid PASSWORD_MIN_LENGTH %q", v)
+		}
+		policy.MinLength = n
+	}
+
+	if path := os.Getenv("PASSWORD_BREACHED_FILE"); path != "" {
+		if err := policy.LoadBreached(path); err != nil {
+			log.Fatalf("load breached passwords: %v", err)
+		}
+		log.Printf("loaded %d breached passwords", policy.Breached())
+	}
+
+	return policy
+}
+
+// newOIDCProviders loads the identity providers users can sign in with
+// from the file at OIDC_CONFIG. Without it there are none.
+func newOIDCProviders() oidc.Providers {
+	path := os.Getenv("OIDC_CONFIG")
+	if path == "" {
+		return nil
+	}
+
+	cfg, err := oidc.LoadConfig(path)
+	if err != nil {
+		log.Fatalf("load identity providers: %v", err)
+	}
+	log.Printf("loaded %d identity providers", len(cfg.Providers))
+
+	return oidc.New(cfg)
+}
+
+func connectRedis(ctx context.Context) *redis.Client {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv("REDIS_PASSWORD"),
+	})
+
+	ctxPing, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctxPing).Err(); err != nil {
+		log.Printf("redis disabled, account deletions stay pending and failed sign-ins are counted per instance: %v", err)
+		return nil
+	}
+	return client
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"auth/internal/passhash"
+	"auth/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// serveMetrics serves Prometheus metrics on METRICS_ADDR, :9090 by
+// default.
+func serveMetrics(users repository.UserRepository, hasher passhash.Argon2id) {
+	addr := os.Getenv("METRICS_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	// hashes are upgraded as their users sign in; this shows how many
+	// are left
+	prometheus.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "auth_legacy_password_hashes",
+			Help: "Users whose password hash is bcrypt or Argon2id with weaker parameters.",
+		},
+		func() float64 {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			forms, err := users.CountPasswordHashes(ctx)
+			if err != nil {
+				log.Printf("count legacy password hashes: %v", err)
+				return -1
+			}
+
+			n := 0
+			for form, count := range forms {
+				if hasher.Outdated(form) {
+					n += count
+				}
+			}
+			return float64(n)
+		},
+	))
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	log.Printf("Auth metrics listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("metrics: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package events carries account events between auth and the services
+// that keep user data, over Redis streams.
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Streams shared with the ledger. Auth publishes to DeletionsStream; the
+// ledger answers on ErasuresStream once a user's data is gone.
+const (
+	DeletionsStream = "account:deletions"
+	ErasuresStream  = "account:erasures"
+
+	group     = "auth"
+	maxLength = 10000
+	readBlock = 5 * time.Second
+)
+
+type Bus struct {
+	client *redis.Client
+}
+
+func New(client *redis.Client) *Bus {
+	return &Bus{client: client}
+}
+
+// PublishDeletion asks the other services to erase the user's data.
+func (b *Bus) PublishDeletion(ctx context.Context, deletionID, userID string) error {
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: DeletionsStream,
+		MaxLen: maxLength,
+		Approx: true,
+		Values: map[string]any{
+			"deletion_id": deletionID,
+			"user_id":     userID,
+		},
+	}).Err()
+}
+
+// ConsumeErasures calls fn for every erasure confirmation until ctx is
+// done. A confirmation is acknowledged only when fn succeeds, otherwise it
+// is handed to fn again on the next start.
+func (b *Bus) ConsumeErasures(
+	ctx context.Context,
+	consumer string,
+	fn func(ctx context.Context, deletionID string) error,
+) error {
+	err := b.client.XGroupCreateMkStream(ctx, ErasuresStream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	// messages delivered before a restart but never acknowledged come
+	// first, then new ones
+	start := "0"
+	for ctx.Err() == nil {
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{ErasuresStream, start},
+			Count:    100,
+			Block:    readBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+
+		var msgs []redis.XMessage
+		if len(streams) > 0 {
+			msgs = streams[0].Messages
+		}
+		if start != ">" {
+			if len(msgs) == 0 {
+				start = ">"
+				continue
+			}
+			start = msgs[len(msgs)-1].ID
+		}
+
+		for _, msg := range msgs {
+			id, _ := msg.Values["deletion_id"].(string)
+			if err := fn(ctx, id); err != nil {
+				log.Printf("account erasure %s: %v", id, err)
+				continue
+			}
+			if err := b.client.XAck(ctx, ErasuresStream, group, msg.ID).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
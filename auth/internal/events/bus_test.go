@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newBus(t *testing.T) (*Bus, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return New(client), client
+}
+
+func TestPublishDeletion(t *testing.T) {
+	bus, client := newBus(t)
+	ctx := context.Background()
+
+	require.NoError(t, bus.PublishDeletion(ctx, "del-1", "user-1"))
+
+	msgs, err := client.XRange(ctx, DeletionsStream, "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, map[string]any{"deletion_id": "del-1", "user_id": "user-1"}, msgs[0].Values)
+}
+
+func TestConsumeErasures_AcksHandled(t *testing.T) {
+	bus, client := newBus(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, id := range []string{"del-1", "del-2"} {
+		require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{
+			Stream: ErasuresStream,
+			Values: map[string]any{"deletion_id": id},
+		}).Err())
+	}
+
+	var seen []string
+	err := bus.ConsumeErasures(ctx, "test", func(ctx context.Context, id string) error {
+		seen = append(seen, id)
+		if len(seen) == 2 {
+			cancel()
+			return errors.New("database down")
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []string{"del-1", "del-2"}, seen)
+
+	// the failed confirmation stays pending for the next start
+	pending, err := client.XPending(context.Background(), ErasuresStream, group).Result()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pending.Count)
+}
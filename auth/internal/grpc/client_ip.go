@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"context"
+	"net"
+
+	"auth/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientIPMetadata carries the address of the end user, set by the
+// gateway.
+const ClientIPMetadata = "x-client-ip"
+
+// ClientIP is a unary interceptor putting the client address in the
+// context for the service. Without the metadata the peer address is used.
+func ClientIP(
+	ctx context.Context,
+	req any,
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if ip := clientIP(ctx); ip != "" {
+		ctx = service.WithClientIP(ctx, ip)
+	}
+	return handler(ctx, req)
+}
+
+func clientIP(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(ClientIPMetadata); len(v) > 0 {
+			if ip := net.ParseIP(v[0]); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err == nil {
+			return host
+		}
+	}
+	return ""
+}
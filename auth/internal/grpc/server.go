@@ -2,13 +2,20 @@ package grpc
 
 import (
 	"auth/internal/domain"
+	"auth/internal/repository"
 	"context"
+	"errors"
+	"time"
 
 	authv1 "auth/auth/v1"
 	"auth/internal/service"
+	"auth/internal/throttle"
+	"auth/internal/validation"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type Server struct {
@@ -25,16 +32,13 @@ func New(authService service.Auth) *Server {
 func (s *Server) Register(
 	ctx context.Context,
 	req *authv1.RegisterRequest,
-) (*authv1.AuthResponse, error) {
+) (*authv1.RegisterResponse, error) {
 
-	token, err := s.auth.Register(ctx, req.Email, req.Password)
-	if err != nil {
+	if err := s.auth.Register(ctx, req.Email, req.Password); err != nil {
 		return nil, mapError(err)
 	}
 
-	return &authv1.AuthResponse{
-		AccessToken: token,
-	}, nil
+	return &authv1.RegisterResponse{}, nil
 }
 
 func (s *Server) Login(
@@ -42,22 +46,161 @@ func (s *Server) Login(
 	req *authv1.LoginRequest,
 ) (*authv1.AuthResponse, error) {
 
-	token, err := s.auth.Login(ctx, req.Email, req.Password)
+	tokens, err := s.auth.Login(ctx, req.Email, req.Password)
 	if err != nil {
 		return nil, mapError(err)
 	}
 
-	return &authv1.AuthResponse{
-		AccessToken: token,
+	return toProtoTokens(tokens), nil
+}
+
+func (s *Server) LoginTwoFactor(
+	ctx context.Context,
+	req *authv1.LoginTwoFactorRequest,
+) (*authv1.AuthResponse, error) {
+
+	tokens, err := s.auth.LoginTwoFactor(ctx, req.Challenge, req.Code)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return toProtoTokens(tokens), nil
+}
+
+func (s *Server) Refresh(
+	ctx context.Context,
+	req *authv1.RefreshRequest,
+) (*authv1.AuthResponse, error) {
+
+	tokens, err := s.auth.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return toProtoTokens(tokens), nil
+}
+
+func (s *Server) Logout(
+	ctx context.Context,
+	req *authv1.LogoutRequest,
+) (*authv1.LogoutResponse, error) {
+
+	if err := s.auth.Logout(ctx, req.RefreshToken); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.LogoutResponse{}, nil
+}
+
+func (s *Server) SendVerificationEmail(
+	ctx context.Context,
+	req *authv1.EmailRequest,
+) (*authv1.SendEmailResponse, error) {
+
+	if err := s.auth.SendVerificationEmail(ctx, req.Email); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.SendEmailResponse{}, nil
+}
+
+func (s *Server) VerifyEmail(
+	ctx context.Context,
+	req *authv1.VerifyEmailRequest,
+) (*authv1.VerifyEmailResponse, error) {
+
+	if err := s.auth.VerifyEmail(ctx, req.Token); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.VerifyEmailResponse{}, nil
+}
+
+func (s *Server) RequestPasswordReset(
+	ctx context.Context,
+	req *authv1.EmailRequest,
+) (*authv1.SendEmailResponse, error) {
+
+	if err := s.auth.RequestPasswordReset(ctx, req.Email); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.SendEmailResponse{}, nil
+}
+
+func (s *Server) ResetPassword(
+	ctx context.Context,
+	req *authv1.ResetPasswordRequest,
+) (*authv1.ResetPasswordResponse, error) {
+
+	if err := s.auth.ResetPassword(ctx, req.Token, req.Password); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.ResetPasswordResponse{}, nil
+}
+
+func (s *Server) EnrollTOTP(
+	ctx context.Context,
+	req *authv1.EnrollTOTPRequest,
+) (*authv1.TOTPEnrollment, error) {
+
+	e, err := s.auth.EnrollTOTP(ctx, req.AccessToken)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.TOTPEnrollment{
+		Secret: e.Secret,
+		Uri:    e.URI,
+		QrCode: e.QRCode,
 	}, nil
 }
 
+func (s *Server) ConfirmTOTP(
+	ctx context.Context,
+	req *authv1.TOTPCodeRequest,
+) (*authv1.RecoveryCodes, error) {
+
+	codes, err := s.auth.ConfirmTOTP(ctx, req.AccessToken, req.Code)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.RecoveryCodes{Codes: codes}, nil
+}
+
+func (s *Server) DisableTOTP(
+	ctx context.Context,
+	req *authv1.TOTPCodeRequest,
+) (*authv1.DisableTOTPResponse, error) {
+
+	if err := s.auth.DisableTOTP(ctx, req.AccessToken, req.Code); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.DisableTOTPResponse{}, nil
+}
+
+func (s *Server) RegenerateRecoveryCodes(
+	ctx context.Context,
+	req *authv1.TOTPCodeRequest,
+) (*authv1.RecoveryCodes, error) {
+
+	codes, err := s.auth.RegenerateRecoveryCodes(ctx, req.AccessToken, req.Code)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.RecoveryCodes{Codes: codes}, nil
+}
+
 func (s *Server) Validate(
 	ctx context.Context,
 	req *authv1.ValidateRequest,
 ) (*authv1.ValidateResponse, error) {
 
-	userID, err := s.auth.Validate(ctx, req.Token)
+	user, err := s.auth.Authenticate(ctx, req.Token)
 	if err != nil {
 		return &authv1.ValidateResponse{
 			Valid: false,
@@ -65,20 +208,419 @@ func (s *Server) Validate(
 	}
 
 	return &authv1.ValidateResponse{
+		UserId: user.ID,
+		Valid:  true,
+		Role:   user.Role,
+	}, nil
+}
+
+func (s *Server) GetJWKS(
+	ctx context.Context,
+	req *authv1.JWKSRequest,
+) (*authv1.JWKS, error) {
+
+	keys := s.auth.JWKS()
+
+	res := &authv1.JWKS{Keys: make([]*authv1.JWK, 0, len(keys))}
+	for _, k := range keys {
+		res.Keys = append(res.Keys, &authv1.JWK{
+			Kty: k.Kty,
+			Crv: k.Crv,
+			X:   k.X,
+			Kid: k.Kid,
+			Alg: k.Alg,
+			Use: k.Use,
+		})
+	}
+	return res, nil
+}
+
+func (s *Server) ListRevocations(
+	ctx context.Context,
+	req *authv1.RevocationsRequest,
+) (*authv1.Revocations, error) {
+
+	var since time.Time
+	if req.Since != "" {
+		t, err := time.Parse(time.RFC3339Nano, req.Since)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "since must be an RFC 3339 time")
+		}
+		since = t
+	}
+
+	res, err := s.auth.Revocations(ctx, since)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.Revocations{
+		SessionIds: res.SessionIDs,
+		UserIds:    res.UserIDs,
+		AsOf:       res.AsOf.UTC().Format(time.RFC3339Nano),
+	}, nil
+}
+
+func (s *Server) DeleteAccount(
+	ctx context.Context,
+	req *authv1.DeleteAccountRequest,
+) (*authv1.AccountDeletion, error) {
+
+	d, err := s.auth.DeleteAccount(ctx, req.AccessToken, req.Password)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return toProtoDeletion(d), nil
+}
+
+func (s *Server) GetAccountDeletion(
+	ctx context.Context,
+	req *authv1.AccountDeletionRequest,
+) (*authv1.AccountDeletion, error) {
+
+	d, err := s.auth.GetAccountDeletion(ctx, req.Id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return toProtoDeletion(d), nil
+}
+
+func (s *Server) CreatePersonalToken(
+	ctx context.Context,
+	req *authv1.CreatePersonalTokenRequest,
+) (*authv1.PersonalToken, error) {
+
+	t, token, err := s.auth.CreatePersonalToken(
+		ctx,
+		req.AccessToken,
+		req.Name,
+		req.Scopes,
+		time.Duration(req.ExpiresIn)*time.Second,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	res := toProtoPersonalToken(t)
+	res.Token = token
+	return res, nil
+}
+
+func (s *Server) ListPersonalTokens(
+	ctx context.Context,
+	req *authv1.PersonalTokensRequest,
+) (*authv1.PersonalTokens, error) {
+
+	tokens, err := s.auth.ListPersonalTokens(ctx, req.AccessToken)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	res := &authv1.PersonalTokens{}
+	for i := range tokens {
+		res.Tokens = append(res.Tokens, toProtoPersonalToken(&tokens[i]))
+	}
+	return res, nil
+}
+
+func (s *Server) RevokePersonalToken(
+	ctx context.Context,
+	req *authv1.RevokePersonalTokenRequest,
+) (*authv1.RevokePersonalTokenResponse, error) {
+
+	if err := s.auth.RevokePersonalToken(ctx, req.AccessToken, req.Id); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.RevokePersonalTokenResponse{}, nil
+}
+
+func (s *Server) ValidatePersonalToken(
+	ctx context.Context,
+	req *authv1.ValidateRequest,
+) (*authv1.ValidatePersonalTokenResponse, error) {
+
+	userID, scopes, err := s.auth.ValidatePersonalToken(ctx, req.Token)
+	if err != nil {
+		return &authv1.ValidatePersonalTokenResponse{
+			Valid: false,
+		}, nil
+	}
+
+	return &authv1.ValidatePersonalTokenResponse{
 		UserId: userID,
 		Valid:  true,
+		Scopes: scopes,
 	}, nil
 }
 
+func (s *Server) ListSessions(
+	ctx context.Context,
+	req *authv1.SessionsRequest,
+) (*authv1.Sessions, error) {
+
+	sessions, current, err := s.auth.ListSessions(ctx, req.AccessToken)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	res := &authv1.Sessions{}
+	for i := range sessions {
+		session := toProtoSession(&sessions[i])
+		session.Current = sessions[i].ID == current
+		res.Sessions = append(res.Sessions, session)
+	}
+	return res, nil
+}
+
+func (s *Server) RevokeSession(
+	ctx context.Context,
+	req *authv1.RevokeSessionRequest,
+) (*authv1.RevokeSessionResponse, error) {
+
+	if err := s.auth.RevokeSession(ctx, req.AccessToken, req.Id); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.RevokeSessionResponse{}, nil
+}
+
+func (s *Server) RevokeAllOtherSessions(
+	ctx context.Context,
+	req *authv1.SessionsRequest,
+) (*authv1.RevokeSessionResponse, error) {
+
+	if err := s.auth.RevokeAllOtherSessions(ctx, req.AccessToken); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.RevokeSessionResponse{}, nil
+}
+
+func (s *Server) ListUsers(
+	ctx context.Context,
+	req *authv1.AdminRequest,
+) (*authv1.Users, error) {
+
+	users, err := s.auth.ListUsers(ctx, req.AccessToken)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	res := &authv1.Users{}
+	for i := range users {
+		res.Users = append(res.Users, toProtoUser(&users[i]))
+	}
+	return res, nil
+}
+
+func (s *Server) SetUserRole(
+	ctx context.Context,
+	req *authv1.SetUserRoleRequest,
+) (*authv1.User, error) {
+
+	user, err := s.auth.SetUserRole(ctx, req.AccessToken, req.UserId, req.Role)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return toProtoUser(user), nil
+}
+
+func (s *Server) GetUserStats(
+	ctx context.Context,
+	req *authv1.AdminRequest,
+) (*authv1.UserStats, error) {
+
+	stats, err := s.auth.UserStats(ctx, req.AccessToken)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	res := &authv1.UserStats{
+		Users:          int64(stats.Users),
+		VerifiedUsers:  int64(stats.Verified),
+		TwoFactorUsers: int64(stats.TwoFactor),
+		UsersByRole:    map[string]int64{},
+	}
+	for role, n := range stats.ByRole {
+		res.UsersByRole[role] = int64(n)
+	}
+	return res, nil
+}
+
+func (s *Server) StartOIDCLogin(
+	ctx context.Context,
+	req *authv1.StartOIDCLoginRequest,
+) (*authv1.StartOIDCLoginResponse, error) {
+
+	url, err := s.auth.StartOIDCLogin(ctx, req.Provider)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &authv1.StartOIDCLoginResponse{Url: url}, nil
+}
+
+func (s *Server) FinishOIDCLogin(
+	ctx context.Context,
+	req *authv1.FinishOIDCLoginRequest,
+) (*authv1.AuthResponse, error) {
+
+	tokens, err := s.auth.FinishOIDCLogin(ctx, req.Provider, req.State, req.Code)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return toProtoTokens(tokens), nil
+}
+
+func toProtoTokens(t *service.Tokens) *authv1.AuthResponse {
+	return &authv1.AuthResponse{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		ExpiresIn:    int64(t.ExpiresIn.Seconds()),
+		Challenge:    t.Challenge,
+	}
+}
+
+func toProtoDeletion(d *repository.AccountDeletion) *authv1.AccountDeletion {
+	res := &authv1.AccountDeletion{
+		Id:          d.ID,
+		Status:      d.Status,
+		Attempts:    int32(d.Attempts),
+		RequestedAt: d.RequestedAt.UTC().Format(time.RFC3339),
+	}
+	if d.CompletedAt != nil {
+		res.CompletedAt = d.CompletedAt.UTC().Format(time.RFC3339)
+	}
+	return res
+}
+
+func toProtoPersonalToken(t *repository.PersonalToken) *authv1.PersonalToken {
+	res := &authv1.PersonalToken{
+		Id:        t.ID,
+		Name:      t.Name,
+		Scopes:    t.Scopes,
+		CreatedAt: t.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if t.ExpiresAt != nil {
+		res.ExpiresAt = t.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if t.LastUsedAt != nil {
+		res.LastUsedAt = t.LastUsedAt.UTC().Format(time.RFC3339)
+	}
+	return res
+}
+
+func toProtoSession(s *repository.Session) *authv1.Session {
+	return &authv1.Session{
+		Id:         s.ID,
+		UserAgent:  s.UserAgent,
+		Ip:         s.IP,
+		CreatedAt:  s.CreatedAt.UTC().Format(time.RFC3339),
+		LastUsedAt: s.LastUsedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func toProtoUser(u *repository.User) *authv1.User {
+	return &authv1.User{
+		Id:            u.ID,
+		Email:         u.Email,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerified,
+		CreatedAt:     u.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
 func mapError(err error) error {
+	var locked *throttle.LockedError
+	if errors.As(err, &locked) {
+		return lockedError(locked)
+	}
+
+	var invalid *validation.Error
+	if errors.As(err, &invalid) {
+		return validationError(invalid)
+	}
+
 	switch err {
 	case service.ErrInvalidCredentials:
 		return status.Error(codes.Unauthenticated, "invalid credentials")
-	case service.ErrUserAlreadyExists:
-		return status.Error(codes.AlreadyExists, "user already exists")
 	case domain.ErrInvalidToken:
 		return status.Error(codes.Unauthenticated, "invalid token")
+	case service.ErrTokenReused:
+		return status.Error(codes.Unauthenticated, "refresh token reused, session revoked")
+	case service.ErrInvalidEmailToken:
+		return status.Error(codes.InvalidArgument, "invalid or expired token")
+	case service.ErrReauthenticationRequired:
+		return status.Error(codes.PermissionDenied, "sign in again to confirm")
+	case service.ErrInvalidCode:
+		return status.Error(codes.Unauthenticated, "invalid code")
+	case service.ErrInvalidChallenge:
+		return status.Error(codes.Unauthenticated, "invalid or expired login challenge")
+	case service.ErrTwoFactorEnabled:
+		return status.Error(codes.FailedPrecondition, "two-factor authentication already enabled")
+	case service.ErrTwoFactorNotEnrolled:
+		return status.Error(codes.FailedPrecondition, "two-factor authentication not enrolled")
+	case service.ErrDeletionNotFound:
+		return status.Error(codes.NotFound, "account deletion not found")
+	case service.ErrPersonalTokenNotFound:
+		return status.Error(codes.NotFound, "personal token not found")
+	case service.ErrSessionNotFound:
+		return status.Error(codes.NotFound, "session not found")
+	case service.ErrNotAdmin:
+		return status.Error(codes.PermissionDenied, "admin role required")
+	case service.ErrUserNotFound:
+		return status.Error(codes.NotFound, "user not found")
+	case service.ErrOwnRole:
+		return status.Error(codes.FailedPrecondition, "admins cannot change their own role")
+	case service.ErrUnknownProvider:
+		return status.Error(codes.NotFound, "unknown identity provider")
+	case service.ErrProviderUnavailable:
+		return status.Error(codes.Unavailable, "identity provider unavailable")
+	case service.ErrInvalidOIDCState:
+		return status.Error(codes.InvalidArgument, "invalid or expired sign-in state")
+	case service.ErrOIDCLoginFailed:
+		return status.Error(codes.Unauthenticated, "sign-in with identity provider failed")
+	case service.ErrOIDCEmailNotVerified:
+		return status.Error(codes.PermissionDenied, "identity provider has not verified the email")
+	case service.ErrAccountNotVerified:
+		return status.Error(codes.FailedPrecondition, "verify the email of your account before signing in with an identity provider")
 	default:
 		return status.Error(codes.Internal, "internal error")
 	}
 }
+
+// lockedError tells the client when to retry in a RetryInfo detail.
+func lockedError(err *throttle.LockedError) error {
+	st := status.New(codes.ResourceExhausted, "too many failed attempts")
+	detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(err.RetryAfter),
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// validationError lists the problems with the request in a BadRequest
+// detail.
+func validationError(err *validation.Error) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(err.Fields))
+	for i, f := range err.Fields {
+		violations[i] = &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.Message,
+		}
+	}
+
+	st := status.New(codes.InvalidArgument, err.Error())
+	detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
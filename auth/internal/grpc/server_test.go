@@ -3,36 +3,199 @@ package grpc
 import (
 	"context"
 	"errors"
+	"net"
 	"testing"
+	"time"
 
 	authv1 "auth/auth/v1"
+	"auth/internal/domain"
+	"auth/internal/jwt"
+	"auth/internal/repository"
 	"auth/internal/service"
+	"auth/internal/throttle"
+	"auth/internal/validation"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 type mockAuthService struct {
-	register func(ctx context.Context, email, password string) (string, error)
-	login    func(ctx context.Context, email, password string) (string, error)
-	validate func(ctx context.Context, token string) (string, error)
+	register func(ctx context.Context, email, password string) error
+	login    func(ctx context.Context, email, password string) (*service.Tokens, error)
+	refresh  func(ctx context.Context, refreshToken string) (*service.Tokens, error)
+	logout   func(ctx context.Context, refreshToken string) error
+	validate func(ctx context.Context, token string) (*repository.User, error)
+	jwks     func() []jwt.JWK
+
+	loginTwoFactor          func(ctx context.Context, challenge, code string) (*service.Tokens, error)
+	enrollTOTP              func(ctx context.Context, token string) (*service.TOTPEnrollment, error)
+	confirmTOTP             func(ctx context.Context, token, code string) ([]string, error)
+	disableTOTP             func(ctx context.Context, token, code string) error
+	regenerateRecoveryCodes func(ctx context.Context, token, code string) ([]string, error)
+
+	sendVerification     func(ctx context.Context, email string) error
+	verifyEmail          func(ctx context.Context, token string) error
+	requestPasswordReset func(ctx context.Context, email string) error
+	resetPassword        func(ctx context.Context, token, password string) error
+
+	revocations func(ctx context.Context, since time.Time) (*service.Revocations, error)
+
+	deleteAccount      func(ctx context.Context, token, password string) (*repository.AccountDeletion, error)
+	getAccountDeletion func(ctx context.Context, id string) (*repository.AccountDeletion, error)
+
+	createPersonalToken   func(ctx context.Context, accessToken, name string, scopes []string, expiresIn time.Duration) (*repository.PersonalToken, string, error)
+	listPersonalTokens    func(ctx context.Context, accessToken string) ([]repository.PersonalToken, error)
+	revokePersonalToken   func(ctx context.Context, accessToken, id string) error
+	validatePersonalToken func(ctx context.Context, token string) (string, []string, error)
+
+	listSessions           func(ctx context.Context, accessToken string) ([]repository.Session, string, error)
+	revokeSession          func(ctx context.Context, accessToken, id string) error
+	revokeAllOtherSessions func(ctx context.Context, accessToken string) error
+
+	listUsers   func(ctx context.Context, accessToken string) ([]repository.User, error)
+	setUserRole func(ctx context.Context, accessToken, userID, role string) (*repository.User, error)
+	userStats   func(ctx context.Context, accessToken string) (*repository.UserStats, error)
+
+	startOIDCLogin  func(ctx context.Context, provider string) (string, error)
+	finishOIDCLogin func(ctx context.Context, provider, state, code string) (*service.Tokens, error)
 }
 
-func (m *mockAuthService) Register(ctx context.Context, email, password string) (string, error) {
+func (m *mockAuthService) Register(ctx context.Context, email, password string) error {
 	return m.register(ctx, email, password)
 }
 
-func (m *mockAuthService) Login(ctx context.Context, email, password string) (string, error) {
+func (m *mockAuthService) Login(ctx context.Context, email, password string) (*service.Tokens, error) {
 	return m.login(ctx, email, password)
 }
 
+func (m *mockAuthService) LoginTwoFactor(ctx context.Context, challenge, code string) (*service.Tokens, error) {
+	return m.loginTwoFactor(ctx, challenge, code)
+}
+
+func (m *mockAuthService) EnrollTOTP(ctx context.Context, token string) (*service.TOTPEnrollment, error) {
+	return m.enrollTOTP(ctx, token)
+}
+
+func (m *mockAuthService) ConfirmTOTP(ctx context.Context, token, code string) ([]string, error) {
+	return m.confirmTOTP(ctx, token, code)
+}
+
+func (m *mockAuthService) DisableTOTP(ctx context.Context, token, code string) error {
+	return m.disableTOTP(ctx, token, code)
+}
+
+func (m *mockAuthService) RegenerateRecoveryCodes(ctx context.Context, token, code string) ([]string, error) {
+	return m.regenerateRecoveryCodes(ctx, token, code)
+}
+
+func (m *mockAuthService) Refresh(ctx context.Context, refreshToken string) (*service.Tokens, error) {
+	return m.refresh(ctx, refreshToken)
+}
+
+func (m *mockAuthService) Logout(ctx context.Context, refreshToken string) error {
+	return m.logout(ctx, refreshToken)
+}
+
+func (m *mockAuthService) SendVerificationEmail(ctx context.Context, email string) error {
+	return m.sendVerification(ctx, email)
+}
+
+func (m *mockAuthService) VerifyEmail(ctx context.Context, token string) error {
+	return m.verifyEmail(ctx, token)
+}
+
+func (m *mockAuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	return m.requestPasswordReset(ctx, email)
+}
+
+func (m *mockAuthService) ResetPassword(ctx context.Context, token, password string) error {
+	return m.resetPassword(ctx, token, password)
+}
+
 func (m *mockAuthService) Validate(ctx context.Context, token string) (string, error) {
+	user, err := m.validate(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
+
+func (m *mockAuthService) Authenticate(ctx context.Context, token string) (*repository.User, error) {
 	return m.validate(ctx, token)
 }
 
+func (m *mockAuthService) ListUsers(ctx context.Context, accessToken string) ([]repository.User, error) {
+	return m.listUsers(ctx, accessToken)
+}
+
+func (m *mockAuthService) SetUserRole(ctx context.Context, accessToken, userID, role string) (*repository.User, error) {
+	return m.setUserRole(ctx, accessToken, userID, role)
+}
+
+func (m *mockAuthService) UserStats(ctx context.Context, accessToken string) (*repository.UserStats, error) {
+	return m.userStats(ctx, accessToken)
+}
+
+func (m *mockAuthService) StartOIDCLogin(ctx context.Context, provider string) (string, error) {
+	return m.startOIDCLogin(ctx, provider)
+}
+
+func (m *mockAuthService) FinishOIDCLogin(ctx context.Context, provider, state, code string) (*service.Tokens, error) {
+	return m.finishOIDCLogin(ctx, provider, state, code)
+}
+
+func (m *mockAuthService) JWKS() []jwt.JWK {
+	return m.jwks()
+}
+
+func (m *mockAuthService) Revocations(ctx context.Context, since time.Time) (*service.Revocations, error) {
+	return m.revocations(ctx, since)
+}
+
+func (m *mockAuthService) DeleteAccount(ctx context.Context, token, password string) (*repository.AccountDeletion, error) {
+	return m.deleteAccount(ctx, token, password)
+}
+
+func (m *mockAuthService) GetAccountDeletion(ctx context.Context, id string) (*repository.AccountDeletion, error) {
+	return m.getAccountDeletion(ctx, id)
+}
+
+func (m *mockAuthService) CreatePersonalToken(ctx context.Context, accessToken, name string, scopes []string, expiresIn time.Duration) (*repository.PersonalToken, string, error) {
+	return m.createPersonalToken(ctx, accessToken, name, scopes, expiresIn)
+}
+
+func (m *mockAuthService) ListPersonalTokens(ctx context.Context, accessToken string) ([]repository.PersonalToken, error) {
+	return m.listPersonalTokens(ctx, accessToken)
+}
+
+func (m *mockAuthService) RevokePersonalToken(ctx context.Context, accessToken, id string) error {
+	return m.revokePersonalToken(ctx, accessToken, id)
+}
+
+func (m *mockAuthService) ValidatePersonalToken(ctx context.Context, token string) (string, []string, error) {
+	return m.validatePersonalToken(ctx, token)
+}
+
+func (m *mockAuthService) ListSessions(ctx context.Context, accessToken string) ([]repository.Session, string, error) {
+	return m.listSessions(ctx, accessToken)
+}
+
+func (m *mockAuthService) RevokeSession(ctx context.Context, accessToken, id string) error {
+	return m.revokeSession(ctx, accessToken, id)
+}
+
+func (m *mockAuthService) RevokeAllOtherSessions(ctx context.Context, accessToken string) error {
+	return m.revokeAllOtherSessions(ctx, accessToken)
+}
+
 func TestRegister_Success(t *testing.T) {
 	svc := &mockAuthService{
-		register: func(ctx context.Context, email, password string) (string, error) {
-			return "jwt-token", nil
+		register: func(ctx context.Context, email, password string) error {
+			return nil
 		},
 	}
 
@@ -45,13 +208,13 @@ func TestRegister_Success(t *testing.T) {
 	})
 
 	require.NoError(t, err)
-	require.Equal(t, "jwt-token", resp.AccessToken)
+	require.NotNil(t, resp)
 }
 
-func TestRegister_UserExists(t *testing.T) {
+func TestRegister_Locked(t *testing.T) {
 	svc := &mockAuthService{
-		register: func(ctx context.Context, email, password string) (string, error) {
-			return "", service.ErrUserAlreadyExists
+		register: func(ctx context.Context, email, password string) error {
+			return &throttle.LockedError{RetryAfter: time.Minute}
 		},
 	}
 
@@ -63,13 +226,13 @@ func TestRegister_UserExists(t *testing.T) {
 		Password: "password",
 	})
 
-	require.Error(t, err)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
 }
 
 func TestLogin_Success(t *testing.T) {
 	svc := &mockAuthService{
-		login: func(ctx context.Context, email, password string) (string, error) {
-			return "jwt-token", nil
+		login: func(ctx context.Context, email, password string) (*service.Tokens, error) {
+			return &service.Tokens{AccessToken: "jwt-token", RefreshToken: "refresh-token", ExpiresIn: 15 * time.Minute}, nil
 		},
 	}
 
@@ -83,12 +246,14 @@ func TestLogin_Success(t *testing.T) {
 
 	require.NoError(t, err)
 	require.Equal(t, "jwt-token", resp.AccessToken)
+	require.Equal(t, "refresh-token", resp.RefreshToken)
+	require.Equal(t, int64(900), resp.ExpiresIn)
 }
 
 func TestLogin_InvalidCredentials(t *testing.T) {
 	svc := &mockAuthService{
-		login: func(ctx context.Context, email, password string) (string, error) {
-			return "", service.ErrInvalidCredentials
+		login: func(ctx context.Context, email, password string) (*service.Tokens, error) {
+			return nil, service.ErrInvalidCredentials
 		},
 	}
 
@@ -103,10 +268,225 @@ func TestLogin_InvalidCredentials(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestLogin_Locked(t *testing.T) {
+	svc := &mockAuthService{
+		login: func(ctx context.Context, email, password string) (*service.Tokens, error) {
+			return nil, &throttle.LockedError{RetryAfter: 90 * time.Second}
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.Login(context.Background(), &authv1.LoginRequest{
+		Email:    "test@mail.com",
+		Password: "bad",
+	})
+
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	require.Equal(t, 90*time.Second, st.Details()[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+}
+
+func TestClientIP(t *testing.T) {
+	var got string
+	handler := func(ctx context.Context, req any) (any, error) {
+		got = service.ClientIP(ctx)
+		return nil, nil
+	}
+
+	peerCtx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 4242},
+	})
+
+	_, err := ClientIP(peerCtx, nil, nil, handler)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.2", got)
+
+	ctx := metadata.NewIncomingContext(peerCtx, metadata.Pairs(ClientIPMetadata, "203.0.113.7"))
+	_, err = ClientIP(ctx, nil, nil, handler)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.7", got)
+
+	// garbage falls back to the peer
+	ctx = metadata.NewIncomingContext(peerCtx, metadata.Pairs(ClientIPMetadata, "not an ip"))
+	_, err = ClientIP(ctx, nil, nil, handler)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.2", got)
+}
+
+func TestUserAgent(t *testing.T) {
+	var got string
+	handler := func(ctx context.Context, req any) (any, error) {
+		got = service.UserAgent(ctx)
+		return nil, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(UserAgentMetadata, "Firefox"))
+	_, err := UserAgent(ctx, nil, nil, handler)
+	require.NoError(t, err)
+	require.Equal(t, "Firefox", got)
+
+	_, err = UserAgent(context.Background(), nil, nil, handler)
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestLogin_Challenge(t *testing.T) {
+	svc := &mockAuthService{
+		login: func(ctx context.Context, email, password string) (*service.Tokens, error) {
+			return &service.Tokens{Challenge: "challenge", ExpiresIn: 5 * time.Minute}, nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.Login(context.Background(), &authv1.LoginRequest{Email: "a@mail.com", Password: "password"})
+
+	require.NoError(t, err)
+	require.Empty(t, resp.AccessToken)
+	require.Equal(t, "challenge", resp.Challenge)
+	require.Equal(t, int64(300), resp.ExpiresIn)
+}
+
+func TestLoginTwoFactor_InvalidCode(t *testing.T) {
+	svc := &mockAuthService{
+		loginTwoFactor: func(ctx context.Context, challenge, code string) (*service.Tokens, error) {
+			return nil, service.ErrInvalidCode
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.LoginTwoFactor(context.Background(), &authv1.LoginTwoFactorRequest{Challenge: "challenge", Code: "000000"})
+
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestEnrollTOTP_AlreadyEnabled(t *testing.T) {
+	svc := &mockAuthService{
+		enrollTOTP: func(ctx context.Context, token string) (*service.TOTPEnrollment, error) {
+			return nil, service.ErrTwoFactorEnabled
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.EnrollTOTP(context.Background(), &authv1.EnrollTOTPRequest{AccessToken: "jwt"})
+
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestConfirmTOTP_Success(t *testing.T) {
+	svc := &mockAuthService{
+		confirmTOTP: func(ctx context.Context, token, code string) ([]string, error) {
+			require.Equal(t, "jwt", token)
+			require.Equal(t, "123456", code)
+			return []string{"abcde-fghij"}, nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.ConfirmTOTP(context.Background(), &authv1.TOTPCodeRequest{AccessToken: "jwt", Code: "123456"})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"abcde-fghij"}, resp.Codes)
+}
+
+func TestRefresh_Success(t *testing.T) {
+	svc := &mockAuthService{
+		refresh: func(ctx context.Context, refreshToken string) (*service.Tokens, error) {
+			require.Equal(t, "refresh-token", refreshToken)
+			return &service.Tokens{AccessToken: "jwt-token-2", RefreshToken: "refresh-token-2", ExpiresIn: 15 * time.Minute}, nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.Refresh(context.Background(), &authv1.RefreshRequest{RefreshToken: "refresh-token"})
+
+	require.NoError(t, err)
+	require.Equal(t, "jwt-token-2", resp.AccessToken)
+	require.Equal(t, "refresh-token-2", resp.RefreshToken)
+}
+
+func TestRefresh_Reused(t *testing.T) {
+	svc := &mockAuthService{
+		refresh: func(ctx context.Context, refreshToken string) (*service.Tokens, error) {
+			return nil, service.ErrTokenReused
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.Refresh(context.Background(), &authv1.RefreshRequest{RefreshToken: "refresh-token"})
+
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestLogout_Success(t *testing.T) {
+	var revoked string
+	svc := &mockAuthService{
+		logout: func(ctx context.Context, refreshToken string) error {
+			revoked = refreshToken
+			return nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.Logout(context.Background(), &authv1.LogoutRequest{RefreshToken: "refresh-token"})
+
+	require.NoError(t, err)
+	require.Equal(t, "refresh-token", revoked)
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	svc := &mockAuthService{
+		verifyEmail: func(ctx context.Context, token string) error {
+			return service.ErrInvalidEmailToken
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.VerifyEmail(context.Background(), &authv1.VerifyEmailRequest{Token: "used"})
+
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestResetPassword_Success(t *testing.T) {
+	var got [2]string
+	svc := &mockAuthService{
+		resetPassword: func(ctx context.Context, token, password string) error {
+			got = [2]string{token, password}
+			return nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.ResetPassword(context.Background(), &authv1.ResetPasswordRequest{Token: "reset-token", Password: "new-password"})
+
+	require.NoError(t, err)
+	require.Equal(t, [2]string{"reset-token", "new-password"}, got)
+}
+
 func TestValidate_Success(t *testing.T) {
 	svc := &mockAuthService{
-		validate: func(ctx context.Context, token string) (string, error) {
-			return "user-123", nil
+		validate: func(ctx context.Context, token string) (*repository.User, error) {
+			return &repository.User{ID: "user-123", Role: repository.RoleReadOnly}, nil
 		},
 	}
 
@@ -120,12 +500,13 @@ func TestValidate_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, resp.Valid)
 	require.Equal(t, "user-123", resp.UserId)
+	require.Equal(t, "read-only", resp.Role)
 }
 
 func TestValidate_Invalid(t *testing.T) {
 	svc := &mockAuthService{
-		validate: func(ctx context.Context, token string) (string, error) {
-			return "", errors.New("invalid token")
+		validate: func(ctx context.Context, token string) (*repository.User, error) {
+			return nil, errors.New("invalid token")
 		},
 	}
 
@@ -139,3 +520,340 @@ func TestValidate_Invalid(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, resp.Valid)
 }
+
+func TestGetJWKS(t *testing.T) {
+	svc := &mockAuthService{
+		jwks: func() []jwt.JWK {
+			return []jwt.JWK{{Kty: "OKP", Crv: "Ed25519", X: "pub", Kid: "key-1", Alg: "EdDSA", Use: "sig"}}
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.GetJWKS(context.Background(), &authv1.JWKSRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Keys, 1)
+	require.Equal(t, "key-1", resp.Keys[0].Kid)
+	require.Equal(t, "EdDSA", resp.Keys[0].Alg)
+}
+
+func TestListRevocations(t *testing.T) {
+	asOf := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	svc := &mockAuthService{
+		revocations: func(ctx context.Context, since time.Time) (*service.Revocations, error) {
+			require.Equal(t, asOf.Add(-time.Minute), since)
+			return &service.Revocations{
+				SessionIDs: []string{"session-1"},
+				UserIDs:    []string{"user-1"},
+				AsOf:       asOf,
+			}, nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.ListRevocations(context.Background(), &authv1.RevocationsRequest{
+		Since: "2026-01-02T03:03:05.000000006Z",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"session-1"}, resp.SessionIds)
+	require.Equal(t, []string{"user-1"}, resp.UserIds)
+	require.Equal(t, "2026-01-02T03:04:05.000000006Z", resp.AsOf)
+
+	_, err = server.ListRevocations(context.Background(), &authv1.RevocationsRequest{Since: "yesterday"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDeleteAccount_Success(t *testing.T) {
+	requested := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc := &mockAuthService{
+		deleteAccount: func(ctx context.Context, token, password string) (*repository.AccountDeletion, error) {
+			require.Equal(t, "jwt-token", token)
+			require.Equal(t, "password", password)
+			return &repository.AccountDeletion{
+				ID:          "del-1",
+				UserID:      "user-123",
+				Status:      repository.DeletionPending,
+				Attempts:    1,
+				RequestedAt: requested,
+			}, nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.DeleteAccount(context.Background(), &authv1.DeleteAccountRequest{
+		AccessToken: "jwt-token",
+		Password:    "password",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "del-1", resp.Id)
+	require.Equal(t, "pending", resp.Status)
+	require.Equal(t, "2026-01-02T03:04:05Z", resp.RequestedAt)
+	require.Empty(t, resp.CompletedAt)
+}
+
+func TestDeleteAccount_WrongPassword(t *testing.T) {
+	svc := &mockAuthService{
+		deleteAccount: func(ctx context.Context, token, password string) (*repository.AccountDeletion, error) {
+			return nil, service.ErrInvalidCredentials
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.DeleteAccount(context.Background(), &authv1.DeleteAccountRequest{
+		AccessToken: "jwt-token",
+		Password:    "wrong",
+	})
+
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGetAccountDeletion_NotFound(t *testing.T) {
+	svc := &mockAuthService{
+		getAccountDeletion: func(ctx context.Context, id string) (*repository.AccountDeletion, error) {
+			return nil, service.ErrDeletionNotFound
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.GetAccountDeletion(context.Background(), &authv1.AccountDeletionRequest{Id: "del-1"})
+
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRegister_Invalid(t *testing.T) {
+	svc := &mockAuthService{
+		register: func(ctx context.Context, email, password string) error {
+			return &validation.Error{Fields: []validation.FieldError{
+				{Field: "password", Message: "must be at least 8 characters"},
+			}}
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.Register(context.Background(), &authv1.RegisterRequest{
+		Email:    "test@mail.com",
+		Password: "short",
+	})
+
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	violations := st.Details()[0].(*errdetails.BadRequest).FieldViolations
+	require.Len(t, violations, 1)
+	require.Equal(t, "password", violations[0].Field)
+	require.Equal(t, "must be at least 8 characters", violations[0].Description)
+}
+
+func TestCreatePersonalToken(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	svc := &mockAuthService{
+		createPersonalToken: func(ctx context.Context, accessToken, name string, scopes []string, expiresIn time.Duration) (*repository.PersonalToken, string, error) {
+			require.Equal(t, 30*24*time.Hour, expiresIn)
+			expires := created.Add(expiresIn)
+			return &repository.PersonalToken{
+				ID:        "pt-1",
+				Name:      name,
+				Scopes:    scopes,
+				CreatedAt: created,
+				ExpiresAt: &expires,
+			}, "gft_secret", nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.CreatePersonalToken(context.Background(), &authv1.CreatePersonalTokenRequest{
+		AccessToken: "jwt",
+		Name:        "sheet",
+		Scopes:      []string{"read"},
+		ExpiresIn:   int64(30 * 24 * time.Hour / time.Second),
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "gft_secret", resp.Token)
+	require.Equal(t, "2026-01-02T03:04:05Z", resp.CreatedAt)
+	require.Equal(t, "2026-02-01T03:04:05Z", resp.ExpiresAt)
+	require.Empty(t, resp.LastUsedAt)
+}
+
+func TestRevokePersonalToken_NotFound(t *testing.T) {
+	svc := &mockAuthService{
+		revokePersonalToken: func(ctx context.Context, accessToken, id string) error {
+			return service.ErrPersonalTokenNotFound
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.RevokePersonalToken(context.Background(), &authv1.RevokePersonalTokenRequest{AccessToken: "jwt", Id: "pt-1"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestValidatePersonalToken(t *testing.T) {
+	svc := &mockAuthService{
+		validatePersonalToken: func(ctx context.Context, token string) (string, []string, error) {
+			if token != "gft_secret" {
+				return "", nil, domain.ErrInvalidToken
+			}
+			return "user-123", []string{"read"}, nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.ValidatePersonalToken(context.Background(), &authv1.ValidateRequest{Token: "gft_secret"})
+	require.NoError(t, err)
+	require.True(t, resp.Valid)
+	require.Equal(t, []string{"read"}, resp.Scopes)
+
+	resp, err = server.ValidatePersonalToken(context.Background(), &authv1.ValidateRequest{Token: "gft_other"})
+	require.NoError(t, err)
+	require.False(t, resp.Valid)
+}
+
+func TestSetUserRole(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc := &mockAuthService{
+		setUserRole: func(ctx context.Context, accessToken, userID, role string) (*repository.User, error) {
+			require.Equal(t, "jwt", accessToken)
+			if userID != "user-123" {
+				return nil, service.ErrUserNotFound
+			}
+			return &repository.User{ID: userID, Email: "test@mail.com", Role: role, CreatedAt: created}, nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.SetUserRole(context.Background(), &authv1.SetUserRoleRequest{AccessToken: "jwt", UserId: "user-123", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, "admin", resp.Role)
+	require.Equal(t, "2026-01-02T03:04:05Z", resp.CreatedAt)
+
+	_, err = server.SetUserRole(context.Background(), &authv1.SetUserRoleRequest{AccessToken: "jwt", UserId: "user-456", Role: "admin"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetUserStats_NotAdmin(t *testing.T) {
+	svc := &mockAuthService{
+		userStats: func(ctx context.Context, accessToken string) (*repository.UserStats, error) {
+			return nil, service.ErrNotAdmin
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.GetUserStats(context.Background(), &authv1.AdminRequest{AccessToken: "jwt"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestListSessions(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc := &mockAuthService{
+		listSessions: func(ctx context.Context, accessToken string) ([]repository.Session, string, error) {
+			require.Equal(t, "jwt", accessToken)
+			return []repository.Session{
+				{ID: "s-2", UserAgent: "Firefox", IP: "203.0.113.7", CreatedAt: created, LastUsedAt: created.Add(time.Hour)},
+				{ID: "s-1", CreatedAt: created, LastUsedAt: created},
+			}, "s-1", nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.ListSessions(context.Background(), &authv1.SessionsRequest{AccessToken: "jwt"})
+	require.NoError(t, err)
+	require.Len(t, resp.Sessions, 2)
+	require.Equal(t, "Firefox", resp.Sessions[0].UserAgent)
+	require.Equal(t, "2026-01-02T04:04:05Z", resp.Sessions[0].LastUsedAt)
+	require.False(t, resp.Sessions[0].Current)
+	require.True(t, resp.Sessions[1].Current)
+}
+
+func TestRevokeSession_NotFound(t *testing.T) {
+	svc := &mockAuthService{
+		revokeSession: func(ctx context.Context, accessToken, id string) error {
+			return service.ErrSessionNotFound
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	_, err := server.RevokeSession(context.Background(), &authv1.RevokeSessionRequest{AccessToken: "jwt", Id: "s-1"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestStartOIDCLogin(t *testing.T) {
+	svc := &mockAuthService{
+		startOIDCLogin: func(ctx context.Context, provider string) (string, error) {
+			if provider != "company" {
+				return "", service.ErrUnknownProvider
+			}
+			return "https://sso.example.com/authorize?state=s", nil
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.StartOIDCLogin(context.Background(), &authv1.StartOIDCLoginRequest{Provider: "company"})
+	require.NoError(t, err)
+	require.Equal(t, "https://sso.example.com/authorize?state=s", resp.Url)
+
+	_, err = server.StartOIDCLogin(context.Background(), &authv1.StartOIDCLoginRequest{Provider: "other"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestFinishOIDCLogin(t *testing.T) {
+	svc := &mockAuthService{
+		finishOIDCLogin: func(ctx context.Context, provider, state, code string) (*service.Tokens, error) {
+			require.Equal(t, "company", provider)
+			switch state {
+			case "state":
+				require.Equal(t, "code", code)
+				return &service.Tokens{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 15 * time.Minute}, nil
+			case "unverified":
+				return nil, service.ErrAccountNotVerified
+			default:
+				return nil, service.ErrInvalidOIDCState
+			}
+		},
+	}
+
+	server := New((*service.AuthService)(nil))
+	server.auth = svc
+
+	resp, err := server.FinishOIDCLogin(context.Background(), &authv1.FinishOIDCLoginRequest{Provider: "company", State: "state", Code: "code"})
+	require.NoError(t, err)
+	require.Equal(t, "access", resp.AccessToken)
+	require.Equal(t, "refresh", resp.RefreshToken)
+
+	_, err = server.FinishOIDCLogin(context.Background(), &authv1.FinishOIDCLoginRequest{Provider: "company", State: "unverified"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = server.FinishOIDCLogin(context.Background(), &authv1.FinishOIDCLoginRequest{Provider: "company", State: "forged"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,30 @@
+package grpc
+
+import (
+	"context"
+
+	"auth/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UserAgentMetadata carries the user agent of the end user, set by the
+// gateway.
+const UserAgentMetadata = "x-user-agent"
+
+// UserAgent is a unary interceptor putting the client's user agent in the
+// context for the service, which records it with new sessions.
+func UserAgent(
+	ctx context.Context,
+	req any,
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(UserAgentMetadata); len(v) > 0 && v[0] != "" {
+			ctx = service.WithUserAgent(ctx, v[0])
+		}
+	}
+	return handler(ctx, req)
+}
@@ -1,51 +1,183 @@
 package jwt
 
 import (
-	"os"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var secret = []byte(getEnv("JWT_SECRET", "dev-secret"))
+// AccessTTL is how long an access token is valid. Clients get a new one
+// with their refresh token.
+const AccessTTL = 15 * time.Minute
+
+var ErrNoSigningKey = errors.New("no signing key")
 
 type Claims struct {
 	UserID string `json:"user_id"`
+
+	// SessionID names the refresh token family the token was issued for;
+	// revoking the family revokes the token.
+	SessionID string `json:"sid"`
+
+	// Role is the user's role when the token was issued. It changes with
+	// the next refresh.
+	Role string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func Generate(userID string) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		},
+// Key is an Ed25519 key tokens are signed with. A retired key no longer
+// signs but still verifies the tokens it signed.
+type Key struct {
+	ID         string
+	PrivateKey ed25519.PrivateKey
+	CreatedAt  time.Time
+	RetiredAt  *time.Time
+}
+
+// NewKey generates a key with a random ID.
+func NewKey() (Key, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	return Key{
+		ID:         hex.EncodeToString(id),
+		PrivateKey: priv,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// JWK is the public half of a key as published in a JWK set (RFC 8037).
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// KeySet signs with the newest active key and verifies with any key it
+// holds. It is safe for concurrent use.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []Key
+}
+
+func NewKeySet(keys []Key) *KeySet {
+	s := &KeySet{}
+	s.Replace(keys)
+	return s
+}
+
+// Replace swaps the keys of the set.
+func (s *KeySet) Replace(keys []Key) {
+	sorted := append([]Key(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	s.mu.Lock()
+	s.keys = sorted
+	s.mu.Unlock()
 }
 
-func getEnv(k, d string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
+func (s *KeySet) Generate(userID, sessionID, role string) (string, error) {
+	key, ok := s.signingKey()
+	if !ok {
+		return "", ErrNoSigningKey
 	}
-	return d
+
+	claims := Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTTL)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
 }
 
-func Validate(tokenStr string) (string, bool, error) {
+func (s *KeySet) Validate(tokenStr string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenStr,
 		&Claims{},
 		func(t *jwt.Token) (any, error) {
-			return secret, nil
+			kid, _ := t.Header["kid"].(string)
+			key, ok := s.key(kid)
+			if !ok {
+				return nil, errors.New("unknown signing key")
+			}
+			return key.PrivateKey.Public(), nil
 		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
 	)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return token.Claims.(*Claims), nil
+}
+
+// JWKS returns the public keys of the set, newest first.
+func (s *KeySet) JWKS() []JWK {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if err != nil || !token.Valid {
-		return "", false, err
+	res := make([]JWK, 0, len(s.keys))
+	for _, k := range s.keys {
+		res = append(res, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k.PrivateKey.Public().(ed25519.PublicKey)),
+			Kid: k.ID,
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+			Use: "sig",
+		})
 	}
+	return res
+}
 
-	claims := token.Claims.(*Claims)
-	return claims.UserID, true, nil
+func (s *KeySet) signingKey() (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.RetiredAt == nil {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+func (s *KeySet) key(id string) (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return Key{}, false
 }
@@ -1,24 +1,106 @@
 package jwt
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/require"
 )
 
+func newKeySet(t *testing.T) (*KeySet, Key) {
+	t.Helper()
+
+	key, err := NewKey()
+	require.NoError(t, err)
+	return NewKeySet([]Key{key}), key
+}
+
 func TestGenerateAndValidate(t *testing.T) {
-	token, err := Generate("user-123")
+	keys, key := newKeySet(t)
+
+	token, err := keys.Generate("user-123", "session-1", "user")
 	require.NoError(t, err)
 
-	userID, valid, err := Validate(token)
+	claims, err := keys.Validate(token)
 	require.NoError(t, err)
-	require.True(t, valid)
-	require.Equal(t, "user-123", userID)
+	require.Equal(t, "user-123", claims.UserID)
+	require.Equal(t, "session-1", claims.SessionID)
+	require.Equal(t, "user", claims.Role)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	require.Equal(t, "EdDSA", parsed.Method.Alg())
+	require.Equal(t, key.ID, parsed.Header["kid"])
 }
 
 func TestValidate_InvalidToken(t *testing.T) {
-	_, valid, err := Validate("invalid.token")
+	keys, _ := newKeySet(t)
+
+	claims, err := keys.Validate("invalid.token")
+
+	require.Error(t, err)
+	require.Nil(t, claims)
+}
+
+func TestValidate_RejectsHS256(t *testing.T) {
+	keys, key := newKeySet(t)
+
+	// an HMAC token keyed with the public key must not pass
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: "user-123", SessionID: "s"})
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString([]byte(key.PrivateKey.Public().(ed25519.PublicKey)))
+	require.NoError(t, err)
+
+	_, err = keys.Validate(signed)
+	require.Error(t, err)
+}
 
+func TestRotation_OldKeyStillValidates(t *testing.T) {
+	keys, old := newKeySet(t)
+
+	token, err := keys.Generate("user-123", "session-1", "user")
+	require.NoError(t, err)
+
+	next, err := NewKey()
+	require.NoError(t, err)
+	next.CreatedAt = old.CreatedAt.Add(time.Second)
+	retired := next.CreatedAt
+	old.RetiredAt = &retired
+	keys.Replace([]Key{old, next})
+
+	_, err = keys.Validate(token)
+	require.NoError(t, err)
+
+	fresh, err := keys.Generate("user-123", "session-1", "user")
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(fresh, &Claims{})
+	require.NoError(t, err)
+	require.Equal(t, next.ID, parsed.Header["kid"])
+
+	// once the old key is dropped its tokens stop validating
+	keys.Replace([]Key{next})
+	_, err = keys.Validate(token)
 	require.Error(t, err)
-	require.False(t, valid)
+}
+
+func TestJWKS(t *testing.T) {
+	keys, key := newKeySet(t)
+
+	jwks := keys.JWKS()
+	require.Len(t, jwks, 1)
+	require.Equal(t, key.ID, jwks[0].Kid)
+	require.Equal(t, "OKP", jwks[0].Kty)
+	require.Equal(t, "Ed25519", jwks[0].Crv)
+
+	x, err := base64.RawURLEncoding.DecodeString(jwks[0].X)
+	require.NoError(t, err)
+	require.Equal(t, key.PrivateKey.Public(), ed25519.PublicKey(x))
+}
+
+func TestGenerate_NoKey(t *testing.T) {
+	_, err := NewKeySet(nil).Generate("user-123", "session-1", "user")
+	require.ErrorIs(t, err, ErrNoSigningKey)
 }
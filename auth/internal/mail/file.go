@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// FileMailer writes emails to w instead of sending them, for local
+// development and tests.
+type FileMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewFileMailer(w io.Writer) *FileMailer {
+	return &FileMailer{w: w}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	data, err := render("gofinance@localhost", msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.w.Write(data); err != nil {
+		return err
+	}
+	_, err = io.WriteString(m.w, "\r\n")
+	return err
+}
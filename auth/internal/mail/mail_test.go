@@ -0,0 +1,129 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	data, err := render("from@test", Message{
+		To:      "to@test",
+		Subject: "Hello",
+		Body:    "line 1\nline 2",
+	}, now)
+	require.NoError(t, err)
+
+	require.Equal(t, "From: from@test\r\n"+
+		"To: to@test\r\n"+
+		"Subject: Hello\r\n"+
+		"Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"line 1\r\nline 2\r\n", string(data))
+}
+
+func TestRender_RejectsHeaderInjection(t *testing.T) {
+	_, err := render("from@test", Message{
+		To:      "to@test\r\nBcc: other@test",
+		Subject: "Hello",
+	}, time.Now())
+	require.ErrorIs(t, err, ErrInvalidHeader)
+}
+
+func TestFileMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewFileMailer(&buf)
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "a@test", Subject: "One", Body: "first"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "b@test", Subject: "Two", Body: "second"}))
+
+	out := buf.String()
+	require.Contains(t, out, "To: a@test\r\n")
+	require.Contains(t, out, "To: b@test\r\n")
+	require.Less(t, strings.Index(out, "first"), strings.Index(out, "second"))
+}
+
+// serveSMTP accepts one SMTP session on lis and returns what was sent in
+// it.
+func serveSMTP(lis net.Listener) <-chan string {
+	got := make(chan string, 1)
+
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+		var session strings.Builder
+		reply("220 test")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			session.WriteString(line)
+
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 test")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					session.WriteString(line)
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				got <- session.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return got
+}
+
+func TestSMTPMailer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	got := serveSMTP(lis)
+
+	m := NewSMTPMailer(lis.Addr().String(), "", "", "noreply@test")
+	err = m.Send(context.Background(), Message{
+		To:      "user@test",
+		Subject: "Verify your email",
+		Body:    "token",
+	})
+	require.NoError(t, err)
+
+	session := <-got
+	require.Contains(t, session, "MAIL FROM:<noreply@test>")
+	require.Contains(t, session, "RCPT TO:<user@test>")
+	require.Contains(t, session, "Subject: Verify your email\r\n")
+}
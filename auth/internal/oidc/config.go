@@ -0,0 +1,68 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// ProviderConfig is an identity provider users can sign in with, such as
+// a company's SSO.
+type ProviderConfig struct {
+	// Name identifies the provider in URLs, e.g. "company".
+	Name string `json:"name"`
+
+	// Issuer is where the provider's discovery document is found, under
+	// /.well-known/openid-configuration.
+	Issuer string `json:"issuer"`
+
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+
+	// RedirectURL is the callback registered with the provider, the
+	// gateway's /auth/oidc/{name}/callback.
+	RedirectURL string `json:"redirect_url"`
+
+	// Scopes are asked for besides openid. Without any, email and profile
+	// are.
+	Scopes []string `json:"scopes"`
+}
+
+// Config lists the providers, as in
+//
+//	{"providers": [{"name": "company", "issuer": "https://sso.example.com", ...}]}
+type Config struct {
+	Providers []ProviderConfig `json:"providers"`
+}
+
+var providerName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// LoadConfig reads the providers from a JSON file. The file holds client
+// secrets and should be readable by the auth service only.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for _, p := range cfg.Providers {
+		switch {
+		case !providerName.MatchString(p.Name):
+			return nil, fmt.Errorf("provider name %q must be lowercase letters, digits and dashes", p.Name)
+		case seen[p.Name]:
+			return nil, fmt.Errorf("provider %s is listed twice", p.Name)
+		case p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "":
+			return nil, fmt.Errorf("provider %s needs issuer, client_id and redirect_url", p.Name)
+		}
+		seen[p.Name] = true
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,48 @@
+package oidc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "oidc.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `{"providers": [{
+		"name": "company",
+		"issuer": "https://sso.example.com",
+		"client_id": "gofinance",
+		"client_secret": "secret",
+		"redirect_url": "https://app.example.com/auth/oidc/company/callback"
+	}]}`)
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Providers, 1)
+	require.Equal(t, "https://sso.example.com", cfg.Providers[0].Issuer)
+
+	providers := New(cfg)
+	require.Contains(t, providers, "company")
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"not json":     `providers`,
+		"bad name":     `{"providers": [{"name": "Company SSO", "issuer": "i", "client_id": "c", "redirect_url": "r"}]}`,
+		"no issuer":    `{"providers": [{"name": "company", "client_id": "c", "redirect_url": "r"}]}`,
+		"listed twice": `{"providers": [{"name": "a", "issuer": "i", "client_id": "c", "redirect_url": "r"}, {"name": "a", "issuer": "i", "client_id": "c", "redirect_url": "r"}]}`,
+	} {
+		_, err := LoadConfig(writeConfig(t, content))
+		require.Error(t, err, name)
+	}
+
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"auth/internal/oidc/oidctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:8080/auth/oidc/company/callback"
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Provider) {
+	idp := oidctest.NewProvider(t)
+	return NewProvider(ProviderConfig{
+		Name:         "company",
+		Issuer:       idp.URL,
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  redirectURL,
+	}), idp
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	p, idp := newTestProvider(t)
+	idp.SignIn(oidctest.User{Subject: "sub-1", Email: "ann@example.com", EmailVerified: true})
+
+	verifier := NewCodeVerifier()
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	code, state := idp.Authorize(t, authURL)
+	require.Equal(t, "state-1", state)
+
+	id, err := p.Exchange(context.Background(), code, verifier, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, &Identity{Subject: "sub-1", Email: "ann@example.com", EmailVerified: true}, id)
+
+	// codes work once
+	_, err = p.Exchange(context.Background(), code, verifier, "nonce-1")
+	require.Error(t, err)
+}
+
+func TestProvider_Exchange_WrongVerifier(t *testing.T) {
+	p, idp := newTestProvider(t)
+	idp.SignIn(oidctest.User{Subject: "sub-1"})
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", NewCodeVerifier())
+	require.NoError(t, err)
+	code, _ := idp.Authorize(t, authURL)
+
+	// an intercepted code is useless without the verifier
+	_, err = p.Exchange(context.Background(), code, NewCodeVerifier(), "nonce-1")
+	require.Error(t, err)
+}
+
+func TestProvider_Exchange_WrongNonce(t *testing.T) {
+	p, idp := newTestProvider(t)
+	idp.SignIn(oidctest.User{Subject: "sub-1"})
+	idp.Nonce = "nonce-of-another-sign-in"
+
+	verifier := NewCodeVerifier()
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	code, _ := idp.Authorize(t, authURL)
+
+	_, err = p.Exchange(context.Background(), code, verifier, "nonce-1")
+	require.True(t, errors.Is(err, ErrInvalidIDToken), err)
+}
+
+func TestProvider_DiscoveryRetried(t *testing.T) {
+	p := NewProvider(ProviderConfig{Name: "down", Issuer: "http://127.0.0.1:1", ClientID: "id", RedirectURL: redirectURL})
+
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce", NewCodeVerifier())
+	require.Error(t, err)
+	require.Nil(t, p.oauth)
+}
@@ -0,0 +1,150 @@
+// Package passhash hashes passwords for storage. New hashes use
+// Argon2id; bcrypt hashes from before are still verified, and reported
+// for replacement.
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownFormat = errors.New("unknown password hash format")
+
+// Hasher hashes new passwords and checks them against stored hashes.
+type Hasher interface {
+	Hash(password string) (string, error)
+
+	// Verify reports whether password matches hash, and whether hash
+	// should be replaced by a new Hash of the password because its
+	// algorithm or parameters are outdated.
+	Verify(password, hash string) (ok, rehash bool, err error)
+}
+
+// Argon2id hashes with the given parameters, encoded in the PHC string
+// format: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>.
+type Argon2id struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Default is the second recommended option of RFC 9106, for when 2 GiB
+// per hash is too much.
+func Default() Argon2id {
+	return Argon2id{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 4,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+var b64 = base64.RawStdEncoding
+
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+	return a.Prefix() + b64.EncodeToString(salt) + "$" + b64.EncodeToString(key), nil
+}
+
+// Prefix is the start of every hash with these parameters.
+func (a Argon2id) Prefix() string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$", argon2.Version, a.Memory, a.Iterations, a.Parallelism)
+}
+
+func (a Argon2id) Verify(password, hash string) (bool, bool, error) {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	}
+
+	params, salt, key, err := decode(hash)
+	if err != nil {
+		return false, false, err
+	}
+
+	got := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(got, key) != 1 {
+		return false, false, nil
+	}
+
+	return true, params.weakerThan(a), nil
+}
+
+// Outdated reports whether Verify would ask for hash to be replaced: it
+// is bcrypt, or Argon2id with weaker parameters than a. Only the form of
+// the hash is read, so its salt and key may be masked.
+func (a Argon2id) Outdated(hash string) bool {
+	if isBcrypt(hash) {
+		return true
+	}
+
+	params, _, _, err := decode(hash)
+	return err == nil && params.weakerThan(a)
+}
+
+func (a Argon2id) weakerThan(b Argon2id) bool {
+	return a.Memory < b.Memory ||
+		a.Iterations < b.Iterations ||
+		a.Parallelism < b.Parallelism ||
+		a.SaltLength < b.SaltLength ||
+		a.KeyLength < b.KeyLength
+}
+
+func decode(hash string) (Argon2id, []byte, []byte, error) {
+	var a Argon2id
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return a, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return a, nil, nil, ErrUnknownFormat
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &a.Memory, &a.Iterations, &a.Parallelism); err != nil {
+		return a, nil, nil, ErrUnknownFormat
+	}
+
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return a, nil, nil, ErrUnknownFormat
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return a, nil, nil, ErrUnknownFormat
+	}
+
+	a.SaltLength = uint32(len(salt))
+	a.KeyLength = uint32(len(key))
+	return a, salt, key, nil
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
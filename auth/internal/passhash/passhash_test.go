@@ -0,0 +1,102 @@
+package passhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cheap keeps the tests fast.
+var cheap = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2id(t *testing.T) {
+	hash, err := cheap.Hash("correct horse")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	ok, rehash, err := cheap.Verify("correct horse", hash)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.False(t, rehash)
+
+	ok, _, err = cheap.Verify("wrong horse", hash)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// salted
+	other, err := cheap.Hash("correct horse")
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other)
+}
+
+func TestArgon2id_RehashesWeakerParameters(t *testing.T) {
+	hash, err := cheap.Hash("correct horse")
+	require.NoError(t, err)
+
+	stronger := cheap
+	stronger.Iterations = 2
+
+	// the parameters are read from the hash
+	ok, rehash, err := stronger.Verify("correct horse", hash)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, rehash)
+}
+
+func TestArgon2id_Bcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ok, rehash, err := cheap.Verify("correct horse", string(legacy))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, rehash)
+
+	ok, rehash, err = cheap.Verify("wrong horse", string(legacy))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.False(t, rehash)
+}
+
+func TestArgon2id_UnknownFormat(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+	} {
+		_, _, err := cheap.Verify("password", hash)
+		require.ErrorIs(t, err, ErrUnknownFormat, hash)
+	}
+}
+
+func TestArgon2id_Outdated(t *testing.T) {
+	hash, err := cheap.Hash("correct horse")
+	require.NoError(t, err)
+	require.False(t, cheap.Outdated(hash))
+
+	stronger := cheap
+	stronger.Iterations = 2
+	require.True(t, stronger.Outdated(hash))
+
+	// hashes with stronger parameters are left as they are
+	weaker := cheap
+	weaker.Memory = 32
+	require.False(t, weaker.Outdated(hash))
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.True(t, cheap.Outdated(string(legacy)))
+	require.True(t, cheap.Outdated("$2b$"))
+
+	// masked as in UserRepository.CountPasswordHashes
+	require.False(t, cheap.Outdated("$argon2id$v=19$m=64,t=1,p=1$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"))
+	require.True(t, cheap.Outdated("$argon2id$v=19$m=64,t=1,p=1$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAA"))
+
+	require.False(t, cheap.Outdated(""))
+	require.False(t, cheap.Outdated("plain"))
+}
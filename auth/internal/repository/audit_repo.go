@@ -0,0 +1,35 @@
+package repository
+
+import "context"
+
+// Audit events.
+const (
+	LoginSucceeded  = "login_succeeded"
+	LoginFailed     = "login_failed"
+	TwoFactorFailed = "two_factor_failed"
+
+	// DeletionPasswordFailed is recorded when a wrong password is given
+	// to delete an account.
+	DeletionPasswordFailed = "deletion_password_failed"
+
+	// AccountLocked is recorded when failures lock an account or client
+	// out.
+	AccountLocked = "account_locked"
+
+	// IdentityLinked is recorded when a user first signs in with an
+	// identity provider.
+	IdentityLinked = "identity_linked"
+)
+
+// AuditEntry is a sign-in event. UserID is empty when the email matched
+// no account.
+type AuditEntry struct {
+	UserID string
+	Email  string
+	IP     string
+	Event  string
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, e AuditEntry) error
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Account deletion statuses.
+const (
+	DeletionPending   = "pending"
+	DeletionCompleted = "completed"
+)
+
+// AccountDeletion tracks the erasure of a deleted user's data in the other
+// services.
+type AccountDeletion struct {
+	ID          string
+	UserID      string
+	Status      string
+	Attempts    int
+	RequestedAt time.Time
+	CompletedAt *time.Time
+}
+
+type DeletionRepository interface {
+	// Get returns nil when there is no such deletion.
+	Get(ctx context.Context, id string) (*AccountDeletion, error)
+
+	// Due returns up to limit pending deletions that have not been
+	// published within retryAfter.
+	Due(ctx context.Context, retryAfter time.Duration, limit int) ([]AccountDeletion, error)
+
+	// MarkPublished records a publishing attempt.
+	MarkPublished(ctx context.Context, id string) error
+
+	// Complete returns false when the deletion is unknown.
+	Complete(ctx context.Context, id string) (bool, error)
+
+	// UsersDeletedSince returns the users deleted at or after t.
+	UsersDeletedSince(ctx context.Context, t time.Time) ([]string, error)
+}
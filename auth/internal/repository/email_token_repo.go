@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Email token purposes.
+const (
+	EmailVerification = "verify_email"
+	PasswordReset     = "reset_password"
+)
+
+// EmailToken is a single-use token sent to a user's email address. Only
+// the hash of the token is kept.
+type EmailToken struct {
+	ID        string
+	UserID    string
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type EmailTokenRepository interface {
+	// Create stores the token and voids the user's unused tokens of the
+	// same purpose, so only the latest email works.
+	Create(ctx context.Context, t EmailToken) error
+
+	// Use marks the token used and returns it. It returns nil when there
+	// is no unused, unexpired token with the hash and purpose.
+	Use(ctx context.Context, hash, purpose string) (*EmailToken, error)
+}
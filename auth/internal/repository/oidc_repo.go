@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// OIDCLogin is a sign-in with an identity provider that has not come back
+// yet. Only the hash of its state is kept.
+type OIDCLogin struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// Identity links a user to who they are at an identity provider.
+type Identity struct {
+	Provider string
+	Subject  string
+	UserID   string
+	Email    string
+}
+
+type OIDCRepository interface {
+	CreateLogin(ctx context.Context, l OIDCLogin) error
+
+	// UseLogin deletes the login and returns it. It returns nil when there
+	// is no unexpired login with the hash.
+	UseLogin(ctx context.Context, stateHash string) (*OIDCLogin, error)
+
+	// UserOf returns the user linked to the subject, or "" when none is.
+	UserOf(ctx context.Context, provider, subject string) (string, error)
+
+	Link(ctx context.Context, id Identity) error
+}
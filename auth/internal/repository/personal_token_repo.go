@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// PersonalToken is a long-lived token a user created for scripts. Only
+// the hash of the token is kept.
+type PersonalToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+type PersonalTokenRepository interface {
+	Create(ctx context.Context, t PersonalToken) error
+
+	// List returns the user's tokens that are not revoked, expired ones
+	// included, newest first.
+	List(ctx context.Context, userID string) ([]PersonalToken, error)
+
+	// Revoke reports false when the user has no such unrevoked token.
+	Revoke(ctx context.Context, userID, id string) (bool, error)
+
+	// RevokeUser revokes every token of the user.
+	RevokeUser(ctx context.Context, userID string) error
+
+	// Use records that the token was used and returns it. It returns nil
+	// when there is no unrevoked, unexpired token with the hash.
+	Use(ctx context.Context, hash string) (*PersonalToken, error)
+}
@@ -0,0 +1,34 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+
+	"auth/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type AuditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+func (r *AuditRepo) Record(
+	ctx context.Context,
+	e repository.AuditEntry,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO auth_audit_log (id, user_id, email, ip, event) VALUES ($1,$2,$3,$4,$5)`,
+		uuid.NewString(),
+		sql.NullString{String: e.UserID, Valid: e.UserID != ""},
+		e.Email,
+		e.IP,
+		e.Event,
+	)
+	return err
+}
@@ -0,0 +1,36 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"auth/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRepo_Record(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewAuditRepo(db)
+
+	mock.ExpectExec(`INSERT INTO auth_audit_log`).
+		WithArgs(sqlmock.AnyArg(), "id-123", "test@mail.com", "10.0.0.1", repository.LoginSucceeded).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO auth_audit_log`).
+		WithArgs(sqlmock.AnyArg(), nil, "other@mail.com", "10.0.0.1", repository.LoginFailed).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, repo.Record(context.Background(), repository.AuditEntry{
+		UserID: "id-123",
+		Email:  "test@mail.com",
+		IP:     "10.0.0.1",
+		Event:  repository.LoginSucceeded,
+	}))
+	require.NoError(t, repo.Record(context.Background(), repository.AuditEntry{
+		Email: "other@mail.com",
+		IP:    "10.0.0.1",
+		Event: repository.LoginFailed,
+	}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,151 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth/internal/repository"
+)
+
+type DeletionRepo struct {
+	db *sql.DB
+}
+
+func NewDeletionRepo(db *sql.DB) *DeletionRepo {
+	return &DeletionRepo{db: db}
+}
+
+const deletionColumns = `id, user_id, status, attempts, requested_at, completed_at`
+
+func (r *DeletionRepo) Get(
+	ctx context.Context,
+	id string,
+) (*repository.AccountDeletion, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT `+deletionColumns+` FROM account_deletions WHERE id=$1`,
+		id,
+	)
+
+	d, err := scanDeletion(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *DeletionRepo) Due(
+	ctx context.Context,
+	retryAfter time.Duration,
+	limit int,
+) ([]repository.AccountDeletion, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+deletionColumns+` FROM account_deletions
+		WHERE status = 'pending'
+		  AND (published_at IS NULL OR published_at < now() - make_interval(secs => $1))
+		ORDER BY requested_at
+		LIMIT $2`,
+		retryAfter.Seconds(),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []repository.AccountDeletion
+	for rows.Next() {
+		d, err := scanDeletion(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *d)
+	}
+	return res, rows.Err()
+}
+
+func (r *DeletionRepo) MarkPublished(
+	ctx context.Context,
+	id string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE account_deletions SET published_at = now(), attempts = attempts + 1 WHERE id=$1`,
+		id,
+	)
+	return err
+}
+
+func (r *DeletionRepo) Complete(
+	ctx context.Context,
+	id string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE account_deletions
+		SET status = 'completed', completed_at = COALESCE(completed_at, now())
+		WHERE id=$1`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *DeletionRepo) UsersDeletedSince(
+	ctx context.Context,
+	t time.Time,
+) ([]string, error) {
+	return queryStrings(
+		ctx,
+		r.db,
+		`SELECT user_id FROM account_deletions WHERE requested_at >= $1`,
+		t,
+	)
+}
+
+// queryStrings runs a query that returns a single text column.
+func queryStrings(ctx context.Context, db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDeletion(row scanner) (*repository.AccountDeletion, error) {
+	var (
+		d         repository.AccountDeletion
+		completed sql.NullTime
+	)
+	if err := row.Scan(&d.ID, &d.UserID, &d.Status, &d.Attempts, &d.RequestedAt, &completed); err != nil {
+		return nil, err
+	}
+	if completed.Valid {
+		d.CompletedAt = &completed.Time
+	}
+	return &d, nil
+}
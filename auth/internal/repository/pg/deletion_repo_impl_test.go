@@ -0,0 +1,100 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var deletionRows = []string{"id", "user_id", "status", "attempts", "requested_at", "completed_at"}
+
+func TestDeletionRepo_Get(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewDeletionRepo(db)
+
+	requested := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	completed := requested.Add(time.Second)
+
+	mock.ExpectQuery(`SELECT .* FROM account_deletions WHERE id`).
+		WithArgs("del-1").
+		WillReturnRows(sqlmock.NewRows(deletionRows).
+			AddRow("del-1", "id-123", "completed", 2, requested, completed))
+
+	d, err := repo.Get(context.Background(), "del-1")
+
+	require.NoError(t, err)
+	require.Equal(t, "completed", d.Status)
+	require.Equal(t, 2, d.Attempts)
+	require.Equal(t, completed, *d.CompletedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletionRepo_Get_NotFound(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewDeletionRepo(db)
+
+	mock.ExpectQuery(`SELECT .* FROM account_deletions WHERE id`).
+		WithArgs("del-1").
+		WillReturnRows(sqlmock.NewRows(deletionRows))
+
+	d, err := repo.Get(context.Background(), "del-1")
+
+	require.NoError(t, err)
+	require.Nil(t, d)
+}
+
+func TestDeletionRepo_Due(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewDeletionRepo(db)
+
+	mock.ExpectQuery(`SELECT .* FROM account_deletions\s+WHERE status = 'pending'`).
+		WithArgs(float64(60), 100).
+		WillReturnRows(sqlmock.NewRows(deletionRows).
+			AddRow("del-1", "id-123", "pending", 0, time.Now(), nil))
+
+	due, err := repo.Due(context.Background(), time.Minute, 100)
+
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	require.Nil(t, due[0].CompletedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletionRepo_Complete(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewDeletionRepo(db)
+
+	mock.ExpectExec(`UPDATE account_deletions\s+SET status = 'completed'`).
+		WithArgs("del-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE account_deletions\s+SET status = 'completed'`).
+		WithArgs("del-2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.Complete(context.Background(), "del-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = repo.Complete(context.Background(), "del-2")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestDeletionRepo_UsersDeletedSince(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewDeletionRepo(db)
+
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT user_id FROM account_deletions WHERE requested_at`).
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("id-123"))
+
+	users, err := repo.UsersDeletedSince(context.Background(), since)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"id-123"}, users)
+}
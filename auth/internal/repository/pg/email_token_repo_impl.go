@@ -0,0 +1,81 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"auth/internal/repository"
+)
+
+type EmailTokenRepo struct {
+	db *sql.DB
+}
+
+func NewEmailTokenRepo(db *sql.DB) *EmailTokenRepo {
+	return &EmailTokenRepo{db: db}
+}
+
+func (r *EmailTokenRepo) Create(
+	ctx context.Context,
+	t repository.EmailToken,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE email_tokens SET used_at = now() WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL`,
+		t.UserID,
+		t.Purpose,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO email_tokens (id, user_id, purpose, token_hash, expires_at) VALUES ($1,$2,$3,$4,$5)`,
+		t.ID,
+		t.UserID,
+		t.Purpose,
+		t.TokenHash,
+		t.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *EmailTokenRepo) Use(
+	ctx context.Context,
+	hash string,
+	purpose string,
+) (*repository.EmailToken, error) {
+	t := repository.EmailToken{
+		Purpose:   purpose,
+		TokenHash: hash,
+	}
+
+	err := r.db.QueryRowContext(
+		ctx,
+		`UPDATE email_tokens SET used_at = now()
+		WHERE token_hash=$1 AND purpose=$2 AND used_at IS NULL AND expires_at > now()
+		RETURNING id, user_id, expires_at`,
+		hash,
+		purpose,
+	).Scan(&t.ID, &t.UserID, &t.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
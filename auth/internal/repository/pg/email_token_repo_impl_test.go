@@ -0,0 +1,65 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailTokenRepo_Create_VoidsEarlierTokens(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewEmailTokenRepo(db)
+
+	expires := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE email_tokens SET used_at = now\(\) WHERE user_id`).
+		WithArgs("id-123", repository.PasswordReset).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO email_tokens`).
+		WithArgs("tok-1", "id-123", repository.PasswordReset, "hash", expires).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Create(context.Background(), repository.EmailToken{
+		ID:        "tok-1",
+		UserID:    "id-123",
+		Purpose:   repository.PasswordReset,
+		TokenHash: "hash",
+		ExpiresAt: expires,
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailTokenRepo_Use(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewEmailTokenRepo(db)
+
+	expires := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`UPDATE email_tokens SET used_at = now\(\)\s+WHERE token_hash`).
+		WithArgs("hash", repository.EmailVerification).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "expires_at"}).
+			AddRow("tok-1", "id-123", expires))
+	mock.ExpectQuery(`UPDATE email_tokens`).
+		WithArgs("hash", repository.EmailVerification).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "expires_at"}))
+
+	tok, err := repo.Use(context.Background(), "hash", repository.EmailVerification)
+	require.NoError(t, err)
+	require.Equal(t, "id-123", tok.UserID)
+	require.Equal(t, expires, tok.ExpiresAt)
+
+	// the token works once
+	tok, err = repo.Use(context.Background(), "hash", repository.EmailVerification)
+	require.NoError(t, err)
+	require.Nil(t, tok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
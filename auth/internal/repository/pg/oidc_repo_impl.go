@@ -0,0 +1,104 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"auth/internal/repository"
+)
+
+type OIDCRepo struct {
+	db *sql.DB
+}
+
+func NewOIDCRepo(db *sql.DB) *OIDCRepo {
+	return &OIDCRepo{db: db}
+}
+
+// CreateLogin also drops the logins whose users never came back.
+func (r *OIDCRepo) CreateLogin(
+	ctx context.Context,
+	l repository.OIDCLogin,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM oidc_logins WHERE expires_at <= now()`); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO oidc_logins (state_hash, provider, nonce, code_verifier, expires_at) VALUES ($1,$2,$3,$4,$5)`,
+		l.StateHash,
+		l.Provider,
+		l.Nonce,
+		l.CodeVerifier,
+		l.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *OIDCRepo) UseLogin(
+	ctx context.Context,
+	stateHash string,
+) (*repository.OIDCLogin, error) {
+	l := repository.OIDCLogin{StateHash: stateHash}
+
+	err := r.db.QueryRowContext(
+		ctx,
+		`DELETE FROM oidc_logins WHERE state_hash=$1 AND expires_at > now()
+		RETURNING provider, nonce, code_verifier, expires_at`,
+		stateHash,
+	).Scan(&l.Provider, &l.Nonce, &l.CodeVerifier, &l.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+func (r *OIDCRepo) UserOf(
+	ctx context.Context,
+	provider string,
+	subject string,
+) (string, error) {
+	var userID string
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT user_id FROM user_identities WHERE provider=$1 AND subject=$2`,
+		provider,
+		subject,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return userID, err
+}
+
+func (r *OIDCRepo) Link(
+	ctx context.Context,
+	id repository.Identity,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO user_identities (provider, subject, user_id, email) VALUES ($1,$2,$3,$4)
+		ON CONFLICT (provider, subject) DO NOTHING`,
+		id.Provider,
+		id.Subject,
+		id.UserID,
+		id.Email,
+	)
+	return err
+}
@@ -0,0 +1,100 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCRepo_CreateLogin(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewOIDCRepo(db)
+
+	expires := time.Date(2026, 1, 2, 3, 14, 5, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM oidc_logins WHERE expires_at <= now\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO oidc_logins`).
+		WithArgs("hash", "company", "nonce", "verifier", expires).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.CreateLogin(context.Background(), repository.OIDCLogin{
+		StateHash:    "hash",
+		Provider:     "company",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    expires,
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOIDCRepo_UseLogin(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewOIDCRepo(db)
+
+	expires := time.Date(2026, 1, 2, 3, 14, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`DELETE FROM oidc_logins WHERE state_hash=\$1 AND expires_at > now\(\)`).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"provider", "nonce", "code_verifier", "expires_at"}).
+			AddRow("company", "nonce", "verifier", expires))
+	mock.ExpectQuery(`DELETE FROM oidc_logins WHERE state_hash=\$1`).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"provider"}))
+
+	l, err := repo.UseLogin(context.Background(), "hash")
+	require.NoError(t, err)
+	require.Equal(t, "company", l.Provider)
+	require.Equal(t, "verifier", l.CodeVerifier)
+
+	// used already
+	l, err = repo.UseLogin(context.Background(), "hash")
+	require.NoError(t, err)
+	require.Nil(t, l)
+}
+
+func TestOIDCRepo_UserOf(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewOIDCRepo(db)
+
+	mock.ExpectQuery(`SELECT user_id FROM user_identities`).
+		WithArgs("company", "sub-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("id-123"))
+	mock.ExpectQuery(`SELECT user_id FROM user_identities`).
+		WithArgs("company", "sub-2").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+	userID, err := repo.UserOf(context.Background(), "company", "sub-1")
+	require.NoError(t, err)
+	require.Equal(t, "id-123", userID)
+
+	userID, err = repo.UserOf(context.Background(), "company", "sub-2")
+	require.NoError(t, err)
+	require.Empty(t, userID)
+}
+
+func TestOIDCRepo_Link(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewOIDCRepo(db)
+
+	mock.ExpectExec(`INSERT INTO user_identities .* ON CONFLICT \(provider, subject\) DO NOTHING`).
+		WithArgs("company", "sub-1", "id-123", "ann@example.com").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Link(context.Background(), repository.Identity{
+		Provider: "company",
+		Subject:  "sub-1",
+		UserID:   "id-123",
+		Email:    "ann@example.com",
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,141 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"auth/internal/repository"
+)
+
+type PersonalTokenRepo struct {
+	db *sql.DB
+}
+
+func NewPersonalTokenRepo(db *sql.DB) *PersonalTokenRepo {
+	return &PersonalTokenRepo{db: db}
+}
+
+func (r *PersonalTokenRepo) Create(
+	ctx context.Context,
+	t repository.PersonalToken,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO personal_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+		t.ID,
+		t.UserID,
+		t.Name,
+		t.TokenHash,
+		strings.Join(t.Scopes, " "),
+		t.ExpiresAt,
+		t.CreatedAt,
+	)
+	return err
+}
+
+func (r *PersonalTokenRepo) List(
+	ctx context.Context,
+	userID string,
+) ([]repository.PersonalToken, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, user_id, name, scopes, expires_at, last_used_at, created_at
+		FROM personal_tokens
+		WHERE user_id=$1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []repository.PersonalToken
+	for rows.Next() {
+		t, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *PersonalTokenRepo) Revoke(
+	ctx context.Context,
+	userID string,
+	id string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE personal_tokens SET revoked_at = now() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL`,
+		id,
+		userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *PersonalTokenRepo) RevokeUser(
+	ctx context.Context,
+	userID string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE personal_tokens SET revoked_at = now() WHERE user_id=$1 AND revoked_at IS NULL`,
+		userID,
+	)
+	return err
+}
+
+func (r *PersonalTokenRepo) Use(
+	ctx context.Context,
+	hash string,
+) (*repository.PersonalToken, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`UPDATE personal_tokens SET last_used_at = now()
+		WHERE token_hash=$1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+		RETURNING id, user_id, name, scopes, expires_at, last_used_at, created_at`,
+		hash,
+	)
+
+	t, err := scanPersonalToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.TokenHash = hash
+	return t, nil
+}
+
+func scanPersonalToken(row scanner) (*repository.PersonalToken, error) {
+	var (
+		t                 repository.PersonalToken
+		scopes            string
+		expires, lastUsed sql.NullTime
+	)
+
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &scopes, &expires, &lastUsed, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	t.Scopes = strings.Fields(scopes)
+	if expires.Valid {
+		t.ExpiresAt = &expires.Time
+	}
+	if lastUsed.Valid {
+		t.LastUsedAt = &lastUsed.Time
+	}
+	return &t, nil
+}
@@ -0,0 +1,105 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalTokenRepo_Create(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewPersonalTokenRepo(db)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectExec(`INSERT INTO personal_tokens`).
+		WithArgs("pt-1", "id-123", "sheet", "hash", "read transactions:write", nil, created).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(context.Background(), repository.PersonalToken{
+		ID:        "pt-1",
+		UserID:    "id-123",
+		Name:      "sheet",
+		TokenHash: "hash",
+		Scopes:    []string{"read", "transactions:write"},
+		CreatedAt: created,
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPersonalTokenRepo_List(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewPersonalTokenRepo(db)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	expires := created.Add(24 * time.Hour)
+
+	mock.ExpectQuery(`SELECT id, user_id, name, scopes, expires_at, last_used_at, created_at FROM personal_tokens`).
+		WithArgs("id-123").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "scopes", "expires_at", "last_used_at", "created_at"}).
+			AddRow("pt-2", "id-123", "script", "read", expires, nil, created).
+			AddRow("pt-1", "id-123", "sheet", "read budgets:write", nil, created, created))
+
+	tokens, err := repo.List(context.Background(), "id-123")
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	require.Equal(t, expires, *tokens[0].ExpiresAt)
+	require.Nil(t, tokens[0].LastUsedAt)
+	require.Equal(t, []string{"read", "budgets:write"}, tokens[1].Scopes)
+	require.Nil(t, tokens[1].ExpiresAt)
+}
+
+func TestPersonalTokenRepo_Use(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewPersonalTokenRepo(db)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`UPDATE personal_tokens SET last_used_at = now\(\)`).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "scopes", "expires_at", "last_used_at", "created_at"}).
+			AddRow("pt-1", "id-123", "sheet", "read", nil, created, created))
+	mock.ExpectQuery(`UPDATE personal_tokens SET last_used_at = now\(\)`).
+		WithArgs("other").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	token, err := repo.Use(context.Background(), "hash")
+	require.NoError(t, err)
+	require.Equal(t, "id-123", token.UserID)
+	require.Equal(t, []string{"read"}, token.Scopes)
+
+	token, err = repo.Use(context.Background(), "other")
+	require.NoError(t, err)
+	require.Nil(t, token)
+}
+
+func TestPersonalTokenRepo_Revoke(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewPersonalTokenRepo(db)
+
+	mock.ExpectExec(`UPDATE personal_tokens SET revoked_at = now\(\)`).
+		WithArgs("pt-1", "id-123").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.Revoke(context.Background(), "id-123", "pt-1")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestPersonalTokenRepo_RevokeUser(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewPersonalTokenRepo(db)
+
+	mock.ExpectExec(`UPDATE personal_tokens SET revoked_at = now\(\) WHERE user_id`).
+		WithArgs("id-123").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, repo.RevokeUser(context.Background(), "id-123"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
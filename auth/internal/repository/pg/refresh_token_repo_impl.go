@@ -0,0 +1,136 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth/internal/repository"
+)
+
+type RefreshTokenRepo struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepo(db *sql.DB) *RefreshTokenRepo {
+	return &RefreshTokenRepo{db: db}
+}
+
+func (r *RefreshTokenRepo) Create(
+	ctx context.Context,
+	t repository.RefreshToken,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO refresh_tokens (id, family_id, user_id, token_hash, expires_at) VALUES ($1,$2,$3,$4,$5)`,
+		t.ID,
+		t.FamilyID,
+		t.UserID,
+		t.TokenHash,
+		t.ExpiresAt,
+	)
+	return err
+}
+
+func (r *RefreshTokenRepo) GetByHash(
+	ctx context.Context,
+	hash string,
+) (*repository.RefreshToken, error) {
+	var (
+		t       repository.RefreshToken
+		used    sql.NullTime
+		revoked sql.NullTime
+	)
+
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT id, family_id, user_id, token_hash, expires_at, used_at, revoked_at
+		FROM refresh_tokens WHERE token_hash=$1`,
+		hash,
+	).Scan(&t.ID, &t.FamilyID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &used, &revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if used.Valid {
+		t.UsedAt = &used.Time
+	}
+	if revoked.Valid {
+		t.RevokedAt = &revoked.Time
+	}
+	return &t, nil
+}
+
+func (r *RefreshTokenRepo) Use(
+	ctx context.Context,
+	id string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET used_at = now()
+		WHERE id=$1 AND used_at IS NULL AND revoked_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *RefreshTokenRepo) RevokeFamily(
+	ctx context.Context,
+	familyID string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET revoked_at = now() WHERE family_id=$1 AND revoked_at IS NULL`,
+		familyID,
+	)
+	return err
+}
+
+func (r *RefreshTokenRepo) RevokeUser(
+	ctx context.Context,
+	userID string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET revoked_at = now() WHERE user_id=$1 AND revoked_at IS NULL`,
+		userID,
+	)
+	return err
+}
+
+func (r *RefreshTokenRepo) RevokedSince(
+	ctx context.Context,
+	t time.Time,
+) ([]string, error) {
+	return queryStrings(
+		ctx,
+		r.db,
+		`SELECT DISTINCT family_id FROM refresh_tokens WHERE revoked_at >= $1`,
+		t,
+	)
+}
+
+func (r *RefreshTokenRepo) FamilyActive(
+	ctx context.Context,
+	familyID string,
+) (bool, error) {
+	var active bool
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens
+			WHERE family_id=$1 AND revoked_at IS NULL AND expires_at > now()
+		)`,
+		familyID,
+	).Scan(&active)
+	return active, err
+}
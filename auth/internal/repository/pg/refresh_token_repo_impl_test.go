@@ -0,0 +1,127 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenRepo_Create(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewRefreshTokenRepo(db)
+
+	expires := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`INSERT INTO refresh_tokens`).
+		WithArgs("tok-1", "fam-1", "id-123", "hash", expires).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(context.Background(), repository.RefreshToken{
+		ID:        "tok-1",
+		FamilyID:  "fam-1",
+		UserID:    "id-123",
+		TokenHash: "hash",
+		ExpiresAt: expires,
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepo_GetByHash(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewRefreshTokenRepo(db)
+
+	expires := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	used := expires.Add(-time.Hour)
+
+	mock.ExpectQuery(`SELECT .* FROM refresh_tokens WHERE token_hash`).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "family_id", "user_id", "token_hash", "expires_at", "used_at", "revoked_at"}).
+			AddRow("tok-1", "fam-1", "id-123", "hash", expires, used, nil))
+	mock.ExpectQuery(`SELECT .* FROM refresh_tokens WHERE token_hash`).
+		WithArgs("other").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	tok, err := repo.GetByHash(context.Background(), "hash")
+	require.NoError(t, err)
+	require.Equal(t, "fam-1", tok.FamilyID)
+	require.Equal(t, used, *tok.UsedAt)
+	require.Nil(t, tok.RevokedAt)
+
+	tok, err = repo.GetByHash(context.Background(), "other")
+	require.NoError(t, err)
+	require.Nil(t, tok)
+}
+
+func TestRefreshTokenRepo_Use(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewRefreshTokenRepo(db)
+
+	mock.ExpectExec(`UPDATE refresh_tokens SET used_at`).
+		WithArgs("tok-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE refresh_tokens SET used_at`).
+		WithArgs("tok-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.Use(context.Background(), "tok-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = repo.Use(context.Background(), "tok-1")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestRefreshTokenRepo_FamilyActive(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewRefreshTokenRepo(db)
+
+	mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at`).
+		WithArgs("fam-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("fam-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	require.NoError(t, repo.RevokeFamily(context.Background(), "fam-1"))
+
+	active, err := repo.FamilyActive(context.Background(), "fam-1")
+	require.NoError(t, err)
+	require.False(t, active)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepo_RevokedSince(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewRefreshTokenRepo(db)
+
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT DISTINCT family_id FROM refresh_tokens WHERE revoked_at`).
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"family_id"}).AddRow("fam-1").AddRow("fam-2"))
+
+	families, err := repo.RevokedSince(context.Background(), since)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"fam-1", "fam-2"}, families)
+}
+
+func TestRefreshTokenRepo_RevokeUser(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewRefreshTokenRepo(db)
+
+	mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\) WHERE user_id`).
+		WithArgs("id-123").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	require.NoError(t, repo.RevokeUser(context.Background(), "id-123"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,118 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+
+	"auth/internal/repository"
+)
+
+// SessionRepo keeps the device details of sessions. Whether a session is
+// active is up to its refresh tokens, so revoking one revokes them.
+type SessionRepo struct {
+	db *sql.DB
+}
+
+func NewSessionRepo(db *sql.DB) *SessionRepo {
+	return &SessionRepo{db: db}
+}
+
+func (r *SessionRepo) Create(
+	ctx context.Context,
+	s repository.Session,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_used_at)
+		VALUES ($1,$2,$3,$4,$5,$6)`,
+		s.ID,
+		s.UserID,
+		s.UserAgent,
+		s.IP,
+		s.CreatedAt,
+		s.LastUsedAt,
+	)
+	return err
+}
+
+func (r *SessionRepo) Touch(
+	ctx context.Context,
+	id string,
+	ip string,
+	userAgent string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE sessions SET last_used_at = now(), ip = $2, user_agent = $3 WHERE id=$1`,
+		id,
+		ip,
+		userAgent,
+	)
+	return err
+}
+
+func (r *SessionRepo) List(
+	ctx context.Context,
+	userID string,
+) ([]repository.Session, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT s.id, s.user_id, s.user_agent, s.ip, s.created_at, s.last_used_at
+		FROM sessions s
+		WHERE s.user_id=$1 AND EXISTS (
+			SELECT 1 FROM refresh_tokens t
+			WHERE t.family_id = s.id AND t.revoked_at IS NULL AND t.expires_at > now()
+		)
+		ORDER BY s.last_used_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []repository.Session
+	for rows.Next() {
+		var s repository.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastUsedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *SessionRepo) Revoke(
+	ctx context.Context,
+	userID string,
+	id string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET revoked_at = now()
+		WHERE family_id=$1 AND user_id=$2 AND revoked_at IS NULL`,
+		id,
+		userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *SessionRepo) RevokeOthers(
+	ctx context.Context,
+	userID string,
+	id string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET revoked_at = now()
+		WHERE user_id=$1 AND family_id<>$2 AND revoked_at IS NULL`,
+		userID,
+		id,
+	)
+	return err
+}
@@ -0,0 +1,87 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRepo_Create(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewSessionRepo(db)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectExec(`INSERT INTO sessions`).
+		WithArgs("fam-1", "id-123", "curl/8.5", "203.0.113.7", created, created).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(context.Background(), repository.Session{
+		ID:         "fam-1",
+		UserID:     "id-123",
+		UserAgent:  "curl/8.5",
+		IP:         "203.0.113.7",
+		CreatedAt:  created,
+		LastUsedAt: created,
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepo_List(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewSessionRepo(db)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	used := created.Add(time.Hour)
+
+	mock.ExpectQuery(`SELECT s.id, s.user_id, s.user_agent, s.ip, s.created_at, s.last_used_at\s+FROM sessions s`).
+		WithArgs("id-123").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "user_agent", "ip", "created_at", "last_used_at"}).
+			AddRow("fam-2", "id-123", "Firefox", "198.51.100.1", created, used).
+			AddRow("fam-1", "id-123", "", "", created, created))
+
+	sessions, err := repo.List(context.Background(), "id-123")
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, "Firefox", sessions[0].UserAgent)
+	require.Equal(t, used, sessions[0].LastUsedAt)
+	require.Equal(t, "fam-1", sessions[1].ID)
+}
+
+func TestSessionRepo_Revoke(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewSessionRepo(db)
+
+	mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\)\s+WHERE family_id=\$1 AND user_id=\$2`).
+		WithArgs("fam-1", "id-123").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\)\s+WHERE family_id=\$1 AND user_id=\$2`).
+		WithArgs("fam-1", "id-456").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.Revoke(context.Background(), "id-123", "fam-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = repo.Revoke(context.Background(), "id-456", "fam-1")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSessionRepo_RevokeOthers(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewSessionRepo(db)
+
+	mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\)\s+WHERE user_id=\$1 AND family_id<>\$2`).
+		WithArgs("id-123", "fam-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	require.NoError(t, repo.RevokeOthers(context.Background(), "id-123", "fam-1"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,89 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"auth/internal/repository"
+)
+
+type SigningKeyRepo struct {
+	db *sql.DB
+}
+
+func NewSigningKeyRepo(db *sql.DB) *SigningKeyRepo {
+	return &SigningKeyRepo{db: db}
+}
+
+func (r *SigningKeyRepo) List(ctx context.Context) ([]repository.SigningKey, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, private_key, created_at, retired_at FROM signing_keys ORDER BY created_at DESC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []repository.SigningKey
+	for rows.Next() {
+		var (
+			k       repository.SigningKey
+			retired sql.NullTime
+		)
+		if err := rows.Scan(&k.ID, &k.PrivateKey, &k.CreatedAt, &retired); err != nil {
+			return nil, err
+		}
+		if retired.Valid {
+			k.RetiredAt = &retired.Time
+		}
+		res = append(res, k)
+	}
+	return res, rows.Err()
+}
+
+func (r *SigningKeyRepo) Rotate(
+	ctx context.Context,
+	key repository.SigningKey,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE signing_keys SET retired_at = $1 WHERE retired_at IS NULL`,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO signing_keys (id, private_key, created_at) VALUES ($1,$2,$3)`,
+		key.ID,
+		key.PrivateKey,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SigningKeyRepo) DeleteRetiredBefore(
+	ctx context.Context,
+	t time.Time,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM signing_keys WHERE retired_at < $1`,
+		t,
+	)
+	return err
+}
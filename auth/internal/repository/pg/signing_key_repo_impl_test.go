@@ -0,0 +1,56 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeyRepo_List(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewSigningKeyRepo(db)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT id, private_key, created_at, retired_at FROM signing_keys`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "private_key", "created_at", "retired_at"}).
+			AddRow("key-2", []byte("seed-2"), created.Add(time.Hour), nil).
+			AddRow("key-1", []byte("seed-1"), created, created.Add(time.Hour)))
+
+	keys, err := repo.List(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Nil(t, keys[0].RetiredAt)
+	require.Equal(t, created.Add(time.Hour), *keys[1].RetiredAt)
+}
+
+func TestSigningKeyRepo_Rotate(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewSigningKeyRepo(db)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE signing_keys SET retired_at`).
+		WithArgs(created).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO signing_keys`).
+		WithArgs("key-2", []byte("seed-2"), created).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Rotate(context.Background(), repository.SigningKey{
+		ID:         "key-2",
+		PrivateKey: []byte("seed-2"),
+		CreatedAt:  created,
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,256 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"auth/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type TwoFactorRepo struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepo(db *sql.DB) *TwoFactorRepo {
+	return &TwoFactorRepo{db: db}
+}
+
+func (r *TwoFactorRepo) GetTOTP(
+	ctx context.Context,
+	userID string,
+) (*repository.TOTP, error) {
+	var (
+		t         repository.TOTP
+		confirmed sql.NullTime
+	)
+
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT user_id, secret, confirmed_at FROM user_totp WHERE user_id=$1`,
+		userID,
+	).Scan(&t.UserID, &t.Secret, &confirmed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if confirmed.Valid {
+		t.ConfirmedAt = &confirmed.Time
+	}
+	return &t, nil
+}
+
+func (r *TwoFactorRepo) SetPendingTOTP(
+	ctx context.Context,
+	userID string,
+	secret string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO user_totp (user_id, secret) VALUES ($1,$2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = now()
+		WHERE user_totp.confirmed_at IS NULL`,
+		userID,
+		secret,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *TwoFactorRepo) ConfirmTOTP(
+	ctx context.Context,
+	userID string,
+	step int64,
+	recoveryCodeHashes []string,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE user_totp SET confirmed_at = now(), last_step = $2 WHERE user_id=$1`,
+		userID,
+		step,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TwoFactorRepo) UseStep(
+	ctx context.Context,
+	userID string,
+	step int64,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE user_totp SET last_step = $2
+		WHERE user_id=$1 AND confirmed_at IS NOT NULL AND (last_step IS NULL OR last_step < $2)`,
+		userID,
+		step,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *TwoFactorRepo) DeleteTOTP(
+	ctx context.Context,
+	userID string,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TwoFactorRepo) ReplaceRecoveryCodes(
+	ctx context.Context,
+	userID string,
+	hashes []string,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, hashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TwoFactorRepo) UseRecoveryCode(
+	ctx context.Context,
+	userID string,
+	hash string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE recovery_codes SET used_at = now()
+		WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`,
+		userID,
+		hash,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *TwoFactorRepo) CreateChallenge(
+	ctx context.Context,
+	c repository.LoginChallenge,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO login_challenges (id, user_id, token_hash, expires_at) VALUES ($1,$2,$3,$4)`,
+		c.ID,
+		c.UserID,
+		c.TokenHash,
+		c.ExpiresAt,
+	)
+	return err
+}
+
+func (r *TwoFactorRepo) AttemptChallenge(
+	ctx context.Context,
+	hash string,
+	maxAttempts int,
+) (*repository.LoginChallenge, error) {
+	c := repository.LoginChallenge{TokenHash: hash}
+
+	err := r.db.QueryRowContext(
+		ctx,
+		`UPDATE login_challenges SET attempts = attempts + 1
+		WHERE token_hash=$1 AND used_at IS NULL AND expires_at > now() AND attempts < $2
+		RETURNING id, user_id, expires_at`,
+		hash,
+		maxAttempts,
+	).Scan(&c.ID, &c.UserID, &c.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *TwoFactorRepo) CompleteChallenge(
+	ctx context.Context,
+	id string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE login_challenges SET used_at = now() WHERE id=$1 AND used_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func replaceRecoveryCodes(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID string,
+	hashes []string,
+) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+
+	for _, h := range hashes {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO recovery_codes (id, user_id, code_hash) VALUES ($1,$2,$3)`,
+			uuid.NewString(),
+			userID,
+			h,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
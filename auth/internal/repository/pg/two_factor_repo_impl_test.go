@@ -0,0 +1,133 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorRepo_GetTOTP(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewTwoFactorRepo(db)
+
+	confirmed := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT user_id, secret, confirmed_at FROM user_totp`).
+		WithArgs("id-123").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "secret", "confirmed_at"}).
+			AddRow("id-123", "SECRET", confirmed))
+	mock.ExpectQuery(`SELECT user_id, secret, confirmed_at FROM user_totp`).
+		WithArgs("id-456").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+	totp, err := repo.GetTOTP(context.Background(), "id-123")
+	require.NoError(t, err)
+	require.Equal(t, "SECRET", totp.Secret)
+	require.Equal(t, confirmed, *totp.ConfirmedAt)
+
+	totp, err = repo.GetTOTP(context.Background(), "id-456")
+	require.NoError(t, err)
+	require.Nil(t, totp)
+}
+
+func TestTwoFactorRepo_SetPendingTOTP_KeepsConfirmed(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewTwoFactorRepo(db)
+
+	mock.ExpectExec(`INSERT INTO user_totp .* ON CONFLICT \(user_id\) DO UPDATE .* WHERE user_totp.confirmed_at IS NULL`).
+		WithArgs("id-123", "SECRET").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.SetPendingTOTP(context.Background(), "id-123", "SECRET")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestTwoFactorRepo_ConfirmTOTP(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewTwoFactorRepo(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE user_totp SET confirmed_at = now\(\), last_step`).
+		WithArgs("id-123", int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM recovery_codes`).
+		WithArgs("id-123").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO recovery_codes`).
+		WithArgs(sqlmock.AnyArg(), "id-123", "hash-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO recovery_codes`).
+		WithArgs(sqlmock.AnyArg(), "id-123", "hash-2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.ConfirmTOTP(context.Background(), "id-123", 42, []string{"hash-1", "hash-2"})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepo_UseStep(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewTwoFactorRepo(db)
+
+	mock.ExpectExec(`UPDATE user_totp SET last_step`).
+		WithArgs("id-123", int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE user_totp SET last_step`).
+		WithArgs("id-123", int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.UseStep(context.Background(), "id-123", 42)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = repo.UseStep(context.Background(), "id-123", 42)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestTwoFactorRepo_AttemptChallenge(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewTwoFactorRepo(db)
+
+	expires := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`UPDATE login_challenges SET attempts = attempts \+ 1`).
+		WithArgs("hash", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "expires_at"}).
+			AddRow("ch-1", "id-123", expires))
+	mock.ExpectQuery(`UPDATE login_challenges SET attempts = attempts \+ 1`).
+		WithArgs("hash", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	c, err := repo.AttemptChallenge(context.Background(), "hash", 5)
+	require.NoError(t, err)
+	require.Equal(t, repository.LoginChallenge{ID: "ch-1", UserID: "id-123", TokenHash: "hash", ExpiresAt: expires}, *c)
+
+	c, err = repo.AttemptChallenge(context.Background(), "hash", 5)
+	require.NoError(t, err)
+	require.Nil(t, c)
+}
+
+func TestTwoFactorRepo_DeleteTOTP(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := NewTwoFactorRepo(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM recovery_codes`).
+		WithArgs("id-123").
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectExec(`DELETE FROM user_totp`).
+		WithArgs("id-123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.DeleteTOTP(context.Background(), "id-123"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/google/uuid"
 )
 
+const userColumns = `id, email, password_hash, role, created_at, email_verified_at IS NOT NULL`
+
 type UserRepo struct {
 	db *sql.DB
 }
@@ -43,16 +45,11 @@ func (r *UserRepo) GetByEmail(
 
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, email, password_hash FROM users WHERE email=$1`,
+		`SELECT `+userColumns+` FROM users WHERE lower(email)=lower($1)`,
 		email,
 	)
 
-	var u repository.User
-	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash); err != nil {
-		return nil, err
-	}
-
-	return &u, nil
+	return scanUser(row)
 }
 
 func (r *UserRepo) GetByID(
@@ -62,14 +59,189 @@ func (r *UserRepo) GetByID(
 
 	row := r.db.QueryRowContext(
 		ctx,
-		`SELECT id, email, password_hash FROM users WHERE id=$1`,
+		`SELECT `+userColumns+` FROM users WHERE id=$1`,
 		id,
 	)
 
-	var u repository.User
-	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash); err != nil {
+	return scanUser(row)
+}
+
+func (r *UserRepo) SetEmailVerified(
+	ctx context.Context,
+	id string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users SET email_verified_at = now() WHERE id=$1 AND email_verified_at IS NULL`,
+		id,
+	)
+	return err
+}
+
+func (r *UserRepo) SetPassword(
+	ctx context.Context,
+	id string,
+	passwordHash string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users SET password_hash=$2 WHERE id=$1`,
+		id,
+		passwordHash,
+	)
+	return err
+}
+
+func (r *UserRepo) List(ctx context.Context) ([]repository.User, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+userColumns+` FROM users ORDER BY created_at, id`,
+	)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var users []repository.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
 
+func (r *UserRepo) SetRole(
+	ctx context.Context,
+	id string,
+	role string,
+) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users SET role=$2 WHERE id=$1`,
+		id,
+		role,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *UserRepo) Stats(ctx context.Context) (*repository.UserStats, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT u.role, count(*), count(u.email_verified_at), count(t.confirmed_at)
+		FROM users u
+		LEFT JOIN user_totp t ON t.user_id = u.id
+		GROUP BY u.role`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := repository.UserStats{ByRole: map[string]int{}}
+	for rows.Next() {
+		var (
+			role                       string
+			users, verified, twoFactor int
+		)
+		if err := rows.Scan(&role, &users, &verified, &twoFactor); err != nil {
+			return nil, err
+		}
+		stats.ByRole[role] = users
+		stats.Users += users
+		stats.Verified += verified
+		stats.TwoFactor += twoFactor
+	}
+	return &stats, rows.Err()
+}
+
+func (r *UserRepo) CountPasswordHashes(ctx context.Context) (map[string]int, error) {
+	// Argon2id salts and keys are masked with "A", which keeps their
+	// length; bcrypt hashes are told apart by their version only
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT CASE
+		           WHEN password_hash LIKE '$argon2id$%'
+		               THEN substring(password_hash FROM '^(?:\$[^$]*){3}\$')
+		                   || translate(
+		                          substring(password_hash FROM '^(?:\$[^$]*){3}\$(.*)$'),
+		                          'ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/',
+		                          repeat('A', 64))
+		           ELSE left(password_hash, 4)
+		       END AS form,
+		       count(*)
+		FROM users
+		WHERE password_hash <> ''
+		GROUP BY form`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	forms := make(map[string]int)
+	for rows.Next() {
+		var form string
+		var n int
+		if err := rows.Scan(&form, &n); err != nil {
+			return nil, err
+		}
+		forms[form] = n
+	}
+	return forms, rows.Err()
+}
+
+func (r *UserRepo) Delete(
+	ctx context.Context,
+	id string,
+) (*repository.AccountDeletion, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	d := repository.AccountDeletion{
+		ID:     uuid.NewString(),
+		UserID: id,
+		Status: repository.DeletionPending,
+	}
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO account_deletions (id, user_id) VALUES ($1,$2) RETURNING requested_at`,
+		d.ID,
+		d.UserID,
+	).Scan(&d.RequestedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, tx.Commit()
+}
+
+func scanUser(row scanner) (*repository.User, error) {
+	var u repository.User
+	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.EmailVerified); err != nil {
+		return nil, err
+	}
 	return &u, nil
 }
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
+
+	"auth/internal/repository"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
@@ -35,10 +38,10 @@ func TestGetByEmail_Success(t *testing.T) {
 	db, mock := setupDB(t)
 	repo := New(db)
 
-	rows := sqlmock.NewRows([]string{"id", "email", "password_hash"}).
-		AddRow("id-123", "test@mail.com", "hash")
+	rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "role", "created_at", "email_verified"}).
+		AddRow("id-123", "test@mail.com", "hash", "user", time.Now(), true)
 
-	mock.ExpectQuery(`SELECT id, email, password_hash FROM users`).
+	mock.ExpectQuery(`SELECT id, email, password_hash, .* FROM users`).
 		WithArgs("test@mail.com").
 		WillReturnRows(rows)
 
@@ -46,16 +49,17 @@ func TestGetByEmail_Success(t *testing.T) {
 
 	require.NoError(t, err)
 	require.Equal(t, "id-123", u.ID)
+	require.True(t, u.EmailVerified)
 }
 
 func TestGetByID_Success(t *testing.T) {
 	db, mock := setupDB(t)
 	repo := New(db)
 
-	rows := sqlmock.NewRows([]string{"id", "email", "password_hash"}).
-		AddRow("id-123", "test@mail.com", "hash")
+	rows := sqlmock.NewRows([]string{"id", "email", "password_hash", "role", "created_at", "email_verified"}).
+		AddRow("id-123", "test@mail.com", "hash", "user", time.Now(), true)
 
-	mock.ExpectQuery(`SELECT id, email, password_hash FROM users`).
+	mock.ExpectQuery(`SELECT id, email, password_hash, .* FROM users`).
 		WithArgs("id-123").
 		WillReturnRows(rows)
 
@@ -64,3 +68,137 @@ func TestGetByID_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "test@mail.com", u.Email)
 }
+
+func TestListUsers(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := New(db)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT id, email, .* FROM users ORDER BY created_at`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "password_hash", "role", "created_at", "email_verified"}).
+			AddRow("id-1", "admin@mail.com", "hash", "admin", created, true).
+			AddRow("id-2", "user@mail.com", "hash", "user", created, false))
+
+	users, err := repo.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	require.Equal(t, "admin", users[0].Role)
+	require.Equal(t, created, users[1].CreatedAt)
+	require.False(t, users[1].EmailVerified)
+}
+
+func TestSetRole(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := New(db)
+
+	mock.ExpectExec(`UPDATE users SET role`).
+		WithArgs("id-123", "admin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET role`).
+		WithArgs("id-456", "admin").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.SetRole(context.Background(), "id-123", "admin"))
+	require.ErrorIs(t, repo.SetRole(context.Background(), "id-456", "admin"), sql.ErrNoRows)
+}
+
+func TestUserStats(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := New(db)
+
+	mock.ExpectQuery(`SELECT u.role, count\(\*\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "count", "verified", "two_factor"}).
+			AddRow("user", 5, 3, 1).
+			AddRow("admin", 1, 1, 1))
+
+	stats, err := repo.Stats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, repository.UserStats{
+		Users:     6,
+		Verified:  4,
+		TwoFactor: 2,
+		ByRole:    map[string]int{"user": 5, "admin": 1},
+	}, *stats)
+}
+
+func TestSetEmailVerified(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := New(db)
+
+	mock.ExpectExec(`UPDATE users SET email_verified_at = now\(\) WHERE id`).
+		WithArgs("id-123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.SetEmailVerified(context.Background(), "id-123"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetPassword(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := New(db)
+
+	mock.ExpectExec(`UPDATE users SET password_hash`).
+		WithArgs("id-123", "new-hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.SetPassword(context.Background(), "id-123", "new-hash"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountPasswordHashes(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := New(db)
+
+	mock.ExpectQuery(`SELECT CASE .* FROM users WHERE password_hash <> '' GROUP BY form`).
+		WillReturnRows(sqlmock.NewRows([]string{"form", "count"}).
+			AddRow("$argon2id$v=19$m=65536,t=3,p=4$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", 7).
+			AddRow("$2a$", 2))
+
+	forms, err := repo.CountPasswordHashes(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		"$argon2id$v=19$m=65536,t=3,p=4$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA": 7,
+		"$2a$": 2,
+	}, forms)
+}
+
+func TestDeleteUser_RecordsDeletion(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := New(db)
+
+	requested := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM users`).
+		WithArgs("id-123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO account_deletions`).
+		WithArgs(sqlmock.AnyArg(), "id-123").
+		WillReturnRows(sqlmock.NewRows([]string{"requested_at"}).AddRow(requested))
+	mock.ExpectCommit()
+
+	d, err := repo.Delete(context.Background(), "id-123")
+
+	require.NoError(t, err)
+	require.NotEmpty(t, d.ID)
+	require.Equal(t, "pending", d.Status)
+	require.Equal(t, requested, d.RequestedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteUser_NotFound(t *testing.T) {
+	db, mock := setupDB(t)
+	repo := New(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM users`).
+		WithArgs("id-123").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err := repo.Delete(context.Background(), "id-123")
+
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshToken is a stored refresh token. Only the hash of the token is
+// kept.
+type RefreshToken struct {
+	ID        string
+	FamilyID  string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, t RefreshToken) error
+
+	// GetByHash returns nil when there is no such token.
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+
+	// Use marks the token used. It reports false when the token was
+	// already used or revoked.
+	Use(ctx context.Context, id string) (bool, error)
+
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// RevokeUser revokes every family of the user.
+	RevokeUser(ctx context.Context, userID string) error
+
+	// RevokedSince returns the families with a token revoked at or after t.
+	RevokedSince(ctx context.Context, t time.Time) ([]string, error)
+
+	// FamilyActive reports whether the family holds a token that is
+	// neither revoked nor expired.
+	FamilyActive(ctx context.Context, familyID string) (bool, error)
+}
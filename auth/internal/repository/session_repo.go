@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Session is a sign-in on a device. Its ID is the family of its refresh
+// tokens and the session ID of its access tokens. IP and UserAgent are
+// those it was last used from.
+type Session struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, s Session) error
+
+	// Touch records that the session was used from ip with userAgent.
+	Touch(ctx context.Context, id, ip, userAgent string) error
+
+	// List returns the user's active sessions, those with a refresh token
+	// that is neither revoked nor expired, most recently used first.
+	List(ctx context.Context, userID string) ([]Session, error)
+
+	// Revoke revokes the refresh tokens of one of the user's sessions. It
+	// reports false when the user has no such unrevoked session.
+	Revoke(ctx context.Context, userID, id string) (bool, error)
+
+	// RevokeOthers revokes every session of the user but the one with id.
+	RevokeOthers(ctx context.Context, userID, id string) error
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// SigningKey is an Ed25519 token signing key. PrivateKey holds the seed.
+type SigningKey struct {
+	ID         string
+	PrivateKey []byte
+	CreatedAt  time.Time
+	RetiredAt  *time.Time
+}
+
+type SigningKeyRepository interface {
+	List(ctx context.Context) ([]SigningKey, error)
+
+	// Rotate stores key and retires every other active key in the same
+	// transaction.
+	Rotate(ctx context.Context, key SigningKey) error
+
+	DeleteRetiredBefore(ctx context.Context, t time.Time) error
+}
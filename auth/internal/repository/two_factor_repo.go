@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// TOTP is a user's authenticator secret. It is not used for sign-in until
+// confirmed.
+type TOTP struct {
+	UserID      string
+	Secret      string
+	ConfirmedAt *time.Time
+}
+
+// LoginChallenge is a password login waiting for a second factor. Only
+// the hash of its token is kept.
+type LoginChallenge struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type TwoFactorRepository interface {
+	// GetTOTP returns nil when the user has no secret.
+	GetTOTP(ctx context.Context, userID string) (*TOTP, error)
+
+	// SetPendingTOTP stores a secret to be confirmed, replacing an
+	// unconfirmed one. It reports false when the user's secret is already
+	// confirmed.
+	SetPendingTOTP(ctx context.Context, userID, secret string) (bool, error)
+
+	// ConfirmTOTP turns the pending secret on, records step as used and
+	// replaces the user's recovery codes.
+	ConfirmTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error
+
+	// UseStep records that a code of the time step was accepted. It
+	// reports false when a code of the step or a later one already was.
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+
+	// DeleteTOTP removes the secret and the recovery codes.
+	DeleteTOTP(ctx context.Context, userID string) error
+
+	ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error
+
+	// UseRecoveryCode marks the code used. It reports false when the user
+	// has no unused code with the hash.
+	UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error)
+
+	CreateChallenge(ctx context.Context, c LoginChallenge) error
+
+	// AttemptChallenge counts an attempt at the challenge and returns it.
+	// It returns nil when the challenge is unknown, completed, expired or
+	// out of attempts.
+	AttemptChallenge(ctx context.Context, hash string, maxAttempts int) (*LoginChallenge, error)
+
+	// CompleteChallenge marks the challenge used. It reports false when it
+	// already was.
+	CompleteChallenge(ctx context.Context, id string) (bool, error)
+}
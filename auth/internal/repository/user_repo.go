@@ -1,15 +1,60 @@
 package repository
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// Roles of users.
+const (
+	RoleUser     = "user"
+	RoleReadOnly = "read-only"
+	RoleAdmin    = "admin"
+)
 
 type User struct {
 	ID           string
 	Email        string
 	PasswordHash string
+	Role         string
+	CreatedAt    time.Time
+
+	EmailVerified bool
+}
+
+// UserStats counts users for admins.
+type UserStats struct {
+	Users     int
+	Verified  int
+	TwoFactor int
+
+	// ByRole counts the users of each role that has any.
+	ByRole map[string]int
 }
 
 type UserRepository interface {
 	Create(ctx context.Context, email, passwordHash string) (string, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByID(ctx context.Context, id string) (*User, error)
+
+	SetEmailVerified(ctx context.Context, id string) error
+	SetPassword(ctx context.Context, id, passwordHash string) error
+
+	// List returns every user, oldest first.
+	List(ctx context.Context) ([]User, error)
+
+	// SetRole returns sql.ErrNoRows when there is no such user.
+	SetRole(ctx context.Context, id, role string) error
+
+	Stats(ctx context.Context) (*UserStats, error)
+
+	// CountPasswordHashes counts the users by the form of their password
+	// hash: the hash with its salt and key masked, so that hashes made
+	// with the same algorithm and parameters count together. Users
+	// without a password are not counted.
+	CountPasswordHashes(ctx context.Context) (map[string]int, error)
+
+	// Delete removes the user and records a pending account deletion in
+	// the same transaction. It returns the deletion.
+	Delete(ctx context.Context, id string) (*AccountDeletion, error)
 }
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"auth/internal/repository"
+)
+
+type clientIPKey struct{}
+
+// WithClientIP returns a context carrying the address of the client the
+// request came from, for throttling and auditing sign-ins.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the address set by WithClientIP, or "".
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// failAttempt counts a failed sign-in against the account and the client
+// and audits it.
+func (s *AuthService) failAttempt(ctx context.Context, userID, email, event string) {
+	ip := ClientIP(ctx)
+	s.audit(ctx, userID, email, event)
+
+	lockedOut, err := s.guard.Fail(ctx, email, ip)
+	if err != nil {
+		log.Printf("count failed sign-in: %v", err)
+		return
+	}
+	if lockedOut {
+		s.audit(ctx, userID, email, repository.AccountLocked)
+	}
+}
+
+// audit records an event. A failure to record does not fail the sign-in.
+func (s *AuthService) audit(ctx context.Context, userID, email, event string) {
+	err := s.auditLog.Record(ctx, repository.AuditEntry{
+		UserID: userID,
+		Email:  email,
+		IP:     ClientIP(ctx),
+		Event:  event,
+	})
+	if err != nil {
+		log.Printf("record %s: %v", event, err)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"auth/internal/repository"
+	"auth/internal/throttle"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockAuditRepo struct {
+	entries []repository.AuditEntry
+}
+
+func (m *mockAuditRepo) Record(ctx context.Context, e repository.AuditEntry) error {
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *mockAuditRepo) events() []string {
+	var events []string
+	for _, e := range m.entries {
+		events = append(events, e.Event)
+	}
+	return events
+}
+
+// passwordService returns a service whose user signs in with "password".
+func passwordService(t *testing.T) (*AuthService, *mockAuditRepo) {
+	t.Helper()
+
+	users, user := emailUsers("test@mail.com", true)
+	hash, err := testHasher.Hash("password")
+	require.NoError(t, err)
+	user.PasswordHash = hash
+
+	svc, _ := newEmailService(t, users)
+	return svc, svc.auditLog.(*mockAuditRepo)
+}
+
+func TestLogin_ThrottlesFailures(t *testing.T) {
+	svc, audit := passwordService(t)
+	ctx := WithClientIP(context.Background(), "10.0.0.1")
+
+	for range throttle.AccountPolicy.Free + 1 {
+		_, err := svc.Login(ctx, "test@mail.com", "wrong")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// even the right password waits
+	_, err := svc.Login(ctx, "test@mail.com", "password")
+	var locked *throttle.LockedError
+	require.ErrorAs(t, err, &locked)
+	require.Positive(t, locked.RetryAfter)
+
+	require.Len(t, audit.entries, throttle.AccountPolicy.Free+1)
+	require.Equal(t, repository.AuditEntry{
+		UserID: "user-123",
+		Email:  "test@mail.com",
+		IP:     "10.0.0.1",
+		Event:  repository.LoginFailed,
+	}, audit.entries[0])
+}
+
+func TestLogin_ThrottlesUnknownEmails(t *testing.T) {
+	svc, audit := passwordService(t)
+
+	for range throttle.AccountPolicy.Free + 1 {
+		_, err := svc.Login(context.Background(), "other@mail.com", "wrong")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, err := svc.Login(context.Background(), "other@mail.com", "wrong")
+	var locked *throttle.LockedError
+	require.ErrorAs(t, err, &locked)
+	require.Empty(t, audit.entries[0].UserID)
+}
+
+func TestLogin_SuccessResetsFailures(t *testing.T) {
+	svc, audit := passwordService(t)
+
+	for range throttle.AccountPolicy.Free {
+		_, err := svc.Login(context.Background(), "test@mail.com", "wrong")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, err := svc.Login(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+	require.Equal(t, repository.LoginSucceeded, audit.entries[len(audit.entries)-1].Event)
+
+	for range throttle.AccountPolicy.Free {
+		_, err := svc.Login(context.Background(), "test@mail.com", "wrong")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = svc.Login(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+}
+
+func TestLoginTwoFactor_ThrottlesWrongCodes(t *testing.T) {
+	svc, _, codes := enrolled(t)
+	challenge := login(t, svc)
+
+	for range throttle.AccountPolicy.Free + 1 {
+		_, err := svc.LoginTwoFactor(context.Background(), challenge, "000000")
+		require.ErrorIs(t, err, ErrInvalidCode)
+	}
+
+	_, err := svc.LoginTwoFactor(context.Background(), challenge, codes[0])
+	var locked *throttle.LockedError
+	require.ErrorAs(t, err, &locked)
+
+	audit := svc.auditLog.(*mockAuditRepo)
+	require.Contains(t, audit.events(), repository.TwoFactorFailed)
+}
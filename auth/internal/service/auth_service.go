@@ -2,87 +2,354 @@ package service
 
 import (
 	"auth/internal/domain"
+	"auth/internal/mail"
 	"context"
 	"errors"
+	"log"
+	"strings"
+	"sync"
 
 	"auth/internal/jwt"
+	"auth/internal/oidc"
+	"auth/internal/passhash"
 	"auth/internal/repository"
-
-	"golang.org/x/crypto/bcrypt"
+	"auth/internal/throttle"
+	"auth/internal/validation"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserAlreadyExists  = errors.New("user already exists")
+	ErrDeletionNotFound   = errors.New("account deletion not found")
+	ErrTokenReused        = errors.New("refresh token reused")
+	ErrInvalidEmailToken  = errors.New("invalid or expired email token")
+
+	ErrReauthenticationRequired = errors.New("sign in again to confirm")
+
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication not enrolled")
+	ErrInvalidCode          = errors.New("invalid code")
+	ErrInvalidChallenge     = errors.New("invalid or expired login challenge")
+
+	ErrPersonalTokenNotFound = errors.New("personal token not found")
+	ErrSessionNotFound       = errors.New("session not found")
+
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrProviderUnavailable  = errors.New("identity provider unavailable")
+	ErrInvalidOIDCState     = errors.New("invalid or expired sign-in state")
+	ErrOIDCLoginFailed      = errors.New("sign-in with identity provider failed")
+	ErrOIDCEmailNotVerified = errors.New("identity provider has not verified the email")
+	ErrAccountNotVerified   = errors.New("account email not verified")
+
+	ErrNotAdmin     = errors.New("admin role required")
+	ErrUserNotFound = errors.New("user not found")
+	ErrOwnRole      = errors.New("admins cannot change their own role")
 )
 
+// DeletionPublisher tells the services that keep user data to erase it.
+type DeletionPublisher interface {
+	PublishDeletion(ctx context.Context, deletionID, userID string) error
+}
+
 type AuthService struct {
-	users repository.UserRepository
+	users       repository.UserRepository
+	tokens      repository.RefreshTokenRepository
+	signingKeys repository.SigningKeyRepository
+	deletions   repository.DeletionRepository
+	emailTokens repository.EmailTokenRepository
+	twoFactor   repository.TwoFactorRepository
+	auditLog    repository.AuditRepository
+
+	personalTokens repository.PersonalTokenRepository
+	sessions       repository.SessionRepository
+
+	oidcLogins repository.OIDCRepository
+
+	// providers are the identity providers users can sign in with
+	providers oidc.Providers
+
+	// guard throttles failed sign-ins and registrations
+	guard *throttle.Guard
+
+	// passwords is what new passwords must meet
+	passwords validation.PasswordPolicy
+	hasher    passhash.Hasher
+
+	// dummyHash is what verifyDummy checks against, hashed on first use
+	dummyHash string
+	dummyOnce sync.Once
+
+	// keys is loaded by RotateKeys
+	keys *jwt.KeySet
+
+	// publisher is nil when no event bus is configured; deletions then
+	// stay pending until one is
+	publisher DeletionPublisher
+
+	mailer mail.Mailer
+
+	// appURL is where the links in emails point, e.g.
+	// https://app.example.com
+	appURL string
 }
 
-func New(users repository.UserRepository) *AuthService {
+// Deps is what an AuthService is built from.
+type Deps struct {
+	Users          repository.UserRepository
+	Tokens         repository.RefreshTokenRepository
+	SigningKeys    repository.SigningKeyRepository
+	Deletions      repository.DeletionRepository
+	EmailTokens    repository.EmailTokenRepository
+	TwoFactor      repository.TwoFactorRepository
+	AuditLog       repository.AuditRepository
+	PersonalTokens repository.PersonalTokenRepository
+	Sessions       repository.SessionRepository
+	OIDCLogins     repository.OIDCRepository
+
+	// Providers are the identity providers users can sign in with
+	Providers oidc.Providers
+
+	// Guard throttles failed sign-ins and registrations
+	Guard *throttle.Guard
+
+	// Passwords is what new passwords must meet
+	Passwords validation.PasswordPolicy
+	Hasher    passhash.Hasher
+
+	// Publisher may be nil when no event bus is configured
+	Publisher DeletionPublisher
+
+	Mailer mail.Mailer
+
+	// AppURL is where the links in emails point, e.g.
+	// https://app.example.com
+	AppURL string
+}
+
+func New(d Deps) *AuthService {
 	return &AuthService{
-		users: users,
+		users:       d.Users,
+		tokens:      d.Tokens,
+		signingKeys: d.SigningKeys,
+		deletions:   d.Deletions,
+		keys:        jwt.NewKeySet(nil),
+		publisher:   d.Publisher,
+		emailTokens: d.EmailTokens,
+		mailer:      d.Mailer,
+		appURL:      strings.TrimSuffix(d.AppURL, "/"),
+		twoFactor:   d.TwoFactor,
+		guard:       d.Guard,
+		auditLog:    d.AuditLog,
+		passwords:   d.Passwords,
+		hasher:      d.Hasher,
+
+		personalTokens: d.PersonalTokens,
+		sessions:       d.Sessions,
+
+		oidcLogins: d.OIDCLogins,
+		providers:  d.Providers,
 	}
 }
 
+// Register creates a user and sends them a verification email. An email
+// that already has an account is sent a notice instead, and the answer is
+// the same, so it does not tell which addresses have accounts. A
+// malformed email or a password against the policy returns a
+// *validation.Error listing the problems. Registrations are limited per
+// client IP on a budget of their own, apart from failed sign-ins.
 func (s *AuthService) Register(
 	ctx context.Context,
 	email string,
 	password string,
-) (string, error) {
+) error {
 
-	_, err := s.users.GetByEmail(ctx, email)
-	if err == nil {
-		return "", ErrUserAlreadyExists
+	ip := ClientIP(ctx)
+	if err := s.guard.AllowRegister(ctx, ip); err != nil {
+		return err
 	}
 
-	hash, err := bcrypt.GenerateFromPassword(
-		[]byte(password),
-		bcrypt.DefaultCost,
-	)
+	email, err := s.validateCredentials(email, password)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	userID, err := s.users.Create(ctx, email, string(hash))
+	if err := s.guard.Register(ctx, ip); err != nil {
+		log.Printf("count registration: %v", err)
+	}
+
+	// hashed either way, so both answers take as long
+	hash, err := s.hasher.Hash(password)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return jwt.Generate(userID)
+	user, err := s.users.GetByEmail(ctx, email)
+	if err == nil {
+		if err := s.sendAccountExists(ctx, user); err != nil {
+			log.Printf("send account notice to user %s: %v", user.ID, err)
+		}
+		return nil
+	}
+
+	userID, err := s.users.Create(ctx, email, hash)
+	if err != nil {
+		return err
+	}
+
+	// the user can ask for the email again
+	if err := s.sendVerification(ctx, userID, email); err != nil {
+		log.Printf("send verification email to user %s: %v", userID, err)
+	}
+
+	return nil
 }
 
+// Login checks the password. For a user with two-factor authentication it
+// returns a challenge instead of tokens; see LoginTwoFactor. Repeated
+// failures for the email or from the client IP return a
+// *throttle.LockedError for a while.
 func (s *AuthService) Login(
 	ctx context.Context,
 	email string,
 	password string,
-) (string, error) {
+) (*Tokens, error) {
+
+	email = validation.CanonicalEmail(email)
+	if err := s.guard.Allow(ctx, email, ClientIP(ctx)); err != nil {
+		return nil, err
+	}
 
 	user, err := s.users.GetByEmail(ctx, email)
 	if err != nil {
-		return "", ErrInvalidCredentials
+		s.verifyDummy(password)
+		s.failAttempt(ctx, "", email, repository.LoginFailed)
+		return nil, ErrInvalidCredentials
+	}
+
+	ok, rehash := s.checkPassword(user, password)
+	if !ok {
+		s.failAttempt(ctx, user.ID, email, repository.LoginFailed)
+		return nil, ErrInvalidCredentials
+	}
+	if rehash {
+		s.rehash(ctx, user.ID, password)
+	}
+
+	challenge, err := s.challenge(ctx, user.ID)
+	if err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	return s.loggedIn(ctx, user)
+}
+
+// checkPassword reports whether password is the user's, and whether its
+// hash should be upgraded. Users who signed up with an identity provider
+// have no password until they reset it.
+func (s *AuthService) checkPassword(user *repository.User, password string) (ok, rehash bool) {
+	if user.PasswordHash == "" {
+		s.verifyDummy(password)
+		return false, false
+	}
+
+	ok, rehash, err := s.hasher.Verify(password, user.PasswordHash)
+	if err != nil {
+		log.Printf("verify password of user %s: %v", user.ID, err)
+	}
+	return ok, rehash
+}
+
+// verifyDummy checks password against a hash no password matches, so
+// that a sign-in without a hash to check takes as long as one with.
+func (s *AuthService) verifyDummy(password string) {
+	s.dummyOnce.Do(func() {
+		hash, err := s.hasher.Hash("dummy password")
+		if err != nil {
+			log.Printf("hash dummy password: %v", err)
+		}
+		s.dummyHash = hash
+	})
+	if s.dummyHash != "" {
+		_, _, _ = s.hasher.Verify(password, s.dummyHash)
+	}
+}
+
+// rehash upgrades a password hash of an outdated algorithm or cost while
+// the password is at hand. The sign-in goes on if it fails.
+func (s *AuthService) rehash(ctx context.Context, userID, password string) {
+	hash, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.users.SetPassword(ctx, userID, hash)
+	}
+	if err != nil {
+		log.Printf("rehash password of user %s: %v", userID, err)
 	}
+}
+
+// validateCredentials returns the canonical email when both it and the
+// password are acceptable for a new user.
+func (s *AuthService) validateCredentials(email, password string) (string, error) {
+	var fields []validation.FieldError
 
-	if err := bcrypt.CompareHashAndPassword(
-		[]byte(user.PasswordHash),
-		[]byte(password),
-	); err != nil {
-		return "", ErrInvalidCredentials
+	email, fieldErr := validation.Email("email", email)
+	if fieldErr != nil {
+		fields = append(fields, *fieldErr)
+	}
+	if fieldErr := s.passwords.Check("password", password, email); fieldErr != nil {
+		fields = append(fields, *fieldErr)
 	}
 
-	return jwt.Generate(user.ID)
+	if len(fields) > 0 {
+		return "", &validation.Error{Fields: fields}
+	}
+	return email, nil
 }
+
+// loggedIn clears the user's failed attempts and starts a session.
+func (s *AuthService) loggedIn(ctx context.Context, user *repository.User) (*Tokens, error) {
+	if err := s.guard.Reset(ctx, user.Email); err != nil {
+		log.Printf("reset failed sign-ins of user %s: %v", user.ID, err)
+	}
+	s.audit(ctx, user.ID, user.Email, repository.LoginSucceeded)
+
+	return s.startSession(ctx, user.ID, user.Role)
+}
+
+// Validate accepts an access token while it has not expired and its
+// session has not been logged out or revoked.
 func (s *AuthService) Validate(ctx context.Context, token string) (string, error) {
-	userID, valid, err := jwt.Validate(token)
-	if err != nil || !valid {
-		return "", domain.ErrInvalidToken
+	user, err := s.Authenticate(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
+
+// Authenticate returns the user of an access token Validate accepts.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*repository.User, error) {
+	user, _, err := s.authenticateSession(ctx, token)
+	return user, err
+}
+
+// authenticateSession is Authenticate that also returns the session of
+// the token.
+func (s *AuthService) authenticateSession(ctx context.Context, token string) (*repository.User, string, error) {
+	claims, err := s.keys.Validate(token)
+	if err != nil || claims.SessionID == "" {
+		return nil, "", domain.ErrInvalidToken
 	}
 
-	_, err = s.users.GetByID(ctx, userID)
+	user, err := s.users.GetByID(ctx, claims.UserID)
 	if err != nil {
-		return "", domain.ErrInvalidToken
+		return nil, "", domain.ErrInvalidToken
+	}
+
+	active, err := s.tokens.FamilyActive(ctx, claims.SessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !active {
+		return nil, "", domain.ErrInvalidToken
 	}
 
-	return userID, nil
+	return user, claims.SessionID, nil
 }
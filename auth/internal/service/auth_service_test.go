@@ -1,22 +1,34 @@
 package service
 
 import (
-	"auth/internal/jwt"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"auth/internal/domain"
+	"auth/internal/passhash"
 	"auth/internal/repository"
+	"auth/internal/throttle"
+	"auth/internal/validation"
 
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// testHasher is cheap, to keep the tests fast.
+var testHasher = passhash.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
 type mockUserRepo struct {
 	getByEmail func(ctx context.Context, email string) (*repository.User, error)
 	getByID    func(ctx context.Context, id string) (*repository.User, error)
 	create     func(ctx context.Context, email, hash string) (string, error)
+	delete     func(ctx context.Context, id string) (*repository.AccountDeletion, error)
+
+	setEmailVerified func(ctx context.Context, id string) error
+	setPassword      func(ctx context.Context, id, hash string) error
+	setRole          func(ctx context.Context, id, role string) error
 }
 
 func (m *mockUserRepo) GetByEmail(ctx context.Context, email string) (*repository.User, error) {
@@ -31,6 +43,34 @@ func (m *mockUserRepo) Create(ctx context.Context, email, hash string) (string,
 	return m.create(ctx, email, hash)
 }
 
+func (m *mockUserRepo) SetEmailVerified(ctx context.Context, id string) error {
+	return m.setEmailVerified(ctx, id)
+}
+
+func (m *mockUserRepo) SetPassword(ctx context.Context, id, hash string) error {
+	return m.setPassword(ctx, id, hash)
+}
+
+func (m *mockUserRepo) List(ctx context.Context) ([]repository.User, error) {
+	return []repository.User{{ID: "user-123"}}, nil
+}
+
+func (m *mockUserRepo) SetRole(ctx context.Context, id, role string) error {
+	return m.setRole(ctx, id, role)
+}
+
+func (m *mockUserRepo) Stats(ctx context.Context) (*repository.UserStats, error) {
+	return &repository.UserStats{Users: 1, ByRole: map[string]int{repository.RoleUser: 1}}, nil
+}
+
+func (m *mockUserRepo) CountPasswordHashes(ctx context.Context) (map[string]int, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) Delete(ctx context.Context, id string) (*repository.AccountDeletion, error) {
+	return m.delete(ctx, id)
+}
+
 func TestRegister_Success(t *testing.T) {
 	repo := &mockUserRepo{
 		getByEmail: func(ctx context.Context, email string) (*repository.User, error) {
@@ -42,50 +82,150 @@ func TestRegister_Success(t *testing.T) {
 		},
 	}
 
-	svc := New(repo)
-
-	token, err := svc.Register(context.Background(), "test@mail.com", "password")
+	svc := newService(t, repo, nil, nil)
 
+	err := svc.Register(context.Background(), "test@mail.com", "password")
 	require.NoError(t, err)
-	require.NotEmpty(t, token)
 }
 
 func TestRegister_UserAlreadyExists(t *testing.T) {
+	users, _ := emailUsers("test@mail.com", true)
+	users.create = func(ctx context.Context, email, hash string) (string, error) {
+		t.Fatal("user created")
+		return "", nil
+	}
+	svc, mailer := newEmailService(t, users)
+
+	// the same answer as for a new address, and a notice to the owner
+	err := svc.Register(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+	require.Len(t, mailer.sent, 1)
+	require.Equal(t, "test@mail.com", mailer.sent[0].To)
+	require.Contains(t, mailer.sent[0].Body, "already has an account")
+}
+
+func TestRegister_UnverifiedUserAlreadyExists(t *testing.T) {
+	users, user := emailUsers("test@mail.com", false)
+	svc, mailer := newEmailService(t, users)
+
+	err := svc.Register(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+
+	path, token := mailer.lastLink(t)
+	require.Equal(t, "/verify-email", path)
+	require.NoError(t, svc.VerifyEmail(context.Background(), token))
+	require.True(t, user.EmailVerified)
+}
+
+func TestRegister_ThrottledByIP(t *testing.T) {
+	users, _ := emailUsers("", false)
+	svc, _ := newEmailService(t, users)
+	ctx := WithClientIP(context.Background(), "203.0.113.7")
+
+	var locked *throttle.LockedError
+	for i := 0; ; i++ {
+		err := svc.Register(ctx, fmt.Sprintf("user%d@mail.com", i), "password")
+		if errors.As(err, &locked) {
+			break
+		}
+		require.NoError(t, err)
+		require.Less(t, i, throttle.RegisterPolicy.Free+1)
+	}
+
+	// other clients can still register
+	err := svc.Register(WithClientIP(context.Background(), "198.51.100.1"), "other@mail.com", "password")
+	require.NoError(t, err)
+}
+
+func TestRegister_DoesNotBlockSignIn(t *testing.T) {
+	users, user := emailUsers("test@mail.com", true)
+	hash, err := testHasher.Hash("password")
+	require.NoError(t, err)
+	user.PasswordHash = hash
+	svc, _ := newEmailService(t, users)
+	ctx := WithClientIP(context.Background(), "203.0.113.7")
+
+	// users behind one NAT sign up until registrations are blocked
+	var locked *throttle.LockedError
+	for i := 0; !errors.As(svc.Register(ctx, fmt.Sprintf("user%d@mail.com", i), "password"), &locked); i++ {
+		require.Less(t, i, throttle.RegisterPolicy.Free+1)
+	}
+
+	_, err = svc.Login(ctx, "test@mail.com", "password")
+	require.NoError(t, err)
+}
+
+func TestRegister_CanonicalEmail(t *testing.T) {
+	var created string
 	repo := &mockUserRepo{
 		getByEmail: func(ctx context.Context, email string) (*repository.User, error) {
-			return &repository.User{ID: "1"}, nil
+			require.Equal(t, "test@mail.com", email)
+			return nil, errors.New("not found")
+		},
+		create: func(ctx context.Context, email, hash string) (string, error) {
+			created = email
+			return "user-123", nil
 		},
 	}
 
-	svc := New(repo)
+	svc := newService(t, repo, nil, nil)
+
+	err := svc.Register(context.Background(), " Test@Mail.COM ", "password")
+	require.NoError(t, err)
+	require.Equal(t, "test@mail.com", created)
+}
+
+func TestRegister_Invalid(t *testing.T) {
+	svc := newService(t, &mockUserRepo{}, nil, nil)
 
-	_, err := svc.Register(context.Background(), "test@mail.com", "password")
+	err := svc.Register(context.Background(), "not-an-email", "short")
 
-	require.ErrorIs(t, err, ErrUserAlreadyExists)
+	var invalid *validation.Error
+	require.ErrorAs(t, err, &invalid)
+	require.Len(t, invalid.Fields, 2)
+	require.Equal(t, "email", invalid.Fields[0].Field)
+	require.Equal(t, "password", invalid.Fields[1].Field)
 }
 
 func TestLogin_Success(t *testing.T) {
-	hash, err := bcrypt.GenerateFromPassword(
-		[]byte("password"),
-		bcrypt.DefaultCost,
-	)
+	hash, err := testHasher.Hash("password")
 	require.NoError(t, err)
 
 	repo := &mockUserRepo{
 		getByEmail: func(ctx context.Context, email string) (*repository.User, error) {
 			return &repository.User{
 				ID:           "user-123",
-				PasswordHash: string(hash),
+				PasswordHash: hash,
 			}, nil
 		},
 	}
 
-	svc := New(repo)
+	svc := newService(t, repo, nil, nil)
 
-	token, err := svc.Login(context.Background(), "test@mail.com", "password")
+	tokens, err := svc.Login(context.Background(), "test@mail.com", "password")
 
 	require.NoError(t, err)
-	require.NotEmpty(t, token)
+	require.NotEmpty(t, tokens.AccessToken)
+	require.NotEmpty(t, tokens.RefreshToken)
+}
+
+func TestLogin_RehashesLegacyHash(t *testing.T) {
+	users, user := emailUsers("test@mail.com", true)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user.PasswordHash = string(legacy)
+
+	svc, _ := newEmailService(t, users)
+
+	_, err = svc.Login(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(user.PasswordHash, testHasher.Prefix()))
+
+	// the new hash works and stays
+	upgraded := user.PasswordHash
+	_, err = svc.Login(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+	require.Equal(t, upgraded, user.PasswordHash)
 }
 
 func TestLogin_InvalidPassword(t *testing.T) {
@@ -98,13 +238,36 @@ func TestLogin_InvalidPassword(t *testing.T) {
 		},
 	}
 
-	svc := New(repo)
+	svc := newService(t, repo, nil, nil)
 
 	_, err := svc.Login(context.Background(), "test@mail.com", "wrong")
 
 	require.ErrorIs(t, err, ErrInvalidCredentials)
 }
 
+// countingHasher counts the hashes it verifies.
+type countingHasher struct {
+	passhash.Hasher
+	verified int
+}
+
+func (h *countingHasher) Verify(password, hash string) (bool, bool, error) {
+	h.verified++
+	return h.Hasher.Verify(password, hash)
+}
+
+func TestLogin_UnknownEmailVerifiesDummyHash(t *testing.T) {
+	users, _ := emailUsers("", false)
+	svc, _ := newEmailService(t, users)
+	hasher := &countingHasher{Hasher: testHasher}
+	svc.hasher = hasher
+
+	// an unknown email takes as long as a wrong password
+	_, err := svc.Login(context.Background(), "nobody@mail.com", "password")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	require.Equal(t, 1, hasher.verified)
+}
+
 func TestValidate_Success(t *testing.T) {
 	repo := &mockUserRepo{
 		getByID: func(ctx context.Context, id string) (*repository.User, error) {
@@ -112,12 +275,11 @@ func TestValidate_Success(t *testing.T) {
 		},
 	}
 
-	svc := New(repo)
+	svc := newService(t, repo, nil, nil)
 
-	token, err := jwt.Generate("user-123")
-	require.NoError(t, err)
+	tokens := signIn(t, svc, "user-123")
 
-	userID, err := svc.Validate(context.Background(), token)
+	userID, err := svc.Validate(context.Background(), tokens.AccessToken)
 
 	require.NoError(t, err)
 	require.Equal(t, "user-123", userID)
@@ -126,7 +288,7 @@ func TestValidate_Success(t *testing.T) {
 func TestValidate_InvalidToken(t *testing.T) {
 	repo := &mockUserRepo{}
 
-	svc := New(repo)
+	svc := newService(t, repo, nil, nil)
 
 	_, err := svc.Validate(context.Background(), "bad.token.value")
 
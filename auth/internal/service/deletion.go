@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// deletionRetryAfter is how long a published deletion may stay
+	// unconfirmed before it is published again.
+	deletionRetryAfter = time.Minute
+	deletionBatchSize  = 100
+	deletionPollEvery  = 15 * time.Second
+
+	// reauthWindow is how recently a user without a password must have
+	// signed in to delete their account.
+	reauthWindow = 5 * time.Minute
+)
+
+// DeleteAccount deletes the user the token belongs to once the password
+// has been confirmed. Users without a password, who signed up with an
+// identity provider, confirm by having signed in within reauthWindow
+// instead. Wrong passwords are throttled and audited as in Login.
+// Sign-in stops working at once; the user's data in the other
+// services is erased in the background and can be followed with
+// GetAccountDeletion.
+func (s *AuthService) DeleteAccount(
+	ctx context.Context,
+	token string,
+	password string,
+) (*repository.AccountDeletion, error) {
+	user, sessionID, err := s.authenticateSession(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.guard.Allow(ctx, user.Email, ClientIP(ctx)); err != nil {
+		return nil, err
+	}
+
+	if user.PasswordHash == "" {
+		if err := s.recentSignIn(ctx, user.ID, sessionID); err != nil {
+			return nil, err
+		}
+	} else if ok, _ := s.checkPassword(user, password); !ok {
+		s.failAttempt(ctx, user.ID, user.Email, repository.DeletionPasswordFailed)
+		return nil, ErrInvalidCredentials
+	}
+
+	d, err := s.users.Delete(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a failed attempt is retried by RunDeletions
+	if err := s.publish(ctx, *d); err != nil {
+		log.Printf("publish account deletion %s: %v", d.ID, err)
+	} else if s.publisher != nil {
+		d.Attempts++
+	}
+
+	return d, nil
+}
+
+// recentSignIn returns ErrReauthenticationRequired unless the session
+// was started within reauthWindow; refreshing its tokens does not count.
+func (s *AuthService) recentSignIn(ctx context.Context, userID, sessionID string) error {
+	sessions, err := s.sessions.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == sessionID && time.Since(session.CreatedAt) < reauthWindow {
+			return nil
+		}
+	}
+	return ErrReauthenticationRequired
+}
+
+func (s *AuthService) GetAccountDeletion(
+	ctx context.Context,
+	id string,
+) (*repository.AccountDeletion, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrDeletionNotFound
+	}
+
+	d, err := s.deletions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDeletionNotFound
+	}
+	return d, nil
+}
+
+// CompleteDeletion records that the user's data has been erased.
+func (s *AuthService) CompleteDeletion(ctx context.Context, id string) error {
+	ok, err := s.deletions.Complete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Printf("erasure confirmed for unknown account deletion %s", id)
+	}
+	return nil
+}
+
+// PublishDeletions publishes pending deletions that are not yet published
+// or were published more than deletionRetryAfter ago without an answer.
+func (s *AuthService) PublishDeletions(ctx context.Context) error {
+	if s.publisher == nil {
+		return nil
+	}
+
+	due, err := s.deletions.Due(ctx, deletionRetryAfter, deletionBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		if err := s.publish(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunDeletions publishes due deletions until ctx is done.
+func (s *AuthService) RunDeletions(ctx context.Context) {
+	ticker := time.NewTicker(deletionPollEvery)
+	defer ticker.Stop()
+
+	for {
+		if err := s.PublishDeletions(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("publish account deletions: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AuthService) publish(ctx context.Context, d repository.AccountDeletion) error {
+	if s.publisher == nil {
+		return nil
+	}
+	if err := s.publisher.PublishDeletion(ctx, d.ID, d.UserID); err != nil {
+		return err
+	}
+	return s.deletions.MarkPublished(ctx, d.ID)
+}
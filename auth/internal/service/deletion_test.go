@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth/internal/domain"
+	"auth/internal/repository"
+	"auth/internal/throttle"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockDeletionRepo struct {
+	deletions map[string]*repository.AccountDeletion
+	published []string
+}
+
+func (m *mockDeletionRepo) Get(ctx context.Context, id string) (*repository.AccountDeletion, error) {
+	return m.deletions[id], nil
+}
+
+func (m *mockDeletionRepo) Due(ctx context.Context, retryAfter time.Duration, limit int) ([]repository.AccountDeletion, error) {
+	var res []repository.AccountDeletion
+	for _, d := range m.deletions {
+		if d.Status == repository.DeletionPending {
+			res = append(res, *d)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockDeletionRepo) MarkPublished(ctx context.Context, id string) error {
+	m.published = append(m.published, id)
+	m.deletions[id].Attempts++
+	return nil
+}
+
+func (m *mockDeletionRepo) Complete(ctx context.Context, id string) (bool, error) {
+	d, ok := m.deletions[id]
+	if !ok {
+		return false, nil
+	}
+	now := time.Now()
+	d.Status = repository.DeletionCompleted
+	d.CompletedAt = &now
+	return true, nil
+}
+
+func (m *mockDeletionRepo) UsersDeletedSince(ctx context.Context, since time.Time) ([]string, error) {
+	var res []string
+	for _, d := range m.deletions {
+		if !d.RequestedAt.Before(since) {
+			res = append(res, d.UserID)
+		}
+	}
+	return res, nil
+}
+
+type mockPublisher struct {
+	err    error
+	events [][2]string
+}
+
+func (m *mockPublisher) PublishDeletion(ctx context.Context, deletionID, userID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, [2]string{deletionID, userID})
+	return nil
+}
+
+const deletionID = "5f0c6a52-3b5e-4d1e-9a55-0d6f1d2c9b11"
+
+func newDeletionService(t *testing.T, publisher DeletionPublisher) (*AuthService, *mockDeletionRepo) {
+	t.Helper()
+
+	hash, err := testHasher.Hash("password")
+	require.NoError(t, err)
+
+	deletions := &mockDeletionRepo{deletions: map[string]*repository.AccountDeletion{}}
+	users := &mockUserRepo{
+		getByID: func(ctx context.Context, id string) (*repository.User, error) {
+			return &repository.User{ID: id, Email: "test@mail.com", PasswordHash: hash}, nil
+		},
+		delete: func(ctx context.Context, id string) (*repository.AccountDeletion, error) {
+			d := &repository.AccountDeletion{
+				ID:          deletionID,
+				UserID:      id,
+				Status:      repository.DeletionPending,
+				RequestedAt: time.Now(),
+			}
+			stored := *d
+			deletions.deletions[d.ID] = &stored
+			return d, nil
+		},
+	}
+
+	return newService(t, users, deletions, publisher), deletions
+}
+
+func TestDeleteAccount_Success(t *testing.T) {
+	publisher := &mockPublisher{}
+	svc, deletions := newDeletionService(t, publisher)
+
+	token := signIn(t, svc, "user-123").AccessToken
+
+	d, err := svc.DeleteAccount(context.Background(), token, "password")
+	require.NoError(t, err)
+	require.Equal(t, repository.DeletionPending, d.Status)
+	require.Equal(t, 1, d.Attempts)
+
+	require.Equal(t, [][2]string{{deletionID, "user-123"}}, publisher.events)
+	require.Equal(t, []string{deletionID}, deletions.published)
+
+	require.NoError(t, svc.CompleteDeletion(context.Background(), deletionID))
+
+	got, err := svc.GetAccountDeletion(context.Background(), deletionID)
+	require.NoError(t, err)
+	require.Equal(t, repository.DeletionCompleted, got.Status)
+	require.NotNil(t, got.CompletedAt)
+}
+
+func TestDeleteAccount_RequiresPassword(t *testing.T) {
+	svc, deletions := newDeletionService(t, &mockPublisher{})
+
+	token := signIn(t, svc, "user-123").AccessToken
+
+	_, err := svc.DeleteAccount(context.Background(), token, "wrong")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = svc.DeleteAccount(context.Background(), "bad.token.value", "password")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	require.Empty(t, deletions.deletions)
+}
+
+func TestDeleteAccount_ThrottlesPasswordGuesses(t *testing.T) {
+	svc, deletions := newDeletionService(t, &mockPublisher{})
+
+	token := signIn(t, svc, "user-123").AccessToken
+
+	var locked *throttle.LockedError
+	for i := 0; ; i++ {
+		_, err := svc.DeleteAccount(context.Background(), token, "wrong")
+		if errors.As(err, &locked) {
+			break
+		}
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		require.Less(t, i, throttle.AccountPolicy.Free+1)
+	}
+
+	// not even the right password gets through for now
+	_, err := svc.DeleteAccount(context.Background(), token, "password")
+	require.ErrorAs(t, err, &locked)
+	require.Empty(t, deletions.deletions)
+
+	require.Contains(t, svc.auditLog.(*mockAuditRepo).events(), repository.DeletionPasswordFailed)
+}
+
+func TestDeleteAccount_RetriedUntilComplete(t *testing.T) {
+	publisher := &mockPublisher{err: errors.New("redis down")}
+	svc, deletions := newDeletionService(t, publisher)
+
+	token := signIn(t, svc, "user-123").AccessToken
+
+	// the user is deleted even when the event cannot be published yet
+	d, err := svc.DeleteAccount(context.Background(), token, "password")
+	require.NoError(t, err)
+	require.Equal(t, 0, d.Attempts)
+	require.Empty(t, deletions.published)
+
+	publisher.err = nil
+	require.NoError(t, svc.PublishDeletions(context.Background()))
+	require.NoError(t, svc.PublishDeletions(context.Background()))
+	require.Len(t, publisher.events, 2)
+
+	require.NoError(t, svc.CompleteDeletion(context.Background(), deletionID))
+	require.NoError(t, svc.PublishDeletions(context.Background()))
+	require.Len(t, publisher.events, 2)
+	require.Equal(t, 2, deletions.deletions[deletionID].Attempts)
+}
+
+func TestGetAccountDeletion_NotFound(t *testing.T) {
+	svc, _ := newDeletionService(t, nil)
+
+	_, err := svc.GetAccountDeletion(context.Background(), "not-a-uuid")
+	require.ErrorIs(t, err, ErrDeletionNotFound)
+
+	_, err = svc.GetAccountDeletion(context.Background(), deletionID)
+	require.ErrorIs(t, err, ErrDeletionNotFound)
+}
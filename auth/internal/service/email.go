@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"auth/internal/mail"
+	"auth/internal/repository"
+	"auth/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+const (
+	verificationTTL  = 48 * time.Hour
+	passwordResetTTL = time.Hour
+)
+
+// SendVerificationEmail sends a new verification link to the address.
+// Unknown and already verified addresses are ignored, so the result does
+// not tell which addresses have accounts.
+func (s *AuthService) SendVerificationEmail(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, validation.CanonicalEmail(email))
+	if err != nil || user.EmailVerified {
+		return nil
+	}
+	return s.sendVerification(ctx, user.ID, user.Email)
+}
+
+// VerifyEmail marks the address the token was sent to as verified.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	t, err := s.emailTokens.Use(ctx, hashToken(token), repository.EmailVerification)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrInvalidEmailToken
+	}
+	return s.users.SetEmailVerified(ctx, t.UserID)
+}
+
+// RequestPasswordReset sends a password reset link to the address.
+// Unknown addresses are ignored, as in SendVerificationEmail.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, validation.CanonicalEmail(email))
+	if err != nil {
+		return nil
+	}
+
+	token, err := s.newEmailToken(ctx, user.ID, repository.PasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your GoFinance password",
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password of your GoFinance account.\n\n"+
+				"Set a new password here within an hour:\n%s\n\n"+
+				"If it was not you, ignore this email; your password stays the same.\n",
+			s.link("/reset-password", token),
+		),
+	})
+}
+
+// ResetPassword sets a new password with a token from
+// RequestPasswordReset, signs the user out everywhere and revokes their
+// personal tokens, which may have leaked with the password. The link proves
+// the user reads their email, so the address is verified too. A password
+// against the policy returns a *validation.Error and keeps the token.
+func (s *AuthService) ResetPassword(ctx context.Context, token, password string) error {
+	if fieldErr := s.passwords.Check("password", password, ""); fieldErr != nil {
+		return &validation.Error{Fields: []validation.FieldError{*fieldErr}}
+	}
+
+	t, err := s.emailTokens.Use(ctx, hashToken(token), repository.PasswordReset)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrInvalidEmailToken
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.users.SetPassword(ctx, t.UserID, hash); err != nil {
+		return err
+	}
+	if err := s.users.SetEmailVerified(ctx, t.UserID); err != nil {
+		return err
+	}
+	if err := s.tokens.RevokeUser(ctx, t.UserID); err != nil {
+		return err
+	}
+	return s.personalTokens.RevokeUser(ctx, t.UserID)
+}
+
+func (s *AuthService) sendVerification(ctx context.Context, userID, email string) error {
+	token, err := s.newEmailToken(ctx, userID, repository.EmailVerification, verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Verify your GoFinance email",
+		Body: fmt.Sprintf(
+			"Welcome to GoFinance!\n\n"+
+				"Confirm this is your email address within 48 hours:\n%s\n",
+			s.link("/verify-email", token),
+		),
+	})
+}
+
+// sendAccountExists answers a registration with an email that already
+// has an account. Until the address is verified, the owner gets the
+// verification link again.
+func (s *AuthService) sendAccountExists(ctx context.Context, user *repository.User) error {
+	if !user.EmailVerified {
+		return s.sendVerification(ctx, user.ID, user.Email)
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Your GoFinance account",
+		Body: fmt.Sprintf(
+			"Someone tried to sign up for GoFinance with this email, which "+
+				"already has an account.\n\n"+
+				"Sign in here, or reset your password from there if you forgot it:\n%s\n\n"+
+				"If it was not you, ignore this email; your account stays the same.\n",
+			s.appURL+"/login",
+		),
+	})
+}
+
+// newEmailToken stores a token for the user and returns it. It replaces
+// the user's earlier tokens of the purpose.
+func (s *AuthService) newEmailToken(
+	ctx context.Context,
+	userID string,
+	purpose string,
+	ttl time.Duration,
+) (string, error) {
+	token, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.emailTokens.Create(ctx, repository.EmailToken{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// link returns the app page at path that completes a flow with token.
+func (s *AuthService) link(path, token string) string {
+	return s.appURL + path + "?token=" + url.QueryEscape(token)
+}
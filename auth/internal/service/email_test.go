@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"auth/internal/domain"
+	"auth/internal/mail"
+	"auth/internal/repository"
+	"auth/internal/throttle"
+	"auth/internal/validation"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testAppURL = "http://app.test"
+
+type mockEmailTokenRepo struct {
+	tokens map[string]*repository.EmailToken
+	used   map[string]bool
+}
+
+func newMockEmailTokenRepo() *mockEmailTokenRepo {
+	return &mockEmailTokenRepo{
+		tokens: map[string]*repository.EmailToken{},
+		used:   map[string]bool{},
+	}
+}
+
+func (m *mockEmailTokenRepo) Create(ctx context.Context, t repository.EmailToken) error {
+	for hash, old := range m.tokens {
+		if old.UserID == t.UserID && old.Purpose == t.Purpose {
+			m.used[hash] = true
+		}
+	}
+	m.tokens[t.TokenHash] = &t
+	return nil
+}
+
+func (m *mockEmailTokenRepo) Use(ctx context.Context, hash, purpose string) (*repository.EmailToken, error) {
+	t, ok := m.tokens[hash]
+	if !ok || m.used[hash] || t.Purpose != purpose || time.Now().After(t.ExpiresAt) {
+		return nil, nil
+	}
+	m.used[hash] = true
+	c := *t
+	return &c, nil
+}
+
+type mockMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg mail.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var linkRe = regexp.MustCompile(`http://app\.test(/[a-z-]+)\?token=(\S+)`)
+
+// lastLink returns the path and token of the link in the last email.
+func (m *mockMailer) lastLink(t *testing.T) (string, string) {
+	t.Helper()
+
+	require.NotEmpty(t, m.sent)
+	match := linkRe.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	require.NotNil(t, match)
+
+	token, err := url.QueryUnescape(match[2])
+	require.NoError(t, err)
+	return match[1], token
+}
+
+// emailUsers keeps one user in memory.
+func emailUsers(email string, verified bool) (*mockUserRepo, *repository.User) {
+	user := &repository.User{ID: "user-123", Email: email, Role: repository.RoleUser, EmailVerified: verified}
+
+	return &mockUserRepo{
+		getByEmail: func(ctx context.Context, e string) (*repository.User, error) {
+			if e != user.Email {
+				return nil, errors.New("not found")
+			}
+			c := *user
+			return &c, nil
+		},
+		getByID: func(ctx context.Context, id string) (*repository.User, error) {
+			c := *user
+			return &c, nil
+		},
+		create: func(ctx context.Context, email, hash string) (string, error) {
+			return user.ID, nil
+		},
+		setEmailVerified: func(ctx context.Context, id string) error {
+			user.EmailVerified = true
+			return nil
+		},
+		setPassword: func(ctx context.Context, id, hash string) error {
+			user.PasswordHash = hash
+			return nil
+		},
+		setRole: func(ctx context.Context, id, role string) error {
+			user.Role = role
+			return nil
+		},
+	}, user
+}
+
+func newEmailService(t *testing.T, users repository.UserRepository) (*AuthService, *mockMailer) {
+	t.Helper()
+
+	mailer := &mockMailer{}
+	tokens := newMockTokenRepo()
+	svc := New(Deps{
+		Users:          users,
+		Tokens:         tokens,
+		SigningKeys:    &mockSigningKeyRepo{},
+		EmailTokens:    newMockEmailTokenRepo(),
+		TwoFactor:      newMockTwoFactorRepo(),
+		AuditLog:       &mockAuditRepo{},
+		PersonalTokens: newMockPersonalTokenRepo(),
+		Sessions:       newMockSessionRepo(tokens),
+		OIDCLogins:     newMockOIDCRepo(),
+		Guard:          throttle.NewGuard(throttle.NewMemoryStore()),
+		Passwords:      validation.DefaultPasswordPolicy(),
+		Hasher:         testHasher,
+		Mailer:         mailer,
+		AppURL:         testAppURL + "/",
+	})
+	require.NoError(t, svc.RotateKeys(context.Background()))
+	return svc, mailer
+}
+
+func TestRegister_SendsVerificationEmail(t *testing.T) {
+	users, user := emailUsers("new@mail.com", false)
+	users.getByEmail = func(ctx context.Context, email string) (*repository.User, error) {
+		return nil, errors.New("not found")
+	}
+	svc, mailer := newEmailService(t, users)
+
+	err := svc.Register(context.Background(), "new@mail.com", "password")
+	require.NoError(t, err)
+
+	require.Len(t, mailer.sent, 1)
+	require.Equal(t, "new@mail.com", mailer.sent[0].To)
+
+	path, token := mailer.lastLink(t)
+	require.Equal(t, "/verify-email", path)
+
+	require.NoError(t, svc.VerifyEmail(context.Background(), token))
+	require.True(t, user.EmailVerified)
+
+	// the link works once
+	require.ErrorIs(t, svc.VerifyEmail(context.Background(), token), ErrInvalidEmailToken)
+}
+
+func TestRegister_SucceedsWhenMailFails(t *testing.T) {
+	users, _ := emailUsers("new@mail.com", false)
+	users.getByEmail = func(ctx context.Context, email string) (*repository.User, error) {
+		return nil, errors.New("not found")
+	}
+	svc, mailer := newEmailService(t, users)
+	mailer.err = errors.New("smtp down")
+
+	err := svc.Register(context.Background(), "new@mail.com", "password")
+	require.NoError(t, err)
+}
+
+func TestSendVerificationEmail(t *testing.T) {
+	users, user := emailUsers("test@mail.com", false)
+	svc, mailer := newEmailService(t, users)
+
+	require.NoError(t, svc.SendVerificationEmail(context.Background(), "test@mail.com"))
+	_, first := mailer.lastLink(t)
+	require.NoError(t, svc.SendVerificationEmail(context.Background(), "test@mail.com"))
+	_, second := mailer.lastLink(t)
+
+	// only the latest link works
+	require.ErrorIs(t, svc.VerifyEmail(context.Background(), first), ErrInvalidEmailToken)
+	require.NoError(t, svc.VerifyEmail(context.Background(), second))
+	require.True(t, user.EmailVerified)
+
+	// unknown and verified addresses get nothing, without an error
+	require.NoError(t, svc.SendVerificationEmail(context.Background(), "other@mail.com"))
+	require.NoError(t, svc.SendVerificationEmail(context.Background(), "test@mail.com"))
+	require.Len(t, mailer.sent, 2)
+}
+
+func TestResetPassword(t *testing.T) {
+	users, user := emailUsers("test@mail.com", false)
+	svc, mailer := newEmailService(t, users)
+	session := signIn(t, svc, user.ID)
+	_, pat, err := svc.CreatePersonalToken(context.Background(), session.AccessToken, "script", []string{ScopeRead}, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RequestPasswordReset(context.Background(), "test@mail.com"))
+	path, token := mailer.lastLink(t)
+	require.Equal(t, "/reset-password", path)
+
+	// a reset link does not verify through VerifyEmail
+	require.ErrorIs(t, svc.VerifyEmail(context.Background(), token), ErrInvalidEmailToken)
+
+	require.NoError(t, svc.RequestPasswordReset(context.Background(), "test@mail.com"))
+	_, token = mailer.lastLink(t)
+
+	require.NoError(t, svc.ResetPassword(context.Background(), token, "new-password"))
+	ok, _, err := testHasher.Verify("new-password", user.PasswordHash)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, user.EmailVerified)
+
+	// every session is signed out and personal tokens stop working
+	_, err = svc.Validate(context.Background(), session.AccessToken)
+	require.Error(t, err)
+	_, _, err = svc.ValidatePersonalToken(context.Background(), pat)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	require.ErrorIs(t, svc.ResetPassword(context.Background(), token, "another-password"), ErrInvalidEmailToken)
+}
+
+func TestResetPassword_WeakPassword(t *testing.T) {
+	users, _ := emailUsers("test@mail.com", false)
+	svc, mailer := newEmailService(t, users)
+
+	require.NoError(t, svc.RequestPasswordReset(context.Background(), "Test@Mail.com"))
+	_, token := mailer.lastLink(t)
+
+	var invalid *validation.Error
+	require.ErrorAs(t, svc.ResetPassword(context.Background(), token, "short"), &invalid)
+
+	// the link still works
+	require.NoError(t, svc.ResetPassword(context.Background(), token, "new-password"))
+}
+
+func TestResetPassword_ExpiredToken(t *testing.T) {
+	users, _ := emailUsers("test@mail.com", false)
+	svc, mailer := newEmailService(t, users)
+
+	require.NoError(t, svc.RequestPasswordReset(context.Background(), "test@mail.com"))
+	_, token := mailer.lastLink(t)
+
+	tokens := svc.emailTokens.(*mockEmailTokenRepo)
+	tokens.tokens[hashToken(token)].ExpiresAt = time.Now().Add(-time.Second)
+
+	require.ErrorIs(t, svc.ResetPassword(context.Background(), token, "new-password"), ErrInvalidEmailToken)
+}
+
+func TestRequestPasswordReset_UnknownEmail(t *testing.T) {
+	users, _ := emailUsers("test@mail.com", false)
+	svc, mailer := newEmailService(t, users)
+
+	require.NoError(t, svc.RequestPasswordReset(context.Background(), "other@mail.com"))
+	require.Empty(t, mailer.sent)
+}
@@ -1,9 +1,43 @@
 package service
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"auth/internal/jwt"
+	"auth/internal/repository"
+)
 
 type Auth interface {
-	Register(ctx context.Context, email, password string) (string, error)
-	Login(ctx context.Context, email, password string) (string, error)
+	Register(ctx context.Context, email, password string) error
+	Login(ctx context.Context, email, password string) (*Tokens, error)
+	LoginTwoFactor(ctx context.Context, challenge, code string) (*Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, error)
+	Logout(ctx context.Context, refreshToken string) error
+	SendVerificationEmail(ctx context.Context, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	EnrollTOTP(ctx context.Context, token string) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, token, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, token, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, token, code string) ([]string, error)
 	Validate(ctx context.Context, token string) (string, error)
+	Authenticate(ctx context.Context, token string) (*repository.User, error)
+	JWKS() []jwt.JWK
+	Revocations(ctx context.Context, since time.Time) (*Revocations, error)
+	DeleteAccount(ctx context.Context, token, password string) (*repository.AccountDeletion, error)
+	GetAccountDeletion(ctx context.Context, id string) (*repository.AccountDeletion, error)
+	CreatePersonalToken(ctx context.Context, accessToken, name string, scopes []string, expiresIn time.Duration) (*repository.PersonalToken, string, error)
+	ListPersonalTokens(ctx context.Context, accessToken string) ([]repository.PersonalToken, error)
+	RevokePersonalToken(ctx context.Context, accessToken, id string) error
+	ValidatePersonalToken(ctx context.Context, token string) (string, []string, error)
+	ListSessions(ctx context.Context, accessToken string) ([]repository.Session, string, error)
+	RevokeSession(ctx context.Context, accessToken, id string) error
+	RevokeAllOtherSessions(ctx context.Context, accessToken string) error
+	ListUsers(ctx context.Context, accessToken string) ([]repository.User, error)
+	SetUserRole(ctx context.Context, accessToken, userID, role string) (*repository.User, error)
+	UserStats(ctx context.Context, accessToken string) (*repository.UserStats, error)
+	StartOIDCLogin(ctx context.Context, provider string) (string, error)
+	FinishOIDCLogin(ctx context.Context, provider, state, code string) (*Tokens, error)
 }
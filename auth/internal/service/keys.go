@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"log"
+	"time"
+
+	"auth/internal/jwt"
+	"auth/internal/repository"
+)
+
+const (
+	// keyRotateEvery is how long a key signs before it is replaced.
+	keyRotateEvery = 30 * 24 * time.Hour
+
+	// keyReloadEvery is how often keys are reloaded, so every instance
+	// picks up a key another one created.
+	keyReloadEvery = time.Minute
+
+	// keyRetention is how long a retired key keeps verifying: the lifetime
+	// of the last token it signed, plus the time an instance may still
+	// sign with it before reloading.
+	keyRetention = jwt.AccessTTL + 2*keyReloadEvery
+)
+
+// RotateKeys loads the signing keys. A new key is created when there is
+// none or the active one is due, and retired keys whose tokens have all
+// expired are deleted.
+func (s *AuthService) RotateKeys(ctx context.Context) error {
+	stored, err := s.signingKeys.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if rotationDue(stored, now) {
+		key, err := jwt.NewKey()
+		if err != nil {
+			return err
+		}
+
+		err = s.signingKeys.Rotate(ctx, repository.SigningKey{
+			ID:         key.ID,
+			PrivateKey: key.PrivateKey.Seed(),
+			CreatedAt:  key.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("rotated token signing key, new key %s", key.ID)
+
+		if stored, err = s.signingKeys.List(ctx); err != nil {
+			return err
+		}
+	}
+
+	expired := now.Add(-keyRetention).UTC()
+	if err := s.signingKeys.DeleteRetiredBefore(ctx, expired); err != nil {
+		return err
+	}
+
+	keys := make([]jwt.Key, 0, len(stored))
+	for _, k := range stored {
+		if k.RetiredAt != nil && k.RetiredAt.Before(expired) {
+			continue
+		}
+		if len(k.PrivateKey) != ed25519.SeedSize {
+			return fmt.Errorf("signing key %s: invalid seed", k.ID)
+		}
+		keys = append(keys, jwt.Key{
+			ID:         k.ID,
+			PrivateKey: ed25519.NewKeyFromSeed(k.PrivateKey),
+			CreatedAt:  k.CreatedAt,
+			RetiredAt:  k.RetiredAt,
+		})
+	}
+	s.keys.Replace(keys)
+
+	return nil
+}
+
+// RunKeyRotation reloads and rotates the keys until ctx is done.
+func (s *AuthService) RunKeyRotation(ctx context.Context) {
+	ticker := time.NewTicker(keyReloadEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.RotateKeys(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("rotate signing keys: %v", err)
+		}
+	}
+}
+
+// JWKS returns the public keys access tokens can be verified with.
+func (s *AuthService) JWKS() []jwt.JWK {
+	return s.keys.JWKS()
+}
+
+func rotationDue(keys []repository.SigningKey, now time.Time) bool {
+	var newest *repository.SigningKey
+	for i, k := range keys {
+		if k.RetiredAt == nil && (newest == nil || k.CreatedAt.After(newest.CreatedAt)) {
+			newest = &keys[i]
+		}
+	}
+	return newest == nil || now.Sub(newest.CreatedAt) >= keyRotateEvery
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+	"auth/internal/throttle"
+	"auth/internal/validation"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockSigningKeyRepo struct {
+	keys []repository.SigningKey
+}
+
+func (m *mockSigningKeyRepo) List(ctx context.Context) ([]repository.SigningKey, error) {
+	return append([]repository.SigningKey(nil), m.keys...), nil
+}
+
+func (m *mockSigningKeyRepo) Rotate(ctx context.Context, key repository.SigningKey) error {
+	for i := range m.keys {
+		if m.keys[i].RetiredAt == nil {
+			retired := key.CreatedAt
+			m.keys[i].RetiredAt = &retired
+		}
+	}
+	m.keys = append(m.keys, key)
+	return nil
+}
+
+func (m *mockSigningKeyRepo) DeleteRetiredBefore(ctx context.Context, t time.Time) error {
+	kept := m.keys[:0]
+	for _, k := range m.keys {
+		if k.RetiredAt == nil || !k.RetiredAt.Before(t) {
+			kept = append(kept, k)
+		}
+	}
+	m.keys = kept
+	return nil
+}
+
+// newService returns a service with in-memory refresh tokens and a
+// loaded signing key.
+func newService(
+	t *testing.T,
+	users repository.UserRepository,
+	deletions repository.DeletionRepository,
+	publisher DeletionPublisher,
+) *AuthService {
+	t.Helper()
+
+	tokens := newMockTokenRepo()
+	svc := New(Deps{
+		Users:          users,
+		Tokens:         tokens,
+		SigningKeys:    &mockSigningKeyRepo{},
+		Deletions:      deletions,
+		EmailTokens:    newMockEmailTokenRepo(),
+		TwoFactor:      newMockTwoFactorRepo(),
+		AuditLog:       &mockAuditRepo{},
+		PersonalTokens: newMockPersonalTokenRepo(),
+		Sessions:       newMockSessionRepo(tokens),
+		OIDCLogins:     newMockOIDCRepo(),
+		Guard:          throttle.NewGuard(throttle.NewMemoryStore()),
+		Passwords:      validation.DefaultPasswordPolicy(),
+		Hasher:         testHasher,
+		Publisher:      publisher,
+		Mailer:         &mockMailer{},
+		AppURL:         testAppURL,
+	})
+	require.NoError(t, svc.RotateKeys(context.Background()))
+	return svc
+}
+
+func TestRotateKeys_CreatesFirstKey(t *testing.T) {
+	keys := &mockSigningKeyRepo{}
+	svc := New(Deps{
+		Users:       &mockUserRepo{},
+		Tokens:      newMockTokenRepo(),
+		SigningKeys: keys,
+		Passwords:   validation.DefaultPasswordPolicy(),
+		Hasher:      testHasher,
+	})
+
+	require.NoError(t, svc.RotateKeys(context.Background()))
+	require.Len(t, keys.keys, 1)
+
+	// a fresh key is kept
+	require.NoError(t, svc.RotateKeys(context.Background()))
+	require.Len(t, keys.keys, 1)
+
+	jwks := svc.JWKS()
+	require.Len(t, jwks, 1)
+	require.Equal(t, keys.keys[0].ID, jwks[0].Kid)
+}
+
+func TestRotateKeys_OldTokensValidateUntilExpired(t *testing.T) {
+	users := &mockUserRepo{
+		getByID: func(ctx context.Context, id string) (*repository.User, error) {
+			return &repository.User{ID: id}, nil
+		},
+	}
+	keys := &mockSigningKeyRepo{}
+	svc := New(Deps{
+		Users:       users,
+		Tokens:      newMockTokenRepo(),
+		SigningKeys: keys,
+		Passwords:   validation.DefaultPasswordPolicy(),
+		Hasher:      testHasher,
+	})
+	require.NoError(t, svc.RotateKeys(context.Background()))
+
+	tokens := signIn(t, svc, "user-123")
+
+	// the key is due
+	keys.keys[0].CreatedAt = time.Now().Add(-keyRotateEvery - time.Hour)
+	require.NoError(t, svc.RotateKeys(context.Background()))
+	require.Len(t, keys.keys, 2)
+	require.Len(t, svc.JWKS(), 2)
+
+	_, err := svc.Validate(context.Background(), tokens.AccessToken)
+	require.NoError(t, err)
+
+	// once every token of the retired key has expired it is dropped
+	retired := time.Now().Add(-keyRetention - time.Minute)
+	keys.keys[0].RetiredAt = &retired
+	require.NoError(t, svc.RotateKeys(context.Background()))
+	require.Len(t, keys.keys, 1)
+	require.Len(t, svc.JWKS(), 1)
+
+	_, err = svc.Validate(context.Background(), tokens.AccessToken)
+	require.Error(t, err)
+}
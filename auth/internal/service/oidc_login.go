@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"auth/internal/oidc"
+	"auth/internal/repository"
+	"auth/internal/validation"
+)
+
+// oidcLoginTTL is how long the user has to sign in at the provider.
+const oidcLoginTTL = 10 * time.Minute
+
+// StartOIDCLogin begins signing in with a configured identity provider and
+// returns the provider's URL to send the user to. The provider sends them
+// back to its redirect URL with a code and a state for FinishOIDCLogin.
+func (s *AuthService) StartOIDCLogin(ctx context.Context, provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	state, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+	verifier := oidc.NewCodeVerifier()
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.Printf("start sign-in with %s: %v", provider, err)
+		return "", ErrProviderUnavailable
+	}
+
+	err = s.oidcLogins.CreateLogin(ctx, repository.OIDCLogin{
+		StateHash:    hashToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// FinishOIDCLogin redeems the code the provider sent back with the state
+// of a sign-in StartOIDCLogin began; each state is good for one try. As
+// with Login, a user with two-factor authentication gets a challenge
+// instead of tokens.
+func (s *AuthService) FinishOIDCLogin(
+	ctx context.Context,
+	provider string,
+	state string,
+	code string,
+) (*Tokens, error) {
+
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	login, err := s.oidcLogins.UseLogin(ctx, hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if login == nil || login.Provider != provider {
+		return nil, ErrInvalidOIDCState
+	}
+
+	identity, err := p.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		log.Printf("finish sign-in with %s: %v", provider, err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, err := s.oidcUser(ctx, provider, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.challenge(ctx, user.ID)
+	if err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	return s.loggedIn(ctx, user)
+}
+
+// oidcUser returns the user an identity signs in as. An identity seen
+// before is the user it was linked to. A new one is linked by the email
+// the provider verified: to the user with that email, who is created
+// without a password when there is none. Users who have not verified
+// the email themselves are not linked, as whoever registered it may not
+// own it.
+func (s *AuthService) oidcUser(
+	ctx context.Context,
+	provider string,
+	identity *oidc.Identity,
+) (*repository.User, error) {
+
+	userID, err := s.oidcLogins.UserOf(ctx, provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" {
+		return s.users.GetByID(ctx, userID)
+	}
+
+	email, fieldErr := validation.Email("email", identity.Email)
+	if fieldErr != nil || !identity.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		userID, err := s.users.Create(ctx, email, "")
+		if err != nil {
+			return nil, err
+		}
+		if err := s.users.SetEmailVerified(ctx, userID); err != nil {
+			return nil, err
+		}
+		user = &repository.User{
+			ID:            userID,
+			Email:         email,
+			Role:          repository.RoleUser,
+			EmailVerified: true,
+		}
+	} else if !user.EmailVerified {
+		return nil, ErrAccountNotVerified
+	}
+
+	err = s.oidcLogins.Link(ctx, repository.Identity{
+		Provider: provider,
+		Subject:  identity.Subject,
+		UserID:   user.ID,
+		Email:    email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, user.ID, email, repository.IdentityLinked)
+
+	return user, nil
+}
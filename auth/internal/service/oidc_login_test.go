@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/oidc"
+	"auth/internal/oidc/oidctest"
+	"auth/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockOIDCRepo struct {
+	logins     map[string]repository.OIDCLogin
+	identities map[string]repository.Identity
+}
+
+func newMockOIDCRepo() *mockOIDCRepo {
+	return &mockOIDCRepo{
+		logins:     map[string]repository.OIDCLogin{},
+		identities: map[string]repository.Identity{},
+	}
+}
+
+func (m *mockOIDCRepo) CreateLogin(ctx context.Context, l repository.OIDCLogin) error {
+	m.logins[l.StateHash] = l
+	return nil
+}
+
+func (m *mockOIDCRepo) UseLogin(ctx context.Context, stateHash string) (*repository.OIDCLogin, error) {
+	l, ok := m.logins[stateHash]
+	delete(m.logins, stateHash)
+	if !ok || !l.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+func (m *mockOIDCRepo) UserOf(ctx context.Context, provider, subject string) (string, error) {
+	return m.identities[provider+"/"+subject].UserID, nil
+}
+
+func (m *mockOIDCRepo) Link(ctx context.Context, id repository.Identity) error {
+	if _, ok := m.identities[id.Provider+"/"+id.Subject]; !ok {
+		m.identities[id.Provider+"/"+id.Subject] = id
+	}
+	return nil
+}
+
+// oidcService returns a service whose users can sign in with a mock
+// provider named "company".
+func oidcService(t *testing.T, users repository.UserRepository) (*AuthService, *oidctest.Provider) {
+	t.Helper()
+
+	idp := oidctest.NewProvider(t)
+	svc, _ := newEmailService(t, users)
+	svc.providers = oidc.Providers{
+		"company": oidc.NewProvider(oidc.ProviderConfig{
+			Name:         "company",
+			Issuer:       idp.URL,
+			ClientID:     oidctest.ClientID,
+			ClientSecret: oidctest.ClientSecret,
+			RedirectURL:  testAppURL + "/auth/oidc/company/callback",
+		}),
+	}
+	return svc, idp
+}
+
+// signInAt signs user in at the provider and returns the code and state
+// it sends back.
+func signInAt(t *testing.T, svc *AuthService, idp *oidctest.Provider, user oidctest.User) (string, string) {
+	t.Helper()
+
+	idp.SignIn(user)
+	authURL, err := svc.StartOIDCLogin(context.Background(), "company")
+	require.NoError(t, err)
+	return idp.Authorize(t, authURL)
+}
+
+func TestOIDCLogin_CreatesUser(t *testing.T) {
+	users, user := emailUsers("", false)
+	created := 0
+	users.create = func(ctx context.Context, email, hash string) (string, error) {
+		require.Empty(t, hash)
+		created++
+		user.Email = email
+		return user.ID, nil
+	}
+	svc, idp := oidcService(t, users)
+
+	code, state := signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "Ann@Example.com", EmailVerified: true})
+	tokens, err := svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.NoError(t, err)
+
+	userID, err := svc.Validate(context.Background(), tokens.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, "user-123", userID)
+	require.Equal(t, "ann@example.com", user.Email)
+	require.True(t, user.EmailVerified)
+
+	// the same identity signs in as the same user, whatever its email
+	code, state = signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "ann@new.example.com", EmailVerified: true})
+	_, err = svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.NoError(t, err)
+	require.Equal(t, 1, created)
+
+	require.Equal(t, []string{
+		repository.IdentityLinked,
+		repository.LoginSucceeded,
+		repository.LoginSucceeded,
+	}, svc.auditLog.(*mockAuditRepo).events())
+
+	// there is no password to sign in with
+	_, err = svc.Login(context.Background(), "ann@example.com", "")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestOIDCLogin_CreatedUserDeletesAccount(t *testing.T) {
+	users, user := emailUsers("", false)
+	users.create = func(ctx context.Context, email, hash string) (string, error) {
+		user.Email = email
+		return user.ID, nil
+	}
+	deleted := false
+	users.delete = func(ctx context.Context, id string) (*repository.AccountDeletion, error) {
+		deleted = true
+		return &repository.AccountDeletion{ID: "deletion-1", UserID: id, Status: repository.DeletionPending}, nil
+	}
+	svc, idp := oidcService(t, users)
+
+	code, state := signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "ann@example.com", EmailVerified: true})
+	tokens, err := svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.NoError(t, err)
+
+	// a session signed in long ago has to sign in again
+	for _, s := range svc.sessions.(*mockSessionRepo).sessions {
+		s.CreatedAt = time.Now().Add(-reauthWindow - time.Minute)
+	}
+	_, err = svc.DeleteAccount(context.Background(), tokens.AccessToken, "")
+	require.ErrorIs(t, err, ErrReauthenticationRequired)
+	require.False(t, deleted)
+
+	code, state = signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "ann@example.com", EmailVerified: true})
+	tokens, err = svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.NoError(t, err)
+
+	d, err := svc.DeleteAccount(context.Background(), tokens.AccessToken, "")
+	require.NoError(t, err)
+	require.Equal(t, "user-123", d.UserID)
+	require.True(t, deleted)
+}
+
+func TestOIDCLogin_LinksVerifiedUser(t *testing.T) {
+	users, _ := emailUsers("ann@example.com", true)
+	users.create = func(ctx context.Context, email, hash string) (string, error) {
+		t.Fatal("user created")
+		return "", nil
+	}
+	svc, idp := oidcService(t, users)
+
+	code, state := signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "Ann@Example.com", EmailVerified: true})
+	_, err := svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.NoError(t, err)
+
+	require.Equal(t, repository.Identity{
+		Provider: "company",
+		Subject:  "sub-1",
+		UserID:   "user-123",
+		Email:    "ann@example.com",
+	}, svc.oidcLogins.(*mockOIDCRepo).identities["company/sub-1"])
+}
+
+func TestOIDCLogin_RejectsUnverifiedEmail(t *testing.T) {
+	users, _ := emailUsers("ann@example.com", true)
+	svc, idp := oidcService(t, users)
+
+	code, state := signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "ann@example.com"})
+	_, err := svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.ErrorIs(t, err, ErrOIDCEmailNotVerified)
+	require.Empty(t, svc.oidcLogins.(*mockOIDCRepo).identities)
+}
+
+func TestOIDCLogin_RejectsUnverifiedAccount(t *testing.T) {
+	// whoever registered the email may not own it
+	users, _ := emailUsers("ann@example.com", false)
+	svc, idp := oidcService(t, users)
+
+	code, state := signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "ann@example.com", EmailVerified: true})
+	_, err := svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.ErrorIs(t, err, ErrAccountNotVerified)
+	require.Empty(t, svc.oidcLogins.(*mockOIDCRepo).identities)
+}
+
+func TestOIDCLogin_State(t *testing.T) {
+	users, _ := emailUsers("ann@example.com", true)
+	svc, idp := oidcService(t, users)
+	ann := oidctest.User{Subject: "sub-1", Email: "ann@example.com", EmailVerified: true}
+
+	code, _ := signInAt(t, svc, idp, ann)
+	_, err := svc.FinishOIDCLogin(context.Background(), "company", "forged", code)
+	require.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	code, state := signInAt(t, svc, idp, ann)
+	_, err = svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.NoError(t, err)
+
+	// a state is good for one try
+	_, err = svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	// and expires
+	code, state = signInAt(t, svc, idp, ann)
+	login := svc.oidcLogins.(*mockOIDCRepo).logins[hashToken(state)]
+	login.ExpiresAt = time.Now().Add(-time.Second)
+	svc.oidcLogins.(*mockOIDCRepo).logins[hashToken(state)] = login
+	_, err = svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	_, err = svc.StartOIDCLogin(context.Background(), "other")
+	require.ErrorIs(t, err, ErrUnknownProvider)
+	_, err = svc.FinishOIDCLogin(context.Background(), "other", state, code)
+	require.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestOIDCLogin_RejectsReplayedIDToken(t *testing.T) {
+	users, _ := emailUsers("ann@example.com", true)
+	svc, idp := oidcService(t, users)
+	idp.Nonce = "from-another-sign-in"
+
+	code, state := signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "ann@example.com", EmailVerified: true})
+	_, err := svc.FinishOIDCLogin(context.Background(), "company", state, code)
+	require.ErrorIs(t, err, ErrOIDCLoginFailed)
+}
+
+func TestOIDCLogin_RejectsWrongCode(t *testing.T) {
+	users, _ := emailUsers("ann@example.com", true)
+	svc, idp := oidcService(t, users)
+
+	_, state := signInAt(t, svc, idp, oidctest.User{Subject: "sub-1", Email: "ann@example.com", EmailVerified: true})
+	tokens, err := svc.FinishOIDCLogin(context.Background(), "company", state, "guessed")
+	require.ErrorIs(t, err, ErrOIDCLoginFailed)
+	require.Nil(t, tokens)
+}
+
+func TestStartOIDCLogin_ProviderDown(t *testing.T) {
+	users, _ := emailUsers("ann@example.com", true)
+	svc, idp := oidcService(t, users)
+	idp.Close()
+
+	_, err := svc.StartOIDCLogin(context.Background(), "company")
+	require.ErrorIs(t, err, ErrProviderUnavailable)
+	require.Empty(t, svc.oidcLogins.(*mockOIDCRepo).logins)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"auth/internal/domain"
+	"auth/internal/repository"
+	"auth/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+// PersonalTokenPrefix starts every personal token, so the gateway can
+// tell them from access tokens and secret scanners can find leaked ones.
+const PersonalTokenPrefix = "gft_"
+
+// Scopes of personal tokens.
+const (
+	ScopeRead              = "read"
+	ScopeTransactionsWrite = "transactions:write"
+	ScopeBudgetsWrite      = "budgets:write"
+)
+
+// personalTokenScopes is every scope, in the order they are listed.
+var personalTokenScopes = []string{ScopeRead, ScopeTransactionsWrite, ScopeBudgetsWrite}
+
+const maxPersonalTokenName = 100
+
+// CreatePersonalToken creates a token for the user of the access token.
+// The token is returned here only. It never expires when expiresIn is
+// zero. Invalid names, or scopes the user's role does not have, return a
+// *validation.Error.
+func (s *AuthService) CreatePersonalToken(
+	ctx context.Context,
+	accessToken string,
+	name string,
+	scopes []string,
+	expiresIn time.Duration,
+) (*repository.PersonalToken, string, error) {
+	user, err := s.Authenticate(ctx, accessToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name, scopes, err = validatePersonalToken(name, scopes, expiresIn, user.Role)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	token := PersonalTokenPrefix + secret
+
+	t := repository.PersonalToken{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if expiresIn > 0 {
+		expires := t.CreatedAt.Add(expiresIn)
+		t.ExpiresAt = &expires
+	}
+
+	if err := s.personalTokens.Create(ctx, t); err != nil {
+		return nil, "", err
+	}
+	return &t, token, nil
+}
+
+// ListPersonalTokens returns the unrevoked tokens of the user of the
+// access token.
+func (s *AuthService) ListPersonalTokens(ctx context.Context, accessToken string) ([]repository.PersonalToken, error) {
+	userID, err := s.Validate(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.personalTokens.List(ctx, userID)
+}
+
+// RevokePersonalToken revokes one of the tokens of the user of the
+// access token.
+func (s *AuthService) RevokePersonalToken(ctx context.Context, accessToken, id string) error {
+	userID, err := s.Validate(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrPersonalTokenNotFound
+	}
+
+	ok, err := s.personalTokens.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+// ValidatePersonalToken returns the user and the scopes of a personal
+// token, less those the user's role has lost since it was created.
+// Validate does not accept personal tokens, so they cannot manage the
+// account.
+func (s *AuthService) ValidatePersonalToken(ctx context.Context, token string) (string, []string, error) {
+	if !strings.HasPrefix(token, PersonalTokenPrefix) {
+		return "", nil, domain.ErrInvalidToken
+	}
+
+	t, err := s.personalTokens.Use(ctx, hashToken(token))
+	if err != nil {
+		return "", nil, err
+	}
+	if t == nil {
+		return "", nil, domain.ErrInvalidToken
+	}
+
+	user, err := s.users.GetByID(ctx, t.UserID)
+	if err != nil {
+		return "", nil, domain.ErrInvalidToken
+	}
+
+	var scopes []string
+	for _, scope := range t.Scopes {
+		if slices.Contains(roleScopes[user.Role], scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return t.UserID, scopes, nil
+}
+
+// validatePersonalToken returns the trimmed name and the scopes without
+// duplicates, in a fixed order.
+func validatePersonalToken(name string, scopes []string, expiresIn time.Duration, role string) (string, []string, error) {
+	var fields []validation.FieldError
+
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		fields = append(fields, validation.FieldError{Field: "name", Message: "is required"})
+	case len(name) > maxPersonalTokenName:
+		fields = append(fields, validation.FieldError{Field: "name", Message: "is too long"})
+	}
+
+	var granted []string
+	for _, scope := range personalTokenScopes {
+		if slices.Contains(scopes, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(personalTokenScopes, scope) {
+			fields = append(fields, validation.FieldError{Field: "scopes", Message: "unknown scope " + scope})
+			break
+		}
+		if !slices.Contains(roleScopes[role], scope) {
+			fields = append(fields, validation.FieldError{Field: "scopes", Message: "scope " + scope + " is not allowed for role " + role})
+			break
+		}
+	}
+	if len(scopes) == 0 {
+		fields = append(fields, validation.FieldError{Field: "scopes", Message: "is required"})
+	}
+
+	if expiresIn < 0 {
+		fields = append(fields, validation.FieldError{Field: "expires_in", Message: "must not be negative"})
+	}
+
+	if len(fields) > 0 {
+		return "", nil, &validation.Error{Fields: fields}
+	}
+	return name, granted, nil
+}
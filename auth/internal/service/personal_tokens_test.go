@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/domain"
+	"auth/internal/repository"
+	"auth/internal/validation"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockPersonalTokenRepo struct {
+	tokens  map[string]*repository.PersonalToken
+	revoked map[string]bool
+}
+
+func newMockPersonalTokenRepo() *mockPersonalTokenRepo {
+	return &mockPersonalTokenRepo{
+		tokens:  map[string]*repository.PersonalToken{},
+		revoked: map[string]bool{},
+	}
+}
+
+func (m *mockPersonalTokenRepo) Create(ctx context.Context, t repository.PersonalToken) error {
+	m.tokens[t.ID] = &t
+	return nil
+}
+
+func (m *mockPersonalTokenRepo) List(ctx context.Context, userID string) ([]repository.PersonalToken, error) {
+	var tokens []repository.PersonalToken
+	for id, t := range m.tokens {
+		if t.UserID == userID && !m.revoked[id] {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *mockPersonalTokenRepo) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	t, ok := m.tokens[id]
+	if !ok || t.UserID != userID || m.revoked[id] {
+		return false, nil
+	}
+	m.revoked[id] = true
+	return true, nil
+}
+
+func (m *mockPersonalTokenRepo) RevokeUser(ctx context.Context, userID string) error {
+	for id, t := range m.tokens {
+		if t.UserID == userID {
+			m.revoked[id] = true
+		}
+	}
+	return nil
+}
+
+func (m *mockPersonalTokenRepo) Use(ctx context.Context, hash string) (*repository.PersonalToken, error) {
+	for id, t := range m.tokens {
+		if t.TokenHash != hash || m.revoked[id] || t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt) {
+			continue
+		}
+		now := time.Now()
+		t.LastUsedAt = &now
+		c := *t
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func TestPersonalToken(t *testing.T) {
+	users, user := emailUsers("test@mail.com", true)
+	svc, _ := newEmailService(t, users)
+	session := signIn(t, svc, user.ID)
+
+	created, token, err := svc.CreatePersonalToken(
+		context.Background(),
+		session.AccessToken,
+		" Google Sheet ",
+		[]string{ScopeTransactionsWrite, ScopeRead, ScopeRead},
+		0,
+	)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, PersonalTokenPrefix))
+	require.Equal(t, "Google Sheet", created.Name)
+	require.Equal(t, []string{ScopeRead, ScopeTransactionsWrite}, created.Scopes)
+	require.Nil(t, created.ExpiresAt)
+	require.Equal(t, hashToken(token), created.TokenHash)
+
+	userID, scopes, err := svc.ValidatePersonalToken(context.Background(), token)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, userID)
+	require.Equal(t, []string{ScopeRead, ScopeTransactionsWrite}, scopes)
+
+	// a personal token cannot act as an access token, e.g. to create more
+	_, err = svc.Validate(context.Background(), token)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	_, _, err = svc.CreatePersonalToken(context.Background(), token, "more", []string{ScopeRead}, 0)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	tokens, err := svc.ListPersonalTokens(context.Background(), session.AccessToken)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.NotNil(t, tokens[0].LastUsedAt)
+
+	require.NoError(t, svc.RevokePersonalToken(context.Background(), session.AccessToken, created.ID))
+	require.ErrorIs(t, svc.RevokePersonalToken(context.Background(), session.AccessToken, created.ID), ErrPersonalTokenNotFound)
+
+	_, _, err = svc.ValidatePersonalToken(context.Background(), token)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestPersonalToken_Expires(t *testing.T) {
+	users, user := emailUsers("test@mail.com", true)
+	svc, _ := newEmailService(t, users)
+	session := signIn(t, svc, user.ID)
+
+	created, token, err := svc.CreatePersonalToken(context.Background(), session.AccessToken, "script", []string{ScopeRead}, time.Hour)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), *created.ExpiresAt, time.Minute)
+
+	expired := time.Now().Add(-time.Second)
+	svc.personalTokens.(*mockPersonalTokenRepo).tokens[created.ID].ExpiresAt = &expired
+
+	_, _, err = svc.ValidatePersonalToken(context.Background(), token)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestCreatePersonalToken_Invalid(t *testing.T) {
+	users, user := emailUsers("test@mail.com", true)
+	svc, _ := newEmailService(t, users)
+	session := signIn(t, svc, user.ID)
+
+	_, _, err := svc.CreatePersonalToken(context.Background(), session.AccessToken, "", []string{"admin"}, -time.Hour)
+
+	var invalid *validation.Error
+	require.ErrorAs(t, err, &invalid)
+
+	var fields []string
+	for _, f := range invalid.Fields {
+		fields = append(fields, f.Field)
+	}
+	require.Equal(t, []string{"name", "scopes", "expires_in"}, fields)
+}
+
+func TestRevokePersonalToken_OtherUser(t *testing.T) {
+	users, user := emailUsers("test@mail.com", true)
+	svc, _ := newEmailService(t, users)
+
+	created, _, err := svc.CreatePersonalToken(context.Background(), signIn(t, svc, user.ID).AccessToken, "sheet", []string{ScopeRead}, 0)
+	require.NoError(t, err)
+	svc.personalTokens.(*mockPersonalTokenRepo).tokens[created.ID].UserID = "user-456"
+
+	err = svc.RevokePersonalToken(context.Background(), signIn(t, svc, user.ID).AccessToken, created.ID)
+	require.ErrorIs(t, err, ErrPersonalTokenNotFound)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"auth/internal/jwt"
+)
+
+// revocationOverlap is taken off AsOf so the next call also covers
+// revocations committed while this one read, and some clock skew between
+// instances and the database.
+const revocationOverlap = 5 * time.Second
+
+// Revocations lists what invalidated access tokens before they expire.
+type Revocations struct {
+	SessionIDs []string
+	UserIDs    []string
+
+	// AsOf is what to pass as since to the next call. Consecutive calls
+	// overlap, so an entry may be returned twice.
+	AsOf time.Time
+}
+
+// Revocations returns the sessions revoked and the users deleted since
+// since. Older ones are left out, since every token they could affect has
+// expired.
+func (s *AuthService) Revocations(ctx context.Context, since time.Time) (*Revocations, error) {
+	now := time.Now()
+	if oldest := now.Add(-jwt.AccessTTL); since.Before(oldest) {
+		since = oldest
+	}
+
+	sessions, err := s.tokens.RevokedSince(ctx, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.deletions.UsersDeletedSince(ctx, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Revocations{
+		SessionIDs: sessions,
+		UserIDs:    users,
+		AsOf:       now.Add(-revocationOverlap),
+	}, nil
+}
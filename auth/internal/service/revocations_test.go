@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocations(t *testing.T) {
+	svc, _ := newDeletionService(t, nil)
+
+	start := time.Now()
+
+	loggedOut := signIn(t, svc, "user-123")
+	signIn(t, svc, "user-456")
+	require.NoError(t, svc.Logout(context.Background(), loggedOut.RefreshToken))
+
+	deleted := signIn(t, svc, "user-789").AccessToken
+	_, err := svc.DeleteAccount(context.Background(), deleted, "password")
+	require.NoError(t, err)
+
+	// a zero since asks for everything that still matters
+	res, err := svc.Revocations(context.Background(), time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"session-user-123"}, res.SessionIDs)
+	require.Equal(t, []string{"user-789"}, res.UserIDs)
+	require.False(t, res.AsOf.Before(start.Add(-revocationOverlap)))
+
+	res, err = svc.Revocations(context.Background(), time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.Empty(t, res.SessionIDs)
+	require.Empty(t, res.UserIDs)
+}
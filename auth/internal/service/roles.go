@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"auth/internal/repository"
+	"auth/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+// Scopes only sessions get, through their role. Personal tokens cannot
+// be given them.
+const (
+	ScopeBackupRestore = "backup:restore"
+	ScopeAdmin         = "admin"
+)
+
+// roleScopes is what each role may do. The gateway enforces the same
+// table per route.
+var roleScopes = map[string][]string{
+	repository.RoleUser:     {ScopeRead, ScopeTransactionsWrite, ScopeBudgetsWrite, ScopeBackupRestore},
+	repository.RoleReadOnly: {ScopeRead},
+	repository.RoleAdmin:    {ScopeRead, ScopeTransactionsWrite, ScopeBudgetsWrite, ScopeBackupRestore, ScopeAdmin},
+}
+
+// roles is every role, in the order they are listed.
+var roles = []string{repository.RoleUser, repository.RoleReadOnly, repository.RoleAdmin}
+
+// ListUsers returns every user. It takes an admin's access token.
+func (s *AuthService) ListUsers(ctx context.Context, accessToken string) ([]repository.User, error) {
+	if _, err := s.admin(ctx, accessToken); err != nil {
+		return nil, err
+	}
+	return s.users.List(ctx)
+}
+
+// SetUserRole changes the role of a user and signs them out, so the new
+// role applies at once. It takes an admin's access token; admins cannot
+// change their own role and so always leave one admin.
+func (s *AuthService) SetUserRole(ctx context.Context, accessToken, userID, role string) (*repository.User, error) {
+	admin, err := s.admin(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(roles, role) {
+		return nil, &validation.Error{Fields: []validation.FieldError{{
+			Field:   "role",
+			Message: "must be one of " + strings.Join(roles, ", "),
+		}}}
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	if userID == admin.ID {
+		return nil, ErrOwnRole
+	}
+
+	if err := s.users.SetRole(ctx, userID, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if err := s.tokens.RevokeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.users.GetByID(ctx, userID)
+}
+
+// UserStats counts users. It takes an admin's access token.
+func (s *AuthService) UserStats(ctx context.Context, accessToken string) (*repository.UserStats, error) {
+	if _, err := s.admin(ctx, accessToken); err != nil {
+		return nil, err
+	}
+	return s.users.Stats(ctx)
+}
+
+// admin returns the user of the access token when they are an admin.
+func (s *AuthService) admin(ctx context.Context, accessToken string) (*repository.User, error) {
+	user, err := s.Authenticate(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != repository.RoleAdmin {
+		return nil, ErrNotAdmin
+	}
+	return user, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"auth/internal/domain"
+	"auth/internal/repository"
+	"auth/internal/validation"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAdminID = "0b7d8c1e-5f4a-4c2b-9e6d-1a2b3c4d5e6f"
+	testUserID  = "6f5e4d3c-2b1a-4d9e-8b2c-4a5f1e8c7d0b"
+)
+
+// roleUsers keeps an admin and a user in memory.
+func roleUsers() (*mockUserRepo, map[string]*repository.User) {
+	users := map[string]*repository.User{
+		testAdminID: {ID: testAdminID, Email: "admin@mail.com", Role: repository.RoleAdmin},
+		testUserID:  {ID: testUserID, Email: "user@mail.com", Role: repository.RoleUser},
+	}
+
+	return &mockUserRepo{
+		getByID: func(ctx context.Context, id string) (*repository.User, error) {
+			u, ok := users[id]
+			if !ok {
+				return nil, sql.ErrNoRows
+			}
+			c := *u
+			return &c, nil
+		},
+		setRole: func(ctx context.Context, id, role string) error {
+			u, ok := users[id]
+			if !ok {
+				return sql.ErrNoRows
+			}
+			u.Role = role
+			return nil
+		},
+	}, users
+}
+
+func TestSetUserRole(t *testing.T) {
+	repo, users := roleUsers()
+	svc, _ := newEmailService(t, repo)
+	admin := signIn(t, svc, testAdminID)
+	session := signIn(t, svc, testUserID)
+
+	user, err := svc.SetUserRole(context.Background(), admin.AccessToken, testUserID, repository.RoleReadOnly)
+	require.NoError(t, err)
+	require.Equal(t, repository.RoleReadOnly, user.Role)
+	require.Equal(t, repository.RoleReadOnly, users[testUserID].Role)
+
+	// the user signs in again to get the new role
+	_, err = svc.Validate(context.Background(), session.AccessToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	_, err = svc.Refresh(context.Background(), session.RefreshToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestSetUserRole_Rejected(t *testing.T) {
+	repo, _ := roleUsers()
+	svc, _ := newEmailService(t, repo)
+	admin := signIn(t, svc, testAdminID)
+	user := signIn(t, svc, testUserID)
+
+	_, err := svc.SetUserRole(context.Background(), user.AccessToken, testUserID, repository.RoleAdmin)
+	require.ErrorIs(t, err, ErrNotAdmin)
+
+	_, err = svc.SetUserRole(context.Background(), admin.AccessToken, testAdminID, repository.RoleUser)
+	require.ErrorIs(t, err, ErrOwnRole)
+
+	_, err = svc.SetUserRole(context.Background(), admin.AccessToken, "user-789", repository.RoleUser)
+	require.ErrorIs(t, err, ErrUserNotFound)
+	_, err = svc.SetUserRole(context.Background(), admin.AccessToken, "5d1c8e7a-3b2f-4a6d-9c0e-8f7b6a5d4c3b", repository.RoleUser)
+	require.ErrorIs(t, err, ErrUserNotFound)
+
+	var invalid *validation.Error
+	_, err = svc.SetUserRole(context.Background(), admin.AccessToken, testUserID, "root")
+	require.ErrorAs(t, err, &invalid)
+}
+
+func TestAdminOnly(t *testing.T) {
+	repo, _ := roleUsers()
+	svc, _ := newEmailService(t, repo)
+	admin := signIn(t, svc, testAdminID)
+	user := signIn(t, svc, testUserID)
+
+	_, err := svc.ListUsers(context.Background(), user.AccessToken)
+	require.ErrorIs(t, err, ErrNotAdmin)
+	_, err = svc.UserStats(context.Background(), user.AccessToken)
+	require.ErrorIs(t, err, ErrNotAdmin)
+
+	list, err := svc.ListUsers(context.Background(), admin.AccessToken)
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+
+	stats, err := svc.UserStats(context.Background(), admin.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Users)
+}
+
+func TestRefresh_CarriesCurrentRole(t *testing.T) {
+	repo, users := roleUsers()
+	svc, _ := newEmailService(t, repo)
+	session := signIn(t, svc, testUserID)
+
+	// e.g. changed in the database by hand
+	users[testUserID].Role = repository.RoleAdmin
+
+	tokens, err := svc.Refresh(context.Background(), session.RefreshToken)
+	require.NoError(t, err)
+
+	claims, err := svc.keys.Validate(tokens.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, repository.RoleAdmin, claims.Role)
+}
+
+func TestPersonalToken_LimitedByRole(t *testing.T) {
+	repo, users := roleUsers()
+	svc, _ := newEmailService(t, repo)
+	session := signIn(t, svc, testUserID)
+
+	_, token, err := svc.CreatePersonalToken(context.Background(), session.AccessToken, "script", []string{ScopeRead, ScopeBudgetsWrite}, 0)
+	require.NoError(t, err)
+
+	// personal tokens never get the scopes of sessions only
+	var invalid *validation.Error
+	_, _, err = svc.CreatePersonalToken(context.Background(), session.AccessToken, "restore", []string{ScopeBackupRestore}, 0)
+	require.ErrorAs(t, err, &invalid)
+
+	users[testUserID].Role = repository.RoleReadOnly
+
+	_, scopes, err := svc.ValidatePersonalToken(context.Background(), token)
+	require.NoError(t, err)
+	require.Equal(t, []string{ScopeRead}, scopes)
+
+	session = signIn(t, svc, testUserID)
+	_, _, err = svc.CreatePersonalToken(context.Background(), session.AccessToken, "script", []string{ScopeTransactionsWrite}, 0)
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, "scope transactions:write is not allowed for role read-only", invalid.Fields[0].Message)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// maxUserAgent is how much of a user agent is kept; the rest is cut off.
+const maxUserAgent = 512
+
+type userAgentKey struct{}
+
+// WithUserAgent returns a context carrying the user agent of the client
+// the request came from, to tell sessions apart.
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+// UserAgent returns the user agent set by WithUserAgent, or "".
+func UserAgent(ctx context.Context) string {
+	ua, _ := ctx.Value(userAgentKey{}).(string)
+	if len(ua) > maxUserAgent {
+		ua = ua[:maxUserAgent]
+	}
+	return ua
+}
+
+// startSession records a new session of the user on the client of the
+// request and issues its first tokens.
+func (s *AuthService) startSession(ctx context.Context, userID, role string) (*Tokens, error) {
+	now := time.Now().UTC()
+
+	session := repository.Session{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		UserAgent:  UserAgent(ctx),
+		IP:         ClientIP(ctx),
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, userID, role, session.ID)
+}
+
+// ListSessions returns the active sessions of the user of the access
+// token, most recently used first, and the ID of the token's own session.
+func (s *AuthService) ListSessions(ctx context.Context, accessToken string) ([]repository.Session, string, error) {
+	user, current, err := s.authenticateSession(ctx, accessToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessions, err := s.sessions.List(ctx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return sessions, current, nil
+}
+
+// RevokeSession signs the user of the access token out of one of their
+// sessions, the token's own included. Its refresh token stops working at
+// once and its access tokens once the gateway has seen the revocation.
+func (s *AuthService) RevokeSession(ctx context.Context, accessToken, id string) error {
+	userID, err := s.Validate(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrSessionNotFound
+	}
+
+	ok, err := s.sessions.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllOtherSessions signs the user of the access token out
+// everywhere but in the token's own session.
+func (s *AuthService) RevokeAllOtherSessions(ctx context.Context, accessToken string) error {
+	user, current, err := s.authenticateSession(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	return s.sessions.RevokeOthers(ctx, user.ID, current)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/domain"
+	"auth/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockSessionRepo revokes sessions in the refresh tokens, as the real one
+// does.
+type mockSessionRepo struct {
+	tokens   *mockTokenRepo
+	sessions map[string]*repository.Session
+}
+
+func newMockSessionRepo(tokens *mockTokenRepo) *mockSessionRepo {
+	return &mockSessionRepo{tokens: tokens, sessions: map[string]*repository.Session{}}
+}
+
+func (m *mockSessionRepo) Create(ctx context.Context, s repository.Session) error {
+	m.sessions[s.ID] = &s
+	return nil
+}
+
+func (m *mockSessionRepo) Touch(ctx context.Context, id, ip, userAgent string) error {
+	if s, ok := m.sessions[id]; ok {
+		s.LastUsedAt = time.Now()
+		s.IP = ip
+		s.UserAgent = userAgent
+	}
+	return nil
+}
+
+func (m *mockSessionRepo) List(ctx context.Context, userID string) ([]repository.Session, error) {
+	var res []repository.Session
+	for _, s := range m.sessions {
+		active, _ := m.tokens.FamilyActive(ctx, s.ID)
+		if s.UserID == userID && active {
+			res = append(res, *s)
+		}
+	}
+	slices.SortFunc(res, func(a, b repository.Session) int {
+		return b.LastUsedAt.Compare(a.LastUsedAt)
+	})
+	return res, nil
+}
+
+func (m *mockSessionRepo) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	s, ok := m.sessions[id]
+	if !ok || s.UserID != userID {
+		return false, nil
+	}
+	active, _ := m.tokens.FamilyActive(ctx, id)
+	if !active {
+		return false, nil
+	}
+	return true, m.tokens.RevokeFamily(ctx, id)
+}
+
+func (m *mockSessionRepo) RevokeOthers(ctx context.Context, userID, id string) error {
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.ID != id {
+			if err := m.tokens.RevokeFamily(ctx, s.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// signInFrom logs the user of passwordService in from a client.
+func signInFrom(t *testing.T, svc *AuthService, ip, userAgent string) *Tokens {
+	t.Helper()
+
+	ctx := WithUserAgent(WithClientIP(context.Background(), ip), userAgent)
+	tokens, err := svc.Login(ctx, "test@mail.com", "password")
+	require.NoError(t, err)
+	return tokens
+}
+
+func TestListSessions(t *testing.T) {
+	svc, _ := passwordService(t)
+
+	laptop := signInFrom(t, svc, "10.0.0.1", "Firefox")
+	phone := signInFrom(t, svc, "10.0.0.2", "Safari")
+
+	// using the laptop session moves it to the top
+	ctx := WithUserAgent(WithClientIP(context.Background(), "10.0.0.3"), "Firefox")
+	_, err := svc.Refresh(ctx, laptop.RefreshToken)
+	require.NoError(t, err)
+
+	sessions, current, err := svc.ListSessions(context.Background(), phone.AccessToken)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, "Firefox", sessions[0].UserAgent)
+	require.Equal(t, "10.0.0.3", sessions[0].IP)
+	require.Equal(t, "Safari", sessions[1].UserAgent)
+	require.Equal(t, sessions[1].ID, current)
+
+	_, _, err = svc.ListSessions(context.Background(), "invalid")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestRevokeSession(t *testing.T) {
+	svc, _ := passwordService(t)
+
+	laptop := signInFrom(t, svc, "10.0.0.1", "Firefox")
+	phone := signInFrom(t, svc, "10.0.0.2", "Safari")
+
+	sessions, _, err := svc.ListSessions(context.Background(), phone.AccessToken)
+	require.NoError(t, err)
+	idx := slices.IndexFunc(sessions, func(s repository.Session) bool { return s.UserAgent == "Firefox" })
+	require.GreaterOrEqual(t, idx, 0)
+
+	require.NoError(t, svc.RevokeSession(context.Background(), phone.AccessToken, sessions[idx].ID))
+
+	// the laptop is signed out, the phone is not
+	_, err = svc.Validate(context.Background(), laptop.AccessToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	_, err = svc.Refresh(context.Background(), laptop.RefreshToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+	_, err = svc.Validate(context.Background(), phone.AccessToken)
+	require.NoError(t, err)
+
+	err = svc.RevokeSession(context.Background(), phone.AccessToken, sessions[idx].ID)
+	require.ErrorIs(t, err, ErrSessionNotFound)
+	err = svc.RevokeSession(context.Background(), phone.AccessToken, "not-a-uuid")
+	require.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestRevokeSession_OtherUser(t *testing.T) {
+	svc := newTokenService(t)
+
+	own, err := svc.startSession(context.Background(), "user-123", repository.RoleUser)
+	require.NoError(t, err)
+	other, err := svc.startSession(context.Background(), "user-456", repository.RoleUser)
+	require.NoError(t, err)
+
+	sessions, _, err := svc.ListSessions(context.Background(), other.AccessToken)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	err = svc.RevokeSession(context.Background(), own.AccessToken, sessions[0].ID)
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	_, err = svc.Validate(context.Background(), other.AccessToken)
+	require.NoError(t, err)
+}
+
+func TestRevokeAllOtherSessions(t *testing.T) {
+	svc, _ := passwordService(t)
+
+	first := signInFrom(t, svc, "10.0.0.1", "Firefox")
+	second := signInFrom(t, svc, "10.0.0.2", "Safari")
+	current := signInFrom(t, svc, "10.0.0.3", "curl/8.5")
+
+	require.NoError(t, svc.RevokeAllOtherSessions(context.Background(), current.AccessToken))
+
+	for _, tokens := range []*Tokens{first, second} {
+		_, err := svc.Validate(context.Background(), tokens.AccessToken)
+		require.ErrorIs(t, err, domain.ErrInvalidToken)
+	}
+
+	sessions, _, err := svc.ListSessions(context.Background(), current.AccessToken)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, "curl/8.5", sessions[0].UserAgent)
+}
+
+func TestUserAgent_Truncated(t *testing.T) {
+	ctx := WithUserAgent(context.Background(), strings.Repeat("a", 1000))
+	require.Len(t, UserAgent(ctx), maxUserAgent)
+	require.Empty(t, UserAgent(context.Background()))
+}
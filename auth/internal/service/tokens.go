@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"auth/internal/domain"
+	"auth/internal/jwt"
+	"auth/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// refreshTTL is how long a refresh token may be used. Every refresh
+// issues a new token, so a session lasts while it is used at least this
+// often.
+const refreshTTL = 30 * 24 * time.Hour
+
+// Tokens is what a sign-in or refresh returns.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+
+	// Challenge is returned instead of the tokens when the password was
+	// right but a second factor is needed. LoginTwoFactor takes it within
+	// ExpiresIn.
+	Challenge string
+}
+
+// Refresh exchanges a refresh token for new tokens of the same session.
+// Each refresh token works once; presenting one again means it was copied,
+// so the whole session is revoked.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	t, err := s.tokens.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.RevokedAt != nil || time.Now().After(t.ExpiresAt) {
+		return nil, domain.ErrInvalidToken
+	}
+
+	ok := false
+	if t.UsedAt == nil {
+		ok, err = s.tokens.Use(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		log.Printf("refresh token reused, revoking session %s of user %s", t.FamilyID, t.UserID)
+		if err := s.tokens.RevokeFamily(ctx, t.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+
+	// the new access token carries the user's current role
+	user, err := s.users.GetByID(ctx, t.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	if err := s.sessions.Touch(ctx, t.FamilyID, ClientIP(ctx), UserAgent(ctx)); err != nil {
+		log.Printf("record use of session %s: %v", t.FamilyID, err)
+	}
+
+	return s.issue(ctx, user.ID, user.Role, t.FamilyID)
+}
+
+// Logout revokes the session the refresh token belongs to, together with
+// its access tokens. Unknown tokens are ignored.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	t, err := s.tokens.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil || t == nil {
+		return err
+	}
+	return s.tokens.RevokeFamily(ctx, t.FamilyID)
+}
+
+// issue stores a new refresh token of the family and signs an access
+// token for it.
+func (s *AuthService) issue(ctx context.Context, userID, role, familyID string) (*Tokens, error) {
+	refresh, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokens.Create(ctx, repository.RefreshToken{
+		ID:        uuid.NewString(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: time.Now().Add(refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.keys.Generate(userID, familyID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    jwt.AccessTTL,
+	}, nil
+}
+
+// newSecret returns a random token for a user to present later.
+func newSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
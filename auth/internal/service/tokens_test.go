@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/domain"
+	"auth/internal/jwt"
+	"auth/internal/repository"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockTokenRepo struct {
+	tokens map[string]*repository.RefreshToken
+}
+
+func newMockTokenRepo() *mockTokenRepo {
+	return &mockTokenRepo{tokens: map[string]*repository.RefreshToken{}}
+}
+
+func (m *mockTokenRepo) Create(ctx context.Context, t repository.RefreshToken) error {
+	m.tokens[t.TokenHash] = &t
+	return nil
+}
+
+func (m *mockTokenRepo) GetByHash(ctx context.Context, hash string) (*repository.RefreshToken, error) {
+	t, ok := m.tokens[hash]
+	if !ok {
+		return nil, nil
+	}
+	c := *t
+	return &c, nil
+}
+
+func (m *mockTokenRepo) Use(ctx context.Context, id string) (bool, error) {
+	for _, t := range m.tokens {
+		if t.ID == id && t.UsedAt == nil && t.RevokedAt == nil {
+			now := time.Now()
+			t.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockTokenRepo) RevokeUser(ctx context.Context, userID string) error {
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockTokenRepo) RevokedSince(ctx context.Context, since time.Time) ([]string, error) {
+	seen := map[string]bool{}
+	var res []string
+	for _, t := range m.tokens {
+		if t.RevokedAt != nil && !t.RevokedAt.Before(since) && !seen[t.FamilyID] {
+			seen[t.FamilyID] = true
+			res = append(res, t.FamilyID)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockTokenRepo) FamilyActive(ctx context.Context, familyID string) (bool, error) {
+	for _, t := range m.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil && time.Now().Before(t.ExpiresAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// signIn starts a session for the user and returns its tokens.
+func signIn(t *testing.T, svc *AuthService, userID string) *Tokens {
+	t.Helper()
+
+	tokens, err := svc.issue(context.Background(), userID, repository.RoleUser, "session-"+userID)
+	require.NoError(t, err)
+	return tokens
+}
+
+func newTokenService(t *testing.T) *AuthService {
+	users := &mockUserRepo{
+		getByID: func(ctx context.Context, id string) (*repository.User, error) {
+			return &repository.User{ID: id}, nil
+		},
+	}
+	return newService(t, users, nil, nil)
+}
+
+func TestRefresh_Rotates(t *testing.T) {
+	svc := newTokenService(t)
+	first := signIn(t, svc, "user-123")
+
+	second, err := svc.Refresh(context.Background(), first.RefreshToken)
+	require.NoError(t, err)
+	require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	require.Equal(t, jwt.AccessTTL, second.ExpiresIn)
+
+	userID, err := svc.Validate(context.Background(), second.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, "user-123", userID)
+
+	_, err = svc.Refresh(context.Background(), "unknown")
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	svc := newTokenService(t)
+	first := signIn(t, svc, "user-123")
+
+	second, err := svc.Refresh(context.Background(), first.RefreshToken)
+	require.NoError(t, err)
+
+	// the first token was copied and is presented again
+	_, err = svc.Refresh(context.Background(), first.RefreshToken)
+	require.ErrorIs(t, err, ErrTokenReused)
+
+	_, err = svc.Refresh(context.Background(), second.RefreshToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	_, err = svc.Validate(context.Background(), second.AccessToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+}
+
+func TestLogout_RevokesSession(t *testing.T) {
+	svc := newTokenService(t)
+	session := signIn(t, svc, "user-123")
+	other := signIn(t, svc, "user-456")
+
+	require.NoError(t, svc.Logout(context.Background(), session.RefreshToken))
+	require.NoError(t, svc.Logout(context.Background(), "unknown"))
+
+	_, err := svc.Validate(context.Background(), session.AccessToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	_, err = svc.Refresh(context.Background(), session.RefreshToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+
+	_, err = svc.Validate(context.Background(), other.AccessToken)
+	require.NoError(t, err)
+}
+
+func TestRefresh_Expired(t *testing.T) {
+	svc := newTokenService(t)
+	session := signIn(t, svc, "user-123")
+
+	for _, tok := range svc.tokens.(*mockTokenRepo).tokens {
+		tok.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+
+	_, err := svc.Refresh(context.Background(), session.RefreshToken)
+	require.ErrorIs(t, err, domain.ErrInvalidToken)
+}
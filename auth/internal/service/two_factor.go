@@ -0,0 +1,307 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"image/png"
+	"strings"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpIssuer = "GoFinance"
+	totpPeriod = 30 * time.Second
+
+	// totpSkew is how many time steps a code may be off either way, for
+	// clocks that drift.
+	totpSkew = 1
+
+	// challengeTTL is how long a login may wait for its second factor.
+	challengeTTL         = 5 * time.Minute
+	challengeMaxAttempts = 5
+
+	recoveryCodeCount = 10
+)
+
+// TOTPEnrollment is a new authenticator secret, to be confirmed with
+// ConfirmTOTP.
+type TOTPEnrollment struct {
+	Secret string
+
+	// URI is the otpauth:// URI authenticator apps import.
+	URI string
+
+	// QRCode is a PNG of URI.
+	QRCode []byte
+}
+
+// EnrollTOTP creates an authenticator secret for the user the token
+// belongs to. Sign-in asks for codes only once the secret is confirmed;
+// enrolling again before that replaces the secret.
+func (s *AuthService) EnrollTOTP(ctx context.Context, token string) (*TOTPEnrollment, error) {
+	user, err := s.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.twoFactor.SetPendingTOTP(ctx, user.ID, key.Secret())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return nil, err
+	}
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, img); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: qr.Bytes(),
+	}, nil
+}
+
+// ConfirmTOTP turns two-factor authentication on with a first code from
+// the authenticator and returns the user's recovery codes. They are not
+// shown again.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, token, code string) ([]string, error) {
+	user, err := s.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.twoFactor.GetTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if t.ConfirmedAt != nil {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := matchTOTP(t.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactor.ConfirmTOTP(ctx, user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off. It takes a code from
+// the authenticator or a recovery code.
+func (s *AuthService) DisableTOTP(ctx context.Context, token, code string) error {
+	user, err := s.Authenticate(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkSecondFactor(ctx, user.ID, code); err != nil {
+		return err
+	}
+	return s.twoFactor.DeleteTOTP(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes. It takes a
+// code from the authenticator or a recovery code.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, token, code string) ([]string, error) {
+	user, err := s.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSecondFactor(ctx, user.ID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactor.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// LoginTwoFactor completes a login that returned a challenge, with a code
+// from the authenticator or a recovery code. Wrong codes count against
+// the account like wrong passwords.
+func (s *AuthService) LoginTwoFactor(ctx context.Context, challenge, code string) (*Tokens, error) {
+	c, err := s.twoFactor.AttemptChallenge(ctx, hashToken(challenge), challengeMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.users.GetByID(ctx, c.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.guard.Allow(ctx, user.Email, ClientIP(ctx)); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSecondFactor(ctx, c.UserID, code); err != nil {
+		if errors.Is(err, ErrInvalidCode) {
+			s.failAttempt(ctx, user.ID, user.Email, repository.TwoFactorFailed)
+		}
+		return nil, err
+	}
+
+	ok, err := s.twoFactor.CompleteChallenge(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidChallenge
+	}
+
+	return s.loggedIn(ctx, user)
+}
+
+// challenge starts a login that waits for the second factor, or returns
+// nil when the user has no confirmed authenticator.
+func (s *AuthService) challenge(ctx context.Context, userID string) (*Tokens, error) {
+	t, err := s.twoFactor.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.ConfirmedAt == nil {
+		return nil, nil
+	}
+
+	token, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.twoFactor.CreateChallenge(ctx, repository.LoginChallenge{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(challengeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{Challenge: token, ExpiresIn: challengeTTL}, nil
+}
+
+// checkSecondFactor accepts a current authenticator code that has not
+// been used yet, or an unused recovery code.
+func (s *AuthService) checkSecondFactor(ctx context.Context, userID, code string) error {
+	t, err := s.twoFactor.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if t == nil || t.ConfirmedAt == nil {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	if step, ok := matchTOTP(t.Secret, code, time.Now()); ok {
+		ok, err := s.twoFactor.UseStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+
+	ok, err := s.twoFactor.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// matchTOTP returns the time step of the code when it is valid at now.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		return 0, false
+	}
+
+	step := now.Unix() / int64(totpPeriod/time.Second)
+	for skew := -totpSkew; skew <= totpSkew; skew++ {
+		at := now.Add(time.Duration(skew) * totpPeriod)
+		want, err := totp.GenerateCode(secret, at)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step + int64(skew), true
+		}
+	}
+	return 0, false
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns codes like "abcde-fghij" and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		c := strings.ToLower(recoveryEncoding.EncodeToString(raw))[:10]
+		codes[i] = c[:5] + "-" + c[5:]
+		hashes[i] = hashToken(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode forgives case and spacing of a typed code.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.Join(strings.Fields(code), ""))
+	if len(code) == 10 && !strings.Contains(code, "-") {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/repository"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTwoFactorRepo struct {
+	totp       map[string]*repository.TOTP
+	lastStep   map[string]int64
+	recovery   map[string]map[string]bool
+	challenges map[string]*repository.LoginChallenge
+	attempts   map[string]int
+	completed  map[string]bool
+}
+
+func newMockTwoFactorRepo() *mockTwoFactorRepo {
+	return &mockTwoFactorRepo{
+		totp:       map[string]*repository.TOTP{},
+		lastStep:   map[string]int64{},
+		recovery:   map[string]map[string]bool{},
+		challenges: map[string]*repository.LoginChallenge{},
+		attempts:   map[string]int{},
+		completed:  map[string]bool{},
+	}
+}
+
+func (m *mockTwoFactorRepo) GetTOTP(ctx context.Context, userID string) (*repository.TOTP, error) {
+	t, ok := m.totp[userID]
+	if !ok {
+		return nil, nil
+	}
+	c := *t
+	return &c, nil
+}
+
+func (m *mockTwoFactorRepo) SetPendingTOTP(ctx context.Context, userID, secret string) (bool, error) {
+	if t, ok := m.totp[userID]; ok && t.ConfirmedAt != nil {
+		return false, nil
+	}
+	m.totp[userID] = &repository.TOTP{UserID: userID, Secret: secret}
+	return true, nil
+}
+
+func (m *mockTwoFactorRepo) ConfirmTOTP(ctx context.Context, userID string, step int64, hashes []string) error {
+	now := time.Now()
+	m.totp[userID].ConfirmedAt = &now
+	m.lastStep[userID] = step
+	return m.ReplaceRecoveryCodes(ctx, userID, hashes)
+}
+
+func (m *mockTwoFactorRepo) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	if last, ok := m.lastStep[userID]; ok && last >= step {
+		return false, nil
+	}
+	m.lastStep[userID] = step
+	return true, nil
+}
+
+func (m *mockTwoFactorRepo) DeleteTOTP(ctx context.Context, userID string) error {
+	delete(m.totp, userID)
+	delete(m.recovery, userID)
+	return nil
+}
+
+func (m *mockTwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	m.recovery[userID] = map[string]bool{}
+	for _, h := range hashes {
+		m.recovery[userID][h] = true
+	}
+	return nil
+}
+
+func (m *mockTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	if !m.recovery[userID][hash] {
+		return false, nil
+	}
+	m.recovery[userID][hash] = false
+	return true, nil
+}
+
+func (m *mockTwoFactorRepo) CreateChallenge(ctx context.Context, c repository.LoginChallenge) error {
+	m.challenges[c.TokenHash] = &c
+	return nil
+}
+
+func (m *mockTwoFactorRepo) AttemptChallenge(ctx context.Context, hash string, maxAttempts int) (*repository.LoginChallenge, error) {
+	c, ok := m.challenges[hash]
+	if !ok || m.completed[c.ID] || time.Now().After(c.ExpiresAt) || m.attempts[c.ID] >= maxAttempts {
+		return nil, nil
+	}
+	m.attempts[c.ID]++
+	cc := *c
+	return &cc, nil
+}
+
+func (m *mockTwoFactorRepo) CompleteChallenge(ctx context.Context, id string) (bool, error) {
+	if m.completed[id] {
+		return false, nil
+	}
+	m.completed[id] = true
+	return true, nil
+}
+
+// enrolled returns a service whose user has confirmed an authenticator,
+// the user's secret and recovery codes.
+func enrolled(t *testing.T) (*AuthService, string, []string) {
+	t.Helper()
+
+	users, user := emailUsers("test@mail.com", true)
+	hash, err := testHasher.Hash("password")
+	require.NoError(t, err)
+	user.PasswordHash = hash
+
+	svc, _ := newEmailService(t, users)
+	session := signIn(t, svc, user.ID)
+
+	enrollment, err := svc.EnrollTOTP(context.Background(), session.AccessToken)
+	require.NoError(t, err)
+
+	// the code confirming the secret is from the previous step, so the
+	// current one can sign in
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now().Add(-totpPeriod))
+	require.NoError(t, err)
+
+	codes, err := svc.ConfirmTOTP(context.Background(), session.AccessToken, code)
+	require.NoError(t, err)
+
+	return svc, enrollment.Secret, codes
+}
+
+func login(t *testing.T, svc *AuthService) string {
+	t.Helper()
+
+	res, err := svc.Login(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+	require.Empty(t, res.AccessToken)
+	require.NotEmpty(t, res.Challenge)
+	require.Equal(t, challengeTTL, res.ExpiresIn)
+	return res.Challenge
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	users, user := emailUsers("test@mail.com", true)
+	hash, err := testHasher.Hash("password")
+	require.NoError(t, err)
+	user.PasswordHash = hash
+
+	svc, _ := newEmailService(t, users)
+	session := signIn(t, svc, user.ID)
+
+	enrollment, err := svc.EnrollTOTP(context.Background(), session.AccessToken)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/GoFinance:test@mail.com?"))
+	require.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+	require.True(t, bytes.HasPrefix(enrollment.QRCode, []byte("\x89PNG")))
+
+	// until confirmed, sign-in takes the password only
+	res, err := svc.Login(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+	require.NotEmpty(t, res.AccessToken)
+
+	_, err = svc.ConfirmTOTP(context.Background(), session.AccessToken, "000000")
+	require.ErrorIs(t, err, ErrInvalidCode)
+
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+	codes, err := svc.ConfirmTOTP(context.Background(), session.AccessToken, code)
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	_, err = svc.EnrollTOTP(context.Background(), session.AccessToken)
+	require.ErrorIs(t, err, ErrTwoFactorEnabled)
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	svc, secret, _ := enrolled(t)
+
+	challenge := login(t, svc)
+
+	// the code that confirmed the secret is spent
+	old, err := totp.GenerateCode(secret, time.Now().Add(-totpPeriod))
+	require.NoError(t, err)
+	_, err = svc.LoginTwoFactor(context.Background(), challenge, old)
+	require.ErrorIs(t, err, ErrInvalidCode)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	tokens, err := svc.LoginTwoFactor(context.Background(), challenge, code)
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.AccessToken)
+
+	// neither the challenge nor the code work twice
+	_, err = svc.LoginTwoFactor(context.Background(), challenge, code)
+	require.ErrorIs(t, err, ErrInvalidChallenge)
+	_, err = svc.LoginTwoFactor(context.Background(), login(t, svc), code)
+	require.ErrorIs(t, err, ErrInvalidCode)
+}
+
+func TestLoginTwoFactor_RecoveryCode(t *testing.T) {
+	svc, _, codes := enrolled(t)
+
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+	_, err := svc.LoginTwoFactor(context.Background(), login(t, svc), typed)
+	require.NoError(t, err)
+
+	_, err = svc.LoginTwoFactor(context.Background(), login(t, svc), codes[0])
+	require.ErrorIs(t, err, ErrInvalidCode)
+}
+
+func TestLoginTwoFactor_LimitsAttempts(t *testing.T) {
+	svc, secret, _ := enrolled(t)
+	challenge := login(t, svc)
+
+	for range challengeMaxAttempts {
+		_, err := svc.LoginTwoFactor(context.Background(), challenge, "000000")
+		require.ErrorIs(t, err, ErrInvalidCode)
+
+		// so the account throttling does not step in first
+		require.NoError(t, svc.guard.Reset(context.Background(), "test@mail.com"))
+	}
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	_, err = svc.LoginTwoFactor(context.Background(), challenge, code)
+	require.ErrorIs(t, err, ErrInvalidChallenge)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	svc, _, codes := enrolled(t)
+	session := signIn(t, svc, "user-123")
+
+	require.ErrorIs(t, svc.DisableTOTP(context.Background(), session.AccessToken, "000000"), ErrInvalidCode)
+	require.NoError(t, svc.DisableTOTP(context.Background(), session.AccessToken, codes[1]))
+
+	res, err := svc.Login(context.Background(), "test@mail.com", "password")
+	require.NoError(t, err)
+	require.NotEmpty(t, res.AccessToken)
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	svc, secret, old := enrolled(t)
+	session := signIn(t, svc, "user-123")
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	codes, err := svc.RegenerateRecoveryCodes(context.Background(), session.AccessToken, code)
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	_, err = svc.LoginTwoFactor(context.Background(), login(t, svc), old[2])
+	require.ErrorIs(t, err, ErrInvalidCode)
+	_, err = svc.LoginTwoFactor(context.Background(), login(t, svc), codes[2])
+	require.NoError(t, err)
+}
+
+func TestMatchTOTP(t *testing.T) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: totpIssuer, AccountName: "test@mail.com"})
+	require.NoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	step := now.Unix() / 30
+
+	for skew, ok := range map[int]bool{-2: false, -1: true, 0: true, 1: true, 2: false} {
+		code, err := totp.GenerateCode(key.Secret(), now.Add(time.Duration(skew)*totpPeriod))
+		require.NoError(t, err)
+
+		got, matched := matchTOTP(key.Secret(), code, now)
+		require.Equal(t, ok, matched, "skew %d", skew)
+		if ok {
+			require.Equal(t, step+int64(skew), got)
+		}
+	}
+}
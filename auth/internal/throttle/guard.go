@@ -0,0 +1,207 @@
+// Package throttle slows down and locks out repeated failed sign-ins, per
+// account and per client IP, and registrations per client IP.
+package throttle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Policy is how a key is throttled. After Free failures within Window
+// every failure blocks the key for Delay, doubling up to MaxDelay; the
+// LockAfter-th failure locks it out for Lockout.
+type Policy struct {
+	Window    time.Duration
+	Free      int
+	Delay     time.Duration
+	MaxDelay  time.Duration
+	LockAfter int
+	Lockout   time.Duration
+}
+
+var (
+	// AccountPolicy guards a single account against password guessing.
+	AccountPolicy = Policy{
+		Window:    15 * time.Minute,
+		Free:      3,
+		Delay:     time.Second,
+		MaxDelay:  time.Minute,
+		LockAfter: 10,
+		Lockout:   15 * time.Minute,
+	}
+
+	// IPPolicy guards against one client trying many accounts. It is
+	// looser, since users behind a NAT share an address.
+	IPPolicy = Policy{
+		Window:    time.Hour,
+		Free:      20,
+		Delay:     time.Second,
+		MaxDelay:  time.Minute,
+		LockAfter: 100,
+		Lockout:   time.Hour,
+	}
+
+	// RegisterPolicy limits the accounts one client signs up. It is kept
+	// apart from IPPolicy, so sign-ups behind a NAT do not block sign-ins.
+	RegisterPolicy = Policy{
+		Window:    time.Hour,
+		Free:      10,
+		Delay:     time.Second,
+		MaxDelay:  time.Minute,
+		LockAfter: 50,
+		Lockout:   time.Hour,
+	}
+)
+
+// block returns how long the key is blocked after its n-th failure.
+func (p Policy) block(n int) time.Duration {
+	if n >= p.LockAfter {
+		return p.Lockout
+	}
+	if n <= p.Free {
+		return 0
+	}
+
+	d := p.Delay
+	for range n - p.Free - 1 {
+		d *= 2
+		if d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return d
+}
+
+// LockedError rejects an attempt while the account or the client is
+// blocked.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Guard tracks failed attempts by account and by client IP, and
+// registrations by client IP.
+type Guard struct {
+	store    Store
+	account  Policy
+	ip       Policy
+	register Policy
+}
+
+func NewGuard(store Store) *Guard {
+	return &Guard{
+		store:    store,
+		account:  AccountPolicy,
+		ip:       IPPolicy,
+		register: RegisterPolicy,
+	}
+}
+
+// Allow returns a *LockedError while the account or ip is blocked. Either
+// may be empty.
+func (g *Guard) Allow(ctx context.Context, account, ip string) error {
+	return g.allow(ctx, g.keys(account, ip))
+}
+
+// Fail records a failed attempt. It reports whether the account or ip is
+// now locked out.
+func (g *Guard) Fail(ctx context.Context, account, ip string) (bool, error) {
+	return g.fail(ctx, g.keys(account, ip))
+}
+
+// AllowRegister returns a *LockedError while ip has signed up too many
+// accounts. An empty ip is always allowed.
+func (g *Guard) AllowRegister(ctx context.Context, ip string) error {
+	return g.allow(ctx, registerKeys(ip))
+}
+
+// Register records a registration from ip.
+func (g *Guard) Register(ctx context.Context, ip string) error {
+	_, err := g.fail(ctx, registerKeys(ip))
+	return err
+}
+
+func (g *Guard) allow(ctx context.Context, keys []string) error {
+	var wait time.Duration
+	for _, key := range keys {
+		d, err := g.store.Locked(ctx, key)
+		if err != nil {
+			return err
+		}
+		wait = max(wait, d)
+	}
+
+	if wait > 0 {
+		return &LockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (g *Guard) fail(ctx context.Context, keys []string) (bool, error) {
+	lockedOut := false
+	for _, key := range keys {
+		p := g.policy(key)
+
+		n, err := g.store.Fail(ctx, key, p.Window)
+		if err != nil {
+			return false, err
+		}
+
+		if d := p.block(n); d > 0 {
+			if err := g.store.Lock(ctx, key, d); err != nil {
+				return false, err
+			}
+		}
+		lockedOut = lockedOut || n == p.LockAfter
+	}
+	return lockedOut, nil
+}
+
+// Reset clears the failures of an account after a successful sign-in.
+// Those of the IP stay, or one known password would let a client keep
+// guessing others.
+func (g *Guard) Reset(ctx context.Context, account string) error {
+	return g.store.Reset(ctx, accountKey(account))
+}
+
+func (g *Guard) keys(account, ip string) []string {
+	var keys []string
+	if account != "" {
+		keys = append(keys, accountKey(account))
+	}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
+
+func registerKeys(ip string) []string {
+	if ip == "" {
+		return nil
+	}
+	return []string{"register:" + ip}
+}
+
+func (g *Guard) policy(key string) Policy {
+	switch {
+	case strings.HasPrefix(key, "ip:"):
+		return g.ip
+	case strings.HasPrefix(key, "register:"):
+		return g.register
+	}
+	return g.account
+}
+
+// accountKey hashes the email, so stores hold no addresses. Unknown
+// emails are tracked like known ones and lock the same way.
+func accountKey(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return "account:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Block(t *testing.T) {
+	for n, want := range map[int]time.Duration{
+		1:  0,
+		3:  0,
+		4:  time.Second,
+		5:  2 * time.Second,
+		9:  32 * time.Second,
+		10: 15 * time.Minute,
+	} {
+		require.Equal(t, want, AccountPolicy.block(n), "failure %d", n)
+	}
+
+	// the delay is capped
+	require.Equal(t, time.Minute, IPPolicy.block(99))
+}
+
+func TestGuard_LocksOutAccount(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	guard := NewGuard(store)
+	ctx := context.Background()
+
+	for n := 1; n < AccountPolicy.LockAfter; n++ {
+		require.NoError(t, guard.Allow(ctx, "test@mail.com", "10.0.0.1"))
+		lockedOut, err := guard.Fail(ctx, "test@mail.com", "10.0.0.1")
+		require.NoError(t, err)
+		require.False(t, lockedOut)
+
+		// wait out the delay
+		now = now.Add(AccountPolicy.block(n))
+	}
+
+	lockedOut, err := guard.Fail(ctx, "Test@Mail.com", "10.0.0.1")
+	require.NoError(t, err)
+	require.True(t, lockedOut)
+
+	var locked *LockedError
+	require.ErrorAs(t, guard.Allow(ctx, "test@mail.com", ""), &locked)
+	require.Equal(t, AccountPolicy.Lockout, locked.RetryAfter)
+
+	// other accounts from the same address are not affected
+	require.NoError(t, guard.Allow(ctx, "other@mail.com", "10.0.0.1"))
+
+	now = now.Add(AccountPolicy.Lockout)
+	require.NoError(t, guard.Allow(ctx, "test@mail.com", "10.0.0.1"))
+}
+
+func TestGuard_DelaysAfterFreeFailures(t *testing.T) {
+	guard := NewGuard(NewMemoryStore())
+	ctx := context.Background()
+
+	for range AccountPolicy.Free {
+		_, err := guard.Fail(ctx, "test@mail.com", "")
+		require.NoError(t, err)
+	}
+	require.NoError(t, guard.Allow(ctx, "test@mail.com", ""))
+
+	_, err := guard.Fail(ctx, "test@mail.com", "")
+	require.NoError(t, err)
+
+	var locked *LockedError
+	require.ErrorAs(t, guard.Allow(ctx, "test@mail.com", ""), &locked)
+	require.LessOrEqual(t, locked.RetryAfter, AccountPolicy.Delay)
+}
+
+func TestGuard_ResetKeepsIPFailures(t *testing.T) {
+	guard := NewGuard(NewMemoryStore())
+	ctx := context.Background()
+
+	for range IPPolicy.Free + 1 {
+		_, err := guard.Fail(ctx, "", "10.0.0.1")
+		require.NoError(t, err)
+	}
+	_, err := guard.Fail(ctx, "test@mail.com", "")
+	require.NoError(t, err)
+
+	require.NoError(t, guard.Reset(ctx, "test@mail.com"))
+
+	require.NoError(t, guard.Allow(ctx, "test@mail.com", ""))
+	require.Error(t, guard.Allow(ctx, "test@mail.com", "10.0.0.1"))
+}
+
+func TestMemoryStore_WindowExpires(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		n, err := store.Fail(ctx, "key", time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, want, n)
+	}
+
+	now = now.Add(time.Minute)
+	n, err := store.Fail(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestGuard_RegisterKeptApart(t *testing.T) {
+	guard := NewGuard(NewMemoryStore())
+	ctx := context.Background()
+
+	for range RegisterPolicy.Free + 1 {
+		require.NoError(t, guard.Register(ctx, "10.0.0.1"))
+	}
+
+	var locked *LockedError
+	require.ErrorAs(t, guard.AllowRegister(ctx, "10.0.0.1"), &locked)
+	require.NoError(t, guard.Allow(ctx, "test@mail.com", "10.0.0.1"))
+	require.NoError(t, guard.AllowRegister(ctx, ""))
+}
@@ -0,0 +1,87 @@
+package throttle
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore shares the counts between instances. While Redis fails it
+// counts in memory instead, so an outage does not turn the limits off.
+type RedisStore struct {
+	client   *redis.Client
+	fallback *MemoryStore
+
+	// down is set while Redis calls fail, to log the outage once
+	down atomic.Bool
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{
+		client:   client,
+		fallback: NewMemoryStore(),
+	}
+}
+
+func (s *RedisStore) Fail(ctx context.Context, key string, window time.Duration) (int, error) {
+	// the window starts with the first failure
+	pipe := s.client.TxPipeline()
+	n := pipe.Incr(ctx, failuresKey(key))
+	pipe.ExpireNX(ctx, failuresKey(key), window)
+	_, err := pipe.Exec(ctx)
+	if s.failed(err) {
+		return s.fallback.Fail(ctx, key, window)
+	}
+	return int(n.Val()), nil
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	err := s.client.Set(ctx, lockKey(key), 1, d).Err()
+	if s.failed(err) {
+		return s.fallback.Lock(ctx, key, d)
+	}
+	return nil
+}
+
+func (s *RedisStore) Locked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, lockKey(key)).Result()
+	if s.failed(err) {
+		return s.fallback.Locked(ctx, key)
+	}
+	// PTTL is negative for missing keys
+	return max(ttl, 0), nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	err := s.client.Del(ctx, failuresKey(key), lockKey(key)).Err()
+	if s.failed(err) {
+		return s.fallback.Reset(ctx, key)
+	}
+	return nil
+}
+
+// failed reports whether the Redis call failed and logs when Redis goes
+// down or comes back.
+func (s *RedisStore) failed(err error) bool {
+	if err != nil {
+		if !s.down.Swap(true) {
+			log.Printf("login throttling falls back to memory: %v", err)
+		}
+		return true
+	}
+	if s.down.Swap(false) {
+		log.Println("login throttling uses redis again")
+	}
+	return false
+}
+
+func failuresKey(key string) string {
+	return "auth:throttle:failures:" + key
+}
+
+func lockKey(key string) string {
+	return "auth:throttle:lock:" + key
+}
@@ -0,0 +1,65 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	// no retries, so falling back does not wait for them
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisStore(client), mr
+}
+
+func TestRedisStore(t *testing.T) {
+	store, mr := newRedisStore(t)
+	ctx := context.Background()
+
+	for want := 1; want <= 2; want++ {
+		n, err := store.Fail(ctx, "key", time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, want, n)
+	}
+	// later failures do not extend the window
+	require.Equal(t, time.Minute, mr.TTL(failuresKey("key")))
+
+	require.NoError(t, store.Lock(ctx, "key", 30*time.Second))
+	d, err := store.Locked(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, d)
+
+	mr.FastForward(30 * time.Second)
+	d, err = store.Locked(ctx, "key")
+	require.NoError(t, err)
+	require.Zero(t, d)
+
+	require.NoError(t, store.Reset(ctx, "key"))
+	require.False(t, mr.Exists(failuresKey("key")))
+}
+
+func TestRedisStore_FallsBackToMemory(t *testing.T) {
+	store, mr := newRedisStore(t)
+	ctx := context.Background()
+	mr.Close()
+
+	for want := 1; want <= 2; want++ {
+		n, err := store.Fail(ctx, "key", time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, want, n)
+	}
+
+	require.NoError(t, store.Lock(ctx, "key", time.Minute))
+	d, err := store.Locked(ctx, "key")
+	require.NoError(t, err)
+	require.Positive(t, d)
+}
@@ -0,0 +1,113 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps failure counts and locks by key.
+type Store interface {
+	// Fail adds a failure to key and returns the failures within window
+	// of the first one.
+	Fail(ctx context.Context, key string, window time.Duration) (int, error)
+
+	// Lock blocks key for d.
+	Lock(ctx context.Context, key string, d time.Duration) error
+
+	// Locked returns how much longer key is blocked, or zero.
+	Locked(ctx context.Context, key string) (time.Duration, error)
+
+	// Reset forgets the failures and the lock of key.
+	Reset(ctx context.Context, key string) error
+}
+
+// sweepEvery is how many failures the memory store records between
+// sweeps of expired keys.
+const sweepEvery = 1000
+
+type memoryEntry struct {
+	failures    int
+	expiresAt   time.Time
+	lockedUntil time.Time
+}
+
+// MemoryStore keeps the counts of a single instance in memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	writes  int
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: map[string]*memoryEntry{},
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryStore) Fail(_ context.Context, key string, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.writes++
+	if m.writes%sweepEvery == 0 {
+		m.sweep(now)
+	}
+
+	e := m.entries[key]
+	if e == nil {
+		e = &memoryEntry{}
+		m.entries[key] = e
+	}
+	if !now.Before(e.expiresAt) {
+		e.failures = 0
+		e.expiresAt = now.Add(window)
+	}
+	e.failures++
+
+	return e.failures, nil
+}
+
+func (m *MemoryStore) Lock(_ context.Context, key string, d time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.entries[key]
+	if e == nil {
+		e = &memoryEntry{}
+		m.entries[key] = e
+	}
+	e.lockedUntil = m.now().Add(d)
+
+	return nil
+}
+
+func (m *MemoryStore) Locked(_ context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.entries[key]
+	if e == nil {
+		return 0, nil
+	}
+	return max(e.lockedUntil.Sub(m.now()), 0), nil
+}
+
+func (m *MemoryStore) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *MemoryStore) sweep(now time.Time) {
+	for k, e := range m.entries {
+		if !now.Before(e.expiresAt) && !now.Before(e.lockedUntil) {
+			delete(m.entries, k)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package validation
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// PasswordPolicy is what a new password must meet.
+type PasswordPolicy struct {
+	MinLength int
+
+	// MaxBytes caps the encoded length, so hashing stays cheap to ask
+	// for.
+	MaxBytes int
+
+	// breached holds upper case SHA-1 hex digests of known leaked
+	// passwords.
+	breached map[string]struct{}
+}
+
+// DefaultPasswordPolicy follows NIST SP 800-63B: a minimum length and no
+// composition rules.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 8,
+		MaxBytes:  1024,
+	}
+}
+
+// LoadBreached reads leaked passwords to refuse, one per line. Lines may
+// also be SHA-1 digests in the "HASH:COUNT" format of the Have I Been
+// Pwned downloads.
+func (p *PasswordPolicy) LoadBreached(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return p.readBreached(f)
+}
+
+func (p *PasswordPolicy) readBreached(r io.Reader) error {
+	if p.breached == nil {
+		p.breached = map[string]struct{}{}
+	}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if digest, ok := sha1Line(line); ok {
+			p.breached[digest] = struct{}{}
+			continue
+		}
+		p.breached[passwordDigest(line)] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read breached passwords: %w", err)
+	}
+	return nil
+}
+
+// Breached returns how many leaked passwords are loaded.
+func (p *PasswordPolicy) Breached() int {
+	return len(p.breached)
+}
+
+// Check returns the problems with password for field. The password may
+// not be the user's email either.
+func (p PasswordPolicy) Check(field, password, email string) *FieldError {
+	switch {
+	case utf8.RuneCountInString(password) < p.MinLength:
+		return &FieldError{Field: field, Message: fmt.Sprintf("must be at least %d characters", p.MinLength)}
+	case p.MaxBytes > 0 && len(password) > p.MaxBytes:
+		return &FieldError{Field: field, Message: fmt.Sprintf("must be at most %d bytes", p.MaxBytes)}
+	case email != "" && strings.EqualFold(password, email):
+		return &FieldError{Field: field, Message: "must not be the email address"}
+	}
+
+	if _, ok := p.breached[passwordDigest(password)]; ok {
+		return &FieldError{Field: field, Message: "appears in a list of leaked passwords"}
+	}
+	return nil
+}
+
+func passwordDigest(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// sha1Line returns the digest of a "HASH" or "HASH:COUNT" line.
+func sha1Line(line string) (string, bool) {
+	digest, _, _ := strings.Cut(line, ":")
+	if len(digest) != 2*sha1.Size {
+		return "", false
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", false
+	}
+	return strings.ToUpper(digest), true
+}
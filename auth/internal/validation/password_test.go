@@ -0,0 +1,40 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	p := DefaultPasswordPolicy()
+
+	require.Nil(t, p.Check("password", "correct horse battery", "test@mail.com"))
+	// length counts characters, not bytes
+	require.Nil(t, p.Check("password", "äöüäöüäö", ""))
+
+	for _, pw := range []string{
+		"",
+		"short",
+		strings.Repeat("a", 1025),
+		"Test@Mail.com",
+	} {
+		fieldErr := p.Check("password", pw, "test@mail.com")
+		require.NotNil(t, fieldErr, pw)
+		require.Equal(t, "password", fieldErr.Field)
+	}
+}
+
+func TestPasswordPolicy_Breached(t *testing.T) {
+	p := DefaultPasswordPolicy()
+
+	// "password1" as a plain line and "qwertyuiop" as a HIBP digest
+	list := "password1\r\n\nCD8F9E3E5E5C2A8D1E0A3C2D0E5B5F0F0C0C0C0C\nB0399D2029F64D445BD131FFAA399A42D2F8E7DC:3303003\n"
+	require.NoError(t, p.readBreached(strings.NewReader(list)))
+	require.Equal(t, 3, p.Breached())
+
+	require.NotNil(t, p.Check("password", "password1", ""))
+	require.NotNil(t, p.Check("password", "qwertyuiop", ""))
+	require.Nil(t, p.Check("password", "password2", ""))
+}
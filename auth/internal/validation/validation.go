@@ -0,0 +1,63 @@
+// Package validation checks the email addresses and passwords users sign
+// up with.
+package validation
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// FieldError is a problem with one request field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error lists every problem found in a request.
+type Error struct {
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+// maxEmailLength is the longest address SMTP can deliver to.
+const maxEmailLength = 254
+
+// CanonicalEmail is the form emails are stored and looked up in: trimmed
+// and lower case. Providers treat local parts case-insensitively in
+// practice, so A@x.com and a@x.com are one user.
+func CanonicalEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Email returns the canonical form of a bare address like
+// "name@example.com", or a FieldError for field.
+func Email(field, email string) (string, *FieldError) {
+	email = CanonicalEmail(email)
+
+	if email == "" {
+		return "", &FieldError{Field: field, Message: "is required"}
+	}
+	if len(email) > maxEmailLength {
+		return "", &FieldError{Field: field, Message: "is too long"}
+	}
+
+	// ParseAddress also takes forms like "Name <name@example.com>"
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", &FieldError{Field: field, Message: "is not a valid email address"}
+	}
+
+	domain := email[strings.LastIndexByte(email, '@')+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", &FieldError{Field: field, Message: "is not a valid email address"}
+	}
+
+	return email, nil
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmail(t *testing.T) {
+	for in, want := range map[string]string{
+		"test@mail.com":        "test@mail.com",
+		"  Test@Mail.COM ":     "test@mail.com",
+		"first.last+tag@x.org": "first.last+tag@x.org",
+	} {
+		got, fieldErr := Email("email", in)
+		require.Nil(t, fieldErr, in)
+		require.Equal(t, want, got)
+	}
+
+	for _, in := range []string{
+		"",
+		"plain",
+		"@mail.com",
+		"test@",
+		"test@localhost",
+		"test@mail.com.",
+		"Test <test@mail.com>",
+		"a b@mail.com",
+		"test@mail.com, other@mail.com",
+	} {
+		_, fieldErr := Email("email", in)
+		require.NotNil(t, fieldErr, in)
+		require.Equal(t, "email", fieldErr.Field)
+	}
+}
+
+func TestError(t *testing.T) {
+	err := &Error{Fields: []FieldError{
+		{Field: "email", Message: "is required"},
+		{Field: "password", Message: "must be at least 8 characters"},
+	}}
+	require.Equal(t, "invalid request: email: is required; password: must be at least 8 characters", err.Error())
+}
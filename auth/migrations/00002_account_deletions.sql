@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- outbox of deleted accounts: each row is published to the ledger until
+-- the ledger confirms it has erased the user's data
+CREATE TABLE account_deletions (
+                                   id           UUID PRIMARY KEY,
+                                   user_id      UUID NOT NULL,
+                                   status       TEXT NOT NULL DEFAULT 'pending',
+                                   attempts     INT NOT NULL DEFAULT 0,
+                                   requested_at TIMESTAMP NOT NULL DEFAULT now(),
+                                   published_at TIMESTAMP,
+                                   completed_at TIMESTAMP
+);
+
+CREATE INDEX account_deletions_pending_idx
+    ON account_deletions (published_at)
+    WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE account_deletions;
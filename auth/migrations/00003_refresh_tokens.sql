@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- refresh tokens are stored as SHA-256 hashes. Each refresh replaces the
+-- token with a new one of the same family; presenting a used token again
+-- revokes the whole family.
+CREATE TABLE refresh_tokens (
+                                id         UUID PRIMARY KEY,
+                                family_id  UUID NOT NULL,
+                                user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                token_hash TEXT UNIQUE NOT NULL,
+                                expires_at TIMESTAMP NOT NULL,
+                                created_at TIMESTAMP NOT NULL DEFAULT now(),
+                                used_at    TIMESTAMP,
+                                revoked_at TIMESTAMP
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP TABLE refresh_tokens;
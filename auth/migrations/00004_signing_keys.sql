@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- Ed25519 keys access tokens are signed with, stored as 32-byte seeds.
+-- The newest key without retired_at signs; retired keys keep verifying
+-- until the tokens they signed have expired.
+CREATE TABLE signing_keys (
+                              id          TEXT PRIMARY KEY,
+                              private_key BYTEA NOT NULL,
+                              created_at  TIMESTAMP NOT NULL,
+                              retired_at  TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE signing_keys;
@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- the gateway polls for sessions revoked and accounts deleted recently
+CREATE INDEX refresh_tokens_revoked_idx
+    ON refresh_tokens (revoked_at)
+    WHERE revoked_at IS NOT NULL;
+
+CREATE INDEX account_deletions_requested_idx
+    ON account_deletions (requested_at);
+
+-- +goose Down
+DROP INDEX account_deletions_requested_idx;
+DROP INDEX refresh_tokens_revoked_idx;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- single-use tokens sent by email to verify an address or reset a
+-- password. Like refresh tokens they are stored as SHA-256 hashes.
+CREATE TABLE email_tokens (
+                              id         UUID PRIMARY KEY,
+                              user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                              purpose    TEXT NOT NULL,
+                              token_hash TEXT UNIQUE NOT NULL,
+                              expires_at TIMESTAMP NOT NULL,
+                              created_at TIMESTAMP NOT NULL DEFAULT now(),
+                              used_at    TIMESTAMP
+);
+
+CREATE INDEX email_tokens_user_idx ON email_tokens (user_id, purpose);
+
+-- +goose Down
+DROP TABLE email_tokens;
+ALTER TABLE users DROP COLUMN email_verified_at;
@@ -0,0 +1,39 @@
+-- +goose Up
+
+-- a TOTP secret is pending until the user confirms it with a first code.
+-- last_step is the time step of the last accepted code; a code is never
+-- accepted twice.
+CREATE TABLE user_totp (
+                           user_id      UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+                           secret       TEXT NOT NULL,
+                           confirmed_at TIMESTAMP,
+                           last_step    BIGINT,
+                           created_at   TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- single-use codes for when the authenticator is lost, stored as SHA-256
+-- hashes
+CREATE TABLE recovery_codes (
+                                id        UUID PRIMARY KEY,
+                                user_id   UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                code_hash TEXT NOT NULL,
+                                used_at   TIMESTAMP
+);
+
+CREATE INDEX recovery_codes_user_idx ON recovery_codes (user_id);
+
+-- a password login of a user with 2FA returns a challenge, completed with
+-- a code
+CREATE TABLE login_challenges (
+                                  id         UUID PRIMARY KEY,
+                                  user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                  token_hash TEXT UNIQUE NOT NULL,
+                                  expires_at TIMESTAMP NOT NULL,
+                                  attempts   INT NOT NULL DEFAULT 0,
+                                  used_at    TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE login_challenges;
+DROP TABLE recovery_codes;
+DROP TABLE user_totp;
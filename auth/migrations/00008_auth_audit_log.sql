@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- sign-in attempts, for investigating account takeovers. user_id is
+-- NULL when the email matched no account.
+CREATE TABLE auth_audit_log (
+                                id         UUID PRIMARY KEY,
+                                user_id    UUID REFERENCES users (id) ON DELETE CASCADE,
+                                email      TEXT NOT NULL,
+                                ip         TEXT NOT NULL,
+                                event      TEXT NOT NULL,
+                                created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX auth_audit_log_email_idx ON auth_audit_log (email, created_at);
+
+-- +goose Down
+DROP TABLE auth_audit_log;
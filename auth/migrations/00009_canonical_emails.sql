@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- emails are stored trimmed and in lower case, and unique regardless of
+-- case. Addresses that only differ in case belong to different users
+-- here and have to be merged or renamed by hand first.
+-- +goose StatementBegin
+DO $$
+DECLARE
+    collisions TEXT;
+BEGIN
+    SELECT string_agg(canonical, ', ')
+    INTO collisions
+    FROM (
+        SELECT lower(btrim(email)) AS canonical
+        FROM users
+        GROUP BY 1
+        HAVING count(*) > 1
+    ) c;
+
+    IF collisions IS NOT NULL THEN
+        RAISE EXCEPTION 'users with emails differing only in case: %', collisions;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+UPDATE users SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX users_email_lower_idx ON users (lower(email));
+
+-- +goose Down
+DROP INDEX users_email_lower_idx;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- long-lived tokens users create for scripts, stored as SHA-256 hashes.
+-- scopes is space separated.
+CREATE TABLE personal_tokens (
+                                 id           UUID PRIMARY KEY,
+                                 user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                 name         TEXT NOT NULL,
+                                 token_hash   TEXT UNIQUE NOT NULL,
+                                 scopes       TEXT NOT NULL,
+                                 expires_at   TIMESTAMP,
+                                 last_used_at TIMESTAMP,
+                                 created_at   TIMESTAMP NOT NULL DEFAULT now(),
+                                 revoked_at   TIMESTAMP
+);
+
+CREATE INDEX personal_tokens_user_idx ON personal_tokens (user_id);
+
+-- +goose Down
+DROP TABLE personal_tokens;
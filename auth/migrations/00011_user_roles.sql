@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- what a user may do: user is the default, read-only users can only
+-- look, and admins also manage users. The first admin is promoted by
+-- hand:
+--   UPDATE users SET role = 'admin' WHERE email = '...';
+ALTER TABLE users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'user'
+        CHECK (role IN ('user', 'read-only', 'admin'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN role;
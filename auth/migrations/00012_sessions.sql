@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- a session is a sign-in on a device. Its id is the family_id of its
+-- refresh tokens and the sid of its access tokens; it is active while the
+-- family holds a token that is neither revoked nor expired. ip and
+-- user_agent are those it was last used from.
+CREATE TABLE sessions (
+                          id           UUID PRIMARY KEY,
+                          user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                          user_agent   TEXT NOT NULL DEFAULT '',
+                          ip           TEXT NOT NULL DEFAULT '',
+                          created_at   TIMESTAMP NOT NULL DEFAULT now(),
+                          last_used_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX sessions_user_idx ON sessions (user_id);
+CREATE INDEX refresh_tokens_user_idx ON refresh_tokens (user_id);
+
+-- sessions started before this migration, without device details
+INSERT INTO sessions (id, user_id, created_at, last_used_at)
+SELECT family_id, user_id, min(created_at), max(created_at)
+FROM refresh_tokens
+GROUP BY family_id, user_id;
+
+-- +goose Down
+DROP INDEX refresh_tokens_user_idx;
+DROP TABLE sessions;
@@ -0,0 +1,29 @@
+-- +goose Up
+
+-- a sign-in with an identity provider waiting for the user to come back.
+-- The state is stored as a SHA-256 hash; the nonce and the PKCE code
+-- verifier are checked against what the provider returns.
+CREATE TABLE oidc_logins (
+                             state_hash    TEXT PRIMARY KEY,
+                             provider      TEXT NOT NULL,
+                             nonce         TEXT NOT NULL,
+                             code_verifier TEXT NOT NULL,
+                             expires_at    TIMESTAMP NOT NULL
+);
+
+-- who a user is at an identity provider. subject is the provider's id of
+-- the user, which stays when their email changes.
+CREATE TABLE user_identities (
+                                 provider   TEXT NOT NULL,
+                                 subject    TEXT NOT NULL,
+                                 user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                 email      TEXT NOT NULL,
+                                 created_at TIMESTAMP NOT NULL DEFAULT now(),
+                                 PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX user_identities_user_idx ON user_identities (user_id);
+
+-- +goose Down
+DROP TABLE user_identities;
+DROP TABLE oidc_logins;
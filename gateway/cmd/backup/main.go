@@ -0,0 +1,219 @@
+// Command backup downloads and restores GoFinance account backups through
+// the gateway API.
+//
+// Usage:
+//
+//	backup [-url URL] [-token TOKEN] export [-o FILE]
+//	backup [-url URL] [-token TOKEN] restore [-mode merge|replace] FILE
+//
+// The URL and token default to GOFINANCE_URL and GOFINANCE_TOKEN. The
+// token is the one returned by /auth/login.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const usage = `usage:
+  backup [-url URL] [-token TOKEN] export [-o FILE]
+  backup [-url URL] [-token TOKEN] restore [-mode merge|replace] FILE
+`
+
+type client struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+func main() {
+	url := flag.String("url", envOr("GOFINANCE_URL", "http://localhost:8080"), "gateway URL")
+	token := flag.String("token", os.Getenv("GOFINANCE_TOKEN"), "access token")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *token == "" {
+		fatal(errors.New("a token is required: set -token or GOFINANCE_TOKEN"))
+	}
+
+	c := &client{
+		url:   strings.TrimRight(*url, "/"),
+		token: *token,
+		// the gateway allows backups 10 minutes
+		http: &http.Client{Timeout: 11 * time.Minute},
+	}
+
+	var err error
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "export":
+		err = c.export(args)
+	case "restore":
+		err = c.restore(args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func (c *client) export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("o", "gofinance-backup-"+time.Now().Format("20060102")+".zip", "output file, - for stdout")
+	_ = fs.Parse(args)
+
+	req, err := http.NewRequest(http.MethodGet, c.url+"/api/backup", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if *out == "-" {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+
+	// write next to the target first, so a failed download does not
+	// replace an older backup
+	tmp, err := os.CreateTemp(filepath.Dir(*out), ".backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), *out); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote %s (%d bytes)\n", *out, n)
+	return nil
+}
+
+func (c *client) restore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	mode := fs.String("mode", "merge", "merge adds to the account, replace deletes its data first")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("restore needs exactly one archive")
+	}
+	if *mode != "merge" && *mode != "replace" {
+		return fmt.Errorf("unknown mode %q", *mode)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// the form is streamed, so the archive is never held in memory
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeForm(mw, *mode, filepath.Base(f.Name()), f))
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, c.url+"/api/backup/restore", pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Budgets        int64 `json:"budgets"`
+		Transactions   int64 `json:"transactions"`
+		ImportProfiles int64 `json:"import_profiles"`
+		Duplicates     int64 `json:"duplicates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"restored %d budgets, %d transactions and %d import profiles; %d transactions were already stored\n",
+		res.Budgets, res.Transactions, res.ImportProfiles, res.Duplicates,
+	)
+	return nil
+}
+
+func writeForm(mw *multipart.Writer, mode, name string, file io.Reader) error {
+	if err := mw.WriteField("mode", mode); err != nil {
+		return err
+	}
+
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fw, file); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+// do sends req and turns error responses into errors.
+func (c *client) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return resp, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "backup:", err)
+	os.Exit(1)
+}
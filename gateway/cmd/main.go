@@ -13,8 +13,10 @@
 package main
 
 import (
+	"context"
 	authv1 "gateway/auth/v1"
 	_ "gateway/docs"
+	"gateway/internal/cache"
 	"gateway/internal/handlers"
 	"gateway/internal/middleware"
 	"log"
@@ -40,6 +42,8 @@ func main() {
 	ledgerConn, err := grpc.Dial(
 		ledgerAddr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(middleware.PropagateIdempotencyKey, middleware.PropagateLedgerID),
+		grpc.WithStreamInterceptor(middleware.PropagateLedgerIDStream),
 	)
 	if err != nil {
 		log.Fatalf("failed to connect to ledger: %v", err)
@@ -56,6 +60,7 @@ func main() {
 	authConn, err := grpc.Dial(
 		authAddr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(middleware.PropagateClientIP, middleware.PropagateUserAgent),
 	)
 	if err != nil {
 		log.Fatalf("failed to connect to auth: %v", err)
@@ -66,6 +71,7 @@ func main() {
 
 	hLedger := handlers.NewHandler(ledgerClient)
 	hAuth := handlers.NewAuthHandler(authClient)
+	hAdmin := handlers.NewAdminHandler(authClient)
 
 	mux := http.NewServeMux()
 	auth := http.NewServeMux()
@@ -87,6 +93,159 @@ func main() {
 		}
 	})
 
+	auth.HandleFunc("/auth/login/2fa", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.LoginTwoFactor(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.Refresh(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/logout", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.Logout(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/email/verification", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.SendVerificationEmail(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/email/verify", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.VerifyEmail(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/password/forgot", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.ForgotPassword(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/password/reset", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.ResetPassword(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/2fa/totp", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.EnrollTOTP(w, r)
+		case http.MethodDelete:
+			hAuth.DisableTOTP(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/2fa/totp/confirm", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.ConfirmTOTP(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/2fa/recovery-codes", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.RegenerateRecoveryCodes(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hAuth.JWKS(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/account", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			hAuth.DeleteAccount(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/account/deletions/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hAuth.GetAccountDeletion(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hAuth.CreatePersonalToken(w, r)
+		case http.MethodGet:
+			hAuth.ListPersonalTokens(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/tokens/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			hAuth.RevokePersonalToken(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/sessions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hAuth.ListSessions(w, r)
+		case http.MethodDelete:
+			hAuth.RevokeAllOtherSessions(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/sessions/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			hAuth.RevokeSession(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	auth.HandleFunc("/auth/oidc/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodGet:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case strings.HasSuffix(r.URL.Path, "/callback"):
+			hAuth.OIDCCallback(w, r)
+		default:
+			hAuth.StartOIDCLogin(w, r)
+		}
+	})
+
 	mux.HandleFunc("/api/transactions", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
@@ -130,7 +289,27 @@ func main() {
 	mux.HandleFunc("/api/transactions/import", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			hLedger.ImportCSV(w, r)
+			hLedger.ImportTransactions(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/transactions/duplicates", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hLedger.FindDuplicates(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/import-profiles", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hLedger.ListImportProfiles(w, r)
+		case http.MethodPost:
+			hLedger.SaveImportProfile(w, r)
+		case http.MethodDelete:
+			hLedger.DeleteImportProfile(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
@@ -138,35 +317,185 @@ func main() {
 	mux.HandleFunc("/api/transactions/export", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			hLedger.ExportCSV(w, r)
+			hLedger.ExportTransactions(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/imports", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hLedger.CreateImportJob(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/imports/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hLedger.GetImportJob(w, r)
+		case http.MethodDelete:
+			hLedger.CancelImportJob(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/backup", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hLedger.ExportBackup(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/backup/restore", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hLedger.RestoreBackup(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/ledgers", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hLedger.ListLedgers(w, r)
+		case http.MethodPost:
+			hLedger.CreateLedger(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/ledgers/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/invitations") && r.Method == http.MethodPost:
+			hLedger.CreateInvitation(w, r)
+		case strings.HasSuffix(r.URL.Path, "/members") && r.Method == http.MethodGet:
+			hLedger.ListMembers(w, r)
+		case strings.Contains(r.URL.Path, "/members/") && r.Method == http.MethodPut:
+			hLedger.SetMemberRole(w, r)
+		case strings.Contains(r.URL.Path, "/members/") && r.Method == http.MethodDelete:
+			hLedger.RemoveMember(w, r)
+		case strings.HasSuffix(r.URL.Path, "/spending") && r.Method == http.MethodGet:
+			hLedger.MemberSpending(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/invitations/accept", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			hLedger.AcceptInvitation(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/admin/users", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hAdmin.ListUsers(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/admin/users/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			hAdmin.SetUserRole(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/admin/stats", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			hAdmin.Stats(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	})
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 
-	protected := middleware.NewJWT(authClient)(mux)
-
-	handler := middleware.Logging(
-		middleware.TimeoutMiddleware(2 * time.Second)(
-			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.URL.Path == "/auth/login" ||
-					r.URL.Path == "/auth/register" ||
-					strings.HasPrefix(r.URL.Path, "/swagger/") {
-
-					if strings.HasPrefix(r.URL.Path, "/swagger/") {
-						mux.ServeHTTP(w, r)
-						return
-					}
-
-					auth.ServeHTTP(w, r)
-					return
-				}
-				protected.ServeHTTP(w, r)
-			}),
-		),
+	cache.Init(context.Background())
+	defer func() {
+		if cache.Client != nil {
+			_ = cache.Client.Close()
+		}
+	}()
+
+	// tokens are verified in the gateway unless JWT_VERIFY=remote, which
+	// asks auth for every request
+	jwtAuth := middleware.NewJWT(authClient)
+	if os.Getenv("JWT_VERIFY") != "remote" {
+		verifier := middleware.NewVerifier(authClient)
+		go verifier.Run(context.Background())
+		jwtAuth = middleware.NewLocalJWT(verifier)
+	}
+
+	protected := jwtAuth(
+		middleware.LedgerID(middleware.Idempotency(cache.Client)(mux)),
 	)
 
+	routes := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// account deletion, two-factor settings, personal tokens and
+		// sessions check the token themselves; auth takes only access
+		// tokens there, so a personal token cannot manage the account
+		if r.URL.Path == "/auth/login" ||
+			r.URL.Path == "/auth/register" ||
+			r.URL.Path == "/auth/login/2fa" ||
+			r.URL.Path == "/auth/refresh" ||
+			r.URL.Path == "/auth/logout" ||
+			r.URL.Path == "/auth/email/verification" ||
+			r.URL.Path == "/auth/email/verify" ||
+			r.URL.Path == "/auth/password/forgot" ||
+			r.URL.Path == "/auth/password/reset" ||
+			strings.HasPrefix(r.URL.Path, "/auth/2fa/") ||
+			r.URL.Path == "/.well-known/jwks.json" ||
+			r.URL.Path == "/auth/account" ||
+			strings.HasPrefix(r.URL.Path, "/auth/account/deletions/") ||
+			r.URL.Path == "/auth/tokens" ||
+			strings.HasPrefix(r.URL.Path, "/auth/tokens/") ||
+			r.URL.Path == "/auth/sessions" ||
+			strings.HasPrefix(r.URL.Path, "/auth/sessions/") ||
+			strings.HasPrefix(r.URL.Path, "/auth/oidc/") ||
+			strings.HasPrefix(r.URL.Path, "/swagger/") {
+
+			if strings.HasPrefix(r.URL.Path, "/swagger/") {
+				mux.ServeHTTP(w, r)
+				return
+			}
+
+			auth.ServeHTTP(w, r)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	})
+
+	short := middleware.TimeoutMiddleware(2 * time.Second)(routes)
+	// statement and backup uploads of up to 512MB and full exports take
+	// longer
+	long := middleware.TimeoutMiddleware(10 * time.Minute)(routes)
+
+	// behind a reverse proxy set TRUST_PROXY=true, so sign-ins are
+	// throttled by the client address rather than the proxy's
+	clientIP := middleware.ClientIP(os.Getenv("TRUST_PROXY") == "true")
+
+	handler := middleware.Logging(clientIP(middleware.UserAgent(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost &&
+				(r.URL.Path == "/api/transactions/import" ||
+					r.URL.Path == "/api/imports" ||
+					r.URL.Path == "/api/backup/restore") ||
+				r.Method == http.MethodGet &&
+					(r.URL.Path == "/api/transactions/export" || r.URL.Path == "/api/backup") {
+
+				long.ServeHTTP(w, r)
+				return
+			}
+			short.ServeHTTP(w, r)
+		}),
+	)))
+
 	log.Println("Gateway started on :8080")
 	log.Fatal(http.ListenAndServe(":8080", handler))
 
@@ -6,14 +6,21 @@ type CreateTransactionRequest struct {
 	Amount      float64 `json:"amount"`
 	Category    string  `json:"category"`
 	Description string  `json:"description"`
-	Date        string  `json:"date"` // YYYY-MM-DD
+	Date        string  `json:"date"`                  // YYYY-MM-DD
+	ExternalID  string  `json:"external_id,omitempty"` // bank transaction id, used to skip duplicates
 }
 
 type TransactionResponse struct {
+	ID          int32   `json:"id,omitempty"`
 	Amount      float64 `json:"amount"`
 	Category    string  `json:"category"`
 	Description string  `json:"description"`
 	Date        string  `json:"date"`
+	ExternalID  string  `json:"external_id,omitempty"`
+}
+
+type DuplicateGroupResponse struct {
+	Transactions []TransactionResponse `json:"transactions"`
 }
 
 type CreateBudgetRequest struct {
@@ -35,6 +42,7 @@ type ReportResponse struct {
 
 type BulkErrorResponse struct {
 	Index int    `json:"index"`
+	Line  int    `json:"line,omitempty"`
 	Error string `json:"error"`
 }
 
@@ -43,3 +51,103 @@ type BulkImportResponse struct {
 	Rejected int64               `json:"rejected"`
 	Errors   []BulkErrorResponse `json:"errors"`
 }
+
+type SkippedRowResponse struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+type ImportResponse struct {
+	Format      string               `json:"format"`
+	Accepted    int64                `json:"accepted"`
+	Rejected    int64                `json:"rejected"`
+	Skipped     int64                `json:"skipped"`
+	Duplicates  int64                `json:"duplicates"`
+	Errors      []BulkErrorResponse  `json:"errors"`
+	SkippedRows []SkippedRowResponse `json:"skipped_rows"`
+
+	// DuplicateLines are the records already imported before
+	DuplicateLines []int `json:"duplicate_lines"`
+}
+
+type ImportJobResponse struct {
+	ID         string              `json:"id"`
+	Status     string              `json:"status"` // uploading, queued, running, completed, failed, cancelled
+	Format     string              `json:"format"`
+	FileName   string              `json:"file_name,omitempty"`
+	Total      int64               `json:"total"`
+	Processed  int64               `json:"processed"`
+	Accepted   int64               `json:"accepted"`
+	Rejected   int64               `json:"rejected"`
+	Duplicates int64               `json:"duplicates"`
+	Error      string              `json:"error,omitempty"`
+	Errors     []BulkErrorResponse `json:"errors"`
+	CreatedAt  string              `json:"created_at"`
+	UpdatedAt  string              `json:"updated_at"`
+
+	// SkippedRows are only reported when the job is created
+	SkippedRows []SkippedRowResponse `json:"skipped_rows,omitempty"`
+}
+
+type ImportProfile struct {
+	Name              string `json:"name"`
+	AmountColumn      string `json:"amount_column"`
+	DateColumn        string `json:"date_column"`
+	CategoryColumn    string `json:"category_column,omitempty"`
+	DescriptionColumn string `json:"description_column,omitempty"`
+	ExternalIDColumn  string `json:"external_id_column,omitempty"`
+	Delimiter         string `json:"delimiter,omitempty"`
+	DecimalComma      bool   `json:"decimal_comma"`
+	DateFormat        string `json:"date_format,omitempty"`     // DD.MM.YYYY, YYYY-MM-DD, ...
+	Encoding          string `json:"encoding,omitempty"`        // utf-8 | windows-1251
+	SignConvention    string `json:"sign_convention,omitempty"` // positive | negative | absolute
+}
+
+type RestoreBackupResponse struct {
+	Budgets        int64 `json:"budgets"`
+	Transactions   int64 `json:"transactions"`
+	ImportProfiles int64 `json:"import_profiles"`
+	Duplicates     int64 `json:"duplicates"` // transactions already stored and skipped
+}
+
+type CreateLedgerRequest struct {
+	Name string `json:"name"`
+}
+
+type LedgerResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	OwnerID   string `json:"owner_id"`
+	Role      string `json:"role"` // yours: owner | editor | viewer
+	CreatedAt string `json:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	Role string `json:"role"` // editor | viewer
+}
+
+type InvitationResponse struct {
+	Token     string `json:"token"` // shown once; accept it at /api/invitations/accept
+	Role      string `json:"role"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+type MemberResponse struct {
+	UserID   string `json:"user_id"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joined_at"`
+}
+
+type SetMemberRoleRequest struct {
+	Role string `json:"role"` // editor | viewer
+}
+
+type MemberSpendingResponse struct {
+	UserID       string  `json:"user_id"`
+	Transactions int64   `json:"transactions"`
+	Total        float64 `json:"total"`
+}
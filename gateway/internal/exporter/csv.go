@@ -0,0 +1,33 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvWriter writes the layout read by the CSV importer:
+// amount,category,description,date,external_id with a header row.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	c := &csvWriter{w: csv.NewWriter(w)}
+	_ = c.w.Write([]string{"amount", "category", "description", "date", "external_id"})
+	return c
+}
+
+func (c *csvWriter) Write(t Transaction) error {
+	return c.w.Write([]string{
+		t.Amount.StringFixed(2),
+		t.Category,
+		t.Description,
+		t.Date.Format("2006-01-02"),
+		t.ExternalID,
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
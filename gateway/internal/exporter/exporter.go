@@ -0,0 +1,94 @@
+// Package exporter writes transactions in formats the importer reads back.
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatOFX   Format = "ofx"
+	FormatXLSX  Format = "xlsx"
+)
+
+var contentTypes = map[Format]string{
+	FormatCSV:   "text/csv",
+	FormatJSONL: "application/x-ndjson",
+	FormatOFX:   "application/x-ofx",
+	FormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ContentType is the media type of the format.
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Transaction is a single expense of an export.
+type Transaction struct {
+	ID          int32
+	Amount      decimal.Decimal
+	Category    string
+	Description string
+	Date        time.Time
+	ExternalID  string
+}
+
+// Writer encodes transactions one by one. Close completes the document;
+// it does not close the underlying writer.
+type Writer interface {
+	Write(t Transaction) error
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatOFX:
+		return newOFXWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %q", format)
+	}
+}
+
+// Negotiate picks the first supported format listed in an Accept header.
+// An empty header or a wildcard means CSV.
+func Negotiate(accept string) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return FormatCSV, true
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case "*/*", "text/*":
+			return FormatCSV, true
+		case "application/jsonl", "application/x-jsonlines":
+			return FormatJSONL, true
+		}
+
+		for f, ct := range contentTypes {
+			if ct == mediaType {
+				return f, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gateway/internal/importer"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+var sample = []Transaction{
+	{
+		ID:          1,
+		Amount:      decimal.RequireFromString("12.3"),
+		Category:    "food",
+		Description: `Café & "Bar" <Main St>, 1`,
+		Date:        time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC),
+		ExternalID:  "FIT-1",
+	},
+	{
+		ID:       2,
+		Amount:   decimal.NewFromInt(1500),
+		Category: "rent",
+		Date:     time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+func TestRoundTrip(t *testing.T) {
+	formats := map[Format]importer.Format{
+		FormatCSV:   importer.FormatCSV,
+		FormatJSONL: importer.FormatJSONL,
+		FormatOFX:   importer.FormatOFX,
+		FormatXLSX:  importer.FormatXLSX,
+	}
+
+	for format, imported := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w, err := NewWriter(format, &buf)
+			require.NoError(t, err)
+			for _, tx := range sample {
+				require.NoError(t, w.Write(tx))
+			}
+			require.NoError(t, w.Close())
+
+			require.Equal(t, imported, importer.Detect("transactions."+string(format), nil))
+			require.Equal(t, imported, importer.Detect("upload", buf.Bytes()[:min(buf.Len(), 512)]))
+
+			r, err := importer.NewReader(imported, bytes.NewReader(buf.Bytes()), importer.Options{DefaultCategory: "misc"})
+			require.NoError(t, err)
+
+			got, skipped, err := importer.ReadAll(r)
+			require.NoError(t, err)
+			require.Empty(t, skipped)
+			require.Len(t, got, len(sample))
+
+			for i, want := range sample {
+				require.True(t, want.Amount.Equal(got[i].Amount), "amount %d", i)
+				require.Equal(t, want.Date, got[i].Date)
+				require.Equal(t, want.Description, got[i].Description)
+
+				if format == FormatOFX {
+					// OFX carries no categories and needs an ID for every record
+					require.Equal(t, "misc", got[i].Category)
+					require.NotEmpty(t, got[i].ExternalID)
+					continue
+				}
+				require.Equal(t, want.Category, got[i].Category)
+				require.Equal(t, want.ExternalID, got[i].ExternalID)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]Format{
+		"":                             FormatCSV,
+		"*/*":                          FormatCSV,
+		"text/csv":                     FormatCSV,
+		"application/x-ndjson":         FormatJSONL,
+		"application/json, text/csv":   FormatCSV,
+		"application/x-ofx;q=0.9, */*": FormatOFX,
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FormatXLSX,
+	}
+
+	for accept, want := range tests {
+		got, ok := Negotiate(accept)
+		require.True(t, ok, accept)
+		require.Equal(t, want, got, accept)
+	}
+
+	_, ok := Negotiate("application/pdf")
+	require.False(t, ok)
+}
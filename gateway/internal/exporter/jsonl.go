@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// jsonlWriter writes one JSON object per line. Amounts are strings so no
+// precision is lost.
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+type jsonlRecord struct {
+	ID          int32  `json:"id"`
+	Amount      string `json:"amount"`
+	Category    string `json:"category"`
+	Description string `json:"description,omitempty"`
+	Date        string `json:"date"`
+	ExternalID  string `json:"external_id,omitempty"`
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (j *jsonlWriter) Write(t Transaction) error {
+	return j.enc.Encode(jsonlRecord{
+		ID:          t.ID,
+		Amount:      t.Amount.StringFixed(2),
+		Category:    t.Category,
+		Description: t.Description,
+		Date:        t.Date.Format("2006-01-02"),
+		ExternalID:  t.ExternalID,
+	})
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
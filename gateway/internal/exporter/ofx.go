@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+)
+
+// ofxWriter writes an OFX 2 bank statement with one debit per expense.
+// OFX has no place for categories; they are set again on import.
+type ofxWriter struct {
+	w *bufio.Writer
+}
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>0</TRNUID>
+<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS>
+<BANKTRANLIST>
+`
+
+const ofxFooter = `</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+func newOFXWriter(w io.Writer) *ofxWriter {
+	o := &ofxWriter{w: bufio.NewWriter(w)}
+	_, _ = o.w.WriteString(ofxHeader)
+	return o
+}
+
+func (o *ofxWriter) Write(t Transaction) error {
+	// FITIDs must be unique within the account; manual entries have none
+	fitID := t.ExternalID
+	if fitID == "" {
+		fitID = fmt.Sprintf("gofinance-%d", t.ID)
+	}
+
+	_, err := fmt.Fprintf(o.w,
+		"<STMTTRN>\n<TRNTYPE>DEBIT</TRNTYPE>\n<DTPOSTED>%s</DTPOSTED>\n<TRNAMT>%s</TRNAMT>\n<FITID>%s</FITID>\n",
+		t.Date.Format("20060102"),
+		t.Amount.Neg().StringFixed(2),
+		html.EscapeString(fitID),
+	)
+	if err != nil {
+		return err
+	}
+
+	if t.Description != "" {
+		_, err = fmt.Fprintf(o.w, "<NAME>%s</NAME>\n", html.EscapeString(t.Description))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = o.w.WriteString("</STMTTRN>\n")
+	return err
+}
+
+func (o *ofxWriter) Close() error {
+	if _, err := o.w.WriteString(ofxFooter); err != nil {
+		return err
+	}
+	return o.w.Flush()
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	authv1 "gateway/auth/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdminHandler serves the routes of admins. The gateway lets only tokens
+// with the admin scope through, and auth checks the role again.
+type AdminHandler struct {
+	client authv1.AuthServiceClient
+}
+
+func NewAdminHandler(c authv1.AuthServiceClient) *AdminHandler {
+	return &AdminHandler{client: c}
+}
+
+type userResponse struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type setRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type statsResponse struct {
+	Users          int64            `json:"users"`
+	VerifiedUsers  int64            `json:"verified_users"`
+	TwoFactorUsers int64            `json:"two_factor_users"`
+	UsersByRole    map[string]int64 `json:"users_by_role"`
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description List every user, oldest first. Admins only.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} userResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/admin/users [get]
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.client.ListUsers(r.Context(), &authv1.AdminRequest{AccessToken: token})
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	users := make([]userResponse, 0, len(resp.Users))
+	for _, u := range resp.Users {
+		users = append(users, toUser(u))
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+// SetUserRole godoc
+// @Summary Change a user's role
+// @Description Set the role of a user to user, read-only or admin. The
+// @Description user is signed out and gets the new role on the next
+// @Description sign-in. Admins cannot change their own role. Admins only.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body setRoleRequest true "New role"
+// @Success 200 {object} userResponse
+// @Failure 400 {object} validationErrorResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/admin/users/{id}/role [put]
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/admin/users/"), "/role")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req setRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.client.SetUserRole(
+		r.Context(),
+		&authv1.SetUserRoleRequest{
+			AccessToken: token,
+			UserId:      id,
+			Role:        req.Role,
+		},
+	)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			invalidFields(w, err)
+			return
+		}
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toUser(resp))
+}
+
+// Stats godoc
+// @Summary System stats
+// @Description Count users, verified users, users with two-factor
+// @Description authentication and users of each role. Admins only.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} statsResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/admin/stats [get]
+func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.client.GetUserStats(r.Context(), &authv1.AdminRequest{AccessToken: token})
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statsResponse{
+		Users:          resp.Users,
+		VerifiedUsers:  resp.VerifiedUsers,
+		TwoFactorUsers: resp.TwoFactorUsers,
+		UsersByRole:    resp.UsersByRole,
+	})
+}
+
+func toUser(u *authv1.User) userResponse {
+	return userResponse{
+		ID:            u.Id,
+		Email:         u.Email,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerified,
+		CreatedAt:     u.CreatedAt,
+	}
+}
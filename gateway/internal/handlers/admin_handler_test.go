@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authv1 "gateway/auth/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminListUsers(t *testing.T) {
+	client := &mockAuthClient{
+		listUsers: func(ctx context.Context, in *authv1.AdminRequest, _ ...grpc.CallOption) (*authv1.Users, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			return &authv1.Users{Users: []*authv1.User{
+				{Id: "user-1", Email: "admin@mail.com", Role: "admin", EmailVerified: true, CreatedAt: "2026-01-02T03:04:05Z"},
+			}}, nil
+		},
+	}
+
+	h := NewAdminHandler(client)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+	req.Header.Set("Authorization", "Bearer jwt-token")
+
+	w := httptest.NewRecorder()
+	h.ListUsers(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp []userResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, []userResponse{
+		{ID: "user-1", Email: "admin@mail.com", Role: "admin", EmailVerified: true, CreatedAt: "2026-01-02T03:04:05Z"},
+	}, resp)
+}
+
+func TestAdminSetUserRole(t *testing.T) {
+	client := &mockAuthClient{
+		setUserRole: func(ctx context.Context, in *authv1.SetUserRoleRequest, _ ...grpc.CallOption) (*authv1.User, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			switch in.UserId {
+			case "user-2":
+				return &authv1.User{Id: in.UserId, Role: in.Role}, nil
+			case "user-1":
+				return nil, status.Error(codes.FailedPrecondition, "admins cannot change their own role")
+			default:
+				return nil, status.Error(codes.NotFound, "user not found")
+			}
+		},
+	}
+
+	h := NewAdminHandler(client)
+
+	for path, want := range map[string]int{
+		"/api/admin/users/user-2/role": http.StatusOK,
+		"/api/admin/users/user-1/role": http.StatusConflict,
+		"/api/admin/users/user-3/role": http.StatusNotFound,
+		"/api/admin/users/user-2":      http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodPut, path, bytes.NewBufferString(`{"role":"read-only"}`))
+		req.Header.Set("Authorization", "Bearer jwt-token")
+
+		w := httptest.NewRecorder()
+		h.SetUserRole(w, req)
+		require.Equal(t, want, w.Code, path)
+	}
+}
+
+func TestAdminStats_NotAdmin(t *testing.T) {
+	client := &mockAuthClient{
+		userStats: func(ctx context.Context, in *authv1.AdminRequest, _ ...grpc.CallOption) (*authv1.UserStats, error) {
+			return nil, status.Error(codes.PermissionDenied, "admin role required")
+		},
+	}
+
+	h := NewAdminHandler(client)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil)
+	req.Header.Set("Authorization", "Bearer jwt-token")
+
+	w := httptest.NewRecorder()
+	h.Stats(w, req)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
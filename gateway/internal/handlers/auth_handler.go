@@ -1,10 +1,30 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	authv1 "gateway/auth/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// oidcStateCookie holds the hash of the state of the sign-in with an
+	// identity provider the browser started.
+	oidcStateCookie = "oidc_state"
+	// oidcStateTTL is as long as auth keeps the sign-in.
+	oidcStateTTL = 10 * time.Minute
 )
 
 type AuthHandler struct {
@@ -23,18 +43,46 @@ type authRequest struct {
 }
 
 type authResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// loginChallengeResponse is returned by login instead of the tokens when
+// the user has two-factor authentication on.
+type loginChallengeResponse struct {
+	Challenge string `json:"challenge"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+type loginTwoFactorRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+// validationErrorResponse lists what is wrong with each field, e.g.
+// {"password": "must be at least 8 characters"}.
+type validationErrorResponse struct {
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 // Register godoc
 // @Summary Register
-// @Description Register new user
+// @Description Register new user. The answer is the same for an address
+// @Description that already has an account; its owner is emailed instead
+// @Description of a new account being created. Sign in once registered.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body authRequest true "Register request"
-// @Success 200 {object} authResponse
-// @Failure 400 {object} map[string]string
+// @Success 202
+// @Failure 400 {object} validationErrorResponse
+// @Failure 429 {object} map[string]string "Too many registrations, see Retry-After"
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req authRequest
@@ -43,7 +91,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.client.Register(
+	_, err := h.client.Register(
 		r.Context(),
 		&authv1.RegisterRequest{
 			Email:    req.Email,
@@ -51,11 +99,18 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		},
 	)
 	if err != nil {
-		http.Error(w, grpcToHTTP(err), http.StatusUnauthorized)
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			invalidFields(w, err)
+		case codes.ResourceExhausted:
+			tooManyAttempts(w, err)
+		default:
+			http.Error(w, grpcToHTTP(err), authStatus(err))
+		}
 		return
 	}
 
-	writeJSON(w, http.StatusOK, authResponse{Token: resp.AccessToken})
+	w.WriteHeader(http.StatusAccepted)
 }
 
 // Login godoc
@@ -66,7 +121,10 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param request body authRequest true "Login request"
 // @Success 200 {object} authResponse
+// @Success 202 {object} loginChallengeResponse "Second factor needed, see /auth/login/2fa"
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string "Too many failed attempts, see Retry-After"
+// @Failure 500 {object} map[string]string
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req authRequest
@@ -83,11 +141,1016 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		},
 	)
 	if err != nil {
-		http.Error(w, grpcToHTTP(err), http.StatusUnauthorized)
+		loginFailed(w, err)
+		return
+	}
+
+	if resp.Challenge != "" {
+		writeJSON(w, http.StatusAccepted, loginChallengeResponse{
+			Challenge: resp.Challenge,
+			ExpiresIn: resp.ExpiresIn,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAuthResponse(resp))
+}
+
+// LoginTwoFactor godoc
+// @Summary Complete login with a second factor
+// @Description Exchange the challenge from login and a code from the
+// @Description authenticator app, or a recovery code, for tokens. A
+// @Description challenge allows five attempts.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body loginTwoFactorRequest true "Challenge and code"
+// @Success 200 {object} authResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string "Too many failed attempts, see Retry-After"
+// @Failure 500 {object} map[string]string
+// @Router /auth/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req loginTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Challenge == "" || req.Code == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.client.LoginTwoFactor(
+		r.Context(),
+		&authv1.LoginTwoFactorRequest{
+			Challenge: req.Challenge,
+			Code:      req.Code,
+		},
+	)
+	if err != nil {
+		loginFailed(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAuthResponse(resp))
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access and refresh token.
+// @Description Each refresh token works once; using one twice logs the
+// @Description session out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body refreshRequest true "Refresh token"
+// @Success 200 {object} authResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.client.Refresh(
+		r.Context(),
+		&authv1.RefreshRequest{RefreshToken: req.RefreshToken},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAuthResponse(resp))
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the session of a refresh token, including its access tokens
+// @Tags auth
+// @Accept json
+// @Param request body refreshRequest true "Refresh token"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.client.Logout(
+		r.Context(),
+		&authv1.LogoutRequest{RefreshToken: req.RefreshToken},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type emailRequest struct {
+	Email string `json:"email"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// SendVerificationEmail godoc
+// @Summary Resend verification email
+// @Description Send a new email verification link. The answer is the same
+// @Description for unknown and already verified addresses.
+// @Tags auth
+// @Accept json
+// @Param request body emailRequest true "Email address"
+// @Success 202
+// @Failure 400 {object} map[string]string
+// @Router /auth/email/verification [post]
+func (h *AuthHandler) SendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	var req emailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.client.SendVerificationEmail(
+		r.Context(),
+		&authv1.EmailRequest{Email: req.Email},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email
+// @Description Confirm the email address with the token from the
+// @Description verification link. Each link works once.
+// @Tags auth
+// @Accept json
+// @Param request body verifyEmailRequest true "Token from the email"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Router /auth/email/verify [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.client.VerifyEmail(
+		r.Context(),
+		&authv1.VerifyEmailRequest{Token: req.Token},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword godoc
+// @Summary Request password reset
+// @Description Email a password reset link, valid for an hour. The answer
+// @Description is the same for unknown addresses.
+// @Tags auth
+// @Accept json
+// @Param request body emailRequest true "Email address"
+// @Success 202
+// @Failure 400 {object} map[string]string
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req emailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, authResponse{Token: resp.AccessToken})
+	_, err := h.client.RequestPasswordReset(
+		r.Context(),
+		&authv1.EmailRequest{Email: req.Email},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the token from the reset link.
+// @Description Every session of the user is signed out.
+// @Tags auth
+// @Accept json
+// @Param request body resetPasswordRequest true "Token and new password"
+// @Success 204
+// @Failure 400 {object} validationErrorResponse
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" || req.Password == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.client.ResetPassword(
+		r.Context(),
+		&authv1.ResetPasswordRequest{
+			Token:    req.Token,
+			Password: req.Password,
+		},
+	)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			invalidFields(w, err)
+			return
+		}
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+type jwksResponse struct {
+	Keys []jwk `json:"keys"`
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys access tokens are signed with. Tokens name their
+// @Description key in the kid header; keys are rotated and a retired key is
+// @Description listed until its last token has expired.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} jwksResponse
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.GetJWKS(r.Context(), &authv1.JWKSRequest{})
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	res := jwksResponse{Keys: make([]jwk, 0, len(resp.Keys))}
+	for _, k := range resp.Keys {
+		res.Keys = append(res.Keys, jwk{
+			Kty: k.Kty,
+			Crv: k.Crv,
+			X:   k.X,
+			Kid: k.Kid,
+			Alg: k.Alg,
+			Use: k.Use,
+		})
+	}
+
+	// a rotated key signs at once, so verifiers refetch the set when a
+	// token names a key they do not know
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, res)
+}
+
+func toAuthResponse(resp *authv1.AuthResponse) authResponse {
+	return authResponse{
+		Token:        resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+	}
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type accountDeletionResponse struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	Attempts    int32  `json:"attempts"`
+	RequestedAt string `json:"requested_at"`
+	CompletedAt string `json:"completed_at,omitempty"`
+}
+
+// DeleteAccount godoc
+// @Summary Delete account
+// @Description Delete the signed-in user after confirming the password.
+// @Description Users without a password, who signed up with an identity
+// @Description provider, must have signed in within the last 5 minutes
+// @Description instead. Sign-in stops working at once; the user's
+// @Description transactions, budgets and cached data are erased in the
+// @Description background.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body deleteAccountRequest true "Current password"
+// @Success 202 {object} accountDeletionResponse
+// @Header 202 {string} Location "Deletion status"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Sign in again to confirm"
+// @Failure 429 {object} map[string]string "Too many wrong passwords, see Retry-After"
+// @Router /auth/account [delete]
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req deleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.client.DeleteAccount(
+		r.Context(),
+		&authv1.DeleteAccountRequest{
+			AccessToken: token,
+			Password:    req.Password,
+		},
+	)
+	if err != nil {
+		if status.Code(err) == codes.ResourceExhausted {
+			tooManyAttempts(w, err)
+			return
+		}
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.Header().Set("Location", "/auth/account/deletions/"+resp.Id)
+	writeJSON(w, http.StatusAccepted, toAccountDeletion(resp))
+}
+
+// GetAccountDeletion godoc
+// @Summary Get account deletion
+// @Description Follow the erasure of a deleted account. Status is pending
+// @Description until every service has erased the user's data, then completed.
+// @Tags auth
+// @Produce json
+// @Param id path string true "Deletion ID"
+// @Success 200 {object} accountDeletionResponse
+// @Failure 404 {object} map[string]string
+// @Router /auth/account/deletions/{id} [get]
+func (h *AuthHandler) GetAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/auth/account/deletions/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	resp, err := h.client.GetAccountDeletion(
+		r.Context(),
+		&authv1.AccountDeletionRequest{Id: id},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAccountDeletion(resp))
+}
+
+func toAccountDeletion(d *authv1.AccountDeletion) accountDeletionResponse {
+	return accountDeletionResponse{
+		ID:          d.Id,
+		Status:      d.Status,
+		Attempts:    d.Attempts,
+		RequestedAt: d.RequestedAt,
+		CompletedAt: d.CompletedAt,
+	}
+}
+
+type totpEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode []byte `json:"qr_code" swaggertype:"string" format:"base64"`
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTOTP godoc
+// @Summary Enroll an authenticator
+// @Description Create a TOTP secret for the signed-in user. Add it to an
+// @Description authenticator app from the otpauth URI or the QR code (a
+// @Description base64 PNG), then confirm it with a first code. Enrolling
+// @Description again before confirming replaces the secret.
+// @Tags two-factor
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} totpEnrollmentResponse
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/2fa/totp [post]
+func (h *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.client.EnrollTOTP(
+		r.Context(),
+		&authv1.EnrollTOTPRequest{AccessToken: token},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	// the secret must not be kept by caches
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, totpEnrollmentResponse{
+		Secret: resp.Secret,
+		URI:    resp.Uri,
+		QRCode: resp.QrCode,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Turn on two-factor authentication
+// @Description Confirm the enrolled authenticator with a code from it.
+// @Description From then on login asks for a code. Returns recovery codes,
+// @Description each usable once instead of a code; they are not shown again.
+// @Tags two-factor
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body totpCodeRequest true "Code from the authenticator"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/2fa/totp/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	h.withCode(w, r, func(token, code string) {
+		resp, err := h.client.ConfirmTOTP(
+			r.Context(),
+			&authv1.TOTPCodeRequest{AccessToken: token, Code: code},
+		)
+		if err != nil {
+			http.Error(w, grpcToHTTP(err), authStatus(err))
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: resp.Codes})
+	})
+}
+
+// DisableTOTP godoc
+// @Summary Turn off two-factor authentication
+// @Description Remove the authenticator and the recovery codes. Takes a
+// @Description code from the authenticator or a recovery code.
+// @Tags two-factor
+// @Security BearerAuth
+// @Accept json
+// @Param request body totpCodeRequest true "Code"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/2fa/totp [delete]
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	h.withCode(w, r, func(token, code string) {
+		_, err := h.client.DisableTOTP(
+			r.Context(),
+			&authv1.TOTPCodeRequest{AccessToken: token, Code: code},
+		)
+		if err != nil {
+			http.Error(w, grpcToHTTP(err), authStatus(err))
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace recovery codes
+// @Description Issue new recovery codes; the old ones stop working. Takes
+// @Description a code from the authenticator or a recovery code.
+// @Tags two-factor
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body totpCodeRequest true "Code"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/2fa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	h.withCode(w, r, func(token, code string) {
+		resp, err := h.client.RegenerateRecoveryCodes(
+			r.Context(),
+			&authv1.TOTPCodeRequest{AccessToken: token, Code: code},
+		)
+		if err != nil {
+			http.Error(w, grpcToHTTP(err), authStatus(err))
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: resp.Codes})
+	})
+}
+
+type createPersonalTokenRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int64    `json:"expires_in"`
+}
+
+type personalTokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	Token      string   `json:"token,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+}
+
+// CreatePersonalToken godoc
+// @Summary Create a personal token
+// @Description Create a long-lived token for scripts, sent as
+// @Description "Authorization: Bearer gft_...". Scopes are read,
+// @Description transactions:write and budgets:write. The token does not
+// @Description expire when expires_in (seconds) is 0. It is shown only here.
+// @Tags personal-tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body createPersonalTokenRequest true "Name, scopes and lifetime"
+// @Success 201 {object} personalTokenResponse
+// @Failure 400 {object} validationErrorResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/tokens [post]
+func (h *AuthHandler) CreatePersonalToken(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req createPersonalTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.client.CreatePersonalToken(
+		r.Context(),
+		&authv1.CreatePersonalTokenRequest{
+			AccessToken: token,
+			Name:        req.Name,
+			Scopes:      req.Scopes,
+			ExpiresIn:   req.ExpiresIn,
+		},
+	)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			invalidFields(w, err)
+			return
+		}
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, toPersonalToken(resp))
+}
+
+// ListPersonalTokens godoc
+// @Summary List personal tokens
+// @Description List the signed-in user's tokens that are not revoked,
+// @Description without the tokens themselves.
+// @Tags personal-tokens
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} personalTokenResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/tokens [get]
+func (h *AuthHandler) ListPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.client.ListPersonalTokens(
+		r.Context(),
+		&authv1.PersonalTokensRequest{AccessToken: token},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	tokens := make([]personalTokenResponse, 0, len(resp.Tokens))
+	for _, t := range resp.Tokens {
+		tokens = append(tokens, toPersonalToken(t))
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// RevokePersonalToken godoc
+// @Summary Revoke a personal token
+// @Description The token stops working at once.
+// @Tags personal-tokens
+// @Security BearerAuth
+// @Param id path string true "Token ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/tokens/{id} [delete]
+func (h *AuthHandler) RevokePersonalToken(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/auth/tokens/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.client.RevokePersonalToken(
+		r.Context(),
+		&authv1.RevokePersonalTokenRequest{AccessToken: token, Id: id},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toPersonalToken(t *authv1.PersonalToken) personalTokenResponse {
+	return personalTokenResponse{
+		ID:         t.Id,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		Token:      t.Token,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+}
+
+type sessionResponse struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	Current    bool   `json:"current"`
+}
+
+// ListSessions godoc
+// @Summary List sessions
+// @Description List where the signed-in user is signed in, most recently
+// @Description used first. A session is used when its tokens are
+// @Description refreshed; current marks the one of the request.
+// @Tags sessions
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} sessionResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.client.ListSessions(
+		r.Context(),
+		&authv1.SessionsRequest{AccessToken: token},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	sessions := make([]sessionResponse, 0, len(resp.Sessions))
+	for _, s := range resp.Sessions {
+		sessions = append(sessions, sessionResponse{
+			ID:         s.Id,
+			UserAgent:  s.UserAgent,
+			IP:         s.Ip,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			Current:    s.Current,
+		})
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Sign out of a session. Its refresh token stops working at
+// @Description once and its access tokens within seconds.
+// @Tags sessions
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/auth/sessions/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.client.RevokeSession(
+		r.Context(),
+		&authv1.RevokeSessionRequest{AccessToken: token, Id: id},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAllOtherSessions godoc
+// @Summary Revoke all other sessions
+// @Description Sign out everywhere but in the session of the request.
+// @Tags sessions
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Router /auth/sessions [delete]
+func (h *AuthHandler) RevokeAllOtherSessions(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.client.RevokeAllOtherSessions(
+		r.Context(),
+		&authv1.SessionsRequest{AccessToken: token},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StartOIDCLogin godoc
+// @Summary Sign in with an identity provider
+// @Description Redirects to the sign-in page of a configured identity
+// @Description provider, such as the company SSO. The provider sends the
+// @Description user back to /auth/oidc/{provider}/callback, which only
+// @Description completes sign-ins started in the same browser.
+// @Tags sso
+// @Param provider path string true "Provider name"
+// @Success 302
+// @Header 302 {string} Set-Cookie "oidc_state, for the callback"
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string "The provider cannot be reached"
+// @Router /auth/oidc/{provider} [get]
+func (h *AuthHandler) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider := strings.TrimPrefix(r.URL.Path, "/auth/oidc/")
+	if provider == "" || strings.Contains(provider, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	resp, err := h.client.StartOIDCLogin(
+		r.Context(),
+		&authv1.StartOIDCLoginRequest{Provider: provider},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	authURL, err := url.Parse(resp.Url)
+	if err != nil || authURL.Query().Get("state") == "" {
+		http.Error(w, "invalid sign-in url", http.StatusBadGateway)
+		return
+	}
+
+	// binds the sign-in to this browser, so nobody can send the user to
+	// the callback of a sign-in they started themselves
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    hashState(authURL.Query().Get("state")),
+		Path:     "/auth/oidc/",
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, resp.Url, http.StatusFound)
+}
+
+// OIDCCallback godoc
+// @Summary Complete sign-in with an identity provider
+// @Description Where the identity provider sends the user back. The first
+// @Description sign-in links the provider's account to the user with its
+// @Description email, or creates one; the provider must have verified the
+// @Description email, and so must an existing user. Users with two-factor
+// @Description authentication get a challenge as from /auth/login.
+// @Tags sso
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State of the sign-in"
+// @Success 200 {object} authResponse
+// @Success 202 {object} loginChallengeResponse "Second factor needed, see /auth/login/2fa"
+// @Failure 400 {object} map[string]string "Unknown or expired state, or the sign-in was started in another browser"
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "The provider has not verified the email"
+// @Failure 409 {object} map[string]string "The user has not verified the email"
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/auth/oidc/"), "/callback")
+	if !ok || provider == "" || strings.Contains(provider, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	// the state is good for one try either way
+	cookie, cookieErr := r.Cookie(oidcStateCookie)
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     "/auth/oidc/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		http.Error(w, "sign-in with identity provider failed: "+e, http.StatusUnauthorized)
+		return
+	}
+
+	if cookieErr != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(hashState(q.Get("state")))) != 1 {
+		http.Error(w, "sign-in was not started in this browser", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.client.FinishOIDCLogin(
+		r.Context(),
+		&authv1.FinishOIDCLoginRequest{
+			Provider: provider,
+			State:    q.Get("state"),
+			Code:     q.Get("code"),
+		},
+	)
+	if err != nil {
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+		return
+	}
+
+	if resp.Challenge != "" {
+		writeJSON(w, http.StatusAccepted, loginChallengeResponse{
+			Challenge: resp.Challenge,
+			ExpiresIn: resp.ExpiresIn,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAuthResponse(resp))
+}
+
+// hashState keeps the state itself out of the cookie.
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// withCode calls fn with the bearer token and the code from the body.
+func (h *AuthHandler) withCode(w http.ResponseWriter, r *http.Request, fn func(token, code string)) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req totpCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	fn(token, req.Code)
+}
+
+// bearerToken returns the access token of requests auth checks itself.
+func bearerToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		http.Error(w, "missing or invalid Authorization header", http.StatusUnauthorized)
+		return "", false
+	}
+	return token, true
+}
+
+func authStatus(err error) int {
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.Unavailable:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// loginFailed answers a failed sign-in. Rejected credentials, malformed
+// ones too, are 401; failures of auth itself keep their own status.
+func loginFailed(w http.ResponseWriter, err error) {
+	switch status.Code(err) {
+	case codes.ResourceExhausted:
+		tooManyAttempts(w, err)
+	case codes.Unauthenticated, codes.InvalidArgument:
+		http.Error(w, grpcToHTTP(err), http.StatusUnauthorized)
+	default:
+		http.Error(w, grpcToHTTP(err), authStatus(err))
+	}
+}
+
+// invalidFields answers a request auth rejected as invalid, with the
+// field problems it reported.
+func invalidFields(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	resp := validationErrorResponse{Error: st.Message()}
+
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			resp.Error = "invalid request"
+			resp.Fields = map[string]string{}
+			for _, v := range br.FieldViolations {
+				resp.Fields[v.Field] = v.Description
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusBadRequest, resp)
+}
+
+// tooManyAttempts answers a sign-in rejected after repeated failures,
+// with the wait auth asked for in Retry-After.
+func tooManyAttempts(w http.ResponseWriter, err error) {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			secs := int(math.Ceil(info.RetryDelay.AsDuration().Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+		}
+	}
+	http.Error(w, "too many failed attempts", http.StatusTooManyRequests)
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
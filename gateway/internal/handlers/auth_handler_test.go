@@ -7,26 +7,60 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	authv1 "gateway/auth/v1"
 	ledgerv1 "gateway/ledger/v1"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type mockAuthClient struct {
 	authv1.AuthServiceClient
-	register func(ctx context.Context, in *authv1.RegisterRequest, opts ...grpc.CallOption) (*authv1.AuthResponse, error)
+	register func(ctx context.Context, in *authv1.RegisterRequest, opts ...grpc.CallOption) (*authv1.RegisterResponse, error)
 	login    func(ctx context.Context, in *authv1.LoginRequest, opts ...grpc.CallOption) (*authv1.AuthResponse, error)
+	refresh  func(ctx context.Context, in *authv1.RefreshRequest, opts ...grpc.CallOption) (*authv1.AuthResponse, error)
+	logout   func(ctx context.Context, in *authv1.LogoutRequest, opts ...grpc.CallOption) (*authv1.LogoutResponse, error)
+	jwks     func(ctx context.Context, in *authv1.JWKSRequest, opts ...grpc.CallOption) (*authv1.JWKS, error)
+
+	verifyEmail   func(ctx context.Context, in *authv1.VerifyEmailRequest, opts ...grpc.CallOption) (*authv1.VerifyEmailResponse, error)
+	resetRequest  func(ctx context.Context, in *authv1.EmailRequest, opts ...grpc.CallOption) (*authv1.SendEmailResponse, error)
+	resetPassword func(ctx context.Context, in *authv1.ResetPasswordRequest, opts ...grpc.CallOption) (*authv1.ResetPasswordResponse, error)
+
+	loginTwoFactor func(ctx context.Context, in *authv1.LoginTwoFactorRequest, opts ...grpc.CallOption) (*authv1.AuthResponse, error)
+	enrollTOTP     func(ctx context.Context, in *authv1.EnrollTOTPRequest, opts ...grpc.CallOption) (*authv1.TOTPEnrollment, error)
+	confirmTOTP    func(ctx context.Context, in *authv1.TOTPCodeRequest, opts ...grpc.CallOption) (*authv1.RecoveryCodes, error)
+
+	deleteAccount func(ctx context.Context, in *authv1.DeleteAccountRequest, opts ...grpc.CallOption) (*authv1.AccountDeletion, error)
+	getDeletion   func(ctx context.Context, in *authv1.AccountDeletionRequest, opts ...grpc.CallOption) (*authv1.AccountDeletion, error)
+
+	createToken func(ctx context.Context, in *authv1.CreatePersonalTokenRequest, opts ...grpc.CallOption) (*authv1.PersonalToken, error)
+	listTokens  func(ctx context.Context, in *authv1.PersonalTokensRequest, opts ...grpc.CallOption) (*authv1.PersonalTokens, error)
+	revokeToken func(ctx context.Context, in *authv1.RevokePersonalTokenRequest, opts ...grpc.CallOption) (*authv1.RevokePersonalTokenResponse, error)
+
+	listSessions  func(ctx context.Context, in *authv1.SessionsRequest, opts ...grpc.CallOption) (*authv1.Sessions, error)
+	revokeSession func(ctx context.Context, in *authv1.RevokeSessionRequest, opts ...grpc.CallOption) (*authv1.RevokeSessionResponse, error)
+	revokeOthers  func(ctx context.Context, in *authv1.SessionsRequest, opts ...grpc.CallOption) (*authv1.RevokeSessionResponse, error)
+
+	listUsers   func(ctx context.Context, in *authv1.AdminRequest, opts ...grpc.CallOption) (*authv1.Users, error)
+	setUserRole func(ctx context.Context, in *authv1.SetUserRoleRequest, opts ...grpc.CallOption) (*authv1.User, error)
+	userStats   func(ctx context.Context, in *authv1.AdminRequest, opts ...grpc.CallOption) (*authv1.UserStats, error)
+
+	startOIDC  func(ctx context.Context, in *authv1.StartOIDCLoginRequest, opts ...grpc.CallOption) (*authv1.StartOIDCLoginResponse, error)
+	finishOIDC func(ctx context.Context, in *authv1.FinishOIDCLoginRequest, opts ...grpc.CallOption) (*authv1.AuthResponse, error)
 }
 
 func (m *mockAuthClient) Register(
 	ctx context.Context,
 	in *authv1.RegisterRequest,
 	opts ...grpc.CallOption,
-) (*authv1.AuthResponse, error) {
+) (*authv1.RegisterResponse, error) {
 	return m.register(ctx, in, opts...)
 }
 
@@ -38,19 +72,317 @@ func (m *mockAuthClient) Login(
 	return m.login(ctx, in, opts...)
 }
 
+func (m *mockAuthClient) LoginTwoFactor(
+	ctx context.Context,
+	in *authv1.LoginTwoFactorRequest,
+	opts ...grpc.CallOption,
+) (*authv1.AuthResponse, error) {
+	return m.loginTwoFactor(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) EnrollTOTP(
+	ctx context.Context,
+	in *authv1.EnrollTOTPRequest,
+	opts ...grpc.CallOption,
+) (*authv1.TOTPEnrollment, error) {
+	return m.enrollTOTP(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) ConfirmTOTP(
+	ctx context.Context,
+	in *authv1.TOTPCodeRequest,
+	opts ...grpc.CallOption,
+) (*authv1.RecoveryCodes, error) {
+	return m.confirmTOTP(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) Refresh(
+	ctx context.Context,
+	in *authv1.RefreshRequest,
+	opts ...grpc.CallOption,
+) (*authv1.AuthResponse, error) {
+	return m.refresh(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) Logout(
+	ctx context.Context,
+	in *authv1.LogoutRequest,
+	opts ...grpc.CallOption,
+) (*authv1.LogoutResponse, error) {
+	return m.logout(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) VerifyEmail(
+	ctx context.Context,
+	in *authv1.VerifyEmailRequest,
+	opts ...grpc.CallOption,
+) (*authv1.VerifyEmailResponse, error) {
+	return m.verifyEmail(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) RequestPasswordReset(
+	ctx context.Context,
+	in *authv1.EmailRequest,
+	opts ...grpc.CallOption,
+) (*authv1.SendEmailResponse, error) {
+	return m.resetRequest(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) ResetPassword(
+	ctx context.Context,
+	in *authv1.ResetPasswordRequest,
+	opts ...grpc.CallOption,
+) (*authv1.ResetPasswordResponse, error) {
+	return m.resetPassword(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) GetJWKS(
+	ctx context.Context,
+	in *authv1.JWKSRequest,
+	opts ...grpc.CallOption,
+) (*authv1.JWKS, error) {
+	return m.jwks(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) DeleteAccount(
+	ctx context.Context,
+	in *authv1.DeleteAccountRequest,
+	opts ...grpc.CallOption,
+) (*authv1.AccountDeletion, error) {
+	return m.deleteAccount(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) GetAccountDeletion(
+	ctx context.Context,
+	in *authv1.AccountDeletionRequest,
+	opts ...grpc.CallOption,
+) (*authv1.AccountDeletion, error) {
+	return m.getDeletion(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) CreatePersonalToken(
+	ctx context.Context,
+	in *authv1.CreatePersonalTokenRequest,
+	opts ...grpc.CallOption,
+) (*authv1.PersonalToken, error) {
+	return m.createToken(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) ListPersonalTokens(
+	ctx context.Context,
+	in *authv1.PersonalTokensRequest,
+	opts ...grpc.CallOption,
+) (*authv1.PersonalTokens, error) {
+	return m.listTokens(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) RevokePersonalToken(
+	ctx context.Context,
+	in *authv1.RevokePersonalTokenRequest,
+	opts ...grpc.CallOption,
+) (*authv1.RevokePersonalTokenResponse, error) {
+	return m.revokeToken(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) ListSessions(
+	ctx context.Context,
+	in *authv1.SessionsRequest,
+	opts ...grpc.CallOption,
+) (*authv1.Sessions, error) {
+	return m.listSessions(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) RevokeSession(
+	ctx context.Context,
+	in *authv1.RevokeSessionRequest,
+	opts ...grpc.CallOption,
+) (*authv1.RevokeSessionResponse, error) {
+	return m.revokeSession(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) RevokeAllOtherSessions(
+	ctx context.Context,
+	in *authv1.SessionsRequest,
+	opts ...grpc.CallOption,
+) (*authv1.RevokeSessionResponse, error) {
+	return m.revokeOthers(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) ListUsers(
+	ctx context.Context,
+	in *authv1.AdminRequest,
+	opts ...grpc.CallOption,
+) (*authv1.Users, error) {
+	return m.listUsers(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) SetUserRole(
+	ctx context.Context,
+	in *authv1.SetUserRoleRequest,
+	opts ...grpc.CallOption,
+) (*authv1.User, error) {
+	return m.setUserRole(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) GetUserStats(
+	ctx context.Context,
+	in *authv1.AdminRequest,
+	opts ...grpc.CallOption,
+) (*authv1.UserStats, error) {
+	return m.userStats(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) StartOIDCLogin(
+	ctx context.Context,
+	in *authv1.StartOIDCLoginRequest,
+	opts ...grpc.CallOption,
+) (*authv1.StartOIDCLoginResponse, error) {
+	return m.startOIDC(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) FinishOIDCLogin(
+	ctx context.Context,
+	in *authv1.FinishOIDCLoginRequest,
+	opts ...grpc.CallOption,
+) (*authv1.AuthResponse, error) {
+	return m.finishOIDC(ctx, in, opts...)
+}
+
 type mockLedgerClient struct {
 	ledgerv1.LedgerServiceClient
 	list func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ledgerv1.ListTransactionsResponse, error)
+	bulk func(ctx context.Context, in *ledgerv1.BulkAddTransactionsRequest, opts ...grpc.CallOption) (*ledgerv1.BulkAddTransactionsResponse, error)
+
+	saveProfile func(ctx context.Context, in *ledgerv1.ImportProfile, opts ...grpc.CallOption) (*ledgerv1.ImportProfile, error)
+	getProfile  func(ctx context.Context, in *ledgerv1.ImportProfileRequest, opts ...grpc.CallOption) (*ledgerv1.ImportProfile, error)
+	duplicates  func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ledgerv1.FindDuplicatesResponse, error)
+	importTx    func(ctx context.Context, opts ...grpc.CallOption) (ledgerv1.LedgerService_ImportTransactionsClient, error)
+	export      func(ctx context.Context, in *ledgerv1.ExportTransactionsRequest, opts ...grpc.CallOption) (ledgerv1.LedgerService_ExportTransactionsClient, error)
+	createJob   func(ctx context.Context, opts ...grpc.CallOption) (ledgerv1.LedgerService_CreateImportJobClient, error)
+	getJob      func(ctx context.Context, in *ledgerv1.ImportJobRequest, opts ...grpc.CallOption) (*ledgerv1.ImportJob, error)
+	cancelJob   func(ctx context.Context, in *ledgerv1.ImportJobRequest, opts ...grpc.CallOption) (*ledgerv1.ImportJob, error)
+	backup      func(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (ledgerv1.LedgerService_ExportBackupClient, error)
+	restore     func(ctx context.Context, opts ...grpc.CallOption) (ledgerv1.LedgerService_RestoreBackupClient, error)
+	invite      func(ctx context.Context, in *ledgerv1.CreateInvitationRequest, opts ...grpc.CallOption) (*ledgerv1.Invitation, error)
+	setRole     func(ctx context.Context, in *ledgerv1.SetMemberRoleRequest, opts ...grpc.CallOption) (*ledgerv1.Member, error)
+	spending    func(ctx context.Context, in *ledgerv1.ReportSummaryRequest, opts ...grpc.CallOption) (*ledgerv1.MemberSpendingResponse, error)
+}
+
+func (m *mockLedgerClient) BulkAddTransactions(
+	ctx context.Context,
+	in *ledgerv1.BulkAddTransactionsRequest,
+	opts ...grpc.CallOption,
+) (*ledgerv1.BulkAddTransactionsResponse, error) {
+	return m.bulk(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) ImportTransactions(
+	ctx context.Context,
+	opts ...grpc.CallOption,
+) (ledgerv1.LedgerService_ImportTransactionsClient, error) {
+	return m.importTx(ctx, opts...)
+}
+
+func (m *mockLedgerClient) ExportTransactions(
+	ctx context.Context,
+	in *ledgerv1.ExportTransactionsRequest,
+	opts ...grpc.CallOption,
+) (ledgerv1.LedgerService_ExportTransactionsClient, error) {
+	return m.export(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) CreateImportJob(
+	ctx context.Context,
+	opts ...grpc.CallOption,
+) (ledgerv1.LedgerService_CreateImportJobClient, error) {
+	return m.createJob(ctx, opts...)
+}
+
+func (m *mockLedgerClient) GetImportJob(
+	ctx context.Context,
+	in *ledgerv1.ImportJobRequest,
+	opts ...grpc.CallOption,
+) (*ledgerv1.ImportJob, error) {
+	return m.getJob(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) CancelImportJob(
+	ctx context.Context,
+	in *ledgerv1.ImportJobRequest,
+	opts ...grpc.CallOption,
+) (*ledgerv1.ImportJob, error) {
+	return m.cancelJob(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) ExportBackup(
+	ctx context.Context,
+	in *emptypb.Empty,
+	opts ...grpc.CallOption,
+) (ledgerv1.LedgerService_ExportBackupClient, error) {
+	return m.backup(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) RestoreBackup(
+	ctx context.Context,
+	opts ...grpc.CallOption,
+) (ledgerv1.LedgerService_RestoreBackupClient, error) {
+	return m.restore(ctx, opts...)
+}
+
+func (m *mockLedgerClient) SaveImportProfile(
+	ctx context.Context,
+	in *ledgerv1.ImportProfile,
+	opts ...grpc.CallOption,
+) (*ledgerv1.ImportProfile, error) {
+	return m.saveProfile(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) GetImportProfile(
+	ctx context.Context,
+	in *ledgerv1.ImportProfileRequest,
+	opts ...grpc.CallOption,
+) (*ledgerv1.ImportProfile, error) {
+	return m.getProfile(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) FindDuplicates(
+	ctx context.Context,
+	in *emptypb.Empty,
+	opts ...grpc.CallOption,
+) (*ledgerv1.FindDuplicatesResponse, error) {
+	return m.duplicates(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) CreateInvitation(
+	ctx context.Context,
+	in *ledgerv1.CreateInvitationRequest,
+	opts ...grpc.CallOption,
+) (*ledgerv1.Invitation, error) {
+	return m.invite(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) SetMemberRole(
+	ctx context.Context,
+	in *ledgerv1.SetMemberRoleRequest,
+	opts ...grpc.CallOption,
+) (*ledgerv1.Member, error) {
+	return m.setRole(ctx, in, opts...)
+}
+
+func (m *mockLedgerClient) GetMemberSpending(
+	ctx context.Context,
+	in *ledgerv1.ReportSummaryRequest,
+	opts ...grpc.CallOption,
+) (*ledgerv1.MemberSpendingResponse, error) {
+	return m.spending(ctx, in, opts...)
 }
 
 func TestAuthRegister_Success(t *testing.T) {
 	client := &mockAuthClient{
-		register: func(ctx context.Context, in *authv1.RegisterRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+		register: func(ctx context.Context, in *authv1.RegisterRequest, _ ...grpc.CallOption) (*authv1.RegisterResponse, error) {
 			require.Equal(t, "test@mail.com", in.Email)
 			require.Equal(t, "secret", in.Password)
-			return &authv1.AuthResponse{
-				AccessToken: "jwt-token",
-			}, nil
+			return &authv1.RegisterResponse{}, nil
 		},
 	}
 
@@ -63,12 +395,56 @@ func TestAuthRegister_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 	h.Register(w, req)
 
-	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Empty(t, w.Body.String())
+}
 
-	var resp authResponse
+func TestAuthRegister_TooManyAttempts(t *testing.T) {
+	client := &mockAuthClient{
+		register: func(ctx context.Context, in *authv1.RegisterRequest, _ ...grpc.CallOption) (*authv1.RegisterResponse, error) {
+			st, err := status.New(codes.ResourceExhausted, "too many failed attempts").
+				WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(90 * time.Second)})
+			require.NoError(t, err)
+			return nil, st.Err()
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.Register(w, httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBufferString(`{"email":"test@mail.com","password":"secret"}`)))
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "90", w.Header().Get("Retry-After"))
+}
+
+func TestAuthRegister_InvalidFields(t *testing.T) {
+	client := &mockAuthClient{
+		register: func(ctx context.Context, in *authv1.RegisterRequest, _ ...grpc.CallOption) (*authv1.RegisterResponse, error) {
+			st, err := status.New(codes.InvalidArgument, "invalid request: password: must be at least 8 characters").
+				WithDetails(&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: "password", Description: "must be at least 8 characters"},
+				}})
+			require.NoError(t, err)
+			return nil, st.Err()
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.Register(w, httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBufferString(`{"email":"test@mail.com","password":"short"}`)))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp validationErrorResponse
 	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
-	require.Equal(t, "jwt-token", resp.Token)
+	require.Equal(t, validationErrorResponse{
+		Error:  "invalid request",
+		Fields: map[string]string{"password": "must be at least 8 characters"},
+	}, resp)
 }
+
 func TestAuthLogin_InvalidJSON(t *testing.T) {
 	h := NewAuthHandler(&mockAuthClient{})
 
@@ -84,3 +460,674 @@ func TestAuthLogin_InvalidJSON(t *testing.T) {
 
 	require.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+func TestAuthLogin_Challenge(t *testing.T) {
+	client := &mockAuthClient{
+		login: func(ctx context.Context, in *authv1.LoginRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+			return &authv1.AuthResponse{Challenge: "challenge", ExpiresIn: 300}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(`{"email":"test@mail.com","password":"secret"}`)))
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var resp loginChallengeResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, loginChallengeResponse{Challenge: "challenge", ExpiresIn: 300}, resp)
+}
+
+func TestAuthLogin_TooManyAttempts(t *testing.T) {
+	client := &mockAuthClient{
+		login: func(ctx context.Context, in *authv1.LoginRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+			st, err := status.New(codes.ResourceExhausted, "too many failed attempts").
+				WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)})
+			require.NoError(t, err)
+			return nil, st.Err()
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(`{"email":"test@mail.com","password":"secret"}`)))
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+func TestAuthLogin_BackendError(t *testing.T) {
+	client := &mockAuthClient{
+		login: func(ctx context.Context, in *authv1.LoginRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+			if in.Password == "wrong" {
+				return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+			}
+			return nil, status.Error(codes.Internal, "database is down")
+		},
+		loginTwoFactor: func(ctx context.Context, in *authv1.LoginTwoFactorRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(`{"email":"test@mail.com","password":"wrong"}`)))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// a failing backend is not a wrong password
+	w = httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(`{"email":"test@mail.com","password":"secret"}`)))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = httptest.NewRecorder()
+	h.LoginTwoFactor(w, httptest.NewRequest(http.MethodPost, "/auth/login/2fa", bytes.NewBufferString(`{"challenge":"challenge","code":"123456"}`)))
+	require.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestAuthLoginTwoFactor(t *testing.T) {
+	client := &mockAuthClient{
+		loginTwoFactor: func(ctx context.Context, in *authv1.LoginTwoFactorRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+			if in.Code != "123456" {
+				return nil, status.Error(codes.Unauthenticated, "invalid code")
+			}
+			return &authv1.AuthResponse{AccessToken: "jwt-token", RefreshToken: "refresh-token", ExpiresIn: 900}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.LoginTwoFactor(w, httptest.NewRequest(http.MethodPost, "/auth/login/2fa", bytes.NewBufferString(`{"challenge":"challenge","code":"123456"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp authResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "jwt-token", resp.Token)
+
+	w = httptest.NewRecorder()
+	h.LoginTwoFactor(w, httptest.NewRequest(http.MethodPost, "/auth/login/2fa", bytes.NewBufferString(`{"challenge":"challenge","code":"000000"}`)))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthEnrollTOTP(t *testing.T) {
+	client := &mockAuthClient{
+		enrollTOTP: func(ctx context.Context, in *authv1.EnrollTOTPRequest, _ ...grpc.CallOption) (*authv1.TOTPEnrollment, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			return &authv1.TOTPEnrollment{Secret: "SECRET", Uri: "otpauth://totp/GoFinance:test", QrCode: []byte("png")}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.EnrollTOTP(w, httptest.NewRequest(http.MethodPost, "/auth/2fa/totp", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/2fa/totp", nil)
+	req.Header.Set("Authorization", "Bearer jwt-token")
+	w = httptest.NewRecorder()
+	h.EnrollTOTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	require.JSONEq(t, `{"secret":"SECRET","uri":"otpauth://totp/GoFinance:test","qr_code":"cG5n"}`, w.Body.String())
+}
+
+func TestAuthConfirmTOTP_AlreadyEnabled(t *testing.T) {
+	client := &mockAuthClient{
+		confirmTOTP: func(ctx context.Context, in *authv1.TOTPCodeRequest, _ ...grpc.CallOption) (*authv1.RecoveryCodes, error) {
+			return nil, status.Error(codes.FailedPrecondition, "two-factor authentication already enabled")
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/2fa/totp/confirm", bytes.NewBufferString(`{"code":"123456"}`))
+	req.Header.Set("Authorization", "Bearer jwt-token")
+	w := httptest.NewRecorder()
+	h.ConfirmTOTP(w, req)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestAuthRefresh(t *testing.T) {
+	client := &mockAuthClient{
+		refresh: func(ctx context.Context, in *authv1.RefreshRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+			if in.RefreshToken != "refresh-token" {
+				return nil, status.Error(codes.Unauthenticated, "refresh token reused, session revoked")
+			}
+			return &authv1.AuthResponse{AccessToken: "jwt-token-2", RefreshToken: "refresh-token-2", ExpiresIn: 900}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.Refresh(w, httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBufferString(`{"refresh_token":"refresh-token"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp authResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, authResponse{Token: "jwt-token-2", RefreshToken: "refresh-token-2", ExpiresIn: 900}, resp)
+
+	w = httptest.NewRecorder()
+	h.Refresh(w, httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBufferString(`{"refresh_token":"old"}`)))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	h.Refresh(w, httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBufferString(`{}`)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuthLogout(t *testing.T) {
+	var revoked string
+	client := &mockAuthClient{
+		logout: func(ctx context.Context, in *authv1.LogoutRequest, _ ...grpc.CallOption) (*authv1.LogoutResponse, error) {
+			revoked = in.RefreshToken
+			return &authv1.LogoutResponse{}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.Logout(w, httptest.NewRequest(http.MethodPost, "/auth/logout", bytes.NewBufferString(`{"refresh_token":"refresh-token"}`)))
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "refresh-token", revoked)
+}
+
+func TestAuthVerifyEmail_InvalidToken(t *testing.T) {
+	client := &mockAuthClient{
+		verifyEmail: func(ctx context.Context, in *authv1.VerifyEmailRequest, _ ...grpc.CallOption) (*authv1.VerifyEmailResponse, error) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or expired token")
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.VerifyEmail(w, httptest.NewRequest(http.MethodPost, "/auth/email/verify", bytes.NewBufferString(`{"token":"used"}`)))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuthForgotPassword(t *testing.T) {
+	var email string
+	client := &mockAuthClient{
+		resetRequest: func(ctx context.Context, in *authv1.EmailRequest, _ ...grpc.CallOption) (*authv1.SendEmailResponse, error) {
+			email = in.Email
+			return &authv1.SendEmailResponse{}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.ForgotPassword(w, httptest.NewRequest(http.MethodPost, "/auth/password/forgot", bytes.NewBufferString(`{"email":"test@mail.com"}`)))
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, "test@mail.com", email)
+}
+
+func TestAuthResetPassword(t *testing.T) {
+	var got *authv1.ResetPasswordRequest
+	client := &mockAuthClient{
+		resetPassword: func(ctx context.Context, in *authv1.ResetPasswordRequest, _ ...grpc.CallOption) (*authv1.ResetPasswordResponse, error) {
+			got = in
+			return &authv1.ResetPasswordResponse{}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/auth/password/reset", bytes.NewBufferString(`{"token":"reset-token","password":"new-password"}`)))
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "reset-token", got.Token)
+	require.Equal(t, "new-password", got.Password)
+
+	w = httptest.NewRecorder()
+	h.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/auth/password/reset", bytes.NewBufferString(`{"token":"reset-token"}`)))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuthJWKS(t *testing.T) {
+	client := &mockAuthClient{
+		jwks: func(ctx context.Context, in *authv1.JWKSRequest, _ ...grpc.CallOption) (*authv1.JWKS, error) {
+			return &authv1.JWKS{Keys: []*authv1.JWK{
+				{Kty: "OKP", Crv: "Ed25519", X: "pub", Kid: "key-1", Alg: "EdDSA", Use: "sig"},
+			}}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.JWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	require.JSONEq(t, `{"keys":[{"kty":"OKP","crv":"Ed25519","x":"pub","kid":"key-1","alg":"EdDSA","use":"sig"}]}`, w.Body.String())
+}
+
+func TestAuthDeleteAccount_Accepted(t *testing.T) {
+	client := &mockAuthClient{
+		deleteAccount: func(ctx context.Context, in *authv1.DeleteAccountRequest, _ ...grpc.CallOption) (*authv1.AccountDeletion, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			require.Equal(t, "secret", in.Password)
+			return &authv1.AccountDeletion{
+				Id:          "del-1",
+				Status:      "pending",
+				Attempts:    1,
+				RequestedAt: "2026-01-02T03:04:05Z",
+			}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodDelete, "/auth/account", bytes.NewBufferString(`{"password":"secret"}`))
+	req.Header.Set("Authorization", "Bearer jwt-token")
+
+	w := httptest.NewRecorder()
+	h.DeleteAccount(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, "/auth/account/deletions/del-1", w.Header().Get("Location"))
+
+	var resp accountDeletionResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, accountDeletionResponse{
+		ID:          "del-1",
+		Status:      "pending",
+		Attempts:    1,
+		RequestedAt: "2026-01-02T03:04:05Z",
+	}, resp)
+}
+
+func TestAuthDeleteAccount_Unauthorized(t *testing.T) {
+	client := &mockAuthClient{
+		deleteAccount: func(ctx context.Context, in *authv1.DeleteAccountRequest, _ ...grpc.CallOption) (*authv1.AccountDeletion, error) {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodDelete, "/auth/account", bytes.NewBufferString(`{"password":"secret"}`))
+	w := httptest.NewRecorder()
+	h.DeleteAccount(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/auth/account", bytes.NewBufferString(`{"password":"wrong"}`))
+	req.Header.Set("Authorization", "Bearer jwt-token")
+	w = httptest.NewRecorder()
+	h.DeleteAccount(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthDeleteAccount_TooManyAttempts(t *testing.T) {
+	client := &mockAuthClient{
+		deleteAccount: func(ctx context.Context, in *authv1.DeleteAccountRequest, _ ...grpc.CallOption) (*authv1.AccountDeletion, error) {
+			st, err := status.New(codes.ResourceExhausted, "too many failed attempts").
+				WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Minute)})
+			require.NoError(t, err)
+			return nil, st.Err()
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodDelete, "/auth/account", bytes.NewBufferString(`{"password":"guess"}`))
+	req.Header.Set("Authorization", "Bearer jwt-token")
+	w := httptest.NewRecorder()
+	h.DeleteAccount(w, req)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "60", w.Header().Get("Retry-After"))
+}
+
+func TestAuthGetAccountDeletion(t *testing.T) {
+	client := &mockAuthClient{
+		getDeletion: func(ctx context.Context, in *authv1.AccountDeletionRequest, _ ...grpc.CallOption) (*authv1.AccountDeletion, error) {
+			if in.Id != "del-1" {
+				return nil, status.Error(codes.NotFound, "account deletion not found")
+			}
+			return &authv1.AccountDeletion{
+				Id:          "del-1",
+				Status:      "completed",
+				Attempts:    2,
+				RequestedAt: "2026-01-02T03:04:05Z",
+				CompletedAt: "2026-01-02T03:05:00Z",
+			}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.GetAccountDeletion(w, httptest.NewRequest(http.MethodGet, "/auth/account/deletions/del-1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp accountDeletionResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "completed", resp.Status)
+	require.Equal(t, "2026-01-02T03:05:00Z", resp.CompletedAt)
+
+	w = httptest.NewRecorder()
+	h.GetAccountDeletion(w, httptest.NewRequest(http.MethodGet, "/auth/account/deletions/del-2", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAuthCreatePersonalToken(t *testing.T) {
+	client := &mockAuthClient{
+		createToken: func(ctx context.Context, in *authv1.CreatePersonalTokenRequest, _ ...grpc.CallOption) (*authv1.PersonalToken, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			require.Equal(t, "backup script", in.Name)
+			require.Equal(t, []string{"read"}, in.Scopes)
+			require.Equal(t, int64(3600), in.ExpiresIn)
+			return &authv1.PersonalToken{
+				Id:        "pt-1",
+				Name:      in.Name,
+				Scopes:    in.Scopes,
+				Token:     "gft_secret",
+				CreatedAt: "2026-01-02T03:04:05Z",
+				ExpiresAt: "2026-01-02T04:04:05Z",
+			}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/tokens", bytes.NewBufferString(`{"name":"backup script","scopes":["read"],"expires_in":3600}`))
+	req.Header.Set("Authorization", "Bearer jwt-token")
+
+	w := httptest.NewRecorder()
+	h.CreatePersonalToken(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	var resp personalTokenResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, personalTokenResponse{
+		ID:        "pt-1",
+		Name:      "backup script",
+		Scopes:    []string{"read"},
+		Token:     "gft_secret",
+		CreatedAt: "2026-01-02T03:04:05Z",
+		ExpiresAt: "2026-01-02T04:04:05Z",
+	}, resp)
+}
+
+func TestAuthCreatePersonalToken_InvalidScope(t *testing.T) {
+	client := &mockAuthClient{
+		createToken: func(ctx context.Context, in *authv1.CreatePersonalTokenRequest, _ ...grpc.CallOption) (*authv1.PersonalToken, error) {
+			st, err := status.New(codes.InvalidArgument, "invalid request: scopes: unknown scope admin").
+				WithDetails(&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: "scopes", Description: "unknown scope admin"},
+				}})
+			require.NoError(t, err)
+			return nil, st.Err()
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/tokens", bytes.NewBufferString(`{"name":"script","scopes":["admin"]}`))
+	req.Header.Set("Authorization", "Bearer jwt-token")
+
+	w := httptest.NewRecorder()
+	h.CreatePersonalToken(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp validationErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, map[string]string{"scopes": "unknown scope admin"}, resp.Fields)
+}
+
+func TestAuthListPersonalTokens(t *testing.T) {
+	client := &mockAuthClient{
+		listTokens: func(ctx context.Context, in *authv1.PersonalTokensRequest, _ ...grpc.CallOption) (*authv1.PersonalTokens, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			return &authv1.PersonalTokens{Tokens: []*authv1.PersonalToken{
+				{Id: "pt-1", Name: "script", Scopes: []string{"read"}, CreatedAt: "2026-01-02T03:04:05Z", LastUsedAt: "2026-01-03T00:00:00Z"},
+			}}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/tokens", nil)
+	req.Header.Set("Authorization", "Bearer jwt-token")
+
+	w := httptest.NewRecorder()
+	h.ListPersonalTokens(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp []personalTokenResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp, 1)
+	require.Equal(t, "2026-01-03T00:00:00Z", resp[0].LastUsedAt)
+	require.Empty(t, resp[0].Token)
+}
+
+func TestAuthRevokePersonalToken(t *testing.T) {
+	client := &mockAuthClient{
+		revokeToken: func(ctx context.Context, in *authv1.RevokePersonalTokenRequest, _ ...grpc.CallOption) (*authv1.RevokePersonalTokenResponse, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			if in.Id != "pt-1" {
+				return nil, status.Error(codes.NotFound, "personal token not found")
+			}
+			return &authv1.RevokePersonalTokenResponse{}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	for id, want := range map[string]int{"pt-1": http.StatusNoContent, "pt-2": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/auth/tokens/"+id, nil)
+		req.Header.Set("Authorization", "Bearer jwt-token")
+
+		w := httptest.NewRecorder()
+		h.RevokePersonalToken(w, req)
+		require.Equal(t, want, w.Code, id)
+	}
+}
+
+func TestAuthListSessions(t *testing.T) {
+	client := &mockAuthClient{
+		listSessions: func(ctx context.Context, in *authv1.SessionsRequest, _ ...grpc.CallOption) (*authv1.Sessions, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			return &authv1.Sessions{Sessions: []*authv1.Session{
+				{Id: "s-1", UserAgent: "Firefox", Ip: "203.0.113.7", CreatedAt: "2026-01-02T03:04:05Z", LastUsedAt: "2026-01-02T04:04:05Z", Current: true},
+			}}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/sessions", nil)
+	req.Header.Set("Authorization", "Bearer jwt-token")
+
+	w := httptest.NewRecorder()
+	h.ListSessions(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `[{
+		"id": "s-1",
+		"user_agent": "Firefox",
+		"ip": "203.0.113.7",
+		"created_at": "2026-01-02T03:04:05Z",
+		"last_used_at": "2026-01-02T04:04:05Z",
+		"current": true
+	}]`, w.Body.String())
+}
+
+func TestAuthRevokeSession(t *testing.T) {
+	client := &mockAuthClient{
+		revokeSession: func(ctx context.Context, in *authv1.RevokeSessionRequest, _ ...grpc.CallOption) (*authv1.RevokeSessionResponse, error) {
+			require.Equal(t, "jwt-token", in.AccessToken)
+			if in.Id != "s-1" {
+				return nil, status.Error(codes.NotFound, "session not found")
+			}
+			return &authv1.RevokeSessionResponse{}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	for id, want := range map[string]int{"s-1": http.StatusNoContent, "s-2": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/auth/sessions/"+id, nil)
+		req.Header.Set("Authorization", "Bearer jwt-token")
+
+		w := httptest.NewRecorder()
+		h.RevokeSession(w, req)
+		require.Equal(t, want, w.Code, id)
+	}
+}
+
+func TestAuthRevokeAllOtherSessions(t *testing.T) {
+	client := &mockAuthClient{
+		revokeOthers: func(ctx context.Context, in *authv1.SessionsRequest, _ ...grpc.CallOption) (*authv1.RevokeSessionResponse, error) {
+			if in.AccessToken != "jwt-token" {
+				return nil, status.Error(codes.Unauthenticated, "invalid token")
+			}
+			return &authv1.RevokeSessionResponse{}, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodDelete, "/auth/sessions", nil)
+	req.Header.Set("Authorization", "Bearer jwt-token")
+	w := httptest.NewRecorder()
+	h.RevokeAllOtherSessions(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/auth/sessions", nil)
+	req.Header.Set("Authorization", "Bearer expired")
+	w = httptest.NewRecorder()
+	h.RevokeAllOtherSessions(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthStartOIDCLogin(t *testing.T) {
+	client := &mockAuthClient{
+		startOIDC: func(ctx context.Context, in *authv1.StartOIDCLoginRequest, _ ...grpc.CallOption) (*authv1.StartOIDCLoginResponse, error) {
+			switch in.Provider {
+			case "company":
+				return &authv1.StartOIDCLoginResponse{Url: "https://sso.example.com/authorize?state=s"}, nil
+			case "down":
+				return nil, status.Error(codes.Unavailable, "identity provider unavailable")
+			default:
+				return nil, status.Error(codes.NotFound, "unknown identity provider")
+			}
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/company", nil)
+	w := httptest.NewRecorder()
+	h.StartOIDCLogin(w, req)
+	require.Equal(t, http.StatusFound, w.Code)
+	require.Equal(t, "https://sso.example.com/authorize?state=s", w.Header().Get("Location"))
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, oidcStateCookie, cookies[0].Name)
+	require.Equal(t, hashState("s"), cookies[0].Value)
+	require.Equal(t, "/auth/oidc/", cookies[0].Path)
+	require.True(t, cookies[0].HttpOnly)
+	require.True(t, cookies[0].Secure)
+	require.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+
+	for provider, want := range map[string]int{"other": http.StatusNotFound, "down": http.StatusBadGateway} {
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/"+provider, nil)
+		w := httptest.NewRecorder()
+		h.StartOIDCLogin(w, req)
+		require.Equal(t, want, w.Code, provider)
+	}
+}
+
+// oidcCallback returns a request to the callback of a sign-in the
+// browser started with state.
+func oidcCallback(query, state string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/company/callback?"+query, nil)
+	req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: hashState(state)})
+	return req
+}
+
+func TestAuthOIDCCallback(t *testing.T) {
+	client := &mockAuthClient{
+		finishOIDC: func(ctx context.Context, in *authv1.FinishOIDCLoginRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+			require.Equal(t, "company", in.Provider)
+			switch in.State {
+			case "state":
+				require.Equal(t, "code", in.Code)
+				return &authv1.AuthResponse{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 900}, nil
+			case "2fa":
+				return &authv1.AuthResponse{Challenge: "challenge", ExpiresIn: 300}, nil
+			default:
+				return nil, status.Error(codes.InvalidArgument, "invalid or expired sign-in state")
+			}
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	w := httptest.NewRecorder()
+	h.OIDCCallback(w, oidcCallback("code=code&state=state", "state"))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"token": "access", "refresh_token": "refresh", "expires_in": 900}`, w.Body.String())
+
+	// the cookie is cleared
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, oidcStateCookie, cookies[0].Name)
+	require.Negative(t, cookies[0].MaxAge)
+
+	w = httptest.NewRecorder()
+	h.OIDCCallback(w, oidcCallback("code=code&state=2fa", "2fa"))
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	w = httptest.NewRecorder()
+	h.OIDCCallback(w, oidcCallback("code=code&state=forged", "forged"))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the user cancelled at the provider
+	w = httptest.NewRecorder()
+	h.OIDCCallback(w, oidcCallback("error=access_denied&state=state", "state"))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthOIDCCallback_OtherBrowser(t *testing.T) {
+	client := &mockAuthClient{
+		finishOIDC: func(ctx context.Context, in *authv1.FinishOIDCLoginRequest, _ ...grpc.CallOption) (*authv1.AuthResponse, error) {
+			t.Fatal("sign-in finished")
+			return nil, nil
+		},
+	}
+
+	h := NewAuthHandler(client)
+
+	// a callback of the attacker's own sign-in, without a cookie
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/company/callback?code=code&state=state", nil)
+	w := httptest.NewRecorder()
+	h.OIDCCallback(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// or while the user has a sign-in of their own going
+	w = httptest.NewRecorder()
+	h.OIDCCallback(w, oidcCallback("code=code&state=state", "other"))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
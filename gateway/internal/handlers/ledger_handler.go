@@ -1,15 +1,23 @@
 package handlers
 
 import (
-	"encoding/csv"
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"gateway/internal"
+	"gateway/internal/exporter"
+	"gateway/internal/importer"
 	"gateway/internal/middleware"
 	ledgerv1 "gateway/ledger/v1"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -46,11 +54,21 @@ func grpcErrorToHTTP(w http.ResponseWriter, err error) {
 			"error": st.Message(),
 		})
 
-	case codes.FailedPrecondition, codes.Aborted:
+	case codes.FailedPrecondition, codes.Aborted, codes.AlreadyExists:
 		responseJSON(w, http.StatusConflict, map[string]string{
 			"error": st.Message(),
 		})
 
+	case codes.NotFound:
+		responseJSON(w, http.StatusNotFound, map[string]string{
+			"error": st.Message(),
+		})
+
+	case codes.PermissionDenied:
+		responseJSON(w, http.StatusForbidden, map[string]string{
+			"error": st.Message(),
+		})
+
 	case codes.DeadlineExceeded:
 		responseJSON(w, http.StatusGatewayTimeout, map[string]string{
 			"error": "request timeout",
@@ -67,12 +85,14 @@ func grpcErrorToHTTP(w http.ResponseWriter, err error) {
 // @Summary Create transaction
 // @Tags transactions
 // @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
 // @Accept json
 // @Produce json
 // @Param request body internal.CreateTransactionRequest true "Transaction"
 // @Success 201 {object} map[string]bool
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Param Idempotency-Key header string false "Replays the first response for retries within 24h"
 // @Router /api/transactions [post]
 func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -114,6 +134,7 @@ func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		Category:    dto.Category,
 		Description: dto.Description,
 		Date:        dto.Date,
+		ExternalId:  dto.ExternalID,
 	}
 
 	_, err := h.client.AddTransaction(ctx, req)
@@ -129,6 +150,7 @@ func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 // @Summary List transactions
 // @Tags transactions
 // @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
 // @Produce json
 // @Success 200 {array} internal.TransactionResponse
 // @Router /api/transactions [get]
@@ -161,12 +183,7 @@ func (h *Handler) ListTransactions(w http.ResponseWriter, r *http.Request) {
 
 	out := make([]internal.TransactionResponse, 0, len(resp.Transactions))
 	for _, t := range resp.Transactions {
-		out = append(out, internal.TransactionResponse{
-			Amount:      t.Amount,
-			Category:    t.Category,
-			Description: t.Description,
-			Date:        t.Date,
-		})
+		out = append(out, transactionToDTO(t))
 	}
 
 	responseJSON(w, http.StatusOK, out)
@@ -176,6 +193,7 @@ func (h *Handler) ListTransactions(w http.ResponseWriter, r *http.Request) {
 // @Summary List budgets
 // @Tags budgets
 // @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
 // @Produce json
 // @Success 200 {array} internal.BudgetResponse
 // @Router /api/budgets [get]
@@ -222,10 +240,12 @@ func (h *Handler) ListBudget(w http.ResponseWriter, r *http.Request) {
 // @Summary Create budget
 // @Tags budgets
 // @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
 // @Accept json
 // @Produce json
 // @Param request body internal.CreateBudgetRequest true "Budget"
 // @Success 201 {object} map[string]bool
+// @Param Idempotency-Key header string false "Replays the first response for retries within 24h"
 // @Router /api/budgets [post]
 func (h *Handler) CreateBudget(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -279,6 +299,7 @@ func (h *Handler) CreateBudget(w http.ResponseWriter, r *http.Request) {
 // @Summary Expense summary
 // @Tags reports
 // @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
 // @Produce json
 // @Param from query string true "From date (YYYY-MM-DD)"
 // @Param to query string true "To date (YYYY-MM-DD)"
@@ -323,18 +344,23 @@ func respondTimeout(w http.ResponseWriter) {
 }
 
 type BulkAddTransactionsResponse struct {
-	Success int64 `json:"success"`
-	Failed  int64 `json:"failed"`
+	Success    int64                        `json:"success"`
+	Failed     int64                        `json:"failed"`
+	Duplicates int64                        `json:"duplicates"`
+	Errors     []internal.BulkErrorResponse `json:"errors,omitempty"`
 }
 
 // BulkCreateTransactions godoc
 // @Summary Bulk create transactions
 // @Tags transactions
 // @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
 // @Accept json
 // @Produce json
 // @Param request body []internal.CreateTransactionRequest true "Transactions"
+// @Param atomic query bool false "Store all transactions or none of them"
 // @Success 200 {object} BulkAddTransactionsResponse
+// @Param Idempotency-Key header string false "Replays the first response for retries within 24h"
 // @Router /api/transactions/bulk [post]
 func (h *Handler) BulkCreateTransactions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -364,8 +390,12 @@ func (h *Handler) BulkCreateTransactions(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	atomic, _ := strconv.ParseBool(r.URL.Query().Get("atomic"))
+
 	req := &ledgerv1.BulkAddTransactionsRequest{
 		Workers: workers,
+		Source:  "bulk",
+		Atomic:  atomic,
 	}
 
 	for _, d := range dtos {
@@ -374,6 +404,7 @@ func (h *Handler) BulkCreateTransactions(w http.ResponseWriter, r *http.Request)
 			Category:    d.Category,
 			Description: d.Description,
 			Date:        d.Date,
+			ExternalId:  d.ExternalID,
 		})
 	}
 
@@ -395,13 +426,44 @@ func (h *Handler) BulkCreateTransactions(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	responseJSON(w, http.StatusOK, BulkAddTransactionsResponse{
-		Success: resp.Accepted,
-		Failed:  resp.Rejected,
-	})
+	out := BulkAddTransactionsResponse{
+		Success:    resp.Accepted,
+		Failed:     resp.Rejected,
+		Duplicates: resp.Duplicates,
+	}
+	for _, e := range resp.Errors {
+		out.Errors = append(out.Errors, internal.BulkErrorResponse{
+			Index: int(e.Index),
+			Error: e.Error,
+		})
+	}
+
+	responseJSON(w, http.StatusOK, out)
 }
 
-func (h *Handler) ImportCSV(w http.ResponseWriter, r *http.Request) {
+const (
+	maxImportSize   = 512 << 20 // 512MB
+	importChunkSize = 500
+)
+
+// ImportTransactions godoc
+// @Summary Import bank statement
+// @Description Accepts CSV (amount,category,description,date), OFX/QFX, QIF, camt.053 XML or MT940. The format is detected from the file name and content unless set explicitly. CSV files in a bank's own layout are read with a saved import profile. Records are streamed to the ledger and stored in batches, so files of any length up to 512MB are accepted; atomic imports are sent in one request instead.
+// @Tags transactions
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Statement file"
+// @Param format formData string false "csv, ofx, qif, camt053 or mt940"
+// @Param category formData string false "Category for records without one"
+// @Param profile formData string false "Saved CSV import profile"
+// @Param atomic formData bool false "Store all records or none of them"
+// @Success 200 {object} internal.ImportResponse
+// @Failure 400 {object} map[string]string
+// @Param Idempotency-Key header string false "Replays the first response for retries within 24h"
+// @Router /api/transactions/import [post]
+func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
@@ -419,69 +481,486 @@ func (h *Handler) ImportCSV(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := r.ParseMultipartForm(10 << 20) // 10MB
-	if err != nil {
-		http.Error(w, "cannot parse form", http.StatusBadRequest)
+	st, ok := h.openStatement(ctx, w, r)
+	if !ok {
 		return
 	}
+	defer st.close()
 
-	file, _, err := r.FormFile("file")
+	atomic, _ := strconv.ParseBool(r.FormValue("atomic"))
+
+	reader, err := st.reader()
 	if err != nil {
-		http.Error(w, "file is required", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer file.Close()
+	format := st.format
 
-	reader := csv.NewReader(file)
-	reader.TrimLeadingSpace = true
-
-	rows, err := reader.ReadAll()
+	var (
+		parsed  []importer.Transaction
+		count   int
+		skipped []importer.RowError
+	)
+	if atomic {
+		parsed, skipped, err = importer.ReadAll(reader)
+		count = len(parsed)
+	} else {
+		// the first pass only checks the file, so a malformed statement is
+		// rejected before anything is stored
+		count, skipped, err = importer.Count(reader)
+	}
 	if err != nil {
-		http.Error(w, "invalid csv", http.StatusBadRequest)
+		http.Error(w, st.invalid(err), http.StatusBadRequest)
 		return
 	}
 
-	if len(rows) < 2 {
-		http.Error(w, "empty csv", http.StatusBadRequest)
+	if count == 0 && len(skipped) == 0 {
+		http.Error(w, "empty "+string(format), http.StatusBadRequest)
 		return
 	}
 
-	var txs []*ledgerv1.CreateTransactionRequest
-	for i, row := range rows[1:] {
-		if len(row) < 4 {
-			continue
-		}
+	out := internal.ImportResponse{
+		Format:  string(format),
+		Skipped: int64(len(skipped)),
+	}
 
-		amount, err := strconv.ParseFloat(row[0], 64)
+	if count > 0 {
+		if atomic {
+			err = h.importAtomic(ctx, format, parsed, &out)
+		} else {
+			reader, err = st.reader()
+			if err == nil {
+				err = h.importStream(ctx, format, reader, &out)
+			}
+		}
 		if err != nil {
-			continue
+			grpcErrorToHTTP(w, err)
+			return
 		}
+	}
 
-		txs = append(txs, &ledgerv1.CreateTransactionRequest{
-			Amount:      amount,
-			Category:    row[1],
-			Description: row[2],
-			Date:        row[3],
+	for _, s := range skipped {
+		out.SkippedRows = append(out.SkippedRows, internal.SkippedRowResponse{
+			Line:   s.Line,
+			Reason: s.Reason,
 		})
+	}
+
+	responseJSON(w, http.StatusOK, out)
+}
+
+// statement is an uploaded bank statement kept in a temporary file.
+type statement struct {
+	file   multipart.File
+	name   string
+	format importer.Format
+	opts   importer.Options
+	form   *multipart.Form
+}
+
+// openStatement reads the upload form of r, resolving the import profile
+// and the statement format. It writes the error response itself.
+func (h *Handler) openStatement(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+) (*statement, bool) {
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	// uploads above 1MB are kept in a temporary file
+	err := r.ParseMultipartForm(1 << 20)
+	if err != nil {
+		http.Error(w, "cannot parse form", http.StatusBadRequest)
+		return nil, false
+	}
+
+	st := &statement{
+		form: r.MultipartForm,
+		opts: importer.Options{
+			DefaultCategory: r.FormValue("category"),
+		},
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		st.close()
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return nil, false
+	}
+	st.file = file
+	st.name = header.Filename
+
+	format := importer.Format(strings.ToLower(r.FormValue("format")))
+
+	if name := r.FormValue("profile"); name != "" {
+		if format != "" && format != importer.FormatCSV {
+			st.close()
+			http.Error(w, "import profiles apply to csv only", http.StatusBadRequest)
+			return nil, false
+		}
+
+		p, err := h.client.GetImportProfile(ctx, &ledgerv1.ImportProfileRequest{Name: name})
+		if err != nil {
+			st.close()
+			grpcErrorToHTTP(w, err)
+			return nil, false
+		}
+
+		format = importer.FormatCSV
+		st.opts.CSV = csvProfileFromProto(p)
+	}
+
+	if format == "" {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(file, head)
+		format = importer.Detect(header.Filename, head[:n])
+	}
+	st.format = format
+
+	return st, true
+}
+
+// reader reads the statement from the start.
+func (s *statement) reader() (importer.Reader, error) {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return importer.NewReader(s.format, bufio.NewReader(s.file), s.opts)
+}
+
+// invalid describes a statement that cannot be read.
+func (s *statement) invalid(err error) string {
+	msg := "invalid " + string(s.format)
+	if s.opts.CSV != nil {
+		msg += ": " + err.Error()
+	}
+	return msg
+}
+
+func (s *statement) close() {
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+	_ = s.form.RemoveAll()
+}
 
-		_ = i
+// importAtomic stores the whole statement with one BulkAddTransactions call.
+func (h *Handler) importAtomic(
+	ctx context.Context,
+	format importer.Format,
+	parsed []importer.Transaction,
+	out *internal.ImportResponse,
+) error {
+	txs := make([]*ledgerv1.CreateTransactionRequest, 0, len(parsed))
+	for _, t := range parsed {
+		txs = append(txs, importedToProto(t))
 	}
 
 	resp, err := h.client.BulkAddTransactions(
 		ctx,
 		&ledgerv1.BulkAddTransactionsRequest{
 			Transactions: txs,
-			Workers:      4,
+			Source:       string(format),
+			Atomic:       true,
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	out.Accepted = resp.Accepted
+	out.Rejected = resp.Rejected
+	out.Duplicates = resp.Duplicates
+
+	lines := make([]int, len(parsed))
+	for i, t := range parsed {
+		lines[i] = t.Line
+	}
+	addImportResults(out, lines, resp.Errors, resp.DuplicateIndexes)
+
+	return nil
+}
+
+// importStream sends the statement to the ledger in chunks while it is
+// read, so only the line numbers of the records are kept in memory.
+func (h *Handler) importStream(
+	ctx context.Context,
+	format importer.Format,
+	reader importer.Reader,
+	out *internal.ImportResponse,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := h.client.ImportTransactions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		last   *ledgerv1.ImportProgress
+		errs   []*ledgerv1.BulkError
+		dups   []int32
+		recvCh = make(chan error, 1)
+	)
+
+	go func() {
+		for {
+			p, err := stream.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				recvCh <- err
+				return
+			}
+			last = p
+			errs = append(errs, p.Errors...)
+			dups = append(dups, p.DuplicateIndexes...)
+		}
+	}()
+
+	var lines []int
+	chunk := make([]*ledgerv1.CreateTransactionRequest, 0, importChunkSize)
+
+	send := func() error {
+		err := stream.Send(&ledgerv1.ImportTransactionsRequest{
+			Source:       string(format),
+			Transactions: chunk,
+		})
+		chunk = make([]*ledgerv1.CreateTransactionRequest, 0, importChunkSize)
+		return err
+	}
+
+	var sendErr error
+	for sendErr == nil {
+		t, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *importer.RowError
+		if errors.As(err, &rowErr) {
+			continue
+		}
+		if err != nil {
+			// the file has been checked already
+			cancel()
+			<-recvCh
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		lines = append(lines, t.Line)
+		chunk = append(chunk, importedToProto(t))
+		if len(chunk) == importChunkSize {
+			sendErr = send()
+		}
+	}
+
+	// io.EOF from Send means the ledger has ended the stream; the reason
+	// comes from Recv
+	if sendErr == nil && len(chunk) > 0 {
+		sendErr = send()
+	}
+	if sendErr == nil {
+		sendErr = stream.CloseSend()
+	}
+
+	if err := <-recvCh; err != nil {
+		return err
+	}
+	if sendErr != nil && !errors.Is(sendErr, io.EOF) {
+		return sendErr
+	}
+	if last == nil || !last.Done {
+		return status.Error(codes.Internal, "import stream ended early")
+	}
+
+	out.Accepted = last.Accepted
+	out.Rejected = last.Rejected
+	out.Duplicates = last.Duplicates
+	addImportResults(out, lines, errs, dups)
+
+	return nil
+}
+
+func importedToProto(t importer.Transaction) *ledgerv1.CreateTransactionRequest {
+	return &ledgerv1.CreateTransactionRequest{
+		Amount:      t.Amount.InexactFloat64(),
+		Category:    t.Category,
+		Description: t.Description,
+		Date:        t.Date.Format("2006-01-02"),
+		ExternalId:  t.ExternalID,
+	}
+}
+
+// addImportResults reports errors and duplicates by the line they start on.
+func addImportResults(
+	out *internal.ImportResponse,
+	lines []int,
+	errs []*ledgerv1.BulkError,
+	dups []int32,
+) {
+	for _, i := range dups {
+		if int(i) < len(lines) {
+			out.DuplicateLines = append(out.DuplicateLines, lines[i])
+		}
+	}
+	for _, e := range errs {
+		line := 0
+		if int(e.Index) < len(lines) {
+			line = lines[e.Index]
+		}
+		out.Errors = append(out.Errors, internal.BulkErrorResponse{
+			Index: int(e.Index),
+			Line:  line,
+			Error: e.Error,
+		})
+	}
+}
+
+// CreateImportJob godoc
+// @Summary Start a background import
+// @Description Stores the statement with the ledger and returns at once; the records are imported by a background job. Accepts the same files and fields as /api/transactions/import, except atomic.
+// @Tags imports
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Statement file"
+// @Param format formData string false "csv, ofx, qif, camt053 or mt940"
+// @Param category formData string false "Category for records without one"
+// @Param profile formData string false "Saved CSV import profile"
+// @Success 202 {object} internal.ImportJobResponse
+// @Failure 400 {object} map[string]string
+// @Param Idempotency-Key header string false "Replays the first response for retries within 24h"
+// @Router /api/imports [post]
+func (h *Handler) CreateImportJob(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	st, ok := h.openStatement(ctx, w, r)
+	if !ok {
+		return
+	}
+	defer st.close()
+
+	reader, err := st.reader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	count, skipped, err := importer.Count(reader)
+	if err != nil {
+		http.Error(w, st.invalid(err), http.StatusBadRequest)
+		return
+	}
+	if count == 0 {
+		http.Error(w, "empty "+string(st.format), http.StatusBadRequest)
+		return
+	}
+
+	reader, err = st.reader()
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	job, err := h.uploadImportJob(ctx, st, reader)
 	if err != nil {
 		grpcErrorToHTTP(w, err)
 		return
 	}
-	responseJSON(w, http.StatusOK, resp)
+
+	out := importJobToDTO(job)
+	for _, s := range skipped {
+		out.SkippedRows = append(out.SkippedRows, internal.SkippedRowResponse{
+			Line:   s.Line,
+			Reason: s.Reason,
+		})
+	}
+
+	responseJSON(w, http.StatusAccepted, out)
 }
 
-func (h *Handler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+// uploadImportJob sends the records of the statement to the ledger in
+// chunks. The first chunk names the source and the file.
+func (h *Handler) uploadImportJob(
+	ctx context.Context,
+	st *statement,
+	reader importer.Reader,
+) (*ledgerv1.ImportJob, error) {
+
+	stream, err := h.client.CreateImportJob(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := &ledgerv1.ImportJobChunk{
+		Source:   string(st.format),
+		FileName: st.name,
+	}
+
+	var sendErr error
+	for sendErr == nil {
+		t, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *importer.RowError
+		if errors.As(err, &rowErr) {
+			continue
+		}
+		if err != nil {
+			// the file has been checked already
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		chunk.Rows = append(chunk.Rows, &ledgerv1.ImportJobRow{
+			Line:        int32(t.Line),
+			Transaction: importedToProto(t),
+		})
+		if len(chunk.Rows) == importChunkSize {
+			sendErr = stream.Send(chunk)
+			chunk = &ledgerv1.ImportJobChunk{}
+		}
+	}
+
+	// io.EOF from Send means the ledger has ended the stream; the reason
+	// comes from CloseAndRecv
+	if sendErr == nil && len(chunk.Rows) > 0 {
+		sendErr = stream.Send(chunk)
+	}
+	if sendErr != nil && !errors.Is(sendErr, io.EOF) {
+		return nil, sendErr
+	}
+
+	return stream.CloseAndRecv()
+}
+
+// GetImportJob godoc
+// @Summary Import job status
+// @Description Reports progress and the first 1000 rejected records of a background import.
+// @Tags imports
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} internal.ImportJobResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/imports/{id} [get]
+func (h *Handler) GetImportJob(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
@@ -494,34 +973,583 @@ func (h *Handler) ExportCSV(w http.ResponseWriter, r *http.Request) {
 
 	ctx := metadata.NewOutgoingContext(r.Context(), md)
 
-	resp, err := h.client.ListTransactions(
-		ctx,
-		&emptypb.Empty{},
+	job, err := h.client.GetImportJob(ctx, &ledgerv1.ImportJobRequest{
+		Id: strings.TrimPrefix(r.URL.Path, "/api/imports/"),
+	})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	responseJSON(w, http.StatusOK, importJobToDTO(job))
+}
+
+// CancelImportJob godoc
+// @Summary Cancel import job
+// @Description Stops a background import. Records stored before the job was cancelled are kept.
+// @Tags imports
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} internal.ImportJobResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/imports/{id} [delete]
+func (h *Handler) CancelImportJob(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	job, err := h.client.CancelImportJob(ctx, &ledgerv1.ImportJobRequest{
+		Id: strings.TrimPrefix(r.URL.Path, "/api/imports/"),
+	})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	responseJSON(w, http.StatusOK, importJobToDTO(job))
+}
+
+func importJobToDTO(j *ledgerv1.ImportJob) internal.ImportJobResponse {
+	out := internal.ImportJobResponse{
+		ID:         j.Id,
+		Status:     j.Status,
+		Format:     j.Source,
+		FileName:   j.FileName,
+		Total:      j.Total,
+		Processed:  j.Processed,
+		Accepted:   j.Accepted,
+		Rejected:   j.Rejected,
+		Duplicates: j.Duplicates,
+		Error:      j.Error,
+		Errors:     []internal.BulkErrorResponse{},
+		CreatedAt:  j.CreatedAt,
+		UpdatedAt:  j.UpdatedAt,
+	}
+	for _, e := range j.Errors {
+		out.Errors = append(out.Errors, internal.BulkErrorResponse{
+			Index: int(e.Index),
+			Line:  int(e.Line),
+			Error: e.Error,
+		})
+	}
+	return out
+}
+
+func csvProfileFromProto(p *ledgerv1.ImportProfile) *importer.CSVProfile {
+	var delimiter rune
+	if d := []rune(p.Delimiter); len(d) > 0 {
+		delimiter = d[0]
+	}
+
+	return &importer.CSVProfile{
+		AmountColumn:      p.AmountColumn,
+		DateColumn:        p.DateColumn,
+		CategoryColumn:    p.CategoryColumn,
+		DescriptionColumn: p.DescriptionColumn,
+		ExternalIDColumn:  p.ExternalIdColumn,
+		Delimiter:         delimiter,
+		DecimalComma:      p.DecimalComma,
+		DateFormat:        p.DateFormat,
+		Encoding:          p.Encoding,
+		SignConvention:    p.SignConvention,
+	}
+}
+
+// FindDuplicates godoc
+// @Summary Find duplicate transactions
+// @Description Groups stored transactions that share the date, amount and description, e.g. left over from importing the same statement twice.
+// @Tags transactions
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Produce json
+// @Success 200 {array} internal.DuplicateGroupResponse
+// @Router /api/transactions/duplicates [get]
+func (h *Handler) FindDuplicates(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	resp, err := h.client.FindDuplicates(ctx, &emptypb.Empty{})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	out := make([]internal.DuplicateGroupResponse, 0, len(resp.Groups))
+	for _, g := range resp.Groups {
+		group := internal.DuplicateGroupResponse{
+			Transactions: make([]internal.TransactionResponse, 0, len(g.Transactions)),
+		}
+		for _, t := range g.Transactions {
+			group.Transactions = append(group.Transactions, transactionToDTO(t))
+		}
+		out = append(out, group)
+	}
+
+	responseJSON(w, http.StatusOK, out)
+}
+
+func transactionToDTO(t *ledgerv1.Transaction) internal.TransactionResponse {
+	return internal.TransactionResponse{
+		ID:          t.Id,
+		Amount:      t.Amount,
+		Category:    t.Category,
+		Description: t.Description,
+		Date:        t.Date,
+		ExternalID:  t.ExternalId,
+	}
+}
+
+// ListImportProfiles godoc
+// @Summary List CSV import profiles
+// @Tags import-profiles
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Produce json
+// @Success 200 {array} internal.ImportProfile
+// @Router /api/import-profiles [get]
+func (h *Handler) ListImportProfiles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	resp, err := h.client.ListImportProfiles(ctx, &emptypb.Empty{})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	out := make([]internal.ImportProfile, 0, len(resp.Profiles))
+	for _, p := range resp.Profiles {
+		out = append(out, importProfileToDTO(p))
+	}
+
+	responseJSON(w, http.StatusOK, out)
+}
+
+// SaveImportProfile godoc
+// @Summary Create or replace a CSV import profile
+// @Description Maps the columns of a bank's CSV export by header name. Defaults: delimiter ",", date_format "YYYY-MM-DD", encoding "utf-8", sign_convention "positive".
+// @Tags import-profiles
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Accept json
+// @Produce json
+// @Param request body internal.ImportProfile true "Profile"
+// @Success 201 {object} internal.ImportProfile
+// @Failure 400 {object} map[string]string
+// @Param Idempotency-Key header string false "Replays the first response for retries within 24h"
+// @Router /api/import-profiles [post]
+func (h *Handler) SaveImportProfile(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		http.Error(
+			w,
+			"Content-Type must be application/json",
+			http.StatusUnsupportedMediaType,
+		)
+		return
+	}
+
+	var dto internal.ImportProfile
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		responseJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "invalid json",
+		})
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	resp, err := h.client.SaveImportProfile(ctx, &ledgerv1.ImportProfile{
+		Name:              dto.Name,
+		AmountColumn:      dto.AmountColumn,
+		DateColumn:        dto.DateColumn,
+		CategoryColumn:    dto.CategoryColumn,
+		DescriptionColumn: dto.DescriptionColumn,
+		ExternalIdColumn:  dto.ExternalIDColumn,
+		Delimiter:         dto.Delimiter,
+		DecimalComma:      dto.DecimalComma,
+		DateFormat:        dto.DateFormat,
+		Encoding:          dto.Encoding,
+		SignConvention:    dto.SignConvention,
+	})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	responseJSON(w, http.StatusCreated, importProfileToDTO(resp))
+}
+
+// DeleteImportProfile godoc
+// @Summary Delete a CSV import profile
+// @Tags import-profiles
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Produce json
+// @Param name query string true "Profile name"
+// @Success 200 {object} map[string]bool
+// @Failure 404 {object} map[string]string
+// @Router /api/import-profiles [delete]
+func (h *Handler) DeleteImportProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		responseJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "name is required",
+		})
+		return
+	}
+
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	_, err := h.client.DeleteImportProfile(ctx, &ledgerv1.ImportProfileRequest{Name: name})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	responseJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func importProfileToDTO(p *ledgerv1.ImportProfile) internal.ImportProfile {
+	return internal.ImportProfile{
+		Name:              p.Name,
+		AmountColumn:      p.AmountColumn,
+		DateColumn:        p.DateColumn,
+		CategoryColumn:    p.CategoryColumn,
+		DescriptionColumn: p.DescriptionColumn,
+		ExternalIDColumn:  p.ExternalIdColumn,
+		Delimiter:         p.Delimiter,
+		DecimalComma:      p.DecimalComma,
+		DateFormat:        p.DateFormat,
+		Encoding:          p.Encoding,
+		SignConvention:    p.SignConvention,
+	}
+}
+
+// ExportTransactions godoc
+// @Summary Export transactions
+// @Description Streams transactions in date order as CSV, JSON Lines, OFX or XLSX. The format comes from ?format= or else the Accept header, CSV by default. Every format can be imported again; OFX has no categories, so they are set on import.
+// @Tags transactions
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/x-ofx
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv, jsonl, ofx or xlsx"
+// @Param from query string false "First date, YYYY-MM-DD"
+// @Param to query string false "Last date, YYYY-MM-DD"
+// @Param category query []string false "Only these categories" collectionFormat(multi)
+// @Param source query string false "Only transactions imported from this source"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 406 {object} map[string]string
+// @Router /api/transactions/export [get]
+func (h *Handler) ExportTransactions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	query := r.URL.Query()
+
+	format := exporter.Format(strings.ToLower(query.Get("format")))
+	if format == "" {
+		format, ok = exporter.Negotiate(r.Header.Get("Accept"))
+		if !ok {
+			responseJSON(w, http.StatusNotAcceptable, map[string]string{
+				"error": "supported formats: csv, jsonl, ofx, xlsx",
+			})
+			return
+		}
+	} else if format.ContentType() == "" {
+		responseJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "supported formats: csv, jsonl, ofx, xlsx",
+		})
+		return
+	}
+
+	stream, err := h.client.ExportTransactions(ctx, &ledgerv1.ExportTransactionsRequest{
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+		Categories: query["category"],
+		Source:     query.Get("source"),
+	})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	// the ledger checks the filter before it sends anything, so the
+	// status can still be set after the first message
+	t, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set(
+		"Content-Disposition",
+		`attachment; filename="transactions.`+string(format)+`"`,
 	)
+
+	// once the body has started an error can only cut the response short,
+	// so the client does not take a partial export for a complete one
+	writer, err := exporter.NewWriter(format, w)
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+
+	for t != nil {
+		out, err := exportedFromProto(t)
+		if err != nil {
+			panic(http.ErrAbortHandler)
+		}
+		if err := writer.Write(out); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+
+		t, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func exportedFromProto(t *ledgerv1.Transaction) (exporter.Transaction, error) {
+	date, err := time.Parse("2006-01-02", t.Date)
+	if err != nil {
+		return exporter.Transaction{}, err
+	}
+
+	return exporter.Transaction{
+		ID:          t.Id,
+		Amount:      decimal.NewFromFloat(t.Amount),
+		Category:    t.Category,
+		Description: t.Description,
+		Date:        date,
+		ExternalID:  t.ExternalId,
+	}, nil
+}
+
+// backupChunkSize is the most archive data sent to the ledger in one message.
+const backupChunkSize = 1 << 20
+
+// ExportBackup godoc
+// @Summary Download account backup
+// @Description Streams a zip archive with the account's budgets, transactions, categories and import profiles as JSON, plus a manifest with the format version and a SHA-256 checksum of every file.
+// @Tags backup
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Produce application/zip
+// @Success 200 {file} file
+// @Failure 401 {object} map[string]string
+// @Router /api/backup [get]
+func (h *Handler) ExportBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	stream, err := h.client.ExportBackup(ctx, &emptypb.Empty{})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	// the ledger reads the budgets and profiles before the first chunk
+	chunk, err := stream.Recv()
 	if err != nil {
 		grpcErrorToHTTP(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Type", "application/zip")
 	w.Header().Set(
 		"Content-Disposition",
-		`attachment; filename="transactions.csv"`,
+		`attachment; filename="gofinance-backup-`+time.Now().UTC().Format("20060102")+`.zip"`,
 	)
 
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
+	// a cut-off archive fails its checks, but the client should not have
+	// to find out that way
+	for {
+		if _, err := w.Write(chunk.Data); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+
+		chunk, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// RestoreBackup godoc
+// @Summary Restore account backup
+// @Description Loads an archive from GET /api/backup. The archive is checked before anything is stored. In merge mode archived budgets and import profiles overwrite those with the same category or name and transactions that are already stored are skipped; replace deletes the account's budgets, transactions and import profiles first.
+// @Tags backup
+// @Security BearerAuth
+// @Param X-Ledger-ID header string false "Shared ledger ID; your personal ledger when omitted"
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Backup archive"
+// @Param mode formData string false "merge (default) or replace"
+// @Success 200 {object} internal.RestoreBackupResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/backup/restore [post]
+func (h *Handler) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	_ = writer.Write([]string{
-		"amount", "category", "description", "date",
+	md := metadata.New(map[string]string{
+		"user_id": userID,
 	})
 
-	for _, t := range resp.Transactions {
-		_ = writer.Write([]string{
-			strconv.FormatFloat(t.Amount, 'f', 2, 64),
-			t.Category,
-			t.Description,
-			t.Date,
-		})
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	// uploads above 1MB are kept in a temporary file
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		http.Error(w, "cannot parse form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	res, err := h.uploadBackup(ctx, r.FormValue("mode"), file)
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
 	}
+
+	responseJSON(w, http.StatusOK, internal.RestoreBackupResponse{
+		Budgets:        res.Budgets,
+		Transactions:   res.Transactions,
+		ImportProfiles: res.ImportProfiles,
+		Duplicates:     res.Duplicates,
+	})
+}
+
+// uploadBackup sends the archive to the ledger in chunks. The first chunk
+// carries the mode.
+func (h *Handler) uploadBackup(
+	ctx context.Context,
+	mode string,
+	file io.Reader,
+) (*ledgerv1.RestoreBackupResponse, error) {
+
+	stream, err := h.client.RestoreBackup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, backupChunkSize)
+	req := &ledgerv1.RestoreBackupRequest{Mode: mode}
+
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 {
+			req.Data = buf[:n]
+			// io.EOF from Send means the ledger has ended the stream; the
+			// reason comes from CloseAndRecv
+			if sendErr := stream.Send(req); sendErr != nil {
+				if errors.Is(sendErr, io.EOF) {
+					break
+				}
+				return nil, sendErr
+			}
+			req = &ledgerv1.RestoreBackupRequest{}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	return stream.CloseAndRecv()
 }
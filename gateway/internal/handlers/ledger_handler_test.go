@@ -0,0 +1,763 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gateway/internal"
+	"gateway/internal/middleware"
+	ledgerv1 "gateway/ledger/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func newImportRequest(t *testing.T, filename, content string, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	fw, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(content))
+	require.NoError(t, err)
+
+	for k, v := range fields {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	ctx := context.WithValue(req.Context(), middleware.UserIDKey, "user-1")
+	return req.WithContext(ctx)
+}
+
+func TestImportTransactions_OFX(t *testing.T) {
+	ofx := "OFXHEADER:100\n<OFX><BANKTRANLIST>\n" +
+		"<STMTTRN><DTPOSTED>20250105<TRNAMT>-42.50<FITID>F1<NAME>Shop</STMTTRN>\n" +
+		"<STMTTRN><DTPOSTED>20250106<TRNAMT>100.00<FITID>F2<NAME>Refund</STMTTRN>\n" +
+		"</BANKTRANLIST></OFX>"
+
+	stream := newImportStream(func(chunks []*ledgerv1.ImportTransactionsRequest) *ledgerv1.ImportProgress {
+		require.Len(t, chunks, 1)
+		require.Equal(t, "ofx", chunks[0].Source)
+
+		rows := chunks[0].Transactions
+		require.Len(t, rows, 1)
+		require.Equal(t, 42.5, rows[0].Amount)
+		require.Equal(t, "bank", rows[0].Category)
+		require.Equal(t, "2025-01-05", rows[0].Date)
+		require.Equal(t, "F1", rows[0].ExternalId)
+
+		return &ledgerv1.ImportProgress{Received: 1, Duplicates: 1, DuplicateIndexes: []int32{0}, Done: true}
+	})
+
+	client := &mockLedgerClient{
+		importTx: func(ctx context.Context, _ ...grpc.CallOption) (ledgerv1.LedgerService_ImportTransactionsClient, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, []string{"user-1"}, md.Get("user_id"))
+			return stream, nil
+		},
+	}
+
+	h := NewHandler(client)
+	req := newImportRequest(t, "statement.ofx", ofx, map[string]string{"category": "bank"})
+	w := httptest.NewRecorder()
+
+	h.ImportTransactions(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp internal.ImportResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "ofx", resp.Format)
+	require.Equal(t, int64(0), resp.Accepted)
+	require.Equal(t, int64(1), resp.Duplicates)
+	require.Equal(t, []int{3}, resp.DuplicateLines)
+	require.Equal(t, int64(1), resp.Skipped)
+	require.Len(t, resp.SkippedRows, 1)
+	require.Equal(t, 4, resp.SkippedRows[0].Line)
+}
+
+func TestImportTransactions_UnknownFormat(t *testing.T) {
+	h := NewHandler(&mockLedgerClient{})
+	req := newImportRequest(t, "data.bin", "x", map[string]string{"format": "xls"})
+	w := httptest.NewRecorder()
+
+	h.ImportTransactions(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestImportTransactions_Profile(t *testing.T) {
+	data := "Дата;Сумма;Описание;Номер\n" +
+		"15.01.2025;-1 234,50;Пятёрочка;A-1\n" +
+		"16.01.2025;500,00;Возврат;A-2\n"
+
+	client := &mockLedgerClient{
+		getProfile: func(ctx context.Context, in *ledgerv1.ImportProfileRequest, _ ...grpc.CallOption) (*ledgerv1.ImportProfile, error) {
+			require.Equal(t, "sber", in.Name)
+			return &ledgerv1.ImportProfile{
+				Name:              "sber",
+				AmountColumn:      "Сумма",
+				DateColumn:        "Дата",
+				DescriptionColumn: "Описание",
+				ExternalIdColumn:  "Номер",
+				Delimiter:         ";",
+				DecimalComma:      true,
+				DateFormat:        "DD.MM.YYYY",
+				SignConvention:    "negative",
+			}, nil
+		},
+		bulk: func(ctx context.Context, in *ledgerv1.BulkAddTransactionsRequest, _ ...grpc.CallOption) (*ledgerv1.BulkAddTransactionsResponse, error) {
+			require.Len(t, in.Transactions, 1)
+			require.Equal(t, 1234.5, in.Transactions[0].Amount)
+			require.Equal(t, "groceries", in.Transactions[0].Category)
+			require.Equal(t, "2025-01-15", in.Transactions[0].Date)
+			require.Equal(t, "A-1", in.Transactions[0].ExternalId)
+			require.True(t, in.Atomic)
+
+			return &ledgerv1.BulkAddTransactionsResponse{Accepted: 1}, nil
+		},
+	}
+
+	h := NewHandler(client)
+	req := newImportRequest(t, "export.csv", data, map[string]string{
+		"profile":  "sber",
+		"category": "groceries",
+		"atomic":   "true",
+	})
+	w := httptest.NewRecorder()
+
+	h.ImportTransactions(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp internal.ImportResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "csv", resp.Format)
+	require.Equal(t, int64(1), resp.Accepted)
+	require.Len(t, resp.SkippedRows, 1)
+	require.Equal(t, 3, resp.SkippedRows[0].Line)
+}
+
+// importStream collects the chunks sent by the handler and answers with the
+// progress built by respond once the upload is complete.
+type importStream struct {
+	grpc.ClientStream
+	respond func(chunks []*ledgerv1.ImportTransactionsRequest) *ledgerv1.ImportProgress
+	chunks  []*ledgerv1.ImportTransactionsRequest
+	closed  chan struct{}
+	replied bool
+}
+
+func newImportStream(
+	respond func(chunks []*ledgerv1.ImportTransactionsRequest) *ledgerv1.ImportProgress,
+) *importStream {
+	return &importStream{respond: respond, closed: make(chan struct{})}
+}
+
+func (s *importStream) Send(req *ledgerv1.ImportTransactionsRequest) error {
+	s.chunks = append(s.chunks, req)
+	return nil
+}
+
+func (s *importStream) CloseSend() error {
+	close(s.closed)
+	return nil
+}
+
+func (s *importStream) Recv() (*ledgerv1.ImportProgress, error) {
+	<-s.closed
+	if s.replied {
+		return nil, io.EOF
+	}
+	s.replied = true
+	return s.respond(s.chunks), nil
+}
+
+func TestImportTransactions_StreamsChunks(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("amount,category,description,date\n")
+	for i := 0; i < importChunkSize+1; i++ {
+		fmt.Fprintf(&data, "1.00,food,row %d,2025-01-15\n", i)
+	}
+	data.WriteString("x,food,broken,2025-01-15\n")
+
+	stream := newImportStream(func(chunks []*ledgerv1.ImportTransactionsRequest) *ledgerv1.ImportProgress {
+		require.Len(t, chunks, 2)
+		require.Len(t, chunks[0].Transactions, importChunkSize)
+		require.Len(t, chunks[1].Transactions, 1)
+		require.Equal(t, "csv", chunks[1].Source)
+
+		return &ledgerv1.ImportProgress{
+			Received: importChunkSize + 1,
+			Accepted: importChunkSize,
+			Rejected: 1,
+			Errors:   []*ledgerv1.BulkError{{Index: importChunkSize, Error: "budget exceeded"}},
+			Done:     true,
+		}
+	})
+
+	h := NewHandler(&mockLedgerClient{
+		importTx: func(ctx context.Context, _ ...grpc.CallOption) (ledgerv1.LedgerService_ImportTransactionsClient, error) {
+			return stream, nil
+		},
+	})
+	req := newImportRequest(t, "big.csv", data.String(), nil)
+	w := httptest.NewRecorder()
+
+	h.ImportTransactions(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp internal.ImportResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, int64(importChunkSize), resp.Accepted)
+	require.Equal(t, int64(1), resp.Skipped)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, importChunkSize+2, resp.Errors[0].Line)
+}
+
+func TestImportTransactions_StreamEndsEarly(t *testing.T) {
+	stream := newImportStream(func(chunks []*ledgerv1.ImportTransactionsRequest) *ledgerv1.ImportProgress {
+		return &ledgerv1.ImportProgress{Received: 1}
+	})
+
+	h := NewHandler(&mockLedgerClient{
+		importTx: func(ctx context.Context, _ ...grpc.CallOption) (ledgerv1.LedgerService_ImportTransactionsClient, error) {
+			return stream, nil
+		},
+	})
+	req := newImportRequest(t, "data.csv", "amount,category,description,date\n1.00,food,coffee,2025-01-15\n", nil)
+	w := httptest.NewRecorder()
+
+	h.ImportTransactions(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestImportTransactions_ProfileNotFound(t *testing.T) {
+	client := &mockLedgerClient{
+		getProfile: func(ctx context.Context, in *ledgerv1.ImportProfileRequest, _ ...grpc.CallOption) (*ledgerv1.ImportProfile, error) {
+			return nil, status.Error(codes.NotFound, "import profile not found")
+		},
+	}
+
+	h := NewHandler(client)
+	req := newImportRequest(t, "export.csv", "a,b\n", map[string]string{"profile": "missing"})
+	w := httptest.NewRecorder()
+
+	h.ImportTransactions(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSaveImportProfile(t *testing.T) {
+	client := &mockLedgerClient{
+		saveProfile: func(ctx context.Context, in *ledgerv1.ImportProfile, _ ...grpc.CallOption) (*ledgerv1.ImportProfile, error) {
+			require.Equal(t, "bank", in.Name)
+			require.Equal(t, "Amount", in.AmountColumn)
+			require.Equal(t, ";", in.Delimiter)
+			require.True(t, in.DecimalComma)
+
+			in.Encoding = "utf-8"
+			return in, nil
+		},
+	}
+
+	body := `{"name":"bank","amount_column":"Amount","date_column":"Date","delimiter":";","decimal_comma":true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/import-profiles", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+
+	NewHandler(client).SaveImportProfile(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp internal.ImportProfile
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "bank", resp.Name)
+	require.Equal(t, "utf-8", resp.Encoding)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	client := &mockLedgerClient{
+		duplicates: func(ctx context.Context, in *emptypb.Empty, _ ...grpc.CallOption) (*ledgerv1.FindDuplicatesResponse, error) {
+			return &ledgerv1.FindDuplicatesResponse{
+				Groups: []*ledgerv1.DuplicateGroup{
+					{Transactions: []*ledgerv1.Transaction{
+						{Id: 1, Amount: 5, Category: "food", Description: "Coffee", Date: "2025-01-15"},
+						{Id: 2, Amount: 5, Category: "food", Description: "coffee", Date: "2025-01-15", ExternalId: "F1"},
+					}},
+				},
+			}, nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions/duplicates", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+
+	NewHandler(client).FindDuplicates(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp []internal.DuplicateGroupResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp, 1)
+	require.Len(t, resp[0].Transactions, 2)
+	require.Equal(t, int32(2), resp[0].Transactions[1].ID)
+	require.Equal(t, "F1", resp[0].Transactions[1].ExternalID)
+}
+
+func TestBulkCreateTransactions_Atomic(t *testing.T) {
+	client := &mockLedgerClient{
+		bulk: func(ctx context.Context, in *ledgerv1.BulkAddTransactionsRequest, _ ...grpc.CallOption) (*ledgerv1.BulkAddTransactionsResponse, error) {
+			require.True(t, in.Atomic)
+			require.Len(t, in.Transactions, 2)
+
+			return &ledgerv1.BulkAddTransactionsResponse{
+				Rejected: 2,
+				Errors: []*ledgerv1.BulkError{
+					{Index: 1, Error: "budget exceeded"},
+				},
+			}, nil
+		},
+	}
+
+	h := NewHandler(client)
+
+	body := `[{"amount":10,"category":"food","date":"2025-01-01"},{"amount":20,"category":"food","date":"2025-01-02"}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/bulk?atomic=true", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+
+	h.BulkCreateTransactions(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp BulkAddTransactionsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, int64(0), resp.Success)
+	require.Equal(t, int64(2), resp.Failed)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, 1, resp.Errors[0].Index)
+}
+
+// jobStream collects the chunks of an import job upload.
+type jobStream struct {
+	grpc.ClientStream
+	chunks []*ledgerv1.ImportJobChunk
+	job    *ledgerv1.ImportJob
+}
+
+func (s *jobStream) Send(c *ledgerv1.ImportJobChunk) error {
+	s.chunks = append(s.chunks, c)
+	return nil
+}
+
+func (s *jobStream) CloseAndRecv() (*ledgerv1.ImportJob, error) {
+	return s.job, nil
+}
+
+func TestCreateImportJob(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("amount,category,description,date\n")
+	for i := 0; i < importChunkSize+1; i++ {
+		fmt.Fprintf(&data, "%d,food,lunch,2025-01-15\n", i+1)
+	}
+	data.WriteString("oops,food,lunch,2025-01-15\n")
+
+	stream := &jobStream{
+		job: &ledgerv1.ImportJob{
+			Id:        "0b7e1f2a-0000-4000-8000-000000000001",
+			Status:    "queued",
+			Source:    "csv",
+			FileName:  "statement.csv",
+			Total:     int64(importChunkSize + 1),
+			CreatedAt: "2025-01-15T10:00:00Z",
+			UpdatedAt: "2025-01-15T10:00:00Z",
+		},
+	}
+
+	client := &mockLedgerClient{
+		createJob: func(ctx context.Context, _ ...grpc.CallOption) (ledgerv1.LedgerService_CreateImportJobClient, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, []string{"user-1"}, md.Get("user_id"))
+			return stream, nil
+		},
+	}
+
+	h := NewHandler(client)
+	req := newImportRequest(t, "statement.csv", data.String(), nil)
+	w := httptest.NewRecorder()
+
+	h.CreateImportJob(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	require.Len(t, stream.chunks, 2)
+	require.Equal(t, "csv", stream.chunks[0].Source)
+	require.Equal(t, "statement.csv", stream.chunks[0].FileName)
+	require.Len(t, stream.chunks[0].Rows, importChunkSize)
+	require.Equal(t, int32(2), stream.chunks[0].Rows[0].Line)
+	require.Empty(t, stream.chunks[1].Source)
+	require.Len(t, stream.chunks[1].Rows, 1)
+
+	var resp internal.ImportJobResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "0b7e1f2a-0000-4000-8000-000000000001", resp.ID)
+	require.Equal(t, "queued", resp.Status)
+	require.Equal(t, int64(importChunkSize+1), resp.Total)
+	require.Len(t, resp.SkippedRows, 1)
+	require.Equal(t, importChunkSize+3, resp.SkippedRows[0].Line)
+}
+
+func TestCreateImportJob_Empty(t *testing.T) {
+	client := &mockLedgerClient{
+		createJob: func(ctx context.Context, _ ...grpc.CallOption) (ledgerv1.LedgerService_CreateImportJobClient, error) {
+			t.Fatal("nothing should be uploaded")
+			return nil, nil
+		},
+	}
+
+	h := NewHandler(client)
+	req := newImportRequest(t, "statement.csv", "amount,category,description,date\n", nil)
+	w := httptest.NewRecorder()
+
+	h.CreateImportJob(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetImportJob(t *testing.T) {
+	client := &mockLedgerClient{
+		getJob: func(ctx context.Context, in *ledgerv1.ImportJobRequest, _ ...grpc.CallOption) (*ledgerv1.ImportJob, error) {
+			require.Equal(t, "0b7e1f2a-0000-4000-8000-000000000001", in.Id)
+			return &ledgerv1.ImportJob{
+				Id:        in.Id,
+				Status:    "running",
+				Total:     3000,
+				Processed: 1000,
+				Accepted:  999,
+				Rejected:  1,
+				Errors:    []*ledgerv1.ImportJobError{{Index: 3, Line: 5, Error: "budget exceeded"}},
+			}, nil
+		},
+	}
+
+	h := NewHandler(client)
+	req := httptest.NewRequest(http.MethodGet, "/api/imports/0b7e1f2a-0000-4000-8000-000000000001", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+
+	h.GetImportJob(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp internal.ImportJobResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "running", resp.Status)
+	require.Equal(t, int64(1000), resp.Processed)
+	require.Equal(t, []internal.BulkErrorResponse{{Index: 3, Line: 5, Error: "budget exceeded"}}, resp.Errors)
+}
+
+func TestCancelImportJob_Finished(t *testing.T) {
+	client := &mockLedgerClient{
+		cancelJob: func(ctx context.Context, in *ledgerv1.ImportJobRequest, _ ...grpc.CallOption) (*ledgerv1.ImportJob, error) {
+			return nil, status.Error(codes.FailedPrecondition, "import job is already finished")
+		},
+	}
+
+	h := NewHandler(client)
+	req := httptest.NewRequest(http.MethodDelete, "/api/imports/0b7e1f2a-0000-4000-8000-000000000001", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+	w := httptest.NewRecorder()
+
+	h.CancelImportJob(w, req)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+// exportStream yields txs and then err, io.EOF when err is nil.
+type exportStream struct {
+	grpc.ClientStream
+	txs []*ledgerv1.Transaction
+	err error
+}
+
+func (s *exportStream) Recv() (*ledgerv1.Transaction, error) {
+	if len(s.txs) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	t := s.txs[0]
+	s.txs = s.txs[1:]
+	return t, nil
+}
+
+func newExportRequest(target, accept string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+}
+
+func exportClient(t *testing.T, stream *exportStream) *mockLedgerClient {
+	return &mockLedgerClient{
+		export: func(ctx context.Context, in *ledgerv1.ExportTransactionsRequest, _ ...grpc.CallOption) (ledgerv1.LedgerService_ExportTransactionsClient, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, []string{"user-1"}, md.Get("user_id"))
+			return stream, nil
+		},
+	}
+}
+
+func TestExportTransactions_CSV(t *testing.T) {
+	var got *ledgerv1.ExportTransactionsRequest
+
+	stream := &exportStream{txs: []*ledgerv1.Transaction{
+		{Id: 1, Amount: 12.3, Category: "food", Description: "lunch, late", Date: "2025-01-15"},
+		{Id: 2, Amount: 40, Category: "fun", Date: "2025-01-16", ExternalId: "F2"},
+	}}
+	client := &mockLedgerClient{
+		export: func(ctx context.Context, in *ledgerv1.ExportTransactionsRequest, _ ...grpc.CallOption) (ledgerv1.LedgerService_ExportTransactionsClient, error) {
+			got = in
+			return stream, nil
+		},
+	}
+
+	h := NewHandler(client)
+	w := httptest.NewRecorder()
+
+	h.ExportTransactions(w, newExportRequest("/api/transactions/export?format=csv&from=2025-01-01&category=food&category=fun", "application/x-ofx"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	require.Contains(t, w.Header().Get("Content-Disposition"), "transactions.csv")
+	require.Equal(t, "amount,category,description,date,external_id\n"+
+		"12.30,food,\"lunch, late\",2025-01-15,\n"+
+		"40.00,fun,,2025-01-16,F2\n", w.Body.String())
+
+	require.Equal(t, "2025-01-01", got.From)
+	require.Equal(t, []string{"food", "fun"}, got.Categories)
+}
+
+func TestExportTransactions_Accept(t *testing.T) {
+	stream := &exportStream{txs: []*ledgerv1.Transaction{
+		{Id: 1, Amount: 12.3, Category: "food", Date: "2025-01-15"},
+	}}
+
+	h := NewHandler(exportClient(t, stream))
+	w := httptest.NewRecorder()
+
+	h.ExportTransactions(w, newExportRequest("/api/transactions/export", "application/x-ndjson"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"id":1,"amount":"12.30","category":"food","date":"2025-01-15"}`, w.Body.String())
+}
+
+func TestExportTransactions_NotAcceptable(t *testing.T) {
+	h := NewHandler(&mockLedgerClient{})
+	w := httptest.NewRecorder()
+
+	h.ExportTransactions(w, newExportRequest("/api/transactions/export", "application/pdf"))
+
+	require.Equal(t, http.StatusNotAcceptable, w.Code)
+}
+
+func TestExportTransactions_InvalidFilter(t *testing.T) {
+	stream := &exportStream{err: status.Error(codes.InvalidArgument, "invalid from")}
+
+	h := NewHandler(exportClient(t, stream))
+	w := httptest.NewRecorder()
+
+	h.ExportTransactions(w, newExportRequest("/api/transactions/export?from=yesterday", ""))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "invalid from")
+}
+
+func TestExportTransactions_BrokenStream(t *testing.T) {
+	stream := &exportStream{
+		txs: []*ledgerv1.Transaction{{Id: 1, Amount: 1, Category: "food", Date: "2025-01-15"}},
+		err: status.Error(codes.Unavailable, "ledger went away"),
+	}
+
+	h := NewHandler(exportClient(t, stream))
+	w := httptest.NewRecorder()
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ExportTransactions(w, newExportRequest("/api/transactions/export", ""))
+	})
+}
+
+// backupStream yields chunks and then err, io.EOF when err is nil.
+type backupStream struct {
+	grpc.ClientStream
+	chunks [][]byte
+	err    error
+}
+
+func (s *backupStream) Recv() (*ledgerv1.BackupChunk, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	c := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return &ledgerv1.BackupChunk{Data: c}, nil
+}
+
+func backupClient(t *testing.T, stream *backupStream) *mockLedgerClient {
+	return &mockLedgerClient{
+		backup: func(ctx context.Context, _ *emptypb.Empty, _ ...grpc.CallOption) (ledgerv1.LedgerService_ExportBackupClient, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, []string{"user-1"}, md.Get("user_id"))
+			return stream, nil
+		},
+	}
+}
+
+func newBackupRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/backup", nil)
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+}
+
+func TestExportBackup(t *testing.T) {
+	stream := &backupStream{chunks: [][]byte{[]byte("PK\x03\x04"), []byte("rest")}}
+
+	h := NewHandler(backupClient(t, stream))
+	w := httptest.NewRecorder()
+
+	h.ExportBackup(w, newBackupRequest())
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	require.Contains(t, w.Header().Get("Content-Disposition"), "gofinance-backup-")
+	require.Equal(t, "PK\x03\x04rest", w.Body.String())
+}
+
+func TestExportBackup_Error(t *testing.T) {
+	stream := &backupStream{err: status.Error(codes.Unavailable, "database is down")}
+
+	h := NewHandler(backupClient(t, stream))
+	w := httptest.NewRecorder()
+
+	h.ExportBackup(w, newBackupRequest())
+
+	require.NotEqual(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestExportBackup_BrokenStream(t *testing.T) {
+	stream := &backupStream{
+		chunks: [][]byte{[]byte("PK\x03\x04")},
+		err:    status.Error(codes.Unavailable, "ledger went away"),
+	}
+
+	h := NewHandler(backupClient(t, stream))
+	w := httptest.NewRecorder()
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ExportBackup(w, newBackupRequest())
+	})
+}
+
+// restoreStream collects an uploaded archive.
+type restoreStream struct {
+	grpc.ClientStream
+	chunks []*ledgerv1.RestoreBackupRequest
+	res    *ledgerv1.RestoreBackupResponse
+	err    error
+}
+
+func (s *restoreStream) Send(c *ledgerv1.RestoreBackupRequest) error {
+	s.chunks = append(s.chunks, &ledgerv1.RestoreBackupRequest{
+		Mode: c.Mode,
+		Data: append([]byte(nil), c.Data...),
+	})
+	return nil
+}
+
+func (s *restoreStream) CloseAndRecv() (*ledgerv1.RestoreBackupResponse, error) {
+	return s.res, s.err
+}
+
+func TestRestoreBackup(t *testing.T) {
+	archive := strings.Repeat("z", backupChunkSize+10)
+	stream := &restoreStream{
+		res: &ledgerv1.RestoreBackupResponse{Budgets: 2, Transactions: 40, ImportProfiles: 1, Duplicates: 3},
+	}
+
+	client := &mockLedgerClient{
+		restore: func(ctx context.Context, _ ...grpc.CallOption) (ledgerv1.LedgerService_RestoreBackupClient, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, []string{"user-1"}, md.Get("user_id"))
+			return stream, nil
+		},
+	}
+
+	h := NewHandler(client)
+	req := newImportRequest(t, "backup.zip", archive, map[string]string{"mode": "replace"})
+	w := httptest.NewRecorder()
+
+	h.RestoreBackup(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Len(t, stream.chunks, 2)
+	require.Equal(t, "replace", stream.chunks[0].Mode)
+	require.Empty(t, stream.chunks[1].Mode)
+	require.Equal(t, archive, string(stream.chunks[0].Data)+string(stream.chunks[1].Data))
+
+	var resp internal.RestoreBackupResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, internal.RestoreBackupResponse{Budgets: 2, Transactions: 40, ImportProfiles: 1, Duplicates: 3}, resp)
+}
+
+func TestRestoreBackup_Invalid(t *testing.T) {
+	stream := &restoreStream{err: status.Error(codes.InvalidArgument, "invalid backup: checksum mismatch for budgets.json")}
+
+	client := &mockLedgerClient{
+		restore: func(ctx context.Context, _ ...grpc.CallOption) (ledgerv1.LedgerService_RestoreBackupClient, error) {
+			return stream, nil
+		},
+	}
+
+	h := NewHandler(client)
+	w := httptest.NewRecorder()
+
+	h.RestoreBackup(w, newImportRequest(t, "backup.zip", "PK", nil))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
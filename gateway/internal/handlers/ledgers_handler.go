@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"gateway/internal"
+	"gateway/internal/middleware"
+	ledgerv1 "gateway/ledger/v1"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// ledgerContext returns the context for calls about ledgerID, or the
+// ledger of the X-Ledger-ID header when ledgerID is empty.
+func ledgerContext(w http.ResponseWriter, r *http.Request, ledgerID string) (context.Context, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	ctx := r.Context()
+	if ledgerID != "" {
+		ctx = middleware.WithLedgerID(ctx, ledgerID)
+	}
+
+	md := metadata.New(map[string]string{
+		"user_id": userID,
+	})
+	return metadata.NewOutgoingContext(ctx, md), true
+}
+
+// ledgerPath splits /api/ledgers/{id}/{resource}[/{sub}].
+func ledgerPath(path, resource string) (ledgerID, sub string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/api/ledgers/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] != resource {
+		return "", "", false
+	}
+	if len(parts) == 3 {
+		if parts[2] == "" {
+			return "", "", false
+		}
+		sub = parts[2]
+	}
+	return parts[0], sub, true
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		http.Error(
+			w,
+			"Content-Type must be application/json",
+			http.StatusUnsupportedMediaType,
+		)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		responseJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "invalid json",
+		})
+		return false
+	}
+	return true
+}
+
+// ListLedgers godoc
+// @Summary List ledgers
+// @Description Lists the ledgers you are a member of, your personal ledger
+// @Description included, with your role in each. Pass a ledger's id in the
+// @Description X-Ledger-ID header to work with its transactions and budgets.
+// @Tags ledgers
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} internal.LedgerResponse
+// @Router /api/ledgers [get]
+func (h *Handler) ListLedgers(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := ledgerContext(w, r, "")
+	if !ok {
+		return
+	}
+
+	resp, err := h.client.ListLedgers(ctx, &emptypb.Empty{})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	out := make([]internal.LedgerResponse, 0, len(resp.Ledgers))
+	for _, l := range resp.Ledgers {
+		out = append(out, ledgerToDTO(l))
+	}
+
+	responseJSON(w, http.StatusOK, out)
+}
+
+// CreateLedger godoc
+// @Summary Create a shared ledger
+// @Description Creates a ledger owned by you. Invite others to share it.
+// @Tags ledgers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body internal.CreateLedgerRequest true "Ledger"
+// @Success 201 {object} internal.LedgerResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/ledgers [post]
+func (h *Handler) CreateLedger(w http.ResponseWriter, r *http.Request) {
+	var dto internal.CreateLedgerRequest
+	if !decodeJSON(w, r, &dto) {
+		return
+	}
+
+	ctx, ok := ledgerContext(w, r, "")
+	if !ok {
+		return
+	}
+
+	l, err := h.client.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{Name: dto.Name})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	responseJSON(w, http.StatusCreated, ledgerToDTO(l))
+}
+
+// CreateInvitation godoc
+// @Summary Invite to a ledger
+// @Description Creates a single-use invitation token that is valid for 7
+// @Description days. Whoever accepts it joins the ledger as an editor or a
+// @Description viewer. Owners only.
+// @Tags ledgers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Ledger ID"
+// @Param request body internal.CreateInvitationRequest true "Role"
+// @Success 201 {object} internal.InvitationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/ledgers/{id}/invitations [post]
+func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	ledgerID, sub, ok := ledgerPath(r.URL.Path, "invitations")
+	if !ok || sub != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var dto internal.CreateInvitationRequest
+	if !decodeJSON(w, r, &dto) {
+		return
+	}
+
+	ctx, ok := ledgerContext(w, r, ledgerID)
+	if !ok {
+		return
+	}
+
+	inv, err := h.client.CreateInvitation(ctx, &ledgerv1.CreateInvitationRequest{Role: dto.Role})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	responseJSON(w, http.StatusCreated, internal.InvitationResponse{
+		Token:     inv.Token,
+		Role:      inv.Role,
+		ExpiresAt: inv.ExpiresAt,
+	})
+}
+
+// AcceptInvitation godoc
+// @Summary Join a ledger
+// @Description Accepts an invitation token. Members keep their role when
+// @Description they accept another invitation to the same ledger.
+// @Tags ledgers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body internal.AcceptInvitationRequest true "Invitation"
+// @Success 200 {object} internal.LedgerResponse
+// @Failure 404 {object} map[string]string
+// @Router /api/invitations/accept [post]
+func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var dto internal.AcceptInvitationRequest
+	if !decodeJSON(w, r, &dto) {
+		return
+	}
+
+	ctx, ok := ledgerContext(w, r, "")
+	if !ok {
+		return
+	}
+
+	l, err := h.client.AcceptInvitation(ctx, &ledgerv1.AcceptInvitationRequest{Token: dto.Token})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	responseJSON(w, http.StatusOK, ledgerToDTO(l))
+}
+
+// ListMembers godoc
+// @Summary List ledger members
+// @Tags ledgers
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ledger ID"
+// @Success 200 {array} internal.MemberResponse
+// @Failure 404 {object} map[string]string
+// @Router /api/ledgers/{id}/members [get]
+func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	ledgerID, sub, ok := ledgerPath(r.URL.Path, "members")
+	if !ok || sub != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx, ok := ledgerContext(w, r, ledgerID)
+	if !ok {
+		return
+	}
+
+	resp, err := h.client.ListMembers(ctx, &emptypb.Empty{})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	out := make([]internal.MemberResponse, 0, len(resp.Members))
+	for _, m := range resp.Members {
+		out = append(out, memberToDTO(m))
+	}
+
+	responseJSON(w, http.StatusOK, out)
+}
+
+// SetMemberRole godoc
+// @Summary Change a member's role
+// @Description Makes a member an editor or a viewer. The owner's role
+// @Description cannot change. Owners only.
+// @Tags ledgers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Ledger ID"
+// @Param user_id path string true "User ID"
+// @Param request body internal.SetMemberRoleRequest true "Role"
+// @Success 200 {object} internal.MemberResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/ledgers/{id}/members/{user_id} [put]
+func (h *Handler) SetMemberRole(w http.ResponseWriter, r *http.Request) {
+	ledgerID, userID, ok := ledgerPath(r.URL.Path, "members")
+	if !ok || userID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var dto internal.SetMemberRoleRequest
+	if !decodeJSON(w, r, &dto) {
+		return
+	}
+
+	ctx, ok := ledgerContext(w, r, ledgerID)
+	if !ok {
+		return
+	}
+
+	m, err := h.client.SetMemberRole(ctx, &ledgerv1.SetMemberRoleRequest{
+		UserId: userID,
+		Role:   dto.Role,
+	})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	responseJSON(w, http.StatusOK, memberToDTO(m))
+}
+
+// RemoveMember godoc
+// @Summary Remove a member
+// @Description Owners remove members; members may remove themselves to
+// @Description leave. The owner cannot leave.
+// @Tags ledgers
+// @Security BearerAuth
+// @Param id path string true "Ledger ID"
+// @Param user_id path string true "User ID"
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/ledgers/{id}/members/{user_id} [delete]
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	ledgerID, userID, ok := ledgerPath(r.URL.Path, "members")
+	if !ok || userID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx, ok := ledgerContext(w, r, ledgerID)
+	if !ok {
+		return
+	}
+
+	if _, err := h.client.RemoveMember(ctx, &ledgerv1.MemberRequest{UserId: userID}); err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MemberSpending godoc
+// @Summary Spending per member
+// @Description Totals what each member recorded between the dates,
+// @Description biggest spender first. Former members are included.
+// @Tags ledgers
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Ledger ID"
+// @Param from query string true "From date (YYYY-MM-DD)"
+// @Param to query string true "To date (YYYY-MM-DD)"
+// @Success 200 {array} internal.MemberSpendingResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/ledgers/{id}/spending [get]
+func (h *Handler) MemberSpending(w http.ResponseWriter, r *http.Request) {
+	ledgerID, sub, ok := ledgerPath(r.URL.Path, "spending")
+	if !ok || sub != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx, ok := ledgerContext(w, r, ledgerID)
+	if !ok {
+		return
+	}
+
+	resp, err := h.client.GetMemberSpending(ctx, &ledgerv1.ReportSummaryRequest{
+		From: r.URL.Query().Get("from"),
+		To:   r.URL.Query().Get("to"),
+	})
+	if err != nil {
+		grpcErrorToHTTP(w, err)
+		return
+	}
+
+	out := make([]internal.MemberSpendingResponse, 0, len(resp.Members))
+	for _, m := range resp.Members {
+		out = append(out, internal.MemberSpendingResponse{
+			UserID:       m.UserId,
+			Transactions: m.Transactions,
+			Total:        m.Total,
+		})
+	}
+
+	responseJSON(w, http.StatusOK, out)
+}
+
+func ledgerToDTO(l *ledgerv1.Ledger) internal.LedgerResponse {
+	return internal.LedgerResponse{
+		ID:        l.Id,
+		Name:      l.Name,
+		OwnerID:   l.OwnerId,
+		Role:      l.Role,
+		CreatedAt: l.CreatedAt,
+	}
+}
+
+func memberToDTO(m *ledgerv1.Member) internal.MemberResponse {
+	return internal.MemberResponse{
+		UserID:   m.UserId,
+		Role:     m.Role,
+		JoinedAt: m.JoinedAt,
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gateway/internal"
+	"gateway/internal/middleware"
+	ledgerv1 "gateway/ledger/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newLedgerRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user-1"))
+}
+
+func TestCreateInvitation(t *testing.T) {
+	client := &mockLedgerClient{
+		invite: func(ctx context.Context, in *ledgerv1.CreateInvitationRequest, _ ...grpc.CallOption) (*ledgerv1.Invitation, error) {
+			ledgerID, ok := middleware.GetLedgerID(ctx)
+			require.True(t, ok)
+			require.Equal(t, "ledger-1", ledgerID)
+			require.Equal(t, "viewer", in.Role)
+			return &ledgerv1.Invitation{Token: "t-1", Role: in.Role, ExpiresAt: "2025-01-08T00:00:00Z"}, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	NewHandler(client).CreateInvitation(w, newLedgerRequest(http.MethodPost, "/api/ledgers/ledger-1/invitations", `{"role":"viewer"}`))
+
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp internal.InvitationResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "t-1", resp.Token)
+}
+
+func TestCreateInvitation_NotOwner(t *testing.T) {
+	client := &mockLedgerClient{
+		invite: func(ctx context.Context, in *ledgerv1.CreateInvitationRequest, _ ...grpc.CallOption) (*ledgerv1.Invitation, error) {
+			return nil, status.Error(codes.PermissionDenied, "your role in this ledger does not allow this")
+		},
+	}
+
+	w := httptest.NewRecorder()
+	NewHandler(client).CreateInvitation(w, newLedgerRequest(http.MethodPost, "/api/ledgers/ledger-1/invitations", `{"role":"editor"}`))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestSetMemberRole(t *testing.T) {
+	client := &mockLedgerClient{
+		setRole: func(ctx context.Context, in *ledgerv1.SetMemberRoleRequest, _ ...grpc.CallOption) (*ledgerv1.Member, error) {
+			ledgerID, _ := middleware.GetLedgerID(ctx)
+			require.Equal(t, "ledger-1", ledgerID)
+			require.Equal(t, "user-2", in.UserId)
+			return &ledgerv1.Member{UserId: in.UserId, Role: in.Role}, nil
+		},
+	}
+	h := NewHandler(client)
+
+	w := httptest.NewRecorder()
+	h.SetMemberRole(w, newLedgerRequest(http.MethodPut, "/api/ledgers/ledger-1/members/user-2", `{"role":"editor"}`))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp internal.MemberResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "editor", resp.Role)
+
+	w = httptest.NewRecorder()
+	h.SetMemberRole(w, newLedgerRequest(http.MethodPut, "/api/ledgers/ledger-1/members/", `{"role":"editor"}`))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMemberSpending(t *testing.T) {
+	client := &mockLedgerClient{
+		spending: func(ctx context.Context, in *ledgerv1.ReportSummaryRequest, _ ...grpc.CallOption) (*ledgerv1.MemberSpendingResponse, error) {
+			require.Equal(t, "2025-01-01", in.From)
+			require.Equal(t, "2025-01-31", in.To)
+			return &ledgerv1.MemberSpendingResponse{Members: []*ledgerv1.MemberSpending{
+				{UserId: "user-2", Transactions: 4, Total: 120.5},
+				{UserId: "user-1", Transactions: 1, Total: 10},
+			}}, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	NewHandler(client).MemberSpending(w, newLedgerRequest(http.MethodGet, "/api/ledgers/ledger-1/spending?from=2025-01-01&to=2025-01-31", ""))
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp []internal.MemberSpendingResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp, 2)
+	require.Equal(t, "user-2", resp[0].UserID)
+	require.Equal(t, 120.5, resp[0].Total)
+}
+
+func TestLedgerPath(t *testing.T) {
+	id, sub, ok := ledgerPath("/api/ledgers/l-1/members/u-1", "members")
+	require.True(t, ok)
+	require.Equal(t, "l-1", id)
+	require.Equal(t, "u-1", sub)
+
+	_, _, ok = ledgerPath("/api/ledgers/l-1/members/u-1/x", "members")
+	require.False(t, ok)
+
+	_, _, ok = ledgerPath("/api/ledgers//members", "members")
+	require.False(t, ok)
+
+	_, _, ok = ledgerPath("/api/ledgers/l-1/spending", "members")
+	require.False(t, ok)
+}
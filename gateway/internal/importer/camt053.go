@@ -0,0 +1,235 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// camtReader streams ISO 20022 camt.053 (bank to customer statement)
+// documents. Only <Ntry> elements are decoded, one at a time, so the size of
+// the file does not matter. Both the .001.02 and the newer .001.08 layouts
+// are understood.
+type camtReader struct {
+	d     *xml.Decoder
+	opts  Options
+	queue []camtQueued // batch entries expand into several transactions
+}
+
+// camtQueued is a transaction of a batch entry, or the reason one of its
+// details was skipped.
+type camtQueued struct {
+	tx  Transaction
+	err error
+}
+
+type camtParty struct {
+	Nm  string `xml:"Nm"`
+	Pty struct {
+		Nm string `xml:"Nm"`
+	} `xml:"Pty"`
+}
+
+func (p camtParty) name() string {
+	if p.Nm != "" {
+		return p.Nm
+	}
+	return p.Pty.Nm
+}
+
+type camtDate struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm"`
+}
+
+type camtTxDtls struct {
+	Amt     string `xml:"Amt"`
+	AmtDtls struct {
+		TxAmt struct {
+			Amt string `xml:"Amt"`
+		} `xml:"TxAmt"`
+	} `xml:"AmtDtls"`
+	CdtDbtInd string `xml:"CdtDbtInd"`
+	Refs      struct {
+		AcctSvcrRef string `xml:"AcctSvcrRef"`
+		EndToEndId  string `xml:"EndToEndId"`
+	} `xml:"Refs"`
+	RltdPties struct {
+		Dbtr camtParty `xml:"Dbtr"`
+		Cdtr camtParty `xml:"Cdtr"`
+	} `xml:"RltdPties"`
+	RmtInf struct {
+		Ustrd []string `xml:"Ustrd"`
+	} `xml:"RmtInf"`
+}
+
+type camtEntry struct {
+	NtryRef   string `xml:"NtryRef"`
+	Amt       string `xml:"Amt"`
+	CdtDbtInd string `xml:"CdtDbtInd"`
+	RvslInd   bool   `xml:"RvslInd"`
+	Sts       struct {
+		Value string `xml:",chardata"`
+		Cd    string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookgDt      camtDate     `xml:"BookgDt"`
+	ValDt        camtDate     `xml:"ValDt"`
+	AcctSvcrRef  string       `xml:"AcctSvcrRef"`
+	TxDtls       []camtTxDtls `xml:"NtryDtls>TxDtls"`
+	AddtlNtryInf string       `xml:"AddtlNtryInf"`
+}
+
+func newCAMTReader(r io.Reader, opts Options) *camtReader {
+	return &camtReader{d: xml.NewDecoder(r), opts: opts}
+}
+
+func (c *camtReader) Next() (Transaction, error) {
+	for {
+		if len(c.queue) > 0 {
+			q := c.queue[0]
+			c.queue = c.queue[1:]
+			return q.tx, q.err
+		}
+
+		tok, err := c.d.Token()
+		if err != nil {
+			return Transaction{}, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Ntry" {
+			continue
+		}
+
+		line, _ := c.d.InputPos()
+
+		var e camtEntry
+		if err := c.d.DecodeElement(&e, &start); err != nil {
+			return Transaction{}, err
+		}
+		if err := c.expand(e, line); err != nil {
+			return Transaction{}, err
+		}
+	}
+}
+
+// expand turns one entry into transactions on the queue. A batch entry
+// whose TxDtls all carry their own amount yields one transaction per detail,
+// and a row error for each detail that is skipped.
+func (c *camtReader) expand(e camtEntry, line int) error {
+	status := strings.TrimSpace(e.Sts.Cd)
+	if status == "" {
+		status = strings.TrimSpace(e.Sts.Value)
+	}
+	if status != "" && status != "BOOK" {
+		return &RowError{Line: line, Reason: "entry status " + status + " is not booked"}
+	}
+
+	date, err := parseCAMTDate(e.BookgDt)
+	if err != nil {
+		if date, err = parseCAMTDate(e.ValDt); err != nil {
+			return &RowError{Line: line, Reason: "missing booking date"}
+		}
+	}
+
+	batch := len(e.TxDtls) > 1
+	for _, d := range e.TxDtls {
+		if d.amount() == "" {
+			batch = false
+		}
+	}
+
+	if !batch {
+		var d camtTxDtls
+		if len(e.TxDtls) > 0 {
+			d = e.TxDtls[0]
+		}
+		ref := firstRef(d.Refs.AcctSvcrRef, e.AcctSvcrRef, e.NtryRef, d.Refs.EndToEndId)
+		return c.push(e, d, e.Amt, e.CdtDbtInd, ref, date, line)
+	}
+
+	for i, d := range e.TxDtls {
+		indicator := d.CdtDbtInd
+		if indicator == "" {
+			indicator = e.CdtDbtInd
+		}
+		ref := firstRef(d.Refs.AcctSvcrRef, d.Refs.EndToEndId)
+		if ref == "" {
+			// keep references unique within the batch
+			if ref = firstRef(e.AcctSvcrRef, e.NtryRef); ref != "" {
+				ref = fmt.Sprintf("%s/%d", ref, i+1)
+			}
+		}
+		if err := c.push(e, d, d.amount(), indicator, ref, date, line); err != nil {
+			c.queue = append(c.queue, camtQueued{err: err})
+		}
+	}
+	return nil
+}
+
+func (c *camtReader) push(e camtEntry, d camtTxDtls, raw, indicator, ref string, date time.Time, line int) error {
+	amount, err := parseAmount(raw)
+	if err != nil {
+		return &RowError{Line: line, Reason: "invalid amount: " + raw}
+	}
+
+	// a reversed credit takes money out of the account and vice versa
+	debit := indicator == "DBIT"
+	if e.RvslInd {
+		debit = !debit
+	}
+	if !debit {
+		return &RowError{Line: line, Reason: "not an expense: " + indicator + " " + raw}
+	}
+
+	counterparty := d.RltdPties.Cdtr.name()
+	if e.RvslInd {
+		counterparty = d.RltdPties.Dbtr.name()
+	}
+
+	remittance := strings.Join(d.RmtInf.Ustrd, " ")
+	if remittance == "" {
+		remittance = e.AddtlNtryInf
+	}
+
+	c.queue = append(c.queue, camtQueued{tx: Transaction{
+		Amount:      amount.Abs(),
+		Category:    c.opts.DefaultCategory,
+		Description: joinDescription(counterparty, remittance),
+		Date:        date,
+		ExternalID:  ref,
+		Line:        line,
+	}})
+	return nil
+}
+
+func (d camtTxDtls) amount() string {
+	if d.Amt != "" {
+		return d.Amt
+	}
+	return d.AmtDtls.TxAmt.Amt
+}
+
+func parseCAMTDate(d camtDate) (time.Time, error) {
+	if d.Dt != "" {
+		return time.Parse("2006-01-02", strings.TrimSpace(d.Dt))
+	}
+	if len(d.DtTm) >= 10 {
+		return time.Parse("2006-01-02", d.DtTm[:10])
+	}
+	return time.Time{}, &time.ParseError{Layout: "2006-01-02", Value: d.DtTm}
+}
+
+// firstRef returns the first usable reference; banks fill unused ones with
+// NOTPROVIDED or NONREF.
+func firstRef(refs ...string) string {
+	for _, r := range refs {
+		r = strings.TrimSpace(r)
+		if r != "" && r != "NOTPROVIDED" && r != "NONREF" {
+			return r
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCAMTReader(t *testing.T) {
+	txs, skipped := readFixture(t, "camt053.xml", FormatCAMT053, Options{DefaultCategory: "bank"})
+
+	require.Len(t, txs, 3)
+
+	require.True(t, txs[0].Amount.Equal(decimal.RequireFromString("42.50")))
+	require.Equal(t, "bank", txs[0].Category)
+	require.Equal(t, "Supermarkt GmbH - Einkauf 05.01.", txs[0].Description)
+	require.Equal(t, time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	require.Equal(t, "BANKREF-001", txs[0].ExternalID)
+
+	// batch entry split by TxDtls
+	require.True(t, txs[1].Amount.Equal(decimal.NewFromInt(10)))
+	require.Equal(t, "Stadtwerke - Strom", txs[1].Description)
+	require.Equal(t, "BATCH-7/1", txs[1].ExternalID)
+	require.Equal(t, time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC), txs[1].Date)
+
+	require.True(t, txs[2].Amount.Equal(decimal.NewFromInt(20)))
+	require.Equal(t, "Telekom - Rechnung Januar", txs[2].Description)
+	require.Equal(t, "E2E-77", txs[2].ExternalID)
+
+	require.Len(t, skipped, 2)
+	require.Contains(t, skipped[0].Reason, "CRDT")
+	require.Contains(t, skipped[1].Reason, "PDNG")
+	require.Equal(t, 13, txs[0].Line)
+	require.Equal(t, 30, skipped[0].Line)
+}
+
+func TestCAMTReader_V08(t *testing.T) {
+	txs, skipped := readFixture(t, "camt053_v08.xml", FormatCAMT053, Options{})
+
+	require.Empty(t, skipped)
+	require.Len(t, txs, 2)
+
+	require.Equal(t, "SBB CFF FFS - Bahnticket", txs[0].Description)
+	require.Equal(t, "N-1", txs[0].ExternalID)
+
+	// reversal of a credit is money going out
+	require.True(t, txs[1].Amount.Equal(decimal.NewFromInt(8)))
+	require.Equal(t, "Refund Reversal AG", txs[1].Description)
+}
+
+func TestCAMTReader_BatchRejectsEachDetail(t *testing.T) {
+	doc := `<Document><BkToCstmrStmt><Stmt>
+<Ntry>
+  <Amt>30.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts>BOOK</Sts>
+  <BookgDt><Dt>2025-02-01</Dt></BookgDt>
+  <NtryDtls>
+    <TxDtls><Amt>abc</Amt><Refs><EndToEndId>E2E-1</EndToEndId></Refs></TxDtls>
+    <TxDtls><Amt>10.00</Amt><Refs><EndToEndId>E2E-2</EndToEndId></Refs></TxDtls>
+    <TxDtls><Amt>20.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Refs><EndToEndId>E2E-3</EndToEndId></Refs></TxDtls>
+  </NtryDtls>
+</Ntry>
+</Stmt></BkToCstmrStmt></Document>`
+
+	txs, skipped, err := ReadAll(newCAMTReader(strings.NewReader(doc), Options{}))
+	require.NoError(t, err)
+
+	require.Len(t, txs, 1)
+	require.Equal(t, "E2E-2", txs[0].ExternalID)
+
+	require.Len(t, skipped, 2)
+	require.Contains(t, skipped[0].Reason, "invalid amount")
+	require.Contains(t, skipped[1].Reason, "CRDT")
+}
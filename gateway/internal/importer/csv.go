@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// csvReader reads the gateway's own export layout:
+// amount,category,description,date[,external_id] with a header row.
+type csvReader struct {
+	r          *csv.Reader
+	opts       Options
+	headerRead bool
+}
+
+func newCSVReader(r io.Reader, opts Options) *csvReader {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	return &csvReader{r: reader, opts: opts}
+}
+
+func (c *csvReader) Next() (Transaction, error) {
+	if !c.headerRead {
+		c.headerRead = true
+		if _, err := c.r.Read(); err != nil {
+			return Transaction{}, err
+		}
+	}
+
+	row, err := c.r.Read()
+	if err != nil {
+		return Transaction{}, err
+	}
+	line, _ := c.r.FieldPos(0)
+
+	if len(row) < 4 {
+		return Transaction{}, &RowError{Line: line, Reason: "expected 4 columns"}
+	}
+
+	amount, err := decimal.NewFromString(strings.TrimSpace(row[0]))
+	if err != nil {
+		return Transaction{}, &RowError{Line: line, Reason: "invalid amount: " + row[0]}
+	}
+
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(row[3]))
+	if err != nil {
+		return Transaction{}, &RowError{Line: line, Reason: "invalid date: " + row[3]}
+	}
+
+	category := row[1]
+	if strings.TrimSpace(category) == "" {
+		category = c.opts.DefaultCategory
+	}
+
+	var externalID string
+	if len(row) > 4 {
+		externalID = strings.TrimSpace(row[4])
+	}
+
+	return Transaction{
+		Amount:      amount,
+		Category:    category,
+		Description: row[2],
+		Date:        date,
+		ExternalID:  externalID,
+		Line:        line,
+	}, nil
+}
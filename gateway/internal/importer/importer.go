@@ -0,0 +1,220 @@
+// Package importer turns bank statement files into transactions for the ledger.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatOFX     Format = "ofx"
+	FormatQIF     Format = "qif"
+	FormatCAMT053 Format = "camt053"
+	FormatMT940   Format = "mt940"
+	FormatJSONL   Format = "jsonl"
+	FormatXLSX    Format = "xlsx"
+)
+
+// Transaction is a single expense read from a statement. Bank formats
+// report signed amounts: debits become positive expenses, credits are skipped.
+type Transaction struct {
+	Amount      decimal.Decimal
+	Category    string
+	Description string
+	Date        time.Time
+	ExternalID  string // FITID for OFX/QFX, empty when the format has none
+	Line        int    // where the record starts in the source file
+}
+
+// RowError reports a statement record that was skipped. It is not fatal:
+// the reader can continue with the next record.
+type RowError struct {
+	Line   int
+	Reason string
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Reader yields transactions one by one. Next returns io.EOF once the
+// statement is exhausted and *RowError for records that were skipped.
+type Reader interface {
+	Next() (Transaction, error)
+}
+
+type Options struct {
+	// DefaultCategory is used for records that carry no category of their own.
+	DefaultCategory string
+
+	// CSV switches the CSV reader from the positional
+	// amount,category,description,date layout to a saved column mapping.
+	CSV *CSVProfile
+}
+
+func NewReader(format Format, r io.Reader, opts Options) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		if opts.CSV != nil {
+			return newProfileReader(r, opts)
+		}
+		return newCSVReader(r, opts), nil
+	case FormatOFX:
+		return newOFXReader(r, opts), nil
+	case FormatQIF:
+		return newQIFReader(r, opts), nil
+	case FormatCAMT053:
+		return newCAMTReader(r, opts), nil
+	case FormatMT940:
+		return newMT940Reader(r, opts), nil
+	case FormatJSONL:
+		return newJSONLReader(r, opts), nil
+	case FormatXLSX:
+		return newXLSXReader(r, opts)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %q", format)
+	}
+}
+
+// Detect guesses the statement format from the file name and the first
+// bytes of its content. CSV is the fallback.
+func Detect(filename string, head []byte) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".qif":
+		return FormatQIF
+	case ".sta", ".mt940", ".940":
+		return FormatMT940
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".xlsx":
+		return FormatXLSX
+	}
+
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return FormatXLSX
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")))
+	upper := bytes.ToUpper(trimmed)
+
+	switch {
+	case bytes.HasPrefix(upper, []byte("OFXHEADER")),
+		bytes.Contains(upper, []byte("<OFX>")):
+		return FormatOFX
+	case bytes.HasPrefix(upper, []byte("!TYPE:")),
+		bytes.HasPrefix(upper, []byte("!ACCOUNT")),
+		bytes.HasPrefix(upper, []byte("!OPTION:")):
+		return FormatQIF
+	case bytes.Contains(trimmed, []byte("camt.053")),
+		bytes.Contains(trimmed, []byte("<BkToCstmrStmt")):
+		return FormatCAMT053
+	case bytes.HasPrefix(trimmed, []byte(":20:")),
+		bytes.HasPrefix(trimmed, []byte("{1:")):
+		return FormatMT940
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return FormatJSONL
+	default:
+		return FormatCSV
+	}
+}
+
+// ReadAll drains r, collecting transactions and skipped records separately.
+func ReadAll(r Reader) ([]Transaction, []RowError, error) {
+	var (
+		txs     []Transaction
+		skipped []RowError
+	)
+
+	for {
+		tx, err := r.Next()
+		if err == io.EOF {
+			return txs, skipped, nil
+		}
+		if err != nil {
+			rowErr, ok := err.(*RowError)
+			if !ok {
+				return nil, nil, err
+			}
+			skipped = append(skipped, *rowErr)
+			continue
+		}
+		txs = append(txs, tx)
+	}
+}
+
+// Count drains r like ReadAll but keeps only the number of transactions,
+// for checking a statement without holding it in memory.
+func Count(r Reader) (int, []RowError, error) {
+	var (
+		n       int
+		skipped []RowError
+	)
+
+	for {
+		_, err := r.Next()
+		if err == io.EOF {
+			return n, skipped, nil
+		}
+		if err != nil {
+			rowErr, ok := err.(*RowError)
+			if !ok {
+				return 0, nil, err
+			}
+			skipped = append(skipped, *rowErr)
+			continue
+		}
+		n++
+	}
+}
+
+// parseAmount accepts both "1234.56" and "1 234,56" style numbers.
+func parseAmount(s string) (decimal.Decimal, error) {
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, " ", "")
+	s = strings.ReplaceAll(s, " ", "")
+
+	if strings.Contains(s, ",") {
+		if strings.Contains(s, ".") {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.ReplaceAll(s, ",", ".")
+		}
+	}
+
+	return decimal.NewFromString(s)
+}
+
+// expense converts a signed bank amount into an expense amount.
+// Negative amounts are debits; zero and positive amounts are not expenses.
+func expense(amount decimal.Decimal) (decimal.Decimal, bool) {
+	if !amount.IsNegative() {
+		return decimal.Zero, false
+	}
+	return amount.Neg(), true
+}
+
+func joinDescription(parts ...string) string {
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if len(out) > 0 && out[len(out)-1] == p {
+			continue
+		}
+		out = append(out, p)
+	}
+	return strings.Join(out, " - ")
+}
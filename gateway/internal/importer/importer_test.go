@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func readFixture(t *testing.T, name string, format Format, opts Options) ([]Transaction, []RowError) {
+	t.Helper()
+
+	f, err := os.Open("testdata/" + name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	r, err := NewReader(format, f, opts)
+	require.NoError(t, err)
+
+	txs, skipped, err := ReadAll(r)
+	require.NoError(t, err)
+	return txs, skipped
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		head     string
+		want     Format
+	}{
+		{"ofx extension", "bank.OFX", "", FormatOFX},
+		{"qfx extension", "bank.qfx", "", FormatOFX},
+		{"qif extension", "bank.qif", "", FormatQIF},
+		{"sgml header", "upload", "OFXHEADER:100\nDATA:OFXSGML", FormatOFX},
+		{"xml header", "upload", "<?xml version=\"1.0\"?>\n<?OFX OFXHEADER=\"200\"?>\n<OFX>", FormatOFX},
+		{"qif header", "upload.txt", "\ufeff!Type:Bank\nD01/01/2025", FormatQIF},
+		{"mt940 extension", "konto.sta", "", FormatMT940},
+		{"camt namespace", "export.xml", "<?xml version=\"1.0\"?>\n<Document xmlns=\"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02\">", FormatCAMT053},
+		{"mt940 header", "upload", ":20:STARTUMSE\n:25:123", FormatMT940},
+		{"swift block", "upload", "{1:F01BANKDEFFXXXX0000000000}{2:I940", FormatMT940},
+		{"jsonl extension", "export.ndjson", "", FormatJSONL},
+		{"json lines", "upload", "{\"amount\":\"12.30\"}", FormatJSONL},
+		{"xlsx extension", "export.XLSX", "", FormatXLSX},
+		{"zip header", "upload", "PK\x03\x04\x14\x00", FormatXLSX},
+		{"csv fallback", "upload", "amount,category,description,date", FormatCSV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Detect(tt.filename, []byte(tt.head)))
+		})
+	}
+}
+
+func TestNewReader_UnknownFormat(t *testing.T) {
+	_, err := NewReader("xls", strings.NewReader(""), Options{})
+	require.Error(t, err)
+}
+
+func TestCSVReader(t *testing.T) {
+	data := "amount,category,description,date\n" +
+		"10.50,food,lunch,2025-01-01\n" +
+		"oops,food,bad,2025-01-02\n" +
+		"5,food\n" +
+		"7,,taxi,2025-01-03\n"
+
+	r, err := NewReader(FormatCSV, strings.NewReader(data), Options{DefaultCategory: "misc"})
+	require.NoError(t, err)
+
+	txs, skipped, err := ReadAll(r)
+	require.NoError(t, err)
+
+	require.Len(t, txs, 2)
+	require.True(t, txs[0].Amount.Equal(decimal.RequireFromString("10.50")))
+	require.Equal(t, "food", txs[0].Category)
+	require.Equal(t, "misc", txs[1].Category)
+
+	require.Len(t, skipped, 2)
+	require.Equal(t, 3, skipped[0].Line)
+	require.Equal(t, 4, skipped[1].Line)
+}
+
+func TestCount(t *testing.T) {
+	data := "amount,category,description,date\n" +
+		"10.50,food,lunch,2025-01-01\n" +
+		"oops,food,bad,2025-01-02\n" +
+		"7,food,taxi,2025-01-03\n"
+
+	r, err := NewReader(FormatCSV, strings.NewReader(data), Options{})
+	require.NoError(t, err)
+
+	n, skipped, err := Count(r)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Len(t, skipped, 1)
+	require.Equal(t, 3, skipped[0].Line)
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := map[string]string{
+		"-12.34":   "-12.34",
+		"-12,34":   "-12.34",
+		"1,234.56": "1234.56",
+		"1 234,56": "1234.56",
+		" -0.5 ":   "-0.5",
+	}
+
+	for in, want := range tests {
+		got, err := parseAmount(in)
+		require.NoError(t, err, in)
+		require.True(t, got.Equal(decimal.RequireFromString(want)), in)
+	}
+}
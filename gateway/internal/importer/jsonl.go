@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// jsonlReader reads the gateway's JSON Lines export: one object per line
+// with amount, category, description, date and external_id.
+type jsonlReader struct {
+	s    *bufio.Scanner
+	opts Options
+	line int
+}
+
+type jsonlRecord struct {
+	Amount      *decimal.Decimal `json:"amount"`
+	Category    string           `json:"category"`
+	Description string           `json:"description"`
+	Date        string           `json:"date"`
+	ExternalID  string           `json:"external_id"`
+}
+
+func newJSONLReader(r io.Reader, opts Options) *jsonlReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	return &jsonlReader{s: s, opts: opts}
+}
+
+func (j *jsonlReader) Next() (Transaction, error) {
+	for j.s.Scan() {
+		j.line++
+		line := j.s.Bytes()
+		if j.line == 1 {
+			line = []byte(strings.TrimPrefix(string(line), "\xef\xbb\xbf"))
+		}
+		if strings.TrimSpace(string(line)) == "" {
+			continue
+		}
+
+		var rec jsonlRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return Transaction{}, &RowError{Line: j.line, Reason: "invalid json"}
+		}
+
+		if rec.Amount == nil {
+			return Transaction{}, &RowError{Line: j.line, Reason: "missing amount"}
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(rec.Date))
+		if err != nil {
+			return Transaction{}, &RowError{Line: j.line, Reason: "invalid date: " + rec.Date}
+		}
+
+		category := rec.Category
+		if strings.TrimSpace(category) == "" {
+			category = j.opts.DefaultCategory
+		}
+
+		return Transaction{
+			Amount:      *rec.Amount,
+			Category:    category,
+			Description: rec.Description,
+			Date:        date,
+			ExternalID:  rec.ExternalID,
+			Line:        j.line,
+		}, nil
+	}
+
+	if err := j.s.Err(); err != nil {
+		return Transaction{}, err
+	}
+	return Transaction{}, io.EOF
+}
@@ -0,0 +1,41 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLReader(t *testing.T) {
+	data := `{"amount":"12.30","category":"food","description":"lunch","date":"2025-01-15","external_id":"F1"}` + "\n" +
+		"\n" +
+		`{"amount":7,"date":"2025-01-16"}` + "\n" +
+		`{"amount":"7","date":"16.01.2025"}` + "\n" +
+		`{"category":"food","date":"2025-01-16"}` + "\n" +
+		`not json` + "\n"
+
+	r, err := NewReader(FormatJSONL, strings.NewReader(data), Options{DefaultCategory: "misc"})
+	require.NoError(t, err)
+
+	txs, skipped, err := ReadAll(r)
+	require.NoError(t, err)
+
+	require.Len(t, txs, 2)
+	require.True(t, txs[0].Amount.Equal(decimal.RequireFromString("12.3")))
+	require.Equal(t, "food", txs[0].Category)
+	require.Equal(t, "F1", txs[0].ExternalID)
+	require.Equal(t, 1, txs[0].Line)
+
+	require.Equal(t, "misc", txs[1].Category)
+	require.Equal(t, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC), txs[1].Date)
+	require.Equal(t, 3, txs[1].Line)
+
+	require.Len(t, skipped, 3)
+	require.Equal(t, 4, skipped[0].Line)
+	require.Contains(t, skipped[0].Reason, "invalid date")
+	require.Equal(t, "missing amount", skipped[1].Reason)
+	require.Equal(t, "invalid json", skipped[2].Reason)
+}
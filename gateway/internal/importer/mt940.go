@@ -0,0 +1,252 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// mt940Reader streams SWIFT MT940 customer statements line by line. Every
+// :61: statement line becomes one transaction, enriched with the :86:
+// information field that follows it.
+type mt940Reader struct {
+	s    *bufio.Scanner
+	opts Options
+	line int
+
+	// the tag line read ahead while folding continuation lines
+	peeked     string
+	peekedLine int
+	hasPeeked  bool
+
+	// a complete field handed back by Next
+	pending *mt940Field
+}
+
+// :61:YYMMDD[MMDD]{C|D|RC|RD}[funds code]amount{N|S|F}xxx reference[//bank reference]
+var mt940StatementLine = regexp.MustCompile(
+	`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})([^/]*)(?://(.*))?$`,
+)
+
+var mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+
+type mt940Field struct {
+	tag   string
+	value string
+	line  int
+}
+
+func newMT940Reader(r io.Reader, opts Options) *mt940Reader {
+	return &mt940Reader{s: bufio.NewScanner(r), opts: opts}
+}
+
+func (m *mt940Reader) Next() (Transaction, error) {
+	var (
+		statement *mt940Field
+		info      *mt940Field
+	)
+
+	for {
+		field, err := m.nextField()
+		if err == io.EOF && statement != nil {
+			return m.record(*statement, info)
+		}
+		if err != nil {
+			return Transaction{}, err
+		}
+
+		switch {
+		case field.tag == "61":
+			if statement != nil {
+				m.unread(field)
+				return m.record(*statement, info)
+			}
+			statement = &field
+
+		case field.tag == "86" && statement != nil && info == nil:
+			info = &field
+
+		case statement != nil:
+			m.unread(field)
+			return m.record(*statement, info)
+		}
+	}
+}
+
+func (m *mt940Reader) record(f mt940Field, info *mt940Field) (Transaction, error) {
+	parts := mt940StatementLine.FindStringSubmatch(f.value)
+	if parts == nil {
+		return Transaction{}, &RowError{Line: f.line, Reason: "invalid :61: statement line"}
+	}
+
+	date, err := time.Parse("060102", parts[1])
+	if err != nil {
+		return Transaction{}, &RowError{Line: f.line, Reason: "invalid value date: " + parts[1]}
+	}
+	// the booking date is when the money left the account, so it wins
+	// over the value date when the bank sends it
+	if parts[2] != "" {
+		if date, err = mt940EntryDate(date, parts[2]); err != nil {
+			return Transaction{}, &RowError{Line: f.line, Reason: "invalid entry date: " + parts[2]}
+		}
+	}
+
+	raw := strings.TrimSuffix(parts[5], ",")
+	amount, err := parseAmount(raw)
+	if err != nil {
+		return Transaction{}, &RowError{Line: f.line, Reason: "invalid amount: " + parts[5]}
+	}
+
+	// RC is a reversal of a credit, i.e. money leaving the account
+	mark := parts[3]
+	if mark != "D" && mark != "RC" {
+		return Transaction{}, &RowError{Line: f.line, Reason: "not an expense: " + mark + " " + parts[5]}
+	}
+
+	var counterparty, remittance string
+	if info != nil {
+		counterparty, remittance = parseMT940Info(info.value)
+	}
+
+	return Transaction{
+		Amount:      amount,
+		Category:    m.opts.DefaultCategory,
+		Description: joinDescription(counterparty, remittance),
+		Date:        date,
+		ExternalID:  firstRef(parts[8], parts[7]),
+		Line:        f.line,
+	}, nil
+}
+
+// mt940EntryDate resolves the MMDD entry date of a :61: line. It carries
+// no year, so it takes the one that puts it nearest the value date: a
+// payment booked on 30 December with value date 2 January was booked the
+// year before.
+func mt940EntryDate(value time.Time, mmdd string) (time.Time, error) {
+	var best time.Time
+	for _, year := range []int{value.Year() - 1, value.Year(), value.Year() + 1} {
+		d, err := time.Parse("20060102", fmt.Sprintf("%04d%s", year, mmdd))
+		if err != nil {
+			// 29 February exists in some years only
+			continue
+		}
+		if best.IsZero() || absDuration(d.Sub(value)) < absDuration(best.Sub(value)) {
+			best = d
+		}
+	}
+	if best.IsZero() {
+		return time.Time{}, fmt.Errorf("invalid entry date %q", mmdd)
+	}
+	return best, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// parseMT940Info splits a :86: field. The structured German layout
+// ("166?00...?20...?32...") carries remittance lines in ?20-?29 and ?60-?63
+// and the counterparty name in ?32/?33; anything else is taken as free text.
+func parseMT940Info(s string) (counterparty, remittance string) {
+	if len(s) < 4 || s[3] != '?' {
+		return "", s
+	}
+
+	var rem, name []string
+	for _, sub := range strings.Split(s[4:], "?") {
+		if len(sub) < 2 {
+			continue
+		}
+		code, value := sub[:2], sub[2:]
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			rem = append(rem, value)
+		case code == "32" || code == "33":
+			name = append(name, value)
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(name, "")), strings.TrimSpace(strings.Join(rem, ""))
+}
+
+// nextField returns the next :tag: field with continuation lines folded in.
+// Lines outside fields (block headers, "-" terminators) are ignored.
+func (m *mt940Reader) nextField() (mt940Field, error) {
+	if m.pending != nil {
+		f := *m.pending
+		m.pending = nil
+		return f, nil
+	}
+
+	var (
+		text string
+		line int
+	)
+
+	if m.hasPeeked {
+		m.hasPeeked = false
+		text, line = m.peeked, m.peekedLine
+	} else {
+		for {
+			l, n, err := m.readLine()
+			if err != nil {
+				return mt940Field{}, err
+			}
+			if mt940Tag.MatchString(l) {
+				text, line = l, n
+				break
+			}
+		}
+	}
+
+	parts := mt940Tag.FindStringSubmatch(text)
+	field := mt940Field{tag: parts[1], value: parts[2], line: line}
+	structured := field.tag == "86" && len(field.value) > 3 && field.value[3] == '?'
+
+	for {
+		l, n, err := m.readLine()
+		if err == io.EOF {
+			return field, nil
+		}
+		if err != nil {
+			return mt940Field{}, err
+		}
+		if mt940Tag.MatchString(l) {
+			m.peeked, m.peekedLine, m.hasPeeked = l, n, true
+			return field, nil
+		}
+		if l == "-" || strings.HasPrefix(l, "-}") || strings.HasPrefix(l, "{") {
+			continue
+		}
+		if field.tag == "61" {
+			// supplementary details; not used
+			continue
+		}
+		if structured {
+			field.value += l
+		} else {
+			field.value += " " + l
+		}
+	}
+}
+
+func (m *mt940Reader) unread(f mt940Field) {
+	m.pending = &f
+}
+
+func (m *mt940Reader) readLine() (string, int, error) {
+	if !m.s.Scan() {
+		if err := m.s.Err(); err != nil {
+			return "", 0, err
+		}
+		return "", 0, io.EOF
+	}
+	m.line++
+	return strings.TrimRight(m.s.Text(), "\r"), m.line, nil
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMT940Reader(t *testing.T) {
+	txs, skipped := readFixture(t, "statement.sta", FormatMT940, Options{DefaultCategory: "bank"})
+
+	require.Len(t, txs, 3)
+
+	require.True(t, txs[0].Amount.Equal(decimal.RequireFromString("42.50")))
+	require.Equal(t, "bank", txs[0].Category)
+	require.Equal(t, "Supermarkt GmbH - SVWZ+Einkauf 05.01.Filiale 12", txs[0].Description)
+	require.Equal(t, time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	require.Equal(t, "BANKREF-1", txs[0].ExternalID)
+	require.Equal(t, 6, txs[0].Line)
+
+	require.True(t, txs[1].Amount.Equal(decimal.RequireFromString("19.99")))
+	require.Equal(t, "Lastschrift Mobilfunk Rechnung Januar", txs[1].Description)
+	require.Equal(t, "KD-4711", txs[1].ExternalID)
+
+	require.True(t, txs[2].Amount.Equal(decimal.NewFromInt(5)))
+	require.Equal(t, "REV-9", txs[2].ExternalID)
+
+	require.Len(t, skipped, 2)
+	require.Equal(t, 9, skipped[0].Line)
+	require.Contains(t, skipped[0].Reason, "not an expense")
+	require.Equal(t, 15, skipped[1].Line)
+}
+
+func TestMT940Reader_EntryDate(t *testing.T) {
+	txs, skipped := readFixture(t, "statement_entry_date.sta", FormatMT940, Options{})
+
+	require.Len(t, txs, 3)
+	require.Equal(t, time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	// booked in the old year, valued in the new one and the other way round
+	require.Equal(t, time.Date(2025, 12, 30, 0, 0, 0, 0, time.UTC), txs[1].Date)
+	require.Equal(t, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), txs[2].Date)
+
+	require.Len(t, skipped, 1)
+	require.Equal(t, 12, skipped[0].Line)
+	require.Contains(t, skipped[0].Reason, "invalid entry date")
+}
+
+func TestParseMT940Info(t *testing.T) {
+	name, rem := parseMT940Info("166?00SEPA?20Invoice ?2142?32ACME?33 GmbH")
+	require.Equal(t, "ACME GmbH", name)
+	require.Equal(t, "Invoice 42", rem)
+
+	name, rem = parseMT940Info("Card payment")
+	require.Empty(t, name)
+	require.Equal(t, "Card payment", rem)
+}
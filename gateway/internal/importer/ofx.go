@@ -0,0 +1,184 @@
+package importer
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// ofxReader understands both OFX 1.x (SGML, closing tags optional for
+// elements) and OFX 2.x (XML). QFX is OFX with Intuit extensions and is
+// read the same way. Only <STMTTRN> aggregates are looked at.
+type ofxReader struct {
+	r       *bufio.Reader
+	opts    Options
+	line    int
+	pending bool // '<' already consumed by the previous text read
+
+	// reopen is set when a <STMTTRN> closed the previous, unterminated one.
+	reopen     bool
+	reopenLine int
+}
+
+func newOFXReader(r io.Reader, opts Options) *ofxReader {
+	return &ofxReader{r: bufio.NewReader(r), opts: opts, line: 1}
+}
+
+func (o *ofxReader) Next() (Transaction, error) {
+	var (
+		fields map[string]string
+		start  int
+	)
+
+	if o.reopen {
+		o.reopen = false
+		fields = make(map[string]string)
+		start = o.reopenLine
+	}
+
+	for {
+		tag, err := o.nextTag()
+		if err == io.EOF && fields != nil {
+			return Transaction{}, &RowError{Line: start, Reason: "unterminated STMTTRN"}
+		}
+		if err != nil {
+			return Transaction{}, err
+		}
+
+		switch {
+		case tag == "STMTTRN":
+			if fields != nil {
+				// SGML files sometimes omit </STMTTRN>; flush the open
+				// record and start the new one on the next call.
+				o.reopen = true
+				o.reopenLine = o.line
+				return o.record(start, fields)
+			}
+			fields = make(map[string]string)
+			start = o.line
+
+		case tag == "/STMTTRN", tag == "/BANKTRANLIST":
+			if fields != nil {
+				return o.record(start, fields)
+			}
+
+		case fields != nil && !strings.HasPrefix(tag, "/"):
+			value, err := o.text()
+			if err != nil && err != io.EOF {
+				return Transaction{}, err
+			}
+			if value != "" {
+				fields[tag] = value
+			}
+		}
+	}
+}
+
+func (o *ofxReader) record(line int, f map[string]string) (Transaction, error) {
+	raw, ok := f["TRNAMT"]
+	if !ok {
+		return Transaction{}, &RowError{Line: line, Reason: "missing TRNAMT"}
+	}
+	amount, err := parseAmount(raw)
+	if err != nil {
+		return Transaction{}, &RowError{Line: line, Reason: "invalid TRNAMT: " + raw}
+	}
+
+	date, err := parseOFXDate(f["DTPOSTED"])
+	if err != nil {
+		return Transaction{}, &RowError{Line: line, Reason: "invalid DTPOSTED: " + f["DTPOSTED"]}
+	}
+
+	value, ok := expense(amount)
+	if !ok {
+		return Transaction{}, &RowError{Line: line, Reason: "not an expense: amount " + raw}
+	}
+
+	return Transaction{
+		Amount:      value,
+		Category:    o.opts.DefaultCategory,
+		Description: joinDescription(f["NAME"], f["MEMO"]),
+		Date:        date,
+		ExternalID:  f["FITID"],
+		Line:        line,
+	}, nil
+}
+
+// nextTag skips to the next '<' and returns the upper-cased tag name,
+// e.g. "STMTTRN" or "/STMTTRN". Attributes and processing instructions
+// are dropped.
+func (o *ofxReader) nextTag() (string, error) {
+	if !o.pending {
+		if err := o.skipTo('<'); err != nil {
+			return "", err
+		}
+	}
+	o.pending = false
+
+	var b strings.Builder
+	for {
+		c, err := o.readByte()
+		if err != nil {
+			return "", err
+		}
+		if c == '>' {
+			break
+		}
+		b.WriteByte(c)
+	}
+
+	name := strings.TrimSpace(b.String())
+	if i := strings.IndexAny(name, " \t\r\n"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSuffix(name, "/")
+
+	return strings.ToUpper(name), nil
+}
+
+// text reads an element value up to the next '<'.
+func (o *ofxReader) text() (string, error) {
+	var b strings.Builder
+	for {
+		c, err := o.readByte()
+		if err != nil {
+			return strings.TrimSpace(html.UnescapeString(b.String())), err
+		}
+		if c == '<' {
+			o.pending = true
+			break
+		}
+		b.WriteByte(c)
+	}
+	return strings.TrimSpace(html.UnescapeString(b.String())), nil
+}
+
+func (o *ofxReader) skipTo(delim byte) error {
+	for {
+		c, err := o.readByte()
+		if err != nil {
+			return err
+		}
+		if c == delim {
+			return nil
+		}
+	}
+}
+
+func (o *ofxReader) readByte() (byte, error) {
+	c, err := o.r.ReadByte()
+	if err == nil && c == '\n' {
+		o.line++
+	}
+	return c, err
+}
+
+// parseOFXDate reads the date part of YYYYMMDD[HHMMSS[.XXX]][[gmt offset:tz]].
+func parseOFXDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, &time.ParseError{Layout: "20060102", Value: s}
+	}
+	return time.Parse("20060102", s[:8])
+}
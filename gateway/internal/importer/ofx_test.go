@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOFXReader_SGML(t *testing.T) {
+	txs, skipped := readFixture(t, "statement.ofx", FormatOFX, Options{DefaultCategory: "bank"})
+
+	require.Len(t, txs, 2)
+
+	require.True(t, txs[0].Amount.Equal(decimal.RequireFromString("42.50")))
+	require.Equal(t, "bank", txs[0].Category)
+	require.Equal(t, "GROCERY MART - Card purchase", txs[0].Description)
+	require.Equal(t, time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	require.Equal(t, "2025010501", txs[0].ExternalID)
+
+	require.Equal(t, "Books & Co", txs[1].Description)
+	require.Equal(t, "2025011201", txs[1].ExternalID)
+
+	require.Len(t, skipped, 1)
+	require.Contains(t, skipped[0].Reason, "not an expense")
+}
+
+func TestOFXReader_XML(t *testing.T) {
+	txs, skipped := readFixture(t, "statement.qfx", FormatOFX, Options{})
+
+	require.Len(t, txs, 1)
+	require.True(t, txs[0].Amount.Equal(decimal.RequireFromString("12.30")))
+	require.Equal(t, "QFX-1", txs[0].ExternalID)
+
+	require.Len(t, skipped, 1)
+	require.Contains(t, skipped[0].Reason, "DTPOSTED")
+}
+
+func TestOFXReader_UnclosedSTMTTRN(t *testing.T) {
+	data := "<OFX><BANKTRANLIST>\n" +
+		"<STMTTRN><DTPOSTED>20250101<TRNAMT>-1<FITID>A\n" +
+		"<STMTTRN><DTPOSTED>20250102<TRNAMT>-2<FITID>B\n" +
+		"</BANKTRANLIST></OFX>"
+
+	r, err := NewReader(FormatOFX, strings.NewReader(data), Options{})
+	require.NoError(t, err)
+
+	txs, skipped, err := ReadAll(r)
+	require.NoError(t, err)
+	require.Empty(t, skipped)
+	require.Len(t, txs, 2)
+	require.Equal(t, "A", txs[0].ExternalID)
+	require.Equal(t, "B", txs[1].ExternalID)
+}
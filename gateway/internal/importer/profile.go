@@ -0,0 +1,248 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Sign conventions for the amount column of a CSV profile.
+const (
+	SignPositive = "positive" // expenses are positive, everything else is skipped
+	SignNegative = "negative" // expenses are negative, as banks usually export them
+	SignAbsolute = "absolute" // every non-zero amount is an expense
+)
+
+// CSVProfile describes a bank's own CSV layout. Columns are looked up by
+// header name, ignoring case and surrounding spaces.
+type CSVProfile struct {
+	AmountColumn      string
+	DateColumn        string
+	CategoryColumn    string
+	DescriptionColumn string
+	ExternalIDColumn  string
+
+	Delimiter      rune   // ',' when zero
+	DecimalComma   bool   // "1.234,56" instead of "1,234.56"
+	DateFormat     string // DD.MM.YYYY, YYYY-MM-DD, MM/DD/YY, ...
+	Encoding       string // one of encodings
+	SignConvention string
+}
+
+// encodings maps the encoding names a profile may use to the canonical
+// one, as the ledger does when it saves profiles.
+var encodings = map[string]string{
+	"utf-8":        "utf-8",
+	"utf8":         "utf-8",
+	"windows-1251": "windows-1251",
+	"cp1251":       "windows-1251",
+}
+
+// profileReader reads CSV files laid out as described by a CSVProfile.
+type profileReader struct {
+	r       *csv.Reader
+	p       CSVProfile
+	opts    Options
+	layout  string
+	columns map[string]int
+	err     error
+}
+
+func newProfileReader(r io.Reader, opts Options) (*profileReader, error) {
+	p := *opts.CSV
+
+	switch encodings[strings.ToLower(p.Encoding)] {
+	case "utf-8":
+	case "windows-1251":
+		r = charmap.Windows1251.NewDecoder().Reader(r)
+	default:
+		if p.Encoding != "" {
+			return nil, fmt.Errorf("unsupported encoding: %q", p.Encoding)
+		}
+	}
+
+	if p.DateFormat == "" {
+		p.DateFormat = "YYYY-MM-DD"
+	}
+	if p.SignConvention == "" {
+		p.SignConvention = SignPositive
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if p.Delimiter != 0 {
+		reader.Comma = p.Delimiter
+	}
+
+	return &profileReader{
+		r:      reader,
+		p:      p,
+		opts:   opts,
+		layout: goDateLayout(p.DateFormat),
+	}, nil
+}
+
+func (c *profileReader) Next() (Transaction, error) {
+	if c.columns == nil && c.err == nil {
+		c.err = c.readHeader()
+	}
+	if c.err != nil {
+		return Transaction{}, c.err
+	}
+
+	row, err := c.r.Read()
+	if err != nil {
+		// a malformed record does not stop the reader
+		if pErr, ok := err.(*csv.ParseError); ok {
+			return Transaction{}, &RowError{Line: pErr.StartLine, Reason: pErr.Err.Error()}
+		}
+		return Transaction{}, err
+	}
+	line, _ := c.r.FieldPos(0)
+
+	raw, ok := c.field(row, c.p.AmountColumn)
+	if !ok {
+		return Transaction{}, &RowError{Line: line, Reason: "missing column " + c.p.AmountColumn}
+	}
+	amount, err := c.parseAmount(raw)
+	if err != nil {
+		return Transaction{}, &RowError{Line: line, Reason: "invalid amount: " + raw}
+	}
+
+	value, ok := c.expense(amount)
+	if !ok {
+		return Transaction{}, &RowError{Line: line, Reason: "not an expense: amount " + raw}
+	}
+
+	rawDate, _ := c.field(row, c.p.DateColumn)
+	date, err := time.Parse(c.layout, rawDate)
+	if err != nil {
+		return Transaction{}, &RowError{Line: line, Reason: "invalid date: " + rawDate}
+	}
+
+	category, _ := c.field(row, c.p.CategoryColumn)
+	if category == "" {
+		category = c.opts.DefaultCategory
+	}
+	description, _ := c.field(row, c.p.DescriptionColumn)
+	ref, _ := c.field(row, c.p.ExternalIDColumn)
+
+	return Transaction{
+		Amount:      value,
+		Category:    category,
+		Description: description,
+		Date:        date,
+		ExternalID:  ref,
+		Line:        line,
+	}, nil
+}
+
+func (c *profileReader) readHeader() error {
+	header, err := c.r.Read()
+	if err == io.EOF {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+
+	c.columns = make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, dup := c.columns[key]; !dup {
+			c.columns[key] = i
+		}
+	}
+
+	for _, name := range []string{
+		c.p.AmountColumn,
+		c.p.DateColumn,
+		c.p.CategoryColumn,
+		c.p.DescriptionColumn,
+		c.p.ExternalIDColumn,
+	} {
+		if name == "" {
+			continue
+		}
+		if _, ok := c.columns[strings.ToLower(strings.TrimSpace(name))]; !ok {
+			return fmt.Errorf("column %q not found in header", name)
+		}
+	}
+	return nil
+}
+
+// field returns the trimmed value of a named column. ok is false when the
+// column is not configured or the row is too short to hold it.
+func (c *profileReader) field(row []string, name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	i, ok := c.columns[strings.ToLower(strings.TrimSpace(name))]
+	if !ok || i >= len(row) {
+		return "", false
+	}
+	return strings.TrimSpace(row[i]), true
+}
+
+func (c *profileReader) parseAmount(s string) (decimal.Decimal, error) {
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "'", "").Replace(s)
+	s = strings.TrimPrefix(s, "+")
+
+	point, group := ".", ","
+	if c.p.DecimalComma {
+		point, group = ",", "."
+	}
+
+	// groups after the first have exactly three digits, so "1,5" with a
+	// decimal point is a mistake rather than 15
+	whole, frac, hasFrac := strings.Cut(s, point)
+	groups := strings.Split(whole, group)
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return decimal.Zero, fmt.Errorf("invalid digit grouping in %q", s)
+		}
+	}
+
+	s = strings.Join(groups, "")
+	if hasFrac {
+		s += "." + frac
+	}
+	return decimal.NewFromString(s)
+}
+
+func (c *profileReader) expense(amount decimal.Decimal) (decimal.Decimal, bool) {
+	switch c.p.SignConvention {
+	case SignNegative:
+		return expense(amount)
+	case SignAbsolute:
+		if amount.IsZero() {
+			return decimal.Zero, false
+		}
+		return amount.Abs(), true
+	default:
+		if !amount.IsPositive() {
+			return decimal.Zero, false
+		}
+		return amount, true
+	}
+}
+
+// goDateLayout converts a DD.MM.YYYY style format into a Go time layout.
+func goDateLayout(format string) string {
+	return strings.NewReplacer(
+		"YYYY", "2006",
+		"YY", "06",
+		"MM", "01",
+		"DD", "02",
+	).Replace(format)
+}
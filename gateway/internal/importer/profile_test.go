@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileReader_Windows1251(t *testing.T) {
+	profile := &CSVProfile{
+		AmountColumn:      "сумма",
+		DateColumn:        "Дата операции",
+		CategoryColumn:    "Категория",
+		DescriptionColumn: "Описание",
+		ExternalIDColumn:  "Номер",
+		Delimiter:         ';',
+		DecimalComma:      true,
+		DateFormat:        "DD.MM.YYYY",
+		Encoding:          "CP1251",
+		SignConvention:    SignNegative,
+	}
+
+	txs, skipped := readFixture(t, "profile_cp1251.csv", FormatCSV, Options{
+		DefaultCategory: "прочее",
+		CSV:             profile,
+	})
+
+	require.Len(t, txs, 2)
+
+	require.True(t, txs[0].Amount.Equal(decimal.RequireFromString("1234.50")))
+	require.Equal(t, "Супермаркеты", txs[0].Category)
+	require.Equal(t, "Пятёрочка", txs[0].Description)
+	require.Equal(t, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	require.Equal(t, "A-1", txs[0].ExternalID)
+	require.Equal(t, 2, txs[0].Line)
+
+	require.True(t, txs[1].Amount.Equal(decimal.RequireFromString("99.9")))
+	require.Equal(t, "прочее", txs[1].Category)
+
+	require.Len(t, skipped, 3)
+	require.Equal(t, 3, skipped[0].Line)
+	require.Contains(t, skipped[0].Reason, "not an expense")
+	require.Equal(t, 5, skipped[1].Line)
+	require.Contains(t, skipped[1].Reason, "invalid date")
+	require.Equal(t, 6, skipped[2].Line)
+	require.Contains(t, skipped[2].Reason, "invalid amount")
+}
+
+func TestProfileReader_SignConventions(t *testing.T) {
+	data := "\ufeffDate,Amount\n2025-01-15,\"1,200.00\"\n2025-01-16,-5\n2025-01-17,0\n"
+
+	tests := []struct {
+		sign  string
+		want  []string
+		lines []int
+	}{
+		{SignPositive, []string{"1200"}, []int{3, 4}},
+		{SignNegative, []string{"5"}, []int{2, 4}},
+		{SignAbsolute, []string{"1200", "5"}, []int{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sign, func(t *testing.T) {
+			r, err := NewReader(FormatCSV, strings.NewReader(data), Options{
+				CSV: &CSVProfile{
+					AmountColumn:   "amount",
+					DateColumn:     "date",
+					SignConvention: tt.sign,
+				},
+			})
+			require.NoError(t, err)
+
+			txs, skipped, err := ReadAll(r)
+			require.NoError(t, err)
+
+			require.Len(t, txs, len(tt.want))
+			for i, w := range tt.want {
+				require.True(t, txs[i].Amount.Equal(decimal.RequireFromString(w)), txs[i].Amount.String())
+			}
+
+			lines := make([]int, 0, len(skipped))
+			for _, s := range skipped {
+				lines = append(lines, s.Line)
+			}
+			require.Equal(t, tt.lines, lines)
+		})
+	}
+}
+
+func TestProfileReader_ParseAmount(t *testing.T) {
+	tests := []struct {
+		in           string
+		decimalComma bool
+		want         string
+	}{
+		{"1,234.56", false, "1234.56"},
+		{"1,234,567", false, "1234567"},
+		{"-12.5", false, "-12.5"},
+		{"1.234,56", true, "1234.56"},
+		{"1 234,5", true, "1234.5"},
+		{"1,5", false, ""},
+		{"12,34.00", false, ""},
+		{"1.5,00", true, ""},
+	}
+
+	for _, tt := range tests {
+		c := &profileReader{p: CSVProfile{DecimalComma: tt.decimalComma}}
+		got, err := c.parseAmount(tt.in)
+		if tt.want == "" {
+			require.Error(t, err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		require.True(t, got.Equal(decimal.RequireFromString(tt.want)), "%s: %s", tt.in, got)
+	}
+}
+
+func TestProfileReader_MissingColumn(t *testing.T) {
+	r, err := NewReader(FormatCSV, strings.NewReader("date,sum\n2025-01-15,10\n"), Options{
+		CSV: &CSVProfile{AmountColumn: "amount", DateColumn: "date"},
+	})
+	require.NoError(t, err)
+
+	_, _, err = ReadAll(r)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `"amount"`)
+}
+
+func TestProfileReader_UnknownEncoding(t *testing.T) {
+	_, err := NewReader(FormatCSV, strings.NewReader(""), Options{
+		CSV: &CSVProfile{AmountColumn: "amount", DateColumn: "date", Encoding: "koi8-r"},
+	})
+	require.Error(t, err)
+}
+
+func TestGoDateLayout(t *testing.T) {
+	require.Equal(t, "02.01.2006", goDateLayout("DD.MM.YYYY"))
+	require.Equal(t, "01/02/06", goDateLayout("MM/DD/YY"))
+	require.Equal(t, "2006-01-02", goDateLayout("YYYY-MM-DD"))
+}
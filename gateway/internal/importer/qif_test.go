@@ -0,0 +1,42 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQIFReader(t *testing.T) {
+	txs, skipped := readFixture(t, "statement.qif", FormatQIF, Options{DefaultCategory: "other"})
+
+	require.Len(t, txs, 3)
+
+	require.True(t, txs[0].Amount.Equal(decimal.NewFromInt(45)))
+	require.Equal(t, "food", txs[0].Category)
+	require.Equal(t, "Grocery Store - Weekly shop", txs[0].Description)
+	require.Equal(t, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	require.Empty(t, txs[0].ExternalID)
+
+	require.True(t, txs[1].Amount.Equal(decimal.NewFromInt(1200)))
+	require.Equal(t, "other", txs[1].Category)
+	require.Equal(t, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC), txs[1].Date)
+
+	require.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), txs[2].Date)
+
+	require.Len(t, skipped, 2)
+	require.Equal(t, 12, skipped[0].Line)
+	require.Contains(t, skipped[0].Reason, "not an expense")
+	require.Contains(t, skipped[1].Reason, "transfer")
+}
+
+func TestParseQIFDate(t *testing.T) {
+	want := time.Date(2004, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	for _, in := range []string{"3/7/2004", "03/07/04", "3/7' 4", "3/7'04", "07.03.2004", "2004-03-07"} {
+		got, err := parseQIFDate(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+}
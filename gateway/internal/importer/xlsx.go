@@ -0,0 +1,368 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// xlsxReader reads the first worksheet of an Excel workbook. The first row
+// names the columns: amount and date are required, category, description
+// and external_id are optional. Rows are decoded one at a time.
+type xlsxReader struct {
+	dec     *xml.Decoder
+	sheet   io.Closer
+	strings []string
+	opts    Options
+
+	cols       map[string]int
+	headerRead bool
+	row        int
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+type xlsxRow struct {
+	Num   int        `xml:"r,attr"`
+	Cells []xlsxCell `xml:"c"`
+}
+
+// excelEpoch is day zero of Excel serial dates.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func newXLSXReader(r io.Reader, opts Options) (*xlsxReader, error) {
+	ra, size, err := readerAt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	x := &xlsxReader{opts: opts}
+
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		x.strings, err = readSharedStrings(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx: %w", err)
+		}
+	}
+
+	f, ok := files[firstSheet(files)]
+	if !ok {
+		return nil, fmt.Errorf("invalid xlsx: no worksheet")
+	}
+
+	sheet, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx: %w", err)
+	}
+	x.sheet = sheet
+	x.dec = xml.NewDecoder(sheet)
+
+	return x, nil
+}
+
+// readerAt avoids copying uploads that are already backed by a file.
+func readerAt(r io.Reader) (io.ReaderAt, int64, error) {
+	if f, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, err
+		}
+		return f, size, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// firstSheet resolves the first sheet of the workbook to its part name.
+func firstSheet(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var wb struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(files["xl/workbook.xml"], &wb); err != nil || len(wb.Sheets) == 0 {
+		return fallback
+	}
+
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return fallback
+	}
+
+	for _, rel := range rels.Items {
+		if rel.ID != wb.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+func decodeZipXML(f *zip.File, v any) error {
+	if f == nil {
+		return io.ErrUnexpectedEOF
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var out []string
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "si" {
+			continue
+		}
+
+		var si xlsxText
+		if err := dec.DecodeElement(&si, &start); err != nil {
+			return nil, err
+		}
+		out = append(out, si.String())
+	}
+}
+
+func (x *xlsxReader) Next() (Transaction, error) {
+	for {
+		row, err := x.nextRow()
+		if err != nil {
+			return Transaction{}, err
+		}
+
+		if !x.headerRead {
+			x.headerRead = true
+			if err := x.readHeader(row); err != nil {
+				return Transaction{}, err
+			}
+			continue
+		}
+
+		if isBlank(row) {
+			continue
+		}
+
+		return x.record(row)
+	}
+}
+
+func (x *xlsxReader) readHeader(row []string) error {
+	x.cols = make(map[string]int)
+	for i, name := range row {
+		x.cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, required := range []string{"amount", "date"} {
+		if _, ok := x.cols[required]; !ok {
+			return fmt.Errorf("missing %s column", required)
+		}
+	}
+	return nil
+}
+
+func (x *xlsxReader) record(row []string) (Transaction, error) {
+	get := func(name string) string {
+		i, ok := x.cols[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	rawAmount := get("amount")
+	amount, err := decimal.NewFromString(rawAmount)
+	if err != nil {
+		return Transaction{}, &RowError{Line: x.row, Reason: "invalid amount: " + rawAmount}
+	}
+
+	rawDate := get("date")
+	date, err := parseXLSXDate(rawDate)
+	if err != nil {
+		return Transaction{}, &RowError{Line: x.row, Reason: "invalid date: " + rawDate}
+	}
+
+	category := get("category")
+	if category == "" {
+		category = x.opts.DefaultCategory
+	}
+
+	return Transaction{
+		// numeric cells may carry binary floating point noise
+		Amount:      amount.Round(2),
+		Category:    category,
+		Description: get("description"),
+		Date:        date,
+		ExternalID:  get("external_id"),
+		Line:        x.row,
+	}, nil
+}
+
+// nextRow decodes the next <row> of the sheet into cell values by column.
+func (x *xlsxReader) nextRow() ([]string, error) {
+	for {
+		tok, err := x.dec.Token()
+		if err == io.EOF {
+			_ = x.sheet.Close()
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := x.dec.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("invalid xlsx: %w", err)
+		}
+
+		if row.Num > 0 {
+			x.row = row.Num
+		} else {
+			x.row++
+		}
+
+		var values []string
+		for _, c := range row.Cells {
+			col := columnIndex(c.Ref)
+			if col < 0 {
+				col = len(values)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			values[col] = x.cellValue(c)
+		}
+		return values, nil
+	}
+}
+
+func (x *xlsxReader) cellValue(c xlsxCell) string {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil || i < 0 || i >= len(x.strings) {
+			return ""
+		}
+		return x.strings[i]
+	case "inlineStr":
+		return c.Inline.String()
+	default:
+		return c.Value
+	}
+}
+
+// columnIndex turns the letters of a cell reference like "AB12" into a
+// zero-based column index.
+func columnIndex(ref string) int {
+	n := 0
+	for _, c := range strings.ToUpper(ref) {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		n = n*26 + int(c-'A'+1)
+	}
+	return n - 1
+}
+
+// parseXLSXDate accepts YYYY-MM-DD text and Excel serial dates.
+func parseXLSXDate(s string) (time.Time, error) {
+	if d, err := time.Parse("2006-01-02", s); err == nil {
+		return d, nil
+	}
+
+	serial, err := strconv.ParseFloat(s, 64)
+	if err != nil || serial < 1 {
+		return time.Time{}, &time.ParseError{Layout: "2006-01-02", Value: s}
+	}
+	return excelEpoch.AddDate(0, 0, int(serial)), nil
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
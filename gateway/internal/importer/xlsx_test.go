@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// buildXLSX packs a workbook the way spreadsheet applications save it:
+// text in shared strings and dates as serial numbers.
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestXLSXReader(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Export" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId3" Target="worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>Date</t></si><si><t>Amount</t></si><si><t>Description</t></si>` +
+			`<si><r><t>Coffee </t></r><r><t>shop</t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="s"><v>2</v></c></row>` +
+			`<row r="2"><c r="A2"><v>45672</v></c><c r="B2"><v>4.2000000000000002</v></c><c r="D2" t="s"><v>3</v></c></row>` +
+			`<row r="4"><c r="A4" t="str"><v>2025-01-16</v></c><c r="B4"><v>oops</v></c></row>` +
+			`<row r="5"></row>` +
+			`<row r="6"><c r="A6" t="inlineStr"><is><t>2025-01-17</t></is></c><c r="B6"><v>10</v></c></row>` +
+			`</sheetData></worksheet>`,
+	})
+
+	require.Equal(t, FormatXLSX, Detect("upload", data[:4]))
+
+	r, err := NewReader(FormatXLSX, bytes.NewReader(data), Options{DefaultCategory: "misc"})
+	require.NoError(t, err)
+
+	txs, skipped, err := ReadAll(r)
+	require.NoError(t, err)
+
+	require.Len(t, txs, 2)
+	require.Equal(t, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	require.True(t, txs[0].Amount.Equal(decimal.RequireFromString("4.2")))
+	require.Equal(t, "Coffee shop", txs[0].Description)
+	require.Equal(t, "misc", txs[0].Category)
+	require.Equal(t, 2, txs[0].Line)
+	require.Equal(t, 6, txs[1].Line)
+
+	require.Len(t, skipped, 1)
+	require.Equal(t, 4, skipped[0].Line)
+}
+
+func TestXLSXReader_MissingColumn(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row><c t="inlineStr"><is><t>amount</t></is></c><c t="inlineStr"><is><t>when</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+	})
+
+	r, err := NewReader(FormatXLSX, bytes.NewReader(data), Options{})
+	require.NoError(t, err)
+
+	_, _, err = ReadAll(r)
+	require.EqualError(t, err, "missing date column")
+}
+
+func TestXLSXReader_NotZip(t *testing.T) {
+	_, err := NewReader(FormatXLSX, bytes.NewReader([]byte("amount,date\n")), Options{})
+	require.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	ClientIPKey = contextKey("client_ip")
+
+	// ClientIPMetadata carries the client address to auth, which
+	// throttles failed sign-ins by it.
+	ClientIPMetadata = "x-client-ip"
+)
+
+// ClientIP puts the address of the client in the request context. With
+// trustProxy it is the last X-Forwarded-For entry, the one added by the
+// proxy in front of the gateway; earlier entries come from the client
+// and can be forged.
+func ClientIP(trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := clientIP(r, trustProxy); ip != "" {
+				r = r.WithContext(context.WithValue(r.Context(), ClientIPKey, ip))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := net.ParseIP(strings.TrimSpace(entries[len(entries)-1])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+func GetClientIP(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(ClientIPKey).(string)
+	return ip, ok && ip != ""
+}
+
+// PropagateClientIP is a gRPC client interceptor that forwards the
+// client address as metadata.
+func PropagateClientIP(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if ip, ok := GetClientIP(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, ClientIPMetadata, ip)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestClientIP(t *testing.T) {
+	var got string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GetClientIP(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	req.RemoteAddr = "10.0.0.2:4242"
+	req.Header.Add("X-Forwarded-For", "1.2.3.4, 198.51.100.1")
+	req.Header.Add("X-Forwarded-For", "203.0.113.7")
+
+	ClientIP(false)(next).ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, "10.0.0.2", got)
+
+	// only the entry of the proxy is trusted
+	ClientIP(true)(next).ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, "203.0.113.7", got)
+
+	req.Header.Set("X-Forwarded-For", "not an ip")
+	ClientIP(true)(next).ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, "10.0.0.2", got)
+}
+
+func TestPropagateClientIP(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ClientIPKey, "203.0.113.7")
+
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, []string{"203.0.113.7"}, md.Get(ClientIPMetadata))
+		return nil
+	}
+
+	err := PropagateClientIP(ctx, "/auth.v1.AuthService/Login", nil, nil, nil, invoker)
+	require.NoError(t, err)
+}
@@ -0,0 +1,261 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	IdempotencyKey       = contextKey("idempotency_key")
+
+	// IdempotencyMetadata carries the key to the backend services.
+	IdempotencyMetadata = "idempotency-key"
+
+	idempotencyTTL    = 24 * time.Hour
+	maxIdempotencyKey = 255
+
+	// maxIdempotencyDrain is how much of the body a handler left unread
+	// is read to hash it. A request with more left is not recorded.
+	maxIdempotencyDrain = 1 << 20
+)
+
+// idempotencyLockTTL is how long a request holds its key when the gateway
+// dies before it finishes. The lock is refreshed while the request runs,
+// however long that is.
+var idempotencyLockTTL = time.Minute
+
+type idempotencyRecord struct {
+	Done        bool   `json:"done"`
+	RequestHash string `json:"request_hash"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency replays the stored response when a mutating request is retried
+// with the same Idempotency-Key. Keys are scoped per user, so it must run
+// after NewJWT. Without Redis requests pass through unchanged.
+func Idempotency(client *redis.Client) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !mutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), IdempotencyKey, key)
+			r = r.WithContext(ctx)
+
+			userID, ok := GetUserID(ctx)
+			if client == nil || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			redisKey := "idempotency:" + userID + ":" + key
+
+			lock, _ := json.Marshal(idempotencyRecord{})
+			acquired, err := client.SetNX(ctx, redisKey, lock, idempotencyLockTTL).Result()
+			if err != nil {
+				log.Printf("idempotency disabled for request: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !acquired {
+				replay(ctx, w, r, client, redisKey)
+				return
+			}
+
+			// the body is hashed as the handler reads it, so uploads are
+			// not held in memory
+			h := newRequestHash(r)
+			body := io.TeeReader(r.Body, h)
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{body, r.Body}
+
+			stop := keepLock(ctx, client, redisKey)
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			stop()
+
+			// failures caused by the server may be retried with the same
+			// key, and so may requests whose body could not be hashed
+			if rec.status >= http.StatusInternalServerError {
+				_ = client.Del(context.WithoutCancel(ctx), redisKey).Err()
+				return
+			}
+			n, err := io.Copy(io.Discard, io.LimitReader(body, maxIdempotencyDrain+1))
+			if err != nil || n > maxIdempotencyDrain {
+				_ = client.Del(context.WithoutCancel(ctx), redisKey).Err()
+				return
+			}
+
+			data, _ := json.Marshal(idempotencyRecord{
+				Done:        true,
+				RequestHash: hex.EncodeToString(h.Sum(nil)),
+				Status:      rec.status,
+				ContentType: rec.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			})
+			if err := client.Set(context.WithoutCancel(ctx), redisKey, data, idempotencyTTL).Err(); err != nil {
+				log.Printf("idempotency store failed: %v", err)
+			}
+		})
+	}
+}
+
+// keepLock extends the lock on redisKey until the returned func is called.
+func keepLock(ctx context.Context, client *redis.Client, redisKey string) func() {
+	ctx = context.WithoutCancel(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := client.PExpire(ctx, redisKey, idempotencyLockTTL).Err(); err != nil {
+					log.Printf("idempotency lock refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// replay answers a retry. While the first request runs its body is not
+// read; once it is done the body is hashed to check it is the same one.
+func replay(ctx context.Context, w http.ResponseWriter, r *http.Request, client *redis.Client, redisKey string) {
+	data, err := client.Get(ctx, redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// the first request has just failed and released the key
+		http.Error(w, "request with this Idempotency-Key failed, retry", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "internal_error", http.StatusInternalServerError)
+		return
+	}
+
+	var stored idempotencyRecord
+	if err := json.Unmarshal(data, &stored); err != nil {
+		http.Error(w, "internal_error", http.StatusInternalServerError)
+		return
+	}
+
+	if !stored.Done {
+		http.Error(w, "request with this Idempotency-Key is in progress", http.StatusConflict)
+		return
+	}
+
+	h := newRequestHash(r)
+	if _, err := io.Copy(h, r.Body); err != nil {
+		http.Error(w, "cannot read body", http.StatusBadRequest)
+		return
+	}
+	if stored.RequestHash != hex.EncodeToString(h.Sum(nil)) {
+		http.Error(w, "Idempotency-Key was used with a different request", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	_, _ = w.Write(stored.Body)
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// newRequestHash starts the hash of a request, to be followed by its body.
+// It covers the ledger too, so a key reused for another ledger is a
+// different request.
+func newRequestHash(r *http.Request) hash.Hash {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	if id := r.Header.Get(LedgerIDHeader); id != "" {
+		h.Write([]byte(LedgerIDHeader + ": " + id + "\n"))
+	}
+	return h
+}
+
+// recordingWriter passes the response through and keeps a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func GetIdempotencyKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(IdempotencyKey).(string)
+	return key, ok && key != ""
+}
+
+// PropagateIdempotencyKey is a gRPC client interceptor that forwards the
+// request's Idempotency-Key as metadata.
+func PropagateIdempotencyKey(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if key, ok := GetIdempotencyKey(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, IdempotencyMetadata, key)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func newRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func idempotentRequest(user, key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	ctx := context.WithValue(req.Context(), UserIDKey, user)
+	return req.WithContext(ctx)
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	var calls int32
+	handler := Idempotency(newRedis(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		body, _ := io.ReadAll(r.Body)
+		require.Equal(t, `{"amount":10}`, string(body))
+
+		key, ok := GetIdempotencyKey(r.Context())
+		require.True(t, ok)
+		require.Equal(t, "k-1", key)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("u1", "k-1", `{"amount":10}`))
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, idempotentRequest("u1", "k-1", `{"amount":10}`))
+
+	require.Equal(t, http.StatusCreated, second.Code)
+	require.Equal(t, `{"success":true}`, second.Body.String())
+	require.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	require.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// keys are per user
+	other := httptest.NewRecorder()
+	handler.ServeHTTP(other, idempotentRequest("u2", "k-1", `{"amount":10}`))
+	require.Empty(t, other.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_DifferentBody(t *testing.T) {
+	handler := Idempotency(newRedis(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", `{"amount":10}`))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest("u1", "k-1", `{"amount":20}`))
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func TestIdempotency_DifferentLedger(t *testing.T) {
+	handler := Idempotency(newRedis(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", `{"amount":10}`))
+
+	req := idempotentRequest("u1", "k-1", `{"amount":10}`)
+	req.Header.Set(LedgerIDHeader, "ledger-2")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func TestIdempotency_InFlight(t *testing.T) {
+	client := newRedis(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := Idempotency(client)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", `{}`))
+	}()
+
+	<-started
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest("u1", "k-1", `{}`))
+	require.Equal(t, http.StatusConflict, rr.Code)
+
+	close(release)
+	<-done
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	var calls int32
+	handler := Idempotency(newRedis(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", `{}`))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest("u1", "k-1", `{}`))
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_LargeBody(t *testing.T) {
+	var calls int32
+	handler := Idempotency(newRedis(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		n, _ := io.Copy(io.Discard, r.Body)
+		require.EqualValues(t, 3<<20, n)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	upload := strings.Repeat("a", 3<<20)
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", upload))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest("u1", "k-1", upload))
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest("u1", "k-1", strings.Repeat("b", 3<<20)))
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_UnreadBodyReleasesKey(t *testing.T) {
+	var calls int32
+	handler := Idempotency(newRedis(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+	}))
+
+	upload := strings.Repeat("a", 3<<20)
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", upload))
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", upload))
+
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestKeepLock(t *testing.T) {
+	ttl := idempotencyLockTTL
+	idempotencyLockTTL = 30 * time.Millisecond
+	t.Cleanup(func() { idempotencyLockTTL = ttl })
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	require.NoError(t, client.Set(context.Background(), "lock", "{}", time.Hour).Err())
+
+	stop := keepLock(context.Background(), client, "lock")
+	require.Eventually(t, func() bool {
+		return mr.TTL("lock") == idempotencyLockTTL
+	}, time.Second, 5*time.Millisecond)
+	stop()
+}
+
+func TestIdempotency_WithoutRedis(t *testing.T) {
+	var calls int32
+	handler := Idempotency(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", `{}`))
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("u1", "k-1", `{}`))
+
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestPropagateIdempotencyKey(t *testing.T) {
+	ctx := metadata.NewOutgoingContext(
+		context.WithValue(context.Background(), IdempotencyKey, "k-1"),
+		metadata.New(map[string]string{"user_id": "u1"}),
+	)
+
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, []string{"u1"}, md.Get("user_id"))
+		require.Equal(t, []string{"k-1"}, md.Get(IdempotencyMetadata))
+		return nil
+	}
+
+	err := PropagateIdempotencyKey(ctx, "/ledger.v1.LedgerService/AddTransaction", nil, nil, nil, invoker)
+	require.NoError(t, err)
+}
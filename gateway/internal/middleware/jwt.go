@@ -12,7 +12,47 @@ type contextKey string
 
 const UserIDKey contextKey = "user_id"
 
+// NewJWT checks every token with auth's Validate, and personal tokens
+// with ValidatePersonalToken.
 func NewJWT(client authv1.AuthServiceClient) func(next http.Handler) http.Handler {
+	return jwtAuth(func(ctx context.Context, token string) (string, []string, error) {
+		if strings.HasPrefix(token, PersonalTokenPrefix) {
+			return validatePersonalToken(ctx, client, token)
+		}
+
+		resp, err := client.Validate(
+			ctx,
+			&authv1.ValidateRequest{
+				Token: token,
+			},
+		)
+		if err != nil || !resp.Valid {
+			return "", nil, errInvalidToken
+		}
+		return resp.UserId, sessionScopes(resp.Role), nil
+	})
+}
+
+// NewLocalJWT checks tokens with v, which calls auth only when it has to.
+// Personal tokens can be revoked at any time, so they are always checked
+// with auth.
+func NewLocalJWT(v *Verifier) func(next http.Handler) http.Handler {
+	return jwtAuth(func(ctx context.Context, token string) (string, []string, error) {
+		if strings.HasPrefix(token, PersonalTokenPrefix) {
+			return validatePersonalToken(ctx, v.client, token)
+		}
+
+		userID, role, err := v.Validate(ctx, token)
+		if err != nil {
+			return "", nil, err
+		}
+		return userID, sessionScopes(role), nil
+	})
+}
+
+// jwtAuth lets through requests with a valid token that has the scope
+// the route needs. validate returns the user and the token's scopes.
+func jwtAuth(validate func(ctx context.Context, token string) (string, []string, error)) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -28,21 +68,17 @@ func NewJWT(client authv1.AuthServiceClient) func(next http.Handler) http.Handle
 				return
 			}
 
-			tokenStr := parts[1]
-
-			resp, err := client.Validate(
-				r.Context(),
-				&authv1.ValidateRequest{
-					Token: tokenStr,
-				},
-			)
-
-			if err != nil || !resp.Valid {
+			userID, scopes, err := validate(r.Context(), parts[1])
+			if err != nil {
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
+			if reason := authorize(r, scopes); reason != "" {
+				http.Error(w, reason, http.StatusForbidden)
+				return
+			}
 
-			ctx := context.WithValue(r.Context(), UserIDKey, resp.UserId)
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
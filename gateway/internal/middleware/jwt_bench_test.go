@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	authv1 "gateway/auth/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// authServer serves the fake auth over gRPC so both modes pay for the
+// round trips they make.
+type authServer struct {
+	authv1.UnimplementedAuthServiceServer
+	client *mockAuthClient
+}
+
+func (s *authServer) Validate(ctx context.Context, in *authv1.ValidateRequest) (*authv1.ValidateResponse, error) {
+	return s.client.Validate(ctx, in)
+}
+
+func (s *authServer) GetJWKS(ctx context.Context, in *authv1.JWKSRequest) (*authv1.JWKS, error) {
+	return s.client.GetJWKS(ctx, in)
+}
+
+func (s *authServer) ListRevocations(ctx context.Context, in *authv1.RevocationsRequest) (*authv1.Revocations, error) {
+	return s.client.ListRevocations(ctx, in)
+}
+
+func dialAuth(b *testing.B, auth *fakeAuth) authv1.AuthServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	authv1.RegisterAuthServiceServer(srv, &authServer{client: auth.client()})
+	go func() { _ = srv.Serve(lis) }()
+	b.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(b, err)
+	b.Cleanup(func() { _ = conn.Close() })
+
+	return authv1.NewAuthServiceClient(conn)
+}
+
+func benchmarkJWT(b *testing.B, mw func(next http.Handler) http.Handler, token string) {
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				b.Fatalf("status %d", rr.Code)
+			}
+		}
+	})
+}
+
+func BenchmarkJWT_Remote(b *testing.B) {
+	key := newSigningKey(b, "k1")
+	client := dialAuth(b, &fakeAuth{keys: []signingKey{key}})
+
+	benchmarkJWT(b, NewJWT(client), key.sign(b, "user-1", "s1", time.Hour))
+}
+
+func BenchmarkJWT_Local(b *testing.B) {
+	key := newSigningKey(b, "k1")
+	v := NewVerifier(dialAuth(b, &fakeAuth{keys: []signingKey{key}}))
+	require.NoError(b, v.RefreshKeys(context.Background()))
+	require.NoError(b, v.RefreshRevocations(context.Background()))
+
+	token := key.sign(b, "user-1", "s1", time.Hour)
+	_, _, err := v.Validate(context.Background(), token)
+	require.NoError(b, err)
+
+	benchmarkJWT(b, NewLocalJWT(v), token)
+}
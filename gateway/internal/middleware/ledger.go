@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// LedgerIDHeader names the shared ledger a request is about. Requests
+	// without it use the caller's personal ledger.
+	LedgerIDHeader = "X-Ledger-ID"
+	LedgerIDKey    = contextKey("ledger_id")
+
+	// LedgerIDMetadata carries the ledger to the ledger service, which
+	// checks that the user is a member of it.
+	LedgerIDMetadata = "ledger_id"
+)
+
+// LedgerID puts the X-Ledger-ID header in the request context.
+func LedgerID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get(LedgerIDHeader); id != "" {
+			r = r.WithContext(WithLedgerID(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithLedgerID makes calls to the ledger service made with ctx about the
+// ledger id, e.g. for routes that name the ledger in the path.
+func WithLedgerID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, LedgerIDKey, id)
+}
+
+func GetLedgerID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(LedgerIDKey).(string)
+	return id, ok && id != ""
+}
+
+// PropagateLedgerID is a gRPC client interceptor that forwards the ledger
+// of the request as metadata.
+func PropagateLedgerID(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if id, ok := GetLedgerID(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, LedgerIDMetadata, id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// PropagateLedgerIDStream does what PropagateLedgerID does for streaming
+// calls such as imports and backups.
+func PropagateLedgerIDStream(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	if id, ok := GetLedgerID(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, LedgerIDMetadata, id)
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLedgerID(t *testing.T) {
+	var got string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GetLedgerID(r.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/budgets", nil)
+	LedgerID(next).ServeHTTP(httptest.NewRecorder(), req)
+	require.Empty(t, got)
+
+	req.Header.Set(LedgerIDHeader, "ledger-1")
+	LedgerID(next).ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, "ledger-1", got)
+}
+
+func TestPropagateLedgerID(t *testing.T) {
+	ctx := metadata.NewOutgoingContext(
+		WithLedgerID(context.Background(), "ledger-1"),
+		metadata.Pairs("user_id", "user-1"),
+	)
+
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, []string{"user-1"}, md.Get("user_id"))
+		require.Equal(t, []string{"ledger-1"}, md.Get(LedgerIDMetadata))
+		return nil
+	}
+
+	err := PropagateLedgerID(ctx, "/ledger.v1.LedgerService/ListBudgets", nil, nil, nil, invoker)
+	require.NoError(t, err)
+}
+
+func TestPropagateLedgerIDStream(t *testing.T) {
+	ctx := WithLedgerID(context.Background(), "ledger-1")
+
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, []string{"ledger-1"}, md.Get(LedgerIDMetadata))
+		return nil, nil
+	}
+
+	_, err := PropagateLedgerIDStream(ctx, &grpc.StreamDesc{}, nil, "/ledger.v1.LedgerService/ExportBackup", streamer)
+	require.NoError(t, err)
+}
@@ -14,7 +14,10 @@ import (
 )
 
 type mockAuthClient struct {
-	validateFn func(ctx context.Context, in *authv1.ValidateRequest, opts ...grpc.CallOption) (*authv1.ValidateResponse, error)
+	validateFn    func(ctx context.Context, in *authv1.ValidateRequest, opts ...grpc.CallOption) (*authv1.ValidateResponse, error)
+	jwksFn        func(ctx context.Context, in *authv1.JWKSRequest, opts ...grpc.CallOption) (*authv1.JWKS, error)
+	revocationsFn func(ctx context.Context, in *authv1.RevocationsRequest, opts ...grpc.CallOption) (*authv1.Revocations, error)
+	personalFn    func(ctx context.Context, in *authv1.ValidateRequest, opts ...grpc.CallOption) (*authv1.ValidatePersonalTokenResponse, error)
 }
 
 func (m *mockAuthClient) Validate(
@@ -25,12 +28,108 @@ func (m *mockAuthClient) Validate(
 	return m.validateFn(ctx, in, opts...)
 }
 
-func (m *mockAuthClient) Register(context.Context, *authv1.RegisterRequest, ...grpc.CallOption) (*authv1.AuthResponse, error) {
+func (m *mockAuthClient) Register(context.Context, *authv1.RegisterRequest, ...grpc.CallOption) (*authv1.RegisterResponse, error) {
 	panic("not used")
 }
 func (m *mockAuthClient) Login(context.Context, *authv1.LoginRequest, ...grpc.CallOption) (*authv1.AuthResponse, error) {
 	panic("not used")
 }
+func (m *mockAuthClient) Refresh(context.Context, *authv1.RefreshRequest, ...grpc.CallOption) (*authv1.AuthResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) Logout(context.Context, *authv1.LogoutRequest, ...grpc.CallOption) (*authv1.LogoutResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) GetJWKS(
+	ctx context.Context,
+	in *authv1.JWKSRequest,
+	opts ...grpc.CallOption,
+) (*authv1.JWKS, error) {
+	return m.jwksFn(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) ListRevocations(
+	ctx context.Context,
+	in *authv1.RevocationsRequest,
+	opts ...grpc.CallOption,
+) (*authv1.Revocations, error) {
+	return m.revocationsFn(ctx, in, opts...)
+}
+
+func (m *mockAuthClient) SendVerificationEmail(context.Context, *authv1.EmailRequest, ...grpc.CallOption) (*authv1.SendEmailResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) VerifyEmail(context.Context, *authv1.VerifyEmailRequest, ...grpc.CallOption) (*authv1.VerifyEmailResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) RequestPasswordReset(context.Context, *authv1.EmailRequest, ...grpc.CallOption) (*authv1.SendEmailResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) ResetPassword(context.Context, *authv1.ResetPasswordRequest, ...grpc.CallOption) (*authv1.ResetPasswordResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) LoginTwoFactor(context.Context, *authv1.LoginTwoFactorRequest, ...grpc.CallOption) (*authv1.AuthResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) EnrollTOTP(context.Context, *authv1.EnrollTOTPRequest, ...grpc.CallOption) (*authv1.TOTPEnrollment, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) ConfirmTOTP(context.Context, *authv1.TOTPCodeRequest, ...grpc.CallOption) (*authv1.RecoveryCodes, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) DisableTOTP(context.Context, *authv1.TOTPCodeRequest, ...grpc.CallOption) (*authv1.DisableTOTPResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) RegenerateRecoveryCodes(context.Context, *authv1.TOTPCodeRequest, ...grpc.CallOption) (*authv1.RecoveryCodes, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) DeleteAccount(context.Context, *authv1.DeleteAccountRequest, ...grpc.CallOption) (*authv1.AccountDeletion, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) GetAccountDeletion(context.Context, *authv1.AccountDeletionRequest, ...grpc.CallOption) (*authv1.AccountDeletion, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) CreatePersonalToken(context.Context, *authv1.CreatePersonalTokenRequest, ...grpc.CallOption) (*authv1.PersonalToken, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) ListPersonalTokens(context.Context, *authv1.PersonalTokensRequest, ...grpc.CallOption) (*authv1.PersonalTokens, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) RevokePersonalToken(context.Context, *authv1.RevokePersonalTokenRequest, ...grpc.CallOption) (*authv1.RevokePersonalTokenResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) ListUsers(context.Context, *authv1.AdminRequest, ...grpc.CallOption) (*authv1.Users, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) SetUserRole(context.Context, *authv1.SetUserRoleRequest, ...grpc.CallOption) (*authv1.User, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) GetUserStats(context.Context, *authv1.AdminRequest, ...grpc.CallOption) (*authv1.UserStats, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) ListSessions(context.Context, *authv1.SessionsRequest, ...grpc.CallOption) (*authv1.Sessions, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) RevokeSession(context.Context, *authv1.RevokeSessionRequest, ...grpc.CallOption) (*authv1.RevokeSessionResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) RevokeAllOtherSessions(context.Context, *authv1.SessionsRequest, ...grpc.CallOption) (*authv1.RevokeSessionResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) StartOIDCLogin(context.Context, *authv1.StartOIDCLoginRequest, ...grpc.CallOption) (*authv1.StartOIDCLoginResponse, error) {
+	panic("not used")
+}
+func (m *mockAuthClient) FinishOIDCLogin(context.Context, *authv1.FinishOIDCLoginRequest, ...grpc.CallOption) (*authv1.AuthResponse, error) {
+	panic("not used")
+}
+
+func (m *mockAuthClient) ValidatePersonalToken(
+	ctx context.Context,
+	in *authv1.ValidateRequest,
+	opts ...grpc.CallOption,
+) (*authv1.ValidatePersonalTokenResponse, error) {
+	return m.personalFn(ctx, in, opts...)
+}
 
 func TestNewJWT_OK(t *testing.T) {
 	client := &mockAuthClient{
@@ -50,7 +149,7 @@ func TestNewJWT_OK(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
 	req.Header.Set("Authorization", "Bearer valid-token")
 	rr := httptest.NewRecorder()
 
@@ -96,6 +195,121 @@ func TestNewJWT_InvalidToken(t *testing.T) {
 	require.Equal(t, http.StatusUnauthorized, rr.Code)
 }
 
+func TestNewJWT_PersonalTokenScopes(t *testing.T) {
+	client := &mockAuthClient{
+		personalFn: func(ctx context.Context, in *authv1.ValidateRequest, _ ...grpc.CallOption) (*authv1.ValidatePersonalTokenResponse, error) {
+			require.Equal(t, "gft_script", in.Token)
+			return &authv1.ValidatePersonalTokenResponse{
+				UserId: "user-123",
+				Valid:  true,
+				Scopes: []string{ScopeRead, ScopeTransactionsWrite},
+			}, nil
+		},
+	}
+
+	handler := NewJWT(client)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		require.True(t, ok)
+		require.Equal(t, "user-123", userID)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/budgets", http.StatusOK},
+		{http.MethodPost, "/api/transactions", http.StatusOK},
+		{http.MethodDelete, "/api/imports/imp-1", http.StatusOK},
+		{http.MethodPost, "/api/budgets", http.StatusForbidden},
+		{http.MethodPost, "/api/backup/restore", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer gft_script")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, tc.want, rr.Code, "%s %s", tc.method, tc.path)
+	}
+}
+
+func TestNewJWT_RoleScopes(t *testing.T) {
+	client := &mockAuthClient{
+		validateFn: func(ctx context.Context, in *authv1.ValidateRequest, _ ...grpc.CallOption) (*authv1.ValidateResponse, error) {
+			return &authv1.ValidateResponse{UserId: "user-123", Valid: true, Role: in.Token}, nil
+		},
+	}
+
+	handler := NewJWT(client)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		role, method, path string
+		want               int
+	}{
+		{RoleReadOnly, http.MethodGet, "/api/transactions", http.StatusOK},
+		{RoleReadOnly, http.MethodPost, "/api/transactions", http.StatusForbidden},
+		{RoleUser, http.MethodPost, "/api/backup/restore", http.StatusOK},
+		{RoleUser, http.MethodGet, "/api/admin/users", http.StatusForbidden},
+		{RoleAdmin, http.MethodGet, "/api/admin/users", http.StatusOK},
+		{RoleAdmin, http.MethodPut, "/api/admin/users/user-456/role", http.StatusOK},
+		{"", http.MethodPost, "/api/budgets", http.StatusOK},
+		{"unknown", http.MethodGet, "/api/budgets", http.StatusForbidden},
+		{RoleAdmin, http.MethodGet, "/api/unknown", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tc.role)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, tc.want, rr.Code, "%s %s %s", tc.role, tc.method, tc.path)
+	}
+}
+
+func TestNewJWT_ExplainsDenial(t *testing.T) {
+	client := &mockAuthClient{
+		validateFn: func(ctx context.Context, in *authv1.ValidateRequest, _ ...grpc.CallOption) (*authv1.ValidateResponse, error) {
+			return &authv1.ValidateResponse{UserId: "user-123", Valid: true, Role: RoleReadOnly}, nil
+		},
+	}
+
+	handler := NewJWT(client)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/budgets", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	require.Equal(t, "POST /api/budgets needs the budgets:write scope, which this token does not have\n", rr.Body.String())
+}
+
+func TestNewJWT_RevokedPersonalToken(t *testing.T) {
+	client := &mockAuthClient{
+		personalFn: func(ctx context.Context, in *authv1.ValidateRequest, _ ...grpc.CallOption) (*authv1.ValidatePersonalTokenResponse, error) {
+			return &authv1.ValidatePersonalTokenResponse{Valid: false}, nil
+		},
+	}
+
+	handler := NewJWT(client)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+	req.Header.Set("Authorization", "Bearer gft_revoked")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
 func TestGetUserID_OK(t *testing.T) {
 	ctx := context.WithValue(context.Background(), UserIDKey, "user-42")
 
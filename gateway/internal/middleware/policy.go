@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	authv1 "gateway/auth/v1"
+)
+
+// PersonalTokenPrefix starts the personal tokens auth issues for scripts.
+// Everything else in the Authorization header is an access token.
+const PersonalTokenPrefix = "gft_"
+
+// Scopes a request may need. Personal tokens carry the first three;
+// sessions get theirs from the user's role.
+const (
+	ScopeRead              = "read"
+	ScopeTransactionsWrite = "transactions:write"
+	ScopeBudgetsWrite      = "budgets:write"
+	ScopeBackupRestore     = "backup:restore"
+	ScopeAdmin             = "admin"
+)
+
+// Roles of users, as auth puts them in access tokens.
+const (
+	RoleUser     = "user"
+	RoleReadOnly = "read-only"
+	RoleAdmin    = "admin"
+)
+
+// roleScopes is what each role may do. Auth keeps the same table for
+// the personal tokens it issues.
+var roleScopes = map[string][]string{
+	RoleUser:     {ScopeRead, ScopeTransactionsWrite, ScopeBudgetsWrite, ScopeBackupRestore},
+	RoleReadOnly: {ScopeRead},
+	RoleAdmin:    {ScopeRead, ScopeTransactionsWrite, ScopeBudgetsWrite, ScopeBackupRestore, ScopeAdmin},
+}
+
+// policy is the scope a request needs. A path ending in "/" also
+// matches everything below it.
+type policy struct {
+	method string
+	path   string
+	scope  string
+}
+
+// policies lists every protected route. Requests matching none are
+// denied, so a new route needs an entry here.
+var policies = []policy{
+	{http.MethodGet, "/api/transactions", ScopeRead},
+	{http.MethodPost, "/api/transactions", ScopeTransactionsWrite},
+	{http.MethodPost, "/api/transactions/bulk", ScopeTransactionsWrite},
+	{http.MethodPost, "/api/transactions/import", ScopeTransactionsWrite},
+	{http.MethodGet, "/api/transactions/duplicates", ScopeRead},
+	{http.MethodGet, "/api/transactions/export", ScopeRead},
+
+	{http.MethodGet, "/api/import-profiles", ScopeRead},
+	{http.MethodPost, "/api/import-profiles", ScopeTransactionsWrite},
+	{http.MethodDelete, "/api/import-profiles", ScopeTransactionsWrite},
+	{http.MethodPost, "/api/imports", ScopeTransactionsWrite},
+	{http.MethodGet, "/api/imports/", ScopeRead},
+	{http.MethodDelete, "/api/imports/", ScopeTransactionsWrite},
+
+	{http.MethodGet, "/api/budgets", ScopeRead},
+	{http.MethodPost, "/api/budgets", ScopeBudgetsWrite},
+	{http.MethodGet, "/api/reports/summary", ScopeRead},
+
+	{http.MethodGet, "/api/backup", ScopeRead},
+	{http.MethodPost, "/api/backup/restore", ScopeBackupRestore},
+
+	// sharing a ledger is a change to its settings, like its budgets
+	{http.MethodGet, "/api/ledgers", ScopeRead},
+	{http.MethodPost, "/api/ledgers", ScopeBudgetsWrite},
+	{http.MethodGet, "/api/ledgers/", ScopeRead},
+	{http.MethodPost, "/api/ledgers/", ScopeBudgetsWrite},
+	{http.MethodPut, "/api/ledgers/", ScopeBudgetsWrite},
+	{http.MethodDelete, "/api/ledgers/", ScopeBudgetsWrite},
+	{http.MethodPost, "/api/invitations/accept", ScopeBudgetsWrite},
+
+	{http.MethodGet, "/api/admin/users", ScopeAdmin},
+	{http.MethodPut, "/api/admin/users/", ScopeAdmin},
+	{http.MethodGet, "/api/admin/stats", ScopeAdmin},
+}
+
+// requiredScope returns the scope r needs, or false when no policy
+// covers it.
+func requiredScope(r *http.Request) (string, bool) {
+	for _, p := range policies {
+		if p.method != r.Method {
+			continue
+		}
+		if r.URL.Path == p.path || strings.HasSuffix(p.path, "/") && strings.HasPrefix(r.URL.Path, p.path) {
+			return p.scope, true
+		}
+	}
+	return "", false
+}
+
+// authorize returns why a token with scopes may not make r, or "" when
+// it may.
+func authorize(r *http.Request, scopes []string) string {
+	scope, ok := requiredScope(r)
+	if !ok {
+		return fmt.Sprintf("%s %s is not allowed", r.Method, r.URL.Path)
+	}
+	if !slices.Contains(scopes, scope) {
+		return fmt.Sprintf("%s %s needs the %s scope, which this token does not have", r.Method, r.URL.Path, scope)
+	}
+	return ""
+}
+
+// sessionScopes returns the scopes of an access token. Tokens issued
+// before roles existed have none and belong to plain users.
+func sessionScopes(role string) []string {
+	if role == "" {
+		role = RoleUser
+	}
+	return roleScopes[role]
+}
+
+// validatePersonalToken checks a personal token with auth, which returns
+// the scopes the token still has.
+func validatePersonalToken(ctx context.Context, client authv1.AuthServiceClient, token string) (string, []string, error) {
+	resp, err := client.ValidatePersonalToken(ctx, &authv1.ValidateRequest{Token: token})
+	if err != nil || !resp.Valid {
+		return "", nil, errInvalidToken
+	}
+	return resp.UserId, resp.Scopes, nil
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	UserAgentKey = contextKey("user_agent")
+
+	// UserAgentMetadata carries the client's user agent to auth, which
+	// records it with the sessions it starts.
+	UserAgentMetadata = "x-user-agent"
+)
+
+// UserAgent puts the User-Agent header in the request context.
+func UserAgent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ua := r.UserAgent(); ua != "" {
+			r = r.WithContext(context.WithValue(r.Context(), UserAgentKey, ua))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func GetUserAgent(ctx context.Context) (string, bool) {
+	ua, ok := ctx.Value(UserAgentKey).(string)
+	return ua, ok && ua != ""
+}
+
+// PropagateUserAgent is a gRPC client interceptor that forwards the
+// client's user agent as metadata.
+func PropagateUserAgent(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if ua, ok := GetUserAgent(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, UserAgentMetadata, ua)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPropagateUserAgent(t *testing.T) {
+	var ctx context.Context
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	req.Header.Set("User-Agent", "Firefox")
+	UserAgent(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, []string{"Firefox"}, md.Get(UserAgentMetadata))
+		return nil
+	}
+
+	err := PropagateUserAgent(ctx, "/auth.v1.AuthService/Login", nil, nil, nil, invoker)
+	require.NoError(t, err)
+}
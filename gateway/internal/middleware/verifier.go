@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	authv1 "gateway/auth/v1"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// revocationPoll is how often the revocation list is refreshed, and
+	// so how long a logout may take to reach the gateway.
+	revocationPoll = 5 * time.Second
+
+	// revocationMaxAge is how old the list may get while auth cannot be
+	// reached before every token is checked with auth again.
+	revocationMaxAge = time.Minute
+
+	// revocationRetention outlives every access token issued before a
+	// revocation. Auth issues them for 15 minutes.
+	revocationRetention = 30 * time.Minute
+
+	// userTTL is how long a user confirmed by auth is trusted without
+	// asking again. Deleted users are also dropped through the revocation
+	// list.
+	userTTL = 5 * time.Minute
+
+	// keysMinRefetch limits how often a token with an unknown key makes
+	// the verifier fetch the key set.
+	keysMinRefetch = 10 * time.Second
+
+	remoteTimeout = 2 * time.Second
+)
+
+var (
+	errInvalidToken      = errors.New("invalid token")
+	errKeysUnavailable   = errors.New("signing keys unavailable")
+	errUnknownSigningKey = errors.New("unknown signing key")
+)
+
+type claims struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid"`
+	Role      string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Verifier checks access tokens in the gateway. Signatures are verified
+// with the keys auth publishes, and revoked sessions and deleted users
+// come from a list polled from auth. A token is checked with auth's
+// Validate only when the verifier cannot decide on its own: the keys or
+// a fresh revocation list are missing, or its user has not been confirmed
+// within userTTL.
+type Verifier struct {
+	client authv1.AuthServiceClient
+
+	mu            sync.RWMutex
+	keys          map[string]ed25519.PublicKey
+	keysFetchedAt time.Time
+
+	revokedSessions map[string]time.Time
+	deletedUsers    map[string]time.Time
+	revocationsAsOf string
+	refreshedAt     time.Time
+
+	users map[string]time.Time
+}
+
+func NewVerifier(client authv1.AuthServiceClient) *Verifier {
+	return &Verifier{
+		client:          client,
+		keys:            map[string]ed25519.PublicKey{},
+		revokedSessions: map[string]time.Time{},
+		deletedUsers:    map[string]time.Time{},
+		users:           map[string]time.Time{},
+	}
+}
+
+// Run keeps the keys and the revocation list up to date until ctx is
+// done.
+func (v *Verifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(revocationPoll)
+	defer ticker.Stop()
+
+	for {
+		if err := v.RefreshKeys(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("fetch signing keys: %v", err)
+		}
+		if err := v.RefreshRevocations(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("fetch revocations: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Validate returns the user the token belongs to and the user's role.
+// A role change revokes the user's sessions, so the role in the token is
+// current.
+func (v *Verifier) Validate(ctx context.Context, token string) (string, string, error) {
+	c, err := v.verify(ctx, token)
+	if errors.Is(err, errKeysUnavailable) {
+		return v.remote(ctx, token)
+	}
+	if err != nil || c.UserID == "" || c.SessionID == "" {
+		return "", "", errInvalidToken
+	}
+
+	now := time.Now()
+
+	v.mu.RLock()
+	fresh := now.Sub(v.refreshedAt) <= revocationMaxAge
+	_, revoked := v.revokedSessions[c.SessionID]
+	_, deleted := v.deletedUsers[c.UserID]
+	checkedAt, known := v.users[c.UserID]
+	v.mu.RUnlock()
+
+	if !fresh {
+		return v.remote(ctx, token)
+	}
+	if revoked || deleted {
+		return "", "", errInvalidToken
+	}
+	if !known || now.Sub(checkedAt) > userTTL {
+		return v.remote(ctx, token)
+	}
+
+	return c.UserID, c.Role, nil
+}
+
+// RefreshKeys replaces the keys with the set auth publishes.
+func (v *Verifier) RefreshKeys(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+
+	resp, err := v.client.GetJWKS(ctx, &authv1.JWKSRequest{})
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(resp.Keys))
+	for _, k := range resp.Keys {
+		if k.Kty != "OKP" || k.Crv != "Ed25519" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[k.Kid] = ed25519.PublicKey(x)
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.keysFetchedAt = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
+
+// RefreshRevocations adds what was revoked since the last refresh and
+// forgets revocations no token can still be affected by.
+func (v *Verifier) RefreshRevocations(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+
+	v.mu.RLock()
+	since := v.revocationsAsOf
+	v.mu.RUnlock()
+
+	resp, err := v.client.ListRevocations(ctx, &authv1.RevocationsRequest{Since: since})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, id := range resp.SessionIds {
+		v.revokedSessions[id] = now
+	}
+	for _, id := range resp.UserIds {
+		v.deletedUsers[id] = now
+		delete(v.users, id)
+	}
+	for id, at := range v.revokedSessions {
+		if now.Sub(at) > revocationRetention {
+			delete(v.revokedSessions, id)
+		}
+	}
+	for id, at := range v.deletedUsers {
+		if now.Sub(at) > revocationRetention {
+			delete(v.deletedUsers, id)
+		}
+	}
+	for id, at := range v.users {
+		if now.Sub(at) > userTTL {
+			delete(v.users, id)
+		}
+	}
+
+	v.revocationsAsOf = resp.AsOf
+	v.refreshedAt = now
+
+	return nil
+}
+
+// verify checks the signature and expiry of the token.
+func (v *Verifier) verify(ctx context.Context, token string) (*claims, error) {
+	parse := func() (*claims, error) {
+		t, err := jwt.ParseWithClaims(
+			token,
+			&claims{},
+			func(t *jwt.Token) (any, error) {
+				kid, _ := t.Header["kid"].(string)
+				v.mu.RLock()
+				key, ok := v.keys[kid]
+				v.mu.RUnlock()
+				if !ok {
+					return nil, errUnknownSigningKey
+				}
+				return key, nil
+			},
+			jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+			jwt.WithExpirationRequired(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return t.Claims.(*claims), nil
+	}
+
+	v.mu.RLock()
+	noKeys := len(v.keys) == 0
+	v.mu.RUnlock()
+	if noKeys {
+		return nil, errKeysUnavailable
+	}
+
+	c, err := parse()
+	if !errors.Is(err, errUnknownSigningKey) {
+		return c, err
+	}
+
+	// auth may have rotated its key since the last fetch
+	v.mu.RLock()
+	recent := time.Since(v.keysFetchedAt) < keysMinRefetch
+	v.mu.RUnlock()
+	if recent {
+		return nil, err
+	}
+	if err := v.RefreshKeys(ctx); err != nil {
+		return nil, errKeysUnavailable
+	}
+	return parse()
+}
+
+// remote asks auth and remembers the user on success.
+func (v *Verifier) remote(ctx context.Context, token string) (string, string, error) {
+	resp, err := v.client.Validate(ctx, &authv1.ValidateRequest{Token: token})
+	if err != nil || !resp.Valid {
+		return "", "", errInvalidToken
+	}
+
+	v.mu.Lock()
+	v.users[resp.UserId] = time.Now()
+	v.mu.Unlock()
+
+	return resp.UserId, resp.Role, nil
+}
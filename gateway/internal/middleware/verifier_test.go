@@ -0,0 +1,273 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	authv1 "gateway/auth/v1"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type signingKey struct {
+	id  string
+	key ed25519.PrivateKey
+}
+
+func newSigningKey(t testing.TB, id string) signingKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return signingKey{id: id, key: key}
+}
+
+func (k signingKey) jwk() *authv1.JWK {
+	return &authv1.JWK{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		Kid: k.id,
+		X:   base64.RawURLEncoding.EncodeToString(k.key.Public().(ed25519.PublicKey)),
+	}
+}
+
+func (k signingKey) sign(t testing.TB, userID, sessionID string, ttl time.Duration) string {
+	return k.signRole(t, userID, sessionID, RoleUser, ttl)
+}
+
+func (k signingKey) signRole(t testing.TB, userID, sessionID, role string, ttl time.Duration) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	})
+	token.Header["kid"] = k.id
+	s, err := token.SignedString(k.key)
+	require.NoError(t, err)
+	return s
+}
+
+// fakeAuth answers like auth would for the keys it holds and counts the
+// calls to Validate.
+type fakeAuth struct {
+	keys        []signingKey
+	revocations *authv1.Revocations
+	validations atomic.Int64
+}
+
+func (f *fakeAuth) client() *mockAuthClient {
+	return &mockAuthClient{
+		jwksFn: func(context.Context, *authv1.JWKSRequest, ...grpc.CallOption) (*authv1.JWKS, error) {
+			resp := &authv1.JWKS{}
+			for _, k := range f.keys {
+				resp.Keys = append(resp.Keys, k.jwk())
+			}
+			return resp, nil
+		},
+		revocationsFn: func(context.Context, *authv1.RevocationsRequest, ...grpc.CallOption) (*authv1.Revocations, error) {
+			resp := f.revocations
+			if resp == nil {
+				resp = &authv1.Revocations{}
+			}
+			f.revocations = nil
+			return resp, nil
+		},
+		validateFn: func(_ context.Context, in *authv1.ValidateRequest, _ ...grpc.CallOption) (*authv1.ValidateResponse, error) {
+			f.validations.Add(1)
+			c := &claims{}
+			_, err := jwt.ParseWithClaims(in.Token, c, func(t *jwt.Token) (any, error) {
+				for _, k := range f.keys {
+					if k.id == t.Header["kid"] {
+						return k.key.Public(), nil
+					}
+				}
+				return nil, errUnknownSigningKey
+			})
+			if err != nil {
+				return &authv1.ValidateResponse{Valid: false}, nil
+			}
+			return &authv1.ValidateResponse{UserId: c.UserID, Valid: true, Role: c.Role}, nil
+		},
+	}
+}
+
+func newTestVerifier(t *testing.T, auth *fakeAuth) *Verifier {
+	v := NewVerifier(auth.client())
+	require.NoError(t, v.RefreshKeys(context.Background()))
+	require.NoError(t, v.RefreshRevocations(context.Background()))
+	return v
+}
+
+func TestVerifier_ConfirmsUserOnce(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	auth := &fakeAuth{keys: []signingKey{key}}
+	v := newTestVerifier(t, auth)
+
+	token := key.sign(t, "user-1", "s1", time.Minute)
+
+	userID, _, err := v.Validate(context.Background(), token)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", userID)
+	require.EqualValues(t, 1, auth.validations.Load())
+
+	userID, _, err = v.Validate(context.Background(), key.sign(t, "user-1", "s2", time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", userID)
+	require.EqualValues(t, 1, auth.validations.Load())
+}
+
+func TestVerifier_Role(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	auth := &fakeAuth{keys: []signingKey{key}}
+	v := newTestVerifier(t, auth)
+
+	// once by auth, then from the token
+	for range 2 {
+		_, role, err := v.Validate(context.Background(), key.signRole(t, "user-1", "s1", RoleAdmin, time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, RoleAdmin, role)
+	}
+	require.EqualValues(t, 1, auth.validations.Load())
+}
+
+func TestVerifier_RevokedSession(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	auth := &fakeAuth{keys: []signingKey{key}}
+	v := newTestVerifier(t, auth)
+
+	token := key.sign(t, "user-1", "s1", time.Minute)
+	_, _, err := v.Validate(context.Background(), token)
+	require.NoError(t, err)
+
+	auth.revocations = &authv1.Revocations{SessionIds: []string{"s1"}}
+	require.NoError(t, v.RefreshRevocations(context.Background()))
+
+	_, _, err = v.Validate(context.Background(), token)
+	require.Error(t, err)
+
+	_, _, err = v.Validate(context.Background(), key.sign(t, "user-1", "s2", time.Minute))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, auth.validations.Load())
+}
+
+func TestVerifier_DeletedUser(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	auth := &fakeAuth{keys: []signingKey{key}}
+	v := newTestVerifier(t, auth)
+
+	token := key.sign(t, "user-1", "s1", time.Minute)
+	_, _, err := v.Validate(context.Background(), token)
+	require.NoError(t, err)
+
+	auth.revocations = &authv1.Revocations{UserIds: []string{"user-1"}}
+	require.NoError(t, v.RefreshRevocations(context.Background()))
+
+	_, _, err = v.Validate(context.Background(), token)
+	require.Error(t, err)
+	require.EqualValues(t, 1, auth.validations.Load())
+}
+
+func TestVerifier_RejectsWithoutAuth(t *testing.T) {
+	key := newSigningKey(t, "k1")
+	auth := &fakeAuth{keys: []signingKey{key}}
+	v := newTestVerifier(t, auth)
+
+	other := newSigningKey(t, "k1")
+
+	tests := map[string]string{
+		"expired":       key.sign(t, "user-1", "s1", -time.Minute),
+		"bad signature": other.sign(t, "user-1", "s1", time.Minute),
+		"no session":    key.sign(t, "user-1", "", time.Minute),
+		"malformed":     "not-a-token",
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := v.Validate(context.Background(), token)
+			require.Error(t, err)
+		})
+	}
+	require.EqualValues(t, 0, auth.validations.Load())
+}
+
+func TestVerifier_FetchesRotatedKey(t *testing.T) {
+	old := newSigningKey(t, "k1")
+	auth := &fakeAuth{keys: []signingKey{old}}
+	v := newTestVerifier(t, auth)
+
+	_, _, err := v.Validate(context.Background(), old.sign(t, "user-1", "s1", time.Minute))
+	require.NoError(t, err)
+
+	rotated := newSigningKey(t, "k2")
+	auth.keys = append(auth.keys, rotated)
+	token := rotated.sign(t, "user-1", "s2", time.Minute)
+
+	// the keys were just fetched, so the token is turned away
+	_, _, err = v.Validate(context.Background(), token)
+	require.Error(t, err)
+
+	v.keysFetchedAt = time.Now().Add(-keysMinRefetch)
+
+	userID, _, err := v.Validate(context.Background(), token)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", userID)
+	require.EqualValues(t, 1, auth.validations.Load())
+}
+
+func TestVerifier_FallsBackToAuth(t *testing.T) {
+	key := newSigningKey(t, "k1")
+
+	t.Run("no keys", func(t *testing.T) {
+		auth := &fakeAuth{keys: []signingKey{key}}
+		client := auth.client()
+		client.jwksFn = func(context.Context, *authv1.JWKSRequest, ...grpc.CallOption) (*authv1.JWKS, error) {
+			return nil, errors.New("unavailable")
+		}
+		v := NewVerifier(client)
+		require.Error(t, v.RefreshKeys(context.Background()))
+		require.NoError(t, v.RefreshRevocations(context.Background()))
+
+		token := key.sign(t, "user-1", "s1", time.Minute)
+		for range 2 {
+			_, _, err := v.Validate(context.Background(), token)
+			require.NoError(t, err)
+		}
+		require.EqualValues(t, 2, auth.validations.Load())
+	})
+
+	t.Run("stale revocations", func(t *testing.T) {
+		auth := &fakeAuth{keys: []signingKey{key}}
+		v := newTestVerifier(t, auth)
+		v.refreshedAt = time.Now().Add(-revocationMaxAge - time.Second)
+
+		token := key.sign(t, "user-1", "s1", time.Minute)
+		for range 2 {
+			_, _, err := v.Validate(context.Background(), token)
+			require.NoError(t, err)
+		}
+		require.EqualValues(t, 2, auth.validations.Load())
+	})
+}
+
+func TestVerifier_PassesAsOf(t *testing.T) {
+	var got []string
+	v := NewVerifier(&mockAuthClient{
+		revocationsFn: func(_ context.Context, in *authv1.RevocationsRequest, _ ...grpc.CallOption) (*authv1.Revocations, error) {
+			got = append(got, in.Since)
+			return &authv1.Revocations{AsOf: "2026-10-19T10:00:00Z"}, nil
+		},
+	})
+
+	require.NoError(t, v.RefreshRevocations(context.Background()))
+	require.NoError(t, v.RefreshRevocations(context.Background()))
+	require.Equal(t, []string{"", "2026-10-19T10:00:00Z"}, got)
+}
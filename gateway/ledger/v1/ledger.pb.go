@@ -28,6 +28,8 @@ type Transaction struct {
 	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
 	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	Date          string                 `protobuf:"bytes,4,opt,name=date,proto3" json:"date,omitempty"` // YYYY-MM-DD
+	Id            int32                  `protobuf:"varint,5,opt,name=id,proto3" json:"id,omitempty"`
+	ExternalId    string                 `protobuf:"bytes,6,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,6 +92,20 @@ func (x *Transaction) GetDate() string {
 	return ""
 }
 
+func (x *Transaction) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transaction) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
 type Budget struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Category      string                 `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
@@ -155,7 +171,8 @@ type CreateTransactionRequest struct {
 	Amount        float64                `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
 	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
 	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Date          string                 `protobuf:"bytes,4,opt,name=date,proto3" json:"date,omitempty"` // YYYY-MM-DD
+	Date          string                 `protobuf:"bytes,4,opt,name=date,proto3" json:"date,omitempty"`                               // YYYY-MM-DD
+	ExternalId    string                 `protobuf:"bytes,5,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"` // bank transaction id (OFX FITID), optional
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -218,6 +235,13 @@ func (x *CreateTransactionRequest) GetDate() string {
 	return ""
 }
 
+func (x *CreateTransactionRequest) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
 type CreateBudgetRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Category      string                 `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
@@ -322,6 +346,75 @@ func (x *ListTransactionsResponse) GetTransactions() []*Transaction {
 	return nil
 }
 
+// Empty fields match every transaction.
+type ExportTransactionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"` // YYYY-MM-DD, inclusive
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`     // YYYY-MM-DD, inclusive
+	Categories    []string               `protobuf:"bytes,3,rep,name=categories,proto3" json:"categories,omitempty"`
+	Source        string                 `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"` // csv, ofx, ... for imported transactions only
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportTransactionsRequest) Reset() {
+	*x = ExportTransactionsRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportTransactionsRequest) ProtoMessage() {}
+
+func (x *ExportTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ExportTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ExportTransactionsRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ExportTransactionsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ExportTransactionsRequest) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *ExportTransactionsRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
 type ListBudgetsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Budgets       []*Budget              `protobuf:"bytes,1,rep,name=budgets,proto3" json:"budgets,omitempty"`
@@ -331,7 +424,7 @@ type ListBudgetsResponse struct {
 
 func (x *ListBudgetsResponse) Reset() {
 	*x = ListBudgetsResponse{}
-	mi := &file_ledger_v1_ledger_proto_msgTypes[5]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -343,7 +436,7 @@ func (x *ListBudgetsResponse) String() string {
 func (*ListBudgetsResponse) ProtoMessage() {}
 
 func (x *ListBudgetsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ledger_v1_ledger_proto_msgTypes[5]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -356,7 +449,7 @@ func (x *ListBudgetsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListBudgetsResponse.ProtoReflect.Descriptor instead.
 func (*ListBudgetsResponse) Descriptor() ([]byte, []int) {
-	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{5}
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ListBudgetsResponse) GetBudgets() []*Budget {
@@ -376,7 +469,7 @@ type ReportSummaryRequest struct {
 
 func (x *ReportSummaryRequest) Reset() {
 	*x = ReportSummaryRequest{}
-	mi := &file_ledger_v1_ledger_proto_msgTypes[6]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -388,7 +481,7 @@ func (x *ReportSummaryRequest) String() string {
 func (*ReportSummaryRequest) ProtoMessage() {}
 
 func (x *ReportSummaryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ledger_v1_ledger_proto_msgTypes[6]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -401,7 +494,7 @@ func (x *ReportSummaryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReportSummaryRequest.ProtoReflect.Descriptor instead.
 func (*ReportSummaryRequest) Descriptor() ([]byte, []int) {
-	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{6}
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ReportSummaryRequest) GetFrom() string {
@@ -428,7 +521,7 @@ type ReportSummaryResponse struct {
 
 func (x *ReportSummaryResponse) Reset() {
 	*x = ReportSummaryResponse{}
-	mi := &file_ledger_v1_ledger_proto_msgTypes[7]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -440,7 +533,7 @@ func (x *ReportSummaryResponse) String() string {
 func (*ReportSummaryResponse) ProtoMessage() {}
 
 func (x *ReportSummaryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ledger_v1_ledger_proto_msgTypes[7]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -453,7 +546,7 @@ func (x *ReportSummaryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReportSummaryResponse.ProtoReflect.Descriptor instead.
 func (*ReportSummaryResponse) Descriptor() ([]byte, []int) {
-	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{7}
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ReportSummaryResponse) GetTotals() map[string]float64 {
@@ -466,14 +559,16 @@ func (x *ReportSummaryResponse) GetTotals() map[string]float64 {
 type BulkAddTransactionsRequest struct {
 	state         protoimpl.MessageState      `protogen:"open.v1"`
 	Transactions  []*CreateTransactionRequest `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
-	Workers       int32                       `protobuf:"varint,2,opt,name=workers,proto3" json:"workers,omitempty"`
+	Workers       int32                       `protobuf:"varint,2,opt,name=workers,proto3" json:"workers,omitempty"` // categories checked in parallel; 0 means 4, capped at 16
+	Source        string                      `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`    // e.g. "ofx" or "csv"; duplicates are detected per source
+	Atomic        bool                        `protobuf:"varint,4,opt,name=atomic,proto3" json:"atomic,omitempty"`   // store all rows in one database transaction or none
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *BulkAddTransactionsRequest) Reset() {
 	*x = BulkAddTransactionsRequest{}
-	mi := &file_ledger_v1_ledger_proto_msgTypes[8]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -485,7 +580,7 @@ func (x *BulkAddTransactionsRequest) String() string {
 func (*BulkAddTransactionsRequest) ProtoMessage() {}
 
 func (x *BulkAddTransactionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_ledger_v1_ledger_proto_msgTypes[8]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -498,7 +593,7 @@ func (x *BulkAddTransactionsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BulkAddTransactionsRequest.ProtoReflect.Descriptor instead.
 func (*BulkAddTransactionsRequest) Descriptor() ([]byte, []int) {
-	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{8}
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *BulkAddTransactionsRequest) GetTransactions() []*CreateTransactionRequest {
@@ -515,6 +610,20 @@ func (x *BulkAddTransactionsRequest) GetWorkers() int32 {
 	return 0
 }
 
+func (x *BulkAddTransactionsRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *BulkAddTransactionsRequest) GetAtomic() bool {
+	if x != nil {
+		return x.Atomic
+	}
+	return false
+}
+
 type BulkError struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
@@ -525,7 +634,7 @@ type BulkError struct {
 
 func (x *BulkError) Reset() {
 	*x = BulkError{}
-	mi := &file_ledger_v1_ledger_proto_msgTypes[9]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -537,7 +646,7 @@ func (x *BulkError) String() string {
 func (*BulkError) ProtoMessage() {}
 
 func (x *BulkError) ProtoReflect() protoreflect.Message {
-	mi := &file_ledger_v1_ledger_proto_msgTypes[9]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -550,7 +659,7 @@ func (x *BulkError) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BulkError.ProtoReflect.Descriptor instead.
 func (*BulkError) Descriptor() ([]byte, []int) {
-	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{9}
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *BulkError) GetIndex() int32 {
@@ -568,17 +677,19 @@ func (x *BulkError) GetError() string {
 }
 
 type BulkAddTransactionsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Accepted      int64                  `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
-	Rejected      int64                  `protobuf:"varint,2,opt,name=rejected,proto3" json:"rejected,omitempty"`
-	Errors        []*BulkError           `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Accepted         int64                  `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected         int64                  `protobuf:"varint,2,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Errors           []*BulkError           `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
+	Duplicates       int64                  `protobuf:"varint,4,opt,name=duplicates,proto3" json:"duplicates,omitempty"`
+	DuplicateIndexes []int32                `protobuf:"varint,5,rep,packed,name=duplicate_indexes,json=duplicateIndexes,proto3" json:"duplicate_indexes,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *BulkAddTransactionsResponse) Reset() {
 	*x = BulkAddTransactionsResponse{}
-	mi := &file_ledger_v1_ledger_proto_msgTypes[10]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -590,7 +701,7 @@ func (x *BulkAddTransactionsResponse) String() string {
 func (*BulkAddTransactionsResponse) ProtoMessage() {}
 
 func (x *BulkAddTransactionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_ledger_v1_ledger_proto_msgTypes[10]
+	mi := &file_ledger_v1_ledger_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -603,7 +714,7 @@ func (x *BulkAddTransactionsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BulkAddTransactionsResponse.ProtoReflect.Descriptor instead.
 func (*BulkAddTransactionsResponse) Descriptor() ([]byte, []int) {
-	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{10}
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *BulkAddTransactionsResponse) GetAccepted() int64 {
@@ -627,58 +738,1824 @@ func (x *BulkAddTransactionsResponse) GetErrors() []*BulkError {
 	return nil
 }
 
-var File_ledger_v1_ledger_proto protoreflect.FileDescriptor
+func (x *BulkAddTransactionsResponse) GetDuplicates() int64 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
 
-const file_ledger_v1_ledger_proto_rawDesc = "" +
-	"\n" +
-	"\x16ledger/v1/ledger.proto\x12\tledger.v1\x1a\x1bgoogle/protobuf/empty.proto\"w\n" +
-	"\vTransaction\x12\x16\n" +
-	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12\x1a\n" +
-	"\bcategory\x18\x02 \x01(\tR\bcategory\x12 \n" +
-	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x12\n" +
-	"\x04date\x18\x04 \x01(\tR\x04date\"R\n" +
-	"\x06Budget\x12\x1a\n" +
-	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x14\n" +
-	"\x05limit\x18\x02 \x01(\x01R\x05limit\x12\x16\n" +
-	"\x06period\x18\x03 \x01(\tR\x06period\"\x84\x01\n" +
-	"\x18CreateTransactionRequest\x12\x16\n" +
-	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12\x1a\n" +
-	"\bcategory\x18\x02 \x01(\tR\bcategory\x12 \n" +
-	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x12\n" +
-	"\x04date\x18\x04 \x01(\tR\x04date\"_\n" +
-	"\x13CreateBudgetRequest\x12\x1a\n" +
-	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x14\n" +
-	"\x05limit\x18\x02 \x01(\x01R\x05limit\x12\x16\n" +
-	"\x06period\x18\x03 \x01(\tR\x06period\"V\n" +
-	"\x18ListTransactionsResponse\x12:\n" +
-	"\ftransactions\x18\x01 \x03(\v2\x16.ledger.v1.TransactionR\ftransactions\"B\n" +
-	"\x13ListBudgetsResponse\x12+\n" +
-	"\abudgets\x18\x01 \x03(\v2\x11.ledger.v1.BudgetR\abudgets\":\n" +
-	"\x14ReportSummaryRequest\x12\x12\n" +
-	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
-	"\x02to\x18\x02 \x01(\tR\x02to\"\x98\x01\n" +
-	"\x15ReportSummaryResponse\x12D\n" +
-	"\x06totals\x18\x01 \x03(\v2,.ledger.v1.ReportSummaryResponse.TotalsEntryR\x06totals\x1a9\n" +
-	"\vTotalsEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"\x7f\n" +
-	"\x1aBulkAddTransactionsRequest\x12G\n" +
-	"\ftransactions\x18\x01 \x03(\v2#.ledger.v1.CreateTransactionRequestR\ftransactions\x12\x18\n" +
-	"\aworkers\x18\x02 \x01(\x05R\aworkers\"7\n" +
-	"\tBulkError\x12\x14\n" +
-	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error\"\x83\x01\n" +
-	"\x1bBulkAddTransactionsResponse\x12\x1a\n" +
-	"\baccepted\x18\x01 \x01(\x03R\baccepted\x12\x1a\n" +
-	"\brejected\x18\x02 \x01(\x03R\brejected\x12,\n" +
-	"\x06errors\x18\x03 \x03(\v2\x14.ledger.v1.BulkErrorR\x06errors2\xf3\x03\n" +
+func (x *BulkAddTransactionsResponse) GetDuplicateIndexes() []int32 {
+	if x != nil {
+		return x.DuplicateIndexes
+	}
+	return nil
+}
+
+// ImportTransactionsRequest is one chunk of a streamed import. Indexes in
+// the progress messages count rows across all chunks.
+type ImportTransactionsRequest struct {
+	state         protoimpl.MessageState      `protogen:"open.v1"`
+	Source        string                      `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"` // read from the first chunk
+	Transactions  []*CreateTransactionRequest `protobuf:"bytes,2,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportTransactionsRequest) Reset() {
+	*x = ImportTransactionsRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportTransactionsRequest) ProtoMessage() {}
+
+func (x *ImportTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ImportTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ImportTransactionsRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ImportTransactionsRequest) GetTransactions() []*CreateTransactionRequest {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+// ImportProgress is sent after every stored batch. Counters are totals so
+// far; errors and duplicate_indexes cover the latest batch only.
+type ImportProgress struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Received         int64                  `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`
+	Accepted         int64                  `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected         int64                  `protobuf:"varint,3,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Duplicates       int64                  `protobuf:"varint,4,opt,name=duplicates,proto3" json:"duplicates,omitempty"`
+	Errors           []*BulkError           `protobuf:"bytes,5,rep,name=errors,proto3" json:"errors,omitempty"`
+	DuplicateIndexes []int32                `protobuf:"varint,6,rep,packed,name=duplicate_indexes,json=duplicateIndexes,proto3" json:"duplicate_indexes,omitempty"`
+	Done             bool                   `protobuf:"varint,7,opt,name=done,proto3" json:"done,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ImportProgress) Reset() {
+	*x = ImportProgress{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportProgress) ProtoMessage() {}
+
+func (x *ImportProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportProgress.ProtoReflect.Descriptor instead.
+func (*ImportProgress) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ImportProgress) GetReceived() int64 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *ImportProgress) GetAccepted() int64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *ImportProgress) GetRejected() int64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *ImportProgress) GetDuplicates() int64 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+func (x *ImportProgress) GetErrors() []*BulkError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ImportProgress) GetDuplicateIndexes() []int32 {
+	if x != nil {
+		return x.DuplicateIndexes
+	}
+	return nil
+}
+
+func (x *ImportProgress) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+// ImportJobChunk is one piece of an import job upload. The first chunk
+// names the job.
+type ImportJobChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Rows          []*ImportJobRow        `protobuf:"bytes,3,rep,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportJobChunk) Reset() {
+	*x = ImportJobChunk{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportJobChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportJobChunk) ProtoMessage() {}
+
+func (x *ImportJobChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportJobChunk.ProtoReflect.Descriptor instead.
+func (*ImportJobChunk) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ImportJobChunk) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ImportJobChunk) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ImportJobChunk) GetRows() []*ImportJobRow {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+type ImportJobRow struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Line          int32                     `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"` // where the record starts in the file
+	Transaction   *CreateTransactionRequest `protobuf:"bytes,2,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportJobRow) Reset() {
+	*x = ImportJobRow{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportJobRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportJobRow) ProtoMessage() {}
+
+func (x *ImportJobRow) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportJobRow.ProtoReflect.Descriptor instead.
+func (*ImportJobRow) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ImportJobRow) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *ImportJobRow) GetTransaction() *CreateTransactionRequest {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+type ImportJobError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Line          int32                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportJobError) Reset() {
+	*x = ImportJobError{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportJobError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportJobError) ProtoMessage() {}
+
+func (x *ImportJobError) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportJobError.ProtoReflect.Descriptor instead.
+func (*ImportJobError) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ImportJobError) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ImportJobError) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *ImportJobError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ImportJob struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // uploading, queued, running, completed, failed, cancelled
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	FileName      string                 `protobuf:"bytes,4,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Total         int64                  `protobuf:"varint,5,opt,name=total,proto3" json:"total,omitempty"`
+	Processed     int64                  `protobuf:"varint,6,opt,name=processed,proto3" json:"processed,omitempty"`
+	Accepted      int64                  `protobuf:"varint,7,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected      int64                  `protobuf:"varint,8,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Duplicates    int64                  `protobuf:"varint,9,opt,name=duplicates,proto3" json:"duplicates,omitempty"`
+	Error         string                 `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"` // why the job failed
+	Errors        []*ImportJobError      `protobuf:"bytes,11,rep,name=errors,proto3" json:"errors,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // RFC 3339
+	UpdatedAt     string                 `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportJob) Reset() {
+	*x = ImportJob{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportJob) ProtoMessage() {}
+
+func (x *ImportJob) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportJob.ProtoReflect.Descriptor instead.
+func (*ImportJob) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ImportJob) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ImportJob) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ImportJob) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ImportJob) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ImportJob) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ImportJob) GetProcessed() int64 {
+	if x != nil {
+		return x.Processed
+	}
+	return 0
+}
+
+func (x *ImportJob) GetAccepted() int64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *ImportJob) GetRejected() int64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *ImportJob) GetDuplicates() int64 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+func (x *ImportJob) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ImportJob) GetErrors() []*ImportJobError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ImportJob) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *ImportJob) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+type ImportJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportJobRequest) Reset() {
+	*x = ImportJobRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportJobRequest) ProtoMessage() {}
+
+func (x *ImportJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportJobRequest.ProtoReflect.Descriptor instead.
+func (*ImportJobRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ImportJobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Transactions with the same date, amount and description.
+type DuplicateGroup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DuplicateGroup) Reset() {
+	*x = DuplicateGroup{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DuplicateGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DuplicateGroup) ProtoMessage() {}
+
+func (x *DuplicateGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DuplicateGroup.ProtoReflect.Descriptor instead.
+func (*DuplicateGroup) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DuplicateGroup) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+type FindDuplicatesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Groups        []*DuplicateGroup      `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindDuplicatesResponse) Reset() {
+	*x = FindDuplicatesResponse{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindDuplicatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindDuplicatesResponse) ProtoMessage() {}
+
+func (x *FindDuplicatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindDuplicatesResponse.ProtoReflect.Descriptor instead.
+func (*FindDuplicatesResponse) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *FindDuplicatesResponse) GetGroups() []*DuplicateGroup {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+// CSV column mapping saved by a user; columns are matched by header name.
+type ImportProfile struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Name              string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	AmountColumn      string                 `protobuf:"bytes,2,opt,name=amount_column,json=amountColumn,proto3" json:"amount_column,omitempty"`
+	DateColumn        string                 `protobuf:"bytes,3,opt,name=date_column,json=dateColumn,proto3" json:"date_column,omitempty"`
+	CategoryColumn    string                 `protobuf:"bytes,4,opt,name=category_column,json=categoryColumn,proto3" json:"category_column,omitempty"`
+	DescriptionColumn string                 `protobuf:"bytes,5,opt,name=description_column,json=descriptionColumn,proto3" json:"description_column,omitempty"`
+	ExternalIdColumn  string                 `protobuf:"bytes,6,opt,name=external_id_column,json=externalIdColumn,proto3" json:"external_id_column,omitempty"`
+	Delimiter         string                 `protobuf:"bytes,7,opt,name=delimiter,proto3" json:"delimiter,omitempty"`
+	DecimalComma      bool                   `protobuf:"varint,8,opt,name=decimal_comma,json=decimalComma,proto3" json:"decimal_comma,omitempty"`
+	DateFormat        string                 `protobuf:"bytes,9,opt,name=date_format,json=dateFormat,proto3" json:"date_format,omitempty"`              // e.g. DD.MM.YYYY
+	Encoding          string                 `protobuf:"bytes,10,opt,name=encoding,proto3" json:"encoding,omitempty"`                                   // utf-8 | windows-1251
+	SignConvention    string                 `protobuf:"bytes,11,opt,name=sign_convention,json=signConvention,proto3" json:"sign_convention,omitempty"` // positive | negative | absolute
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ImportProfile) Reset() {
+	*x = ImportProfile{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportProfile) ProtoMessage() {}
+
+func (x *ImportProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportProfile.ProtoReflect.Descriptor instead.
+func (*ImportProfile) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ImportProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetAmountColumn() string {
+	if x != nil {
+		return x.AmountColumn
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetDateColumn() string {
+	if x != nil {
+		return x.DateColumn
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetCategoryColumn() string {
+	if x != nil {
+		return x.CategoryColumn
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetDescriptionColumn() string {
+	if x != nil {
+		return x.DescriptionColumn
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetExternalIdColumn() string {
+	if x != nil {
+		return x.ExternalIdColumn
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetDelimiter() string {
+	if x != nil {
+		return x.Delimiter
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetDecimalComma() bool {
+	if x != nil {
+		return x.DecimalComma
+	}
+	return false
+}
+
+func (x *ImportProfile) GetDateFormat() string {
+	if x != nil {
+		return x.DateFormat
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *ImportProfile) GetSignConvention() string {
+	if x != nil {
+		return x.SignConvention
+	}
+	return ""
+}
+
+type ImportProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportProfileRequest) Reset() {
+	*x = ImportProfileRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportProfileRequest) ProtoMessage() {}
+
+func (x *ImportProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportProfileRequest.ProtoReflect.Descriptor instead.
+func (*ImportProfileRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ImportProfileRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListImportProfilesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profiles      []*ImportProfile       `protobuf:"bytes,1,rep,name=profiles,proto3" json:"profiles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListImportProfilesResponse) Reset() {
+	*x = ListImportProfilesResponse{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListImportProfilesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListImportProfilesResponse) ProtoMessage() {}
+
+func (x *ListImportProfilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListImportProfilesResponse.ProtoReflect.Descriptor instead.
+func (*ListImportProfilesResponse) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListImportProfilesResponse) GetProfiles() []*ImportProfile {
+	if x != nil {
+		return x.Profiles
+	}
+	return nil
+}
+
+// BackupChunk is one piece of a backup archive: a zip of JSON files and a
+// manifest with their checksums.
+type BackupChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupChunk) Reset() {
+	*x = BackupChunk{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupChunk) ProtoMessage() {}
+
+func (x *BackupChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupChunk.ProtoReflect.Descriptor instead.
+func (*BackupChunk) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *BackupChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// RestoreBackupRequest streams an archive; the first message carries the
+// mode.
+type RestoreBackupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"` // merge (default) | replace
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreBackupRequest) Reset() {
+	*x = RestoreBackupRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreBackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreBackupRequest) ProtoMessage() {}
+
+func (x *RestoreBackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreBackupRequest.ProtoReflect.Descriptor instead.
+func (*RestoreBackupRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *RestoreBackupRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *RestoreBackupRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type RestoreBackupResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Budgets        int64                  `protobuf:"varint,1,opt,name=budgets,proto3" json:"budgets,omitempty"`
+	Transactions   int64                  `protobuf:"varint,2,opt,name=transactions,proto3" json:"transactions,omitempty"`
+	ImportProfiles int64                  `protobuf:"varint,3,opt,name=import_profiles,json=importProfiles,proto3" json:"import_profiles,omitempty"`
+	Duplicates     int64                  `protobuf:"varint,4,opt,name=duplicates,proto3" json:"duplicates,omitempty"` // transactions already stored and skipped
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RestoreBackupResponse) Reset() {
+	*x = RestoreBackupResponse{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreBackupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreBackupResponse) ProtoMessage() {}
+
+func (x *RestoreBackupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreBackupResponse.ProtoReflect.Descriptor instead.
+func (*RestoreBackupResponse) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *RestoreBackupResponse) GetBudgets() int64 {
+	if x != nil {
+		return x.Budgets
+	}
+	return 0
+}
+
+func (x *RestoreBackupResponse) GetTransactions() int64 {
+	if x != nil {
+		return x.Transactions
+	}
+	return 0
+}
+
+func (x *RestoreBackupResponse) GetImportProfiles() int64 {
+	if x != nil {
+		return x.ImportProfiles
+	}
+	return 0
+}
+
+func (x *RestoreBackupResponse) GetDuplicates() int64 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+// Ledger is a workspace of budgets and transactions shared by its members.
+type Ledger struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	OwnerId       string                 `protobuf:"bytes,3,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`
+	Role          string                 `protobuf:"bytes,4,opt,name=role,proto3" json:"role,omitempty"`                            // of the caller: owner | editor | viewer
+	CreatedAt     string                 `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // RFC 3339
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ledger) Reset() {
+	*x = Ledger{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ledger) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ledger) ProtoMessage() {}
+
+func (x *Ledger) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ledger.ProtoReflect.Descriptor instead.
+func (*Ledger) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *Ledger) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Ledger) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Ledger) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *Ledger) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Ledger) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+type CreateLedgerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateLedgerRequest) Reset() {
+	*x = CreateLedgerRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateLedgerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateLedgerRequest) ProtoMessage() {}
+
+func (x *CreateLedgerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateLedgerRequest.ProtoReflect.Descriptor instead.
+func (*CreateLedgerRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *CreateLedgerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListLedgersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ledgers       []*Ledger              `protobuf:"bytes,1,rep,name=ledgers,proto3" json:"ledgers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLedgersResponse) Reset() {
+	*x = ListLedgersResponse{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLedgersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLedgersResponse) ProtoMessage() {}
+
+func (x *ListLedgersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLedgersResponse.ProtoReflect.Descriptor instead.
+func (*ListLedgersResponse) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListLedgersResponse) GetLedgers() []*Ledger {
+	if x != nil {
+		return x.Ledgers
+	}
+	return nil
+}
+
+type CreateInvitationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"` // editor | viewer
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateInvitationRequest) Reset() {
+	*x = CreateInvitationRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateInvitationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateInvitationRequest) ProtoMessage() {}
+
+func (x *CreateInvitationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateInvitationRequest.ProtoReflect.Descriptor instead.
+func (*CreateInvitationRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *CreateInvitationRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+// Invitation carries the token only when it is created; the token can be
+// used once before it expires.
+type Invitation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	ExpiresAt     string                 `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // RFC 3339
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Invitation) Reset() {
+	*x = Invitation{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invitation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invitation) ProtoMessage() {}
+
+func (x *Invitation) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invitation.ProtoReflect.Descriptor instead.
+func (*Invitation) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *Invitation) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Invitation) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Invitation) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+type AcceptInvitationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcceptInvitationRequest) Reset() {
+	*x = AcceptInvitationRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcceptInvitationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptInvitationRequest) ProtoMessage() {}
+
+func (x *AcceptInvitationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptInvitationRequest.ProtoReflect.Descriptor instead.
+func (*AcceptInvitationRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *AcceptInvitationRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type Member struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	JoinedAt      string                 `protobuf:"bytes,3,opt,name=joined_at,json=joinedAt,proto3" json:"joined_at,omitempty"` // RFC 3339
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Member) Reset() {
+	*x = Member{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Member) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Member) ProtoMessage() {}
+
+func (x *Member) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Member.ProtoReflect.Descriptor instead.
+func (*Member) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *Member) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Member) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Member) GetJoinedAt() string {
+	if x != nil {
+		return x.JoinedAt
+	}
+	return ""
+}
+
+type ListMembersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Members       []*Member              `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMembersResponse) Reset() {
+	*x = ListMembersResponse{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMembersResponse) ProtoMessage() {}
+
+func (x *ListMembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMembersResponse.ProtoReflect.Descriptor instead.
+func (*ListMembersResponse) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListMembersResponse) GetMembers() []*Member {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type SetMemberRoleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"` // editor | viewer
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetMemberRoleRequest) Reset() {
+	*x = SetMemberRoleRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetMemberRoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetMemberRoleRequest) ProtoMessage() {}
+
+func (x *SetMemberRoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetMemberRoleRequest.ProtoReflect.Descriptor instead.
+func (*SetMemberRoleRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *SetMemberRoleRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SetMemberRoleRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+type MemberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MemberRequest) Reset() {
+	*x = MemberRequest{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemberRequest) ProtoMessage() {}
+
+func (x *MemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemberRequest.ProtoReflect.Descriptor instead.
+func (*MemberRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *MemberRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// MemberSpending is what one member recorded in the period.
+type MemberSpending struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Transactions  int64                  `protobuf:"varint,2,opt,name=transactions,proto3" json:"transactions,omitempty"`
+	Total         float64                `protobuf:"fixed64,3,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MemberSpending) Reset() {
+	*x = MemberSpending{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemberSpending) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemberSpending) ProtoMessage() {}
+
+func (x *MemberSpending) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemberSpending.ProtoReflect.Descriptor instead.
+func (*MemberSpending) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *MemberSpending) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *MemberSpending) GetTransactions() int64 {
+	if x != nil {
+		return x.Transactions
+	}
+	return 0
+}
+
+func (x *MemberSpending) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type MemberSpendingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Members       []*MemberSpending      `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MemberSpendingResponse) Reset() {
+	*x = MemberSpendingResponse{}
+	mi := &file_ledger_v1_ledger_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemberSpendingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemberSpendingResponse) ProtoMessage() {}
+
+func (x *MemberSpendingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_v1_ledger_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemberSpendingResponse.ProtoReflect.Descriptor instead.
+func (*MemberSpendingResponse) Descriptor() ([]byte, []int) {
+	return file_ledger_v1_ledger_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *MemberSpendingResponse) GetMembers() []*MemberSpending {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+var File_ledger_v1_ledger_proto protoreflect.FileDescriptor
+
+const file_ledger_v1_ledger_proto_rawDesc = "" +
+	"\n" +
+	"\x16ledger/v1/ledger.proto\x12\tledger.v1\x1a\x1bgoogle/protobuf/empty.proto\"\xa8\x01\n" +
+	"\vTransaction\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04date\x18\x04 \x01(\tR\x04date\x12\x0e\n" +
+	"\x02id\x18\x05 \x01(\x05R\x02id\x12\x1f\n" +
+	"\vexternal_id\x18\x06 \x01(\tR\n" +
+	"externalId\"R\n" +
+	"\x06Budget\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x01R\x05limit\x12\x16\n" +
+	"\x06period\x18\x03 \x01(\tR\x06period\"\xa5\x01\n" +
+	"\x18CreateTransactionRequest\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04date\x18\x04 \x01(\tR\x04date\x12\x1f\n" +
+	"\vexternal_id\x18\x05 \x01(\tR\n" +
+	"externalId\"_\n" +
+	"\x13CreateBudgetRequest\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x01R\x05limit\x12\x16\n" +
+	"\x06period\x18\x03 \x01(\tR\x06period\"V\n" +
+	"\x18ListTransactionsResponse\x12:\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x16.ledger.v1.TransactionR\ftransactions\"w\n" +
+	"\x19ExportTransactionsRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x1e\n" +
+	"\n" +
+	"categories\x18\x03 \x03(\tR\n" +
+	"categories\x12\x16\n" +
+	"\x06source\x18\x04 \x01(\tR\x06source\"B\n" +
+	"\x13ListBudgetsResponse\x12+\n" +
+	"\abudgets\x18\x01 \x03(\v2\x11.ledger.v1.BudgetR\abudgets\":\n" +
+	"\x14ReportSummaryRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\"\x98\x01\n" +
+	"\x15ReportSummaryResponse\x12D\n" +
+	"\x06totals\x18\x01 \x03(\v2,.ledger.v1.ReportSummaryResponse.TotalsEntryR\x06totals\x1a9\n" +
+	"\vTotalsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"\xaf\x01\n" +
+	"\x1aBulkAddTransactionsRequest\x12G\n" +
+	"\ftransactions\x18\x01 \x03(\v2#.ledger.v1.CreateTransactionRequestR\ftransactions\x12\x18\n" +
+	"\aworkers\x18\x02 \x01(\x05R\aworkers\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x16\n" +
+	"\x06atomic\x18\x04 \x01(\bR\x06atomic\"7\n" +
+	"\tBulkError\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xd0\x01\n" +
+	"\x1bBulkAddTransactionsResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x03R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x02 \x01(\x03R\brejected\x12,\n" +
+	"\x06errors\x18\x03 \x03(\v2\x14.ledger.v1.BulkErrorR\x06errors\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x04 \x01(\x03R\n" +
+	"duplicates\x12+\n" +
+	"\x11duplicate_indexes\x18\x05 \x03(\x05R\x10duplicateIndexes\"|\n" +
+	"\x19ImportTransactionsRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12G\n" +
+	"\ftransactions\x18\x02 \x03(\v2#.ledger.v1.CreateTransactionRequestR\ftransactions\"\xf3\x01\n" +
+	"\x0eImportProgress\x12\x1a\n" +
+	"\breceived\x18\x01 \x01(\x03R\breceived\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\x03R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x03 \x01(\x03R\brejected\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x04 \x01(\x03R\n" +
+	"duplicates\x12,\n" +
+	"\x06errors\x18\x05 \x03(\v2\x14.ledger.v1.BulkErrorR\x06errors\x12+\n" +
+	"\x11duplicate_indexes\x18\x06 \x03(\x05R\x10duplicateIndexes\x12\x12\n" +
+	"\x04done\x18\a \x01(\bR\x04done\"r\n" +
+	"\x0eImportJobChunk\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12+\n" +
+	"\x04rows\x18\x03 \x03(\v2\x17.ledger.v1.ImportJobRowR\x04rows\"i\n" +
+	"\fImportJobRow\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x05R\x04line\x12E\n" +
+	"\vtransaction\x18\x02 \x01(\v2#.ledger.v1.CreateTransactionRequestR\vtransaction\"P\n" +
+	"\x0eImportJobError\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\x05R\x04line\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xfb\x02\n" +
+	"\tImportJob\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x1b\n" +
+	"\tfile_name\x18\x04 \x01(\tR\bfileName\x12\x14\n" +
+	"\x05total\x18\x05 \x01(\x03R\x05total\x12\x1c\n" +
+	"\tprocessed\x18\x06 \x01(\x03R\tprocessed\x12\x1a\n" +
+	"\baccepted\x18\a \x01(\x03R\baccepted\x12\x1a\n" +
+	"\brejected\x18\b \x01(\x03R\brejected\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\t \x01(\x03R\n" +
+	"duplicates\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\x121\n" +
+	"\x06errors\x18\v \x03(\v2\x19.ledger.v1.ImportJobErrorR\x06errors\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\f \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\r \x01(\tR\tupdatedAt\"\"\n" +
+	"\x10ImportJobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"L\n" +
+	"\x0eDuplicateGroup\x12:\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x16.ledger.v1.TransactionR\ftransactions\"K\n" +
+	"\x16FindDuplicatesResponse\x121\n" +
+	"\x06groups\x18\x01 \x03(\v2\x19.ledger.v1.DuplicateGroupR\x06groups\"\x98\x03\n" +
+	"\rImportProfile\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\ramount_column\x18\x02 \x01(\tR\famountColumn\x12\x1f\n" +
+	"\vdate_column\x18\x03 \x01(\tR\n" +
+	"dateColumn\x12'\n" +
+	"\x0fcategory_column\x18\x04 \x01(\tR\x0ecategoryColumn\x12-\n" +
+	"\x12description_column\x18\x05 \x01(\tR\x11descriptionColumn\x12,\n" +
+	"\x12external_id_column\x18\x06 \x01(\tR\x10externalIdColumn\x12\x1c\n" +
+	"\tdelimiter\x18\a \x01(\tR\tdelimiter\x12#\n" +
+	"\rdecimal_comma\x18\b \x01(\bR\fdecimalComma\x12\x1f\n" +
+	"\vdate_format\x18\t \x01(\tR\n" +
+	"dateFormat\x12\x1a\n" +
+	"\bencoding\x18\n" +
+	" \x01(\tR\bencoding\x12'\n" +
+	"\x0fsign_convention\x18\v \x01(\tR\x0esignConvention\"*\n" +
+	"\x14ImportProfileRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"R\n" +
+	"\x1aListImportProfilesResponse\x124\n" +
+	"\bprofiles\x18\x01 \x03(\v2\x18.ledger.v1.ImportProfileR\bprofiles\"!\n" +
+	"\vBackupChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\">\n" +
+	"\x14RestoreBackupRequest\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\x9e\x01\n" +
+	"\x15RestoreBackupResponse\x12\x18\n" +
+	"\abudgets\x18\x01 \x01(\x03R\abudgets\x12\"\n" +
+	"\ftransactions\x18\x02 \x01(\x03R\ftransactions\x12'\n" +
+	"\x0fimport_profiles\x18\x03 \x01(\x03R\x0eimportProfiles\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x04 \x01(\x03R\n" +
+	"duplicates\"z\n" +
+	"\x06Ledger\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x19\n" +
+	"\bowner_id\x18\x03 \x01(\tR\aownerId\x12\x12\n" +
+	"\x04role\x18\x04 \x01(\tR\x04role\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\tR\tcreatedAt\")\n" +
+	"\x13CreateLedgerRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"B\n" +
+	"\x13ListLedgersResponse\x12+\n" +
+	"\aledgers\x18\x01 \x03(\v2\x11.ledger.v1.LedgerR\aledgers\"-\n" +
+	"\x17CreateInvitationRequest\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\"U\n" +
+	"\n" +
+	"Invitation\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\tR\texpiresAt\"/\n" +
+	"\x17AcceptInvitationRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"R\n" +
+	"\x06Member\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12\x1b\n" +
+	"\tjoined_at\x18\x03 \x01(\tR\bjoinedAt\"B\n" +
+	"\x13ListMembersResponse\x12+\n" +
+	"\amembers\x18\x01 \x03(\v2\x11.ledger.v1.MemberR\amembers\"C\n" +
+	"\x14SetMemberRoleRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\"(\n" +
+	"\rMemberRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"c\n" +
+	"\x0eMemberSpending\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\"\n" +
+	"\ftransactions\x18\x02 \x01(\x03R\ftransactions\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x01R\x05total\"M\n" +
+	"\x16MemberSpendingResponse\x123\n" +
+	"\amembers\x18\x01 \x03(\v2\x19.ledger.v1.MemberSpendingR\amembers2\xe0\x0f\n" +
 	"\rLedgerService\x12M\n" +
 	"\x0eAddTransaction\x12#.ledger.v1.CreateTransactionRequest\x1a\x16.ledger.v1.Transaction\x12O\n" +
-	"\x10ListTransactions\x12\x16.google.protobuf.Empty\x1a#.ledger.v1.ListTransactionsResponse\x12>\n" +
+	"\x10ListTransactions\x12\x16.google.protobuf.Empty\x1a#.ledger.v1.ListTransactionsResponse\x12T\n" +
+	"\x12ExportTransactions\x12$.ledger.v1.ExportTransactionsRequest\x1a\x16.ledger.v1.Transaction0\x01\x12>\n" +
 	"\tSetBudget\x12\x1e.ledger.v1.CreateBudgetRequest\x1a\x11.ledger.v1.Budget\x12E\n" +
 	"\vListBudgets\x12\x16.google.protobuf.Empty\x1a\x1e.ledger.v1.ListBudgetsResponse\x12U\n" +
 	"\x10GetReportSummary\x12\x1f.ledger.v1.ReportSummaryRequest\x1a .ledger.v1.ReportSummaryResponse\x12d\n" +
-	"\x13BulkAddTransactions\x12%.ledger.v1.BulkAddTransactionsRequest\x1a&.ledger.v1.BulkAddTransactionsResponseB\x1aZ\x18ledger/ledgerpb;ledgerpbb\x06proto3"
+	"\x13BulkAddTransactions\x12%.ledger.v1.BulkAddTransactionsRequest\x1a&.ledger.v1.BulkAddTransactionsResponse\x12G\n" +
+	"\x11SaveImportProfile\x12\x18.ledger.v1.ImportProfile\x1a\x18.ledger.v1.ImportProfile\x12M\n" +
+	"\x10GetImportProfile\x12\x1f.ledger.v1.ImportProfileRequest\x1a\x18.ledger.v1.ImportProfile\x12S\n" +
+	"\x12ListImportProfiles\x12\x16.google.protobuf.Empty\x1a%.ledger.v1.ListImportProfilesResponse\x12N\n" +
+	"\x13DeleteImportProfile\x12\x1f.ledger.v1.ImportProfileRequest\x1a\x16.google.protobuf.Empty\x12K\n" +
+	"\x0eFindDuplicates\x12\x16.google.protobuf.Empty\x1a!.ledger.v1.FindDuplicatesResponse\x12Y\n" +
+	"\x12ImportTransactions\x12$.ledger.v1.ImportTransactionsRequest\x1a\x19.ledger.v1.ImportProgress(\x010\x01\x12D\n" +
+	"\x0fCreateImportJob\x12\x19.ledger.v1.ImportJobChunk\x1a\x14.ledger.v1.ImportJob(\x01\x12A\n" +
+	"\fGetImportJob\x12\x1b.ledger.v1.ImportJobRequest\x1a\x14.ledger.v1.ImportJob\x12D\n" +
+	"\x0fCancelImportJob\x12\x1b.ledger.v1.ImportJobRequest\x1a\x14.ledger.v1.ImportJob\x12@\n" +
+	"\fExportBackup\x12\x16.google.protobuf.Empty\x1a\x16.ledger.v1.BackupChunk0\x01\x12T\n" +
+	"\rRestoreBackup\x12\x1f.ledger.v1.RestoreBackupRequest\x1a .ledger.v1.RestoreBackupResponse(\x01\x12A\n" +
+	"\fCreateLedger\x12\x1e.ledger.v1.CreateLedgerRequest\x1a\x11.ledger.v1.Ledger\x12E\n" +
+	"\vListLedgers\x12\x16.google.protobuf.Empty\x1a\x1e.ledger.v1.ListLedgersResponse\x12M\n" +
+	"\x10CreateInvitation\x12\".ledger.v1.CreateInvitationRequest\x1a\x15.ledger.v1.Invitation\x12I\n" +
+	"\x10AcceptInvitation\x12\".ledger.v1.AcceptInvitationRequest\x1a\x11.ledger.v1.Ledger\x12E\n" +
+	"\vListMembers\x12\x16.google.protobuf.Empty\x1a\x1e.ledger.v1.ListMembersResponse\x12C\n" +
+	"\rSetMemberRole\x12\x1f.ledger.v1.SetMemberRoleRequest\x1a\x11.ledger.v1.Member\x12@\n" +
+	"\fRemoveMember\x12\x18.ledger.v1.MemberRequest\x1a\x16.google.protobuf.Empty\x12W\n" +
+	"\x11GetMemberSpending\x12\x1f.ledger.v1.ReportSummaryRequest\x1a!.ledger.v1.MemberSpendingResponseB\x1aZ\x18ledger/ledgerpb;ledgerpbb\x06proto3"
 
 var (
 	file_ledger_v1_ledger_proto_rawDescOnce sync.Once
@@ -692,45 +2569,124 @@ func file_ledger_v1_ledger_proto_rawDescGZIP() []byte {
 	return file_ledger_v1_ledger_proto_rawDescData
 }
 
-var file_ledger_v1_ledger_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_ledger_v1_ledger_proto_msgTypes = make([]protoimpl.MessageInfo, 40)
 var file_ledger_v1_ledger_proto_goTypes = []any{
 	(*Transaction)(nil),                 // 0: ledger.v1.Transaction
 	(*Budget)(nil),                      // 1: ledger.v1.Budget
 	(*CreateTransactionRequest)(nil),    // 2: ledger.v1.CreateTransactionRequest
 	(*CreateBudgetRequest)(nil),         // 3: ledger.v1.CreateBudgetRequest
 	(*ListTransactionsResponse)(nil),    // 4: ledger.v1.ListTransactionsResponse
-	(*ListBudgetsResponse)(nil),         // 5: ledger.v1.ListBudgetsResponse
-	(*ReportSummaryRequest)(nil),        // 6: ledger.v1.ReportSummaryRequest
-	(*ReportSummaryResponse)(nil),       // 7: ledger.v1.ReportSummaryResponse
-	(*BulkAddTransactionsRequest)(nil),  // 8: ledger.v1.BulkAddTransactionsRequest
-	(*BulkError)(nil),                   // 9: ledger.v1.BulkError
-	(*BulkAddTransactionsResponse)(nil), // 10: ledger.v1.BulkAddTransactionsResponse
-	nil,                                 // 11: ledger.v1.ReportSummaryResponse.TotalsEntry
-	(*emptypb.Empty)(nil),               // 12: google.protobuf.Empty
+	(*ExportTransactionsRequest)(nil),   // 5: ledger.v1.ExportTransactionsRequest
+	(*ListBudgetsResponse)(nil),         // 6: ledger.v1.ListBudgetsResponse
+	(*ReportSummaryRequest)(nil),        // 7: ledger.v1.ReportSummaryRequest
+	(*ReportSummaryResponse)(nil),       // 8: ledger.v1.ReportSummaryResponse
+	(*BulkAddTransactionsRequest)(nil),  // 9: ledger.v1.BulkAddTransactionsRequest
+	(*BulkError)(nil),                   // 10: ledger.v1.BulkError
+	(*BulkAddTransactionsResponse)(nil), // 11: ledger.v1.BulkAddTransactionsResponse
+	(*ImportTransactionsRequest)(nil),   // 12: ledger.v1.ImportTransactionsRequest
+	(*ImportProgress)(nil),              // 13: ledger.v1.ImportProgress
+	(*ImportJobChunk)(nil),              // 14: ledger.v1.ImportJobChunk
+	(*ImportJobRow)(nil),                // 15: ledger.v1.ImportJobRow
+	(*ImportJobError)(nil),              // 16: ledger.v1.ImportJobError
+	(*ImportJob)(nil),                   // 17: ledger.v1.ImportJob
+	(*ImportJobRequest)(nil),            // 18: ledger.v1.ImportJobRequest
+	(*DuplicateGroup)(nil),              // 19: ledger.v1.DuplicateGroup
+	(*FindDuplicatesResponse)(nil),      // 20: ledger.v1.FindDuplicatesResponse
+	(*ImportProfile)(nil),               // 21: ledger.v1.ImportProfile
+	(*ImportProfileRequest)(nil),        // 22: ledger.v1.ImportProfileRequest
+	(*ListImportProfilesResponse)(nil),  // 23: ledger.v1.ListImportProfilesResponse
+	(*BackupChunk)(nil),                 // 24: ledger.v1.BackupChunk
+	(*RestoreBackupRequest)(nil),        // 25: ledger.v1.RestoreBackupRequest
+	(*RestoreBackupResponse)(nil),       // 26: ledger.v1.RestoreBackupResponse
+	(*Ledger)(nil),                      // 27: ledger.v1.Ledger
+	(*CreateLedgerRequest)(nil),         // 28: ledger.v1.CreateLedgerRequest
+	(*ListLedgersResponse)(nil),         // 29: ledger.v1.ListLedgersResponse
+	(*CreateInvitationRequest)(nil),     // 30: ledger.v1.CreateInvitationRequest
+	(*Invitation)(nil),                  // 31: ledger.v1.Invitation
+	(*AcceptInvitationRequest)(nil),     // 32: ledger.v1.AcceptInvitationRequest
+	(*Member)(nil),                      // 33: ledger.v1.Member
+	(*ListMembersResponse)(nil),         // 34: ledger.v1.ListMembersResponse
+	(*SetMemberRoleRequest)(nil),        // 35: ledger.v1.SetMemberRoleRequest
+	(*MemberRequest)(nil),               // 36: ledger.v1.MemberRequest
+	(*MemberSpending)(nil),              // 37: ledger.v1.MemberSpending
+	(*MemberSpendingResponse)(nil),      // 38: ledger.v1.MemberSpendingResponse
+	nil,                                 // 39: ledger.v1.ReportSummaryResponse.TotalsEntry
+	(*emptypb.Empty)(nil),               // 40: google.protobuf.Empty
 }
 var file_ledger_v1_ledger_proto_depIdxs = []int32{
 	0,  // 0: ledger.v1.ListTransactionsResponse.transactions:type_name -> ledger.v1.Transaction
 	1,  // 1: ledger.v1.ListBudgetsResponse.budgets:type_name -> ledger.v1.Budget
-	11, // 2: ledger.v1.ReportSummaryResponse.totals:type_name -> ledger.v1.ReportSummaryResponse.TotalsEntry
+	39, // 2: ledger.v1.ReportSummaryResponse.totals:type_name -> ledger.v1.ReportSummaryResponse.TotalsEntry
 	2,  // 3: ledger.v1.BulkAddTransactionsRequest.transactions:type_name -> ledger.v1.CreateTransactionRequest
-	9,  // 4: ledger.v1.BulkAddTransactionsResponse.errors:type_name -> ledger.v1.BulkError
-	2,  // 5: ledger.v1.LedgerService.AddTransaction:input_type -> ledger.v1.CreateTransactionRequest
-	12, // 6: ledger.v1.LedgerService.ListTransactions:input_type -> google.protobuf.Empty
-	3,  // 7: ledger.v1.LedgerService.SetBudget:input_type -> ledger.v1.CreateBudgetRequest
-	12, // 8: ledger.v1.LedgerService.ListBudgets:input_type -> google.protobuf.Empty
-	6,  // 9: ledger.v1.LedgerService.GetReportSummary:input_type -> ledger.v1.ReportSummaryRequest
-	8,  // 10: ledger.v1.LedgerService.BulkAddTransactions:input_type -> ledger.v1.BulkAddTransactionsRequest
-	0,  // 11: ledger.v1.LedgerService.AddTransaction:output_type -> ledger.v1.Transaction
-	4,  // 12: ledger.v1.LedgerService.ListTransactions:output_type -> ledger.v1.ListTransactionsResponse
-	1,  // 13: ledger.v1.LedgerService.SetBudget:output_type -> ledger.v1.Budget
-	5,  // 14: ledger.v1.LedgerService.ListBudgets:output_type -> ledger.v1.ListBudgetsResponse
-	7,  // 15: ledger.v1.LedgerService.GetReportSummary:output_type -> ledger.v1.ReportSummaryResponse
-	10, // 16: ledger.v1.LedgerService.BulkAddTransactions:output_type -> ledger.v1.BulkAddTransactionsResponse
-	11, // [11:17] is the sub-list for method output_type
-	5,  // [5:11] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	10, // 4: ledger.v1.BulkAddTransactionsResponse.errors:type_name -> ledger.v1.BulkError
+	2,  // 5: ledger.v1.ImportTransactionsRequest.transactions:type_name -> ledger.v1.CreateTransactionRequest
+	10, // 6: ledger.v1.ImportProgress.errors:type_name -> ledger.v1.BulkError
+	15, // 7: ledger.v1.ImportJobChunk.rows:type_name -> ledger.v1.ImportJobRow
+	2,  // 8: ledger.v1.ImportJobRow.transaction:type_name -> ledger.v1.CreateTransactionRequest
+	16, // 9: ledger.v1.ImportJob.errors:type_name -> ledger.v1.ImportJobError
+	0,  // 10: ledger.v1.DuplicateGroup.transactions:type_name -> ledger.v1.Transaction
+	19, // 11: ledger.v1.FindDuplicatesResponse.groups:type_name -> ledger.v1.DuplicateGroup
+	21, // 12: ledger.v1.ListImportProfilesResponse.profiles:type_name -> ledger.v1.ImportProfile
+	27, // 13: ledger.v1.ListLedgersResponse.ledgers:type_name -> ledger.v1.Ledger
+	33, // 14: ledger.v1.ListMembersResponse.members:type_name -> ledger.v1.Member
+	37, // 15: ledger.v1.MemberSpendingResponse.members:type_name -> ledger.v1.MemberSpending
+	2,  // 16: ledger.v1.LedgerService.AddTransaction:input_type -> ledger.v1.CreateTransactionRequest
+	40, // 17: ledger.v1.LedgerService.ListTransactions:input_type -> google.protobuf.Empty
+	5,  // 18: ledger.v1.LedgerService.ExportTransactions:input_type -> ledger.v1.ExportTransactionsRequest
+	3,  // 19: ledger.v1.LedgerService.SetBudget:input_type -> ledger.v1.CreateBudgetRequest
+	40, // 20: ledger.v1.LedgerService.ListBudgets:input_type -> google.protobuf.Empty
+	7,  // 21: ledger.v1.LedgerService.GetReportSummary:input_type -> ledger.v1.ReportSummaryRequest
+	9,  // 22: ledger.v1.LedgerService.BulkAddTransactions:input_type -> ledger.v1.BulkAddTransactionsRequest
+	21, // 23: ledger.v1.LedgerService.SaveImportProfile:input_type -> ledger.v1.ImportProfile
+	22, // 24: ledger.v1.LedgerService.GetImportProfile:input_type -> ledger.v1.ImportProfileRequest
+	40, // 25: ledger.v1.LedgerService.ListImportProfiles:input_type -> google.protobuf.Empty
+	22, // 26: ledger.v1.LedgerService.DeleteImportProfile:input_type -> ledger.v1.ImportProfileRequest
+	40, // 27: ledger.v1.LedgerService.FindDuplicates:input_type -> google.protobuf.Empty
+	12, // 28: ledger.v1.LedgerService.ImportTransactions:input_type -> ledger.v1.ImportTransactionsRequest
+	14, // 29: ledger.v1.LedgerService.CreateImportJob:input_type -> ledger.v1.ImportJobChunk
+	18, // 30: ledger.v1.LedgerService.GetImportJob:input_type -> ledger.v1.ImportJobRequest
+	18, // 31: ledger.v1.LedgerService.CancelImportJob:input_type -> ledger.v1.ImportJobRequest
+	40, // 32: ledger.v1.LedgerService.ExportBackup:input_type -> google.protobuf.Empty
+	25, // 33: ledger.v1.LedgerService.RestoreBackup:input_type -> ledger.v1.RestoreBackupRequest
+	28, // 34: ledger.v1.LedgerService.CreateLedger:input_type -> ledger.v1.CreateLedgerRequest
+	40, // 35: ledger.v1.LedgerService.ListLedgers:input_type -> google.protobuf.Empty
+	30, // 36: ledger.v1.LedgerService.CreateInvitation:input_type -> ledger.v1.CreateInvitationRequest
+	32, // 37: ledger.v1.LedgerService.AcceptInvitation:input_type -> ledger.v1.AcceptInvitationRequest
+	40, // 38: ledger.v1.LedgerService.ListMembers:input_type -> google.protobuf.Empty
+	35, // 39: ledger.v1.LedgerService.SetMemberRole:input_type -> ledger.v1.SetMemberRoleRequest
+	36, // 40: ledger.v1.LedgerService.RemoveMember:input_type -> ledger.v1.MemberRequest
+	7,  // 41: ledger.v1.LedgerService.GetMemberSpending:input_type -> ledger.v1.ReportSummaryRequest
+	0,  // 42: ledger.v1.LedgerService.AddTransaction:output_type -> ledger.v1.Transaction
+	4,  // 43: ledger.v1.LedgerService.ListTransactions:output_type -> ledger.v1.ListTransactionsResponse
+	0,  // 44: ledger.v1.LedgerService.ExportTransactions:output_type -> ledger.v1.Transaction
+	1,  // 45: ledger.v1.LedgerService.SetBudget:output_type -> ledger.v1.Budget
+	6,  // 46: ledger.v1.LedgerService.ListBudgets:output_type -> ledger.v1.ListBudgetsResponse
+	8,  // 47: ledger.v1.LedgerService.GetReportSummary:output_type -> ledger.v1.ReportSummaryResponse
+	11, // 48: ledger.v1.LedgerService.BulkAddTransactions:output_type -> ledger.v1.BulkAddTransactionsResponse
+	21, // 49: ledger.v1.LedgerService.SaveImportProfile:output_type -> ledger.v1.ImportProfile
+	21, // 50: ledger.v1.LedgerService.GetImportProfile:output_type -> ledger.v1.ImportProfile
+	23, // 51: ledger.v1.LedgerService.ListImportProfiles:output_type -> ledger.v1.ListImportProfilesResponse
+	40, // 52: ledger.v1.LedgerService.DeleteImportProfile:output_type -> google.protobuf.Empty
+	20, // 53: ledger.v1.LedgerService.FindDuplicates:output_type -> ledger.v1.FindDuplicatesResponse
+	13, // 54: ledger.v1.LedgerService.ImportTransactions:output_type -> ledger.v1.ImportProgress
+	17, // 55: ledger.v1.LedgerService.CreateImportJob:output_type -> ledger.v1.ImportJob
+	17, // 56: ledger.v1.LedgerService.GetImportJob:output_type -> ledger.v1.ImportJob
+	17, // 57: ledger.v1.LedgerService.CancelImportJob:output_type -> ledger.v1.ImportJob
+	24, // 58: ledger.v1.LedgerService.ExportBackup:output_type -> ledger.v1.BackupChunk
+	26, // 59: ledger.v1.LedgerService.RestoreBackup:output_type -> ledger.v1.RestoreBackupResponse
+	27, // 60: ledger.v1.LedgerService.CreateLedger:output_type -> ledger.v1.Ledger
+	29, // 61: ledger.v1.LedgerService.ListLedgers:output_type -> ledger.v1.ListLedgersResponse
+	31, // 62: ledger.v1.LedgerService.CreateInvitation:output_type -> ledger.v1.Invitation
+	27, // 63: ledger.v1.LedgerService.AcceptInvitation:output_type -> ledger.v1.Ledger
+	34, // 64: ledger.v1.LedgerService.ListMembers:output_type -> ledger.v1.ListMembersResponse
+	33, // 65: ledger.v1.LedgerService.SetMemberRole:output_type -> ledger.v1.Member
+	40, // 66: ledger.v1.LedgerService.RemoveMember:output_type -> google.protobuf.Empty
+	38, // 67: ledger.v1.LedgerService.GetMemberSpending:output_type -> ledger.v1.MemberSpendingResponse
+	42, // [42:68] is the sub-list for method output_type
+	16, // [16:42] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_ledger_v1_ledger_proto_init() }
@@ -744,7 +2700,7 @@ func file_ledger_v1_ledger_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ledger_v1_ledger_proto_rawDesc), len(file_ledger_v1_ledger_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   40,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
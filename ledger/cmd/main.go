@@ -5,6 +5,7 @@ import (
 	"ledger/internal/cache"
 	"ledger/internal/db"
 	"ledger/internal/db/sqlc"
+	"ledger/internal/events"
 	"ledger/internal/repository/pg"
 	"ledger/internal/service"
 	"log"
@@ -47,13 +48,21 @@ func main() {
 	cache.Init(ctx)
 	q := sqlc.New(database)
 	budgetRepo := pg.NewBudgetRepo(q)
-	expenseRepo := pg.NewExpenseRepo(q)
+	expenseRepo := pg.NewExpenseRepo(database, q)
 	reportRepo := pg.NewReportRepo(q)
+	profileRepo := pg.NewImportProfileRepo(q)
+	jobRepo := pg.NewImportJobRepo(database, q)
+	accountRepo := pg.NewAccountRepo(database, q)
+	ledgerRepo := pg.NewLedgerRepo(database, q)
 
 	svc := service.New(
 		budgetRepo,
 		expenseRepo,
 		reportRepo,
+		profileRepo,
+		jobRepo,
+		accountRepo,
+		ledgerRepo,
 	)
 	closeFn := func() {
 		if cache.Client != nil {
@@ -75,13 +84,28 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to listen %s: %v", addr, err)
 	}
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(ledgergrpc.IdempotencyInterceptor),
+	)
 
 	server := ledgergrpc.NewServer(svc)
 	ledgerv1.RegisterLedgerServiceServer(grpcServer, server)
 
 	log.Printf("Ledger gRPC server listening on %s", addr)
 
+	go svc.RunImportJobs(ctx)
+
+	// deleted accounts arrive from auth over Redis
+	if cache.Client != nil {
+		go func() {
+			host, _ := os.Hostname()
+			consumer := events.NewConsumer(cache.Client, svc)
+			if err := consumer.Run(ctx, "ledger-"+host); err != nil && ctx.Err() == nil {
+				log.Printf("account deletions: %v", err)
+			}
+		}()
+	}
+
 	go func() {
 		<-ctx.Done()
 		log.Println("shutting down gRPC server...")
@@ -0,0 +1,291 @@
+// Package backup reads and writes account archives: a zip of JSON files
+// described by a manifest that records the SHA-256 checksum, size and
+// record count of every file.
+package backup
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+const (
+	Format       = "gofinance-backup"
+	Version      = 1
+	ManifestName = "manifest.json"
+)
+
+// maxUnpackedSize caps the total size the manifest may announce, so a small
+// archive cannot unpack into an unbounded amount of data.
+const maxUnpackedSize = 1 << 30
+
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []File    `json:"files"`
+}
+
+type File struct {
+	Name    string `json:"name"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size"`
+	Records int    `json:"records"`
+}
+
+// Writer builds an archive. Files are compressed as they are written and
+// the manifest is added by Close.
+type Writer struct {
+	zw       *zip.Writer
+	manifest Manifest
+}
+
+func NewWriter(w io.Writer, createdAt time.Time) *Writer {
+	return &Writer{
+		zw: zip.NewWriter(w),
+		manifest: Manifest{
+			Format:    Format,
+			Version:   Version,
+			CreatedAt: createdAt.UTC(),
+		},
+	}
+}
+
+// WriteArray stores a JSON array under name. each is called once and adds
+// the elements one at a time, so the array never has to be held in memory.
+func (w *Writer) WriteArray(name string, each func(add func(v any) error) error) error {
+	f, err := w.create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString("["); err != nil {
+		return err
+	}
+
+	records := 0
+	err = each(func(v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		sep := ",\n"
+		if records == 0 {
+			sep = "\n"
+		}
+		if _, err := f.WriteString(sep); err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+		records++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString("\n]\n"); err != nil {
+		return err
+	}
+	return f.close(records)
+}
+
+// WriteObject stores v as a single JSON document under name.
+func (w *Writer) WriteObject(name string, v any) error {
+	f, err := w.create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return f.close(1)
+}
+
+// Close writes the manifest and finishes the archive.
+func (w *Writer) Close() error {
+	f, err := w.zw.Create(ManifestName)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(w.manifest); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+func (w *Writer) create(name string) (*fileWriter, error) {
+	if name == ManifestName {
+		return nil, fmt.Errorf("%s is reserved", name)
+	}
+
+	zf, err := w.zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fileWriter{w: w, name: name, hash: sha256.New()}
+	f.Writer = bufio.NewWriter(io.MultiWriter(zf, f.hash, &f.size))
+	return f, nil
+}
+
+// fileWriter hashes and counts what is written to one archive file.
+type fileWriter struct {
+	*bufio.Writer
+
+	w    *Writer
+	name string
+	hash hash.Hash
+	size counter
+}
+
+type counter struct {
+	n int64
+}
+
+func (c *counter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+func (f *fileWriter) close(records int) error {
+	if err := f.Flush(); err != nil {
+		return err
+	}
+
+	f.w.manifest.Files = append(f.w.manifest.Files, File{
+		Name:    f.name,
+		SHA256:  hex.EncodeToString(f.hash.Sum(nil)),
+		Size:    f.size.n,
+		Records: records,
+	})
+	return nil
+}
+
+// Reader gives access to the files of a verified archive.
+type Reader struct {
+	Manifest Manifest
+
+	files map[string]*zip.File
+	sizes map[string]int64
+}
+
+// NewReader opens an archive and checks its manifest and the checksum of
+// every file it lists. Files missing from the manifest are ignored.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("not a zip archive")
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	mf, ok := files[ManifestName]
+	if !ok {
+		return nil, errors.New("missing " + ManifestName)
+	}
+
+	var m Manifest
+	if err := decodeFile(mf, 1<<20, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestName, err)
+	}
+
+	if m.Format != Format {
+		return nil, fmt.Errorf("unknown format %q", m.Format)
+	}
+	if m.Version < 1 || m.Version > Version {
+		return nil, fmt.Errorf("unsupported version %d", m.Version)
+	}
+
+	br := &Reader{
+		Manifest: m,
+		files:    make(map[string]*zip.File, len(m.Files)),
+		sizes:    make(map[string]int64, len(m.Files)),
+	}
+
+	var total int64
+	for _, entry := range m.Files {
+		total += entry.Size
+		if entry.Size < 0 || total > maxUnpackedSize {
+			return nil, errors.New("archive is too large")
+		}
+
+		f, ok := files[entry.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing %s", entry.Name)
+		}
+		if err := verify(f, entry); err != nil {
+			return nil, err
+		}
+
+		br.files[entry.Name] = f
+		br.sizes[entry.Name] = entry.Size
+	}
+	return br, nil
+}
+
+func verify(f *zip.File, entry File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%s: %w", entry.Name, err)
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, io.LimitReader(rc, entry.Size+1))
+	if err != nil {
+		return fmt.Errorf("%s: %w", entry.Name, err)
+	}
+
+	if n != entry.Size || hex.EncodeToString(h.Sum(nil)) != entry.SHA256 {
+		return fmt.Errorf("checksum mismatch for %s", entry.Name)
+	}
+	return nil
+}
+
+// Has reports whether the manifest lists name.
+func (r *Reader) Has(name string) bool {
+	_, ok := r.files[name]
+	return ok
+}
+
+// Decode reads the JSON file name into v.
+func (r *Reader) Decode(name string, v any) error {
+	f, ok := r.files[name]
+	if !ok {
+		return fmt.Errorf("missing %s", name)
+	}
+
+	if err := decodeFile(f, r.sizes[name], v); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
+func decodeFile(f *zip.File, limit int64, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return json.NewDecoder(io.LimitReader(rc, limit)).Decode(v)
+}
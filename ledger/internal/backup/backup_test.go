@@ -0,0 +1,104 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func writeArchive(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	err := w.WriteArray("items.json", func(add func(v any) error) error {
+		for _, name := range []string{"a", "b", "c"} {
+			if err := add(item{Name: name}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteArray("empty.json", func(add func(v any) error) error { return nil }))
+	require.NoError(t, w.WriteObject("settings.json", map[string]bool{"on": true}))
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := writeArchive(t)
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	require.Equal(t, Format, r.Manifest.Format)
+	require.Equal(t, Version, r.Manifest.Version)
+	require.Len(t, r.Manifest.Files, 3)
+	require.Equal(t, "items.json", r.Manifest.Files[0].Name)
+	require.Equal(t, 3, r.Manifest.Files[0].Records)
+	require.Equal(t, 0, r.Manifest.Files[1].Records)
+
+	var items []item
+	require.NoError(t, r.Decode("items.json", &items))
+	require.Equal(t, []item{{"a"}, {"b"}, {"c"}}, items)
+
+	var empty []item
+	require.NoError(t, r.Decode("empty.json", &empty))
+	require.Empty(t, empty)
+
+	var settings map[string]bool
+	require.NoError(t, r.Decode("settings.json", &settings))
+	require.True(t, settings["on"])
+
+	require.False(t, r.Has("other.json"))
+	require.Error(t, r.Decode("other.json", &settings))
+}
+
+func TestNewReader_ChecksumMismatch(t *testing.T) {
+	data := writeArchive(t)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	// copy the archive with one element of items.json changed
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+
+		if f.Name == "items.json" {
+			body = bytes.Replace(body, []byte(`"b"`), []byte(`"x"`), 1)
+		}
+
+		w, err := zw.Create(f.Name)
+		require.NoError(t, err)
+		_, err = w.Write(body)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	_, err = NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.EqualError(t, err, "checksum mismatch for items.json")
+}
+
+func TestNewReader_NotAnArchive(t *testing.T) {
+	data := []byte("amount,category\n")
+
+	_, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	require.EqualError(t, err, "not a zip archive")
+}
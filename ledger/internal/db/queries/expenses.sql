@@ -1,3 +1,43 @@
+-- name: AnonymizeUserExpenses :exec
+UPDATE expenses
+SET user_id = '00000000-0000-0000-0000-000000000000'
+WHERE user_id = $1;
+
+-- name: DeleteLedgerExpenses :exec
+DELETE FROM expenses
+WHERE ledger_id = $1;
+
+-- name: ExistingFingerprints :many
+SELECT fingerprint::TEXT
+FROM expenses
+WHERE ledger_id = sqlc.arg(ledger_id)
+  AND source = sqlc.arg(source)
+  AND fingerprint = ANY(sqlc.arg(fingerprints)::TEXT[]);
+
+-- name: ExpenseFingerprintExists :one
+SELECT EXISTS (
+    SELECT 1
+    FROM expenses
+    WHERE ledger_id = $1
+      AND source = $2
+      AND fingerprint = $3
+);
+
+-- name: FindDuplicateExpenses :many
+SELECT id, user_id, amount, category, description, date, external_id, source, fingerprint, ledger_id
+FROM expenses e
+WHERE e.ledger_id = $1
+  AND EXISTS (
+    SELECT 1
+    FROM expenses d
+    WHERE d.ledger_id = e.ledger_id
+      AND d.id <> e.id
+      AND d.date = e.date
+      AND d.amount = e.amount
+      AND lower(COALESCE(d.description, '')) = lower(COALESCE(e.description, ''))
+)
+ORDER BY date DESC, amount, lower(COALESCE(description, '')), id;
+
 -- name: GetBudgetLimit :one
 SELECT limit_amount
 FROM budgets
@@ -7,23 +47,63 @@ FOR UPDATE;
 -- name: GetSumByCategory :one
 SELECT COALESCE(SUM(amount), 0)::DECIMAL(14,2)
 FROM expenses
-WHERE user_id = $1
+WHERE ledger_id = $1
   AND category = $2;
 
 -- name: InsertExpense :one
-INSERT INTO expenses (user_id, amount, category, description, date)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO expenses (ledger_id, user_id, amount, category, description, date, external_id, source, fingerprint)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (ledger_id, source, fingerprint) DO NOTHING
     RETURNING id;
 
+-- name: InsertExpenses :many
+INSERT INTO expenses (ledger_id, user_id, amount, category, description, date, external_id, source, fingerprint)
+SELECT sqlc.arg(ledger_id), sqlc.arg(user_id), u.amount, u.category, NULLIF(u.description, ''), u.date, NULLIF(u.external_id, ''), sqlc.arg(source), u.fingerprint
+FROM unnest(
+    sqlc.arg(amounts)::DECIMAL(14,2)[],
+    sqlc.arg(categories)::TEXT[],
+    sqlc.arg(descriptions)::TEXT[],
+    sqlc.arg(dates)::DATE[],
+    sqlc.arg(external_ids)::TEXT[],
+    sqlc.arg(fingerprints)::TEXT[]
+) AS u(amount, category, description, date, external_id, fingerprint)
+ON CONFLICT (ledger_id, source, fingerprint) DO NOTHING
+RETURNING fingerprint::TEXT;
+
 -- name: ListExpenses :many
-SELECT id, user_id, amount, category, description, date
+SELECT id, user_id, amount, category, description, date, external_id, source, fingerprint, ledger_id
 FROM expenses
-WHERE user_id = $1
+WHERE ledger_id = $1
 ORDER BY date DESC, id DESC;
 
+-- name: ListExpensesAfter :many
+SELECT id, user_id, amount, category, description, date, external_id, source, fingerprint, ledger_id
+FROM expenses
+WHERE ledger_id = sqlc.arg(ledger_id)
+  AND date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+  AND (cardinality(sqlc.arg(categories)::TEXT[]) = 0 OR category = ANY(sqlc.arg(categories)::TEXT[]))
+  AND (sqlc.arg(source)::TEXT = '' OR source = sqlc.arg(source)::TEXT)
+  AND (date, id) > (sqlc.arg(after_date)::DATE, sqlc.arg(after_id)::INT)
+ORDER BY date, id
+LIMIT sqlc.arg(page_size);
+
+-- name: RestoreExpenses :execrows
+INSERT INTO expenses (ledger_id, user_id, amount, category, description, date, external_id, source, fingerprint)
+SELECT sqlc.arg(ledger_id), sqlc.arg(user_id), u.amount, u.category, NULLIF(u.description, ''), u.date, NULLIF(u.external_id, ''), u.source, NULLIF(u.fingerprint, '')
+FROM unnest(
+    sqlc.arg(amounts)::DECIMAL(14,2)[],
+    sqlc.arg(categories)::TEXT[],
+    sqlc.arg(descriptions)::TEXT[],
+    sqlc.arg(dates)::DATE[],
+    sqlc.arg(external_ids)::TEXT[],
+    sqlc.arg(sources)::TEXT[],
+    sqlc.arg(fingerprints)::TEXT[]
+) AS u(amount, category, description, date, external_id, source, fingerprint)
+ON CONFLICT (ledger_id, source, fingerprint) DO NOTHING;
+
 -- name: SumByCategoryAndPeriod :one
 SELECT COALESCE(SUM(amount), 0)::DECIMAL(14,2)
 FROM expenses
-WHERE user_id = sqlc.arg(user_id)
+WHERE ledger_id = sqlc.arg(ledger_id)
   AND category = sqlc.arg(category)
   AND date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date);
\ No newline at end of file
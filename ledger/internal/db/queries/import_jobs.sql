@@ -0,0 +1,121 @@
+-- name: CancelImportJob :execrows
+UPDATE import_jobs
+SET status = 'cancelled', updated_at = now()
+WHERE ledger_id = $1
+  AND id = $2
+  AND status IN ('uploading', 'queued', 'running');
+
+-- name: ClaimImportJob :one
+UPDATE import_jobs
+SET status = 'running', updated_at = now()
+WHERE id = (
+    SELECT j.id
+    FROM import_jobs j
+    WHERE j.status = 'queued'
+       OR (j.status = 'running' AND j.updated_at < sqlc.arg(stale_before)::TIMESTAMPTZ)
+    ORDER BY j.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id, status, source, file_name, total, processed, accepted, rejected, duplicates, error, created_at, updated_at, ledger_id;
+
+-- name: CreateImportJob :exec
+INSERT INTO import_jobs (id, ledger_id, user_id, status, source, file_name)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: DeleteFinishedImportJobRows :execrows
+DELETE FROM import_job_rows r
+USING import_jobs j
+WHERE r.job_id = j.id
+  AND j.status NOT IN ('uploading', 'queued', 'running');
+
+-- name: DeleteImportJobRows :exec
+DELETE FROM import_job_rows
+WHERE job_id = $1
+  AND idx < $2;
+
+-- name: DeleteUserImportJobs :exec
+DELETE FROM import_jobs
+WHERE user_id = $1;
+
+-- name: FailStaleImportJobUploads :execrows
+UPDATE import_jobs
+SET status = 'failed', error = 'upload did not finish', updated_at = now()
+WHERE status = 'uploading'
+  AND updated_at < sqlc.arg(stale_before)::TIMESTAMPTZ;
+
+-- name: FinishImportJob :exec
+UPDATE import_jobs
+SET status = $2, error = $3, updated_at = now()
+WHERE id = $1
+  AND status IN ('uploading', 'running');
+
+-- name: GetImportJob :one
+SELECT id, user_id, status, source, file_name, total, processed, accepted, rejected, duplicates, error, created_at, updated_at, ledger_id
+FROM import_jobs
+WHERE ledger_id = $1
+  AND id = $2;
+
+-- name: GetImportJobStatus :one
+SELECT status
+FROM import_jobs
+WHERE id = $1;
+
+-- name: InsertImportJobErrors :exec
+INSERT INTO import_job_errors (job_id, idx, line, error)
+SELECT sqlc.arg(job_id), u.idx, u.line, u.error
+FROM unnest(
+    sqlc.arg(idxs)::INT[],
+    sqlc.arg(lines)::INT[],
+    sqlc.arg(errors)::TEXT[]
+) AS u(idx, line, error)
+ON CONFLICT (job_id, idx) DO NOTHING;
+
+-- name: InsertImportJobRows :exec
+INSERT INTO import_job_rows (job_id, idx, line, amount, category, description, date, external_id, fingerprint)
+SELECT sqlc.arg(job_id), u.idx, u.line, u.amount, u.category, u.description, u.date, u.external_id, u.fingerprint
+FROM unnest(
+    sqlc.arg(idxs)::INT[],
+    sqlc.arg(lines)::INT[],
+    sqlc.arg(amounts)::DECIMAL(14,2)[],
+    sqlc.arg(categories)::TEXT[],
+    sqlc.arg(descriptions)::TEXT[],
+    sqlc.arg(dates)::DATE[],
+    sqlc.arg(external_ids)::TEXT[],
+    sqlc.arg(fingerprints)::TEXT[]
+) AS u(idx, line, amount, category, description, date, external_id, fingerprint);
+
+-- name: ListImportJobErrors :many
+SELECT job_id, idx, line, error
+FROM import_job_errors
+WHERE job_id = $1
+ORDER BY idx
+LIMIT $2;
+
+-- name: ListImportJobRows :many
+SELECT job_id, idx, line, amount, category, description, date, external_id, fingerprint
+FROM import_job_rows
+WHERE job_id = $1
+ORDER BY idx
+LIMIT $2;
+
+-- name: QueueImportJob :execrows
+UPDATE import_jobs
+SET status = 'queued', total = $2, updated_at = now()
+WHERE id = $1
+  AND status = 'uploading';
+
+-- name: TouchImportJobUpload :execrows
+UPDATE import_jobs
+SET updated_at = now()
+WHERE id = $1
+  AND status = 'uploading';
+
+-- name: UpdateImportJobProgress :exec
+UPDATE import_jobs
+SET processed  = $2,
+    accepted   = accepted + $3,
+    rejected   = rejected + $4,
+    duplicates = duplicates + $5,
+    updated_at = now()
+WHERE id = $1;
@@ -0,0 +1,44 @@
+-- name: UpsertImportProfile :exec
+INSERT INTO import_profiles (
+    ledger_id, name, amount_column, date_column, category_column,
+    description_column, external_id_column, delimiter, decimal_comma,
+    date_format, encoding, sign_convention
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+    ON CONFLICT (ledger_id, name)
+DO UPDATE SET
+    amount_column      = EXCLUDED.amount_column,
+    date_column        = EXCLUDED.date_column,
+    category_column    = EXCLUDED.category_column,
+    description_column = EXCLUDED.description_column,
+    external_id_column = EXCLUDED.external_id_column,
+    delimiter          = EXCLUDED.delimiter,
+    decimal_comma      = EXCLUDED.decimal_comma,
+    date_format        = EXCLUDED.date_format,
+    encoding           = EXCLUDED.encoding,
+    sign_convention    = EXCLUDED.sign_convention;
+
+-- name: GetImportProfile :one
+SELECT id, ledger_id, name, amount_column, date_column, category_column,
+       description_column, external_id_column, delimiter, decimal_comma,
+       date_format, encoding, sign_convention
+FROM import_profiles
+WHERE ledger_id = $1
+  AND name = $2;
+
+-- name: ListImportProfiles :many
+SELECT id, ledger_id, name, amount_column, date_column, category_column,
+       description_column, external_id_column, delimiter, decimal_comma,
+       date_format, encoding, sign_convention
+FROM import_profiles
+WHERE ledger_id = $1
+ORDER BY name;
+
+-- name: DeleteImportProfile :execrows
+DELETE FROM import_profiles
+WHERE ledger_id = $1
+  AND name = $2;
+
+-- name: DeleteLedgerImportProfiles :exec
+DELETE FROM import_profiles
+WHERE ledger_id = $1;
@@ -0,0 +1,84 @@
+-- name: AddLedgerMember :exec
+INSERT INTO ledger_members (ledger_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (ledger_id, user_id) DO NOTHING;
+
+-- name: CreateLedger :exec
+INSERT INTO ledgers (id, name, owner_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: CreateLedgerInvitation :exec
+INSERT INTO ledger_invitations (token_hash, ledger_id, role, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: DeleteLedgerMember :execrows
+DELETE FROM ledger_members
+WHERE ledger_id = $1
+  AND user_id = $2
+  AND role <> 'owner';
+
+-- name: DeleteOwnedLedgers :many
+DELETE FROM ledgers
+WHERE owner_id = $1
+RETURNING id;
+
+-- name: DeleteUserLedgerMemberships :exec
+DELETE FROM ledger_members
+WHERE user_id = $1;
+
+-- name: GetLedger :one
+SELECT id, name, owner_id, created_at
+FROM ledgers
+WHERE id = $1;
+
+-- name: GetLedgerMember :one
+SELECT ledger_id, user_id, role, joined_at
+FROM ledger_members
+WHERE ledger_id = $1
+  AND user_id = $2;
+
+-- name: ListLedgerMembers :many
+SELECT ledger_id, user_id, role, joined_at
+FROM ledger_members
+WHERE ledger_id = $1
+ORDER BY joined_at, user_id;
+
+-- name: ListUserLedgers :many
+SELECT l.id, l.name, l.owner_id, l.created_at, m.role
+FROM ledgers l
+JOIN ledger_members m ON m.ledger_id = l.id
+WHERE m.user_id = $1
+ORDER BY l.created_at, l.id;
+
+-- name: SetLedgerMemberRole :execrows
+UPDATE ledger_members
+SET role = $3
+WHERE ledger_id = $1
+  AND user_id = $2
+  AND role <> 'owner';
+
+-- name: TakeLedgerInvitation :one
+DELETE FROM ledger_invitations
+WHERE token_hash = $1
+RETURNING token_hash, ledger_id, role, invited_by, expires_at, created_at;
+
+-- name: TransferSharedLedgers :exec
+WITH heirs AS (
+    SELECT DISTINCT ON (m.ledger_id) m.ledger_id, m.user_id
+    FROM ledger_members m
+    JOIN ledgers l ON l.id = m.ledger_id
+    WHERE l.owner_id = $1
+      AND m.user_id <> $1
+    ORDER BY m.ledger_id, m.joined_at, m.user_id
+), promoted AS (
+    UPDATE ledger_members m
+    SET role = 'owner'
+    FROM heirs h
+    WHERE m.ledger_id = h.ledger_id
+      AND m.user_id = h.user_id
+)
+UPDATE ledgers l
+SET owner_id = h.user_id
+FROM heirs h
+WHERE l.id = h.ledger_id;
@@ -3,6 +3,17 @@ SELECT
     category,
     COALESCE(SUM(amount), 0)::DECIMAL(14,2) AS total
 FROM expenses
-WHERE user_id = $1
+WHERE ledger_id = $1
   AND date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
-ORDER BY category;
\ No newline at end of file
+ORDER BY category;
+
+-- name: MemberSpending :many
+SELECT
+    user_id,
+    COUNT(*) AS transactions,
+    COALESCE(SUM(amount), 0)::DECIMAL(14,2) AS total
+FROM expenses
+WHERE ledger_id = $1
+  AND date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+GROUP BY user_id
+ORDER BY total DESC, user_id;
\ No newline at end of file
@@ -12,24 +12,34 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const deleteLedgerBudgets = `-- name: DeleteLedgerBudgets :exec
+DELETE FROM budgets
+WHERE ledger_id = $1
+`
+
+func (q *Queries) DeleteLedgerBudgets(ctx context.Context, ledgerID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteLedgerBudgets, ledgerID)
+	return err
+}
+
 const getByCategory = `-- name: GetByCategory :one
-SELECT id, user_id, category, limit_amount, period
+SELECT id, ledger_id, category, limit_amount, period
 FROM budgets
-WHERE user_id = $1
+WHERE ledger_id = $1
   AND category = $2
 `
 
 type GetByCategoryParams struct {
-	UserID   uuid.UUID
+	LedgerID uuid.UUID
 	Category string
 }
 
 func (q *Queries) GetByCategory(ctx context.Context, arg GetByCategoryParams) (Budget, error) {
-	row := q.db.QueryRowContext(ctx, getByCategory, arg.UserID, arg.Category)
+	row := q.db.QueryRowContext(ctx, getByCategory, arg.LedgerID, arg.Category)
 	var i Budget
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.LedgerID,
 		&i.Category,
 		&i.LimitAmount,
 		&i.Period,
@@ -38,14 +48,14 @@ func (q *Queries) GetByCategory(ctx context.Context, arg GetByCategoryParams) (B
 }
 
 const listBudgets = `-- name: ListBudgets :many
-SELECT id, user_id, category, limit_amount, period
+SELECT id, ledger_id, category, limit_amount, period
 FROM budgets
-WHERE user_id = $1
+WHERE ledger_id = $1
 ORDER BY category
 `
 
-func (q *Queries) ListBudgets(ctx context.Context, userID uuid.UUID) ([]Budget, error) {
-	rows, err := q.db.QueryContext(ctx, listBudgets, userID)
+func (q *Queries) ListBudgets(ctx context.Context, ledgerID uuid.UUID) ([]Budget, error) {
+	rows, err := q.db.QueryContext(ctx, listBudgets, ledgerID)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +65,7 @@ func (q *Queries) ListBudgets(ctx context.Context, userID uuid.UUID) ([]Budget,
 		var i Budget
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
+			&i.LedgerID,
 			&i.Category,
 			&i.LimitAmount,
 			&i.Period,
@@ -76,11 +86,11 @@ func (q *Queries) ListBudgets(ctx context.Context, userID uuid.UUID) ([]Budget,
 const listExpenseCategories = `-- name: ListExpenseCategories :many
 SELECT DISTINCT category
 FROM expenses
-WHERE user_id = $1
+WHERE ledger_id = $1
 `
 
-func (q *Queries) ListExpenseCategories(ctx context.Context, userID uuid.UUID) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, listExpenseCategories, userID)
+func (q *Queries) ListExpenseCategories(ctx context.Context, ledgerID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenseCategories, ledgerID)
 	if err != nil {
 		return nil, err
 	}
@@ -103,16 +113,16 @@ func (q *Queries) ListExpenseCategories(ctx context.Context, userID uuid.UUID) (
 }
 
 const upsertBudget = `-- name: UpsertBudget :exec
-INSERT INTO budgets (user_id, category, limit_amount, period)
+INSERT INTO budgets (ledger_id, category, limit_amount, period)
 VALUES ($1, $2, $3, $4)
-    ON CONFLICT (user_id, category)
+    ON CONFLICT (ledger_id, category)
 DO UPDATE SET
     limit_amount = EXCLUDED.limit_amount,
            period       = EXCLUDED.period
 `
 
 type UpsertBudgetParams struct {
-	UserID      uuid.UUID
+	LedgerID    uuid.UUID
 	Category    string
 	LimitAmount decimal.Decimal
 	Period      string
@@ -120,7 +130,7 @@ type UpsertBudgetParams struct {
 
 func (q *Queries) UpsertBudget(ctx context.Context, arg UpsertBudgetParams) error {
 	_, err := q.db.ExecContext(ctx, upsertBudget,
-		arg.UserID,
+		arg.LedgerID,
 		arg.Category,
 		arg.LimitAmount,
 		arg.Period,
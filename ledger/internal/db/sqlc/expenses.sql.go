@@ -14,6 +14,137 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const anonymizeUserExpenses = `-- name: AnonymizeUserExpenses :exec
+UPDATE expenses
+SET user_id = '00000000-0000-0000-0000-000000000000'
+WHERE user_id = $1
+`
+
+func (q *Queries) AnonymizeUserExpenses(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, anonymizeUserExpenses, userID)
+	return err
+}
+
+const deleteLedgerExpenses = `-- name: DeleteLedgerExpenses :exec
+DELETE FROM expenses
+WHERE ledger_id = $1
+`
+
+func (q *Queries) DeleteLedgerExpenses(ctx context.Context, ledgerID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteLedgerExpenses, ledgerID)
+	return err
+}
+
+const existingFingerprints = `-- name: ExistingFingerprints :many
+SELECT fingerprint::TEXT
+FROM expenses
+WHERE ledger_id = $1
+  AND source = $2
+  AND fingerprint = ANY($3::TEXT[])
+`
+
+type ExistingFingerprintsParams struct {
+	LedgerID     uuid.UUID
+	Source       string
+	Fingerprints []string
+}
+
+func (q *Queries) ExistingFingerprints(ctx context.Context, arg ExistingFingerprintsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, existingFingerprints, arg.LedgerID, arg.Source, arg.Fingerprints)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var fingerprint string
+		if err := rows.Scan(&fingerprint); err != nil {
+			return nil, err
+		}
+		items = append(items, fingerprint)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const expenseFingerprintExists = `-- name: ExpenseFingerprintExists :one
+SELECT EXISTS (
+    SELECT 1
+    FROM expenses
+    WHERE ledger_id = $1
+      AND source = $2
+      AND fingerprint = $3
+)
+`
+
+type ExpenseFingerprintExistsParams struct {
+	LedgerID    uuid.UUID
+	Source      string
+	Fingerprint sql.NullString
+}
+
+func (q *Queries) ExpenseFingerprintExists(ctx context.Context, arg ExpenseFingerprintExistsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, expenseFingerprintExists, arg.LedgerID, arg.Source, arg.Fingerprint)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const findDuplicateExpenses = `-- name: FindDuplicateExpenses :many
+SELECT id, user_id, amount, category, description, date, external_id, source, fingerprint, ledger_id
+FROM expenses e
+WHERE e.ledger_id = $1
+  AND EXISTS (
+    SELECT 1
+    FROM expenses d
+    WHERE d.ledger_id = e.ledger_id
+      AND d.id <> e.id
+      AND d.date = e.date
+      AND d.amount = e.amount
+      AND lower(COALESCE(d.description, '')) = lower(COALESCE(e.description, ''))
+)
+ORDER BY date DESC, amount, lower(COALESCE(description, '')), id
+`
+
+func (q *Queries) FindDuplicateExpenses(ctx context.Context, ledgerID uuid.UUID) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, findDuplicateExpenses, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Amount,
+			&i.Category,
+			&i.Description,
+			&i.Date,
+			&i.ExternalID,
+			&i.Source,
+			&i.Fingerprint,
+			&i.LedgerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBudgetLimit = `-- name: GetBudgetLimit :one
 SELECT limit_amount
 FROM budgets
@@ -31,58 +162,127 @@ func (q *Queries) GetBudgetLimit(ctx context.Context, category string) (decimal.
 const getSumByCategory = `-- name: GetSumByCategory :one
 SELECT COALESCE(SUM(amount), 0)::DECIMAL(14,2)
 FROM expenses
-WHERE user_id = $1
+WHERE ledger_id = $1
   AND category = $2
 `
 
 type GetSumByCategoryParams struct {
-	UserID   uuid.UUID
+	LedgerID uuid.UUID
 	Category string
 }
 
 func (q *Queries) GetSumByCategory(ctx context.Context, arg GetSumByCategoryParams) (decimal.Decimal, error) {
-	row := q.db.QueryRowContext(ctx, getSumByCategory, arg.UserID, arg.Category)
+	row := q.db.QueryRowContext(ctx, getSumByCategory, arg.LedgerID, arg.Category)
 	var column_1 decimal.Decimal
 	err := row.Scan(&column_1)
 	return column_1, err
 }
 
 const insertExpense = `-- name: InsertExpense :one
-INSERT INTO expenses (user_id, amount, category, description, date)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO expenses (ledger_id, user_id, amount, category, description, date, external_id, source, fingerprint)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (ledger_id, source, fingerprint) DO NOTHING
     RETURNING id
 `
 
 type InsertExpenseParams struct {
+	LedgerID    uuid.UUID
 	UserID      uuid.UUID
 	Amount      decimal.Decimal
 	Category    string
 	Description sql.NullString
 	Date        time.Time
+	ExternalID  sql.NullString
+	Source      string
+	Fingerprint sql.NullString
 }
 
 func (q *Queries) InsertExpense(ctx context.Context, arg InsertExpenseParams) (int32, error) {
 	row := q.db.QueryRowContext(ctx, insertExpense,
+		arg.LedgerID,
 		arg.UserID,
 		arg.Amount,
 		arg.Category,
 		arg.Description,
 		arg.Date,
+		arg.ExternalID,
+		arg.Source,
+		arg.Fingerprint,
 	)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
 }
 
+const insertExpenses = `-- name: InsertExpenses :many
+INSERT INTO expenses (ledger_id, user_id, amount, category, description, date, external_id, source, fingerprint)
+SELECT $1, $2, u.amount, u.category, NULLIF(u.description, ''), u.date, NULLIF(u.external_id, ''), $3, u.fingerprint
+FROM unnest(
+    $4::DECIMAL(14,2)[],
+    $5::TEXT[],
+    $6::TEXT[],
+    $7::DATE[],
+    $8::TEXT[],
+    $9::TEXT[]
+) AS u(amount, category, description, date, external_id, fingerprint)
+ON CONFLICT (ledger_id, source, fingerprint) DO NOTHING
+RETURNING fingerprint::TEXT
+`
+
+type InsertExpensesParams struct {
+	LedgerID     uuid.UUID
+	UserID       uuid.UUID
+	Source       string
+	Amounts      []string
+	Categories   []string
+	Descriptions []string
+	Dates        []time.Time
+	ExternalIds  []string
+	Fingerprints []string
+}
+
+func (q *Queries) InsertExpenses(ctx context.Context, arg InsertExpensesParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, insertExpenses,
+		arg.LedgerID,
+		arg.UserID,
+		arg.Source,
+		arg.Amounts,
+		arg.Categories,
+		arg.Descriptions,
+		arg.Dates,
+		arg.ExternalIds,
+		arg.Fingerprints,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var fingerprint string
+		if err := rows.Scan(&fingerprint); err != nil {
+			return nil, err
+		}
+		items = append(items, fingerprint)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listExpenses = `-- name: ListExpenses :many
-SELECT id, user_id, amount, category, description, date
+SELECT id, user_id, amount, category, description, date, external_id, source, fingerprint, ledger_id
 FROM expenses
-WHERE user_id = $1
+WHERE ledger_id = $1
 ORDER BY date DESC, id DESC
 `
 
-func (q *Queries) ListExpenses(ctx context.Context, userID uuid.UUID) ([]Expense, error) {
-	rows, err := q.db.QueryContext(ctx, listExpenses, userID)
+func (q *Queries) ListExpenses(ctx context.Context, ledgerID uuid.UUID) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listExpenses, ledgerID)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +297,10 @@ func (q *Queries) ListExpenses(ctx context.Context, userID uuid.UUID) ([]Expense
 			&i.Category,
 			&i.Description,
 			&i.Date,
+			&i.ExternalID,
+			&i.Source,
+			&i.Fingerprint,
+			&i.LedgerID,
 		); err != nil {
 			return nil, err
 		}
@@ -111,16 +315,127 @@ func (q *Queries) ListExpenses(ctx context.Context, userID uuid.UUID) ([]Expense
 	return items, nil
 }
 
+const listExpensesAfter = `-- name: ListExpensesAfter :many
+SELECT id, user_id, amount, category, description, date, external_id, source, fingerprint, ledger_id
+FROM expenses
+WHERE ledger_id = $1
+  AND date BETWEEN $2 AND $3
+  AND (cardinality($4::TEXT[]) = 0 OR category = ANY($4::TEXT[]))
+  AND ($5::TEXT = '' OR source = $5::TEXT)
+  AND (date, id) > ($6::DATE, $7::INT)
+ORDER BY date, id
+LIMIT $8
+`
+
+type ListExpensesAfterParams struct {
+	LedgerID   uuid.UUID
+	FromDate   time.Time
+	ToDate     time.Time
+	Categories []string
+	Source     string
+	AfterDate  time.Time
+	AfterID    int32
+	PageSize   int32
+}
+
+func (q *Queries) ListExpensesAfter(ctx context.Context, arg ListExpensesAfterParams) ([]Expense, error) {
+	rows, err := q.db.QueryContext(ctx, listExpensesAfter,
+		arg.LedgerID,
+		arg.FromDate,
+		arg.ToDate,
+		arg.Categories,
+		arg.Source,
+		arg.AfterDate,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Expense
+	for rows.Next() {
+		var i Expense
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Amount,
+			&i.Category,
+			&i.Description,
+			&i.Date,
+			&i.ExternalID,
+			&i.Source,
+			&i.Fingerprint,
+			&i.LedgerID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreExpenses = `-- name: RestoreExpenses :execrows
+INSERT INTO expenses (ledger_id, user_id, amount, category, description, date, external_id, source, fingerprint)
+SELECT $1, $2, u.amount, u.category, NULLIF(u.description, ''), u.date, NULLIF(u.external_id, ''), u.source, NULLIF(u.fingerprint, '')
+FROM unnest(
+    $3::DECIMAL(14,2)[],
+    $4::TEXT[],
+    $5::TEXT[],
+    $6::DATE[],
+    $7::TEXT[],
+    $8::TEXT[],
+    $9::TEXT[]
+) AS u(amount, category, description, date, external_id, source, fingerprint)
+ON CONFLICT (ledger_id, source, fingerprint) DO NOTHING
+`
+
+type RestoreExpensesParams struct {
+	LedgerID     uuid.UUID
+	UserID       uuid.UUID
+	Amounts      []string
+	Categories   []string
+	Descriptions []string
+	Dates        []time.Time
+	ExternalIds  []string
+	Sources      []string
+	Fingerprints []string
+}
+
+func (q *Queries) RestoreExpenses(ctx context.Context, arg RestoreExpensesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreExpenses,
+		arg.LedgerID,
+		arg.UserID,
+		arg.Amounts,
+		arg.Categories,
+		arg.Descriptions,
+		arg.Dates,
+		arg.ExternalIds,
+		arg.Sources,
+		arg.Fingerprints,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const sumByCategoryAndPeriod = `-- name: SumByCategoryAndPeriod :one
 SELECT COALESCE(SUM(amount), 0)::DECIMAL(14,2)
 FROM expenses
-WHERE user_id = $1
+WHERE ledger_id = $1
   AND category = $2
   AND date BETWEEN $3 AND $4
 `
 
 type SumByCategoryAndPeriodParams struct {
-	UserID   uuid.UUID
+	LedgerID uuid.UUID
 	Category string
 	FromDate time.Time
 	ToDate   time.Time
@@ -128,7 +443,7 @@ type SumByCategoryAndPeriodParams struct {
 
 func (q *Queries) SumByCategoryAndPeriod(ctx context.Context, arg SumByCategoryAndPeriodParams) (decimal.Decimal, error) {
 	row := q.db.QueryRowContext(ctx, sumByCategoryAndPeriod,
-		arg.UserID,
+		arg.LedgerID,
 		arg.Category,
 		arg.FromDate,
 		arg.ToDate,
@@ -14,7 +14,7 @@ import (
 
 type Budget struct {
 	ID          int32
-	UserID      uuid.UUID
+	LedgerID    uuid.UUID
 	Category    string
 	LimitAmount decimal.Decimal
 	Period      string
@@ -27,4 +27,83 @@ type Expense struct {
 	Category    string
 	Description sql.NullString
 	Date        time.Time
+	ExternalID  sql.NullString
+	Source      string
+	Fingerprint sql.NullString
+	LedgerID    uuid.UUID
+}
+
+type ImportJob struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Status     string
+	Source     string
+	FileName   string
+	Total      int64
+	Processed  int64
+	Accepted   int64
+	Rejected   int64
+	Duplicates int64
+	Error      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	LedgerID   uuid.UUID
+}
+
+type ImportJobError struct {
+	JobID uuid.UUID
+	Idx   int32
+	Line  int32
+	Error string
+}
+
+type ImportJobRow struct {
+	JobID       uuid.UUID
+	Idx         int32
+	Line        int32
+	Amount      decimal.Decimal
+	Category    string
+	Description string
+	Date        time.Time
+	ExternalID  string
+	Fingerprint string
+}
+
+type ImportProfile struct {
+	ID                int32
+	LedgerID          uuid.UUID
+	Name              string
+	AmountColumn      string
+	DateColumn        string
+	CategoryColumn    string
+	DescriptionColumn string
+	ExternalIDColumn  string
+	Delimiter         string
+	DecimalComma      bool
+	DateFormat        string
+	Encoding          string
+	SignConvention    string
+}
+
+type Ledger struct {
+	ID        uuid.UUID
+	Name      string
+	OwnerID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type LedgerInvitation struct {
+	TokenHash string
+	LedgerID  uuid.UUID
+	Role      string
+	InvitedBy uuid.UUID
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type LedgerMember struct {
+	LedgerID uuid.UUID
+	UserID   uuid.UUID
+	Role     string
+	JoinedAt time.Time
 }
@@ -13,18 +13,65 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const memberSpending = `-- name: MemberSpending :many
+SELECT
+    user_id,
+    COUNT(*) AS transactions,
+    COALESCE(SUM(amount), 0)::DECIMAL(14,2) AS total
+FROM expenses
+WHERE ledger_id = $1
+  AND date BETWEEN $2 AND $3
+GROUP BY user_id
+ORDER BY total DESC, user_id
+`
+
+type MemberSpendingParams struct {
+	LedgerID uuid.UUID
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+type MemberSpendingRow struct {
+	UserID       uuid.UUID
+	Transactions int64
+	Total        decimal.Decimal
+}
+
+func (q *Queries) MemberSpending(ctx context.Context, arg MemberSpendingParams) ([]MemberSpendingRow, error) {
+	rows, err := q.db.QueryContext(ctx, memberSpending, arg.LedgerID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MemberSpendingRow
+	for rows.Next() {
+		var i MemberSpendingRow
+		if err := rows.Scan(&i.UserID, &i.Transactions, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const reportSummary = `-- name: ReportSummary :many
 SELECT
     category,
     COALESCE(SUM(amount), 0)::DECIMAL(14,2) AS total
 FROM expenses
-WHERE user_id = $1
+WHERE ledger_id = $1
   AND date BETWEEN $2 AND $3
 ORDER BY category
 `
 
 type ReportSummaryParams struct {
-	UserID   uuid.UUID
+	LedgerID uuid.UUID
 	FromDate time.Time
 	ToDate   time.Time
 }
@@ -35,7 +82,7 @@ type ReportSummaryRow struct {
 }
 
 func (q *Queries) ReportSummary(ctx context.Context, arg ReportSummaryParams) ([]ReportSummaryRow, error) {
-	rows, err := q.db.QueryContext(ctx, reportSummary, arg.UserID, arg.FromDate, arg.ToDate)
+	rows, err := q.db.QueryContext(ctx, reportSummary, arg.LedgerID, arg.FromDate, arg.ToDate)
 	if err != nil {
 		return nil, err
 	}
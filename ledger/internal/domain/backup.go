@@ -0,0 +1,25 @@
+package domain
+
+// Backup is everything an archive carries for one ledger.
+type Backup struct {
+	Budgets        []Budget
+	Transactions   []Transaction
+	ImportProfiles []ImportProfile
+}
+
+// Restore modes. Merge adds the archive to what is stored, replace deletes
+// the ledger's budgets, transactions and import profiles first.
+const (
+	RestoreMerge   = "merge"
+	RestoreReplace = "replace"
+)
+
+type RestoreResult struct {
+	Budgets        int64 `json:"budgets"`
+	Transactions   int64 `json:"transactions"`
+	ImportProfiles int64 `json:"import_profiles"`
+
+	// Duplicates are archived transactions that were already stored and
+	// were skipped.
+	Duplicates int64 `json:"duplicates"`
+}
@@ -9,7 +9,7 @@ import (
 
 type Budget struct {
 	ID       int32           `json:"id"`
-	UserID   uuid.UUID       `json:"user_id"`
+	LedgerID uuid.UUID       `json:"ledger_id"`
 	Category string          `json:"category"`
 	Limit    decimal.Decimal `json:"limit"`
 	Period   string          `json:"period"` // daily | weekly | monthly | ""
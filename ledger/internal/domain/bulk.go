@@ -1,12 +1,33 @@
 package domain
 
+import "fmt"
+
 type BulkImportError struct {
 	Index int    `json:"index"`
 	Error string `json:"error"`
 }
 
+// BulkRowError reports the row that made an all-or-nothing insert fail.
+type BulkRowError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Index, e.Err)
+}
+
+func (e *BulkRowError) Unwrap() error {
+	return e.Err
+}
+
 type BulkImportResult struct {
 	Accepted int64             `json:"accepted"`
 	Rejected int64             `json:"rejected"`
 	Errors   []BulkImportError `json:"errors"`
+
+	// Duplicates were already stored (or repeated within the batch) and
+	// were skipped without an error.
+	Duplicates       int64 `json:"duplicates"`
+	DuplicateIndexes []int `json:"duplicate_indexes"`
 }
@@ -43,4 +43,24 @@ func (e *InvalidDateError) Error() string {
 
 var ErrBudgetNotFound = errors.New("budget not found")
 
+var ErrDuplicateTransaction = errors.New("duplicate transaction")
+
+var ErrImportProfileNotFound = errors.New("import profile not found")
+
+var ErrImportJobNotFound = errors.New("import job not found")
+
+var ErrImportJobFinished = errors.New("import job is already finished")
+
 var ErrUnauthenticated = errors.New("Unauthenticated")
+
+var ErrInvalidBackup = errors.New("invalid backup")
+
+var ErrLedgerNotFound = errors.New("ledger not found")
+
+var ErrLedgerForbidden = errors.New("your role in this ledger does not allow this")
+
+var ErrMemberNotFound = errors.New("member not found")
+
+var ErrLedgerOwner = errors.New("the owner of a ledger cannot leave it or change role")
+
+var ErrInvitationNotFound = errors.New("invitation not found or expired")
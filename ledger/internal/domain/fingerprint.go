@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// DuplicateGroup holds stored transactions that look like the same expense.
+type DuplicateGroup struct {
+	Transactions []Transaction `json:"transactions"`
+}
+
+// Fingerprint identifies a transaction within its import source. The bank's
+// own id wins when there is one; otherwise the date, amount and description
+// are hashed. occurrence tells apart identical expenses in the same batch
+// (two coffees on the same day), so re-importing the batch yields the same
+// fingerprints.
+func Fingerprint(t Transaction, occurrence int) string {
+	if id := strings.TrimSpace(t.ExternalID); id != "" {
+		return "ext:" + id
+	}
+
+	sum := sha256.Sum256([]byte(ContentKey(t) + "|" + strconv.Itoa(occurrence)))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ContentKey is what two copies of the same expense have in common.
+func ContentKey(t Transaction) string {
+	return strings.Join([]string{
+		t.Date.Format("2006-01-02"),
+		t.Amount.StringFixed(2),
+		strings.ToLower(strings.Join(strings.Fields(t.Description), " ")),
+	}, "|")
+}
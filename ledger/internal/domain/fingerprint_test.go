@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	a := Transaction{Amount: decimal.RequireFromString("5.00"), Description: "Coffee  shop", Date: day}
+	b := Transaction{Amount: decimal.NewFromInt(5), Description: "coffee shop", Date: day, Category: "other"}
+
+	require.Equal(t, Fingerprint(a, 0), Fingerprint(b, 0))
+	require.NotEqual(t, Fingerprint(a, 0), Fingerprint(a, 1))
+
+	b.ExternalID = "FIT-9"
+	require.Equal(t, "ext:FIT-9", Fingerprint(b, 3))
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ImportJobUploading = "uploading"
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+	ImportJobCancelled = "cancelled"
+)
+
+// ImportJob is a statement import stored in the background. Rows are kept
+// in the database until the worker has processed them, so a job survives
+// restarts of the ledger.
+type ImportJob struct {
+	ID         uuid.UUID        `json:"id"`
+	LedgerID   uuid.UUID        `json:"ledger_id"`
+	UserID     uuid.UUID        `json:"user_id"` // the member who uploaded it
+	Status     string           `json:"status"`
+	Source     string           `json:"source"`
+	FileName   string           `json:"file_name"`
+	Total      int64            `json:"total"`
+	Processed  int64            `json:"processed"`
+	Accepted   int64            `json:"accepted"`
+	Rejected   int64            `json:"rejected"`
+	Duplicates int64            `json:"duplicates"`
+	Error      string           `json:"error,omitempty"` // why the job failed
+	Errors     []ImportJobError `json:"errors,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// Finished reports whether the worker is done with the job.
+func (j ImportJob) Finished() bool {
+	switch j.Status {
+	case ImportJobCompleted, ImportJobFailed, ImportJobCancelled:
+		return true
+	}
+	return false
+}
+
+// ImportJobRow is a record of the uploaded statement. Index counts rows
+// from zero, Line is where the record starts in the file.
+type ImportJobRow struct {
+	Index       int         `json:"index"`
+	Line        int         `json:"line"`
+	Transaction Transaction `json:"transaction"`
+}
+
+type ImportJobError struct {
+	Index int    `json:"index"`
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportJobProgress is the outcome of one processed batch.
+type ImportJobProgress struct {
+	Processed  int64 // rows with a lower index are done
+	Accepted   int64
+	Rejected   int64
+	Duplicates int64
+	Errors     []ImportJobError
+}
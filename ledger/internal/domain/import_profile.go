@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// ImportProfile describes how to read a CSV bank export. Profiles belong
+// to a ledger, so its members share them.
+// The ledger only stores it; the gateway applies it while parsing.
+type ImportProfile struct {
+	ID                int32     `json:"id"`
+	LedgerID          uuid.UUID `json:"ledger_id"`
+	Name              string    `json:"name"`
+	AmountColumn      string    `json:"amount_column"`
+	DateColumn        string    `json:"date_column"`
+	CategoryColumn    string    `json:"category_column"`
+	DescriptionColumn string    `json:"description_column"`
+	ExternalIDColumn  string    `json:"external_id_column"`
+	Delimiter         string    `json:"delimiter"`
+	DecimalComma      bool      `json:"decimal_comma"`
+	DateFormat        string    `json:"date_format"`     // DD.MM.YYYY, YYYY-MM-DD, ...
+	Encoding          string    `json:"encoding"`        // one of Encodings
+	SignConvention    string    `json:"sign_convention"` // positive | negative | absolute
+}
+
+// Encodings maps the encoding names a profile may use to the canonical
+// one. The gateway's CSV reader accepts the same names.
+var Encodings = map[string]string{
+	"utf-8":        "utf-8",
+	"utf8":         "utf-8",
+	"windows-1251": "windows-1251",
+	"cp1251":       "windows-1251",
+}
+
+// CanonicalEncoding returns the canonical name of an encoding in
+// Encodings, in any case.
+func CanonicalEncoding(name string) (string, bool) {
+	c, ok := Encodings[strings.ToLower(name)]
+	return c, ok
+}
+
+func (p ImportProfile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return &ValidationError{
+			Field:   "name",
+			Message: "must not be empty",
+		}
+	}
+	if strings.TrimSpace(p.AmountColumn) == "" {
+		return &ValidationError{
+			Field:   "amount_column",
+			Message: "must not be empty",
+		}
+	}
+	if strings.TrimSpace(p.DateColumn) == "" {
+		return &ValidationError{
+			Field:   "date_column",
+			Message: "must not be empty",
+		}
+	}
+	if p.Delimiter != "" && utf8.RuneCountInString(p.Delimiter) != 1 {
+		return &ValidationError{
+			Field:   "delimiter",
+			Message: "must be a single character",
+		}
+	}
+	if p.DateFormat != "" && !validDateFormat(p.DateFormat) {
+		return &ValidationError{
+			Field:   "date_format",
+			Message: "must contain DD, MM and YYYY or YY",
+		}
+	}
+	if _, ok := CanonicalEncoding(p.Encoding); p.Encoding != "" && !ok {
+		return &ValidationError{
+			Field:   "encoding",
+			Message: "can be either utf-8 or windows-1251",
+		}
+	}
+	switch p.SignConvention {
+	case "", "positive", "negative", "absolute":
+	default:
+		return &ValidationError{
+			Field:   "sign_convention",
+			Message: "can be either positive, negative or absolute",
+		}
+	}
+	return nil
+}
+
+func validDateFormat(f string) bool {
+	return strings.Contains(f, "DD") &&
+		strings.Contains(f, "MM") &&
+		strings.Contains(f, "YY")
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Roles of ledger members. Viewers read, editors also record transactions
+// and change budgets and import profiles, owners also manage members.
+const (
+	LedgerOwner  = "owner"
+	LedgerEditor = "editor"
+	LedgerViewer = "viewer"
+)
+
+var ledgerRoleRank = map[string]int{
+	LedgerViewer: 1,
+	LedgerEditor: 2,
+	LedgerOwner:  3,
+}
+
+// PersonalLedgerName names the ledger every user has. It has the user's id,
+// so requests that name no ledger use it.
+const PersonalLedgerName = "Personal"
+
+const maxLedgerName = 100
+
+// ErasedUser records the transactions of members whose account was
+// deleted, in the ledgers they did not own.
+var ErasedUser = uuid.Nil
+
+// Ledger holds budgets and transactions shared by its members.
+type Ledger struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	Role      string    `json:"role"` // of the member who asked for it
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (l Ledger) Validate() error {
+	if strings.TrimSpace(l.Name) == "" {
+		return &ValidationError{
+			Field:   "name",
+			Message: "must not be empty",
+		}
+	}
+	if utf8.RuneCountInString(l.Name) > maxLedgerName {
+		return &ValidationError{
+			Field:   "name",
+			Message: "must be at most 100 characters",
+		}
+	}
+	return nil
+}
+
+type Member struct {
+	LedgerID uuid.UUID `json:"ledger_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Can reports whether the member's role allows what role allows.
+func (m Member) Can(role string) bool {
+	return ledgerRoleRank[m.Role] >= ledgerRoleRank[role]
+}
+
+// Invitation lets whoever holds its token join a ledger with Role. Only
+// the hash of the token is stored, so Token is set when it is created.
+type Invitation struct {
+	LedgerID  uuid.UUID `json:"ledger_id"`
+	Role      string    `json:"role"`
+	InvitedBy uuid.UUID `json:"invited_by"`
+	Token     string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (i Invitation) Validate() error {
+	if i.Role != LedgerEditor && i.Role != LedgerViewer {
+		return &ValidationError{
+			Field:   "role",
+			Message: "can be either editor or viewer",
+		}
+	}
+	return nil
+}
+
+// MemberSpending is what one member recorded in a period. Members who have
+// left the ledger are reported too; those whose account was deleted
+// together as ErasedUser.
+type MemberSpending struct {
+	UserID       uuid.UUID       `json:"user_id"`
+	Transactions int64           `json:"transactions"`
+	Total        decimal.Decimal `json:"total"`
+}
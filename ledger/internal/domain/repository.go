@@ -11,54 +11,302 @@ import (
 type BudgetRepository interface {
 	Upsert(
 		ctx context.Context,
-		userID uuid.UUID,
+		ledgerID uuid.UUID,
 		b Budget,
 	) error
 
 	GetByCategory(
 		ctx context.Context,
-		userID uuid.UUID,
+		ledgerID uuid.UUID,
 		category string,
 	) (*Budget, error)
 
 	List(
 		ctx context.Context,
-		userID uuid.UUID,
+		ledgerID uuid.UUID,
 	) ([]Budget, error)
 }
 
 type ExpenseRepository interface {
 	Add(
 		ctx context.Context,
-		userID uuid.UUID,
+		ledgerID uuid.UUID,
 		t Transaction,
 	) error
 
-	List(
+	AddAll(
 		ctx context.Context,
+		ledgerID uuid.UUID,
+		txs []Transaction,
+	) error
+
+	// AddBatch stores txs of one source, recorded by userID, with a single
+	// statement and returns the indexes of rows skipped because their
+	// fingerprint is already stored. Every row must carry a fingerprint.
+	AddBatch(
+		ctx context.Context,
+		ledgerID uuid.UUID,
 		userID uuid.UUID,
+		source string,
+		txs []Transaction,
+	) ([]int, error)
+
+	List(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+	) ([]Transaction, error)
+
+	// ListAfter returns up to limit transactions matching f in (date, id)
+	// order, starting after the transaction after. A zero after starts
+	// from the beginning.
+	ListAfter(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		f TransactionFilter,
+		after Transaction,
+		limit int,
 	) ([]Transaction, error)
 
 	SumByCategory(
 		ctx context.Context,
-		userID uuid.UUID,
+		ledgerID uuid.UUID,
 		category string,
 	) (decimal.Decimal, error)
 
 	SumByCategoryAndPeriod(
 		ctx context.Context,
-		userID uuid.UUID,
+		ledgerID uuid.UUID,
 		category string,
 		from time.Time,
 		to time.Time,
 	) (decimal.Decimal, error)
+
+	HasFingerprint(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		source string,
+		fingerprint string,
+	) (bool, error)
+
+	// ExistingFingerprints returns which of fingerprints are already stored.
+	ExistingFingerprints(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		source string,
+		fingerprints []string,
+	) (map[string]bool, error)
+
+	FindDuplicates(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+	) ([]DuplicateGroup, error)
+}
+
+type ImportProfileRepository interface {
+	Upsert(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		p ImportProfile,
+	) error
+
+	GetByName(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		name string,
+	) (*ImportProfile, error)
+
+	List(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+	) ([]ImportProfile, error)
+
+	Delete(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		name string,
+	) (bool, error)
+}
+
+type ImportJobRepository interface {
+	Create(
+		ctx context.Context,
+		job ImportJob,
+	) error
+
+	// AddRows returns ErrImportJobFinished once the job is no longer
+	// uploading.
+	AddRows(
+		ctx context.Context,
+		jobID uuid.UUID,
+		rows []ImportJobRow,
+	) error
+
+	// Queue hands an uploaded job over to the worker.
+	Queue(
+		ctx context.Context,
+		jobID uuid.UUID,
+		total int64,
+	) error
+
+	// Get returns nil when the ledger has no such job.
+	Get(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		jobID uuid.UUID,
+	) (*ImportJob, error)
+
+	// Cancel returns false when the job is already finished.
+	Cancel(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		jobID uuid.UUID,
+	) (bool, error)
+
+	// Claim marks the oldest queued job as running, or takes over a running
+	// job that has not made progress for staleAfter. It returns nil when
+	// there is nothing to do.
+	Claim(
+		ctx context.Context,
+		staleAfter time.Duration,
+	) (*ImportJob, error)
+
+	Status(
+		ctx context.Context,
+		jobID uuid.UUID,
+	) (string, error)
+
+	// Rows returns up to limit unprocessed rows in upload order.
+	Rows(
+		ctx context.Context,
+		jobID uuid.UUID,
+		limit int,
+	) ([]ImportJobRow, error)
+
+	// SaveProgress records a processed batch and drops its rows.
+	SaveProgress(
+		ctx context.Context,
+		jobID uuid.UUID,
+		p ImportJobProgress,
+	) error
+
+	// Finish sets the final status of a running job.
+	Finish(
+		ctx context.Context,
+		jobID uuid.UUID,
+		status string,
+		reason string,
+	) error
+
+	// Sweep fails jobs whose upload has not sent rows for staleAfter and
+	// drops the rows left behind by finished jobs. It returns how many
+	// uploads were failed.
+	Sweep(
+		ctx context.Context,
+		staleAfter time.Duration,
+	) (int64, error)
+}
+
+type AccountRepository interface {
+	// Restore stores b in the ledger in a single database transaction and
+	// returns how many transactions were inserted; rows whose fingerprint is
+	// already stored are skipped. Transactions are recorded by userID. With
+	// replace the ledger's budgets, transactions and import profiles are
+	// deleted first.
+	Restore(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		userID uuid.UUID,
+		b Backup,
+		replace bool,
+	) (int64, error)
+
+	// Delete removes the ledgers only the user belongs to with everything
+	// in them, the user's memberships and import jobs, in a single
+	// database transaction, and returns the ids of the deleted ledgers.
+	// Ledgers the user owns with other members pass to the member who
+	// joined first. Transactions the user recorded in ledgers that stay
+	// are recorded by ErasedUser. Deleting an account that holds nothing
+	// is not an error.
+	Delete(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
 }
 
 type ReportRepository interface {
 	GetReportSummary(
 		ctx context.Context,
-		userID uuid.UUID,
+		ledgerID uuid.UUID,
 		from time.Time,
 		to time.Time,
 	) ([]ReportSummary, error)
+
+	MemberSpending(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		from time.Time,
+		to time.Time,
+	) ([]MemberSpending, error)
+}
+
+type LedgerRepository interface {
+	// Create stores l with its owner as the first member. Creating a
+	// ledger that exists already is not an error.
+	Create(
+		ctx context.Context,
+		l Ledger,
+	) error
+
+	// Get returns nil when there is no such ledger.
+	Get(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+	) (*Ledger, error)
+
+	// ListForUser returns the ledgers of a member with the member's role.
+	ListForUser(
+		ctx context.Context,
+		userID uuid.UUID,
+	) ([]Ledger, error)
+
+	// Member returns nil when the user is not a member of the ledger.
+	Member(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		userID uuid.UUID,
+	) (*Member, error)
+
+	Members(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+	) ([]Member, error)
+
+	// SetMemberRole returns false when there is no such member or the
+	// member is the owner.
+	SetMemberRole(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		userID uuid.UUID,
+		role string,
+	) (bool, error)
+
+	// RemoveMember returns false when there is no such member or the
+	// member is the owner.
+	RemoveMember(
+		ctx context.Context,
+		ledgerID uuid.UUID,
+		userID uuid.UUID,
+	) (bool, error)
+
+	CreateInvitation(
+		ctx context.Context,
+		tokenHash string,
+		inv Invitation,
+	) error
+
+	// AcceptInvitation uses up the invitation and adds the user with its
+	// role; members keep the role they have. It returns nil when there is
+	// no such invitation or it has expired.
+	AcceptInvitation(
+		ctx context.Context,
+		tokenHash string,
+		userID uuid.UUID,
+	) (*Invitation, error)
 }
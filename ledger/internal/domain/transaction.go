@@ -10,11 +10,34 @@ import (
 
 type Transaction struct {
 	ID          int32           `json:"id"`
-	UserID      uuid.UUID       `json:"user_id"`
+	LedgerID    uuid.UUID       `json:"ledger_id"`
+	UserID      uuid.UUID       `json:"user_id"` // the member who recorded it
 	Amount      decimal.Decimal `json:"amount"`
 	Category    string          `json:"category"`
 	Description string          `json:"description"`
 	Date        time.Time       `json:"date"`
+	ExternalID  string          `json:"external_id,omitempty"`
+	Source      string          `json:"source,omitempty"`
+	Fingerprint string          `json:"fingerprint,omitempty"`
+}
+
+// TransactionFilter selects the transactions of an export. Zero fields
+// match everything; From and To are inclusive.
+type TransactionFilter struct {
+	From       time.Time
+	To         time.Time
+	Categories []string
+	Source     string
+}
+
+func (f TransactionFilter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && f.From.After(f.To) {
+		return &ValidationError{
+			Field:   "from",
+			Message: "must not be after to",
+		}
+	}
+	return nil
 }
 
 func (t Transaction) Validate() error {
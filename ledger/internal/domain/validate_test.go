@@ -182,3 +182,96 @@ func TestTransactionValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestImportProfileValidate(t *testing.T) {
+	valid := ImportProfile{
+		Name:         "bank",
+		AmountColumn: "Amount",
+		DateColumn:   "Date",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(p *ImportProfile)
+		wantErr bool
+		field   string
+	}{
+		{
+			name:   "valid profile",
+			modify: func(p *ImportProfile) {},
+		},
+		{
+			name: "full profile",
+			modify: func(p *ImportProfile) {
+				p.Delimiter = ";"
+				p.DateFormat = "DD.MM.YYYY"
+				p.Encoding = "Windows-1251"
+				p.SignConvention = "negative"
+			},
+		},
+		{
+			name:   "encoding alias",
+			modify: func(p *ImportProfile) { p.Encoding = "CP1251" },
+		},
+		{
+			name:    "empty name",
+			modify:  func(p *ImportProfile) { p.Name = " " },
+			wantErr: true,
+			field:   "name",
+		},
+		{
+			name:    "no amount column",
+			modify:  func(p *ImportProfile) { p.AmountColumn = "" },
+			wantErr: true,
+			field:   "amount_column",
+		},
+		{
+			name:    "no date column",
+			modify:  func(p *ImportProfile) { p.DateColumn = "" },
+			wantErr: true,
+			field:   "date_column",
+		},
+		{
+			name:    "long delimiter",
+			modify:  func(p *ImportProfile) { p.Delimiter = ";;" },
+			wantErr: true,
+			field:   "delimiter",
+		},
+		{
+			name:    "date format without year",
+			modify:  func(p *ImportProfile) { p.DateFormat = "DD.MM" },
+			wantErr: true,
+			field:   "date_format",
+		},
+		{
+			name:    "unknown encoding",
+			modify:  func(p *ImportProfile) { p.Encoding = "koi8-r" },
+			wantErr: true,
+			field:   "encoding",
+		},
+		{
+			name:    "unknown sign convention",
+			modify:  func(p *ImportProfile) { p.SignConvention = "inverted" },
+			wantErr: true,
+			field:   "sign_convention",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.modify(&p)
+
+			err := p.Validate()
+
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+
+			vErr, ok := err.(*ValidationError)
+			require.True(t, ok, "error must be ValidationError")
+			require.Equal(t, tt.field, vErr.Field)
+		})
+	}
+}
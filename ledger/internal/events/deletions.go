@@ -0,0 +1,117 @@
+// Package events consumes account events published by auth over Redis
+// streams.
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Streams shared with auth. Auth publishes deleted accounts to
+// DeletionsStream and retries until the erasure is confirmed on
+// ErasuresStream.
+const (
+	DeletionsStream = "account:deletions"
+	ErasuresStream  = "account:erasures"
+
+	group     = "ledger"
+	maxLength = 10000
+	readBlock = 5 * time.Second
+)
+
+type Eraser interface {
+	EraseAccount(ctx context.Context, userID uuid.UUID) error
+}
+
+type Consumer struct {
+	client *redis.Client
+	eraser Eraser
+}
+
+func NewConsumer(client *redis.Client, eraser Eraser) *Consumer {
+	return &Consumer{client: client, eraser: eraser}
+}
+
+// Run erases the data of deleted accounts until ctx is done. A deletion is
+// acknowledged once the data is gone and the erasure is confirmed; a failed
+// one is left to auth, which publishes it again.
+func (c *Consumer) Run(ctx context.Context, consumer string) error {
+	err := c.client.XGroupCreateMkStream(ctx, DeletionsStream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	// messages delivered before a restart but never acknowledged come
+	// first, then new ones
+	start := "0"
+	for ctx.Err() == nil {
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{DeletionsStream, start},
+			Count:    100,
+			Block:    readBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+
+		var msgs []redis.XMessage
+		if len(streams) > 0 {
+			msgs = streams[0].Messages
+		}
+		if start != ">" {
+			if len(msgs) == 0 {
+				start = ">"
+				continue
+			}
+			start = msgs[len(msgs)-1].ID
+		}
+
+		for _, msg := range msgs {
+			if err := c.handle(ctx, msg); err != nil {
+				log.Printf("account deletion %s: %v", msg.ID, err)
+				continue
+			}
+			if err := c.client.XAck(ctx, DeletionsStream, group, msg.ID).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+func (c *Consumer) handle(ctx context.Context, msg redis.XMessage) error {
+	deletionID, _ := msg.Values["deletion_id"].(string)
+	rawUserID, _ := msg.Values["user_id"].(string)
+
+	// no data can be stored under an invalid ID, so there is nothing to
+	// erase but the deletion is still confirmed
+	if userID, err := uuid.Parse(rawUserID); err == nil {
+		if err := c.eraser.EraseAccount(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	return c.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: ErasuresStream,
+		MaxLen: maxLength,
+		Approx: true,
+		Values: map[string]any{
+			"deletion_id": deletionID,
+			"user_id":     rawUserID,
+		},
+	}).Err()
+}
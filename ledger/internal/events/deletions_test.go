@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+type mockEraser struct {
+	erased []uuid.UUID
+	fail   map[uuid.UUID]bool
+	after  func()
+}
+
+func (m *mockEraser) EraseAccount(ctx context.Context, userID uuid.UUID) error {
+	defer m.after()
+	if m.fail[userID] {
+		return errors.New("database down")
+	}
+	m.erased = append(m.erased, userID)
+	return nil
+}
+
+func TestConsumer_ErasesAndConfirms(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	erased, failing := uuid.New(), uuid.New()
+	for i, id := range []uuid.UUID{erased, failing} {
+		require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{
+			Stream: DeletionsStream,
+			Values: map[string]any{"deletion_id": []string{"del-1", "del-2"}[i], "user_id": id.String()},
+		}).Err())
+	}
+
+	calls := 0
+	eraser := &mockEraser{
+		fail: map[uuid.UUID]bool{failing: true},
+		after: func() {
+			if calls++; calls == 2 {
+				cancel()
+			}
+		},
+	}
+
+	err := NewConsumer(client, eraser).Run(ctx, "test")
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []uuid.UUID{erased}, eraser.erased)
+
+	confirmed, err := client.XRange(context.Background(), ErasuresStream, "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, confirmed, 1)
+	require.Equal(t, "del-1", confirmed[0].Values["deletion_id"])
+
+	// the failed deletion stays pending for the next start
+	pending, err := client.XPending(context.Background(), DeletionsStream, group).Result()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pending.Count)
+}
@@ -0,0 +1,180 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"ledger/internal/cache"
+	ledgerv1 "ledger/ledger/v1"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	idempotencyMetadata = "idempotency-key"
+	idempotencyTTL      = 24 * time.Hour
+)
+
+// idempotencyLockTTL bounds how long a crashed server keeps a key locked;
+// keepLock extends it while the handler runs.
+var idempotencyLockTTL = time.Minute
+
+type idempotencyRecord struct {
+	RequestHash string `json:"request_hash"`
+	Response    []byte `json:"response,omitempty"` // anypb.Any, empty while in flight
+}
+
+// idempotentMethods change data; reads are never cached.
+var idempotentMethods = map[string]bool{
+	ledgerv1.LedgerService_AddTransaction_FullMethodName:      true,
+	ledgerv1.LedgerService_SetBudget_FullMethodName:           true,
+	ledgerv1.LedgerService_BulkAddTransactions_FullMethodName: true,
+	ledgerv1.LedgerService_SaveImportProfile_FullMethodName:   true,
+	ledgerv1.LedgerService_DeleteImportProfile_FullMethodName: true,
+	ledgerv1.LedgerService_CancelImportJob_FullMethodName:     true,
+	ledgerv1.LedgerService_CreateLedger_FullMethodName:        true,
+	ledgerv1.LedgerService_CreateInvitation_FullMethodName:    true,
+	ledgerv1.LedgerService_AcceptInvitation_FullMethodName:    true,
+	ledgerv1.LedgerService_SetMemberRole_FullMethodName:       true,
+	ledgerv1.LedgerService_RemoveMember_FullMethodName:        true,
+}
+
+// IdempotencyInterceptor enforces the idempotency-key metadata sent by the
+// gateway: the first response per user, ledger, method and key is kept for
+// 24h and returned again for retries.
+func IdempotencyInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if cache.Client == nil || !idempotentMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(idempotencyMetadata)
+	users := md.Get("user_id")
+	if len(keys) == 0 || keys[0] == "" || len(users) == 0 {
+		return handler(ctx, req)
+	}
+
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return handler(ctx, req)
+	}
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	sum := sha256.Sum256(raw)
+	hash := hex.EncodeToString(sum[:])
+
+	ledgerID := users[0]
+	if ledgers := md.Get("ledger_id"); len(ledgers) > 0 && ledgers[0] != "" {
+		ledgerID = ledgers[0]
+	}
+
+	key := "idempotency:ledger:" + users[0] + ":" + ledgerID + ":" + info.FullMethod + ":" + keys[0]
+
+	data, _ := json.Marshal(idempotencyRecord{RequestHash: hash})
+
+	acquired, err := cache.Client.SetNX(ctx, key, data, idempotencyLockTTL).Result()
+	if err != nil {
+		log.Printf("idempotency disabled for request: %v", err)
+		return handler(ctx, req)
+	}
+	if !acquired {
+		return replayResponse(ctx, key, hash)
+	}
+
+	stop := keepLock(ctx, key)
+	resp, err := handler(ctx, req)
+	stop()
+	if err != nil {
+		// let the client retry with the same key
+		_ = cache.Client.Del(context.WithoutCancel(ctx), key).Err()
+		return nil, err
+	}
+
+	packed, err := anypb.New(resp.(proto.Message))
+	if err == nil {
+		var body []byte
+		if body, err = proto.Marshal(packed); err == nil {
+			data, _ = json.Marshal(idempotencyRecord{RequestHash: hash, Response: body})
+			err = cache.Client.Set(context.WithoutCancel(ctx), key, data, idempotencyTTL).Err()
+		}
+	}
+	if err != nil {
+		log.Printf("idempotency store failed: %v", err)
+	}
+
+	return resp, nil
+}
+
+// keepLock extends the lock on key until the returned func is called.
+func keepLock(ctx context.Context, key string) func() {
+	ctx = context.WithoutCancel(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := cache.Client.PExpire(ctx, key, idempotencyLockTTL).Err(); err != nil {
+					log.Printf("idempotency lock refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func replayResponse(ctx context.Context, key, hash string) (any, error) {
+	data, err := cache.Client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, status.Error(codes.Aborted, "request with this idempotency key failed, retry")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	var stored idempotencyRecord
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if stored.RequestHash != hash {
+		return nil, status.Error(codes.FailedPrecondition, "idempotency key was used with a different request")
+	}
+	if len(stored.Response) == 0 {
+		return nil, status.Error(codes.Aborted, "request with this idempotency key is in progress")
+	}
+
+	var packed anypb.Any
+	if err := proto.Unmarshal(stored.Response, &packed); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return packed.UnmarshalNew()
+}
@@ -0,0 +1,164 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ledger/internal/cache"
+	ledgerv1 "ledger/ledger/v1"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func withRedis(t *testing.T) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	cache.Client = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = cache.Client.Close()
+		cache.Client = nil
+	})
+}
+
+func idempotentCtx(key string) context.Context {
+	md := metadata.New(map[string]string{
+		"user_id":         "user-1",
+		"idempotency-key": key,
+	})
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestIdempotencyInterceptor_Replay(t *testing.T) {
+	withRedis(t)
+
+	info := &grpc.UnaryServerInfo{FullMethod: ledgerv1.LedgerService_SetBudget_FullMethodName}
+	calls := 0
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls++
+		r := req.(*ledgerv1.CreateBudgetRequest)
+		return &ledgerv1.Budget{Category: r.Category, Limit: r.Limit}, nil
+	}
+
+	req := &ledgerv1.CreateBudgetRequest{Category: "food", Limit: 100}
+
+	first, err := IdempotencyInterceptor(idempotentCtx("k-1"), req, info, handler)
+	require.NoError(t, err)
+
+	second, err := IdempotencyInterceptor(idempotentCtx("k-1"), req, info, handler)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, calls)
+	require.Equal(t, "food", second.(*ledgerv1.Budget).Category)
+	require.Equal(t, first.(*ledgerv1.Budget).Limit, second.(*ledgerv1.Budget).Limit)
+
+	_, err = IdempotencyInterceptor(idempotentCtx("k-1"), &ledgerv1.CreateBudgetRequest{Category: "fun", Limit: 5}, info, handler)
+	st, _ := status.FromError(err)
+	require.Equal(t, codes.FailedPrecondition, st.Code())
+}
+
+func TestIdempotencyInterceptor_ErrorReleasesKey(t *testing.T) {
+	withRedis(t)
+
+	info := &grpc.UnaryServerInfo{FullMethod: ledgerv1.LedgerService_AddTransaction_FullMethodName}
+	calls := 0
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls++
+		if calls == 1 {
+			return nil, status.Error(codes.Internal, "db down")
+		}
+		return &ledgerv1.Transaction{Category: "food"}, nil
+	}
+
+	req := &ledgerv1.CreateTransactionRequest{Amount: 10, Category: "food", Date: "2025-01-01"}
+
+	_, err := IdempotencyInterceptor(idempotentCtx("k-1"), req, info, handler)
+	require.Error(t, err)
+
+	_, err = IdempotencyInterceptor(idempotentCtx("k-1"), req, info, handler)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestIdempotencyInterceptor_ReadsPassThrough(t *testing.T) {
+	withRedis(t)
+
+	info := &grpc.UnaryServerInfo{FullMethod: ledgerv1.LedgerService_ListBudgets_FullMethodName}
+	calls := 0
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls++
+		return &ledgerv1.ListBudgetsResponse{}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := IdempotencyInterceptor(idempotentCtx("k-1"), &emptypb.Empty{}, info, handler)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, calls)
+}
+
+func TestIdempotencyInterceptor_PerLedger(t *testing.T) {
+	withRedis(t)
+
+	info := &grpc.UnaryServerInfo{FullMethod: ledgerv1.LedgerService_SetBudget_FullMethodName}
+	calls := 0
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls++
+		return &ledgerv1.Budget{Category: "food"}, nil
+	}
+
+	req := &ledgerv1.CreateBudgetRequest{Category: "food", Limit: 100}
+
+	_, err := IdempotencyInterceptor(idempotentCtx("k-1"), req, info, handler)
+	require.NoError(t, err)
+
+	// the same key for another ledger is another request
+	md, _ := metadata.FromIncomingContext(idempotentCtx("k-1"))
+	md = md.Copy()
+	md.Set("ledger_id", "ledger-2")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	_, err = IdempotencyInterceptor(ctx, req, info, handler)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestIdempotencyInterceptor_KeepsLockWhileRunning(t *testing.T) {
+	ttl := idempotencyLockTTL
+	idempotencyLockTTL = 30 * time.Millisecond
+	t.Cleanup(func() { idempotencyLockTTL = ttl })
+
+	mr := miniredis.RunT(t)
+	cache.Client = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = cache.Client.Close()
+		cache.Client = nil
+	})
+
+	info := &grpc.UnaryServerInfo{FullMethod: ledgerv1.LedgerService_BulkAddTransactions_FullMethodName}
+	key := "idempotency:ledger:user-1:user-1:" + info.FullMethod + ":k-1"
+	req := &ledgerv1.CreateBudgetRequest{Category: "food", Limit: 100}
+
+	handler := func(ctx context.Context, _ any) (any, error) {
+		// a handler outliving the lock TTL keeps its key
+		mr.FastForward(20 * time.Millisecond)
+		require.Eventually(t, func() bool {
+			return mr.TTL(key) == idempotencyLockTTL
+		}, time.Second, 5*time.Millisecond)
+
+		_, err := IdempotencyInterceptor(idempotentCtx("k-1"), req, info, nil)
+		require.Equal(t, codes.Aborted, status.Code(err))
+		return &emptypb.Empty{}, nil
+	}
+
+	_, err := IdempotencyInterceptor(idempotentCtx("k-1"), req, info, handler)
+	require.NoError(t, err)
+}
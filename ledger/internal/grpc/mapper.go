@@ -24,10 +24,32 @@ func mapDomainError(err error) error {
 		return status.Error(codes.FailedPrecondition, bErr.Error())
 	}
 
-	if errors.Is(err, domain.ErrBudgetNotFound) {
+	if errors.Is(err, domain.ErrBudgetNotFound) ||
+		errors.Is(err, domain.ErrInvalidBackup) {
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
 
+	if errors.Is(err, domain.ErrDuplicateTransaction) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+
+	if errors.Is(err, domain.ErrImportProfileNotFound) ||
+		errors.Is(err, domain.ErrImportJobNotFound) ||
+		errors.Is(err, domain.ErrLedgerNotFound) ||
+		errors.Is(err, domain.ErrMemberNotFound) ||
+		errors.Is(err, domain.ErrInvitationNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	if errors.Is(err, domain.ErrLedgerForbidden) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if errors.Is(err, domain.ErrImportJobFinished) ||
+		errors.Is(err, domain.ErrLedgerOwner) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
 	if errors.Is(err, context.DeadlineExceeded) {
 		return status.Error(codes.DeadlineExceeded, err.Error())
 	}
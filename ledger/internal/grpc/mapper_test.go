@@ -3,6 +3,7 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"ledger/internal/domain"
@@ -52,6 +53,45 @@ func TestMapDomainError_BudgetNotFound(t *testing.T) {
 	require.Equal(t, codes.InvalidArgument, st.Code())
 }
 
+func TestMapDomainError_DuplicateTransaction(t *testing.T) {
+	err := mapDomainError(domain.ErrDuplicateTransaction)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.AlreadyExists, st.Code())
+}
+
+func TestMapDomainError_ImportProfileNotFound(t *testing.T) {
+	err := mapDomainError(domain.ErrImportProfileNotFound)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.NotFound, st.Code())
+}
+
+func TestMapDomainError_Ledger(t *testing.T) {
+	cases := map[error]codes.Code{
+		domain.ErrLedgerNotFound:     codes.NotFound,
+		domain.ErrLedgerForbidden:    codes.PermissionDenied,
+		domain.ErrLedgerOwner:        codes.FailedPrecondition,
+		domain.ErrInvitationNotFound: codes.NotFound,
+	}
+
+	for src, code := range cases {
+		st, ok := status.FromError(mapDomainError(src))
+		require.True(t, ok)
+		require.Equal(t, code, st.Code(), src.Error())
+	}
+}
+
+func TestMapDomainError_InvalidBackup(t *testing.T) {
+	err := mapDomainError(fmt.Errorf("%w: checksum mismatch for budgets.json", domain.ErrInvalidBackup))
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+}
+
 func TestMapDomainError_ContextDeadlineExceeded(t *testing.T) {
 	err := mapDomainError(context.DeadlineExceeded)
 
@@ -1,12 +1,18 @@
 package grpc
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"io"
 	domain2 "ledger/internal/domain"
 	"ledger/internal/service"
 	ledgerv1 "ledger/ledger/v1"
+	"os"
+	"sort"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	_ "github.com/shopspring/decimal"
 	"google.golang.org/grpc/codes"
@@ -38,6 +44,7 @@ func (s *Server) AddTransaction(
 		Category:    req.Category,
 		Description: req.Description,
 		Date:        date,
+		ExternalID:  req.ExternalId,
 	}
 
 	if err := s.service.AddTransaction(ctx, tx); err != nil {
@@ -64,17 +71,48 @@ func (s *Server) ListTransactions(
 
 	res := &ledgerv1.ListTransactionsResponse{}
 	for _, t := range txs {
-		res.Transactions = append(res.Transactions, &ledgerv1.Transaction{
-			Amount:      t.Amount.InexactFloat64(),
-			Category:    t.Category,
-			Description: t.Description,
-			Date:        t.Date.Format("2006-01-02"),
-		})
+		res.Transactions = append(res.Transactions, transactionToProto(t))
 	}
 
 	return res, nil
 }
 
+func (s *Server) ExportTransactions(
+	req *ledgerv1.ExportTransactionsRequest,
+	stream ledgerv1.LedgerService_ExportTransactionsServer,
+) error {
+
+	var f domain2.TransactionFilter
+
+	if req.From != "" {
+		from, err := time.Parse("2006-01-02", req.From)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid from")
+		}
+		f.From = from
+	}
+
+	if req.To != "" {
+		to, err := time.Parse("2006-01-02", req.To)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid to")
+		}
+		f.To = to
+	}
+
+	f.Categories = req.Categories
+	f.Source = req.Source
+
+	err := s.service.ExportTransactions(stream.Context(), f, func(t domain2.Transaction) error {
+		return stream.Send(transactionToProto(t))
+	})
+	if err != nil {
+		return mapDomainError(err)
+	}
+
+	return nil
+}
+
 func (s *Server) SetBudget(
 	ctx context.Context,
 	req *ledgerv1.CreateBudgetRequest,
@@ -168,17 +206,32 @@ func (s *Server) BulkAddTransactions(
 			Category:    t.Category,
 			Description: t.Description,
 			Date:        date,
+			ExternalID:  t.ExternalId,
+			Source:      req.Source,
 		})
 	}
 
-	res, err := s.service.BulkAddTransactions(ctx, txs, int(req.Workers))
+	var (
+		res *domain2.BulkImportResult
+		err error
+	)
+	if req.Atomic {
+		res, err = s.service.BulkAddTransactionsAtomic(ctx, txs)
+	} else {
+		res, err = s.service.BulkAddTransactions(ctx, txs, int(req.Workers))
+	}
 	if err != nil {
 		return nil, mapDomainError(err)
 	}
 
 	out := &ledgerv1.BulkAddTransactionsResponse{
-		Accepted: res.Accepted,
-		Rejected: res.Rejected,
+		Accepted:   res.Accepted,
+		Rejected:   res.Rejected,
+		Duplicates: res.Duplicates,
+	}
+
+	for _, i := range res.DuplicateIndexes {
+		out.DuplicateIndexes = append(out.DuplicateIndexes, int32(i))
 	}
 
 	for _, e := range res.Errors {
@@ -190,3 +243,624 @@ func (s *Server) BulkAddTransactions(
 
 	return out, nil
 }
+
+// importBatchSize is how many streamed rows are stored with one statement.
+const importBatchSize = 1000
+
+// ImportTransactions stores a streamed upload in batches and reports
+// progress after each one, so memory use does not grow with the file.
+func (s *Server) ImportTransactions(
+	stream ledgerv1.LedgerService_ImportTransactionsServer,
+) error {
+
+	ctx := stream.Context()
+
+	var (
+		imp      service.Import
+		progress ledgerv1.ImportProgress
+		batch    []domain2.Transaction
+		batchIdx []int
+		errs     []*ledgerv1.BulkError
+	)
+
+	flush := func(done bool) error {
+		var dups []int32
+
+		if len(batch) > 0 {
+			res, err := imp.AddBatch(ctx, batch)
+			if err != nil {
+				return mapDomainError(err)
+			}
+
+			progress.Accepted += res.Accepted
+			progress.Rejected += res.Rejected
+			progress.Duplicates += res.Duplicates
+
+			for _, i := range res.DuplicateIndexes {
+				dups = append(dups, int32(batchIdx[i]))
+			}
+			for _, e := range res.Errors {
+				errs = append(errs, &ledgerv1.BulkError{
+					Index: int32(batchIdx[e.Index]),
+					Error: e.Error,
+				})
+			}
+			sort.Slice(errs, func(i, j int) bool {
+				return errs[i].Index < errs[j].Index
+			})
+		}
+
+		err := stream.Send(&ledgerv1.ImportProgress{
+			Received:         progress.Received,
+			Accepted:         progress.Accepted,
+			Rejected:         progress.Rejected,
+			Duplicates:       progress.Duplicates,
+			Errors:           errs,
+			DuplicateIndexes: dups,
+			Done:             done,
+		})
+
+		batch, batchIdx, errs = batch[:0], batchIdx[:0], nil
+		return err
+	}
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if imp == nil {
+			if imp, err = s.service.StartImport(ctx, req.Source); err != nil {
+				return mapDomainError(err)
+			}
+		}
+
+		for _, t := range req.Transactions {
+			index := int(progress.Received)
+			progress.Received++
+
+			date, err := time.Parse("2006-01-02", t.Date)
+			if err != nil {
+				progress.Rejected++
+				errs = append(errs, &ledgerv1.BulkError{
+					Index: int32(index),
+					Error: "invalid date",
+				})
+			} else {
+				batch = append(batch, domain2.Transaction{
+					Amount:      decimal.NewFromFloat(t.Amount),
+					Category:    t.Category,
+					Description: t.Description,
+					Date:        date,
+					ExternalID:  t.ExternalId,
+				})
+				batchIdx = append(batchIdx, index)
+			}
+
+			if len(batch)+len(errs) >= importBatchSize {
+				if err := flush(false); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return flush(true)
+}
+
+// CreateImportJob stores an uploaded statement as a background job and
+// returns it queued; the worker stores the transactions later.
+func (s *Server) CreateImportJob(
+	stream ledgerv1.LedgerService_CreateImportJobServer,
+) error {
+
+	ctx := stream.Context()
+
+	var upload service.ImportJobUpload
+
+	abort := func(err error, reason string) error {
+		if upload != nil {
+			_ = upload.Abort(context.WithoutCancel(ctx), reason)
+		}
+		return err
+	}
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return abort(err, "upload interrupted")
+		}
+
+		if upload == nil {
+			upload, err = s.service.StartImportJob(ctx, chunk.Source, chunk.FileName)
+			if err != nil {
+				return mapDomainError(err)
+			}
+		}
+
+		rows := make([]domain2.ImportJobRow, 0, len(chunk.Rows))
+		for _, r := range chunk.Rows {
+			t := r.GetTransaction()
+			date, err := time.Parse("2006-01-02", t.GetDate())
+			if err != nil {
+				return abort(status.Error(codes.InvalidArgument, "invalid date"), "invalid date")
+			}
+
+			rows = append(rows, domain2.ImportJobRow{
+				Line: int(r.Line),
+				Transaction: domain2.Transaction{
+					Amount:      decimal.NewFromFloat(t.Amount),
+					Category:    t.Category,
+					Description: t.Description,
+					Date:        date,
+					ExternalID:  t.ExternalId,
+				},
+			})
+		}
+
+		if err := upload.AddRows(ctx, rows); err != nil {
+			return abort(mapDomainError(err), "upload failed")
+		}
+	}
+
+	if upload == nil {
+		return status.Error(codes.InvalidArgument, "empty upload")
+	}
+
+	job, err := upload.Finish(ctx)
+	if err != nil {
+		return abort(mapDomainError(err), "upload failed")
+	}
+
+	return stream.SendAndClose(importJobToProto(*job))
+}
+
+func (s *Server) GetImportJob(
+	ctx context.Context,
+	req *ledgerv1.ImportJobRequest,
+) (*ledgerv1.ImportJob, error) {
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid job id")
+	}
+
+	job, err := s.service.GetImportJob(ctx, id)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return importJobToProto(*job), nil
+}
+
+func (s *Server) CancelImportJob(
+	ctx context.Context,
+	req *ledgerv1.ImportJobRequest,
+) (*ledgerv1.ImportJob, error) {
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid job id")
+	}
+
+	job, err := s.service.CancelImportJob(ctx, id)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return importJobToProto(*job), nil
+}
+
+func (s *Server) SaveImportProfile(
+	ctx context.Context,
+	req *ledgerv1.ImportProfile,
+) (*ledgerv1.ImportProfile, error) {
+
+	p, err := s.service.SaveImportProfile(ctx, importProfileFromProto(req))
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return importProfileToProto(*p), nil
+}
+
+func (s *Server) GetImportProfile(
+	ctx context.Context,
+	req *ledgerv1.ImportProfileRequest,
+) (*ledgerv1.ImportProfile, error) {
+
+	p, err := s.service.GetImportProfile(ctx, req.Name)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return importProfileToProto(*p), nil
+}
+
+func (s *Server) ListImportProfiles(
+	ctx context.Context,
+	_ *emptypb.Empty,
+) (*ledgerv1.ListImportProfilesResponse, error) {
+
+	profiles, err := s.service.ListImportProfiles(ctx)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	res := &ledgerv1.ListImportProfilesResponse{}
+	for _, p := range profiles {
+		res.Profiles = append(res.Profiles, importProfileToProto(p))
+	}
+
+	return res, nil
+}
+
+func (s *Server) DeleteImportProfile(
+	ctx context.Context,
+	req *ledgerv1.ImportProfileRequest,
+) (*emptypb.Empty, error) {
+
+	if err := s.service.DeleteImportProfile(ctx, req.Name); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func importProfileFromProto(p *ledgerv1.ImportProfile) domain2.ImportProfile {
+	return domain2.ImportProfile{
+		Name:              p.Name,
+		AmountColumn:      p.AmountColumn,
+		DateColumn:        p.DateColumn,
+		CategoryColumn:    p.CategoryColumn,
+		DescriptionColumn: p.DescriptionColumn,
+		ExternalIDColumn:  p.ExternalIdColumn,
+		Delimiter:         p.Delimiter,
+		DecimalComma:      p.DecimalComma,
+		DateFormat:        p.DateFormat,
+		Encoding:          p.Encoding,
+		SignConvention:    p.SignConvention,
+	}
+}
+
+func importProfileToProto(p domain2.ImportProfile) *ledgerv1.ImportProfile {
+	return &ledgerv1.ImportProfile{
+		Name:              p.Name,
+		AmountColumn:      p.AmountColumn,
+		DateColumn:        p.DateColumn,
+		CategoryColumn:    p.CategoryColumn,
+		DescriptionColumn: p.DescriptionColumn,
+		ExternalIdColumn:  p.ExternalIDColumn,
+		Delimiter:         p.Delimiter,
+		DecimalComma:      p.DecimalComma,
+		DateFormat:        p.DateFormat,
+		Encoding:          p.Encoding,
+		SignConvention:    p.SignConvention,
+	}
+}
+
+func (s *Server) FindDuplicates(
+	ctx context.Context,
+	_ *emptypb.Empty,
+) (*ledgerv1.FindDuplicatesResponse, error) {
+
+	groups, err := s.service.FindDuplicates(ctx)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	res := &ledgerv1.FindDuplicatesResponse{}
+	for _, g := range groups {
+		group := &ledgerv1.DuplicateGroup{}
+		for _, t := range g.Transactions {
+			group.Transactions = append(group.Transactions, transactionToProto(t))
+		}
+		res.Groups = append(res.Groups, group)
+	}
+
+	return res, nil
+}
+
+// backupChunkSize is the most archive data sent in one BackupChunk.
+const backupChunkSize = 64 << 10
+
+// maxBackupSize bounds an uploaded archive.
+const maxBackupSize = 512 << 20
+
+// ExportBackup streams the user's backup archive as it is written.
+func (s *Server) ExportBackup(
+	_ *emptypb.Empty,
+	stream ledgerv1.LedgerService_ExportBackupServer,
+) error {
+
+	w := bufio.NewWriterSize(backupWriter{stream: stream}, backupChunkSize)
+
+	if err := s.service.ExportBackup(stream.Context(), w); err != nil {
+		return mapDomainError(err)
+	}
+	if err := w.Flush(); err != nil {
+		return mapDomainError(err)
+	}
+
+	return nil
+}
+
+// backupWriter sends what is written as BackupChunks.
+type backupWriter struct {
+	stream ledgerv1.LedgerService_ExportBackupServer
+}
+
+func (w backupWriter) Write(p []byte) (int, error) {
+	for sent := 0; sent < len(p); sent += backupChunkSize {
+		chunk := p[sent:min(sent+backupChunkSize, len(p))]
+		if err := w.stream.Send(&ledgerv1.BackupChunk{Data: chunk}); err != nil {
+			return sent, err
+		}
+	}
+	return len(p), nil
+}
+
+// RestoreBackup spools the uploaded archive to a temporary file, since
+// the zip directory sits at its end, and restores it.
+func (s *Server) RestoreBackup(
+	stream ledgerv1.LedgerService_RestoreBackupServer,
+) error {
+
+	f, err := os.CreateTemp("", "ledger-restore-*.zip")
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var (
+		mode string
+		size int64
+	)
+	for first := true; ; first = false {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if first {
+			mode = chunk.Mode
+		}
+
+		size += int64(len(chunk.Data))
+		if size > maxBackupSize {
+			return status.Error(codes.InvalidArgument, "backup is too large")
+		}
+		if _, err := f.Write(chunk.Data); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	if size == 0 {
+		return status.Error(codes.InvalidArgument, "empty backup")
+	}
+
+	res, err := s.service.RestoreBackup(stream.Context(), f, size, mode)
+	if err != nil {
+		return mapDomainError(err)
+	}
+
+	return stream.SendAndClose(&ledgerv1.RestoreBackupResponse{
+		Budgets:        res.Budgets,
+		Transactions:   res.Transactions,
+		ImportProfiles: res.ImportProfiles,
+		Duplicates:     res.Duplicates,
+	})
+}
+
+func transactionToProto(t domain2.Transaction) *ledgerv1.Transaction {
+	return &ledgerv1.Transaction{
+		Id:          t.ID,
+		Amount:      t.Amount.InexactFloat64(),
+		Category:    t.Category,
+		Description: t.Description,
+		Date:        t.Date.Format("2006-01-02"),
+		ExternalId:  t.ExternalID,
+	}
+}
+
+func importJobToProto(j domain2.ImportJob) *ledgerv1.ImportJob {
+	out := &ledgerv1.ImportJob{
+		Id:         j.ID.String(),
+		Status:     j.Status,
+		Source:     j.Source,
+		FileName:   j.FileName,
+		Total:      j.Total,
+		Processed:  j.Processed,
+		Accepted:   j.Accepted,
+		Rejected:   j.Rejected,
+		Duplicates: j.Duplicates,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  j.UpdatedAt.Format(time.RFC3339),
+	}
+
+	for _, e := range j.Errors {
+		out.Errors = append(out.Errors, &ledgerv1.ImportJobError{
+			Index: int32(e.Index),
+			Line:  int32(e.Line),
+			Error: e.Error,
+		})
+	}
+
+	return out
+}
+
+func (s *Server) CreateLedger(
+	ctx context.Context,
+	req *ledgerv1.CreateLedgerRequest,
+) (*ledgerv1.Ledger, error) {
+
+	l, err := s.service.CreateLedger(ctx, req.Name)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return ledgerToProto(*l), nil
+}
+
+func (s *Server) ListLedgers(
+	ctx context.Context,
+	_ *emptypb.Empty,
+) (*ledgerv1.ListLedgersResponse, error) {
+
+	ledgers, err := s.service.ListLedgers(ctx)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	resp := &ledgerv1.ListLedgersResponse{}
+	for _, l := range ledgers {
+		resp.Ledgers = append(resp.Ledgers, ledgerToProto(l))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) CreateInvitation(
+	ctx context.Context,
+	req *ledgerv1.CreateInvitationRequest,
+) (*ledgerv1.Invitation, error) {
+
+	inv, err := s.service.CreateInvitation(ctx, req.Role)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &ledgerv1.Invitation{
+		Token:     inv.Token,
+		Role:      inv.Role,
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *Server) AcceptInvitation(
+	ctx context.Context,
+	req *ledgerv1.AcceptInvitationRequest,
+) (*ledgerv1.Ledger, error) {
+
+	l, err := s.service.AcceptInvitation(ctx, req.Token)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return ledgerToProto(*l), nil
+}
+
+func (s *Server) ListMembers(
+	ctx context.Context,
+	_ *emptypb.Empty,
+) (*ledgerv1.ListMembersResponse, error) {
+
+	members, err := s.service.ListMembers(ctx)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	resp := &ledgerv1.ListMembersResponse{}
+	for _, m := range members {
+		resp.Members = append(resp.Members, memberToProto(m))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) SetMemberRole(
+	ctx context.Context,
+	req *ledgerv1.SetMemberRoleRequest,
+) (*ledgerv1.Member, error) {
+
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	m, err := s.service.SetMemberRole(ctx, userID, req.Role)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return memberToProto(*m), nil
+}
+
+func (s *Server) RemoveMember(
+	ctx context.Context,
+	req *ledgerv1.MemberRequest,
+) (*emptypb.Empty, error) {
+
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+
+	if err := s.service.RemoveMember(ctx, userID); err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetMemberSpending(
+	ctx context.Context,
+	req *ledgerv1.ReportSummaryRequest,
+) (*ledgerv1.MemberSpendingResponse, error) {
+
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid from")
+	}
+
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid to")
+	}
+
+	spending, err := s.service.GetMemberSpending(ctx, from, to)
+	if err != nil {
+		return nil, mapDomainError(err)
+	}
+
+	resp := &ledgerv1.MemberSpendingResponse{}
+	for _, m := range spending {
+		resp.Members = append(resp.Members, &ledgerv1.MemberSpending{
+			UserId:       m.UserID.String(),
+			Transactions: m.Transactions,
+			Total:        m.Total.InexactFloat64(),
+		})
+	}
+
+	return resp, nil
+}
+
+func ledgerToProto(l domain2.Ledger) *ledgerv1.Ledger {
+	return &ledgerv1.Ledger{
+		Id:        l.ID.String(),
+		Name:      l.Name,
+		OwnerId:   l.OwnerID.String(),
+		Role:      l.Role,
+		CreatedAt: l.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func memberToProto(m domain2.Member) *ledgerv1.Member {
+	return &ledgerv1.Member{
+		UserId:   m.UserID.String(),
+		Role:     m.Role,
+		JoinedAt: m.JoinedAt.Format(time.RFC3339),
+	}
+}
@@ -1,15 +1,21 @@
 package grpc
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"testing"
 	"time"
 
 	"ledger/internal/domain"
+	"ledger/internal/service"
 	ledgerv1 "ledger/ledger/v1"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -22,6 +28,20 @@ type mockLedgerService struct {
 	listBudgetsFn func(ctx context.Context) ([]domain.Budget, error)
 	reportFn      func(ctx context.Context, from, to time.Time) ([]domain.ReportSummary, error)
 	bulkFn        func(ctx context.Context, txs []domain.Transaction, workers int) (*domain.BulkImportResult, error)
+	saveProfileFn func(ctx context.Context, p domain.ImportProfile) (*domain.ImportProfile, error)
+	getProfileFn  func(ctx context.Context, name string) (*domain.ImportProfile, error)
+	duplicatesFn  func(ctx context.Context) ([]domain.DuplicateGroup, error)
+	atomicFn      func(ctx context.Context, txs []domain.Transaction) (*domain.BulkImportResult, error)
+	importFn      func(ctx context.Context, source string) (service.Import, error)
+	exportFn      func(ctx context.Context, f domain.TransactionFilter, fn func(domain.Transaction) error) error
+	startJobFn    func(ctx context.Context, source, fileName string) (service.ImportJobUpload, error)
+	getJobFn      func(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error)
+	cancelJobFn   func(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error)
+	backupFn      func(ctx context.Context, w io.Writer) error
+	restoreFn     func(ctx context.Context, r io.ReaderAt, size int64, mode string) (*domain.RestoreResult, error)
+	acceptFn      func(ctx context.Context, token string) (*domain.Ledger, error)
+	setRoleFn     func(ctx context.Context, userID uuid.UUID, role string) (*domain.Member, error)
+	spendingFn    func(ctx context.Context, from, to time.Time) ([]domain.MemberSpending, error)
 }
 
 func (m *mockLedgerService) AddTransaction(ctx context.Context, tx domain.Transaction) error {
@@ -52,6 +72,99 @@ func (m *mockLedgerService) BulkAddTransactions(
 	return m.bulkFn(ctx, txs, workers)
 }
 
+func (m *mockLedgerService) SaveImportProfile(ctx context.Context, p domain.ImportProfile) (*domain.ImportProfile, error) {
+	return m.saveProfileFn(ctx, p)
+}
+
+func (m *mockLedgerService) GetImportProfile(ctx context.Context, name string) (*domain.ImportProfile, error) {
+	return m.getProfileFn(ctx, name)
+}
+
+func (m *mockLedgerService) ListImportProfiles(ctx context.Context) ([]domain.ImportProfile, error) {
+	return nil, nil
+}
+
+func (m *mockLedgerService) DeleteImportProfile(ctx context.Context, name string) error {
+	return nil
+}
+
+func (m *mockLedgerService) BulkAddTransactionsAtomic(
+	ctx context.Context,
+	txs []domain.Transaction,
+) (*domain.BulkImportResult, error) {
+	return m.atomicFn(ctx, txs)
+}
+
+func (m *mockLedgerService) StartImport(ctx context.Context, source string) (service.Import, error) {
+	return m.importFn(ctx, source)
+}
+
+func (m *mockLedgerService) ExportTransactions(ctx context.Context, f domain.TransactionFilter, fn func(domain.Transaction) error) error {
+	return m.exportFn(ctx, f, fn)
+}
+
+func (m *mockLedgerService) StartImportJob(ctx context.Context, source, fileName string) (service.ImportJobUpload, error) {
+	return m.startJobFn(ctx, source, fileName)
+}
+
+func (m *mockLedgerService) GetImportJob(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error) {
+	return m.getJobFn(ctx, id)
+}
+
+func (m *mockLedgerService) CancelImportJob(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error) {
+	return m.cancelJobFn(ctx, id)
+}
+
+func (m *mockLedgerService) RunImportJobs(ctx context.Context) {}
+
+func (m *mockLedgerService) EraseAccount(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
+func (m *mockLedgerService) ExportBackup(ctx context.Context, w io.Writer) error {
+	return m.backupFn(ctx, w)
+}
+
+func (m *mockLedgerService) RestoreBackup(ctx context.Context, r io.ReaderAt, size int64, mode string) (*domain.RestoreResult, error) {
+	return m.restoreFn(ctx, r, size, mode)
+}
+
+func (m *mockLedgerService) FindDuplicates(ctx context.Context) ([]domain.DuplicateGroup, error) {
+	return m.duplicatesFn(ctx)
+}
+
+func (m *mockLedgerService) CreateLedger(ctx context.Context, name string) (*domain.Ledger, error) {
+	return nil, nil
+}
+
+func (m *mockLedgerService) ListLedgers(ctx context.Context) ([]domain.Ledger, error) {
+	return nil, nil
+}
+
+func (m *mockLedgerService) CreateInvitation(ctx context.Context, role string) (*domain.Invitation, error) {
+	return nil, nil
+}
+
+func (m *mockLedgerService) AcceptInvitation(ctx context.Context, token string) (*domain.Ledger, error) {
+	return m.acceptFn(ctx, token)
+}
+
+func (m *mockLedgerService) ListMembers(ctx context.Context) ([]domain.Member, error) {
+	return nil, nil
+}
+
+func (m *mockLedgerService) SetMemberRole(ctx context.Context, userID uuid.UUID, role string) (*domain.Member, error) {
+	return m.setRoleFn(ctx, userID, role)
+}
+
+func (m *mockLedgerService) RemoveMember(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
+func (m *mockLedgerService) GetMemberSpending(ctx context.Context, from, to time.Time) ([]domain.MemberSpending, error) {
+	return m.spendingFn(ctx, from, to)
+}
+
 func TestAddTransaction_OK(t *testing.T) {
 	svc := &mockLedgerService{
 		addTxFn: func(ctx context.Context, tx domain.Transaction) error {
@@ -161,10 +274,13 @@ func TestBulkAddTransactions(t *testing.T) {
 		bulkFn: func(ctx context.Context, txs []domain.Transaction, workers int) (*domain.BulkImportResult, error) {
 			require.Len(t, txs, 2)
 			require.Equal(t, 2, workers)
+			require.Equal(t, "csv", txs[0].Source)
 
 			return &domain.BulkImportResult{
-				Accepted: 2,
-				Rejected: 0,
+				Accepted:         1,
+				Rejected:         0,
+				Duplicates:       1,
+				DuplicateIndexes: []int{1},
 			}, nil
 		},
 	}
@@ -173,6 +289,7 @@ func TestBulkAddTransactions(t *testing.T) {
 
 	resp, err := server.BulkAddTransactions(context.Background(), &ledgerv1.BulkAddTransactionsRequest{
 		Workers: 2,
+		Source:  "csv",
 		Transactions: []*ledgerv1.CreateTransactionRequest{
 			{
 				Amount:   10,
@@ -188,6 +305,585 @@ func TestBulkAddTransactions(t *testing.T) {
 	})
 
 	require.NoError(t, err)
-	require.Equal(t, int64(2), resp.Accepted)
+	require.Equal(t, int64(1), resp.Accepted)
 	require.Equal(t, int64(0), resp.Rejected)
+	require.Equal(t, int64(1), resp.Duplicates)
+	require.Equal(t, []int32{1}, resp.DuplicateIndexes)
+}
+
+func TestBulkAddTransactions_Atomic(t *testing.T) {
+	svc := &mockLedgerService{
+		atomicFn: func(ctx context.Context, txs []domain.Transaction) (*domain.BulkImportResult, error) {
+			require.Len(t, txs, 2)
+			return &domain.BulkImportResult{
+				Rejected: 2,
+				Errors: []domain.BulkImportError{
+					{Index: 1, Error: "budget exceeded"},
+				},
+			}, nil
+		},
+	}
+
+	server := NewServer(svc)
+
+	resp, err := server.BulkAddTransactions(context.Background(), &ledgerv1.BulkAddTransactionsRequest{
+		Atomic: true,
+		Transactions: []*ledgerv1.CreateTransactionRequest{
+			{Amount: 10, Category: "food", Date: "2025-01-01"},
+			{Amount: 20, Category: "food", Date: "2025-01-02"},
+		},
+	})
+
+	require.NoError(t, err)
+	require.Zero(t, resp.Accepted)
+	require.Equal(t, int64(2), resp.Rejected)
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, int32(1), resp.Errors[0].Index)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	svc := &mockLedgerService{
+		duplicatesFn: func(ctx context.Context) ([]domain.DuplicateGroup, error) {
+			return []domain.DuplicateGroup{
+				{Transactions: []domain.Transaction{
+					{ID: 1, Amount: decimal.NewFromInt(5), Category: "food", Date: day},
+					{ID: 2, Amount: decimal.NewFromInt(5), Category: "food", Date: day, ExternalID: "F1"},
+				}},
+			}, nil
+		},
+	}
+
+	server := NewServer(svc)
+
+	resp, err := server.FindDuplicates(context.Background(), &emptypb.Empty{})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Groups, 1)
+	require.Len(t, resp.Groups[0].Transactions, 2)
+	require.Equal(t, int32(2), resp.Groups[0].Transactions[1].Id)
+	require.Equal(t, "F1", resp.Groups[0].Transactions[1].ExternalId)
+	require.Equal(t, "2025-01-15", resp.Groups[0].Transactions[0].Date)
+}
+
+func TestSaveImportProfile(t *testing.T) {
+	svc := &mockLedgerService{
+		saveProfileFn: func(ctx context.Context, p domain.ImportProfile) (*domain.ImportProfile, error) {
+			require.Equal(t, "Сумма", p.AmountColumn)
+			require.Equal(t, "Ref", p.ExternalIDColumn)
+			require.True(t, p.DecimalComma)
+			p.Encoding = "utf-8"
+			return &p, nil
+		},
+	}
+
+	server := NewServer(svc)
+
+	res, err := server.SaveImportProfile(context.Background(), &ledgerv1.ImportProfile{
+		Name:             "bank",
+		AmountColumn:     "Сумма",
+		DateColumn:       "Дата",
+		ExternalIdColumn: "Ref",
+		Delimiter:        ";",
+		DecimalComma:     true,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "bank", res.Name)
+	require.Equal(t, "utf-8", res.Encoding)
+	require.Equal(t, ";", res.Delimiter)
+}
+
+func TestGetImportProfile_NotFound(t *testing.T) {
+	svc := &mockLedgerService{
+		getProfileFn: func(ctx context.Context, name string) (*domain.ImportProfile, error) {
+			return nil, domain.ErrImportProfileNotFound
+		},
+	}
+
+	server := NewServer(svc)
+
+	_, err := server.GetImportProfile(context.Background(), &ledgerv1.ImportProfileRequest{Name: "missing"})
+
+	st, _ := status.FromError(err)
+	require.Equal(t, codes.NotFound, st.Code())
+}
+
+type mockImport struct {
+	batches [][]domain.Transaction
+}
+
+func (m *mockImport) AddBatch(ctx context.Context, txs []domain.Transaction) (*domain.BulkImportResult, error) {
+	m.batches = append(m.batches, append([]domain.Transaction(nil), txs...))
+
+	// the first row of every batch is a duplicate, the second is rejected
+	res := &domain.BulkImportResult{
+		Accepted:         int64(len(txs) - 2),
+		Rejected:         1,
+		Duplicates:       1,
+		DuplicateIndexes: []int{0},
+		Errors:           []domain.BulkImportError{{Index: 1, Error: "budget exceeded"}},
+	}
+	return res, nil
+}
+
+type importStream struct {
+	grpc.ServerStream
+	requests []*ledgerv1.ImportTransactionsRequest
+	progress []*ledgerv1.ImportProgress
+}
+
+func (s *importStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *importStream) Recv() (*ledgerv1.ImportTransactionsRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *importStream) Send(p *ledgerv1.ImportProgress) error {
+	s.progress = append(s.progress, p)
+	return nil
+}
+
+func TestImportTransactions_Stream(t *testing.T) {
+	imp := &mockImport{}
+
+	svc := &mockLedgerService{
+		importFn: func(ctx context.Context, source string) (service.Import, error) {
+			require.Equal(t, "csv", source)
+			return imp, nil
+		},
+	}
+
+	server := NewServer(svc)
+
+	rows := make([]*ledgerv1.CreateTransactionRequest, 0, importBatchSize+10)
+	for i := 0; i < importBatchSize+10; i++ {
+		rows = append(rows, &ledgerv1.CreateTransactionRequest{
+			Amount:   1,
+			Category: "food",
+			Date:     fmt.Sprintf("2025-01-%02d", i%28+1),
+		})
+	}
+	rows[2].Date = "bad"
+
+	stream := &importStream{
+		requests: []*ledgerv1.ImportTransactionsRequest{
+			{Source: "csv", Transactions: rows[:500]},
+			{Transactions: rows[500:]},
+		},
+	}
+
+	require.NoError(t, server.ImportTransactions(stream))
+
+	require.Len(t, imp.batches, 2)
+	require.Len(t, imp.batches[0], importBatchSize-1)
+	require.Len(t, imp.batches[1], 10)
+
+	require.Len(t, stream.progress, 2)
+
+	first := stream.progress[0]
+	require.False(t, first.Done)
+	require.Equal(t, int64(importBatchSize), first.Received)
+	require.Equal(t, []int32{0}, first.DuplicateIndexes)
+	require.Len(t, first.Errors, 2)
+	require.Equal(t, int32(1), first.Errors[0].Index)
+	require.Equal(t, int32(2), first.Errors[1].Index)
+	require.Equal(t, "invalid date", first.Errors[1].Error)
+
+	last := stream.progress[1]
+	require.True(t, last.Done)
+	require.Equal(t, int64(importBatchSize+10), last.Received)
+	require.Equal(t, int64(3), last.Rejected)
+	require.Equal(t, int64(2), last.Duplicates)
+	require.Equal(t, int64(importBatchSize+10-5), last.Accepted)
+	require.Equal(t, []int32{importBatchSize}, last.DuplicateIndexes)
+}
+
+func TestImportTransactions_Empty(t *testing.T) {
+	server := NewServer(&mockLedgerService{})
+
+	stream := &importStream{}
+	require.NoError(t, server.ImportTransactions(stream))
+
+	require.Len(t, stream.progress, 1)
+	require.True(t, stream.progress[0].Done)
+	require.Zero(t, stream.progress[0].Received)
+}
+
+type mockJobUpload struct {
+	rows    []domain.ImportJobRow
+	aborted string
+}
+
+func (m *mockJobUpload) AddRows(ctx context.Context, rows []domain.ImportJobRow) error {
+	m.rows = append(m.rows, rows...)
+	return nil
+}
+
+func (m *mockJobUpload) Finish(ctx context.Context) (*domain.ImportJob, error) {
+	return &domain.ImportJob{
+		ID:     uuid.MustParse("7a1b2c3d-0000-4000-8000-000000000001"),
+		Status: domain.ImportJobQueued,
+		Source: "csv",
+		Total:  int64(len(m.rows)),
+	}, nil
+}
+
+func (m *mockJobUpload) Abort(ctx context.Context, reason string) error {
+	m.aborted = reason
+	return nil
+}
+
+type jobStream struct {
+	grpc.ServerStream
+	chunks []*ledgerv1.ImportJobChunk
+	resp   *ledgerv1.ImportJob
+}
+
+func (s *jobStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *jobStream) Recv() (*ledgerv1.ImportJobChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	c := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return c, nil
+}
+
+func (s *jobStream) SendAndClose(job *ledgerv1.ImportJob) error {
+	s.resp = job
+	return nil
+}
+
+func TestCreateImportJob(t *testing.T) {
+	upload := &mockJobUpload{}
+
+	svc := &mockLedgerService{
+		startJobFn: func(ctx context.Context, source, fileName string) (service.ImportJobUpload, error) {
+			require.Equal(t, "csv", source)
+			require.Equal(t, "statement.csv", fileName)
+			return upload, nil
+		},
+	}
+
+	stream := &jobStream{
+		chunks: []*ledgerv1.ImportJobChunk{
+			{
+				Source:   "csv",
+				FileName: "statement.csv",
+				Rows: []*ledgerv1.ImportJobRow{
+					{Line: 2, Transaction: &ledgerv1.CreateTransactionRequest{Amount: 10, Category: "food", Date: "2025-01-15"}},
+				},
+			},
+			{
+				Rows: []*ledgerv1.ImportJobRow{
+					{Line: 3, Transaction: &ledgerv1.CreateTransactionRequest{Amount: 20, Category: "food", Date: "2025-01-16"}},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, NewServer(svc).CreateImportJob(stream))
+
+	require.Len(t, upload.rows, 2)
+	require.Equal(t, 3, upload.rows[1].Line)
+	require.True(t, upload.rows[1].Transaction.Amount.Equal(decimal.NewFromInt(20)))
+
+	require.Equal(t, "7a1b2c3d-0000-4000-8000-000000000001", stream.resp.Id)
+	require.Equal(t, "queued", stream.resp.Status)
+	require.Equal(t, int64(2), stream.resp.Total)
+}
+
+func TestCreateImportJob_InvalidDate(t *testing.T) {
+	upload := &mockJobUpload{}
+
+	svc := &mockLedgerService{
+		startJobFn: func(ctx context.Context, source, fileName string) (service.ImportJobUpload, error) {
+			return upload, nil
+		},
+	}
+
+	stream := &jobStream{
+		chunks: []*ledgerv1.ImportJobChunk{{
+			Source: "csv",
+			Rows: []*ledgerv1.ImportJobRow{
+				{Line: 2, Transaction: &ledgerv1.CreateTransactionRequest{Amount: 10, Category: "food", Date: "15.01.2025"}},
+			},
+		}},
+	}
+
+	err := NewServer(svc).CreateImportJob(stream)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, "invalid date", upload.aborted)
+}
+
+func TestGetImportJob(t *testing.T) {
+	id := uuid.New()
+
+	svc := &mockLedgerService{
+		getJobFn: func(ctx context.Context, got uuid.UUID) (*domain.ImportJob, error) {
+			if got != id {
+				return nil, domain.ErrImportJobNotFound
+			}
+			return &domain.ImportJob{
+				ID:        id,
+				Status:    domain.ImportJobRunning,
+				Processed: 1000,
+				Errors:    []domain.ImportJobError{{Index: 3, Line: 5, Error: "budget exceeded"}},
+			}, nil
+		},
+	}
+
+	server := NewServer(svc)
+
+	resp, err := server.GetImportJob(context.Background(), &ledgerv1.ImportJobRequest{Id: id.String()})
+	require.NoError(t, err)
+	require.Equal(t, "running", resp.Status)
+	require.Equal(t, int64(1000), resp.Processed)
+	require.Equal(t, int32(5), resp.Errors[0].Line)
+
+	_, err = server.GetImportJob(context.Background(), &ledgerv1.ImportJobRequest{Id: uuid.NewString()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.GetImportJob(context.Background(), &ledgerv1.ImportJobRequest{Id: "42"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCancelImportJob_Finished(t *testing.T) {
+	svc := &mockLedgerService{
+		cancelJobFn: func(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error) {
+			return nil, domain.ErrImportJobFinished
+		},
+	}
+
+	_, err := NewServer(svc).CancelImportJob(context.Background(), &ledgerv1.ImportJobRequest{Id: uuid.NewString()})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+type exportStream struct {
+	grpc.ServerStream
+	sent []*ledgerv1.Transaction
+}
+
+func (s *exportStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *exportStream) Send(t *ledgerv1.Transaction) error {
+	s.sent = append(s.sent, t)
+	return nil
+}
+
+func TestExportTransactions(t *testing.T) {
+	svc := &mockLedgerService{
+		exportFn: func(ctx context.Context, f domain.TransactionFilter, fn func(domain.Transaction) error) error {
+			require.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), f.From)
+			require.True(t, f.To.IsZero())
+			require.Equal(t, []string{"food"}, f.Categories)
+
+			for i := 1; i <= 3; i++ {
+				err := fn(domain.Transaction{
+					ID:       int32(i),
+					Amount:   decimal.NewFromInt(int64(i)),
+					Category: "food",
+					Date:     time.Date(2025, 1, i, 0, 0, 0, 0, time.UTC),
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	stream := &exportStream{}
+	err := NewServer(svc).ExportTransactions(&ledgerv1.ExportTransactionsRequest{
+		From:       "2025-01-01",
+		Categories: []string{"food"},
+	}, stream)
+	require.NoError(t, err)
+
+	require.Len(t, stream.sent, 3)
+	require.Equal(t, "2025-01-03", stream.sent[2].Date)
+	require.Equal(t, 3.0, stream.sent[2].Amount)
+}
+
+func TestExportTransactions_InvalidDate(t *testing.T) {
+	err := NewServer(&mockLedgerService{}).ExportTransactions(&ledgerv1.ExportTransactionsRequest{
+		To: "31.01.2025",
+	}, &exportStream{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+type backupStream struct {
+	grpc.ServerStream
+	sent [][]byte
+}
+
+func (s *backupStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *backupStream) Send(c *ledgerv1.BackupChunk) error {
+	s.sent = append(s.sent, append([]byte(nil), c.Data...))
+	return nil
+}
+
+func TestExportBackup(t *testing.T) {
+	archive := bytes.Repeat([]byte("backup"), backupChunkSize/2)
+
+	svc := &mockLedgerService{
+		backupFn: func(ctx context.Context, w io.Writer) error {
+			_, err := w.Write(archive)
+			return err
+		},
+	}
+
+	stream := &backupStream{}
+	require.NoError(t, NewServer(svc).ExportBackup(&emptypb.Empty{}, stream))
+
+	require.Len(t, stream.sent, 3)
+	for _, chunk := range stream.sent {
+		require.LessOrEqual(t, len(chunk), backupChunkSize)
+	}
+	require.Equal(t, archive, bytes.Join(stream.sent, nil))
+}
+
+type restoreStream struct {
+	grpc.ServerStream
+	chunks []*ledgerv1.RestoreBackupRequest
+	res    *ledgerv1.RestoreBackupResponse
+}
+
+func (s *restoreStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *restoreStream) Recv() (*ledgerv1.RestoreBackupRequest, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	c := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return c, nil
+}
+
+func (s *restoreStream) SendAndClose(res *ledgerv1.RestoreBackupResponse) error {
+	s.res = res
+	return nil
+}
+
+func TestRestoreBackup(t *testing.T) {
+	svc := &mockLedgerService{
+		restoreFn: func(ctx context.Context, r io.ReaderAt, size int64, mode string) (*domain.RestoreResult, error) {
+			require.Equal(t, domain.RestoreReplace, mode)
+
+			data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+			require.NoError(t, err)
+			require.Equal(t, "first,second", string(data))
+
+			return &domain.RestoreResult{Budgets: 1, Transactions: 10, Duplicates: 2}, nil
+		},
+	}
+
+	stream := &restoreStream{chunks: []*ledgerv1.RestoreBackupRequest{
+		{Mode: domain.RestoreReplace, Data: []byte("first,")},
+		{Data: []byte("second")},
+	}}
+	require.NoError(t, NewServer(svc).RestoreBackup(stream))
+
+	require.Equal(t, int64(10), stream.res.Transactions)
+	require.Equal(t, int64(2), stream.res.Duplicates)
+}
+
+func TestRestoreBackup_Invalid(t *testing.T) {
+	svc := &mockLedgerService{
+		restoreFn: func(ctx context.Context, r io.ReaderAt, size int64, mode string) (*domain.RestoreResult, error) {
+			return nil, fmt.Errorf("%w: not a zip archive", domain.ErrInvalidBackup)
+		},
+	}
+
+	err := NewServer(svc).RestoreBackup(&restoreStream{chunks: []*ledgerv1.RestoreBackupRequest{
+		{Data: []byte("amount,category")},
+	}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = NewServer(svc).RestoreBackup(&restoreStream{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	ledgerID := uuid.New()
+
+	svc := &mockLedgerService{
+		acceptFn: func(ctx context.Context, token string) (*domain.Ledger, error) {
+			if token != "t-1" {
+				return nil, domain.ErrInvitationNotFound
+			}
+			return &domain.Ledger{ID: ledgerID, Name: "Home", Role: domain.LedgerEditor}, nil
+		},
+	}
+
+	server := NewServer(svc)
+
+	resp, err := server.AcceptInvitation(context.Background(), &ledgerv1.AcceptInvitationRequest{Token: "t-1"})
+	require.NoError(t, err)
+	require.Equal(t, ledgerID.String(), resp.Id)
+	require.Equal(t, "editor", resp.Role)
+
+	_, err = server.AcceptInvitation(context.Background(), &ledgerv1.AcceptInvitationRequest{Token: "t-2"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSetMemberRole(t *testing.T) {
+	userID := uuid.New()
+
+	svc := &mockLedgerService{
+		setRoleFn: func(ctx context.Context, got uuid.UUID, role string) (*domain.Member, error) {
+			require.Equal(t, userID, got)
+			return nil, domain.ErrLedgerForbidden
+		},
+	}
+
+	server := NewServer(svc)
+
+	_, err := server.SetMemberRole(context.Background(), &ledgerv1.SetMemberRoleRequest{UserId: userID.String(), Role: "viewer"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = server.SetMemberRole(context.Background(), &ledgerv1.SetMemberRoleRequest{UserId: "42", Role: "viewer"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetMemberSpending(t *testing.T) {
+	userID := uuid.New()
+
+	svc := &mockLedgerService{
+		spendingFn: func(ctx context.Context, from, to time.Time) ([]domain.MemberSpending, error) {
+			require.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), from)
+			return []domain.MemberSpending{
+				{UserID: userID, Transactions: 3, Total: decimal.RequireFromString("42.50")},
+			}, nil
+		},
+	}
+
+	resp, err := NewServer(svc).GetMemberSpending(context.Background(), &ledgerv1.ReportSummaryRequest{
+		From: "2025-01-01",
+		To:   "2025-01-31",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Members, 1)
+	require.Equal(t, userID.String(), resp.Members[0].UserId)
+	require.Equal(t, int64(3), resp.Members[0].Transactions)
+	require.Equal(t, 42.5, resp.Members[0].Total)
 }
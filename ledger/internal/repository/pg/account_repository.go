@@ -0,0 +1,148 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"ledger/internal/db/sqlc"
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// restoreBatchSize bounds the rows sent with one RestoreExpenses statement.
+const restoreBatchSize = 1000
+
+type AccountRepo struct {
+	db *sql.DB
+	q  *sqlc.Queries
+}
+
+func NewAccountRepo(db *sql.DB, q *sqlc.Queries) *AccountRepo {
+	return &AccountRepo{db: db, q: q}
+}
+
+func (r *AccountRepo) Restore(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	userID uuid.UUID,
+	b domain.Backup,
+	replace bool,
+) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	if replace {
+		if err := deleteLedgerData(ctx, q, ledgerID); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, budget := range b.Budgets {
+		err := q.UpsertBudget(ctx, sqlc.UpsertBudgetParams{
+			LedgerID:    ledgerID,
+			Category:    budget.Category,
+			LimitAmount: budget.Limit,
+			Period:      budget.Period,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, p := range b.ImportProfiles {
+		if err := q.UpsertImportProfile(ctx, upsertImportProfileParams(ledgerID, p)); err != nil {
+			return 0, err
+		}
+	}
+
+	var inserted int64
+	for start := 0; start < len(b.Transactions); start += restoreBatchSize {
+		end := min(start+restoreBatchSize, len(b.Transactions))
+
+		n, err := q.RestoreExpenses(ctx, restoreExpensesParams(ledgerID, userID, b.Transactions[start:end]))
+		if err != nil {
+			return 0, err
+		}
+		inserted += n
+	}
+
+	return inserted, tx.Commit()
+}
+
+func (r *AccountRepo) Delete(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	// ledgers with other members are theirs too, so they go to the member
+	// who joined first instead of being deleted
+	if err := q.TransferSharedLedgers(ctx, userID); err != nil {
+		return nil, err
+	}
+	// budgets, transactions, import profiles and jobs, members and
+	// invitations go with their ledgers
+	deleted, err := q.DeleteOwnedLedgers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.DeleteUserLedgerMemberships(ctx, userID); err != nil {
+		return nil, err
+	}
+	// transactions the user recorded in other ledgers are the other
+	// members' books too, so they stay without saying who recorded them
+	if err := q.AnonymizeUserExpenses(ctx, userID); err != nil {
+		return nil, err
+	}
+	// staged rows and errors go with their jobs
+	if err := q.DeleteUserImportJobs(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return deleted, tx.Commit()
+}
+
+// deleteLedgerData deletes the data a backup holds.
+func deleteLedgerData(ctx context.Context, q *sqlc.Queries, ledgerID uuid.UUID) error {
+	if err := q.DeleteLedgerExpenses(ctx, ledgerID); err != nil {
+		return err
+	}
+	if err := q.DeleteLedgerBudgets(ctx, ledgerID); err != nil {
+		return err
+	}
+	return q.DeleteLedgerImportProfiles(ctx, ledgerID)
+}
+
+func restoreExpensesParams(ledgerID, userID uuid.UUID, txs []domain.Transaction) sqlc.RestoreExpensesParams {
+	arg := sqlc.RestoreExpensesParams{
+		LedgerID:     ledgerID,
+		UserID:       userID,
+		Amounts:      make([]string, len(txs)),
+		Categories:   make([]string, len(txs)),
+		Descriptions: make([]string, len(txs)),
+		Dates:        make([]time.Time, len(txs)),
+		ExternalIds:  make([]string, len(txs)),
+		Sources:      make([]string, len(txs)),
+		Fingerprints: make([]string, len(txs)),
+	}
+	for i, t := range txs {
+		arg.Amounts[i] = t.Amount.String()
+		arg.Categories[i] = t.Category
+		arg.Descriptions[i] = t.Description
+		arg.Dates[i] = t.Date
+		arg.ExternalIds[i] = t.ExternalID
+		arg.Sources[i] = t.Source
+		arg.Fingerprints[i] = t.Fingerprint
+	}
+	return arg
+}
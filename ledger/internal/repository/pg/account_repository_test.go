@@ -0,0 +1,156 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ledger/internal/db/sqlc"
+	"ledger/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountRepo_Restore_Replace(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccountRepo(db, sqlc.New(db))
+
+	ledgerID := uuid.New()
+	userID := uuid.New()
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM expenses`).WithArgs(ledgerID).WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec(`DELETE FROM budgets`).WithArgs(ledgerID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM import_profiles`).WithArgs(ledgerID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO budgets`).
+		WithArgs(ledgerID, "food", decimal.NewFromInt(300), "monthly").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO expenses .* FROM unnest`).
+		WithArgs(
+			ledgerID,
+			userID,
+			[]string{"10.5", "20"},
+			[]string{"food", "food"},
+			[]string{"Coffee", ""},
+			[]time.Time{day, day},
+			[]string{"", "F2"},
+			[]string{"", "csv"},
+			[]string{"", "ext:F2"},
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	inserted, err := repo.Restore(context.Background(), ledgerID, userID, domain.Backup{
+		Budgets: []domain.Budget{{Category: "food", Limit: decimal.NewFromInt(300), Period: "monthly"}},
+		Transactions: []domain.Transaction{
+			{Amount: decimal.RequireFromString("10.5"), Category: "food", Description: "Coffee", Date: day},
+			{Amount: decimal.NewFromInt(20), Category: "food", Date: day, ExternalID: "F2", Source: "csv", Fingerprint: "ext:F2"},
+		},
+	}, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), inserted)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountRepo_Restore_RollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccountRepo(db, sqlc.New(db))
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO import_profiles`).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, err = repo.Restore(context.Background(), userID, userID, domain.Backup{
+		ImportProfiles: []domain.ImportProfile{{Name: "bank", AmountColumn: "Sum", DateColumn: "Date"}},
+	}, false)
+	require.EqualError(t, err, "connection reset")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountRepo_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccountRepo(db, sqlc.New(db))
+	userID, team := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`(?s)WITH heirs AS .+UPDATE ledgers l\s+SET owner_id = h.user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`DELETE FROM ledgers\s+WHERE owner_id = \$1\s+RETURNING id`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID).AddRow(team))
+	mock.ExpectExec(`DELETE FROM ledger_members\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE expenses\s+SET user_id = '` + domain.ErasedUser.String() + `'\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM import_jobs\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	deleted, err := repo.Delete(context.Background(), userID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{userID, team}, deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountRepo_Delete_SharedLedgerOwner(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccountRepo(db, sqlc.New(db))
+	userID := uuid.New()
+
+	// the user's only ledger has another member, who takes it over with
+	// everything in it
+	mock.ExpectBegin()
+	mock.ExpectExec(`(?s)WITH heirs AS .+ORDER BY m.ledger_id, m.joined_at, m.user_id.+SET role = 'owner'.+UPDATE ledgers l\s+SET owner_id = h.user_id`).
+		WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`DELETE FROM ledgers\s+WHERE owner_id = \$1\s+RETURNING id`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(`DELETE FROM ledger_members\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE expenses\s+SET user_id = '` + domain.ErasedUser.String() + `'\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 40))
+	mock.ExpectExec(`DELETE FROM import_jobs\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	deleted, err := repo.Delete(context.Background(), userID)
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountRepo_Delete_SharedLedgerMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccountRepo(db, sqlc.New(db))
+	userID := uuid.New()
+
+	// the user owns nothing, but is an editor of another user's ledger,
+	// recorded transactions and uploaded a statement there
+	mock.ExpectBegin()
+	mock.ExpectExec(`WITH heirs AS`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`DELETE FROM ledgers\s+WHERE owner_id`).WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(`DELETE FROM ledger_members\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE expenses\s+SET user_id = '` + domain.ErasedUser.String() + `'\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 12))
+	mock.ExpectExec(`DELETE FROM import_jobs\s+WHERE user_id`).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	deleted, err := repo.Delete(context.Background(), userID)
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
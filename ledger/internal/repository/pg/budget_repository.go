@@ -21,11 +21,11 @@ func NewBudgetRepo(q *sqlc.Queries) *BudgetRepo {
 
 func (r *BudgetRepo) Upsert(
 	ctx context.Context,
-	userID uuid.UUID,
+	ledgerID uuid.UUID,
 	b domain.Budget,
 ) error {
 	return r.q.UpsertBudget(ctx, sqlc.UpsertBudgetParams{
-		UserID:      userID,
+		LedgerID:    ledgerID,
 		Category:    b.Category,
 		LimitAmount: b.Limit,
 		Period:      b.Period,
@@ -34,9 +34,9 @@ func (r *BudgetRepo) Upsert(
 
 func (r *BudgetRepo) List(
 	ctx context.Context,
-	userID uuid.UUID,
+	ledgerID uuid.UUID,
 ) ([]domain.Budget, error) {
-	rows, err := r.q.ListBudgets(ctx, userID)
+	rows, err := r.q.ListBudgets(ctx, ledgerID)
 	if err != nil {
 		return nil, err
 	}
@@ -50,11 +50,11 @@ func (r *BudgetRepo) List(
 
 func (r *BudgetRepo) GetByCategory(
 	ctx context.Context,
-	userID uuid.UUID,
+	ledgerID uuid.UUID,
 	category string,
 ) (*domain.Budget, error) {
 	row, err := r.q.GetByCategory(ctx, sqlc.GetByCategoryParams{
-		UserID:   userID,
+		LedgerID: ledgerID,
 		Category: category,
 	})
 
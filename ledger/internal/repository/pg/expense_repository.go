@@ -3,6 +3,7 @@ package pg
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"ledger/internal/db/sqlc"
@@ -12,34 +13,160 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// maxDate stands in for an open end of a date range.
+var maxDate = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
 type ExpenseRepo struct {
-	q *sqlc.Queries
+	db *sql.DB
+	q  *sqlc.Queries
 }
 
-func NewExpenseRepo(q *sqlc.Queries) *ExpenseRepo {
-	return &ExpenseRepo{q: q}
+func NewExpenseRepo(db *sql.DB, q *sqlc.Queries) *ExpenseRepo {
+	return &ExpenseRepo{db: db, q: q}
 }
 
 func (r *ExpenseRepo) Add(
 	ctx context.Context,
+	ledgerID uuid.UUID,
+	t domain.Transaction,
+) error {
+	return insertExpense(ctx, r.q, ledgerID, t)
+}
+
+// AddAll inserts txs in a single database transaction: either every row is
+// stored or none is.
+func (r *ExpenseRepo) AddAll(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	txs []domain.Transaction,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+	for i, t := range txs {
+		if err := insertExpense(ctx, q, ledgerID, t); err != nil {
+			return &domain.BulkRowError{Index: i, Err: err}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ExpenseRepo) AddBatch(
+	ctx context.Context,
+	ledgerID uuid.UUID,
 	userID uuid.UUID,
+	source string,
+	txs []domain.Transaction,
+) ([]int, error) {
+	arg := sqlc.InsertExpensesParams{
+		LedgerID:     ledgerID,
+		UserID:       userID,
+		Source:       source,
+		Amounts:      make([]string, len(txs)),
+		Categories:   make([]string, len(txs)),
+		Descriptions: make([]string, len(txs)),
+		Dates:        make([]time.Time, len(txs)),
+		ExternalIds:  make([]string, len(txs)),
+		Fingerprints: make([]string, len(txs)),
+	}
+	for i, t := range txs {
+		arg.Amounts[i] = t.Amount.String()
+		arg.Categories[i] = t.Category
+		arg.Descriptions[i] = t.Description
+		arg.Dates[i] = t.Date
+		arg.ExternalIds[i] = t.ExternalID
+		arg.Fingerprints[i] = t.Fingerprint
+	}
+
+	inserted, err := r.q.InsertExpenses(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]bool, len(inserted))
+	for _, fp := range inserted {
+		stored[fp] = true
+	}
+
+	var skipped []int
+	for i, t := range txs {
+		if !stored[t.Fingerprint] {
+			skipped = append(skipped, i)
+		}
+	}
+	return skipped, nil
+}
+
+func insertExpense(
+	ctx context.Context,
+	q *sqlc.Queries,
+	ledgerID uuid.UUID,
 	t domain.Transaction,
 ) error {
-	_, err := r.q.InsertExpense(ctx, sqlc.InsertExpenseParams{
-		UserID:      userID,
+	_, err := q.InsertExpense(ctx, sqlc.InsertExpenseParams{
+		LedgerID:    ledgerID,
+		UserID:      t.UserID,
 		Amount:      t.Amount,
 		Category:    t.Category,
 		Description: sql.NullString{String: t.Description, Valid: t.Description != ""},
 		Date:        t.Date,
+		ExternalID:  sql.NullString{String: t.ExternalID, Valid: t.ExternalID != ""},
+		Source:      t.Source,
+		Fingerprint: sql.NullString{String: t.Fingerprint, Valid: t.Fingerprint != ""},
 	})
+	// ON CONFLICT DO NOTHING returns no row for a known fingerprint
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrDuplicateTransaction
+	}
 	return err
 }
 
 func (r *ExpenseRepo) List(
 	ctx context.Context,
-	userID uuid.UUID,
+	ledgerID uuid.UUID,
+) ([]domain.Transaction, error) {
+	rows, err := r.q.ListExpenses(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.Transaction, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, mapExpense(row))
+	}
+	return res, nil
+}
+
+func (r *ExpenseRepo) ListAfter(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	f domain.TransactionFilter,
+	after domain.Transaction,
+	limit int,
 ) ([]domain.Transaction, error) {
-	rows, err := r.q.ListExpenses(ctx, userID)
+	arg := sqlc.ListExpensesAfterParams{
+		LedgerID:   ledgerID,
+		FromDate:   f.From,
+		ToDate:     f.To,
+		Categories: f.Categories,
+		Source:     f.Source,
+		AfterDate:  after.Date,
+		AfterID:    after.ID,
+		PageSize:   int32(limit),
+	}
+	if arg.ToDate.IsZero() {
+		arg.ToDate = maxDate
+	}
+	if arg.Categories == nil {
+		arg.Categories = []string{}
+	}
+
+	rows, err := r.q.ListExpensesAfter(ctx, arg)
 	if err != nil {
 		return nil, err
 	}
@@ -53,26 +180,96 @@ func (r *ExpenseRepo) List(
 
 func (r *ExpenseRepo) SumByCategory(
 	ctx context.Context,
-	userID uuid.UUID,
+	ledgerID uuid.UUID,
 	category string,
 ) (decimal.Decimal, error) {
 	return r.q.GetSumByCategory(ctx, sqlc.GetSumByCategoryParams{
-		UserID:   userID,
+		LedgerID: ledgerID,
 		Category: category,
 	})
 }
 
 func (r *ExpenseRepo) SumByCategoryAndPeriod(
 	ctx context.Context,
-	userID uuid.UUID,
+	ledgerID uuid.UUID,
 	category string,
 	from time.Time,
 	to time.Time,
 ) (decimal.Decimal, error) {
 	return r.q.SumByCategoryAndPeriod(ctx, sqlc.SumByCategoryAndPeriodParams{
-		UserID:   userID,
+		LedgerID: ledgerID,
 		Category: category,
 		FromDate: from,
 		ToDate:   to,
 	})
 }
+
+func (r *ExpenseRepo) HasFingerprint(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	source string,
+	fingerprint string,
+) (bool, error) {
+	return r.q.ExpenseFingerprintExists(ctx, sqlc.ExpenseFingerprintExistsParams{
+		LedgerID:    ledgerID,
+		Source:      source,
+		Fingerprint: sql.NullString{String: fingerprint, Valid: true},
+	})
+}
+
+func (r *ExpenseRepo) ExistingFingerprints(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	source string,
+	fingerprints []string,
+) (map[string]bool, error) {
+	rows, err := r.q.ExistingFingerprints(ctx, sqlc.ExistingFingerprintsParams{
+		LedgerID:     ledgerID,
+		Source:       source,
+		Fingerprints: fingerprints,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(rows))
+	for _, fp := range rows {
+		existing[fp] = true
+	}
+	return existing, nil
+}
+
+func (r *ExpenseRepo) FindDuplicates(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+) ([]domain.DuplicateGroup, error) {
+	rows, err := r.q.FindDuplicateExpenses(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	var groups []domain.DuplicateGroup
+
+	for _, row := range rows {
+		t := mapExpense(row)
+		key := domain.ContentKey(t)
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, domain.DuplicateGroup{})
+		}
+		groups[i].Transactions = append(groups[i].Transactions, t)
+	}
+
+	// the database compares descriptions without folding whitespace
+	res := groups[:0]
+	for _, g := range groups {
+		if len(g.Transactions) > 1 {
+			res = append(res, g)
+		}
+	}
+	return res, nil
+}
@@ -3,6 +3,7 @@ package pg
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"testing"
 	"time"
 
@@ -21,10 +22,11 @@ func TestExpenseRepo_Add(t *testing.T) {
 	defer db.Close()
 
 	q := sqlc.New(db)
-	repo := NewExpenseRepo(q)
+	repo := NewExpenseRepo(db, q)
 
-	userID := uuid.New()
+	ledgerID := uuid.New()
 	tx := domain.Transaction{
+		UserID:      uuid.New(),
 		Amount:      decimal.NewFromInt(100),
 		Category:    "food",
 		Description: "lunch",
@@ -33,16 +35,230 @@ func TestExpenseRepo_Add(t *testing.T) {
 
 	mock.ExpectQuery(`INSERT INTO expenses`).
 		WithArgs(
-			userID,
+			ledgerID,
+			tx.UserID,
 			tx.Amount,
 			tx.Category,
 			sql.NullString{String: tx.Description, Valid: true},
 			tx.Date,
+			sql.NullString{},
+			"",
+			sql.NullString{},
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-	err = repo.Add(context.Background(), userID, tx)
+	err = repo.Add(context.Background(), ledgerID, tx)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpenseRepo_Add_Duplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewExpenseRepo(db, sqlc.New(db))
+
+	ledgerID := uuid.New()
+	tx := domain.Transaction{
+		UserID:      uuid.New(),
+		Amount:      decimal.NewFromInt(100),
+		Category:    "food",
+		Date:        time.Now(),
+		ExternalID:  "FIT-1",
+		Source:      "ofx",
+		Fingerprint: "ext:FIT-1",
+	}
+
+	mock.ExpectQuery(`INSERT INTO expenses .* ON CONFLICT`).
+		WithArgs(
+			ledgerID,
+			tx.UserID,
+			tx.Amount,
+			tx.Category,
+			sql.NullString{},
+			tx.Date,
+			sql.NullString{String: "FIT-1", Valid: true},
+			"ofx",
+			sql.NullString{String: "ext:FIT-1", Valid: true},
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	err = repo.Add(context.Background(), ledgerID, tx)
+	require.ErrorIs(t, err, domain.ErrDuplicateTransaction)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpenseRepo_AddAll_RollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewExpenseRepo(db, sqlc.New(db))
+
+	ledgerID := uuid.New()
+	day := time.Now()
+	txs := []domain.Transaction{
+		{Amount: decimal.NewFromInt(10), Category: "food", Date: day},
+		{Amount: decimal.NewFromInt(20), Category: "food", Date: day, Fingerprint: "ext:F1"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO expenses`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO expenses`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err = repo.AddAll(context.Background(), ledgerID, txs)
+
+	var rowErr *domain.BulkRowError
+	require.ErrorAs(t, err, &rowErr)
+	require.Equal(t, 1, rowErr.Index)
+	require.ErrorIs(t, err, domain.ErrDuplicateTransaction)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpenseRepo_AddAll_Commits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewExpenseRepo(db, sqlc.New(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO expenses`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err = repo.AddAll(context.Background(), uuid.New(), []domain.Transaction{
+		{Amount: decimal.NewFromInt(10), Category: "food", Date: time.Now()},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpenseRepo_HasFingerprint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewExpenseRepo(db, sqlc.New(db))
+	ledgerID := uuid.New()
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(ledgerID, "csv", sql.NullString{String: "sha256:abc", Valid: true}).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	ok, err := repo.HasFingerprint(context.Background(), ledgerID, "csv", "sha256:abc")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// arrayConverter passes slices through like the pgx driver does.
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v any) (driver.Value, error) {
+	switch v.(type) {
+	case []string, []int32, []time.Time:
+		return v, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestExpenseRepo_AddBatch(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewExpenseRepo(db, sqlc.New(db))
+
+	ledgerID := uuid.New()
+	userID := uuid.New()
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`INSERT INTO expenses .* FROM unnest`).
+		WithArgs(
+			ledgerID,
+			userID,
+			"csv",
+			[]string{"10.5", "20"},
+			[]string{"food", "food"},
+			[]string{"Coffee", ""},
+			[]time.Time{day, day},
+			[]string{"", "F2"},
+			[]string{"sha256:a", "ext:F2"},
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint"}).AddRow("sha256:a"))
+
+	skipped, err := repo.AddBatch(context.Background(), ledgerID, userID, "csv", []domain.Transaction{
+		{Amount: decimal.RequireFromString("10.5"), Category: "food", Description: "Coffee", Date: day, Fingerprint: "sha256:a"},
+		{Amount: decimal.NewFromInt(20), Category: "food", Date: day, ExternalID: "F2", Fingerprint: "ext:F2"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, skipped)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpenseRepo_ExistingFingerprints(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewExpenseRepo(db, sqlc.New(db))
+	ledgerID := uuid.New()
+
+	mock.ExpectQuery(`SELECT fingerprint::TEXT`).
+		WithArgs(ledgerID, "csv", []string{"sha256:a", "ext:F2"}).
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint"}).AddRow("ext:F2"))
+
+	existing, err := repo.ExistingFingerprints(context.Background(), ledgerID, "csv", []string{"sha256:a", "ext:F2"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"ext:F2": true}, existing)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpenseRepo_FindDuplicates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewExpenseRepo(db, sqlc.New(db))
+
+	ledgerID := uuid.New()
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{
+		"id", "user_id", "amount", "category", "description", "date", "external_id", "source", "fingerprint", "ledger_id",
+	}).
+		AddRow(1, ledgerID, decimal.NewFromInt(5), "food", "Coffee", day, nil, "", nil, ledgerID).
+		AddRow(7, ledgerID, decimal.NewFromInt(5), "cafe", "coffee", day, nil, "csv", "sha256:x", ledgerID).
+		AddRow(3, ledgerID, decimal.NewFromInt(20), "food", "Lunch  bar", day, nil, "", nil, ledgerID).
+		AddRow(4, ledgerID, decimal.NewFromInt(20), "food", "lunch bar", day, "F1", "ofx", "ext:F1", ledgerID)
+
+	mock.ExpectQuery(`SELECT .* FROM expenses e`).
+		WithArgs(ledgerID).
+		WillReturnRows(rows)
+
+	groups, err := repo.FindDuplicates(context.Background(), ledgerID)
 	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	require.Len(t, groups[0].Transactions, 2)
+	require.Equal(t, int32(1), groups[0].Transactions[0].ID)
+	require.Equal(t, int32(7), groups[0].Transactions[1].ID)
+	require.Equal(t, "csv", groups[0].Transactions[1].Source)
+
+	require.Len(t, groups[1].Transactions, 2)
+	require.Equal(t, "F1", groups[1].Transactions[1].ExternalID)
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -53,27 +269,28 @@ func TestExpenseRepo_List(t *testing.T) {
 	defer db.Close()
 
 	q := sqlc.New(db)
-	repo := NewExpenseRepo(q)
+	repo := NewExpenseRepo(db, q)
 
-	userID := uuid.New()
+	ledgerID := uuid.New()
 	now := time.Now()
 
 	rows := sqlmock.NewRows([]string{
-		"id", "user_id", "amount", "category", "description", "date",
+		"id", "user_id", "amount", "category", "description", "date", "external_id", "source", "fingerprint", "ledger_id",
 	}).AddRow(
-		1, userID, decimal.NewFromInt(50), "food", "pizza", now,
+		1, ledgerID, decimal.NewFromInt(50), "food", "pizza", now, "FIT-1", "ofx", "ext:FIT-1", ledgerID,
 	)
 
 	mock.ExpectQuery(`SELECT .* FROM expenses`).
-		WithArgs(userID).
+		WithArgs(ledgerID).
 		WillReturnRows(rows)
 
-	res, err := repo.List(context.Background(), userID)
+	res, err := repo.List(context.Background(), ledgerID)
 	require.NoError(t, err)
 	require.Len(t, res, 1)
 
 	require.Equal(t, "food", res[0].Category)
 	require.Equal(t, "pizza", res[0].Description)
+	require.Equal(t, "FIT-1", res[0].ExternalID)
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -84,19 +301,19 @@ func TestExpenseRepo_SumByCategory(t *testing.T) {
 	defer db.Close()
 
 	q := sqlc.New(db)
-	repo := NewExpenseRepo(q)
+	repo := NewExpenseRepo(db, q)
 
-	userID := uuid.New()
+	ledgerID := uuid.New()
 	category := "food"
 
 	mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\)`).
-		WithArgs(userID, category).
+		WithArgs(ledgerID, category).
 		WillReturnRows(
 			sqlmock.NewRows([]string{"sum"}).
 				AddRow(decimal.NewFromInt(150)),
 		)
 
-	sum, err := repo.SumByCategory(context.Background(), userID, category)
+	sum, err := repo.SumByCategory(context.Background(), ledgerID, category)
 	require.NoError(t, err)
 	require.True(t, sum.Equal(decimal.NewFromInt(150)))
 
@@ -109,15 +326,15 @@ func TestExpenseRepo_SumByCategoryAndPeriod(t *testing.T) {
 	defer db.Close()
 
 	q := sqlc.New(db)
-	repo := NewExpenseRepo(q)
+	repo := NewExpenseRepo(db, q)
 
-	userID := uuid.New()
+	ledgerID := uuid.New()
 	category := "food"
 	from := time.Now().AddDate(0, 0, -7)
 	to := time.Now()
 
 	mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\)`).
-		WithArgs(userID, category, from, to).
+		WithArgs(ledgerID, category, from, to).
 		WillReturnRows(
 			sqlmock.NewRows([]string{"sum"}).
 				AddRow(decimal.NewFromInt(200)),
@@ -125,7 +342,7 @@ func TestExpenseRepo_SumByCategoryAndPeriod(t *testing.T) {
 
 	sum, err := repo.SumByCategoryAndPeriod(
 		context.Background(),
-		userID,
+		ledgerID,
 		category,
 		from,
 		to,
@@ -136,3 +353,30 @@ func TestExpenseRepo_SumByCategoryAndPeriod(t *testing.T) {
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestExpenseRepo_ListAfter(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewExpenseRepo(db, sqlc.New(db))
+
+	ledgerID := uuid.New()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT .* FROM expenses .* ORDER BY date, id`).
+		WithArgs(ledgerID, from, maxDate, []string{}, "", last, int32(7), int32(1000)).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "user_id", "amount", "category", "description", "date", "external_id", "source", "fingerprint", "ledger_id"}).
+				AddRow(8, ledgerID, "12.30", "food", "Coffee", last, nil, "", nil, ledgerID),
+		)
+
+	txs, err := repo.ListAfter(context.Background(), ledgerID, domain.TransactionFilter{From: from},
+		domain.Transaction{ID: 7, Date: last}, 1000)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	require.Equal(t, int32(8), txs[0].ID)
+	require.Equal(t, "Coffee", txs[0].Description)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,329 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"ledger/internal/db/sqlc"
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxImportJobErrors limits how many row errors a job reports.
+const maxImportJobErrors = 1000
+
+type ImportJobRepo struct {
+	db *sql.DB
+	q  *sqlc.Queries
+}
+
+func NewImportJobRepo(db *sql.DB, q *sqlc.Queries) *ImportJobRepo {
+	return &ImportJobRepo{db: db, q: q}
+}
+
+func (r *ImportJobRepo) Create(
+	ctx context.Context,
+	job domain.ImportJob,
+) error {
+	return r.q.CreateImportJob(ctx, sqlc.CreateImportJobParams{
+		ID:       job.ID,
+		LedgerID: job.LedgerID,
+		UserID:   job.UserID,
+		Status:   job.Status,
+		Source:   job.Source,
+		FileName: job.FileName,
+	})
+}
+
+func (r *ImportJobRepo) AddRows(
+	ctx context.Context,
+	jobID uuid.UUID,
+	rows []domain.ImportJobRow,
+) error {
+	arg := sqlc.InsertImportJobRowsParams{
+		JobID:        jobID,
+		Idxs:         make([]int32, len(rows)),
+		Lines:        make([]int32, len(rows)),
+		Amounts:      make([]string, len(rows)),
+		Categories:   make([]string, len(rows)),
+		Descriptions: make([]string, len(rows)),
+		Dates:        make([]time.Time, len(rows)),
+		ExternalIds:  make([]string, len(rows)),
+		Fingerprints: make([]string, len(rows)),
+	}
+	for i, row := range rows {
+		t := row.Transaction
+		arg.Idxs[i] = int32(row.Index)
+		arg.Lines[i] = int32(row.Line)
+		arg.Amounts[i] = t.Amount.String()
+		arg.Categories[i] = t.Category
+		arg.Descriptions[i] = t.Description
+		arg.Dates[i] = t.Date
+		arg.ExternalIds[i] = t.ExternalID
+		arg.Fingerprints[i] = t.Fingerprint
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	// rows of a job cancelled or failed meanwhile would never be dropped
+	n, err := q.TouchImportJobUpload(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrImportJobFinished
+	}
+
+	if err := q.InsertImportJobRows(ctx, arg); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ImportJobRepo) Queue(
+	ctx context.Context,
+	jobID uuid.UUID,
+	total int64,
+) error {
+	n, err := r.q.QueueImportJob(ctx, sqlc.QueueImportJobParams{
+		ID:    jobID,
+		Total: total,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrImportJobFinished
+	}
+	return nil
+}
+
+func (r *ImportJobRepo) Get(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	jobID uuid.UUID,
+) (*domain.ImportJob, error) {
+	row, err := r.q.GetImportJob(ctx, sqlc.GetImportJobParams{
+		LedgerID: ledgerID,
+		ID:       jobID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	errs, err := r.q.ListImportJobErrors(ctx, sqlc.ListImportJobErrorsParams{
+		JobID: jobID,
+		Limit: maxImportJobErrors,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	job := mapImportJob(row)
+	for _, e := range errs {
+		job.Errors = append(job.Errors, domain.ImportJobError{
+			Index: int(e.Idx),
+			Line:  int(e.Line),
+			Error: e.Error,
+		})
+	}
+	return &job, nil
+}
+
+func (r *ImportJobRepo) Cancel(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	jobID uuid.UUID,
+) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	n, err := q.CancelImportJob(ctx, sqlc.CancelImportJobParams{
+		LedgerID: ledgerID,
+		ID:       jobID,
+	})
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	if err := dropImportJobRows(ctx, q, jobID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *ImportJobRepo) Claim(
+	ctx context.Context,
+	staleAfter time.Duration,
+) (*domain.ImportJob, error) {
+	row, err := r.q.ClaimImportJob(ctx, time.Now().Add(-staleAfter))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	job := mapImportJob(row)
+	return &job, nil
+}
+
+func (r *ImportJobRepo) Status(
+	ctx context.Context,
+	jobID uuid.UUID,
+) (string, error) {
+	return r.q.GetImportJobStatus(ctx, jobID)
+}
+
+func (r *ImportJobRepo) Rows(
+	ctx context.Context,
+	jobID uuid.UUID,
+	limit int,
+) ([]domain.ImportJobRow, error) {
+	rows, err := r.q.ListImportJobRows(ctx, sqlc.ListImportJobRowsParams{
+		JobID: jobID,
+		Limit: int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.ImportJobRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapImportJobRow(row))
+	}
+	return out, nil
+}
+
+func (r *ImportJobRepo) SaveProgress(
+	ctx context.Context,
+	jobID uuid.UUID,
+	p domain.ImportJobProgress,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	if len(p.Errors) > 0 {
+		arg := sqlc.InsertImportJobErrorsParams{
+			JobID:  jobID,
+			Idxs:   make([]int32, len(p.Errors)),
+			Lines:  make([]int32, len(p.Errors)),
+			Errors: make([]string, len(p.Errors)),
+		}
+		for i, e := range p.Errors {
+			arg.Idxs[i] = int32(e.Index)
+			arg.Lines[i] = int32(e.Line)
+			arg.Errors[i] = e.Error
+		}
+		if err := q.InsertImportJobErrors(ctx, arg); err != nil {
+			return err
+		}
+	}
+
+	err = q.DeleteImportJobRows(ctx, sqlc.DeleteImportJobRowsParams{
+		JobID: jobID,
+		Idx:   int32(p.Processed),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = q.UpdateImportJobProgress(ctx, sqlc.UpdateImportJobProgressParams{
+		ID:         jobID,
+		Processed:  p.Processed,
+		Accepted:   p.Accepted,
+		Rejected:   p.Rejected,
+		Duplicates: p.Duplicates,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ImportJobRepo) Finish(
+	ctx context.Context,
+	jobID uuid.UUID,
+	status string,
+	reason string,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	err = q.FinishImportJob(ctx, sqlc.FinishImportJobParams{
+		ID:     jobID,
+		Status: status,
+		Error:  reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := dropImportJobRows(ctx, q, jobID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ImportJobRepo) Sweep(
+	ctx context.Context,
+	staleAfter time.Duration,
+) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	n, err := q.FailStaleImportJobUploads(ctx, time.Now().Add(-staleAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := q.DeleteFinishedImportJobRows(ctx); err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
+}
+
+func dropImportJobRows(ctx context.Context, q *sqlc.Queries, jobID uuid.UUID) error {
+	return q.DeleteImportJobRows(ctx, sqlc.DeleteImportJobRowsParams{
+		JobID: jobID,
+		Idx:   math.MaxInt32,
+	})
+}
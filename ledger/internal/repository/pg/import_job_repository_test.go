@@ -0,0 +1,139 @@
+package pg
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"ledger/internal/db/sqlc"
+	"ledger/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportJobRepo_SaveProgress(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportJobRepo(db, sqlc.New(db))
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO import_job_errors`).
+		WithArgs(jobID, []int32{3}, []int32{5}, []string{"budget exceeded"}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM import_job_rows`).
+		WithArgs(jobID, int32(1000)).
+		WillReturnResult(sqlmock.NewResult(0, 1000))
+	mock.ExpectExec(`UPDATE import_jobs`).
+		WithArgs(jobID, int64(1000), int64(990), int64(1), int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.SaveProgress(context.Background(), jobID, domain.ImportJobProgress{
+		Processed:  1000,
+		Accepted:   990,
+		Rejected:   1,
+		Duplicates: 9,
+		Errors:     []domain.ImportJobError{{Index: 3, Line: 5, Error: "budget exceeded"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportJobRepo_Cancel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportJobRepo(db, sqlc.New(db))
+	userID := uuid.New()
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE import_jobs`).
+		WithArgs(userID, jobID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM import_job_rows`).
+		WithArgs(jobID, int32(math.MaxInt32)).
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectCommit()
+
+	ok, err := repo.Cancel(context.Background(), userID, jobID)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// finished jobs stay as they are
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE import_jobs`).
+		WithArgs(userID, jobID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	ok, err = repo.Cancel(context.Background(), userID, jobID)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportJobRepo_AddRows_Finished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportJobRepo(db, sqlc.New(db))
+	jobID := uuid.New()
+
+	// the job was cancelled in the middle of the upload
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE import_jobs SET updated_at = now\(\)`).
+		WithArgs(jobID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.AddRows(context.Background(), jobID, []domain.ImportJobRow{{Line: 2}})
+	require.ErrorIs(t, err, domain.ErrImportJobFinished)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportJobRepo_Sweep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportJobRepo(db, sqlc.New(db))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE import_jobs SET status = 'failed'.* status = 'uploading'`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM import_job_rows r USING import_jobs j`).
+		WillReturnResult(sqlmock.NewResult(0, 150))
+	mock.ExpectCommit()
+
+	n, err := repo.Sweep(context.Background(), 30*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportJobRepo_Claim_Empty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportJobRepo(db, sqlc.New(db))
+
+	mock.ExpectQuery(`UPDATE import_jobs .* SKIP LOCKED`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	job, err := repo.Claim(context.Background(), 5*time.Minute)
+	require.NoError(t, err)
+	require.Nil(t, job)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
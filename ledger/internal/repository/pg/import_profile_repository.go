@@ -0,0 +1,97 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ledger/internal/db/sqlc"
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type ImportProfileRepo struct {
+	q *sqlc.Queries
+}
+
+func NewImportProfileRepo(q *sqlc.Queries) *ImportProfileRepo {
+	return &ImportProfileRepo{q: q}
+}
+
+func (r *ImportProfileRepo) Upsert(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	p domain.ImportProfile,
+) error {
+	return r.q.UpsertImportProfile(ctx, upsertImportProfileParams(ledgerID, p))
+}
+
+func upsertImportProfileParams(ledgerID uuid.UUID, p domain.ImportProfile) sqlc.UpsertImportProfileParams {
+	return sqlc.UpsertImportProfileParams{
+		LedgerID:          ledgerID,
+		Name:              p.Name,
+		AmountColumn:      p.AmountColumn,
+		DateColumn:        p.DateColumn,
+		CategoryColumn:    p.CategoryColumn,
+		DescriptionColumn: p.DescriptionColumn,
+		ExternalIDColumn:  p.ExternalIDColumn,
+		Delimiter:         p.Delimiter,
+		DecimalComma:      p.DecimalComma,
+		DateFormat:        p.DateFormat,
+		Encoding:          p.Encoding,
+		SignConvention:    p.SignConvention,
+	}
+}
+
+func (r *ImportProfileRepo) GetByName(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	name string,
+) (*domain.ImportProfile, error) {
+	row, err := r.q.GetImportProfile(ctx, sqlc.GetImportProfileParams{
+		LedgerID: ledgerID,
+		Name:     name,
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	p := mapImportProfile(row)
+	return &p, nil
+}
+
+func (r *ImportProfileRepo) List(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+) ([]domain.ImportProfile, error) {
+	rows, err := r.q.ListImportProfiles(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.ImportProfile, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, mapImportProfile(row))
+	}
+	return res, nil
+}
+
+func (r *ImportProfileRepo) Delete(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	name string,
+) (bool, error) {
+	n, err := r.q.DeleteImportProfile(ctx, sqlc.DeleteImportProfileParams{
+		LedgerID: ledgerID,
+		Name:     name,
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
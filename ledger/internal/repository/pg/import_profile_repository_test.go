@@ -0,0 +1,118 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"ledger/internal/db/sqlc"
+	"ledger/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var importProfileColumns = []string{
+	"id", "user_id", "name", "amount_column", "date_column", "category_column",
+	"description_column", "external_id_column", "delimiter", "decimal_comma",
+	"date_format", "encoding", "sign_convention",
+}
+
+func TestImportProfileRepo_Upsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportProfileRepo(sqlc.New(db))
+
+	userID := uuid.New()
+	p := domain.ImportProfile{
+		Name:           "sber",
+		AmountColumn:   "Сумма",
+		DateColumn:     "Дата операции",
+		Delimiter:      ";",
+		DecimalComma:   true,
+		DateFormat:     "DD.MM.YYYY",
+		Encoding:       "windows-1251",
+		SignConvention: "negative",
+	}
+
+	mock.ExpectExec(`INSERT INTO import_profiles`).
+		WithArgs(userID, p.Name, p.AmountColumn, p.DateColumn, "", "", "", ";", true, p.DateFormat, p.Encoding, p.SignConvention).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, repo.Upsert(context.Background(), userID, p))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportProfileRepo_GetByName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportProfileRepo(sqlc.New(db))
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT .* FROM import_profiles`).
+		WithArgs(userID, "sber").
+		WillReturnRows(sqlmock.NewRows(importProfileColumns).
+			AddRow(1, userID, "sber", "Сумма", "Дата", "", "", "", ";", true, "DD.MM.YYYY", "windows-1251", "negative"))
+
+	res, err := repo.GetByName(context.Background(), userID, "sber")
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	require.Equal(t, ";", res.Delimiter)
+	require.True(t, res.DecimalComma)
+
+	mock.ExpectQuery(`SELECT .* FROM import_profiles`).
+		WithArgs(userID, "missing").
+		WillReturnError(sql.ErrNoRows)
+
+	res, err = repo.GetByName(context.Background(), userID, "missing")
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportProfileRepo_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportProfileRepo(sqlc.New(db))
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT .* FROM import_profiles`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(importProfileColumns).
+			AddRow(1, userID, "a", "amount", "date", "", "", "", ",", false, "YYYY-MM-DD", "utf-8", "positive").
+			AddRow(2, userID, "b", "amount", "date", "", "", "", ";", true, "DD.MM.YYYY", "utf-8", "negative"))
+
+	res, err := repo.List(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, "b", res[1].Name)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportProfileRepo_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewImportProfileRepo(sqlc.New(db))
+	userID := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM import_profiles`).
+		WithArgs(userID, "a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deleted, err := repo.Delete(context.Background(), userID, "a")
+	require.NoError(t, err)
+	require.True(t, deleted)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,216 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ledger/internal/db/sqlc"
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type LedgerRepo struct {
+	db *sql.DB
+	q  *sqlc.Queries
+}
+
+func NewLedgerRepo(db *sql.DB, q *sqlc.Queries) *LedgerRepo {
+	return &LedgerRepo{db: db, q: q}
+}
+
+func (r *LedgerRepo) Create(
+	ctx context.Context,
+	l domain.Ledger,
+) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	err = q.CreateLedger(ctx, sqlc.CreateLedgerParams{
+		ID:      l.ID,
+		Name:    l.Name,
+		OwnerID: l.OwnerID,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = q.AddLedgerMember(ctx, sqlc.AddLedgerMemberParams{
+		LedgerID: l.ID,
+		UserID:   l.OwnerID,
+		Role:     domain.LedgerOwner,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *LedgerRepo) Get(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+) (*domain.Ledger, error) {
+	row, err := r.q.GetLedger(ctx, ledgerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	l := mapLedger(row)
+	return &l, nil
+}
+
+func (r *LedgerRepo) ListForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]domain.Ledger, error) {
+	rows, err := r.q.ListUserLedgers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.Ledger, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, domain.Ledger{
+			ID:        row.ID,
+			Name:      row.Name,
+			OwnerID:   row.OwnerID,
+			Role:      row.Role,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+func (r *LedgerRepo) Member(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	userID uuid.UUID,
+) (*domain.Member, error) {
+	row, err := r.q.GetLedgerMember(ctx, sqlc.GetLedgerMemberParams{
+		LedgerID: ledgerID,
+		UserID:   userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	m := mapMember(row)
+	return &m, nil
+}
+
+func (r *LedgerRepo) Members(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+) ([]domain.Member, error) {
+	rows, err := r.q.ListLedgerMembers(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.Member, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, mapMember(row))
+	}
+	return res, nil
+}
+
+func (r *LedgerRepo) SetMemberRole(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	userID uuid.UUID,
+	role string,
+) (bool, error) {
+	n, err := r.q.SetLedgerMemberRole(ctx, sqlc.SetLedgerMemberRoleParams{
+		LedgerID: ledgerID,
+		UserID:   userID,
+		Role:     role,
+	})
+	return n > 0, err
+}
+
+func (r *LedgerRepo) RemoveMember(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	userID uuid.UUID,
+) (bool, error) {
+	n, err := r.q.DeleteLedgerMember(ctx, sqlc.DeleteLedgerMemberParams{
+		LedgerID: ledgerID,
+		UserID:   userID,
+	})
+	return n > 0, err
+}
+
+func (r *LedgerRepo) CreateInvitation(
+	ctx context.Context,
+	tokenHash string,
+	inv domain.Invitation,
+) error {
+	return r.q.CreateLedgerInvitation(ctx, sqlc.CreateLedgerInvitationParams{
+		TokenHash: tokenHash,
+		LedgerID:  inv.LedgerID,
+		Role:      inv.Role,
+		InvitedBy: inv.InvitedBy,
+		ExpiresAt: inv.ExpiresAt,
+	})
+}
+
+func (r *LedgerRepo) AcceptInvitation(
+	ctx context.Context,
+	tokenHash string,
+	userID uuid.UUID,
+) (*domain.Invitation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := r.q.WithTx(tx)
+
+	row, err := q.TakeLedgerInvitation(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !row.ExpiresAt.After(time.Now()) {
+		// used up all the same
+		return nil, tx.Commit()
+	}
+
+	err = q.AddLedgerMember(ctx, sqlc.AddLedgerMemberParams{
+		LedgerID: row.LedgerID,
+		UserID:   userID,
+		Role:     row.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &domain.Invitation{
+		LedgerID:  row.LedgerID,
+		Role:      row.Role,
+		InvitedBy: row.InvitedBy,
+		ExpiresAt: row.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,107 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ledger/internal/db/sqlc"
+	"ledger/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerRepo_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLedgerRepo(db, sqlc.New(db))
+
+	l := domain.Ledger{ID: uuid.New(), Name: "Home", OwnerID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO ledgers`).
+		WithArgs(l.ID, "Home", l.OwnerID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO ledger_members`).
+		WithArgs(l.ID, l.OwnerID, domain.LedgerOwner).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Create(context.Background(), l))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLedgerRepo_Member_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLedgerRepo(db, sqlc.New(db))
+
+	ledgerID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT ledger_id, user_id, role, joined_at\s+FROM ledger_members`).
+		WithArgs(ledgerID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"ledger_id", "user_id", "role", "joined_at"}))
+
+	m, err := repo.Member(context.Background(), ledgerID, userID)
+	require.NoError(t, err)
+	require.Nil(t, m)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLedgerRepo_AcceptInvitation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLedgerRepo(db, sqlc.New(db))
+
+	ledgerID, inviter, userID := uuid.New(), uuid.New(), uuid.New()
+	expires := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM ledger_invitations`).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"token_hash", "ledger_id", "role", "invited_by", "expires_at", "created_at",
+		}).AddRow("hash", ledgerID, domain.LedgerEditor, inviter, expires, time.Now()))
+	mock.ExpectExec(`INSERT INTO ledger_members`).
+		WithArgs(ledgerID, userID, domain.LedgerEditor).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	inv, err := repo.AcceptInvitation(context.Background(), "hash", userID)
+	require.NoError(t, err)
+	require.Equal(t, ledgerID, inv.LedgerID)
+	require.Equal(t, domain.LedgerEditor, inv.Role)
+	require.Equal(t, inviter, inv.InvitedBy)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLedgerRepo_AcceptInvitation_Expired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLedgerRepo(db, sqlc.New(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM ledger_invitations`).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"token_hash", "ledger_id", "role", "invited_by", "expires_at", "created_at",
+		}).AddRow("hash", uuid.New(), domain.LedgerViewer, uuid.New(), time.Now().Add(-time.Hour), time.Now()))
+	mock.ExpectCommit()
+
+	inv, err := repo.AcceptInvitation(context.Background(), "hash", uuid.New())
+	require.NoError(t, err)
+	require.Nil(t, inv)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -8,7 +8,7 @@ import (
 func mapBudget(b sqlc.Budget) domain.Budget {
 	return domain.Budget{
 		ID:       b.ID,
-		UserID:   b.UserID,
+		LedgerID: b.LedgerID,
 		Category: b.Category,
 		Limit:    b.LimitAmount,
 		Period:   b.Period,
@@ -18,10 +18,84 @@ func mapBudget(b sqlc.Budget) domain.Budget {
 func mapExpense(e sqlc.Expense) domain.Transaction {
 	return domain.Transaction{
 		ID:          e.ID,
+		LedgerID:    e.LedgerID,
 		UserID:      e.UserID,
 		Amount:      e.Amount,
 		Category:    e.Category,
 		Description: e.Description.String,
 		Date:        e.Date,
+		ExternalID:  e.ExternalID.String,
+		Source:      e.Source,
+		Fingerprint: e.Fingerprint.String,
+	}
+}
+
+func mapImportProfile(p sqlc.ImportProfile) domain.ImportProfile {
+	return domain.ImportProfile{
+		ID:                p.ID,
+		LedgerID:          p.LedgerID,
+		Name:              p.Name,
+		AmountColumn:      p.AmountColumn,
+		DateColumn:        p.DateColumn,
+		CategoryColumn:    p.CategoryColumn,
+		DescriptionColumn: p.DescriptionColumn,
+		ExternalIDColumn:  p.ExternalIDColumn,
+		Delimiter:         p.Delimiter,
+		DecimalComma:      p.DecimalComma,
+		DateFormat:        p.DateFormat,
+		Encoding:          p.Encoding,
+		SignConvention:    p.SignConvention,
+	}
+}
+
+func mapImportJob(j sqlc.ImportJob) domain.ImportJob {
+	return domain.ImportJob{
+		ID:         j.ID,
+		LedgerID:   j.LedgerID,
+		UserID:     j.UserID,
+		Status:     j.Status,
+		Source:     j.Source,
+		FileName:   j.FileName,
+		Total:      j.Total,
+		Processed:  j.Processed,
+		Accepted:   j.Accepted,
+		Rejected:   j.Rejected,
+		Duplicates: j.Duplicates,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		UpdatedAt:  j.UpdatedAt,
+	}
+}
+
+func mapImportJobRow(r sqlc.ImportJobRow) domain.ImportJobRow {
+	return domain.ImportJobRow{
+		Index: int(r.Idx),
+		Line:  int(r.Line),
+		Transaction: domain.Transaction{
+			Amount:      r.Amount,
+			Category:    r.Category,
+			Description: r.Description,
+			Date:        r.Date,
+			ExternalID:  r.ExternalID,
+			Fingerprint: r.Fingerprint,
+		},
+	}
+}
+
+func mapLedger(l sqlc.Ledger) domain.Ledger {
+	return domain.Ledger{
+		ID:        l.ID,
+		Name:      l.Name,
+		OwnerID:   l.OwnerID,
+		CreatedAt: l.CreatedAt,
+	}
+}
+
+func mapMember(m sqlc.LedgerMember) domain.Member {
+	return domain.Member{
+		LedgerID: m.LedgerID,
+		UserID:   m.UserID,
+		Role:     m.Role,
+		JoinedAt: m.JoinedAt,
 	}
 }
@@ -13,11 +13,11 @@ import (
 )
 
 func TestMapBudget(t *testing.T) {
-	userID := uuid.New()
+	ledgerID := uuid.New()
 
 	b := sqlc.Budget{
 		ID:          1,
-		UserID:      userID,
+		LedgerID:    ledgerID,
 		Category:    "food",
 		LimitAmount: decimal.NewFromInt(500),
 		Period:      "monthly",
@@ -26,7 +26,7 @@ func TestMapBudget(t *testing.T) {
 	res := mapBudget(b)
 
 	require.Equal(t, b.ID, res.ID)
-	require.Equal(t, b.UserID, res.UserID)
+	require.Equal(t, b.LedgerID, res.LedgerID)
 	require.Equal(t, b.Category, res.Category)
 	require.True(t, b.LimitAmount.Equal(res.Limit))
 	require.Equal(t, b.Period, res.Period)
@@ -39,6 +39,7 @@ func TestMapExpense(t *testing.T) {
 	e := sqlc.Expense{
 		ID:       1,
 		UserID:   userID,
+		LedgerID: uuid.New(),
 		Amount:   decimal.NewFromInt(100),
 		Category: "food",
 		Description: sql.NullString{
@@ -52,6 +53,7 @@ func TestMapExpense(t *testing.T) {
 
 	require.Equal(t, e.ID, res.ID)
 	require.Equal(t, e.UserID, res.UserID)
+	require.Equal(t, e.LedgerID, res.LedgerID)
 	require.True(t, e.Amount.Equal(res.Amount))
 	require.Equal(t, e.Category, res.Category)
 	require.Equal(t, "coffee", res.Description)
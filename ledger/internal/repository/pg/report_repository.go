@@ -20,13 +20,13 @@ func NewReportRepo(q *sqlc.Queries) *ReportRepo {
 
 func (r *ReportRepo) GetReportSummary(
 	ctx context.Context,
-	userID uuid.UUID,
+	ledgerID uuid.UUID,
 	from time.Time,
 	to time.Time,
 ) ([]domain.ReportSummary, error) {
 
 	rows, err := r.q.ReportSummary(ctx, sqlc.ReportSummaryParams{
-		UserID:   userID,
+		LedgerID: ledgerID,
 		FromDate: from,
 		ToDate:   to,
 	})
@@ -43,3 +43,30 @@ func (r *ReportRepo) GetReportSummary(
 	}
 	return res, nil
 }
+
+func (r *ReportRepo) MemberSpending(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	from time.Time,
+	to time.Time,
+) ([]domain.MemberSpending, error) {
+
+	rows, err := r.q.MemberSpending(ctx, sqlc.MemberSpendingParams{
+		LedgerID: ledgerID,
+		FromDate: from,
+		ToDate:   to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.MemberSpending, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, domain.MemberSpending{
+			UserID:       row.UserID,
+			Transactions: row.Transactions,
+			Total:        row.Total,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"ledger/internal/cache"
+
+	"github.com/google/uuid"
+)
+
+// EraseAccount deletes everything kept for a deleted user: the ledgers
+// nobody else belongs to with their transactions, budgets and import
+// profiles, the user's memberships and import jobs, and the cache entries
+// of the user and the deleted ledgers. Shared ledgers the user owns pass to
+// the member who joined first, and transactions the user recorded in them
+// stay there. Erasing an account twice is harmless, so a failed erasure
+// can simply be retried.
+func (l *ledgerServiceImpl) EraseAccount(ctx context.Context, userID uuid.UUID) error {
+	deleted, err := l.accounts.Delete(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if cache.Client == nil {
+		return nil
+	}
+
+	// the gateway keeps its idempotent responses in the same instance
+	patterns := []string{
+		fmt.Sprintf("idempotency:ledger:%s:*", userID),
+		fmt.Sprintf("idempotency:%s:*", userID),
+	}
+	for _, ledgerID := range deleted {
+		if err := cache.Client.Del(ctx, fmt.Sprintf("budgets:all:%s", ledgerID)).Err(); err != nil {
+			return err
+		}
+		patterns = append(patterns, fmt.Sprintf("report:summary:%s:*", ledgerID))
+	}
+	for _, pattern := range patterns {
+		iter := cache.Client.Scan(ctx, 0, pattern, 0).Iterator()
+		for iter.Next(ctx) {
+			if err := cache.Client.Del(ctx, iter.Val()).Err(); err != nil {
+				return err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"ledger/internal/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEraseAccount(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache.Client = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = cache.Client.Close()
+		cache.Client = nil
+	})
+
+	// the user's personal ledger and a team ledger nobody else joined are
+	// deleted, a shared ledger the user owned passes to another member
+	userID, other := uuid.New(), uuid.New()
+	team, shared := uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{userID, other, team, shared} {
+		require.NoError(t, mr.Set("budgets:all:"+id.String(), "[]"))
+		require.NoError(t, mr.Set("report:summary:"+id.String()+":2025-01-01:2025-02-01", "[]"))
+	}
+	for _, id := range []uuid.UUID{userID, other} {
+		require.NoError(t, mr.Set("idempotency:ledger:"+id.String()+":"+id.String()+":/ledger.v1.LedgerService/SetBudget:k1", "{}"))
+		require.NoError(t, mr.Set("idempotency:"+id.String()+":k1", "{}"))
+	}
+
+	accounts := &mockAccountRepo{ledgers: []uuid.UUID{userID, team}}
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, accounts, &mockLedgerRepo{})
+
+	require.NoError(t, svc.EraseAccount(context.Background(), userID))
+	require.Equal(t, []uuid.UUID{userID}, accounts.deleted)
+
+	require.ElementsMatch(t, []string{
+		"budgets:all:" + other.String(),
+		"report:summary:" + other.String() + ":2025-01-01:2025-02-01",
+		"idempotency:ledger:" + other.String() + ":" + other.String() + ":/ledger.v1.LedgerService/SetBudget:k1",
+		"idempotency:" + other.String() + ":k1",
+		"budgets:all:" + shared.String(),
+		"report:summary:" + shared.String() + ":2025-01-01:2025-02-01",
+	}, mr.Keys())
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"ledger/internal/backup"
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Files of a version 1 archive. categories.json only describes the data:
+// categories have no table of their own, so a restore takes them from the
+// budgets and transactions.
+const (
+	budgetsFile      = "budgets.json"
+	transactionsFile = "transactions.json"
+	categoriesFile   = "categories.json"
+	settingsFile     = "settings.json"
+)
+
+type backupBudget struct {
+	Category string          `json:"category"`
+	Limit    decimal.Decimal `json:"limit"`
+	Period   string          `json:"period,omitempty"`
+}
+
+type backupTransaction struct {
+	Amount      decimal.Decimal `json:"amount"`
+	Category    string          `json:"category"`
+	Description string          `json:"description,omitempty"`
+	Date        string          `json:"date"`
+	ExternalID  string          `json:"external_id,omitempty"`
+	Source      string          `json:"source,omitempty"`
+	Fingerprint string          `json:"fingerprint,omitempty"`
+}
+
+type backupCategory struct {
+	Name         string `json:"name"`
+	Transactions int64  `json:"transactions"`
+	Budget       bool   `json:"budget"`
+}
+
+type backupSettings struct {
+	ImportProfiles []backupImportProfile `json:"import_profiles"`
+}
+
+type backupImportProfile struct {
+	Name              string `json:"name"`
+	AmountColumn      string `json:"amount_column"`
+	DateColumn        string `json:"date_column"`
+	CategoryColumn    string `json:"category_column,omitempty"`
+	DescriptionColumn string `json:"description_column,omitempty"`
+	ExternalIDColumn  string `json:"external_id_column,omitempty"`
+	Delimiter         string `json:"delimiter"`
+	DecimalComma      bool   `json:"decimal_comma"`
+	DateFormat        string `json:"date_format"`
+	Encoding          string `json:"encoding"`
+	SignConvention    string `json:"sign_convention"`
+}
+
+// ExportBackup writes the ledger's budgets, transactions, categories and
+// import profiles to w as a backup archive. Transactions are read page by
+// page while the archive is written.
+func (l *ledgerServiceImpl) ExportBackup(ctx context.Context, w io.Writer) error {
+
+	m, err := l.member(ctx, domain.LedgerViewer)
+	if err != nil {
+		return err
+	}
+
+	budgets, err := l.budgets.List(ctx, m.LedgerID)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := l.profiles.List(ctx, m.LedgerID)
+	if err != nil {
+		return err
+	}
+
+	categories := make(map[string]*backupCategory)
+	category := func(name string) *backupCategory {
+		c, ok := categories[name]
+		if !ok {
+			c = &backupCategory{Name: name}
+			categories[name] = c
+		}
+		return c
+	}
+
+	bw := backup.NewWriter(w, time.Now())
+
+	err = bw.WriteArray(budgetsFile, func(add func(v any) error) error {
+		for _, b := range budgets {
+			category(b.Category).Budget = true
+			if err := add(backupBudget{Category: b.Category, Limit: b.Limit, Period: b.Period}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = bw.WriteArray(transactionsFile, func(add func(v any) error) error {
+		return l.exportTransactions(ctx, m.LedgerID, domain.TransactionFilter{}, func(t domain.Transaction) error {
+			category(t.Category).Transactions++
+			return add(backupTransaction{
+				Amount:      t.Amount,
+				Category:    t.Category,
+				Description: t.Description,
+				Date:        t.Date.Format("2006-01-02"),
+				ExternalID:  t.ExternalID,
+				Source:      t.Source,
+				Fingerprint: t.Fingerprint,
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	err = bw.WriteArray(categoriesFile, func(add func(v any) error) error {
+		for _, name := range names {
+			if err := add(categories[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	settings := backupSettings{ImportProfiles: make([]backupImportProfile, 0, len(profiles))}
+	for _, p := range profiles {
+		settings.ImportProfiles = append(settings.ImportProfiles, backupImportProfile{
+			Name:              p.Name,
+			AmountColumn:      p.AmountColumn,
+			DateColumn:        p.DateColumn,
+			CategoryColumn:    p.CategoryColumn,
+			DescriptionColumn: p.DescriptionColumn,
+			ExternalIDColumn:  p.ExternalIDColumn,
+			Delimiter:         p.Delimiter,
+			DecimalComma:      p.DecimalComma,
+			DateFormat:        p.DateFormat,
+			Encoding:          p.Encoding,
+			SignConvention:    p.SignConvention,
+		})
+	}
+	if err := bw.WriteObject(settingsFile, settings); err != nil {
+		return err
+	}
+
+	return bw.Close()
+}
+
+// RestoreBackup loads an archive written by ExportBackup. Everything is
+// checked before anything is stored, and the archive is stored in one
+// database transaction. In merge mode archived budgets and import profiles
+// overwrite those with the same category or name, and transactions that
+// are already stored are skipped. Budget limits are not enforced. Editors
+// may merge; only the owner may replace the ledger.
+func (l *ledgerServiceImpl) RestoreBackup(
+	ctx context.Context,
+	r io.ReaderAt,
+	size int64,
+	mode string,
+) (*domain.RestoreResult, error) {
+
+	switch mode {
+	case "":
+		mode = domain.RestoreMerge
+	case domain.RestoreMerge, domain.RestoreReplace:
+	default:
+		return nil, &domain.ValidationError{
+			Field:   "mode",
+			Message: "can be either merge or replace",
+		}
+	}
+
+	role := domain.LedgerEditor
+	if mode == domain.RestoreReplace {
+		role = domain.LedgerOwner
+	}
+	m, err := l.member(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := readBackup(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidBackup, err)
+	}
+
+	res := &domain.RestoreResult{
+		Budgets:        int64(len(b.Budgets)),
+		ImportProfiles: int64(len(b.ImportProfiles)),
+	}
+	archived := int64(len(b.Transactions))
+
+	if mode == domain.RestoreMerge {
+		b.Transactions, err = l.withoutStored(ctx, m.LedgerID, b.Transactions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	inserted, err := l.accounts.Restore(ctx, m.LedgerID, m.UserID, *b, mode == domain.RestoreReplace)
+	if err != nil {
+		return nil, err
+	}
+	res.Transactions = inserted
+	res.Duplicates = archived - inserted
+
+	invalidateReportCache(ctx, m.LedgerID)
+	invalidateBudgetsCache(ctx, m.LedgerID)
+
+	return res, nil
+}
+
+// withoutStored drops transactions that match a stored one, by fingerprint
+// when they have one and by content otherwise. Each stored transaction
+// matches at most one archived transaction, so genuine repeats survive.
+func (l *ledgerServiceImpl) withoutStored(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	txs []domain.Transaction,
+) ([]domain.Transaction, error) {
+
+	stored := make(map[string]int)
+	err := l.exportTransactions(ctx, ledgerID, domain.TransactionFilter{}, func(t domain.Transaction) error {
+		stored[restoreKey(t)]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := txs[:0]
+	for _, t := range txs {
+		key := restoreKey(t)
+		if stored[key] > 0 {
+			stored[key]--
+			continue
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+func restoreKey(t domain.Transaction) string {
+	if t.Fingerprint != "" {
+		return "fp|" + t.Source + "|" + t.Fingerprint
+	}
+	return "tx|" + t.Category + "|" + domain.ContentKey(t)
+}
+
+// readBackup opens and checks an archive.
+func readBackup(r io.ReaderAt, size int64) (*domain.Backup, error) {
+	br, err := backup.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var budgets []backupBudget
+	if err := br.Decode(budgetsFile, &budgets); err != nil {
+		return nil, err
+	}
+
+	var txs []backupTransaction
+	if err := br.Decode(transactionsFile, &txs); err != nil {
+		return nil, err
+	}
+
+	var settings backupSettings
+	if err := br.Decode(settingsFile, &settings); err != nil {
+		return nil, err
+	}
+
+	b := &domain.Backup{
+		Budgets:        make([]domain.Budget, 0, len(budgets)),
+		Transactions:   make([]domain.Transaction, 0, len(txs)),
+		ImportProfiles: make([]domain.ImportProfile, 0, len(settings.ImportProfiles)),
+	}
+
+	for i, rec := range budgets {
+		budget := domain.Budget{Category: rec.Category, Limit: rec.Limit, Period: rec.Period}
+		if err := domain.CheckValid(budget); err != nil {
+			return nil, fmt.Errorf("%s record %d: %v", budgetsFile, i, err)
+		}
+		b.Budgets = append(b.Budgets, budget)
+	}
+
+	for i, rec := range txs {
+		date, err := time.Parse("2006-01-02", rec.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%s record %d: invalid date %q", transactionsFile, i, rec.Date)
+		}
+
+		t := domain.Transaction{
+			Amount:      rec.Amount,
+			Category:    rec.Category,
+			Description: rec.Description,
+			Date:        date,
+			ExternalID:  rec.ExternalID,
+			Source:      rec.Source,
+			Fingerprint: rec.Fingerprint,
+		}
+		if err := domain.CheckValid(t); err != nil {
+			return nil, fmt.Errorf("%s record %d: %v", transactionsFile, i, err)
+		}
+		b.Transactions = append(b.Transactions, t)
+	}
+
+	for i, rec := range settings.ImportProfiles {
+		p := domain.ImportProfile{
+			Name:              rec.Name,
+			AmountColumn:      rec.AmountColumn,
+			DateColumn:        rec.DateColumn,
+			CategoryColumn:    rec.CategoryColumn,
+			DescriptionColumn: rec.DescriptionColumn,
+			ExternalIDColumn:  rec.ExternalIDColumn,
+			Delimiter:         rec.Delimiter,
+			DecimalComma:      rec.DecimalComma,
+			DateFormat:        rec.DateFormat,
+			Encoding:          rec.Encoding,
+			SignConvention:    rec.SignConvention,
+		}
+		if err := domain.CheckValid(p); err != nil {
+			return nil, fmt.Errorf("%s import profile %d: %v", settingsFile, i, err)
+		}
+		b.ImportProfiles = append(b.ImportProfiles, p)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAccountRepo struct {
+	calls    int
+	restored domain.Backup
+	replace  bool
+	deleted  []uuid.UUID
+	// ledgers is what Delete reports as deleted
+	ledgers []uuid.UUID
+}
+
+func (m *mockAccountRepo) Restore(ctx context.Context, ledgerID uuid.UUID, userID uuid.UUID, b domain.Backup, replace bool) (int64, error) {
+	m.calls++
+	m.restored = b
+	m.replace = replace
+	return int64(len(b.Transactions)), nil
+}
+
+func (m *mockAccountRepo) Delete(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	m.deleted = append(m.deleted, userID)
+	return m.ledgers, nil
+}
+
+var (
+	backupDay      = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	backupImported = domain.Transaction{
+		ID:          1,
+		Amount:      decimal.RequireFromString("12.50"),
+		Category:    "food",
+		Description: "Coffee",
+		Date:        backupDay,
+		ExternalID:  "bank-1",
+		Source:      "csv",
+		Fingerprint: "fp-1",
+	}
+	backupManual = domain.Transaction{
+		ID:       2,
+		Amount:   decimal.NewFromInt(40),
+		Category: "transport",
+		Date:     backupDay.AddDate(0, 0, 1),
+	}
+)
+
+func exportBackup(t *testing.T, userID uuid.UUID) []byte {
+	t.Helper()
+
+	budgets := &mockBudgetRepo{budgets: map[string]domain.Budget{
+		"food": {ID: 7, LedgerID: userID, Category: "food", Limit: decimal.NewFromInt(300), Period: "monthly"},
+	}}
+	expenses := &mockExpenseRepo{items: []domain.Transaction{backupImported, backupManual}}
+	profiles := &mockImportProfileRepo{profiles: map[string]domain.ImportProfile{
+		"bank": {ID: 3, LedgerID: userID, Name: "bank", AmountColumn: "Sum", DateColumn: "Date", Delimiter: ";", DateFormat: "DD.MM.YYYY", Encoding: "utf-8", SignConvention: "negative"},
+	}}
+
+	svc := New(budgets, expenses, &mockReportRepo{}, profiles, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.ExportBackup(ctxWithUser(userID), &buf))
+	return buf.Bytes()
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	userID := uuid.New()
+	data := exportBackup(t, userID)
+
+	accounts := &mockAccountRepo{}
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, accounts, &mockLedgerRepo{})
+
+	res, err := svc.RestoreBackup(ctxWithUser(userID), bytes.NewReader(data), int64(len(data)), domain.RestoreReplace)
+	require.NoError(t, err)
+	require.Equal(t, &domain.RestoreResult{Budgets: 1, Transactions: 2, ImportProfiles: 1}, res)
+
+	require.True(t, accounts.replace)
+	require.Equal(t, []domain.Budget{
+		{Category: "food", Limit: decimal.NewFromInt(300), Period: "monthly"},
+	}, accounts.restored.Budgets)
+	require.Equal(t, []domain.ImportProfile{
+		{Name: "bank", AmountColumn: "Sum", DateColumn: "Date", Delimiter: ";", DateFormat: "DD.MM.YYYY", Encoding: "utf-8", SignConvention: "negative"},
+	}, accounts.restored.ImportProfiles)
+
+	imported, manual := backupImported, backupManual
+	imported.ID, manual.ID = 0, 0
+	require.Len(t, accounts.restored.Transactions, 2)
+	for i, want := range []domain.Transaction{imported, manual} {
+		got := accounts.restored.Transactions[i]
+		require.True(t, want.Amount.Equal(got.Amount))
+		got.Amount = want.Amount
+		require.Equal(t, want, got)
+	}
+}
+
+func TestRestoreBackup_MergeSkipsStored(t *testing.T) {
+	userID := uuid.New()
+	data := exportBackup(t, userID)
+
+	// the imported row was re-imported with another description, the
+	// manual one is stored as it was
+	stored := backupImported
+	stored.Description = "COFFEE SHOP"
+	expenses := &mockExpenseRepo{items: []domain.Transaction{stored, backupManual}}
+
+	accounts := &mockAccountRepo{}
+	svc := New(&mockBudgetRepo{}, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, accounts, &mockLedgerRepo{})
+
+	res, err := svc.RestoreBackup(ctxWithUser(userID), bytes.NewReader(data), int64(len(data)), "")
+	require.NoError(t, err)
+
+	require.False(t, accounts.replace)
+	require.Empty(t, accounts.restored.Transactions)
+	require.Equal(t, int64(0), res.Transactions)
+	require.Equal(t, int64(2), res.Duplicates)
+	require.Equal(t, int64(1), res.Budgets)
+}
+
+func TestRestoreBackup_Invalid(t *testing.T) {
+	userID := uuid.New()
+	data := exportBackup(t, userID)
+
+	accounts := &mockAccountRepo{}
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, accounts, &mockLedgerRepo{})
+
+	_, err := svc.RestoreBackup(ctxWithUser(userID), bytes.NewReader(data), int64(len(data)), "overwrite")
+	var vErr *domain.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	require.Equal(t, "mode", vErr.Field)
+
+	truncated := data[:len(data)/2]
+	_, err = svc.RestoreBackup(ctxWithUser(userID), bytes.NewReader(truncated), int64(len(truncated)), domain.RestoreMerge)
+	require.ErrorIs(t, err, domain.ErrInvalidBackup)
+
+	require.Equal(t, 0, accounts.calls)
+}
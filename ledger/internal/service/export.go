@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const exportPageSize = 1000
+
+// ExportTransactions calls fn for every transaction matching f in date
+// order. Transactions are read page by page, so the size of an export
+// does not matter.
+func (l *ledgerServiceImpl) ExportTransactions(
+	ctx context.Context,
+	f domain.TransactionFilter,
+	fn func(domain.Transaction) error,
+) error {
+
+	m, err := l.member(ctx, domain.LedgerViewer)
+	if err != nil {
+		return err
+	}
+
+	return l.exportTransactions(ctx, m.LedgerID, f, fn)
+}
+
+func (l *ledgerServiceImpl) exportTransactions(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	f domain.TransactionFilter,
+	fn func(domain.Transaction) error,
+) error {
+
+	if err := domain.CheckValid(f); err != nil {
+		return err
+	}
+
+	var after domain.Transaction
+	for {
+		page, err := l.expenses.ListAfter(ctx, ledgerID, f, after, exportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range page {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < exportPageSize {
+			return nil
+		}
+		after = page[len(page)-1]
+	}
+}
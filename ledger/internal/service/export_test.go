@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportTransactions_Pages(t *testing.T) {
+	userID := uuid.New()
+	expenses := &mockExpenseRepo{}
+
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := 2*exportPageSize + 500
+	for i := 0; i < n; i++ {
+		expenses.items = append(expenses.items, domain.Transaction{
+			ID:       int32(n - i),
+			UserID:   userID,
+			Amount:   decimal.NewFromInt(1),
+			Category: "food",
+			Date:     day.AddDate(0, 0, i%30),
+		})
+	}
+
+	svc := New(&mockBudgetRepo{}, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	var got []domain.Transaction
+	err := svc.ExportTransactions(ctxWithUser(userID), domain.TransactionFilter{}, func(t domain.Transaction) error {
+		got = append(got, t)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, got, n)
+	require.Equal(t, 3, expenses.pages)
+	for i := 1; i < len(got); i++ {
+		prev, cur := got[i-1], got[i]
+		require.True(t, prev.Date.Before(cur.Date) || prev.Date.Equal(cur.Date) && prev.ID < cur.ID)
+	}
+}
+
+func TestExportTransactions_StopsOnError(t *testing.T) {
+	userID := uuid.New()
+	expenses := &mockExpenseRepo{
+		items: []domain.Transaction{
+			{ID: 1, Date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: 2, Date: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	svc := New(&mockBudgetRepo{}, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	sendErr := errors.New("client went away")
+	calls := 0
+	err := svc.ExportTransactions(ctxWithUser(userID), domain.TransactionFilter{}, func(t domain.Transaction) error {
+		calls++
+		return sendErr
+	})
+	require.ErrorIs(t, err, sendErr)
+	require.Equal(t, 1, calls)
+}
+
+func TestExportTransactions_InvalidRange(t *testing.T) {
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	err := svc.ExportTransactions(ctxWithUser(uuid.New()), domain.TransactionFilter{
+		From: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, func(domain.Transaction) error { return nil })
+
+	var vErr *domain.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	require.Equal(t, "from", vErr.Field)
+}
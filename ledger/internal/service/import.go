@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Import stores a stream of transactions batch by batch. Each batch is
+// written with one statement and committed on its own; fingerprint
+// occurrences and budget totals carry over from earlier batches.
+type Import interface {
+	// AddBatch stores txs. Indexes in the result are positions within txs.
+	AddBatch(ctx context.Context, txs []domain.Transaction) (*domain.BulkImportResult, error)
+}
+
+type transactionImport struct {
+	l           *ledgerServiceImpl
+	ledgerID    uuid.UUID
+	userID      uuid.UUID
+	source      string
+	occurrences map[string]int
+	tracker     *budgetTracker
+}
+
+func (l *ledgerServiceImpl) StartImport(
+	ctx context.Context,
+	source string,
+) (Import, error) {
+
+	m, err := l.member(ctx, domain.LedgerEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.newTransactionImport(m.LedgerID, m.UserID, source), nil
+}
+
+// newTransactionImport stores transactions in ledgerID as recorded by
+// userID.
+func (l *ledgerServiceImpl) newTransactionImport(
+	ledgerID uuid.UUID,
+	userID uuid.UUID,
+	source string,
+) *transactionImport {
+	return &transactionImport{
+		l:           l,
+		ledgerID:    ledgerID,
+		userID:      userID,
+		source:      source,
+		occurrences: make(map[string]int),
+		tracker:     newBudgetTracker(l, ledgerID),
+	}
+}
+
+func (i *transactionImport) AddBatch(
+	ctx context.Context,
+	txs []domain.Transaction,
+) (*domain.BulkImportResult, error) {
+
+	var res domain.BulkImportResult
+
+	reject := func(index int, err error) {
+		res.Errors = append(res.Errors, domain.BulkImportError{
+			Index: index,
+			Error: err.Error(),
+		})
+	}
+
+	var (
+		valid    []domain.Transaction
+		validIdx []int
+		seen     = make(map[string]bool)
+	)
+
+	for index, t := range txs {
+		t.LedgerID = i.ledgerID
+		t.UserID = i.userID
+		t.Source = i.source
+
+		if t.Fingerprint == "" {
+			key := domain.ContentKey(t)
+			t.Fingerprint = domain.Fingerprint(t, i.occurrences[key])
+			i.occurrences[key]++
+		}
+
+		if err := domain.CheckValid(t); err != nil {
+			reject(index, err)
+			continue
+		}
+
+		if seen[t.Fingerprint] {
+			res.DuplicateIndexes = append(res.DuplicateIndexes, index)
+			continue
+		}
+		seen[t.Fingerprint] = true
+
+		valid = append(valid, t)
+		validIdx = append(validIdx, index)
+	}
+
+	fingerprints := make([]string, len(valid))
+	for k, t := range valid {
+		fingerprints[k] = t.Fingerprint
+	}
+
+	var existing map[string]bool
+	if len(fingerprints) > 0 {
+		var err error
+		existing, err = i.l.expenses.ExistingFingerprints(ctx, i.ledgerID, i.source, fingerprints)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// duplicates are sorted out first, so a repeated upload is not
+	// reported as overspending
+	var (
+		insert    []domain.Transaction
+		insertIdx []int
+	)
+	for k, t := range valid {
+		if existing[t.Fingerprint] {
+			res.DuplicateIndexes = append(res.DuplicateIndexes, validIdx[k])
+			continue
+		}
+
+		rejected, err := i.tracker.reserve(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if rejected != nil {
+			reject(validIdx[k], rejected)
+			continue
+		}
+
+		insert = append(insert, t)
+		insertIdx = append(insertIdx, validIdx[k])
+	}
+
+	if len(insert) > 0 {
+		skipped, err := i.l.expenses.AddBatch(ctx, i.ledgerID, i.userID, i.source, insert)
+		if err != nil {
+			return nil, err
+		}
+
+		// stored by a concurrent request in the meantime
+		for _, k := range skipped {
+			i.tracker.release(insert[k])
+			res.DuplicateIndexes = append(res.DuplicateIndexes, insertIdx[k])
+		}
+
+		res.Accepted = int64(len(insert) - len(skipped))
+		invalidateReportCache(ctx, i.ledgerID)
+	}
+
+	sort.Ints(res.DuplicateIndexes)
+	sort.Slice(res.Errors, func(a, b int) bool {
+		return res.Errors[a].Index < res.Errors[b].Index
+	})
+
+	res.Rejected = int64(len(res.Errors))
+	res.Duplicates = int64(len(res.DuplicateIndexes))
+
+	return &res, nil
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	importJobBatchSize = 1000
+	importJobPoll      = 5 * time.Second
+
+	// a running job without progress for this long is taken over, e.g.
+	// after the ledger was restarted in the middle of it
+	importJobStaleAfter = 5 * time.Minute
+
+	// an upload that has not sent rows for this long is failed, e.g. after
+	// the gateway was restarted in the middle of it
+	importJobUploadStaleAfter = 30 * time.Minute
+	importJobSweepEvery       = time.Minute
+)
+
+// ImportJobUpload receives the rows of a new import job. The job is queued
+// for the worker once Finish is called.
+type ImportJobUpload interface {
+	// AddRows stores rows; their Index is assigned in upload order.
+	AddRows(ctx context.Context, rows []domain.ImportJobRow) error
+	Finish(ctx context.Context) (*domain.ImportJob, error)
+	// Abort marks the job failed, e.g. when the upload broke off.
+	Abort(ctx context.Context, reason string) error
+}
+
+type importJobUpload struct {
+	l           *ledgerServiceImpl
+	job         domain.ImportJob
+	occurrences map[string]int
+	next        int
+}
+
+func (l *ledgerServiceImpl) StartImportJob(
+	ctx context.Context,
+	source string,
+	fileName string,
+) (ImportJobUpload, error) {
+
+	m, err := l.member(ctx, domain.LedgerEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	job := domain.ImportJob{
+		ID:       uuid.New(),
+		LedgerID: m.LedgerID,
+		UserID:   m.UserID,
+		Status:   domain.ImportJobUploading,
+		Source:   source,
+		FileName: fileName,
+	}
+	if err := l.jobs.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return &importJobUpload{
+		l:           l,
+		job:         job,
+		occurrences: make(map[string]int),
+	}, nil
+}
+
+func (u *importJobUpload) AddRows(
+	ctx context.Context,
+	rows []domain.ImportJobRow,
+) error {
+	// fingerprints are fixed now, so a job resumed after a restart numbers
+	// identical rows the same way
+	for i := range rows {
+		rows[i].Index = u.next
+		u.next++
+
+		t := &rows[i].Transaction
+		if t.Fingerprint == "" {
+			key := domain.ContentKey(*t)
+			t.Fingerprint = domain.Fingerprint(*t, u.occurrences[key])
+			u.occurrences[key]++
+		}
+	}
+
+	return u.l.jobs.AddRows(ctx, u.job.ID, rows)
+}
+
+func (u *importJobUpload) Finish(ctx context.Context) (*domain.ImportJob, error) {
+	if err := u.l.jobs.Queue(ctx, u.job.ID, int64(u.next)); err != nil {
+		return nil, err
+	}
+
+	select {
+	case u.l.jobQueued <- struct{}{}:
+	default:
+	}
+
+	return u.l.jobs.Get(ctx, u.job.LedgerID, u.job.ID)
+}
+
+func (u *importJobUpload) Abort(ctx context.Context, reason string) error {
+	return u.l.jobs.Finish(ctx, u.job.ID, domain.ImportJobFailed, reason)
+}
+
+func (l *ledgerServiceImpl) GetImportJob(
+	ctx context.Context,
+	id uuid.UUID,
+) (*domain.ImportJob, error) {
+
+	m, err := l.member(ctx, domain.LedgerViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.importJob(ctx, m.LedgerID, id)
+}
+
+func (l *ledgerServiceImpl) importJob(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	id uuid.UUID,
+) (*domain.ImportJob, error) {
+	job, err := l.jobs.Get(ctx, ledgerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, domain.ErrImportJobNotFound
+	}
+
+	return job, nil
+}
+
+// CancelImportJob stops a job that is not finished yet and drops the rows
+// it has not processed. Batches stored before the cancellation are kept.
+func (l *ledgerServiceImpl) CancelImportJob(
+	ctx context.Context,
+	id uuid.UUID,
+) (*domain.ImportJob, error) {
+
+	m, err := l.member(ctx, domain.LedgerEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelled, err := l.jobs.Cancel(ctx, m.LedgerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := l.importJob(ctx, m.LedgerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, domain.ErrImportJobFinished
+	}
+
+	return job, nil
+}
+
+// RunImportJobs processes queued import jobs one at a time until ctx is
+// cancelled, and sweeps up abandoned uploads. Several ledger instances may
+// run it side by side.
+func (l *ledgerServiceImpl) RunImportJobs(ctx context.Context) {
+	ticker := time.NewTicker(importJobPoll)
+	defer ticker.Stop()
+
+	sweep := time.NewTicker(importJobSweepEvery)
+	defer sweep.Stop()
+
+	l.sweepImportJobs(ctx)
+
+	for {
+		for l.runNextImportJob(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sweep.C:
+			l.sweepImportJobs(ctx)
+		case <-ticker.C:
+		case <-l.jobQueued:
+		}
+	}
+}
+
+func (l *ledgerServiceImpl) sweepImportJobs(ctx context.Context) {
+	n, err := l.jobs.Sweep(ctx, importJobUploadStaleAfter)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("sweep import jobs: %v", err)
+		}
+		return
+	}
+	if n > 0 {
+		log.Printf("failed %d stale import job uploads", n)
+	}
+}
+
+// runNextImportJob reports whether there was a job to run.
+func (l *ledgerServiceImpl) runNextImportJob(ctx context.Context) bool {
+	job, err := l.jobs.Claim(ctx, importJobStaleAfter)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("claim import job: %v", err)
+		}
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	if err := l.processImportJob(ctx, job); err != nil {
+		if ctx.Err() != nil {
+			// shutting down; the job is resumed once it is stale
+			return false
+		}
+
+		log.Printf("import job %s failed: %v", job.ID, err)
+
+		if err := l.jobs.Finish(ctx, job.ID, domain.ImportJobFailed, err.Error()); err != nil {
+			log.Printf("import job %s: %v", job.ID, err)
+		}
+	}
+
+	return true
+}
+
+func (l *ledgerServiceImpl) processImportJob(
+	ctx context.Context,
+	job *domain.ImportJob,
+) error {
+	imp := l.newTransactionImport(job.LedgerID, job.UserID, job.Source)
+
+	for {
+		status, err := l.jobs.Status(ctx, job.ID)
+		if err != nil {
+			return err
+		}
+		if status != domain.ImportJobRunning {
+			// cancelled by the user
+			return nil
+		}
+
+		rows, err := l.jobs.Rows(ctx, job.ID, importJobBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return l.jobs.Finish(ctx, job.ID, domain.ImportJobCompleted, "")
+		}
+
+		txs := make([]domain.Transaction, len(rows))
+		for i, row := range rows {
+			txs[i] = row.Transaction
+		}
+
+		res, err := imp.AddBatch(ctx, txs)
+		if err != nil {
+			return err
+		}
+
+		progress := domain.ImportJobProgress{
+			Processed:  int64(rows[len(rows)-1].Index + 1),
+			Accepted:   res.Accepted,
+			Rejected:   res.Rejected,
+			Duplicates: res.Duplicates,
+		}
+		for _, e := range res.Errors {
+			progress.Errors = append(progress.Errors, domain.ImportJobError{
+				Index: rows[e.Index].Index,
+				Line:  rows[e.Index].Line,
+				Error: e.Error,
+			})
+		}
+
+		if err := l.jobs.SaveProgress(ctx, job.ID, progress); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+type mockImportJobRepo struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*domain.ImportJob
+	rows map[uuid.UUID][]domain.ImportJobRow
+}
+
+func (m *mockImportJobRepo) init() {
+	if m.jobs == nil {
+		m.jobs = make(map[uuid.UUID]*domain.ImportJob)
+		m.rows = make(map[uuid.UUID][]domain.ImportJobRow)
+	}
+}
+
+func (m *mockImportJobRepo) Create(ctx context.Context, job domain.ImportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	m.jobs[job.ID] = &job
+	return nil
+}
+
+func (m *mockImportJobRepo) AddRows(ctx context.Context, jobID uuid.UUID, rows []domain.ImportJobRow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.jobs[jobID]
+	if job.Status != domain.ImportJobUploading {
+		return domain.ErrImportJobFinished
+	}
+	job.UpdatedAt = time.Now()
+	m.rows[jobID] = append(m.rows[jobID], rows...)
+	return nil
+}
+
+func (m *mockImportJobRepo) Queue(ctx context.Context, jobID uuid.UUID, total int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.jobs[jobID]
+	if job.Status != domain.ImportJobUploading {
+		return domain.ErrImportJobFinished
+	}
+	job.Status = domain.ImportJobQueued
+	job.Total = total
+	return nil
+}
+
+func (m *mockImportJobRepo) Get(ctx context.Context, ledgerID uuid.UUID, jobID uuid.UUID) (*domain.ImportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.LedgerID != ledgerID {
+		return nil, nil
+	}
+	out := *job
+	return &out, nil
+}
+
+func (m *mockImportJobRepo) Cancel(ctx context.Context, ledgerID uuid.UUID, jobID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	job, ok := m.jobs[jobID]
+	if !ok || job.LedgerID != ledgerID || job.Finished() {
+		return false, nil
+	}
+	job.Status = domain.ImportJobCancelled
+	delete(m.rows, jobID)
+	return true, nil
+}
+
+func (m *mockImportJobRepo) Claim(ctx context.Context, staleAfter time.Duration) (*domain.ImportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.Status == domain.ImportJobQueued {
+			job.Status = domain.ImportJobRunning
+			out := *job
+			return &out, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockImportJobRepo) Status(ctx context.Context, jobID uuid.UUID) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.jobs[jobID].Status, nil
+}
+
+func (m *mockImportJobRepo) Rows(ctx context.Context, jobID uuid.UUID, limit int) ([]domain.ImportJobRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rows := m.rows[jobID]
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return append([]domain.ImportJobRow(nil), rows...), nil
+}
+
+func (m *mockImportJobRepo) SaveProgress(ctx context.Context, jobID uuid.UUID, p domain.ImportJobProgress) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.jobs[jobID]
+	job.Processed = p.Processed
+	job.Accepted += p.Accepted
+	job.Rejected += p.Rejected
+	job.Duplicates += p.Duplicates
+	job.Errors = append(job.Errors, p.Errors...)
+
+	var left []domain.ImportJobRow
+	for _, r := range m.rows[jobID] {
+		if int64(r.Index) >= p.Processed {
+			left = append(left, r)
+		}
+	}
+	m.rows[jobID] = left
+	return nil
+}
+
+func (m *mockImportJobRepo) Finish(ctx context.Context, jobID uuid.UUID, status string, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.jobs[jobID]
+	if job.Status == domain.ImportJobUploading || job.Status == domain.ImportJobRunning {
+		job.Status = status
+		job.Error = reason
+	}
+	delete(m.rows, jobID)
+	return nil
+}
+
+func (m *mockImportJobRepo) Sweep(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for id, job := range m.jobs {
+		if job.Status == domain.ImportJobUploading && time.Since(job.UpdatedAt) > staleAfter {
+			job.Status = domain.ImportJobFailed
+			job.Error = "upload did not finish"
+			n++
+		}
+		if job.Finished() {
+			delete(m.rows, id)
+		}
+	}
+	return n, nil
+}
+
+func newImportJobService(userID uuid.UUID) (*ledgerServiceImpl, *mockExpenseRepo, *mockImportJobRepo) {
+	budgets := &mockBudgetRepo{
+		budgets: map[string]domain.Budget{
+			"food": {LedgerID: userID, Category: "food", Limit: decimal.NewFromInt(100)},
+		},
+	}
+	expenses := &mockExpenseRepo{}
+	jobs := &mockImportJobRepo{}
+
+	svc := New(budgets, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, jobs, &mockAccountRepo{}, &mockLedgerRepo{})
+	return svc.(*ledgerServiceImpl), expenses, jobs
+}
+
+func jobRow(line int, amount int64, category, description string) domain.ImportJobRow {
+	return domain.ImportJobRow{
+		Line: line,
+		Transaction: domain.Transaction{
+			Amount:      decimal.NewFromInt(amount),
+			Category:    category,
+			Description: description,
+			Date:        time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestImportJob_Process(t *testing.T) {
+	userID := uuid.New()
+	svc, expenses, _ := newImportJobService(userID)
+	ctx := ctxWithUser(userID)
+
+	upload, err := svc.StartImportJob(ctx, "csv", "statement.csv")
+	require.NoError(t, err)
+
+	require.NoError(t, upload.AddRows(ctx, []domain.ImportJobRow{
+		jobRow(2, 5, "food", "coffee"),
+		jobRow(3, 5, "food", "coffee"),
+	}))
+	require.NoError(t, upload.AddRows(ctx, []domain.ImportJobRow{
+		jobRow(4, 10, "fun", "cinema"),
+		jobRow(5, 95, "food", "dinner"),
+	}))
+
+	job, err := upload.Finish(ctx)
+	require.NoError(t, err)
+	require.Equal(t, domain.ImportJobQueued, job.Status)
+	require.Equal(t, int64(4), job.Total)
+
+	require.True(t, svc.runNextImportJob(context.Background()))
+	require.False(t, svc.runNextImportJob(context.Background()))
+
+	job, err = svc.GetImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.ImportJobCompleted, job.Status)
+	require.Equal(t, int64(4), job.Processed)
+	require.Equal(t, int64(2), job.Accepted)
+	require.Equal(t, int64(2), job.Rejected)
+
+	sort.Slice(job.Errors, func(i, j int) bool { return job.Errors[i].Index < job.Errors[j].Index })
+	require.Len(t, job.Errors, 2)
+	require.Equal(t, 2, job.Errors[0].Index)
+	require.Equal(t, 4, job.Errors[0].Line)
+	require.Equal(t, 5, job.Errors[1].Line)
+	require.Contains(t, job.Errors[1].Error, "budget exceeded")
+
+	// two coffees are two purchases
+	require.Len(t, expenses.items, 2)
+	require.NotEqual(t, expenses.items[0].Fingerprint, expenses.items[1].Fingerprint)
+}
+
+func TestImportJob_Cancel(t *testing.T) {
+	userID := uuid.New()
+	svc, expenses, _ := newImportJobService(userID)
+	ctx := ctxWithUser(userID)
+
+	upload, err := svc.StartImportJob(ctx, "csv", "statement.csv")
+	require.NoError(t, err)
+	require.NoError(t, upload.AddRows(ctx, []domain.ImportJobRow{jobRow(2, 5, "food", "coffee")}))
+
+	job, err := upload.Finish(ctx)
+	require.NoError(t, err)
+
+	job, err = svc.CancelImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.ImportJobCancelled, job.Status)
+
+	require.False(t, svc.runNextImportJob(context.Background()))
+	require.Empty(t, expenses.items)
+
+	_, err = svc.CancelImportJob(ctx, job.ID)
+	require.ErrorIs(t, err, domain.ErrImportJobFinished)
+
+	// other users cannot see the job
+	_, err = svc.GetImportJob(ctxWithUser(uuid.New()), job.ID)
+	require.ErrorIs(t, err, domain.ErrImportJobNotFound)
+}
+
+func TestImportJob_CancelUpload(t *testing.T) {
+	userID := uuid.New()
+	svc, _, jobs := newImportJobService(userID)
+	ctx := ctxWithUser(userID)
+
+	upload, err := svc.StartImportJob(ctx, "csv", "statement.csv")
+	require.NoError(t, err)
+	require.NoError(t, upload.AddRows(ctx, []domain.ImportJobRow{jobRow(2, 5, "food", "coffee")}))
+
+	job := upload.(*importJobUpload).job
+	_, err = svc.CancelImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Empty(t, jobs.rows[job.ID])
+
+	// the rest of the upload is not staged
+	err = upload.AddRows(ctx, []domain.ImportJobRow{jobRow(3, 5, "food", "tea")})
+	require.ErrorIs(t, err, domain.ErrImportJobFinished)
+	require.Empty(t, jobs.rows[job.ID])
+
+	_, err = upload.Finish(ctx)
+	require.ErrorIs(t, err, domain.ErrImportJobFinished)
+}
+
+func TestImportJob_SweepStaleUpload(t *testing.T) {
+	userID := uuid.New()
+	svc, _, jobs := newImportJobService(userID)
+	ctx := ctxWithUser(userID)
+
+	stale, err := svc.StartImportJob(ctx, "csv", "stale.csv")
+	require.NoError(t, err)
+	require.NoError(t, stale.AddRows(ctx, []domain.ImportJobRow{jobRow(2, 5, "food", "coffee")}))
+	staleID := stale.(*importJobUpload).job.ID
+	jobs.jobs[staleID].UpdatedAt = time.Now().Add(-importJobUploadStaleAfter - time.Minute)
+
+	active, err := svc.StartImportJob(ctx, "csv", "active.csv")
+	require.NoError(t, err)
+	require.NoError(t, active.AddRows(ctx, []domain.ImportJobRow{jobRow(2, 5, "food", "coffee")}))
+	activeID := active.(*importJobUpload).job.ID
+
+	svc.sweepImportJobs(context.Background())
+
+	job, err := svc.GetImportJob(ctx, staleID)
+	require.NoError(t, err)
+	require.Equal(t, domain.ImportJobFailed, job.Status)
+	require.Empty(t, jobs.rows[staleID])
+
+	job, err = svc.GetImportJob(ctx, activeID)
+	require.NoError(t, err)
+	require.Equal(t, domain.ImportJobUploading, job.Status)
+	require.Len(t, jobs.rows[activeID], 1)
+}
+
+func TestImportJob_Resume(t *testing.T) {
+	userID := uuid.New()
+	svc, expenses, jobs := newImportJobService(userID)
+	ctx := ctxWithUser(userID)
+
+	upload, err := svc.StartImportJob(ctx, "csv", "statement.csv")
+	require.NoError(t, err)
+	require.NoError(t, upload.AddRows(ctx, []domain.ImportJobRow{
+		jobRow(2, 5, "food", "coffee"),
+		jobRow(3, 5, "food", "coffee"),
+	}))
+	job, err := upload.Finish(ctx)
+	require.NoError(t, err)
+
+	// the first row was stored before the ledger stopped and the job
+	// went back to the queue
+	first := jobs.rows[job.ID][0].Transaction
+	first.Source = "csv"
+	expenses.items = append(expenses.items, first)
+
+	require.True(t, svc.runNextImportJob(context.Background()))
+
+	job, err = svc.GetImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.ImportJobCompleted, job.Status)
+	require.Equal(t, int64(1), job.Accepted)
+	require.Equal(t, int64(1), job.Duplicates)
+	require.Len(t, expenses.items, 2)
+}
@@ -2,15 +2,41 @@ package service
 
 import (
 	"context"
+	"io"
 	domain2 "ledger/internal/domain"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type LedgerService interface {
 	AddTransaction(ctx context.Context, t domain2.Transaction) error
 	ListTransactions(ctx context.Context) ([]domain2.Transaction, error)
+	ExportTransactions(ctx context.Context, f domain2.TransactionFilter, fn func(domain2.Transaction) error) error
 	SetBudget(ctx context.Context, b domain2.Budget) error
 	ListBudgets(ctx context.Context) ([]domain2.Budget, error)
 	GetReportSummary(ctx context.Context, from time.Time, to time.Time) ([]domain2.ReportSummary, error)
 	BulkAddTransactions(ctx context.Context, txs []domain2.Transaction, workers int) (*domain2.BulkImportResult, error)
+	BulkAddTransactionsAtomic(ctx context.Context, txs []domain2.Transaction) (*domain2.BulkImportResult, error)
+	StartImport(ctx context.Context, source string) (Import, error)
+	StartImportJob(ctx context.Context, source string, fileName string) (ImportJobUpload, error)
+	GetImportJob(ctx context.Context, id uuid.UUID) (*domain2.ImportJob, error)
+	CancelImportJob(ctx context.Context, id uuid.UUID) (*domain2.ImportJob, error)
+	RunImportJobs(ctx context.Context)
+	FindDuplicates(ctx context.Context) ([]domain2.DuplicateGroup, error)
+	SaveImportProfile(ctx context.Context, p domain2.ImportProfile) (*domain2.ImportProfile, error)
+	GetImportProfile(ctx context.Context, name string) (*domain2.ImportProfile, error)
+	ListImportProfiles(ctx context.Context) ([]domain2.ImportProfile, error)
+	DeleteImportProfile(ctx context.Context, name string) error
+	ExportBackup(ctx context.Context, w io.Writer) error
+	RestoreBackup(ctx context.Context, r io.ReaderAt, size int64, mode string) (*domain2.RestoreResult, error)
+	EraseAccount(ctx context.Context, userID uuid.UUID) error
+	CreateLedger(ctx context.Context, name string) (*domain2.Ledger, error)
+	ListLedgers(ctx context.Context) ([]domain2.Ledger, error)
+	CreateInvitation(ctx context.Context, role string) (*domain2.Invitation, error)
+	AcceptInvitation(ctx context.Context, token string) (*domain2.Ledger, error)
+	ListMembers(ctx context.Context) ([]domain2.Member, error)
+	SetMemberRole(ctx context.Context, userID uuid.UUID, role string) (*domain2.Member, error)
+	RemoveMember(ctx context.Context, userID uuid.UUID) error
+	GetMemberSpending(ctx context.Context, from time.Time, to time.Time) ([]domain2.MemberSpending, error)
 }
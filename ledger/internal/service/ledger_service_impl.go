@@ -3,10 +3,13 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"ledger/internal/cache"
 	"ledger/internal/domain"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +23,13 @@ type ledgerServiceImpl struct {
 	budgets  domain.BudgetRepository
 	expenses domain.ExpenseRepository
 	reports  domain.ReportRepository
+	profiles domain.ImportProfileRepository
+	jobs     domain.ImportJobRepository
+	accounts domain.AccountRepository
+	ledgers  domain.LedgerRepository
+
+	// jobQueued wakes the import worker up early
+	jobQueued chan struct{}
 }
 
 type PeriodRange struct {
@@ -33,14 +43,14 @@ func (l *ledgerServiceImpl) GetReportSummary(
 	to time.Time,
 ) ([]domain.ReportSummary, error) {
 
-	userID, err := UserIDFromContext(ctx)
+	m, err := l.member(ctx, domain.LedgerViewer)
 	if err != nil {
 		return nil, err
 	}
 
 	cacheKey := fmt.Sprintf(
 		"report:summary:%s:%s:%s",
-		userID,
+		m.LedgerID,
 		from.Format("2006-01-02"),
 		to.Format("2006-01-02"),
 	)
@@ -58,7 +68,7 @@ func (l *ledgerServiceImpl) GetReportSummary(
 
 	log.Println("CACHE MISS:", cacheKey)
 
-	categories, err := l.budgets.List(ctx, userID)
+	categories, err := l.budgets.List(ctx, m.LedgerID)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +85,7 @@ func (l *ledgerServiceImpl) GetReportSummary(
 
 			total, err := l.expenses.SumByCategoryAndPeriod(
 				ctx,
-				userID,
+				m.LedgerID,
 				category,
 				from,
 				to,
@@ -121,18 +131,47 @@ func (l *ledgerServiceImpl) AddTransaction(
 	t domain.Transaction,
 ) error {
 
-	userID, err := UserIDFromContext(ctx)
+	m, err := l.member(ctx, domain.LedgerEditor)
 	if err != nil {
 		return err
 	}
 
-	t.UserID = userID
+	if err := l.addTransaction(ctx, m, t); err != nil {
+		return err
+	}
+
+	invalidateReportCache(ctx, m.LedgerID)
+	return nil
+}
+
+// addTransaction stores t for m without touching the report cache.
+func (l *ledgerServiceImpl) addTransaction(
+	ctx context.Context,
+	m *domain.Member,
+	t domain.Transaction,
+) error {
+	t.LedgerID = m.LedgerID
+	t.UserID = m.UserID
 
 	if err := domain.CheckValid(t); err != nil {
 		return err
 	}
 
-	budget, err := l.budgets.GetByCategory(ctx, userID, t.Category)
+	if t.Fingerprint == "" && t.ExternalID != "" {
+		t.Fingerprint = domain.Fingerprint(t, 0)
+	}
+	if t.Fingerprint != "" {
+		// checked before the budget so that a re-import is not reported as overspending
+		dup, err := l.expenses.HasFingerprint(ctx, m.LedgerID, t.Source, t.Fingerprint)
+		if err != nil {
+			return err
+		}
+		if dup {
+			return domain.ErrDuplicateTransaction
+		}
+	}
+
+	budget, err := l.budgets.GetByCategory(ctx, m.LedgerID, t.Category)
 	if err != nil {
 		return err
 	}
@@ -147,19 +186,9 @@ func (l *ledgerServiceImpl) AddTransaction(
 		return err
 	}
 
-	var spent decimal.Decimal
-
-	if pr == nil {
-		// бессрочный бюджет
-		spent, err = l.expenses.SumByCategory(ctx, userID, t.Category)
-	} else {
-		spent, err = l.expenses.SumByCategoryAndPeriod(
-			ctx,
-			userID,
-			t.Category,
-			pr.From,
-			pr.To,
-		)
+	spent, err := l.spent(ctx, m.LedgerID, t.Category, pr)
+	if err != nil {
+		return err
 	}
 
 	if spent.Add(t.Amount).GreaterThan(limit) {
@@ -171,12 +200,26 @@ func (l *ledgerServiceImpl) AddTransaction(
 		}
 	}
 
-	if err := l.expenses.Add(ctx, userID, t); err != nil {
-		return err
-	}
+	return l.expenses.Add(ctx, m.LedgerID, t)
+}
 
-	invalidateReportCache(ctx, userID)
-	return nil
+func (l *ledgerServiceImpl) spent(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	category string,
+	pr *PeriodRange,
+) (decimal.Decimal, error) {
+	if pr == nil {
+		// бессрочный бюджет
+		return l.expenses.SumByCategory(ctx, ledgerID, category)
+	}
+	return l.expenses.SumByCategoryAndPeriod(
+		ctx,
+		ledgerID,
+		category,
+		pr.From,
+		pr.To,
+	)
 }
 
 func BudgetPeriodRange(period string, now time.Time) (*PeriodRange, error) {
@@ -227,12 +270,12 @@ func (l *ledgerServiceImpl) ListTransactions(
 	ctx context.Context,
 ) ([]domain.Transaction, error) {
 
-	userID, err := UserIDFromContext(ctx)
+	m, err := l.member(ctx, domain.LedgerViewer)
 	if err != nil {
 		return nil, err
 	}
 
-	return l.expenses.List(ctx, userID)
+	return l.expenses.List(ctx, m.LedgerID)
 }
 
 func (l *ledgerServiceImpl) SetBudget(
@@ -240,22 +283,22 @@ func (l *ledgerServiceImpl) SetBudget(
 	b domain.Budget,
 ) error {
 
-	userID, err := UserIDFromContext(ctx)
+	m, err := l.member(ctx, domain.LedgerEditor)
 	if err != nil {
 		return err
 	}
-	b.UserID = userID
+	b.LedgerID = m.LedgerID
 	if err := domain.CheckValid(b); err != nil {
 		return err
 	}
 
-	if err := l.budgets.Upsert(ctx, userID, b); err != nil {
+	if err := l.budgets.Upsert(ctx, m.LedgerID, b); err != nil {
 		fmt.Println(err)
 		return err
 	}
 
-	invalidateReportCache(ctx, userID)
-	invalidateBudgetsCache(ctx, userID)
+	invalidateReportCache(ctx, m.LedgerID)
+	invalidateBudgetsCache(ctx, m.LedgerID)
 
 	return nil
 }
@@ -264,12 +307,12 @@ func (l *ledgerServiceImpl) ListBudgets(
 	ctx context.Context,
 ) ([]domain.Budget, error) {
 
-	userID, err := UserIDFromContext(ctx)
+	m, err := l.member(ctx, domain.LedgerViewer)
 	if err != nil {
 		return nil, err
 	}
 
-	cacheKey := fmt.Sprintf("budgets:all:%s", userID)
+	cacheKey := fmt.Sprintf("budgets:all:%s", m.LedgerID)
 
 	if cache.Client != nil {
 		data, err := cache.Client.Get(ctx, cacheKey).Bytes()
@@ -284,7 +327,7 @@ func (l *ledgerServiceImpl) ListBudgets(
 
 	log.Println("CACHE MISS:", cacheKey)
 
-	res, err := l.budgets.List(ctx, userID)
+	res, err := l.budgets.List(ctx, m.LedgerID)
 	if err != nil {
 		return nil, err
 	}
@@ -304,79 +347,446 @@ func (l *ledgerServiceImpl) ListBudgets(
 	return res, nil
 }
 
+const (
+	defaultBulkWorkers = 4
+	maxBulkWorkers     = 16
+)
+
+// BulkAddTransactions stores txs row by row. Rows of one category are
+// checked in input order, so the same input always gets the same rows
+// rejected for the budget; different categories run on up to workers
+// goroutines. Errors and duplicates are returned sorted by index.
 func (l *ledgerServiceImpl) BulkAddTransactions(
 	ctx context.Context,
 	txs []domain.Transaction,
 	workers int,
 ) (*domain.BulkImportResult, error) {
 
-	userID, err := UserIDFromContext(ctx)
+	m, err := l.member(ctx, domain.LedgerEditor)
 	if err != nil {
 		return nil, err
 	}
 
-	type job struct {
-		index int
-		tx    domain.Transaction
+	switch {
+	case workers < 0:
+		return nil, &domain.ValidationError{
+			Field:   "workers",
+			Message: "must not be negative",
+		}
+	case workers == 0:
+		workers = defaultBulkWorkers
+	case workers > maxBulkWorkers:
+		workers = maxBulkWorkers
 	}
 
-	jobs := make(chan job)
-	//results := make(chan error)
+	assignFingerprints(txs)
 
-	var accepted, rejected int64
+	var accepted, rejected, duplicates int64
 	var errorsList []domain.BulkImportError
+	var duplicateIndexes []int
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
+	// repeated fingerprints within the batch are resolved up front, so the
+	// first row wins no matter which category finishes first
+	seen := make(map[string]bool)
+	var groups [][]int
+	groupOf := make(map[string]int)
+
+	for i := range txs {
+		if fp := txs[i].Fingerprint; fp != "" {
+			if seen[fp] {
+				duplicates++
+				duplicateIndexes = append(duplicateIndexes, i)
+				continue
+			}
+			seen[fp] = true
+		}
+
+		g, ok := groupOf[txs[i].Category]
+		if !ok {
+			g = len(groups)
+			groupOf[txs[i].Category] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+
+	if workers > len(groups) {
+		workers = len(groups)
+	}
+
+	jobs := make(chan []int)
+
 	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for j := range jobs {
-				j.tx.UserID = userID
-				err := l.AddTransaction(ctx, j.tx)
-				if err != nil {
-					atomic.AddInt64(&rejected, 1)
-					mu.Lock()
-					errorsList = append(errorsList, domain.BulkImportError{
-						Index: j.index,
-						Error: err.Error(),
-					})
-					mu.Unlock()
-				} else {
-					atomic.AddInt64(&accepted, 1)
+			for group := range jobs {
+				for _, index := range group {
+					err := l.addTransaction(ctx, m, txs[index])
+					if errors.Is(err, domain.ErrDuplicateTransaction) {
+						atomic.AddInt64(&duplicates, 1)
+						mu.Lock()
+						duplicateIndexes = append(duplicateIndexes, index)
+						mu.Unlock()
+					} else if err != nil {
+						atomic.AddInt64(&rejected, 1)
+						mu.Lock()
+						errorsList = append(errorsList, domain.BulkImportError{
+							Index: index,
+							Error: err.Error(),
+						})
+						mu.Unlock()
+					} else {
+						atomic.AddInt64(&accepted, 1)
+					}
 				}
 			}
 		}()
 	}
 
-	go func() {
-		for i, tx := range txs {
-			jobs <- job{i, tx}
-		}
-		close(jobs)
-	}()
+	for _, group := range groups {
+		jobs <- group
+	}
+	close(jobs)
 
 	wg.Wait()
 
-	invalidateReportCache(ctx, userID)
+	invalidateReportCache(ctx, m.LedgerID)
+
+	sort.Ints(duplicateIndexes)
+	sort.Slice(errorsList, func(i, j int) bool {
+		return errorsList[i].Index < errorsList[j].Index
+	})
 
 	return &domain.BulkImportResult{
-		Accepted: accepted,
-		Rejected: rejected,
-		Errors:   errorsList,
+		Accepted:         accepted,
+		Rejected:         rejected,
+		Errors:           errorsList,
+		Duplicates:       duplicates,
+		DuplicateIndexes: duplicateIndexes,
 	}, nil
 }
 
+// BulkAddTransactionsAtomic stores either all of txs or none of them. Rows
+// are validated and budget-checked against a running total per category and
+// budget period; every failing row is reported.
+func (l *ledgerServiceImpl) BulkAddTransactionsAtomic(
+	ctx context.Context,
+	txs []domain.Transaction,
+) (*domain.BulkImportResult, error) {
+
+	m, err := l.member(ctx, domain.LedgerEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	assignFingerprints(txs)
+
+	var (
+		res       domain.BulkImportResult
+		tracker   = newBudgetTracker(l, m.LedgerID)
+		seen      = make(map[string]bool)
+		insert    []domain.Transaction
+		insertIdx []int
+	)
+
+	reject := func(i int, err error) {
+		res.Errors = append(res.Errors, domain.BulkImportError{
+			Index: i,
+			Error: err.Error(),
+		})
+	}
+
+	for i, t := range txs {
+		t.LedgerID = m.LedgerID
+		t.UserID = m.UserID
+
+		if err := domain.CheckValid(t); err != nil {
+			reject(i, err)
+			continue
+		}
+
+		if t.Fingerprint != "" {
+			if seen[t.Fingerprint] {
+				res.DuplicateIndexes = append(res.DuplicateIndexes, i)
+				continue
+			}
+			dup, err := l.expenses.HasFingerprint(ctx, m.LedgerID, t.Source, t.Fingerprint)
+			if err != nil {
+				return nil, err
+			}
+			if dup {
+				res.DuplicateIndexes = append(res.DuplicateIndexes, i)
+				continue
+			}
+			seen[t.Fingerprint] = true
+		}
+
+		rejected, err := tracker.reserve(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if rejected != nil {
+			reject(i, rejected)
+			continue
+		}
+
+		insert = append(insert, t)
+		insertIdx = append(insertIdx, i)
+	}
+
+	res.Duplicates = int64(len(res.DuplicateIndexes))
+
+	if len(res.Errors) == 0 && len(insert) > 0 {
+		err := l.expenses.AddAll(ctx, m.LedgerID, insert)
+
+		var rowErr *domain.BulkRowError
+		switch {
+		case errors.As(err, &rowErr):
+			// e.g. a fingerprint stored concurrently by another request
+			reject(insertIdx[rowErr.Index], rowErr.Err)
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	if len(res.Errors) > 0 {
+		// nothing was stored
+		res.Rejected = int64(len(txs)) - res.Duplicates
+		return &res, nil
+	}
+
+	res.Accepted = int64(len(insert))
+	invalidateReportCache(ctx, m.LedgerID)
+
+	return &res, nil
+}
+
+type periodKey struct {
+	category string
+	from     time.Time
+}
+
+// budgetTracker checks rows against their budgets with a running total per
+// category and budget period, so rows that are not stored yet count too.
+type budgetTracker struct {
+	l        *ledgerServiceImpl
+	ledgerID uuid.UUID
+	budgets  map[string]*domain.Budget
+	spent    map[periodKey]decimal.Decimal
+}
+
+func newBudgetTracker(l *ledgerServiceImpl, ledgerID uuid.UUID) *budgetTracker {
+	return &budgetTracker{
+		l:        l,
+		ledgerID: ledgerID,
+		budgets:  make(map[string]*domain.Budget),
+		spent:    make(map[periodKey]decimal.Decimal),
+	}
+}
+
+// reserve adds t to the running total. rejected explains why the row does
+// not fit; err is a repository failure.
+func (b *budgetTracker) reserve(
+	ctx context.Context,
+	t domain.Transaction,
+) (rejected error, err error) {
+	budget, ok := b.budgets[t.Category]
+	if !ok {
+		if budget, err = b.l.budgets.GetByCategory(ctx, b.ledgerID, t.Category); err != nil {
+			return nil, err
+		}
+		b.budgets[t.Category] = budget
+	}
+	if budget == nil {
+		return domain.ErrBudgetNotFound, nil
+	}
+
+	pr, err := BudgetPeriodRange(budget.Period, t.Date)
+	if err != nil {
+		return err, nil
+	}
+
+	key := periodKey{category: t.Category}
+	if pr != nil {
+		key.from = pr.From
+	}
+
+	current, ok := b.spent[key]
+	if !ok {
+		if current, err = b.l.spent(ctx, b.ledgerID, t.Category, pr); err != nil {
+			return nil, err
+		}
+		b.spent[key] = current
+	}
+
+	if current.Add(t.Amount).GreaterThan(budget.Limit) {
+		return &domain.BudgetExceededError{
+			Category: t.Category,
+			Limit:    budget.Limit,
+			Current:  current,
+			Amount:   t.Amount,
+		}, nil
+	}
+
+	b.spent[key] = current.Add(t.Amount)
+	return nil, nil
+}
+
+// release takes back a reserved row that was not stored after all.
+func (b *budgetTracker) release(t domain.Transaction) {
+	budget := b.budgets[t.Category]
+	if budget == nil {
+		return
+	}
+
+	key := periodKey{category: t.Category}
+	if pr, _ := BudgetPeriodRange(budget.Period, t.Date); pr != nil {
+		key.from = pr.From
+	}
+	b.spent[key] = b.spent[key].Sub(t.Amount)
+}
+
+// assignFingerprints gives identical rows within one batch consecutive
+// occurrence numbers, so uploading the same file twice produces the same
+// fingerprints.
+func assignFingerprints(txs []domain.Transaction) {
+	occurrences := make(map[string]int)
+	for i := range txs {
+		if txs[i].Fingerprint != "" {
+			continue
+		}
+		key := domain.ContentKey(txs[i])
+		txs[i].Fingerprint = domain.Fingerprint(txs[i], occurrences[key])
+		occurrences[key]++
+	}
+}
+
+func (l *ledgerServiceImpl) FindDuplicates(
+	ctx context.Context,
+) ([]domain.DuplicateGroup, error) {
+
+	m, err := l.member(ctx, domain.LedgerViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.expenses.FindDuplicates(ctx, m.LedgerID)
+}
+
+func (l *ledgerServiceImpl) SaveImportProfile(
+	ctx context.Context,
+	p domain.ImportProfile,
+) (*domain.ImportProfile, error) {
+
+	m, err := l.member(ctx, domain.LedgerEditor)
+	if err != nil {
+		return nil, err
+	}
+	p.LedgerID = m.LedgerID
+	p.Name = strings.TrimSpace(p.Name)
+
+	if p.Delimiter == "" {
+		p.Delimiter = ","
+	}
+	if p.DateFormat == "" {
+		p.DateFormat = "YYYY-MM-DD"
+	}
+	if p.Encoding == "" {
+		p.Encoding = "utf-8"
+	}
+	if enc, ok := domain.CanonicalEncoding(p.Encoding); ok {
+		p.Encoding = enc
+	}
+	if p.SignConvention == "" {
+		p.SignConvention = "positive"
+	}
+
+	if err := domain.CheckValid(p); err != nil {
+		return nil, err
+	}
+
+	if err := l.profiles.Upsert(ctx, m.LedgerID, p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (l *ledgerServiceImpl) GetImportProfile(
+	ctx context.Context,
+	name string,
+) (*domain.ImportProfile, error) {
+
+	m, err := l.member(ctx, domain.LedgerViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := l.profiles.GetByName(ctx, m.LedgerID, strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, domain.ErrImportProfileNotFound
+	}
+
+	return p, nil
+}
+
+func (l *ledgerServiceImpl) ListImportProfiles(
+	ctx context.Context,
+) ([]domain.ImportProfile, error) {
+
+	m, err := l.member(ctx, domain.LedgerViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.profiles.List(ctx, m.LedgerID)
+}
+
+func (l *ledgerServiceImpl) DeleteImportProfile(
+	ctx context.Context,
+	name string,
+) error {
+
+	m, err := l.member(ctx, domain.LedgerEditor)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := l.profiles.Delete(ctx, m.LedgerID, strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domain.ErrImportProfileNotFound
+	}
+
+	return nil
+}
+
 func New(
 	b domain.BudgetRepository,
 	e domain.ExpenseRepository,
 	r domain.ReportRepository,
+	p domain.ImportProfileRepository,
+	j domain.ImportJobRepository,
+	a domain.AccountRepository,
+	lr domain.LedgerRepository,
 ) LedgerService {
 	return &ledgerServiceImpl{
-		budgets:  b,
-		expenses: e,
-		reports:  r,
+		budgets:   b,
+		expenses:  e,
+		reports:   r,
+		profiles:  p,
+		jobs:      j,
+		accounts:  a,
+		ledgers:   lr,
+		jobQueued: make(chan struct{}, 1),
 	}
 }
 
@@ -428,12 +838,12 @@ func UserIDFromContext(ctx context.Context) (uuid.UUID, error) {
 	return userID, nil
 }
 
-func invalidateReportCache(ctx context.Context, userID uuid.UUID) {
+func invalidateReportCache(ctx context.Context, ledgerID uuid.UUID) {
 	if cache.Client == nil {
 		return
 	}
 
-	pattern := fmt.Sprintf("report:summary:%s:*", userID.String())
+	pattern := fmt.Sprintf("report:summary:%s:*", ledgerID.String())
 
 	iter := cache.Client.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
@@ -445,11 +855,11 @@ func invalidateReportCache(ctx context.Context, userID uuid.UUID) {
 	}
 }
 
-func invalidateBudgetsCache(ctx context.Context, userID uuid.UUID) {
+func invalidateBudgetsCache(ctx context.Context, ledgerID uuid.UUID) {
 	if cache.Client == nil {
 		return
 	}
 
-	key := fmt.Sprintf("budgets:all:%s", userID.String())
+	key := fmt.Sprintf("budgets:all:%s", ledgerID.String())
 	_ = cache.Client.Del(ctx, key).Err()
 }
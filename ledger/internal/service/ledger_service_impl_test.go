@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -39,23 +41,132 @@ func (m *mockBudgetRepo) List(ctx context.Context, userID uuid.UUID) ([]domain.B
 }
 
 type mockExpenseRepo struct {
-	items []domain.Transaction
+	mu      sync.Mutex
+	items   []domain.Transaction
+	batches int
+	pages   int
 }
 
 func (m *mockExpenseRepo) Add(ctx context.Context, userID uuid.UUID, t domain.Transaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t.Fingerprint != "" {
+		for _, e := range m.items {
+			if e.Source == t.Source && e.Fingerprint == t.Fingerprint {
+				return domain.ErrDuplicateTransaction
+			}
+		}
+	}
 	m.items = append(m.items, t)
 	return nil
 }
 
+func (m *mockExpenseRepo) AddAll(ctx context.Context, userID uuid.UUID, txs []domain.Transaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items = append(m.items, txs...)
+	return nil
+}
+
+func (m *mockExpenseRepo) AddBatch(ctx context.Context, ledgerID uuid.UUID, userID uuid.UUID, source string, txs []domain.Transaction) ([]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.batches++
+
+	var skipped []int
+	for i, t := range txs {
+		if m.has(source, t.Fingerprint) {
+			skipped = append(skipped, i)
+			continue
+		}
+		m.items = append(m.items, t)
+	}
+	return skipped, nil
+}
+
+func (m *mockExpenseRepo) ExistingFingerprints(ctx context.Context, userID uuid.UUID, source string, fingerprints []string) (map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing := make(map[string]bool)
+	for _, fp := range fingerprints {
+		if m.has(source, fp) {
+			existing[fp] = true
+		}
+	}
+	return existing, nil
+}
+
+func (m *mockExpenseRepo) has(source, fingerprint string) bool {
+	for _, e := range m.items {
+		if e.Source == source && e.Fingerprint == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockExpenseRepo) HasFingerprint(ctx context.Context, userID uuid.UUID, source, fingerprint string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.items {
+		if e.Source == source && e.Fingerprint == fingerprint {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockExpenseRepo) FindDuplicates(ctx context.Context, userID uuid.UUID) ([]domain.DuplicateGroup, error) {
+	return nil, nil
+}
+
 func (m *mockExpenseRepo) List(ctx context.Context, userID uuid.UUID) ([]domain.Transaction, error) {
 	return m.items, nil
 }
 
+func (m *mockExpenseRepo) ListAfter(
+	ctx context.Context,
+	userID uuid.UUID,
+	f domain.TransactionFilter,
+	after domain.Transaction,
+	limit int,
+) ([]domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []domain.Transaction
+	for _, t := range m.items {
+		if t.Date.Before(after.Date) || t.Date.Equal(after.Date) && t.ID <= after.ID {
+			continue
+		}
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Date.Equal(out[j].Date) {
+			return out[i].Date.Before(out[j].Date)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	m.pages++
+	return out, nil
+}
+
 func (m *mockExpenseRepo) BudgetLimit(ctx context.Context, userID uuid.UUID, category string) (decimal.Decimal, error) {
 	return decimal.NewFromInt(100), nil
 }
 
 func (m *mockExpenseRepo) SumByCategory(ctx context.Context, userID uuid.UUID, category string) (decimal.Decimal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	sum := decimal.Zero
 	for _, t := range m.items {
 		if t.Category == category {
@@ -91,6 +202,49 @@ func (m *mockReportRepo) GetReportSummary(
 	}, nil
 }
 
+func (m *mockReportRepo) MemberSpending(
+	ctx context.Context,
+	ledgerID uuid.UUID,
+	from time.Time,
+	to time.Time,
+) ([]domain.MemberSpending, error) {
+	return nil, nil
+}
+
+type mockImportProfileRepo struct {
+	profiles map[string]domain.ImportProfile
+}
+
+func (m *mockImportProfileRepo) Upsert(ctx context.Context, userID uuid.UUID, p domain.ImportProfile) error {
+	if m.profiles == nil {
+		m.profiles = make(map[string]domain.ImportProfile)
+	}
+	m.profiles[p.Name] = p
+	return nil
+}
+
+func (m *mockImportProfileRepo) GetByName(ctx context.Context, userID uuid.UUID, name string) (*domain.ImportProfile, error) {
+	p, ok := m.profiles[name]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func (m *mockImportProfileRepo) List(ctx context.Context, userID uuid.UUID) ([]domain.ImportProfile, error) {
+	res := make([]domain.ImportProfile, 0, len(m.profiles))
+	for _, p := range m.profiles {
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+func (m *mockImportProfileRepo) Delete(ctx context.Context, userID uuid.UUID, name string) (bool, error) {
+	_, ok := m.profiles[name]
+	delete(m.profiles, name)
+	return ok, nil
+}
+
 func ctxWithUser(userID uuid.UUID) context.Context {
 	md := metadata.New(map[string]string{
 		"user_id": userID.String(),
@@ -104,7 +258,7 @@ func TestAddTransaction_OK(t *testing.T) {
 	budgets := &mockBudgetRepo{
 		budgets: map[string]domain.Budget{
 			"food": {
-				UserID:   userID,
+				LedgerID: userID,
 				Category: "food",
 				Limit:    decimal.NewFromInt(100),
 				Period:   "monthly",
@@ -115,7 +269,7 @@ func TestAddTransaction_OK(t *testing.T) {
 	expenses := &mockExpenseRepo{}
 	reports := &mockReportRepo{}
 
-	svc := New(budgets, expenses, reports)
+	svc := New(budgets, expenses, reports, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
 
 	tx := domain.Transaction{
 		Amount:   decimal.NewFromInt(30),
@@ -136,7 +290,7 @@ func TestAddTransaction_BudgetExceeded(t *testing.T) {
 	budgets := &mockBudgetRepo{
 		budgets: map[string]domain.Budget{
 			"food": {
-				UserID:   userID,
+				LedgerID: userID,
 				Category: "food",
 				Limit:    decimal.NewFromInt(10),
 				Period:   "monthly",
@@ -147,7 +301,7 @@ func TestAddTransaction_BudgetExceeded(t *testing.T) {
 	expenses := &mockExpenseRepo{}
 	reports := &mockReportRepo{}
 
-	svc := New(budgets, expenses, reports)
+	svc := New(budgets, expenses, reports, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
 
 	tx := domain.Transaction{
 		Amount:   decimal.NewFromInt(50),
@@ -166,7 +320,7 @@ func TestListBudgets(t *testing.T) {
 	budgets := &mockBudgetRepo{
 		budgets: map[string]domain.Budget{
 			"food": {
-				UserID:   userID,
+				LedgerID: userID,
 				Category: "food",
 				Limit:    decimal.NewFromInt(100),
 				Period:   "monthly",
@@ -174,7 +328,7 @@ func TestListBudgets(t *testing.T) {
 		},
 	}
 
-	svc := New(budgets, &mockExpenseRepo{}, &mockReportRepo{})
+	svc := New(budgets, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
 
 	res, err := svc.ListBudgets(ctxWithUser(userID))
 	require.NoError(t, err)
@@ -188,7 +342,7 @@ func TestGetReportSummary(t *testing.T) {
 	budgets := &mockBudgetRepo{
 		budgets: map[string]domain.Budget{
 			"food": {
-				UserID:   userID,
+				LedgerID: userID,
 				Category: "food",
 				Limit:    decimal.NewFromInt(100),
 				Period:   "monthly",
@@ -207,7 +361,7 @@ func TestGetReportSummary(t *testing.T) {
 		},
 	}
 
-	svc := New(budgets, expenses, &mockReportRepo{})
+	svc := New(budgets, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
 
 	from := time.Now().AddDate(0, 0, -7)
 	to := time.Now()
@@ -220,6 +374,291 @@ func TestGetReportSummary(t *testing.T) {
 	require.True(t, res[0].Total.Equal(decimal.NewFromInt(50)))
 }
 
+func TestBulkAddTransactions_SkipsDuplicates(t *testing.T) {
+	userID := uuid.New()
+
+	budgets := &mockBudgetRepo{
+		budgets: map[string]domain.Budget{
+			"food": {
+				LedgerID: userID,
+				Category: "food",
+				Limit:    decimal.NewFromInt(1000),
+			},
+		},
+	}
+	expenses := &mockExpenseRepo{}
+
+	svc := New(budgets, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	batch := func() []domain.Transaction {
+		return []domain.Transaction{
+			{Amount: decimal.NewFromInt(5), Category: "food", Description: "Coffee", Date: day, Source: "csv"},
+			{Amount: decimal.NewFromInt(5), Category: "food", Description: "coffee", Date: day, Source: "csv"},
+			{Amount: decimal.NewFromInt(40), Category: "food", Date: day, ExternalID: "F1", Source: "csv"},
+		}
+	}
+
+	res, err := svc.BulkAddTransactions(ctxWithUser(userID), batch(), 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), res.Accepted)
+	require.Zero(t, res.Duplicates)
+
+	res, err = svc.BulkAddTransactions(ctxWithUser(userID), batch(), 2)
+	require.NoError(t, err)
+	require.Zero(t, res.Accepted)
+	require.Zero(t, res.Rejected)
+	require.Equal(t, int64(3), res.Duplicates)
+	require.Equal(t, []int{0, 1, 2}, res.DuplicateIndexes)
+	require.Len(t, expenses.items, 3)
+}
+
+func TestBulkAddTransactions_Ordered(t *testing.T) {
+	userID := uuid.New()
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	for run := 0; run < 20; run++ {
+		budgets := &mockBudgetRepo{
+			budgets: map[string]domain.Budget{
+				"food": {LedgerID: userID, Category: "food", Limit: decimal.NewFromInt(100)},
+				"fun":  {LedgerID: userID, Category: "fun", Limit: decimal.NewFromInt(50)},
+			},
+		}
+		expenses := &mockExpenseRepo{}
+
+		svc := New(budgets, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+		res, err := svc.BulkAddTransactions(ctxWithUser(userID), []domain.Transaction{
+			{Amount: decimal.NewFromInt(60), Category: "food", Description: "a", Date: day},
+			{Amount: decimal.NewFromInt(40), Category: "fun", Description: "b", Date: day},
+			{Amount: decimal.NewFromInt(50), Category: "food", Description: "c", Date: day},
+			{Amount: decimal.NewFromInt(20), Category: "fun", Description: "d", Date: day},
+			{Amount: decimal.NewFromInt(40), Category: "food", Description: "e", Date: day},
+			{Amount: decimal.NewFromInt(10), Category: "fun", Description: "f", Date: day},
+		}, 8)
+		require.NoError(t, err)
+
+		require.Equal(t, int64(4), res.Accepted)
+		require.Equal(t, int64(2), res.Rejected)
+		require.Len(t, res.Errors, 2)
+		require.Equal(t, 2, res.Errors[0].Index)
+		require.Equal(t, 3, res.Errors[1].Index)
+	}
+}
+
+func TestBulkAddTransactions_Workers(t *testing.T) {
+	userID := uuid.New()
+
+	budgets := &mockBudgetRepo{
+		budgets: map[string]domain.Budget{
+			"food": {LedgerID: userID, Category: "food", Limit: decimal.NewFromInt(100)},
+		},
+	}
+
+	svc := New(budgets, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	txs := []domain.Transaction{
+		{Amount: decimal.NewFromInt(10), Category: "food", Date: time.Now()},
+	}
+
+	_, err := svc.BulkAddTransactions(ctxWithUser(userID), txs, -1)
+	var vErr *domain.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	require.Equal(t, "workers", vErr.Field)
+
+	// zero means the default instead of blocking forever
+	res, err := svc.BulkAddTransactions(ctxWithUser(userID), txs, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), res.Accepted)
+
+	res, err = svc.BulkAddTransactions(ctxWithUser(userID), nil, 1000)
+	require.NoError(t, err)
+	require.Zero(t, res.Accepted)
+}
+
+func TestBulkAddTransactionsAtomic_OK(t *testing.T) {
+	userID := uuid.New()
+
+	budgets := &mockBudgetRepo{
+		budgets: map[string]domain.Budget{
+			"food": {
+				LedgerID: userID,
+				Category: "food",
+				Limit:    decimal.NewFromInt(100),
+				Period:   "monthly",
+			},
+		},
+	}
+	expenses := &mockExpenseRepo{}
+
+	svc := New(budgets, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	jan := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	// 90 in January and 90 in February fit a monthly limit of 100
+	res, err := svc.BulkAddTransactionsAtomic(ctxWithUser(userID), []domain.Transaction{
+		{Amount: decimal.NewFromInt(60), Category: "food", Description: "a", Date: jan},
+		{Amount: decimal.NewFromInt(30), Category: "food", Description: "b", Date: jan},
+		{Amount: decimal.NewFromInt(90), Category: "food", Description: "c", Date: feb},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), res.Accepted)
+	require.Empty(t, res.Errors)
+	require.Len(t, expenses.items, 3)
+}
+
+func TestBulkAddTransactionsAtomic_AllOrNothing(t *testing.T) {
+	userID := uuid.New()
+
+	budgets := &mockBudgetRepo{
+		budgets: map[string]domain.Budget{
+			"food": {
+				LedgerID: userID,
+				Category: "food",
+				Limit:    decimal.NewFromInt(100),
+				Period:   "monthly",
+			},
+		},
+	}
+	expenses := &mockExpenseRepo{}
+
+	svc := New(budgets, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	res, err := svc.BulkAddTransactionsAtomic(ctxWithUser(userID), []domain.Transaction{
+		{Amount: decimal.NewFromInt(60), Category: "food", Description: "a", Date: day},
+		{Amount: decimal.NewFromInt(50), Category: "food", Description: "b", Date: day},
+		{Amount: decimal.NewFromInt(10), Category: "fun", Description: "c", Date: day},
+		{Amount: decimal.Zero, Category: "food", Description: "d", Date: day},
+		{Amount: decimal.NewFromInt(40), Category: "food", Description: "e", Date: day},
+	})
+	require.NoError(t, err)
+
+	require.Zero(t, res.Accepted)
+	require.Equal(t, int64(5), res.Rejected)
+	require.Len(t, res.Errors, 3)
+	require.Equal(t, 1, res.Errors[0].Index)
+	require.Contains(t, res.Errors[0].Error, "budget exceeded")
+	require.Equal(t, 2, res.Errors[1].Index)
+	require.Equal(t, 3, res.Errors[2].Index)
+	require.Empty(t, expenses.items)
+}
+
+func TestStartImport_Batches(t *testing.T) {
+	userID := uuid.New()
+
+	budgets := &mockBudgetRepo{
+		budgets: map[string]domain.Budget{
+			"food": {LedgerID: userID, Category: "food", Limit: decimal.NewFromInt(100)},
+		},
+	}
+	expenses := &mockExpenseRepo{}
+
+	svc := New(budgets, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	day := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	coffee := domain.Transaction{Amount: decimal.NewFromInt(5), Category: "food", Description: "Coffee", Date: day}
+
+	imp, err := svc.StartImport(ctxWithUser(userID), "csv")
+	require.NoError(t, err)
+
+	res, err := imp.AddBatch(ctxWithUser(userID), []domain.Transaction{
+		coffee,
+		{Amount: decimal.NewFromInt(60), Category: "food", Description: "a", Date: day},
+		{Amount: decimal.NewFromInt(10), Category: "fun", Description: "b", Date: day},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.Accepted)
+	require.Equal(t, int64(1), res.Rejected)
+	require.Equal(t, 2, res.Errors[0].Index)
+
+	// the same coffee in the next batch is a second purchase, not a
+	// duplicate; the running total still counts the first batch
+	res, err = imp.AddBatch(ctxWithUser(userID), []domain.Transaction{
+		coffee,
+		{Amount: decimal.NewFromInt(40), Category: "food", Description: "c", Date: day},
+		{Amount: decimal.NewFromInt(1), Category: "food", Date: day, ExternalID: "F1"},
+		{Amount: decimal.NewFromInt(1), Category: "food", Date: day, ExternalID: "F1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.Accepted)
+	require.Equal(t, int64(1), res.Rejected)
+	require.Equal(t, 1, res.Errors[0].Index)
+	require.Contains(t, res.Errors[0].Error, "budget exceeded")
+	require.Equal(t, []int{3}, res.DuplicateIndexes)
+
+	require.Len(t, expenses.items, 4)
+	require.Equal(t, 2, expenses.batches)
+
+	// importing the same file again stores nothing
+	imp, err = svc.StartImport(ctxWithUser(userID), "csv")
+	require.NoError(t, err)
+
+	res, err = imp.AddBatch(ctxWithUser(userID), []domain.Transaction{coffee, coffee})
+	require.NoError(t, err)
+	require.Zero(t, res.Accepted)
+	require.Equal(t, []int{0, 1}, res.DuplicateIndexes)
+}
+
+func TestSaveImportProfile_Defaults(t *testing.T) {
+	userID := uuid.New()
+	profiles := &mockImportProfileRepo{}
+
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, profiles, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	saved, err := svc.SaveImportProfile(ctxWithUser(userID), domain.ImportProfile{
+		Name:         " sber ",
+		AmountColumn: "Amount",
+		DateColumn:   "Date",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "sber", saved.Name)
+	require.Equal(t, userID, saved.LedgerID)
+	require.Equal(t, ",", saved.Delimiter)
+	require.Equal(t, "YYYY-MM-DD", saved.DateFormat)
+	require.Equal(t, "utf-8", saved.Encoding)
+	require.Equal(t, "positive", saved.SignConvention)
+	require.Contains(t, profiles.profiles, "sber")
+}
+
+func TestSaveImportProfile_EncodingAlias(t *testing.T) {
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	saved, err := svc.SaveImportProfile(ctxWithUser(uuid.New()), domain.ImportProfile{
+		Name:         "bank",
+		AmountColumn: "Amount",
+		DateColumn:   "Date",
+		Encoding:     "CP1251",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "windows-1251", saved.Encoding)
+}
+
+func TestSaveImportProfile_Invalid(t *testing.T) {
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	_, err := svc.SaveImportProfile(ctxWithUser(uuid.New()), domain.ImportProfile{
+		Name:         "bank",
+		AmountColumn: "Amount",
+		DateColumn:   "Date",
+		Delimiter:    ";;",
+	})
+	require.Error(t, err)
+	require.IsType(t, &domain.ValidationError{}, err)
+}
+
+func TestGetImportProfile_NotFound(t *testing.T) {
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	_, err := svc.GetImportProfile(ctxWithUser(uuid.New()), "missing")
+	require.ErrorIs(t, err, domain.ErrImportProfileNotFound)
+
+	err = svc.DeleteImportProfile(ctxWithUser(uuid.New()), "missing")
+	require.ErrorIs(t, err, domain.ErrImportProfileNotFound)
+}
+
 func TestUserIDFromContext(t *testing.T) {
 	id := uuid.New()
 	ctx := ctxWithUser(id)
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+// LedgerIDFromContext returns the ledger a request is about, which the
+// gateway passes as "ledger_id" metadata. Requests without one are about
+// the personal ledger of userID.
+func LedgerIDFromContext(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get("ledger_id")
+	if len(values) == 0 || values[0] == "" {
+		return userID, nil
+	}
+
+	ledgerID, err := uuid.Parse(values[0])
+	if err != nil {
+		return uuid.Nil, domain.ErrLedgerNotFound
+	}
+	return ledgerID, nil
+}
+
+// member returns the caller's membership of the ledger the request is
+// about, provided it allows what role allows. Ledgers the caller is no
+// member of are not found; the personal ledger is created on first use.
+func (l *ledgerServiceImpl) member(
+	ctx context.Context,
+	role string,
+) (*domain.Member, error) {
+
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerID, err := LedgerIDFromContext(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := l.ledgers.Member(ctx, ledgerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil && ledgerID == userID {
+		if m, err = l.createPersonalLedger(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	if m == nil {
+		return nil, domain.ErrLedgerNotFound
+	}
+
+	if !m.Can(role) {
+		return nil, domain.ErrLedgerForbidden
+	}
+	return m, nil
+}
+
+func (l *ledgerServiceImpl) createPersonalLedger(
+	ctx context.Context,
+	userID uuid.UUID,
+) (*domain.Member, error) {
+	err := l.ledgers.Create(ctx, domain.Ledger{
+		ID:      userID,
+		Name:    domain.PersonalLedgerName,
+		OwnerID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Member{
+		LedgerID: userID,
+		UserID:   userID,
+		Role:     domain.LedgerOwner,
+		JoinedAt: time.Now(),
+	}, nil
+}
+
+// CreateLedger creates a ledger owned by the caller.
+func (l *ledgerServiceImpl) CreateLedger(
+	ctx context.Context,
+	name string,
+) (*domain.Ledger, error) {
+
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := domain.Ledger{
+		ID:        uuid.New(),
+		Name:      strings.TrimSpace(name),
+		OwnerID:   userID,
+		Role:      domain.LedgerOwner,
+		CreatedAt: time.Now(),
+	}
+	if err := domain.CheckValid(ledger); err != nil {
+		return nil, err
+	}
+
+	if err := l.ledgers.Create(ctx, ledger); err != nil {
+		return nil, err
+	}
+
+	return &ledger, nil
+}
+
+// ListLedgers returns the ledgers the caller is a member of, the personal
+// one included.
+func (l *ledgerServiceImpl) ListLedgers(
+	ctx context.Context,
+) ([]domain.Ledger, error) {
+
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	personal, err := l.ledgers.Member(ctx, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if personal == nil {
+		if _, err := l.createPersonalLedger(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.ledgers.ListForUser(ctx, userID)
+}
+
+// CreateInvitation lets the owner invite someone to the ledger. The token
+// is returned only here and can be used once within a week.
+func (l *ledgerServiceImpl) CreateInvitation(
+	ctx context.Context,
+	role string,
+) (*domain.Invitation, error) {
+
+	m, err := l.member(ctx, domain.LedgerOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := domain.Invitation{
+		LedgerID:  m.LedgerID,
+		Role:      role,
+		InvitedBy: m.UserID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+	if err := domain.CheckValid(inv); err != nil {
+		return nil, err
+	}
+
+	inv.Token, err = newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.ledgers.CreateInvitation(ctx, hashToken(inv.Token), inv); err != nil {
+		return nil, err
+	}
+
+	return &inv, nil
+}
+
+// AcceptInvitation makes the caller a member of the ledger the token
+// invites to. Members keep their role when invited again.
+func (l *ledgerServiceImpl) AcceptInvitation(
+	ctx context.Context,
+	token string,
+) (*domain.Ledger, error) {
+
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := l.ledgers.AcceptInvitation(ctx, hashToken(strings.TrimSpace(token)), userID)
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, domain.ErrInvitationNotFound
+	}
+
+	ledger, err := l.ledgers.Get(ctx, inv.LedgerID)
+	if err != nil {
+		return nil, err
+	}
+	if ledger == nil {
+		return nil, domain.ErrInvitationNotFound
+	}
+
+	m, err := l.ledgers.Member(ctx, inv.LedgerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		ledger.Role = m.Role
+	}
+
+	return ledger, nil
+}
+
+func (l *ledgerServiceImpl) ListMembers(
+	ctx context.Context,
+) ([]domain.Member, error) {
+
+	m, err := l.member(ctx, domain.LedgerViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.ledgers.Members(ctx, m.LedgerID)
+}
+
+// SetMemberRole lets the owner make a member an editor or a viewer.
+func (l *ledgerServiceImpl) SetMemberRole(
+	ctx context.Context,
+	userID uuid.UUID,
+	role string,
+) (*domain.Member, error) {
+
+	m, err := l.member(ctx, domain.LedgerOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != domain.LedgerEditor && role != domain.LedgerViewer {
+		return nil, &domain.ValidationError{
+			Field:   "role",
+			Message: "can be either editor or viewer",
+		}
+	}
+	if userID == m.UserID {
+		return nil, domain.ErrLedgerOwner
+	}
+
+	updated, err := l.ledgers.SetMemberRole(ctx, m.LedgerID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, domain.ErrMemberNotFound
+	}
+
+	return l.ledgers.Member(ctx, m.LedgerID, userID)
+}
+
+// RemoveMember lets the owner remove a member, and members leave. The
+// owner cannot leave the ledger.
+func (l *ledgerServiceImpl) RemoveMember(
+	ctx context.Context,
+	userID uuid.UUID,
+) error {
+
+	role := domain.LedgerOwner
+	if caller, err := UserIDFromContext(ctx); err == nil && caller == userID {
+		role = domain.LedgerViewer
+	}
+
+	m, err := l.member(ctx, role)
+	if err != nil {
+		return err
+	}
+	if m.Role == domain.LedgerOwner && userID == m.UserID {
+		return domain.ErrLedgerOwner
+	}
+
+	removed, err := l.ledgers.RemoveMember(ctx, m.LedgerID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return domain.ErrMemberNotFound
+	}
+
+	return nil
+}
+
+// GetMemberSpending reports what each member recorded in the ledger
+// between from and to, biggest spender first.
+func (l *ledgerServiceImpl) GetMemberSpending(
+	ctx context.Context,
+	from time.Time,
+	to time.Time,
+) ([]domain.MemberSpending, error) {
+
+	m, err := l.member(ctx, domain.LedgerViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.reports.MemberSpending(ctx, m.LedgerID, from, to)
+}
+
+func newInvitationToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
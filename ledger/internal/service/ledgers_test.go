@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+type mockLedgerRepo struct {
+	mu          sync.Mutex
+	ledgers     map[uuid.UUID]domain.Ledger
+	members     map[uuid.UUID]map[uuid.UUID]string
+	invitations map[string]domain.Invitation
+}
+
+func (m *mockLedgerRepo) init() {
+	if m.ledgers == nil {
+		m.ledgers = make(map[uuid.UUID]domain.Ledger)
+		m.members = make(map[uuid.UUID]map[uuid.UUID]string)
+		m.invitations = make(map[string]domain.Invitation)
+	}
+}
+
+func (m *mockLedgerRepo) Create(ctx context.Context, l domain.Ledger) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	if _, ok := m.ledgers[l.ID]; !ok {
+		m.ledgers[l.ID] = l
+		m.members[l.ID] = map[uuid.UUID]string{l.OwnerID: domain.LedgerOwner}
+	}
+	return nil
+}
+
+func (m *mockLedgerRepo) Get(ctx context.Context, ledgerID uuid.UUID) (*domain.Ledger, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	l, ok := m.ledgers[ledgerID]
+	if !ok {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+func (m *mockLedgerRepo) ListForUser(ctx context.Context, userID uuid.UUID) ([]domain.Ledger, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	var res []domain.Ledger
+	for id, members := range m.members {
+		if role, ok := members[userID]; ok {
+			l := m.ledgers[id]
+			l.Role = role
+			res = append(res, l)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockLedgerRepo) Member(ctx context.Context, ledgerID uuid.UUID, userID uuid.UUID) (*domain.Member, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	role, ok := m.members[ledgerID][userID]
+	if !ok {
+		return nil, nil
+	}
+	return &domain.Member{LedgerID: ledgerID, UserID: userID, Role: role}, nil
+}
+
+func (m *mockLedgerRepo) Members(ctx context.Context, ledgerID uuid.UUID) ([]domain.Member, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	var res []domain.Member
+	for userID, role := range m.members[ledgerID] {
+		res = append(res, domain.Member{LedgerID: ledgerID, UserID: userID, Role: role})
+	}
+	return res, nil
+}
+
+func (m *mockLedgerRepo) SetMemberRole(ctx context.Context, ledgerID uuid.UUID, userID uuid.UUID, role string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	current, ok := m.members[ledgerID][userID]
+	if !ok || current == domain.LedgerOwner {
+		return false, nil
+	}
+	m.members[ledgerID][userID] = role
+	return true, nil
+}
+
+func (m *mockLedgerRepo) RemoveMember(ctx context.Context, ledgerID uuid.UUID, userID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	current, ok := m.members[ledgerID][userID]
+	if !ok || current == domain.LedgerOwner {
+		return false, nil
+	}
+	delete(m.members[ledgerID], userID)
+	return true, nil
+}
+
+func (m *mockLedgerRepo) CreateInvitation(ctx context.Context, tokenHash string, inv domain.Invitation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	m.invitations[tokenHash] = inv
+	return nil
+}
+
+func (m *mockLedgerRepo) AcceptInvitation(ctx context.Context, tokenHash string, userID uuid.UUID) (*domain.Invitation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	inv, ok := m.invitations[tokenHash]
+	delete(m.invitations, tokenHash)
+	if !ok || !inv.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	if _, ok := m.members[inv.LedgerID][userID]; !ok {
+		m.members[inv.LedgerID][userID] = inv.Role
+	}
+	return &inv, nil
+}
+
+func ctxWithLedger(userID, ledgerID uuid.UUID) context.Context {
+	md := metadata.New(map[string]string{
+		"user_id":   userID.String(),
+		"ledger_id": ledgerID.String(),
+	})
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+// sharedLedger returns a ledger owned by owner with an editor and a viewer.
+func sharedLedger(t *testing.T, svc LedgerService) (ledgerID, owner, editor, viewer uuid.UUID) {
+	t.Helper()
+
+	owner, editor, viewer = uuid.New(), uuid.New(), uuid.New()
+
+	l, err := svc.CreateLedger(ctxWithUser(owner), " Home ")
+	require.NoError(t, err)
+	require.Equal(t, "Home", l.Name)
+
+	for user, role := range map[uuid.UUID]string{editor: domain.LedgerEditor, viewer: domain.LedgerViewer} {
+		inv, err := svc.CreateInvitation(ctxWithLedger(owner, l.ID), role)
+		require.NoError(t, err)
+		require.NotEmpty(t, inv.Token)
+
+		joined, err := svc.AcceptInvitation(ctxWithUser(user), inv.Token)
+		require.NoError(t, err)
+		require.Equal(t, l.ID, joined.ID)
+		require.Equal(t, role, joined.Role)
+	}
+
+	return l.ID, owner, editor, viewer
+}
+
+func TestLedgers_Roles(t *testing.T) {
+	budgets := &mockBudgetRepo{budgets: map[string]domain.Budget{}}
+	svc := New(budgets, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	ledgerID, owner, editor, viewer := sharedLedger(t, svc)
+	budget := domain.Budget{Category: "food", Limit: decimal.NewFromInt(100)}
+
+	require.NoError(t, svc.SetBudget(ctxWithLedger(editor, ledgerID), budget))
+	require.ErrorIs(t, svc.SetBudget(ctxWithLedger(viewer, ledgerID), budget), domain.ErrLedgerForbidden)
+	require.ErrorIs(t, svc.SetBudget(ctxWithLedger(uuid.New(), ledgerID), budget), domain.ErrLedgerNotFound)
+
+	res, err := svc.ListBudgets(ctxWithLedger(viewer, ledgerID))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, ledgerID, res[0].LedgerID)
+
+	_, err = svc.CreateInvitation(ctxWithLedger(editor, ledgerID), domain.LedgerViewer)
+	require.ErrorIs(t, err, domain.ErrLedgerForbidden)
+
+	m, err := svc.SetMemberRole(ctxWithLedger(owner, ledgerID), viewer, domain.LedgerEditor)
+	require.NoError(t, err)
+	require.Equal(t, domain.LedgerEditor, m.Role)
+
+	_, err = svc.SetMemberRole(ctxWithLedger(owner, ledgerID), owner, domain.LedgerViewer)
+	require.ErrorIs(t, err, domain.ErrLedgerOwner)
+}
+
+func TestLedgers_AddTransactionRecordsMember(t *testing.T) {
+	budgets := &mockBudgetRepo{budgets: map[string]domain.Budget{}}
+	expenses := &mockExpenseRepo{}
+	svc := New(budgets, expenses, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	ledgerID, owner, editor, _ := sharedLedger(t, svc)
+
+	require.NoError(t, svc.SetBudget(ctxWithLedger(owner, ledgerID), domain.Budget{Category: "food", Limit: decimal.NewFromInt(100)}))
+	require.NoError(t, svc.AddTransaction(ctxWithLedger(editor, ledgerID), domain.Transaction{
+		Amount:   decimal.NewFromInt(10),
+		Category: "food",
+		Date:     time.Now(),
+	}))
+
+	require.Len(t, expenses.items, 1)
+	require.Equal(t, ledgerID, expenses.items[0].LedgerID)
+	require.Equal(t, editor, expenses.items[0].UserID)
+}
+
+func TestLedgers_RemoveMember(t *testing.T) {
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	ledgerID, owner, editor, viewer := sharedLedger(t, svc)
+
+	// members may leave, but not remove others
+	require.ErrorIs(t, svc.RemoveMember(ctxWithLedger(editor, ledgerID), viewer), domain.ErrLedgerForbidden)
+	require.NoError(t, svc.RemoveMember(ctxWithLedger(viewer, ledgerID), viewer))
+
+	require.ErrorIs(t, svc.RemoveMember(ctxWithLedger(owner, ledgerID), owner), domain.ErrLedgerOwner)
+	require.NoError(t, svc.RemoveMember(ctxWithLedger(owner, ledgerID), editor))
+	require.ErrorIs(t, svc.RemoveMember(ctxWithLedger(owner, ledgerID), editor), domain.ErrMemberNotFound)
+
+	members, err := svc.ListMembers(ctxWithLedger(owner, ledgerID))
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+}
+
+func TestAcceptInvitation_Invalid(t *testing.T) {
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+
+	_, err := svc.AcceptInvitation(ctxWithUser(uuid.New()), "nope")
+	require.ErrorIs(t, err, domain.ErrInvitationNotFound)
+}
+
+func TestListLedgers_CreatesPersonal(t *testing.T) {
+	svc := New(&mockBudgetRepo{}, &mockExpenseRepo{}, &mockReportRepo{}, &mockImportProfileRepo{}, &mockImportJobRepo{}, &mockAccountRepo{}, &mockLedgerRepo{})
+	userID := uuid.New()
+
+	ledgers, err := svc.ListLedgers(ctxWithUser(userID))
+	require.NoError(t, err)
+	require.Len(t, ledgers, 1)
+	require.Equal(t, userID, ledgers[0].ID)
+	require.Equal(t, domain.PersonalLedgerName, ledgers[0].Name)
+	require.Equal(t, domain.LedgerOwner, ledgers[0].Role)
+}
@@ -22,22 +22,68 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	LedgerService_AddTransaction_FullMethodName      = "/ledger.v1.LedgerService/AddTransaction"
 	LedgerService_ListTransactions_FullMethodName    = "/ledger.v1.LedgerService/ListTransactions"
+	LedgerService_ExportTransactions_FullMethodName  = "/ledger.v1.LedgerService/ExportTransactions"
 	LedgerService_SetBudget_FullMethodName           = "/ledger.v1.LedgerService/SetBudget"
 	LedgerService_ListBudgets_FullMethodName         = "/ledger.v1.LedgerService/ListBudgets"
 	LedgerService_GetReportSummary_FullMethodName    = "/ledger.v1.LedgerService/GetReportSummary"
 	LedgerService_BulkAddTransactions_FullMethodName = "/ledger.v1.LedgerService/BulkAddTransactions"
+	LedgerService_SaveImportProfile_FullMethodName   = "/ledger.v1.LedgerService/SaveImportProfile"
+	LedgerService_GetImportProfile_FullMethodName    = "/ledger.v1.LedgerService/GetImportProfile"
+	LedgerService_ListImportProfiles_FullMethodName  = "/ledger.v1.LedgerService/ListImportProfiles"
+	LedgerService_DeleteImportProfile_FullMethodName = "/ledger.v1.LedgerService/DeleteImportProfile"
+	LedgerService_FindDuplicates_FullMethodName      = "/ledger.v1.LedgerService/FindDuplicates"
+	LedgerService_ImportTransactions_FullMethodName  = "/ledger.v1.LedgerService/ImportTransactions"
+	LedgerService_CreateImportJob_FullMethodName     = "/ledger.v1.LedgerService/CreateImportJob"
+	LedgerService_GetImportJob_FullMethodName        = "/ledger.v1.LedgerService/GetImportJob"
+	LedgerService_CancelImportJob_FullMethodName     = "/ledger.v1.LedgerService/CancelImportJob"
+	LedgerService_ExportBackup_FullMethodName        = "/ledger.v1.LedgerService/ExportBackup"
+	LedgerService_RestoreBackup_FullMethodName       = "/ledger.v1.LedgerService/RestoreBackup"
+	LedgerService_CreateLedger_FullMethodName        = "/ledger.v1.LedgerService/CreateLedger"
+	LedgerService_ListLedgers_FullMethodName         = "/ledger.v1.LedgerService/ListLedgers"
+	LedgerService_CreateInvitation_FullMethodName    = "/ledger.v1.LedgerService/CreateInvitation"
+	LedgerService_AcceptInvitation_FullMethodName    = "/ledger.v1.LedgerService/AcceptInvitation"
+	LedgerService_ListMembers_FullMethodName         = "/ledger.v1.LedgerService/ListMembers"
+	LedgerService_SetMemberRole_FullMethodName       = "/ledger.v1.LedgerService/SetMemberRole"
+	LedgerService_RemoveMember_FullMethodName        = "/ledger.v1.LedgerService/RemoveMember"
+	LedgerService_GetMemberSpending_FullMethodName   = "/ledger.v1.LedgerService/GetMemberSpending"
 )
 
 // LedgerServiceClient is the client API for LedgerService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Every call is about one ledger, named by the "ledger_id" metadata next to
+// "user_id". Calls without it use the caller's personal ledger, whose id is
+// the user's id. The caller must be a member of the ledger: viewers read,
+// editors also write, and owners also manage members and may replace the
+// ledger from a backup.
 type LedgerServiceClient interface {
 	AddTransaction(ctx context.Context, in *CreateTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
 	ListTransactions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	ExportTransactions(ctx context.Context, in *ExportTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Transaction], error)
 	SetBudget(ctx context.Context, in *CreateBudgetRequest, opts ...grpc.CallOption) (*Budget, error)
 	ListBudgets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListBudgetsResponse, error)
 	GetReportSummary(ctx context.Context, in *ReportSummaryRequest, opts ...grpc.CallOption) (*ReportSummaryResponse, error)
 	BulkAddTransactions(ctx context.Context, in *BulkAddTransactionsRequest, opts ...grpc.CallOption) (*BulkAddTransactionsResponse, error)
+	SaveImportProfile(ctx context.Context, in *ImportProfile, opts ...grpc.CallOption) (*ImportProfile, error)
+	GetImportProfile(ctx context.Context, in *ImportProfileRequest, opts ...grpc.CallOption) (*ImportProfile, error)
+	ListImportProfiles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListImportProfilesResponse, error)
+	DeleteImportProfile(ctx context.Context, in *ImportProfileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	FindDuplicates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FindDuplicatesResponse, error)
+	ImportTransactions(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ImportTransactionsRequest, ImportProgress], error)
+	CreateImportJob(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportJobChunk, ImportJob], error)
+	GetImportJob(ctx context.Context, in *ImportJobRequest, opts ...grpc.CallOption) (*ImportJob, error)
+	CancelImportJob(ctx context.Context, in *ImportJobRequest, opts ...grpc.CallOption) (*ImportJob, error)
+	ExportBackup(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupChunk], error)
+	RestoreBackup(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreBackupRequest, RestoreBackupResponse], error)
+	CreateLedger(ctx context.Context, in *CreateLedgerRequest, opts ...grpc.CallOption) (*Ledger, error)
+	ListLedgers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListLedgersResponse, error)
+	CreateInvitation(ctx context.Context, in *CreateInvitationRequest, opts ...grpc.CallOption) (*Invitation, error)
+	AcceptInvitation(ctx context.Context, in *AcceptInvitationRequest, opts ...grpc.CallOption) (*Ledger, error)
+	ListMembers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListMembersResponse, error)
+	SetMemberRole(ctx context.Context, in *SetMemberRoleRequest, opts ...grpc.CallOption) (*Member, error)
+	RemoveMember(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetMemberSpending(ctx context.Context, in *ReportSummaryRequest, opts ...grpc.CallOption) (*MemberSpendingResponse, error)
 }
 
 type ledgerServiceClient struct {
@@ -68,6 +114,25 @@ func (c *ledgerServiceClient) ListTransactions(ctx context.Context, in *emptypb.
 	return out, nil
 }
 
+func (c *ledgerServiceClient) ExportTransactions(ctx context.Context, in *ExportTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Transaction], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LedgerService_ServiceDesc.Streams[0], LedgerService_ExportTransactions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportTransactionsRequest, Transaction]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_ExportTransactionsClient = grpc.ServerStreamingClient[Transaction]
+
 func (c *ledgerServiceClient) SetBudget(ctx context.Context, in *CreateBudgetRequest, opts ...grpc.CallOption) (*Budget, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Budget)
@@ -108,16 +173,250 @@ func (c *ledgerServiceClient) BulkAddTransactions(ctx context.Context, in *BulkA
 	return out, nil
 }
 
+func (c *ledgerServiceClient) SaveImportProfile(ctx context.Context, in *ImportProfile, opts ...grpc.CallOption) (*ImportProfile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportProfile)
+	err := c.cc.Invoke(ctx, LedgerService_SaveImportProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) GetImportProfile(ctx context.Context, in *ImportProfileRequest, opts ...grpc.CallOption) (*ImportProfile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportProfile)
+	err := c.cc.Invoke(ctx, LedgerService_GetImportProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ListImportProfiles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListImportProfilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListImportProfilesResponse)
+	err := c.cc.Invoke(ctx, LedgerService_ListImportProfiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) DeleteImportProfile(ctx context.Context, in *ImportProfileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, LedgerService_DeleteImportProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) FindDuplicates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FindDuplicatesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindDuplicatesResponse)
+	err := c.cc.Invoke(ctx, LedgerService_FindDuplicates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ImportTransactions(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ImportTransactionsRequest, ImportProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LedgerService_ServiceDesc.Streams[1], LedgerService_ImportTransactions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportTransactionsRequest, ImportProgress]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_ImportTransactionsClient = grpc.BidiStreamingClient[ImportTransactionsRequest, ImportProgress]
+
+func (c *ledgerServiceClient) CreateImportJob(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportJobChunk, ImportJob], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LedgerService_ServiceDesc.Streams[2], LedgerService_CreateImportJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportJobChunk, ImportJob]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_CreateImportJobClient = grpc.ClientStreamingClient[ImportJobChunk, ImportJob]
+
+func (c *ledgerServiceClient) GetImportJob(ctx context.Context, in *ImportJobRequest, opts ...grpc.CallOption) (*ImportJob, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportJob)
+	err := c.cc.Invoke(ctx, LedgerService_GetImportJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) CancelImportJob(ctx context.Context, in *ImportJobRequest, opts ...grpc.CallOption) (*ImportJob, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportJob)
+	err := c.cc.Invoke(ctx, LedgerService_CancelImportJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ExportBackup(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LedgerService_ServiceDesc.Streams[3], LedgerService_ExportBackup_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, BackupChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_ExportBackupClient = grpc.ServerStreamingClient[BackupChunk]
+
+func (c *ledgerServiceClient) RestoreBackup(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreBackupRequest, RestoreBackupResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LedgerService_ServiceDesc.Streams[4], LedgerService_RestoreBackup_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RestoreBackupRequest, RestoreBackupResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_RestoreBackupClient = grpc.ClientStreamingClient[RestoreBackupRequest, RestoreBackupResponse]
+
+func (c *ledgerServiceClient) CreateLedger(ctx context.Context, in *CreateLedgerRequest, opts ...grpc.CallOption) (*Ledger, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Ledger)
+	err := c.cc.Invoke(ctx, LedgerService_CreateLedger_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ListLedgers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListLedgersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLedgersResponse)
+	err := c.cc.Invoke(ctx, LedgerService_ListLedgers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) CreateInvitation(ctx context.Context, in *CreateInvitationRequest, opts ...grpc.CallOption) (*Invitation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Invitation)
+	err := c.cc.Invoke(ctx, LedgerService_CreateInvitation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) AcceptInvitation(ctx context.Context, in *AcceptInvitationRequest, opts ...grpc.CallOption) (*Ledger, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Ledger)
+	err := c.cc.Invoke(ctx, LedgerService_AcceptInvitation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) ListMembers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMembersResponse)
+	err := c.cc.Invoke(ctx, LedgerService_ListMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) SetMemberRole(ctx context.Context, in *SetMemberRoleRequest, opts ...grpc.CallOption) (*Member, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Member)
+	err := c.cc.Invoke(ctx, LedgerService_SetMemberRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) RemoveMember(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, LedgerService_RemoveMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ledgerServiceClient) GetMemberSpending(ctx context.Context, in *ReportSummaryRequest, opts ...grpc.CallOption) (*MemberSpendingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MemberSpendingResponse)
+	err := c.cc.Invoke(ctx, LedgerService_GetMemberSpending_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LedgerServiceServer is the server API for LedgerService service.
 // All implementations must embed UnimplementedLedgerServiceServer
 // for forward compatibility.
+//
+// Every call is about one ledger, named by the "ledger_id" metadata next to
+// "user_id". Calls without it use the caller's personal ledger, whose id is
+// the user's id. The caller must be a member of the ledger: viewers read,
+// editors also write, and owners also manage members and may replace the
+// ledger from a backup.
 type LedgerServiceServer interface {
 	AddTransaction(context.Context, *CreateTransactionRequest) (*Transaction, error)
 	ListTransactions(context.Context, *emptypb.Empty) (*ListTransactionsResponse, error)
+	ExportTransactions(*ExportTransactionsRequest, grpc.ServerStreamingServer[Transaction]) error
 	SetBudget(context.Context, *CreateBudgetRequest) (*Budget, error)
 	ListBudgets(context.Context, *emptypb.Empty) (*ListBudgetsResponse, error)
 	GetReportSummary(context.Context, *ReportSummaryRequest) (*ReportSummaryResponse, error)
 	BulkAddTransactions(context.Context, *BulkAddTransactionsRequest) (*BulkAddTransactionsResponse, error)
+	SaveImportProfile(context.Context, *ImportProfile) (*ImportProfile, error)
+	GetImportProfile(context.Context, *ImportProfileRequest) (*ImportProfile, error)
+	ListImportProfiles(context.Context, *emptypb.Empty) (*ListImportProfilesResponse, error)
+	DeleteImportProfile(context.Context, *ImportProfileRequest) (*emptypb.Empty, error)
+	FindDuplicates(context.Context, *emptypb.Empty) (*FindDuplicatesResponse, error)
+	ImportTransactions(grpc.BidiStreamingServer[ImportTransactionsRequest, ImportProgress]) error
+	CreateImportJob(grpc.ClientStreamingServer[ImportJobChunk, ImportJob]) error
+	GetImportJob(context.Context, *ImportJobRequest) (*ImportJob, error)
+	CancelImportJob(context.Context, *ImportJobRequest) (*ImportJob, error)
+	ExportBackup(*emptypb.Empty, grpc.ServerStreamingServer[BackupChunk]) error
+	RestoreBackup(grpc.ClientStreamingServer[RestoreBackupRequest, RestoreBackupResponse]) error
+	CreateLedger(context.Context, *CreateLedgerRequest) (*Ledger, error)
+	ListLedgers(context.Context, *emptypb.Empty) (*ListLedgersResponse, error)
+	CreateInvitation(context.Context, *CreateInvitationRequest) (*Invitation, error)
+	AcceptInvitation(context.Context, *AcceptInvitationRequest) (*Ledger, error)
+	ListMembers(context.Context, *emptypb.Empty) (*ListMembersResponse, error)
+	SetMemberRole(context.Context, *SetMemberRoleRequest) (*Member, error)
+	RemoveMember(context.Context, *MemberRequest) (*emptypb.Empty, error)
+	GetMemberSpending(context.Context, *ReportSummaryRequest) (*MemberSpendingResponse, error)
 	mustEmbedUnimplementedLedgerServiceServer()
 }
 
@@ -134,6 +433,9 @@ func (UnimplementedLedgerServiceServer) AddTransaction(context.Context, *CreateT
 func (UnimplementedLedgerServiceServer) ListTransactions(context.Context, *emptypb.Empty) (*ListTransactionsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListTransactions not implemented")
 }
+func (UnimplementedLedgerServiceServer) ExportTransactions(*ExportTransactionsRequest, grpc.ServerStreamingServer[Transaction]) error {
+	return status.Error(codes.Unimplemented, "method ExportTransactions not implemented")
+}
 func (UnimplementedLedgerServiceServer) SetBudget(context.Context, *CreateBudgetRequest) (*Budget, error) {
 	return nil, status.Error(codes.Unimplemented, "method SetBudget not implemented")
 }
@@ -146,6 +448,63 @@ func (UnimplementedLedgerServiceServer) GetReportSummary(context.Context, *Repor
 func (UnimplementedLedgerServiceServer) BulkAddTransactions(context.Context, *BulkAddTransactionsRequest) (*BulkAddTransactionsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method BulkAddTransactions not implemented")
 }
+func (UnimplementedLedgerServiceServer) SaveImportProfile(context.Context, *ImportProfile) (*ImportProfile, error) {
+	return nil, status.Error(codes.Unimplemented, "method SaveImportProfile not implemented")
+}
+func (UnimplementedLedgerServiceServer) GetImportProfile(context.Context, *ImportProfileRequest) (*ImportProfile, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetImportProfile not implemented")
+}
+func (UnimplementedLedgerServiceServer) ListImportProfiles(context.Context, *emptypb.Empty) (*ListImportProfilesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListImportProfiles not implemented")
+}
+func (UnimplementedLedgerServiceServer) DeleteImportProfile(context.Context, *ImportProfileRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteImportProfile not implemented")
+}
+func (UnimplementedLedgerServiceServer) FindDuplicates(context.Context, *emptypb.Empty) (*FindDuplicatesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method FindDuplicates not implemented")
+}
+func (UnimplementedLedgerServiceServer) ImportTransactions(grpc.BidiStreamingServer[ImportTransactionsRequest, ImportProgress]) error {
+	return status.Error(codes.Unimplemented, "method ImportTransactions not implemented")
+}
+func (UnimplementedLedgerServiceServer) CreateImportJob(grpc.ClientStreamingServer[ImportJobChunk, ImportJob]) error {
+	return status.Error(codes.Unimplemented, "method CreateImportJob not implemented")
+}
+func (UnimplementedLedgerServiceServer) GetImportJob(context.Context, *ImportJobRequest) (*ImportJob, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetImportJob not implemented")
+}
+func (UnimplementedLedgerServiceServer) CancelImportJob(context.Context, *ImportJobRequest) (*ImportJob, error) {
+	return nil, status.Error(codes.Unimplemented, "method CancelImportJob not implemented")
+}
+func (UnimplementedLedgerServiceServer) ExportBackup(*emptypb.Empty, grpc.ServerStreamingServer[BackupChunk]) error {
+	return status.Error(codes.Unimplemented, "method ExportBackup not implemented")
+}
+func (UnimplementedLedgerServiceServer) RestoreBackup(grpc.ClientStreamingServer[RestoreBackupRequest, RestoreBackupResponse]) error {
+	return status.Error(codes.Unimplemented, "method RestoreBackup not implemented")
+}
+func (UnimplementedLedgerServiceServer) CreateLedger(context.Context, *CreateLedgerRequest) (*Ledger, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateLedger not implemented")
+}
+func (UnimplementedLedgerServiceServer) ListLedgers(context.Context, *emptypb.Empty) (*ListLedgersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListLedgers not implemented")
+}
+func (UnimplementedLedgerServiceServer) CreateInvitation(context.Context, *CreateInvitationRequest) (*Invitation, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateInvitation not implemented")
+}
+func (UnimplementedLedgerServiceServer) AcceptInvitation(context.Context, *AcceptInvitationRequest) (*Ledger, error) {
+	return nil, status.Error(codes.Unimplemented, "method AcceptInvitation not implemented")
+}
+func (UnimplementedLedgerServiceServer) ListMembers(context.Context, *emptypb.Empty) (*ListMembersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListMembers not implemented")
+}
+func (UnimplementedLedgerServiceServer) SetMemberRole(context.Context, *SetMemberRoleRequest) (*Member, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetMemberRole not implemented")
+}
+func (UnimplementedLedgerServiceServer) RemoveMember(context.Context, *MemberRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveMember not implemented")
+}
+func (UnimplementedLedgerServiceServer) GetMemberSpending(context.Context, *ReportSummaryRequest) (*MemberSpendingResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetMemberSpending not implemented")
+}
 func (UnimplementedLedgerServiceServer) mustEmbedUnimplementedLedgerServiceServer() {}
 func (UnimplementedLedgerServiceServer) testEmbeddedByValue()                       {}
 
@@ -203,6 +562,17 @@ func _LedgerService_ListTransactions_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LedgerService_ExportTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LedgerServiceServer).ExportTransactions(m, &grpc.GenericServerStream[ExportTransactionsRequest, Transaction]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_ExportTransactionsServer = grpc.ServerStreamingServer[Transaction]
+
 func _LedgerService_SetBudget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateBudgetRequest)
 	if err := dec(in); err != nil {
@@ -275,6 +645,308 @@ func _LedgerService_BulkAddTransactions_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LedgerService_SaveImportProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportProfile)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).SaveImportProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_SaveImportProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).SaveImportProfile(ctx, req.(*ImportProfile))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_GetImportProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).GetImportProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_GetImportProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).GetImportProfile(ctx, req.(*ImportProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ListImportProfiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ListImportProfiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_ListImportProfiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ListImportProfiles(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_DeleteImportProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).DeleteImportProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_DeleteImportProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).DeleteImportProfile(ctx, req.(*ImportProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_FindDuplicates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).FindDuplicates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_FindDuplicates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).FindDuplicates(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ImportTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LedgerServiceServer).ImportTransactions(&grpc.GenericServerStream[ImportTransactionsRequest, ImportProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_ImportTransactionsServer = grpc.BidiStreamingServer[ImportTransactionsRequest, ImportProgress]
+
+func _LedgerService_CreateImportJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LedgerServiceServer).CreateImportJob(&grpc.GenericServerStream[ImportJobChunk, ImportJob]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_CreateImportJobServer = grpc.ClientStreamingServer[ImportJobChunk, ImportJob]
+
+func _LedgerService_GetImportJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).GetImportJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_GetImportJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).GetImportJob(ctx, req.(*ImportJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_CancelImportJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).CancelImportJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_CancelImportJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).CancelImportJob(ctx, req.(*ImportJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ExportBackup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LedgerServiceServer).ExportBackup(m, &grpc.GenericServerStream[emptypb.Empty, BackupChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_ExportBackupServer = grpc.ServerStreamingServer[BackupChunk]
+
+func _LedgerService_RestoreBackup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LedgerServiceServer).RestoreBackup(&grpc.GenericServerStream[RestoreBackupRequest, RestoreBackupResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LedgerService_RestoreBackupServer = grpc.ClientStreamingServer[RestoreBackupRequest, RestoreBackupResponse]
+
+func _LedgerService_CreateLedger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateLedgerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).CreateLedger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_CreateLedger_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).CreateLedger(ctx, req.(*CreateLedgerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ListLedgers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ListLedgers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_ListLedgers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ListLedgers(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_CreateInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).CreateInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_CreateInvitation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).CreateInvitation(ctx, req.(*CreateInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_AcceptInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).AcceptInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_AcceptInvitation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).AcceptInvitation(ctx, req.(*AcceptInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_ListMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).ListMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_ListMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).ListMembers(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_SetMemberRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMemberRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).SetMemberRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_SetMemberRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).SetMemberRole(ctx, req.(*SetMemberRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_RemoveMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).RemoveMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_RemoveMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).RemoveMember(ctx, req.(*MemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LedgerService_GetMemberSpending_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LedgerServiceServer).GetMemberSpending(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LedgerService_GetMemberSpending_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LedgerServiceServer).GetMemberSpending(ctx, req.(*ReportSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LedgerService_ServiceDesc is the grpc.ServiceDesc for LedgerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -306,7 +978,94 @@ var LedgerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BulkAddTransactions",
 			Handler:    _LedgerService_BulkAddTransactions_Handler,
 		},
+		{
+			MethodName: "SaveImportProfile",
+			Handler:    _LedgerService_SaveImportProfile_Handler,
+		},
+		{
+			MethodName: "GetImportProfile",
+			Handler:    _LedgerService_GetImportProfile_Handler,
+		},
+		{
+			MethodName: "ListImportProfiles",
+			Handler:    _LedgerService_ListImportProfiles_Handler,
+		},
+		{
+			MethodName: "DeleteImportProfile",
+			Handler:    _LedgerService_DeleteImportProfile_Handler,
+		},
+		{
+			MethodName: "FindDuplicates",
+			Handler:    _LedgerService_FindDuplicates_Handler,
+		},
+		{
+			MethodName: "GetImportJob",
+			Handler:    _LedgerService_GetImportJob_Handler,
+		},
+		{
+			MethodName: "CancelImportJob",
+			Handler:    _LedgerService_CancelImportJob_Handler,
+		},
+		{
+			MethodName: "CreateLedger",
+			Handler:    _LedgerService_CreateLedger_Handler,
+		},
+		{
+			MethodName: "ListLedgers",
+			Handler:    _LedgerService_ListLedgers_Handler,
+		},
+		{
+			MethodName: "CreateInvitation",
+			Handler:    _LedgerService_CreateInvitation_Handler,
+		},
+		{
+			MethodName: "AcceptInvitation",
+			Handler:    _LedgerService_AcceptInvitation_Handler,
+		},
+		{
+			MethodName: "ListMembers",
+			Handler:    _LedgerService_ListMembers_Handler,
+		},
+		{
+			MethodName: "SetMemberRole",
+			Handler:    _LedgerService_SetMemberRole_Handler,
+		},
+		{
+			MethodName: "RemoveMember",
+			Handler:    _LedgerService_RemoveMember_Handler,
+		},
+		{
+			MethodName: "GetMemberSpending",
+			Handler:    _LedgerService_GetMemberSpending_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportTransactions",
+			Handler:       _LedgerService_ExportTransactions_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportTransactions",
+			Handler:       _LedgerService_ImportTransactions_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "CreateImportJob",
+			Handler:       _LedgerService_CreateImportJob_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportBackup",
+			Handler:       _LedgerService_ExportBackup_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RestoreBackup",
+			Handler:       _LedgerService_RestoreBackup_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "ledger/v1/ledger.proto",
 }
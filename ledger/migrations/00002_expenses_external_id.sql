@@ -0,0 +1,7 @@
+-- +goose Up
+
+ALTER TABLE expenses ADD COLUMN external_id TEXT;
+
+-- +goose Down
+
+ALTER TABLE expenses DROP COLUMN IF EXISTS external_id;
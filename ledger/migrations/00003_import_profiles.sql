@@ -0,0 +1,23 @@
+-- +goose Up
+
+CREATE TABLE import_profiles (
+                                 id                 SERIAL PRIMARY KEY,
+                                 user_id            UUID NOT NULL,
+                                 name               TEXT NOT NULL,
+                                 amount_column      TEXT NOT NULL,
+                                 date_column        TEXT NOT NULL,
+                                 category_column    TEXT NOT NULL DEFAULT '',
+                                 description_column TEXT NOT NULL DEFAULT '',
+                                 external_id_column TEXT NOT NULL DEFAULT '',
+                                 delimiter          TEXT NOT NULL DEFAULT ',',
+                                 decimal_comma      BOOLEAN NOT NULL DEFAULT FALSE,
+                                 date_format        TEXT NOT NULL DEFAULT 'YYYY-MM-DD',
+                                 encoding           TEXT NOT NULL DEFAULT 'utf-8',
+                                 sign_convention    TEXT NOT NULL DEFAULT 'positive',
+
+                                 UNIQUE (user_id, name)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS import_profiles;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+ALTER TABLE expenses ADD COLUMN source TEXT NOT NULL DEFAULT '';
+ALTER TABLE expenses ADD COLUMN fingerprint TEXT;
+
+-- NULL fingerprints (manual entries) never conflict
+ALTER TABLE expenses
+    ADD CONSTRAINT expenses_user_source_fingerprint_key
+        UNIQUE (user_id, source, fingerprint);
+
+-- +goose Down
+
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_user_source_fingerprint_key;
+ALTER TABLE expenses DROP COLUMN IF EXISTS fingerprint;
+ALTER TABLE expenses DROP COLUMN IF EXISTS source;
@@ -0,0 +1,51 @@
+-- +goose Up
+
+CREATE TABLE import_jobs (
+                             id         UUID PRIMARY KEY,
+                             user_id    UUID NOT NULL,
+                             status     TEXT NOT NULL,
+                             source     TEXT NOT NULL,
+                             file_name  TEXT NOT NULL DEFAULT '',
+                             total      BIGINT NOT NULL DEFAULT 0,
+                             processed  BIGINT NOT NULL DEFAULT 0,
+                             accepted   BIGINT NOT NULL DEFAULT 0,
+                             rejected   BIGINT NOT NULL DEFAULT 0,
+                             duplicates BIGINT NOT NULL DEFAULT 0,
+                             error      TEXT NOT NULL DEFAULT '',
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                             updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX import_jobs_pending_idx
+    ON import_jobs (created_at)
+    WHERE status IN ('queued', 'running');
+
+-- rows waiting to be stored; removed once processed
+CREATE TABLE import_job_rows (
+                                 job_id      UUID NOT NULL REFERENCES import_jobs (id) ON DELETE CASCADE,
+                                 idx         INT NOT NULL,
+                                 line        INT NOT NULL,
+                                 amount      DECIMAL(14,2) NOT NULL,
+                                 category    TEXT NOT NULL,
+                                 description TEXT NOT NULL,
+                                 date        DATE NOT NULL,
+                                 external_id TEXT NOT NULL,
+                                 fingerprint TEXT NOT NULL,
+
+                                 PRIMARY KEY (job_id, idx)
+);
+
+CREATE TABLE import_job_errors (
+                                   job_id UUID NOT NULL REFERENCES import_jobs (id) ON DELETE CASCADE,
+                                   idx    INT NOT NULL,
+                                   line   INT NOT NULL,
+                                   error  TEXT NOT NULL,
+
+                                   PRIMARY KEY (job_id, idx)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS import_job_errors;
+DROP TABLE IF EXISTS import_job_rows;
+DROP TABLE IF EXISTS import_jobs;
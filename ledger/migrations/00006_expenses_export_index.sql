@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- exports walk a user's expenses by (date, id)
+CREATE INDEX expenses_user_date_id_idx ON expenses (user_id, date, id);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS expenses_user_date_id_idx;
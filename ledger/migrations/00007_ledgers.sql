@@ -0,0 +1,115 @@
+-- +goose Up
+
+CREATE TABLE ledgers (
+                         id         UUID PRIMARY KEY,
+                         name       TEXT NOT NULL,
+                         owner_id   UUID NOT NULL,
+                         created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE ledger_members (
+                                ledger_id UUID NOT NULL REFERENCES ledgers (id) ON DELETE CASCADE,
+                                user_id   UUID NOT NULL,
+                                role      TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+                                joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+                                PRIMARY KEY (ledger_id, user_id)
+);
+
+CREATE INDEX ledger_members_user_idx ON ledger_members (user_id);
+
+-- single use; only the hash of the token is kept
+CREATE TABLE ledger_invitations (
+                                    token_hash TEXT PRIMARY KEY,
+                                    ledger_id  UUID NOT NULL REFERENCES ledgers (id) ON DELETE CASCADE,
+                                    role       TEXT NOT NULL CHECK (role IN ('editor', 'viewer')),
+                                    invited_by UUID NOT NULL,
+                                    expires_at TIMESTAMPTZ NOT NULL,
+                                    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- every user with data gets a personal ledger with the user's id, so the
+-- data moves over as it is
+INSERT INTO ledgers (id, name, owner_id)
+SELECT user_id, 'Personal', user_id
+FROM (
+    SELECT user_id FROM budgets
+    UNION SELECT user_id FROM expenses
+    UNION SELECT user_id FROM import_profiles
+    UNION SELECT user_id FROM import_jobs
+) AS users;
+
+INSERT INTO ledger_members (ledger_id, user_id, role)
+SELECT id, owner_id, 'owner'
+FROM ledgers;
+
+ALTER TABLE budgets RENAME COLUMN user_id TO ledger_id;
+ALTER TABLE budgets RENAME CONSTRAINT budgets_user_id_category_key TO budgets_ledger_id_category_key;
+ALTER TABLE budgets
+    ADD CONSTRAINT budgets_ledger_id_fkey
+        FOREIGN KEY (ledger_id) REFERENCES ledgers (id) ON DELETE CASCADE;
+
+ALTER TABLE import_profiles RENAME COLUMN user_id TO ledger_id;
+ALTER TABLE import_profiles RENAME CONSTRAINT import_profiles_user_id_name_key TO import_profiles_ledger_id_name_key;
+ALTER TABLE import_profiles
+    ADD CONSTRAINT import_profiles_ledger_id_fkey
+        FOREIGN KEY (ledger_id) REFERENCES ledgers (id) ON DELETE CASCADE;
+
+-- user_id stays as the member who recorded the transaction
+ALTER TABLE expenses ADD COLUMN ledger_id UUID REFERENCES ledgers (id) ON DELETE CASCADE;
+UPDATE expenses SET ledger_id = user_id;
+ALTER TABLE expenses ALTER COLUMN ledger_id SET NOT NULL;
+
+ALTER TABLE expenses DROP CONSTRAINT expenses_user_source_fingerprint_key;
+ALTER TABLE expenses
+    ADD CONSTRAINT expenses_ledger_source_fingerprint_key
+        UNIQUE (ledger_id, source, fingerprint);
+
+DROP INDEX expenses_user_date_id_idx;
+CREATE INDEX expenses_ledger_date_id_idx ON expenses (ledger_id, date, id);
+
+-- user_id stays as the member who uploaded the statement
+ALTER TABLE import_jobs ADD COLUMN ledger_id UUID REFERENCES ledgers (id) ON DELETE CASCADE;
+UPDATE import_jobs SET ledger_id = user_id;
+ALTER TABLE import_jobs ALTER COLUMN ledger_id SET NOT NULL;
+
+-- +goose Down
+
+-- a ledger that is not its owner's personal one cannot be given back to
+-- a single user without losing the other members' books, so the rollback
+-- refuses to run until those ledgers are gone
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM ledgers WHERE id <> owner_id) THEN
+        RAISE EXCEPTION 'cannot roll back ledgers: % shared ledgers exist',
+            (SELECT count(*) FROM ledgers WHERE id <> owner_id)
+            USING HINT = 'export and delete the shared ledgers first';
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE import_jobs DROP COLUMN IF EXISTS ledger_id;
+
+DROP INDEX IF EXISTS expenses_ledger_date_id_idx;
+CREATE INDEX expenses_user_date_id_idx ON expenses (user_id, date, id);
+
+ALTER TABLE expenses DROP CONSTRAINT IF EXISTS expenses_ledger_source_fingerprint_key;
+UPDATE expenses SET user_id = ledger_id;
+ALTER TABLE expenses
+    ADD CONSTRAINT expenses_user_source_fingerprint_key
+        UNIQUE (user_id, source, fingerprint);
+ALTER TABLE expenses DROP COLUMN IF EXISTS ledger_id;
+
+ALTER TABLE import_profiles DROP CONSTRAINT IF EXISTS import_profiles_ledger_id_fkey;
+ALTER TABLE import_profiles RENAME CONSTRAINT import_profiles_ledger_id_name_key TO import_profiles_user_id_name_key;
+ALTER TABLE import_profiles RENAME COLUMN ledger_id TO user_id;
+
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_ledger_id_fkey;
+ALTER TABLE budgets RENAME CONSTRAINT budgets_ledger_id_category_key TO budgets_user_id_category_key;
+ALTER TABLE budgets RENAME COLUMN ledger_id TO user_id;
+
+DROP TABLE IF EXISTS ledger_invitations;
+DROP TABLE IF EXISTS ledger_members;
+DROP TABLE IF EXISTS ledgers;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- FindDuplicateExpenses looks up each expense's twins by this key
+CREATE INDEX expenses_duplicate_key_idx
+    ON expenses (ledger_id, date, amount, lower(COALESCE(description, '')));
+
+-- +goose Down
+
+DROP INDEX IF EXISTS expenses_duplicate_key_idx;